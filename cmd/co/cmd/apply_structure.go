@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var (
+	applyStructureAll       bool
+	applyStructureOverwrite bool
+	applyStructureVars      []string
+)
+
+var applyStructureCmd = &cobra.Command{
+	Use:   "apply-structure <template> [workspace...]",
+	Short: "Apply a structure template to existing workspaces",
+	Long: `Creates the directories and files of a structure template in existing
+workspaces, to standardize folders like docs/, scripts/, and infra/.
+
+A structure template is a template without repos or partials:
+
+  {
+    "schema": 1,
+    "name": "standard-layout",
+    "description": "Standard docs, scripts, and infra folders",
+    "directories": ["docs", "scripts", "infra"]
+  }
+
+Its files/ directory is processed as usual, including .tmpl substitution.
+Files that already exist in a workspace are kept unless --overwrite is given,
+so applying a structure again is safe. Hooks are not run and project.json is
+not changed.
+
+Examples:
+  co apply-structure standard-layout acme--api acme--web
+  co apply-structure standard-layout --all --dry-run`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		templateName, slugs := args[0], args[1:]
+		tmpl, _, err := template.LoadTemplateMulti(cfg.AllTemplatesDirs(), templateName)
+		if err != nil {
+			return err
+		}
+		if !tmpl.IsStructure() {
+			return fmt.Errorf("template %s defines repos or partials; only structure templates can be applied to existing workspaces", templateName)
+		}
+
+		if applyStructureAll {
+			if len(slugs) > 0 {
+				return fmt.Errorf("--all cannot be combined with workspace arguments")
+			}
+			if slugs, err = workspace.ListWorkspaces(cfg); err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+		} else if len(slugs) == 0 {
+			return fmt.Errorf("specify workspaces or --all")
+		}
+		for _, slug := range slugs {
+			if !workspace.Exists(cfg, slug) {
+				return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+			}
+		}
+
+		opts := template.StructureOptions{
+			Variables: parseVarFlags(applyStructureVars),
+			Overwrite: applyStructureOverwrite,
+			DryRun:    dryRun,
+		}
+		results := []*template.StructureResult{}
+		failed := 0
+		for _, slug := range slugs {
+			result, err := template.ApplyStructure(cfg, cfg.WorkspacePath(slug), templateName, opts)
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", slug, err)
+				continue
+			}
+			results = append(results, result)
+			if !dryRun && !jsonOut {
+				fmt.Printf("✓ %s: %d dir(s), %d file(s) created, %d existing file(s) kept\n",
+					slug, len(result.DirsCreated), len(result.FilesWritten), len(result.FilesKept))
+			}
+		}
+
+		if dryRun {
+			return printPlan(structurePlan(templateName, results))
+		}
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+		fmt.Printf("\nApplied %s to %d workspace(s), %d failed.\n", templateName, len(results), failed)
+		return nil
+	},
+}
+
+// structurePlan turns dry-run structure results into a plan.
+func structurePlan(templateName string, results []*template.StructureResult) *model.Plan {
+	plan := model.NewPlan(fmt.Sprintf("Apply structure %s to %d workspace(s)", templateName, len(results)))
+	for _, r := range results {
+		for _, dir := range r.DirsCreated {
+			plan.Add(model.ActionCreate, r.WorkspaceSlug+"/"+dir, "", "")
+		}
+		for _, file := range r.FilesWritten {
+			plan.Add(model.ActionWrite, r.WorkspaceSlug+"/"+file, "", "")
+		}
+		for _, file := range r.FilesKept {
+			plan.Skip(model.ActionWrite, r.WorkspaceSlug+"/"+file, "", "already exists")
+		}
+	}
+	return plan
+}
+
+func init() {
+	takesSlugs(applyStructureCmd, "rest")
+	rootCmd.AddCommand(applyStructureCmd)
+	applyStructureCmd.Flags().BoolVar(&applyStructureAll, "all", false, "apply to every workspace")
+	applyStructureCmd.Flags().BoolVar(&applyStructureOverwrite, "overwrite", false, "replace files that already exist")
+	applyStructureCmd.Flags().StringArrayVarP(&applyStructureVars, "var", "v", nil, "set template variable (key=value)")
+}
@@ -9,8 +9,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/archive"
 	"github.com/tormodhaugland/co/internal/config"
-	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
 	"github.com/tormodhaugland/co/internal/tui"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var (
@@ -24,11 +25,17 @@ var archiveCmd = &cobra.Command{
 	Short: "Archive a workspace",
 	Long: `Creates a git bundle archive for each repo in the workspace.
 Archives are stored in _system/archive/YYYY/.
-Use --delete to remove the workspace after archiving.
+Use --delete to remove the workspace after archiving (asks first unless
+--yes is given or confirm.stash_delete is false). Bundles do not include
+uncommitted changes, so dirty repos need a second confirmation, or
+--discard-unsaved.
 Use --full to archive the entire workspace folder instead of just git bundles.
+Use --dry-run to list the planned actions without archiving.
 
-Supports fuzzy matching - if no exact match is found, you'll be prompted to confirm.`,
-	Args: cobra.ExactArgs(1),
+Supports fuzzy matching - if no exact match is found, you'll be prompted to
+confirm (skipped with --yes).`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := args[0]
 
@@ -38,8 +45,8 @@ Supports fuzzy matching - if no exact match is found, you'll be prompted to conf
 		}
 
 		slug := query
-		if !fs.WorkspaceExists(cfg.CodeRoot, query) {
-			workspaces, err := fs.ListWorkspaces(cfg.CodeRoot)
+		if !workspace.Exists(cfg, query) {
+			workspaces, err := workspace.ListWorkspaces(cfg)
 			if err != nil {
 				return fmt.Errorf("failed to list workspaces: %w", err)
 			}
@@ -55,11 +62,56 @@ Supports fuzzy matching - if no exact match is found, you'll be prompted to conf
 			}
 
 			slug = best.Str
-			result, err := tui.RunConfirm(fmt.Sprintf("Archive workspace '%s'?", slug))
+			if !dryRun && !assumeYes {
+				result, err := tui.RunConfirm(fmt.Sprintf("Archive workspace '%s'?", slug))
+				if err != nil {
+					return fmt.Errorf("prompt failed (use --yes to skip): %w", err)
+				}
+				if result.Aborted || !result.Confirmed {
+					return fmt.Errorf("aborted")
+				}
+			}
+		}
+
+		opts := archive.Options{
+			Reason:      archiveReason,
+			DeleteAfter: archiveDelete,
+			Full:        archiveFull,
+			DryRun:      dryRun,
+		}
+
+		if dryRun {
+			result, err := archive.ArchiveWorkspace(cfg, slug, opts)
 			if err != nil {
-				return fmt.Errorf("prompt failed: %w", err)
+				return err
 			}
-			if result.Aborted || !result.Confirmed {
+			return printPlan(result.Plan)
+		}
+
+		if archiveDelete {
+			ok, err := confirmOp(cfg, config.ConfirmStashDelete, fmt.Sprintf("Archive and DELETE workspace '%s'?", slug))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("aborted")
+			}
+
+			// Bundles keep every commit, so only uncommitted changes are lost
+			// unless the whole folder is archived.
+			var unsaved []git.UnsavedWork
+			if !archiveFull {
+				for _, work := range git.FindUnsavedWork(cfg.WorkspacePath(slug)) {
+					if work.Dirty {
+						unsaved = append(unsaved, git.UnsavedWork{Path: work.Path, Dirty: true})
+					}
+				}
+			}
+			ok, err = confirmUnsaved(unsaved)
+			if err != nil {
+				return err
+			}
+			if !ok {
 				return fmt.Errorf("aborted")
 			}
 		}
@@ -70,13 +122,9 @@ Supports fuzzy matching - if no exact match is found, you'll be prompted to conf
 			fmt.Printf("Archiving workspace: %s\n", slug)
 		}
 
-		opts := archive.Options{
-			Reason:      archiveReason,
-			DeleteAfter: archiveDelete,
-			Full:        archiveFull,
-		}
-
-		result, err := archive.ArchiveWorkspace(cfg, slug, opts)
+		ctx, stop := interruptContext()
+		defer stop()
+		result, err := archive.ArchiveWorkspaceContext(ctx, cfg, slug, opts)
 		if err != nil {
 			return err
 		}
@@ -93,17 +141,123 @@ Supports fuzzy matching - if no exact match is found, you'll be prompted to conf
 		} else {
 			fmt.Printf("Bundles: %d\n", result.BundleCount)
 		}
-		if result.Deleted {
+		if result.Quarantined != "" {
+			fmt.Printf("Workspace moved to quarantine (%s)\n", result.Quarantined)
+		} else if result.Deleted {
 			fmt.Println("Workspace deleted")
 		}
+		for _, path := range result.Pruned {
+			fmt.Printf("Pruned old archive: %s\n", path)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+
+		return nil
+	},
+}
+
+var archiveWorkspaceCmd = &cobra.Command{
+	Use:   "workspace <workspace-slug>",
+	Short: "Move a workspace to cold storage",
+	Long: `Archives the entire workspace folder, removes it from the code root, and
+keeps its metadata so it is still listed by 'co ls' and the dashboard with
+state "archived". Bring it back with 'co archive restore'.
+
+Unlike 'co stash', which archives any folder and forgets it, a workspace in
+cold storage stays known to co. Removing the workspace asks first unless
+--yes is given or confirm.stash_delete is false.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := args[0]
+
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !workspace.Exists(cfg, slug) {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+		}
+		opts := archive.ColdOptions{Reason: archiveReason, DryRun: dryRun}
 
+		if dryRun {
+			result, err := archive.ColdStore(cfg, slug, opts)
+			if err != nil {
+				return err
+			}
+			return printPlan(result.Plan)
+		}
+
+		ok, err := confirmOp(cfg, config.ConfirmStashDelete, fmt.Sprintf("Move workspace '%s' to cold storage?", slug))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+
+		fmt.Printf("Archiving workspace to cold storage: %s\n", slug)
+		result, err := archive.ColdStore(cfg, slug, opts)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+		fmt.Printf("Archive created: %s\n", result.ArchivePath)
+		if result.Quarantined != "" {
+			fmt.Printf("Workspace moved to quarantine (%s)\n", result.Quarantined)
+		}
+		fmt.Printf("Restore with: co archive restore %s\n", slug)
+		return nil
+	},
+}
+
+var archiveRestoreCmd = &cobra.Command{
+	Use:   "restore <workspace-slug>",
+	Short: "Restore a workspace from cold storage",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := args[0]
+
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		result, err := archive.RestoreCold(cfg, slug)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		fmt.Printf("Restored workspace: %s\n", result.RestoredPath)
+		fmt.Println("Run 'co index' to refresh the index.")
 		return nil
 	},
 }
 
 func init() {
+	takesSlugs(archiveCmd, "first")
+	takesSlugs(archiveWorkspaceCmd, "first")
+	takesSlugs(archiveRestoreCmd, "first")
 	archiveCmd.Flags().BoolVar(&archiveDelete, "delete", false, "delete workspace after archiving")
 	archiveCmd.Flags().StringVar(&archiveReason, "reason", "", "reason for archiving")
+	archiveCmd.Flags().BoolVar(&discardUnsaved, "discard-unsaved", false, discardUnsavedUsage)
 	archiveCmd.Flags().BoolVar(&archiveFull, "full", false, "archive entire workspace folder, not just git bundles")
+	archiveWorkspaceCmd.Flags().StringVar(&archiveReason, "reason", "", "reason for archiving")
+	archiveCmd.AddCommand(archiveWorkspaceCmd)
+	archiveCmd.AddCommand(archiveRestoreCmd)
 	rootCmd.AddCommand(archiveCmd)
 }
@@ -8,8 +8,9 @@ import (
 	"github.com/sahilm/fuzzy"
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
-	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
 	"github.com/tormodhaugland/co/internal/tui"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var cdRepoFlag bool
@@ -47,36 +48,11 @@ Then use:
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		// Resolve workspace path
-		var workspacePath string
-		var slug string
-
-		if fs.WorkspaceExists(cfg.CodeRoot, query) {
-			slug = query
-			workspacePath = cfg.WorkspacePath(query)
-		} else {
-			workspaces, err := fs.ListWorkspaces(cfg.CodeRoot)
-			if err != nil {
-				return fmt.Errorf("failed to list workspaces: %w", err)
-			}
-
-			matches := fuzzy.Find(query, workspaces)
-			if len(matches) == 0 {
-				return fmt.Errorf("no workspace found matching: %s", query)
-			}
-
-			best := matches[0]
-			if best.Score < -10 {
-				return fmt.Errorf("no workspace found matching: %s", query)
-			}
-
-			if len(matches) > 1 && matches[0].Score == matches[1].Score {
-				fmt.Fprintf(os.Stderr, "Ambiguous match, using: %s\n", best.Str)
-			}
-
-			slug = best.Str
-			workspacePath = cfg.WorkspacePath(slug)
+		slug, err := resolveWorkspace(cfg, query)
+		if err != nil {
+			return err
 		}
+		workspacePath := cfg.WorkspacePath(slug)
 
 		// Check if we're targeting a repo
 		repoName := ""
@@ -86,10 +62,16 @@ Then use:
 
 		// If --repo flag is set or repo name provided, handle repo selection
 		if cdRepoFlag || repoName != "" {
-			repos, err := fs.ListRepos(workspacePath)
+			located, err := model.LocateWorkspaceRepos(workspacePath)
 			if err != nil {
 				return fmt.Errorf("failed to list repos: %w", err)
 			}
+			repos := make([]string, len(located))
+			repoPaths := make(map[string]string, len(located))
+			for i, repo := range located {
+				repos[i] = repo.Name
+				repoPaths[repo.Name] = filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
+			}
 
 			if len(repos) == 0 {
 				return fmt.Errorf("no repositories found in workspace: %s", slug)
@@ -97,36 +79,24 @@ Then use:
 
 			// If repo name is provided, use fuzzy matching
 			if repoName != "" {
-				matches := fuzzy.Find(repoName, repos)
-				if len(matches) == 0 {
-					return fmt.Errorf("no repo found matching: %s", repoName)
-				}
-
-				best := matches[0]
-				if best.Score < -10 {
+				best, ok := fuzzyBest(repoName, repos)
+				if !ok {
 					return fmt.Errorf("no repo found matching: %s", repoName)
 				}
-
-				if len(matches) > 1 && matches[0].Score == matches[1].Score {
-					fmt.Fprintf(os.Stderr, "Ambiguous match, using: %s\n", best.Str)
-				}
-
-				repoPath := filepath.Join(workspacePath, "repos", best.Str)
-				fmt.Println(repoPath)
+				fmt.Println(repoPaths[best])
 				return nil
 			}
 
 			// Auto-select if only one repo
 			if len(repos) == 1 {
-				repoPath := filepath.Join(workspacePath, "repos", repos[0])
-				fmt.Println(repoPath)
+				fmt.Println(repoPaths[repos[0]])
 				return nil
 			}
 
 			// Interactive repo selection
-			result, err := tui.RunRepoSelect(repos, workspacePath)
+			result, err := tui.RunRepoSelect(located, workspacePath)
 			if err != nil {
-				return fmt.Errorf("repo selection failed: %w", err)
+				return promptError("repo selection failed", err, "name the repo: co cd <slug> <repo-name>")
 			}
 
 			if result.Abort {
@@ -142,7 +112,39 @@ Then use:
 	},
 }
 
+// resolveWorkspace returns the slug of the workspace named query, or of the
+// best fuzzy match of it among all workspaces.
+func resolveWorkspace(cfg *config.Config, query string) (string, error) {
+	if workspace.Exists(cfg, query) {
+		return query, nil
+	}
+	workspaces, err := workspace.ListWorkspaces(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	slug, ok := fuzzyBest(query, workspaces)
+	if !ok {
+		return "", fmt.Errorf("no workspace found matching: %s", query)
+	}
+	return slug, nil
+}
+
+// fuzzyBest returns the best fuzzy match of query among names, noting on
+// stderr which one is used when several match equally well. It reports false
+// when nothing matches closely enough.
+func fuzzyBest(query string, names []string) (string, bool) {
+	matches := fuzzy.Find(query, names)
+	if len(matches) == 0 || matches[0].Score < -10 {
+		return "", false
+	}
+	if len(matches) > 1 && matches[0].Score == matches[1].Score {
+		fmt.Fprintf(os.Stderr, "Ambiguous match, using: %s\n", matches[0].Str)
+	}
+	return matches[0].Str, true
+}
+
 func init() {
+	takesSlugs(cdCmd, "first")
 	rootCmd.AddCommand(cdCmd)
 	cdCmd.Flags().BoolVarP(&cdRepoFlag, "repo", "r", false, "Change into a repo within the workspace (interactive if no repo name given)")
 }
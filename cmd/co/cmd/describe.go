@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var (
+	describeClear bool
+	describeIcon  string
+	describeName  string
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <workspace-slug> [description...]",
+	Short: "Show or set the description, icon, and display name of a workspace",
+	Long: `Shows or sets the freeform description of a workspace, kept in its
+project.json. The description gives slugs like acme--svc2 some human context:
+co ls shows its first line, co show shows it whole, and the workspace
+browser searches it.
+
+--icon and --name set an emoji or glyph and a display name, shown instead of
+the slug in the workspace browser and by co prompt. An empty value removes
+them.
+
+Without a description or flags, prints the current ones.
+
+Examples:
+  co describe acme--svc2                                # show
+  co describe acme--svc2 "Billing API for the EU shop"  # set
+  co describe acme--svc2 --clear                        # remove
+  co describe acme--svc2 --icon 💶 --name "Billing API"
+  co describe acme--svc2 --icon ""                      # remove the icon`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		slug := args[0]
+
+		setIcon, setName := cmd.Flags().Changed("icon"), cmd.Flags().Changed("name")
+
+		if len(args) == 1 && !describeClear && !setIcon && !setName {
+			if !workspace.Exists(cfg, slug) {
+				return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+			}
+			proj, err := model.LoadProject(filepath.Join(cfg.WorkspacePath(slug), "project.json"))
+			if err != nil {
+				return fmt.Errorf("failed to load project.json: %w", err)
+			}
+			if proj.Icon != "" || proj.DisplayName != "" {
+				fmt.Println(model.Label(proj.Icon, proj.DisplayName, proj.Slug))
+			}
+			if proj.Description == "" {
+				fmt.Printf("%s has no description (set one with co describe %s \"...\")\n", slug, slug)
+				return nil
+			}
+			fmt.Println(proj.Description)
+			return nil
+		}
+		if describeClear && len(args) > 1 {
+			return fmt.Errorf("--clear takes no description")
+		}
+
+		if setIcon {
+			if err := workspace.SetIcon(cfg, slug, describeIcon); err != nil {
+				return err
+			}
+		}
+		if setName {
+			if err := workspace.SetDisplayName(cfg, slug, describeName); err != nil {
+				return err
+			}
+		}
+		if (setIcon || setName) && len(args) == 1 && !describeClear {
+			fmt.Printf("Updated %s\n", slug)
+			return nil
+		}
+
+		description := strings.Join(args[1:], " ")
+		if err := workspace.SetDescription(cfg, slug, description); err != nil {
+			return err
+		}
+		if description == "" {
+			fmt.Printf("Removed the description of %s\n", slug)
+		} else {
+			fmt.Printf("Described %s\n", slug)
+		}
+		return nil
+	},
+}
+
+func init() {
+	takesSlugs(describeCmd, "first")
+	describeCmd.Flags().BoolVar(&describeClear, "clear", false, "remove the description")
+	describeCmd.Flags().StringVar(&describeIcon, "icon", "", "set the workspace icon, such as an emoji (empty to remove)")
+	describeCmd.Flags().StringVar(&describeName, "name", "", "set the display name shown instead of the slug (empty to remove)")
+	rootCmd.AddCommand(describeCmd)
+}
@@ -11,11 +11,6 @@ import (
 	"github.com/tormodhaugland/co/internal/tui"
 )
 
-var (
-	doctorYes    bool
-	doctorDryRun bool
-)
-
 type doctorResult struct {
 	CodeRoot string                  `json:"code_root"`
 	Missing  []doctor.MissingProject `json:"missing"`
@@ -31,13 +26,14 @@ var doctorCmd = &cobra.Command{
 	Short: "Check and repair workspace metadata",
 	Long: `Scans workspaces for missing project.json files.
 If any are missing, you can create them interactively.`,
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		missing, err := doctor.FindMissingProjects(cfg.CodeRoot)
+		missing, err := doctor.FindMissingProjects(cfg)
 		if err != nil {
 			return fmt.Errorf("failed to scan workspaces: %w", err)
 		}
@@ -45,7 +41,7 @@ If any are missing, you can create them interactively.`,
 		result := doctorResult{
 			CodeRoot: cfg.CodeRoot,
 			Missing:  missing,
-			DryRun:   doctorDryRun,
+			DryRun:   dryRun,
 		}
 
 		if len(missing) == 0 {
@@ -59,10 +55,10 @@ If any are missing, you can create them interactively.`,
 		}
 
 		if jsonOut {
-			if doctorYes && doctorDryRun {
+			if assumeYes && dryRun {
 				result.Planned = collectSlugs(missing)
 			}
-			if doctorYes && !doctorDryRun {
+			if assumeYes && !dryRun {
 				applyDoctorFixes(&result, true)
 			}
 			enc := json.NewEncoder(os.Stdout)
@@ -81,7 +77,7 @@ If any are missing, you can create them interactively.`,
 			fmt.Printf("  - %s (%s)\n", entry.Slug, entry.Path)
 		}
 
-		if doctorDryRun {
+		if dryRun {
 			fmt.Println("Dry run - no changes made")
 			for _, entry := range missing {
 				fmt.Printf("Would create project.json for %s\n", entry.Slug)
@@ -89,13 +85,13 @@ If any are missing, you can create them interactively.`,
 			return nil
 		}
 
-		if doctorYes {
+		if assumeYes {
 			applyDoctorFixes(&result, false)
 		} else {
 			for _, entry := range missing {
 				confirm, err := tui.RunConfirm(fmt.Sprintf("Create project.json for '%s'?", entry.Slug))
 				if err != nil {
-					return fmt.Errorf("prompt failed: %w", err)
+					return fmt.Errorf("prompt failed (use --yes to skip): %w", err)
 				}
 				if confirm.Aborted {
 					return fmt.Errorf("aborted")
@@ -120,8 +116,6 @@ If any are missing, you can create them interactively.`,
 }
 
 func init() {
-	doctorCmd.Flags().BoolVarP(&doctorYes, "yes", "y", false, "create missing project.json files without prompting")
-	doctorCmd.Flags().BoolVar(&doctorDryRun, "dry-run", false, "preview missing project.json files without creating")
 	rootCmd.AddCommand(doctorCmd)
 }
 
@@ -134,7 +128,7 @@ func applyDoctorFixes(result *doctorResult, quiet bool) {
 }
 
 func createProjectJSON(entry doctor.MissingProject, result *doctorResult, quiet bool) error {
-	project, err := doctor.CreateProjectJSON(entry.Slug, entry.Path)
+	project, err := doctor.CreateProjectJSON(entry)
 	if err != nil {
 		msg := fmt.Sprintf("%s: %v", entry.Slug, err)
 		result.Errors = append(result.Errors, msg)
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/envfile"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var (
+	envVars      []string
+	envReveal    bool
+	envRepo      string
+	envOverwrite bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage workspace .env files",
+	Long: `Generates, previews, checks, and distributes the .env files of a workspace.
+
+The workspace .env at the workspace root is generated from its .env.example,
+or from the example files of its repos (.env.example, .env.sample,
+.env.template, or .env.dist). Example values may use template variables such
+as {{SLUG}} or the variables the workspace was created with. Repos that have
+an example file get their own .env from the workspace .env with co env sync.
+
+Values of secret-looking keys (tokens, passwords, API keys, ...) and passwords
+in URLs are masked in previews unless --reveal is given.
+
+Subcommands:
+  co env generate <workspace>   # Write the workspace .env from the examples
+  co env show <workspace>       # Print an env file with secrets masked
+  co env diff <workspace>       # Compare env files with their examples
+  co env sync <workspace>       # Copy the workspace .env into repos that need one`,
+}
+
+var envGenerateCmd = &cobra.Command{
+	Use:   "generate <workspace-slug>",
+	Short: "Generate the workspace .env from example files",
+	Long: `Writes the workspace .env from its example file, or the merged example
+files of its repos. Values already in the .env are kept, so generate can be
+run again after the examples change. --var KEY=value sets an env key, or a
+template variable used in example values.
+
+Use --dry-run to preview the result with secrets masked.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, workspacePath, err := envWorkspace(args[0])
+		if err != nil {
+			return err
+		}
+
+		f, err := envfile.Generate(cfg, workspacePath, parseVarFlags(envVars))
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(workspacePath, ".env")
+		empty := f.EmptyKeys()
+
+		if dryRun {
+			if jsonOut {
+				plan := model.NewPlan("Generate " + path)
+				plan.Add(model.ActionWrite, path, "", fmt.Sprintf("%d key(s)", len(f.Keys())))
+				return printPlan(plan)
+			}
+			fmt.Printf("Would write %s:\n\n", path)
+			os.Stdout.Write(previewEnv(f))
+			printEmptyKeys(empty)
+			return nil
+		}
+
+		if err := os.WriteFile(path, f.Bytes(), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]any{
+				"path":  path,
+				"keys":  f.Keys(),
+				"empty": empty,
+			})
+		}
+		fmt.Printf("Wrote %s (%d keys)\n", path, len(f.Keys()))
+		printEmptyKeys(empty)
+		return nil
+	},
+}
+
+var envShowCmd = &cobra.Command{
+	Use:   "show <workspace-slug>",
+	Short: "Print a workspace or repo .env with secrets masked",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, workspacePath, err := envWorkspace(args[0])
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(workspacePath, ".env")
+		if envRepo != "" {
+			repoDir := model.RepoDir(model.LayoutNested, envRepo)
+			if specs, err := model.LocateWorkspaceRepos(workspacePath); err == nil {
+				for _, spec := range specs {
+					if spec.Name == envRepo {
+						repoDir = filepath.FromSlash(spec.Path)
+					}
+				}
+			}
+			path = filepath.Join(workspacePath, repoDir, ".env")
+		}
+		f, err := envfile.Load(path)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			values := make(map[string]string)
+			for _, key := range f.Keys() {
+				value, _ := f.Get(key)
+				if !envReveal {
+					value = envfile.MaskValue(key, value)
+				}
+				values[key] = value
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(values)
+		}
+		_, err = os.Stdout.Write(previewEnv(f))
+		return err
+	},
+}
+
+var envDiffCmd = &cobra.Command{
+	Use:   "diff <workspace-slug>",
+	Short: "Compare env files with their example files",
+	Long: `Lists, for the workspace and each repo with an example file, the example keys
+missing from its .env, keys present but empty, and keys not in the example.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, workspacePath, err := envWorkspace(args[0])
+		if err != nil {
+			return err
+		}
+
+		targets, err := envfile.Targets(workspacePath)
+		if err != nil {
+			return err
+		}
+		if envRepo != "" {
+			var filtered []envfile.Target
+			for _, t := range targets {
+				if t.Name == envRepo {
+					filtered = append(filtered, t)
+				}
+			}
+			if len(filtered) == 0 {
+				return fmt.Errorf("repo %s has no example env file", envRepo)
+			}
+			targets = filtered
+		}
+
+		type targetDiff struct {
+			envfile.Target
+			Exists bool `json:"exists"`
+			envfile.Diff
+		}
+		diffs := []targetDiff{}
+		for _, t := range targets {
+			example, err := envfile.Load(t.Example)
+			if err != nil {
+				return err
+			}
+			env, err := envfile.Load(t.Path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			diffs = append(diffs, targetDiff{Target: t, Exists: env != nil, Diff: envfile.Compare(example, env)})
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(diffs)
+		}
+		if len(diffs) == 0 {
+			fmt.Println("No example env files found.")
+			return nil
+		}
+		for _, d := range diffs {
+			rel, _ := filepath.Rel(workspacePath, d.Path)
+			switch {
+			case !d.Exists:
+				fmt.Printf("%s: missing (%d key(s) in %s)\n", rel, len(d.Missing), filepath.Base(d.Example))
+			case d.Clean():
+				fmt.Printf("%s: matches %s\n", rel, filepath.Base(d.Example))
+			default:
+				fmt.Printf("%s:\n", rel)
+				printKeyList("missing", d.Missing)
+				printKeyList("empty", d.Empty)
+				printKeyList("not in example", d.Extra)
+			}
+		}
+		return nil
+	},
+}
+
+var envSyncCmd = &cobra.Command{
+	Use:   "sync <workspace-slug>",
+	Short: "Copy the workspace .env into repos that need one",
+	Long: `Writes a .env into each repo that has an example env file. The repo's .env
+follows its example, with values taken from the workspace .env; keys the
+workspace .env lacks keep the example value and are reported.
+
+Existing repo .env files are kept unless --overwrite is given.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, workspacePath, err := envWorkspace(args[0])
+		if err != nil {
+			return err
+		}
+
+		results, err := envfile.SyncRepos(workspacePath, envOverwrite, dryRun)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			plan := model.NewPlan(fmt.Sprintf("Copy .env into %d repo(s)", len(results)))
+			for _, r := range results {
+				if r.Written {
+					plan.Add(model.ActionWrite, r.Path, "", missingNote(r.Missing))
+				} else {
+					plan.Skip(model.ActionWrite, r.Path, "", "already exists")
+				}
+			}
+			return printPlan(plan)
+		}
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+		if len(results) == 0 {
+			fmt.Println("No repos have an example env file.")
+			return nil
+		}
+		for _, r := range results {
+			if r.Written {
+				fmt.Printf("✓ %s: wrote .env%s\n", r.Repo, prefixNote(missingNote(r.Missing)))
+			} else {
+				fmt.Printf("- %s: .env exists (use --overwrite to replace)\n", r.Repo)
+			}
+		}
+		return nil
+	},
+}
+
+func envWorkspace(slug string) (*config.Config, string, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+	if !workspace.Exists(cfg, slug) {
+		return nil, "", fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+	}
+	return cfg, cfg.WorkspacePath(slug), nil
+}
+
+// previewEnv renders f for the terminal, masking secrets unless --reveal.
+func previewEnv(f *envfile.File) []byte {
+	if envReveal {
+		return f.Bytes()
+	}
+	return f.Masked()
+}
+
+func printEmptyKeys(keys []string) {
+	if len(keys) > 0 {
+		fmt.Printf("\nFill in: %s\n", strings.Join(keys, ", "))
+	}
+}
+
+func printKeyList(label string, keys []string) {
+	if len(keys) > 0 {
+		fmt.Printf("  %s: %s\n", label, strings.Join(keys, ", "))
+	}
+}
+
+func missingNote(missing []string) string {
+	if len(missing) == 0 {
+		return ""
+	}
+	return "not in workspace .env: " + strings.Join(missing, ", ")
+}
+
+func prefixNote(note string) string {
+	if note == "" {
+		return ""
+	}
+	return " (" + note + ")"
+}
+
+func init() {
+	takesSlugs(envGenerateCmd, "first")
+	takesSlugs(envShowCmd, "first")
+	takesSlugs(envDiffCmd, "first")
+	takesSlugs(envSyncCmd, "first")
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envGenerateCmd)
+	envCmd.AddCommand(envShowCmd)
+	envCmd.AddCommand(envDiffCmd)
+	envCmd.AddCommand(envSyncCmd)
+	envGenerateCmd.Flags().StringArrayVarP(&envVars, "var", "v", nil, "set an env key or template variable (key=value)")
+	envGenerateCmd.Flags().BoolVar(&envReveal, "reveal", false, "show secret values in the --dry-run preview")
+	envShowCmd.Flags().BoolVar(&envReveal, "reveal", false, "show secret values")
+	envShowCmd.Flags().StringVar(&envRepo, "repo", "", "show the .env of this repo instead of the workspace")
+	envDiffCmd.Flags().StringVar(&envRepo, "repo", "", "only compare this repo")
+	envSyncCmd.Flags().BoolVar(&envOverwrite, "overwrite", false, "replace existing repo .env files")
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var graphFormat string
+
+var graphCmd = &cobra.Command{
+	Use:   "graph <workspace-slug>",
+	Short: "Show the dependency graph between a workspace's repos",
+	Long: `Shows which repos of a workspace depend on which.
+
+Dependencies are declared with depends_on on a repo in project.json, and
+detected from go.mod (a require of, or replace pointing at, another repo's
+module) and package.json (a dependency on another repo's package, a file:
+or link: path to it, or a workspaces entry matching it).
+
+The ASCII format lists repos in dependency order, each followed by what it
+depends on. The DOT format renders with Graphviz.
+
+Examples:
+  co graph acme--platform
+  co graph acme--platform --format dot | dot -Tsvg > deps.svg
+  co graph acme--platform --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := args[0]
+		graph, err := loadDepGraph(slug)
+		if err != nil {
+			return err
+		}
+
+		order, orderErr := graph.Order()
+		var cycle *workspace.CycleError
+		if orderErr != nil && !errors.As(orderErr, &cycle) {
+			return orderErr
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				*workspace.DepGraph
+				Order []string `json:"order,omitempty"`
+				Cycle []string `json:"cycle,omitempty"`
+			}{graph, order, cycleRepos(cycle)})
+		}
+
+		switch graphFormat {
+		case "dot":
+			fmt.Print(graph.DOT(slug))
+		case "ascii":
+			fmt.Print(graph.ASCII())
+		default:
+			return fmt.Errorf("unknown format %q (use ascii or dot)", graphFormat)
+		}
+		for _, w := range graph.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		if cycle != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", cycle)
+		}
+		return nil
+	},
+}
+
+// loadDepGraph builds the repo dependency graph of the workspace slug.
+func loadDepGraph(slug string) (*workspace.DepGraph, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if !workspace.Exists(cfg, slug) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+	}
+	workspacePath := cfg.WorkspacePath(slug)
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project.json: %w", err)
+	}
+	return workspace.BuildDepGraph(workspacePath, proj)
+}
+
+func cycleRepos(cycle *workspace.CycleError) []string {
+	if cycle == nil {
+		return nil
+	}
+	return cycle.Repos
+}
+
+func init() {
+	takesSlugs(graphCmd, "first")
+	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", "ascii", "output format: ascii or dot")
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var (
+	hooksAll   bool
+	hooksMode  string
+	hooksForce bool
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Install git hooks into the repos of workspaces",
+	Long: `Installs your own git hooks into every repo of a workspace, or of all
+workspaces, and removes them again.
+
+Hooks are scripts named after the git hook they implement (pre-commit,
+commit-msg, pre-push, ...) in the git_hooks directory of the config, by
+default _system/git-hooks. A git-hooks/ directory in the workspace's
+template adds hooks, replacing those of the same name.
+
+  {
+    "git_hooks": { "dir": "~/dotfiles/git-hooks", "mode": "hooks_path" }
+  }
+
+Modes:
+  hooks_path  Copy the hooks to <workspace>/.githooks and point
+              core.hooksPath of each repo there (default)
+  copy        Copy the hooks into each repo's .git/hooks
+
+Repos whose core.hooksPath points elsewhere, and hooks a repo already has,
+are left alone unless --force is given. In copy mode, --force backs up
+replaced hooks, and uninstall restores them.
+
+Subcommands:
+  co hooks install <slug>          # Install into one workspace
+  co hooks install --all           # Install into every workspace
+  co hooks uninstall <slug>        # Remove what install did`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install [workspace-slug]",
+	Short: "Install git hooks into each repo of a workspace",
+	Long: `Installs the configured git hooks into each repo of a workspace, or of
+every workspace with --all. Installing again picks up changed hooks.
+
+Examples:
+  co hooks install acme--platform
+  co hooks install --all --mode copy
+  co hooks install acme--platform --force --dry-run`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		slugs, err := hooksWorkspaces(cfg, args)
+		if err != nil {
+			return err
+		}
+
+		mode := cfg.GetGitHooksConfig().Mode
+		if hooksMode != "" {
+			if hooksMode != config.GitHooksPathMode && hooksMode != config.GitHooksCopyMode {
+				return fmt.Errorf("unknown mode %q (use hooks_path or copy)", hooksMode)
+			}
+			mode = hooksMode
+		}
+		opts := template.GitHooksOptions{Mode: mode, Force: hooksForce, DryRun: dryRun}
+
+		return runHooks(slugs, func(slug string) (*template.GitHooksResult, error) {
+			return template.InstallGitHooks(cfg, slug, opts)
+		})
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall [workspace-slug]",
+	Short: "Remove git hooks installed by co hooks install",
+	Long: `Removes the git hooks installed by co hooks install from each repo of a
+workspace, or of every workspace with --all, in either mode: core.hooksPath
+is unset where it points at the workspace's .githooks, copied hooks are
+removed, and hooks they replaced are restored.
+
+Examples:
+  co hooks uninstall acme--platform
+  co hooks uninstall --all --dry-run`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		slugs, err := hooksWorkspaces(cfg, args)
+		if err != nil {
+			return err
+		}
+		return runHooks(slugs, func(slug string) (*template.GitHooksResult, error) {
+			return template.UninstallGitHooks(cfg, slug, dryRun)
+		})
+	},
+}
+
+// hooksWorkspaces returns the workspace given as argument, or every
+// workspace with --all.
+func hooksWorkspaces(cfg *config.Config, args []string) ([]string, error) {
+	if hooksAll {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("--all cannot be combined with a workspace argument")
+		}
+		slugs, err := workspace.ListWorkspaces(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workspaces: %w", err)
+		}
+		return slugs, nil
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("specify a workspace or --all")
+	}
+	if !workspace.Exists(cfg, args[0]) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, args[0])
+	}
+	return args, nil
+}
+
+// runHooks runs op on each workspace and reports the results.
+func runHooks(slugs []string, op func(slug string) (*template.GitHooksResult, error)) error {
+	results := []*template.GitHooksResult{}
+	failed := 0
+	for _, slug := range slugs {
+		result, err := op(slug)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", slug, err)
+			continue
+		}
+		results = append(results, result)
+		failed += result.Failed()
+		if !jsonOut {
+			printHooksResult(result)
+		}
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	}
+	if dryRun && !jsonOut {
+		fmt.Println("\nDry run: nothing was changed.")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d workspace(s) or repo(s) failed", failed)
+	}
+	return nil
+}
+
+func printHooksResult(result *template.GitHooksResult) {
+	if result.Mode != "" {
+		fmt.Printf("%s (%s: %s)\n", result.WorkspaceSlug, result.Mode, strings.Join(result.Hooks, ", "))
+	} else {
+		fmt.Println(result.WorkspaceSlug)
+	}
+	for _, r := range result.Repos {
+		switch {
+		case r.Error != "":
+			fmt.Printf("  ✗ %s: %s\n", r.Repo, r.Error)
+		case r.Skipped != "":
+			fmt.Printf("  - %s: skipped, %s\n", r.Repo, r.Skipped)
+		default:
+			var parts []string
+			if len(r.Installed) > 0 {
+				parts = append(parts, "installed "+strings.Join(r.Installed, ", "))
+			}
+			if len(r.Kept) > 0 {
+				parts = append(parts, "kept existing "+strings.Join(r.Kept, ", "))
+			}
+			if len(r.Removed) > 0 {
+				parts = append(parts, "removed "+strings.Join(r.Removed, ", "))
+			}
+			if len(r.Restored) > 0 {
+				parts = append(parts, "restored "+strings.Join(r.Restored, ", "))
+			}
+			if result.Mode == "" && r.HooksPath != "" {
+				parts = append(parts, "unset core.hooksPath")
+			}
+			fmt.Printf("  ✓ %s: %s\n", r.Repo, strings.Join(parts, "; "))
+		}
+	}
+}
+
+func init() {
+	takesSlugs(hooksInstallCmd, "first")
+	takesSlugs(hooksUninstallCmd, "first")
+	rootCmd.AddCommand(hooksCmd)
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	hooksCmd.PersistentFlags().BoolVar(&hooksAll, "all", false, "apply to every workspace")
+	hooksInstallCmd.Flags().StringVar(&hooksMode, "mode", "", "hooks_path or copy (default from config)")
+	hooksInstallCmd.Flags().BoolVar(&hooksForce, "force", false, "replace a repo's own core.hooksPath or hooks")
+}
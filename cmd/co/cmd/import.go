@@ -10,6 +10,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/platform"
+	"github.com/tormodhaugland/co/internal/script"
 	"github.com/tormodhaugland/co/internal/template"
 	"github.com/tormodhaugland/co/internal/tui"
 	"github.com/tormodhaugland/co/internal/workspace"
@@ -18,12 +21,22 @@ import (
 var (
 	importOwner        string
 	importProject      string
-	importDryRun       bool
 	importAddTo        string
 	importTemplateName string
 	importTemplateVars []string
 	importNoHooks      bool
 	importInteractive  bool
+	importLast         bool
+	importScanDepth    int
+	importFollowLinks  bool
+	importExclude      []string
+	importSplits       []string
+	importKeepMonorepo bool
+	importSplitMethod  string
+	importMergeInto    string
+	importCdFile       string
+	importLayout       string
+	importRepoPaths    []string
 )
 
 var importCmd = &cobra.Command{
@@ -40,21 +53,48 @@ Non-git files and folders can also be included via an interactive picker.
 
 Use --add-to to add repos to an existing workspace instead of creating a new one.
 Use -i/--interactive to launch a visual file browser for selecting folders to import.
+The browser remembers expanded folders, the cursor, and the filter per folder;
+add --last to reopen the folder browsed most recently.
+
+Git repositories are detected up to 4 levels below the folder by default.
+Use --scan-depth, --follow-symlinks, and --exclude (or "git_scan" in the
+config) to change how deep and where co looks.
+
+Splitting a Monorepo:
+  --split <dir[=name]>   Split a subdirectory of the repo, with its history,
+                         into a repo of its own (repeatable; name defaults
+                         to the last path element)
+  --keep-monorepo        Also import the repo itself; by default it is left
+                         in place once split
+  --split-method <m>     subtree or filter-repo (default: filter-repo when
+                         installed, else subtree)
+
+Merging Repos:
+  --merge-into <name>    Merge the repos found into one repo, each in a
+                         subdirectory with its history (subtree merges),
+                         instead of moving them; they are left in place
 
 Template Support:
   -t, --template <name>  Apply a template after import
   -v, --var <key=value>  Set template variable (can be repeated)
       --no-hooks         Skip running lifecycle hooks`,
-	Args: cobra.MaximumNArgs(1),
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
 		// Determine source path
 		var sourcePath string
-		var err error
 		if len(args) > 0 {
 			sourcePath, err = filepath.Abs(args[0])
 			if err != nil {
 				return fmt.Errorf("invalid path: %w", err)
 			}
+		} else if last := tui.LastImportRoot(cfg); importLast && importInteractive && last != "" {
+			sourcePath = last
 		} else {
 			// No path provided - use current directory
 			sourcePath, err = os.Getwd()
@@ -71,9 +111,8 @@ Template Support:
 			return fmt.Errorf("path is not a directory: %s", sourcePath)
 		}
 
-		cfg, err := config.Load(cfgFile)
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+		if err := applyImportScanFlags(cmd, cfg); err != nil {
+			return err
 		}
 
 		// Interactive mode - launch import browser TUI
@@ -99,7 +138,7 @@ Template Support:
 					fmt.Printf("Added to workspace: %s\n", result.WorkspacePath)
 				}
 			}
-			return nil
+			return handOffChdir(importCdFile, result.ChdirTo)
 		}
 
 		// Non-interactive mode requires a path argument
@@ -107,10 +146,14 @@ Template Support:
 			return fmt.Errorf("folder path required (or use -i/--interactive for visual browser)")
 		}
 
-		gitRoots, err := git.FindGitRoots(sourcePath)
+		scan, err := git.ScanGitRoots(sourcePath, workspace.GitScanOptions(cfg))
 		if err != nil {
 			return fmt.Errorf("failed to scan for git repos: %w", err)
 		}
+		gitRoots := scan.Roots
+		if n := countTruncatedOutsideRepos(scan); n > 0 {
+			fmt.Fprintf(os.Stderr, "Note: scan depth %d reached in %d folder(s); deeper repos were not detected (use --scan-depth).\n", cfg.GetGitScanConfig().MaxDepth, n)
+		}
 
 		// Check if the source folder has any content at all
 		entries, err := os.ReadDir(sourcePath)
@@ -122,6 +165,10 @@ Template Support:
 		}
 
 		if importAddTo != "" {
+			if len(importSplits) > 0 || importMergeInto != "" {
+				return fmt.Errorf("--split and --merge-into cannot be used with --add-to")
+			}
+			importAddTo = cfg.ResolveAlias(importAddTo)
 			return runAddToWorkspace(cfg, sourcePath, gitRoots)
 		}
 
@@ -134,8 +181,8 @@ func runAddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string)
 
 	// Check for non-git files/folders to offer inclusion
 	var extraFilesResult tui.ExtraFilesResult
-	if !importDryRun {
-		nonGitItems, err := tui.FindNonGitItems(sourcePath, gitRoots)
+	if !dryRun {
+		nonGitItems, err := tui.FindNonGitItems(sourcePath, gitRoots, tui.HiddenPolicyFor(cfg), cfg.GetSecretsConfig().Patterns)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to scan for non-git files: %v\n", err)
 		} else if len(nonGitItems) > 0 {
@@ -150,11 +197,24 @@ func runAddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string)
 		}
 	}
 
-	if importDryRun {
+	repoPaths, err := parseImportRepoPaths(sourcePath, gitRoots)
+	if err != nil {
+		return err
+	}
+	workspacePath := cfg.WorkspacePath(slug)
+
+	if dryRun {
+		proj, _ := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+		if proj == nil {
+			proj = &model.Project{}
+		}
 		fmt.Printf("Dry run - would add to workspace: %s\n", slug)
 		for _, root := range gitRoots {
-			repoName := workspace.DeriveRepoName(root, sourcePath)
-			fmt.Printf("  Move %s -> repos/%s\n", root, repoName)
+			dir := repoPaths[root]
+			if dir == "" {
+				dir = proj.RepoDir(workspace.DeriveRepoName(root, sourcePath))
+			}
+			fmt.Printf("  Move %s -> %s\n", root, filepath.ToSlash(dir))
 		}
 		return nil
 	}
@@ -162,8 +222,9 @@ func runAddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string)
 	opts := workspace.ImportOptions{
 		ExtraFiles:     extraFilesResult.SelectedPaths,
 		ExtraFilesDest: extraFilesResult.DestSubfolder,
+		RepoPaths:      repoPaths,
 		OnRepoMove: func(repoName, srcPath, dstPath string) {
-			fmt.Printf("Moving %s -> repos/%s\n", srcPath, repoName)
+			fmt.Printf("Moving %s -> %s\n", srcPath, workspaceRel(workspacePath, dstPath))
 		},
 		OnRepoSkip: func(repoName, reason string) {
 			fmt.Printf("Skipping %s (%s)\n", repoName, reason)
@@ -176,8 +237,15 @@ func runAddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string)
 		},
 	}
 
-	result, err := workspace.AddToWorkspace(cfg, sourcePath, gitRoots, slug, opts)
+	ctx, stop := interruptContext()
+	defer stop()
+
+	result, err := workspace.AddToWorkspaceContext(ctx, cfg, sourcePath, gitRoots, slug, opts)
 	if err != nil {
+		if result != nil {
+			// Interrupted; what was moved is recorded in the workspace
+			fmt.Fprintf(os.Stderr, "Interrupted after importing %d repo(s) into %s\n", len(result.ReposImported), result.WorkspacePath)
+		}
 		return err
 	}
 
@@ -201,12 +269,50 @@ func runAddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string)
 }
 
 func runCreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string) error {
+	splits, err := parseImportSplits(gitRoots)
+	if err != nil {
+		return err
+	}
+	// Split repos stay in place unless asked for too
+	moveRoots := gitRoots
+	if len(splits) > 0 && !importKeepMonorepo {
+		moveRoots = nil
+	}
+	if importMergeInto != "" && (importMergeInto != filepath.Base(importMergeInto) || strings.HasPrefix(importMergeInto, ".")) {
+		return fmt.Errorf("invalid --merge-into repo name: %s", importMergeInto)
+	}
+
 	suggestedOwner := importOwner
 	suggestedProject := importProject
 
+	if c := classifyImport(cfg, sourcePath, gitRoots); c != nil {
+		if suggestedOwner == "" {
+			suggestedOwner = c.Owner
+		}
+		if suggestedProject == "" {
+			suggestedProject = c.Project
+		}
+		if importTemplateName == "" {
+			importTemplateName = c.Template
+		}
+	}
+
 	if suggestedProject == "" {
-		suggestedProject = strings.ToLower(filepath.Base(sourcePath))
-		suggestedProject = workspace.SanitizeSlugPart(suggestedProject)
+		if name, file := workspace.PackageName(sourcePath); name != "" {
+			suggestedProject = workspace.SchemeFor(cfg).Sanitize(name)
+			if suggestedProject != "" && suggestedProject != workspace.SchemeFor(cfg).Sanitize(filepath.Base(sourcePath)) {
+				fmt.Printf("Suggested project %q from %q in %s.\n", suggestedProject, name, file)
+			}
+		}
+	}
+	if suggestedProject == "" {
+		folder := filepath.Base(sourcePath)
+		suggestedProject = workspace.SchemeFor(cfg).Sanitize(folder)
+		if suggestedProject == "" {
+			fmt.Printf("Folder name %q has no slug-safe characters; enter a project name.\n", folder)
+		} else if suggestedProject != folder {
+			fmt.Printf("Suggested project %q from folder name %q.\n", suggestedProject, folder)
+		}
 	}
 
 	var owner, project string
@@ -217,7 +323,7 @@ func runCreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string
 	} else {
 		result, err := tui.RunImportPrompt(sourcePath, gitRoots, suggestedOwner, suggestedProject)
 		if err != nil {
-			return fmt.Errorf("prompt failed: %w", err)
+			return promptError("prompt failed", err, "pass --owner and --project")
 		}
 		if result.Abort {
 			fmt.Println("Import cancelled.")
@@ -227,14 +333,25 @@ func runCreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string
 		project = result.Project
 	}
 
-	slug := owner + "--" + project
-	workspacePath := filepath.Join(cfg.CodeRoot, slug)
-	reposPath := filepath.Join(workspacePath, "repos")
+	slug := workspace.SchemeFor(cfg).Format(owner, project)
+	workspacePath := cfg.WorkspacePath(slug)
+	layout := importLayout
+	if layout == "" {
+		layout = cfg.GetRepoLayout(owner)
+	}
+	if !model.ValidLayout(layout) {
+		return fmt.Errorf("unknown --layout %q (use %s or %s)", layout, model.LayoutNested, model.LayoutFlat)
+	}
+	repoPaths, err := parseImportRepoPaths(sourcePath, moveRoots)
+	if err != nil {
+		return err
+	}
+	reposPath := filepath.Join(workspacePath, model.RepoDir(layout, ""))
 
 	// Check for non-git files/folders to offer inclusion
 	var extraFilesResult tui.ExtraFilesResult
-	if !importDryRun {
-		nonGitItems, err := tui.FindNonGitItems(sourcePath, gitRoots)
+	if !dryRun {
+		nonGitItems, err := tui.FindNonGitItems(sourcePath, gitRoots, tui.HiddenPolicyFor(cfg), cfg.GetSecretsConfig().Patterns)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to scan for non-git files: %v\n", err)
 		} else if len(nonGitItems) > 0 {
@@ -249,19 +366,32 @@ func runCreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string
 		}
 
 		// If no git repos and no files selected, nothing to import
-		if len(gitRoots) == 0 && len(extraFilesResult.SelectedPaths) == 0 {
+		if len(moveRoots) == 0 && len(splits) == 0 && len(extraFilesResult.SelectedPaths) == 0 {
 			fmt.Println("No git repositories found and no files selected. Nothing to import.")
 			return nil
 		}
 	}
 
-	if importDryRun {
+	if dryRun {
 		fmt.Println("Dry run - would perform:")
 		fmt.Printf("  Create workspace: %s\n", workspacePath)
-		fmt.Printf("  Create repos dir: %s\n", reposPath)
-		for _, root := range gitRoots {
+		if layout == model.LayoutNested {
+			fmt.Printf("  Create repos dir: %s\n", reposPath)
+		}
+		for _, split := range splits {
+			fmt.Printf("  Split %s from %s -> %s\n", split.Dir, split.Repo, filepath.ToSlash(model.RepoDir(layout, split.Name)))
+		}
+		for _, root := range moveRoots {
 			repoName := workspace.DeriveRepoName(root, sourcePath)
-			fmt.Printf("  Move %s -> repos/%s\n", root, repoName)
+			dir := repoPaths[root]
+			if dir == "" {
+				dir = model.RepoDir(layout, repoName)
+			}
+			if importMergeInto != "" {
+				fmt.Printf("  Merge %s -> %s/%s\n", root, filepath.ToSlash(model.RepoDir(layout, importMergeInto)), repoName)
+			} else {
+				fmt.Printf("  Move %s -> %s\n", root, filepath.ToSlash(dir))
+			}
 		}
 		return nil
 	}
@@ -271,8 +401,19 @@ func runCreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string
 		Project:        project,
 		ExtraFiles:     extraFilesResult.SelectedPaths,
 		ExtraFilesDest: extraFilesResult.DestSubfolder,
+		Splits:         splits,
+		SplitMethod:    importSplitMethod,
+		MergeInto:      importMergeInto,
+		Layout:         layout,
+		RepoPaths:      repoPaths,
 		OnRepoMove: func(repoName, srcPath, dstPath string) {
-			fmt.Printf("Moving %s -> repos/%s\n", srcPath, repoName)
+			fmt.Printf("Moving %s -> %s\n", srcPath, workspaceRel(workspacePath, dstPath))
+		},
+		OnRepoSplit: func(repoName, srcPath, dir string) {
+			fmt.Printf("Splitting %s -> %s\n", dir, filepath.ToSlash(model.RepoDir(layout, repoName)))
+		},
+		OnRepoMerge: func(repoName, srcPath, into string) {
+			fmt.Printf("Merging %s -> %s/%s\n", srcPath, filepath.ToSlash(model.RepoDir(layout, into)), repoName)
 		},
 		OnFileCopy: func(relPath, dstPath string) {
 			fmt.Printf("Copying %s\n", relPath)
@@ -282,8 +423,15 @@ func runCreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string
 		},
 	}
 
-	result, err := workspace.CreateWorkspace(cfg, sourcePath, gitRoots, opts)
+	ctx, stop := interruptContext()
+	defer stop()
+
+	result, err := workspace.CreateWorkspaceContext(ctx, cfg, sourcePath, moveRoots, opts)
 	if err != nil {
+		if result != nil {
+			// Interrupted; what was moved is recorded in the workspace
+			fmt.Fprintf(os.Stderr, "Interrupted after importing %d repo(s) into %s\n", len(result.ReposImported), result.WorkspacePath)
+		}
 		return err
 	}
 
@@ -302,19 +450,44 @@ func runCreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string
 
 	fmt.Printf("\nCreated workspace: %s\n", result.WorkspacePath)
 
-	// Apply template if specified
+	// Apply template if specified, falling back to the owner's default
+	if importTemplateName == "" {
+		importTemplateName = workspace.DefaultTemplate(cfg, opts.Owner)
+	}
 	if importTemplateName != "" {
 		fmt.Printf("\nApplying template: %s\n", importTemplateName)
-		if err := applyImportTemplate(cfg, result.WorkspacePath); err != nil {
+		if err := applyImportTemplate(cfg, result.WorkspaceSlug, result.WorkspacePath); err != nil {
 			return fmt.Errorf("failed to apply template: %w", err)
 		}
 	}
 
+	written, err := template.WriteShellEnv(cfg, result.WorkspacePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	printShellEnvWritten(written, result.WorkspaceSlug)
+
 	fmt.Printf("Run 'co index' to update the index.\n")
 	return nil
 }
 
-func applyImportTemplate(cfg *config.Config, workspacePath string) error {
+// classifyImport returns the co script's suggestions for importing
+// sourcePath, or nil. Script errors are warnings.
+func classifyImport(cfg *config.Config, sourcePath string, gitRoots []string) *script.Classification {
+	s, err := script.Load(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+	c, err := s.Classify(sourcePath, gitRoots)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+	return c
+}
+
+func applyImportTemplate(cfg *config.Config, slug, workspacePath string) error {
 	// Load template to check for required variables
 	tmpl, err := template.LoadTemplate(cfg.TemplatesDir(), importTemplateName)
 	if err != nil {
@@ -325,17 +498,31 @@ func applyImportTemplate(cfg *config.Config, workspacePath string) error {
 	providedVars := parseImportVarFlags(importTemplateVars)
 
 	// Get built-in variables
-	slug := filepath.Base(workspacePath)
-	owner, project := parseSlugForImport(slug)
-	builtins := template.GetBuiltinVariables(owner, project, workspacePath, cfg.CodeRoot)
+	owner, project := parseSlugForImport(cfg, slug)
+	builtins := template.GetBuiltinVariables(owner, project, slug, workspacePath, cfg.CodeRoot)
+
+	if err := template.CheckProvidedVars(tmpl, providedVars, builtins); err != nil {
+		return err
+	}
 
 	// Check for missing required variables and prompt
 	missing := template.GetMissingRequiredVars(tmpl, providedVars, builtins)
+	if len(missing) > 0 && !platform.IsTerminal(os.Stdin) {
+		names := make([]string, len(missing))
+		for i, v := range missing {
+			names[i] = v.Name
+		}
+		return fmt.Errorf("template '%s' requires %s; stdin is not a terminal to ask, so pass them with --var name=value", importTemplateName, strings.Join(names, ", "))
+	}
 	if len(missing) > 0 {
 		fmt.Printf("Template '%s' requires the following variables:\n\n", importTemplateName)
 		reader := bufio.NewReader(os.Stdin)
 
 		for _, v := range missing {
+			// Answers so far may decide that v does not apply
+			if !template.VarApplies(v, template.KnownValues(tmpl, providedVars, builtins)) {
+				continue
+			}
 			fmt.Printf("%s", v.Name)
 			if v.Description != "" {
 				fmt.Printf(" (%s)", v.Description)
@@ -363,8 +550,9 @@ func applyImportTemplate(cfg *config.Config, workspacePath string) error {
 		TemplateName: importTemplateName,
 		Variables:    providedVars,
 		NoHooks:      importNoHooks,
-		DryRun:       importDryRun,
+		DryRun:       dryRun,
 		Verbose:      true,
+		OnHookEvent:  printHookEvent,
 	}
 
 	result, err := template.ApplyTemplateToExisting(cfg, workspacePath, importTemplateName, opts)
@@ -377,6 +565,7 @@ func applyImportTemplate(cfg *config.Config, workspacePath string) error {
 	if len(result.HooksRun) > 0 {
 		fmt.Printf("  Hooks run: %s\n", strings.Join(result.HooksRun, ", "))
 	}
+	printStepFailures(result.Failures)
 	if len(result.Warnings) > 0 {
 		fmt.Println("  Warnings:")
 		for _, w := range result.Warnings {
@@ -387,6 +576,75 @@ func applyImportTemplate(cfg *config.Config, workspacePath string) error {
 	return nil
 }
 
+// parseImportSplits parses the --split flags, dir or dir=name, against the
+// single repo being imported.
+func parseImportSplits(gitRoots []string) ([]workspace.RepoSplit, error) {
+	if len(importSplits) == 0 {
+		return nil, nil
+	}
+	if len(gitRoots) != 1 {
+		return nil, fmt.Errorf("--split needs a folder holding exactly one git repo, found %d", len(gitRoots))
+	}
+	switch importSplitMethod {
+	case "", git.SplitSubtree, git.SplitFilterRepo:
+	default:
+		return nil, fmt.Errorf("unknown --split-method %q (use %s or %s)", importSplitMethod, git.SplitSubtree, git.SplitFilterRepo)
+	}
+
+	names := make(map[string]bool)
+	var splits []workspace.RepoSplit
+	for _, s := range importSplits {
+		dir, name, _ := strings.Cut(s, "=")
+		dir = strings.Trim(filepath.ToSlash(dir), "/")
+		if name == "" {
+			name = workspace.SplitRepoName(dir)
+		}
+		if dir == "" || name == "" {
+			return nil, fmt.Errorf("invalid --split %q", s)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("two splits are named %s; name one with --split %s=<name>", name, dir)
+		}
+		names[name] = true
+		splits = append(splits, workspace.RepoSplit{Repo: gitRoots[0], Dir: dir, Name: name})
+	}
+	return splits, nil
+}
+
+// workspaceRel returns path relative to the workspace at workspacePath, with
+// slashes, for progress output.
+func workspaceRel(workspacePath, path string) string {
+	if rel, err := filepath.Rel(workspacePath, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// parseImportRepoPaths returns the --repo-path flags as repo paths by git
+// root, for workspace.ImportOptions.RepoPaths.
+func parseImportRepoPaths(sourcePath string, gitRoots []string) (map[string]string, error) {
+	if len(importRepoPaths) == 0 {
+		return nil, nil
+	}
+	roots := make(map[string]string, len(gitRoots))
+	for _, root := range gitRoots {
+		roots[workspace.DeriveRepoName(root, sourcePath)] = root
+	}
+	paths := make(map[string]string)
+	for _, s := range importRepoPaths {
+		name, path, ok := strings.Cut(s, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --repo-path %q (want repo=path)", s)
+		}
+		root, found := roots[name]
+		if !found {
+			return nil, fmt.Errorf("--repo-path: no repo named %s in %s", name, sourcePath)
+		}
+		paths[root] = path
+	}
+	return paths, nil
+}
+
 func parseImportVarFlags(vars []string) map[string]string {
 	result := make(map[string]string)
 	for _, v := range vars {
@@ -398,10 +656,47 @@ func parseImportVarFlags(vars []string) map[string]string {
 	return result
 }
 
-func parseSlugForImport(slug string) (owner, project string) {
-	parts := strings.SplitN(slug, "--", 2)
-	if len(parts) == 2 {
-		return parts[0], parts[1]
+// applyImportScanFlags overrides the configured git scan options with the
+// scan flags given on the command line.
+func applyImportScanFlags(cmd *cobra.Command, cfg *config.Config) error {
+	sc := cfg.GetGitScanConfig()
+	if cmd.Flags().Changed("scan-depth") {
+		if importScanDepth == 0 || importScanDepth < -1 {
+			return fmt.Errorf("--scan-depth must be at least 1, or -1 for unlimited")
+		}
+		sc.MaxDepth = importScanDepth
+	}
+	if cmd.Flags().Changed("follow-symlinks") {
+		sc.FollowSymlinks = importFollowLinks
+	}
+	sc.Exclude = append(sc.Exclude, importExclude...)
+	cfg.GitScan = &sc
+	return nil
+}
+
+// countTruncatedOutsideRepos counts the folders where the scan stopped at the
+// depth limit, ignoring those inside repos that were found (nested repos are
+// moved along with their parent).
+func countTruncatedOutsideRepos(scan git.ScanResult) int {
+	n := 0
+	for _, dir := range scan.Truncated {
+		inside := false
+		for _, root := range scan.Roots {
+			if config.IsWithin(dir, root) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			n++
+		}
+	}
+	return n
+}
+
+func parseSlugForImport(cfg *config.Config, slug string) (owner, project string) {
+	if parsed, ok := workspace.SchemeFor(cfg).Parse(slug); ok {
+		return parsed.Owner, parsed.Project
 	}
 	return slug, slug
 }
@@ -409,11 +704,21 @@ func parseSlugForImport(slug string) (owner, project string) {
 func init() {
 	rootCmd.AddCommand(importCmd)
 	importCmd.Flags().BoolVarP(&importInteractive, "interactive", "i", false, "launch visual import browser")
+	importCmd.Flags().BoolVar(&importLast, "last", false, "with -i and no path, reopen the last browsed folder")
+	importCmd.Flags().StringVar(&importCdFile, "cd-file", "", "with -i, write the workspace chosen to cd to into this file (shell integration)")
 	importCmd.Flags().StringVarP(&importOwner, "owner", "o", "", "workspace owner (skip prompt)")
 	importCmd.Flags().StringVarP(&importProject, "project", "p", "", "project name (skip prompt)")
 	importCmd.Flags().StringVar(&importAddTo, "add-to", "", "add repos to existing workspace instead of creating new")
-	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "show what would be done without making changes")
 	importCmd.Flags().StringVarP(&importTemplateName, "template", "t", "", "Template to apply after import")
 	importCmd.Flags().StringArrayVarP(&importTemplateVars, "var", "v", nil, "Set template variable (key=value)")
 	importCmd.Flags().BoolVar(&importNoHooks, "no-hooks", false, "Skip running lifecycle hooks")
+	importCmd.Flags().IntVar(&importScanDepth, "scan-depth", 0, "how many levels below the folder to scan for git repos (-1 for unlimited; default from config, 4)")
+	importCmd.Flags().BoolVar(&importFollowLinks, "follow-symlinks", false, "follow symlinked directories when scanning for git repos")
+	importCmd.Flags().StringArrayVar(&importExclude, "exclude", nil, "directory name or glob to skip when scanning for git repos (repeatable)")
+	importCmd.Flags().StringArrayVar(&importSplits, "split", nil, "split a subdirectory of the repo into a repo of its own: dir or dir=name (repeatable)")
+	importCmd.Flags().BoolVar(&importKeepMonorepo, "keep-monorepo", false, "with --split, also import the repo the subdirectories are split from")
+	importCmd.Flags().StringVar(&importMergeInto, "merge-into", "", "merge the repos found into one repo of this name, keeping their history")
+	importCmd.Flags().StringVar(&importSplitMethod, "split-method", "", "how to split: subtree or filter-repo (default: filter-repo when installed)")
+	importCmd.Flags().StringVar(&importLayout, "layout", "", "where the repos of a new workspace go: nested (under repos/) or flat (default from config)")
+	importCmd.Flags().StringArrayVar(&importRepoPaths, "repo-path", nil, "put a repo at a path of its own in the workspace: repo=path (repeatable)")
 }
@@ -10,6 +10,11 @@ import (
 	"github.com/tormodhaugland/co/internal/tui"
 )
 
+var (
+	importTUILast   bool
+	importTUICdFile string
+)
+
 var importTUICmd = &cobra.Command{
 	Use:   "import-tui [path]",
 	Short: "Interactive import browser for organizing folders into workspaces",
@@ -23,18 +28,33 @@ highlighting git repositories. You can:
   - Add repos to existing workspaces
   - Stash (archive) folders for later
 
-If no path is provided, the current directory is used.
+If no path is provided, the current directory is used (or, with --last, the
+folder browsed most recently). Expanded folders, the cursor, and the filter are
+remembered per folder between runs.
+
+After an import, the new workspace can be opened in the editor or a tmux
+session, or the browser can quit and hand it to the shell to cd to: with
+--cd-file the path is written to that file for a shell function to read.
 
 Examples:
   co import-tui                    # Browse current directory
   co import-tui ~/projects         # Browse ~/projects
-  co import-tui ./legacy-code      # Browse ./legacy-code`,
+  co import-tui ./legacy-code      # Browse ./legacy-code
+  co import-tui --last             # Resume the last browsed folder`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load config
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
 		// Determine the root path
 		var rootPath string
 		if len(args) > 0 {
 			rootPath = args[0]
+		} else if last := tui.LastImportRoot(cfg); importTUILast && last != "" {
+			rootPath = last
 		} else {
 			var err error
 			rootPath, err = os.Getwd()
@@ -44,7 +64,7 @@ Examples:
 		}
 
 		// Resolve to absolute path
-		rootPath, err := filepath.Abs(rootPath)
+		rootPath, err = filepath.Abs(rootPath)
 		if err != nil {
 			return fmt.Errorf("invalid path: %w", err)
 		}
@@ -58,12 +78,6 @@ Examples:
 			return fmt.Errorf("path is not a directory: %s", rootPath)
 		}
 
-		// Load config
-		cfg, err := config.Load(cfgFile)
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
-		}
-
 		// Run the import browser
 		result, err := tui.RunImportBrowser(cfg, rootPath)
 		if err != nil {
@@ -103,10 +117,28 @@ Examples:
 			}
 		}
 
-		return nil
+		return handOffChdir(importTUICdFile, result.ChdirTo)
 	},
 }
 
+// handOffChdir passes the workspace chosen in the import browser to cd to to
+// the shell integration through cdFile, or prints it when there is no file.
+func handOffChdir(cdFile, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if cdFile == "" {
+		fmt.Printf("cd %s\n", dir)
+		return nil
+	}
+	if err := os.WriteFile(cdFile, []byte(dir+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", cdFile, err)
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(importTUICmd)
+	importTUICmd.Flags().BoolVar(&importTUILast, "last", false, "reopen the last browsed folder when no path is given")
+	importTUICmd.Flags().StringVar(&importTUICdFile, "cd-file", "", "write the workspace chosen to cd to into this file (shell integration)")
 }
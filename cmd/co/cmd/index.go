@@ -2,27 +2,71 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/index"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
-var indexNoProjectSync bool
+var (
+	indexNoProjectSync  bool
+	indexWorkspaceFiles bool
+	indexRoot           bool
+	indexRootFormat     string
+	indexRootOutput     string
+)
 
 var indexCmd = &cobra.Command{
-	Use:   "index",
+	Use:   "index [workspace...]",
 	Short: "Regenerate the workspace index",
 	Long: `Scans code_root and regenerates _system/index.jsonl atomically.
 Computes last commit dates, dirty flags, and workspace sizes.
-Also syncs project.json repo entries from repos/ by default.`,
+Also syncs project.json repo entries from repos/ by default.
+
+With workspace arguments, regenerates the index file at the root of each
+workspace instead: INDEX.md (or workspace_index.file) listing its repos with
+descriptions from their READMEs, the template used, and tags. Only the part
+between the co:index markers is rewritten, so the file can also be your
+README.md. Import and add-to refresh it automatically unless
+workspace_index.manual is set. --workspace-files regenerates it for every
+workspace after rebuilding the index.
+
+--root also writes a page listing all workspaces grouped by owner, with
+links, sizes, and last activity. --format picks markdown (code_root/INDEX.md,
+the default), html (code_root/index.html), or site (a small static site in
+_system/site with a page per workspace); --output overrides the location.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
+		format, err := index.ParsePageFormat(indexRootFormat)
+		if err != nil {
+			return err
+		}
+		if (cmd.Flags().Changed("format") || indexRootOutput != "") && !indexRoot {
+			return fmt.Errorf("--format and --output require --root")
+		}
+
+		if len(args) > 0 {
+			if indexRoot {
+				return fmt.Errorf("--root cannot be combined with workspace arguments")
+			}
+			for _, slug := range args {
+				if !workspace.Exists(cfg, slug) {
+					return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+				}
+			}
+			return writeWorkspaceIndexes(cfg, args)
+		}
+
 		fmt.Printf("Indexing workspaces in %s...\n", cfg.CodeRoot)
 		start := time.Now()
 
@@ -50,11 +94,76 @@ Also syncs project.json repo entries from repos/ by default.`,
 		fmt.Printf("Indexed %d workspaces in %v\n", len(idx.Records), duration.Round(time.Millisecond))
 		fmt.Printf("Index saved to: %s\n", cfg.IndexPath())
 
+		if indexRoot {
+			path, err := writeRootPage(cfg, idx.Records, format)
+			if err != nil {
+				return fmt.Errorf("failed to write index page: %w", err)
+			}
+			fmt.Printf("Index page written to: %s\n", path)
+		}
+
+		if indexWorkspaceFiles {
+			slugs, err := workspace.ListWorkspaces(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+			return writeWorkspaceIndexes(cfg, slugs)
+		}
 		return nil
 	},
 }
 
+// writeRootPage writes the code root index page in the given format and
+// returns where it went.
+func writeRootPage(cfg *config.Config, records []*model.IndexRecord, format index.PageFormat) (string, error) {
+	path := indexRootOutput
+	switch format {
+	case index.PageSite:
+		if path == "" {
+			path = filepath.Join(cfg.SystemDir(), "site")
+		}
+		return filepath.Join(path, "index.html"), index.WriteSite(path, records)
+	case index.PageHTML:
+		if path == "" {
+			path = filepath.Join(cfg.CodeRoot, "index.html")
+		}
+		page, err := index.RenderHTML(records, filepath.Dir(path))
+		if err != nil {
+			return "", err
+		}
+		return path, os.WriteFile(path, []byte(page), fs.FilePerm())
+	default:
+		if path == "" {
+			path = filepath.Join(cfg.CodeRoot, "INDEX.md")
+		}
+		return path, os.WriteFile(path, []byte(index.RenderMarkdown(records, filepath.Dir(path))), fs.FilePerm())
+	}
+}
+
+// writeWorkspaceIndexes regenerates the index file of each workspace.
+func writeWorkspaceIndexes(cfg *config.Config, slugs []string) error {
+	failed := 0
+	for _, slug := range slugs {
+		path, err := workspace.WriteIndex(cfg, cfg.WorkspacePath(slug))
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", slug, err)
+			continue
+		}
+		fmt.Printf("✓ %s\n", path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to write %d workspace index file(s)", failed)
+	}
+	return nil
+}
+
 func init() {
+	takesSlugs(indexCmd, "all")
 	indexCmd.Flags().BoolVar(&indexNoProjectSync, "no-project-sync", false, "skip syncing project.json repos from repos/")
+	indexCmd.Flags().BoolVar(&indexRoot, "root", false, "also write a page listing all workspaces grouped by owner")
+	indexCmd.Flags().StringVar(&indexRootFormat, "format", "markdown", "index page format: markdown, html, or site")
+	indexCmd.Flags().StringVar(&indexRootOutput, "output", "", "where to write the index page (a directory for site)")
+	indexCmd.Flags().BoolVar(&indexWorkspaceFiles, "workspace-files", false, "also regenerate the index file in every workspace")
 	rootCmd.AddCommand(indexCmd)
 }
@@ -1,28 +1,65 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/archive"
 	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/index"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/tui"
 )
 
 var (
-	lsOwner string
-	lsState string
-	lsTag   string
+	lsOwner   string
+	lsState   string
+	lsTag     string
+	lsFormat  string
+	lsColumns string
+	lsFlat    bool
 )
 
 var lsCmd = &cobra.Command{
-	Use:   "ls",
-	Short: "List workspaces",
-	Long:  `Lists all workspaces with optional filtering by owner, state, or tag.`,
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List workspaces",
+	Long: `Lists all workspaces with optional filtering by owner, state, or tag.
+
+Use --format csv or json to export the list for spreadsheets and dashboards,
+and --columns to choose the fields:
+
+  slug, owner, state, path, repos, dirty, size, last_active, last_commit, tags,
+  description
+
+In CSV and JSON, size is in bytes, times are RFC 3339, and CSV tags are
+separated by semicolons. JSON without --columns prints full index records.
+
+The table groups workspaces by owner, most recently active first, under a
+color-coded header with the owner's workspace count and totals of repos,
+dirty repos, and size. Use --flat for one list in index order.
+
+Workspaces moved to cold storage with 'co archive workspace' are listed with
+state "archived" and their archive as the path.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		format := lsFormat
+		if jsonOut {
+			format = "json"
+		}
+		if format != "table" && format != "csv" && format != "json" {
+			return fmt.Errorf("invalid format %q (must be table, csv, or json)", format)
+		}
+		columns, err := index.ParseColumns(lsColumns)
+		if err != nil {
+			return err
+		}
+
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
@@ -37,7 +74,11 @@ var lsCmd = &cobra.Command{
 			return fmt.Errorf("failed to load index: %w", err)
 		}
 
-		records := idx.Records
+		cold, err := archive.ListCold(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to list cold storage: %w", err)
+		}
+		records := archive.WithCold(idx.Records, cold)
 
 		if lsOwner != "" {
 			records = filterByOwner(records, lsOwner)
@@ -49,8 +90,19 @@ var lsCmd = &cobra.Command{
 			records = filterByTag(records, lsTag)
 		}
 
-		if jsonOut {
+		switch {
+		case format == "json" && !cmd.Flags().Changed("columns"):
 			return outputJSON(records)
+		case format == "json":
+			rows := make([]map[string]any, 0, len(records))
+			for _, r := range records {
+				rows = append(rows, index.ColumnValues(r, columns))
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rows)
+		case format == "csv":
+			return index.WriteCSV(os.Stdout, records, columns)
 		}
 
 		if len(records) == 0 {
@@ -58,17 +110,57 @@ var lsCmd = &cobra.Command{
 			return nil
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SLUG\tOWNER\tSTATE\tREPOS\tDIRTY")
-		for _, r := range records {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", r.Slug, r.Owner, r.State, r.RepoCount, r.DirtyRepos)
+		if lsFlat {
+			writeTable(os.Stdout, records, columns)
+			return nil
 		}
-		w.Flush()
-
+		printOwnerGroups(records, columns)
 		return nil
 	},
 }
 
+// writeTable writes records as a table of the given columns.
+func writeTable(out io.Writer, records []*model.IndexRecord, columns []string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := make([]string, len(columns))
+	for i, name := range columns {
+		header[i] = strings.ToUpper(strings.ReplaceAll(name, "_", " "))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, r := range records {
+		row := make([]string, len(columns))
+		for i, name := range columns {
+			row[i] = index.ColumnText(r, name)
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// printOwnerGroups prints records as one table split into owner sections,
+// each under a header with the owner's counts and totals. The table is laid
+// out as a whole, so columns line up across sections.
+func printOwnerGroups(records []*model.IndexRecord, columns []string) {
+	groups := index.GroupByOwner(records)
+	var ordered []*model.IndexRecord
+	for _, g := range groups {
+		ordered = append(ordered, g.Workspaces...)
+	}
+	var buf bytes.Buffer
+	writeTable(&buf, ordered, columns)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	fmt.Println("  " + lines[0])
+	n := 1
+	for _, g := range groups {
+		fmt.Printf("\n%s  %s\n", tui.OwnerStyle(g.Owner).Render(g.Owner), g.Summary())
+		for range g.Workspaces {
+			fmt.Println("  " + lines[n])
+			n++
+		}
+	}
+}
+
 func listArchived(cfg *config.Config) error {
 	entries, err := archive.ListArchives(cfg)
 	if err != nil {
@@ -143,5 +235,8 @@ func init() {
 	lsCmd.Flags().StringVar(&lsOwner, "owner", "", "filter by owner")
 	lsCmd.Flags().StringVar(&lsState, "state", "", "filter by state (active, paused, archived, scratch)")
 	lsCmd.Flags().StringVar(&lsTag, "tag", "", "filter by tag")
+	lsCmd.Flags().StringVar(&lsFormat, "format", "table", "output format (table, csv, json)")
+	lsCmd.Flags().BoolVar(&lsFlat, "flat", false, "list workspaces in one table instead of grouped by owner")
+	lsCmd.Flags().StringVar(&lsColumns, "columns", "", "comma-separated columns to output (default slug,owner,state,repos,dirty,description)")
 	rootCmd.AddCommand(lsCmd)
 }
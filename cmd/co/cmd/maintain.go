@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/maintain"
+)
+
+var maintainFetch bool
+
+var maintainCmd = &cobra.Command{
+	Use:   "maintain",
+	Short: "Run scheduled maintenance",
+	Long: `Runs the upkeep meant for a scheduler such as cron, a systemd timer, or
+launchd, without prompting:
+
+  - prunes archives past each owner's archive_retention_days
+  - purges expired quarantine items
+  - fetches every repo's remotes (with --fetch)
+  - rebuilds the index, refreshing workspace sizes and git state
+  - flags workspaces inactive for archive.suggest_days
+
+Use --json for a machine-readable report. A failing step is reported and
+the remaining steps still run; the command then exits non-zero.
+
+Example crontab entry (every night at 03:00):
+  0 3 * * * co maintain --fetch --json > ~/.cache/co-maintain.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		report := maintain.Run(cfg, maintain.Options{Fetch: maintainFetch})
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			printMaintainReport(report)
+		}
+
+		if len(report.Errors) > 0 {
+			return fmt.Errorf("maintenance finished with %d error(s)", len(report.Errors))
+		}
+		return nil
+	},
+}
+
+func printMaintainReport(report *maintain.Report) {
+	fmt.Printf("Pruned archives: %d\n", len(report.PrunedArchives))
+	for _, path := range report.PrunedArchives {
+		fmt.Printf("  %s\n", path)
+	}
+	fmt.Printf("Purged quarantine items: %d\n", len(report.PurgedQuarantine))
+	if report.FetchSkipped {
+		fmt.Println("Fetch skipped: offline")
+	} else if maintainFetch {
+		fmt.Printf("Fetched repos: %d\n", report.FetchedRepos)
+	}
+	fmt.Printf("Indexed %d workspaces (%s)\n", report.Workspaces, formatBytes(report.TotalSizeBytes))
+
+	if len(report.Stale) > 0 {
+		fmt.Printf("Stale workspaces: %d\n", len(report.Stale))
+		for _, s := range report.Stale {
+			note := ""
+			if s.Archivable {
+				note = " (clean; safe to archive)"
+			}
+			fmt.Printf("  %s: inactive %s%s\n", s.Slug, formatAge(s.InactiveDays), note)
+		}
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", e)
+	}
+	fmt.Printf("Done in %s\n", report.FinishedAt.Sub(report.StartedAt).Round(time.Millisecond))
+}
+
+func init() {
+	maintainCmd.Flags().BoolVar(&maintainFetch, "fetch", false, "fetch the remotes of every repo")
+	rootCmd.AddCommand(maintainCmd)
+}
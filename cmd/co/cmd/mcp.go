@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/mcp"
+	"github.com/tormodhaugland/co/pkg/co"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run a Model Context Protocol server on stdio for AI agents",
+	Long: `Serves co operations as MCP tools over stdin/stdout so AI coding agents can
+locate and organize projects with structured schemas.
+
+Tools:
+  list_workspaces   List workspaces from the index (owner/state/tag filters)
+  find_workspace    Fuzzy-find workspaces by partial name
+  search_code       Semantic code search (needs 'co vector index' and Ollama)
+  list_templates    List available templates
+  create_workspace  Create a workspace, optionally from a template
+  import_folder     Import a folder as a new workspace
+
+Register it with an MCP client by running the command "co mcp", e.g.:
+  {"mcpServers": {"co": {"command": "co", "args": ["mcp"]}}}`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return mcp.New(co.New(cfg)).Serve(ctx, os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
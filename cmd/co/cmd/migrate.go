@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var (
+	migrateFrom  string
+	migrateOwner string
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --from ghq|gopath|flat [root]",
+	Short: "Migrate repos from another layout into workspaces",
+	Long: `Moves repositories from a common existing layout into owner--project workspaces.
+
+Layouts:
+  ghq     <root>/<host>/<owner>/<repo>          (default root: $GHQ_ROOT or ~/ghq)
+  gopath  $GOPATH/src/<host>/<owner>/<repo>     (default root: $GOPATH/src or ~/go/src)
+  flat    <root>/<repo>, e.g. ~/projects, ~/src (root required)
+
+Each repo becomes its own workspace. The owner and project come from the origin
+remote URL (github.com/acme/api -> acme--api), falling back to the owner segment
+of the layout path and then --owner. Repos that would collide with an existing
+workspace or another repo in the migration are skipped.
+
+Always preview first with --dry-run.
+
+Examples:
+  co migrate --from ghq --dry-run
+  co migrate --from gopath
+  co migrate --from flat ~/projects --owner personal --dry-run`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		layout, err := workspace.ParseLayout(migrateFrom)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		root := workspace.DefaultLayoutRoot(layout)
+		if len(args) > 0 {
+			root = args[0]
+		}
+		if root == "" {
+			return fmt.Errorf("a root directory is required for the %s layout", layout)
+		}
+		root, err = filepath.Abs(root)
+		if err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+
+		items, err := workspace.PlanMigration(cfg, layout, root, migrateOwner)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			return printPlan(workspace.MigrationPlan(cfg, layout, items))
+		}
+
+		var onItem func(workspace.MigrationResult)
+		if !jsonOut {
+			onItem = func(r workspace.MigrationResult) {
+				if r.Error != "" {
+					fmt.Fprintf(os.Stderr, "✗ %s: %s\n", r.Item.Slug, r.Error)
+					return
+				}
+				fmt.Printf("✓ %s ← %s\n", r.Item.Slug, r.Item.Source)
+			}
+		}
+		results := workspace.ExecuteMigration(cfg, root, items, onItem)
+
+		if err := rebuildIndex(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to rebuild index: %v\n", err)
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+
+		failed := 0
+		for _, r := range results {
+			if r.Error != "" {
+				failed++
+			}
+		}
+		skipped := len(items) - len(results)
+		fmt.Printf("\nMigrated %d repo(s), %d failed, %d skipped.\n", len(results)-failed, failed, skipped)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().StringVar(&migrateFrom, "from", "", "source layout: ghq, gopath, or flat (required)")
+	migrateCmd.Flags().StringVar(&migrateOwner, "owner", "", "owner for repos without a remote")
+	migrateCmd.MarkFlagRequired("from")
+}
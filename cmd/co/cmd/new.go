@@ -2,27 +2,30 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
-	"github.com/tormodhaugland/co/internal/fs"
-	"github.com/tormodhaugland/co/internal/git"
 	"github.com/tormodhaugland/co/internal/index"
+	"github.com/tormodhaugland/co/internal/lock"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/platform"
 	"github.com/tormodhaugland/co/internal/template"
 	"github.com/tormodhaugland/co/internal/tui"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var (
 	newTemplateName  string
 	newTemplateVars  []string
 	newNoHooks       bool
-	newDryRun        bool
+	newNoCI          bool
 	newListTemplates bool
 	newShowTemplate  string
 )
@@ -41,6 +44,7 @@ Template Support:
       --dry-run          Preview creation without making changes
       --list-templates   List available templates
       --show-template    Show template details`,
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
@@ -67,13 +71,16 @@ Template Support:
 			project = strings.ToLower(args[1])
 			repoURLs = args[2:]
 			selectedTemplate = newTemplateName // Use -t flag if provided
+			if selectedTemplate == "" {
+				selectedTemplate = workspace.DefaultTemplate(cfg, owner)
+			}
 		} else {
 			// Interactive mode: run full prompt flow with template selection
 			templates, _ := template.ListTemplateInfos(cfg.TemplatesDir())
 
-			result, err := tui.RunNewWorkspacePrompt(templates, cfg.TemplatesDir(), cfg.CodeRoot)
+			result, err := tui.RunNewWorkspacePrompt(templates, cfg.TemplatesDir(), cfg)
 			if err != nil {
-				return fmt.Errorf("prompt failed: %w", err)
+				return promptError("prompt failed", err, "run co new <owner> <project> [-t template] [--var name=value]")
 			}
 			if result.Abort {
 				return fmt.Errorf("aborted")
@@ -84,13 +91,17 @@ Template Support:
 			promptedVars = result.Variables
 		}
 
-		slug := owner + "--" + project
-		if !fs.IsValidWorkspaceSlug(slug) {
+		scheme := workspace.SchemeFor(cfg)
+		slug := scheme.Format(owner, project)
+		if !scheme.Valid(slug) {
 			return fmt.Errorf("invalid workspace slug: %s (must be lowercase alphanumeric with hyphens)", slug)
 		}
+		if err := workspace.CheckOwnerPolicy(cfg, owner, project); err != nil {
+			return err
+		}
 
-		if fs.WorkspaceExists(cfg.CodeRoot, slug) && !newDryRun {
-			return fmt.Errorf("workspace already exists: %s", slug)
+		if workspace.Exists(cfg, slug) && !dryRun {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceExists, slug)
 		}
 
 		// If template is specified (via flag or interactive selection), use template-based creation
@@ -105,24 +116,41 @@ Template Support:
 		}
 
 		// Non-template creation (original flow)
-		workspacePath, err := fs.CreateWorkspace(cfg.CodeRoot, slug)
+		ctx, stop := interruptContext()
+		defer stop()
+
+		l, err := lock.Workspace(cfg, slug)
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+
+		layout := cfg.GetRepoLayout(owner)
+		workspacePath, err := workspace.CreateDir(cfg, slug, layout)
 		if err != nil {
 			return fmt.Errorf("failed to create workspace: %w", err)
 		}
 
-		proj := model.NewProject(owner, project)
+		proj := model.NewProject(slug, owner, project)
+		proj.RepoLayout = layout
+		workspace.ApplyOwnerDefaults(cfg, proj)
 
 		for _, url := range repoURLs {
+			if ctx.Err() != nil {
+				fmt.Fprintln(os.Stderr, "Interrupted: skipping the remaining clones")
+				break
+			}
 			repoName := deriveRepoName(url)
-			repoPath := filepath.Join(workspacePath, "repos", repoName)
-
-			fmt.Printf("Cloning %s into repos/%s...\n", url, repoName)
-			if err := git.Clone(url, repoPath); err != nil {
+			if err := cloneOrQueue(ctx, cfg, slug, repoName, url, proj.RepoDir(repoName)); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to clone %s: %v\n", url, err)
 				continue
 			}
 
-			proj.AddRepo(repoName, "repos/"+repoName, url)
+			proj.AddRepo(repoName, filepath.ToSlash(proj.RepoDir(repoName)), url)
+		}
+
+		if err := workspace.RunEventHook(cfg, workspace.EventCreate, proj, workspacePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: create hook failed: %v\n", err)
 		}
 
 		if err := proj.Save(workspacePath); err != nil {
@@ -131,6 +159,12 @@ Template Support:
 
 		fmt.Printf("Created workspace: %s\n", workspacePath)
 
+		written, err := template.WriteShellEnv(cfg, workspacePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		printShellEnvWritten(written, slug)
+
 		// Rebuild the index
 		if err := rebuildIndex(cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to rebuild index: %v\n", err)
@@ -140,29 +174,98 @@ Template Support:
 	},
 }
 
+// printHookEvent prints template hook output as it arrives, prefixed with
+// the hook name, and the exit status and duration of each hook. Output goes
+// to stderr with --json.
+func printHookEvent(e template.HookEvent) {
+	out := os.Stdout
+	if jsonOut || e.Stderr {
+		out = os.Stderr
+	}
+	switch e.Kind {
+	case template.HookStarted:
+		fmt.Fprintf(out, "Running %s hook (%s)...\n", e.Hook, e.Script)
+	case template.HookOutput:
+		fmt.Fprintf(out, "[%s] %s\n", e.Hook, e.Line)
+	case template.HookFinished:
+		r := e.Result
+		elapsed := r.Duration.Round(time.Millisecond)
+		switch {
+		case r.Error == nil:
+			fmt.Fprintf(out, "✓ %s hook finished in %s\n", e.Hook, elapsed)
+		case r.ExitCode != 0:
+			fmt.Fprintf(out, "✗ %s hook exited with code %d after %s\n", e.Hook, r.ExitCode, elapsed)
+		default:
+			fmt.Fprintf(out, "✗ %s hook failed after %s: %v\n", e.Hook, elapsed, r.Error)
+		}
+	}
+}
+
+// cloneOrQueue clones url into dir, relative to the workspace slug, or
+// queues the clone for co resume-network while offline.
+func cloneOrQueue(ctx context.Context, cfg *config.Config, slug, repoName, url, dir string) error {
+	repoPath := filepath.Join(cfg.WorkspacePath(slug), dir)
+	if cfg.IsOffline() {
+		fmt.Printf("Offline: queued clone of %s into %s\n", url, filepath.ToSlash(dir))
+		return template.QueueClone(cfg, slug, repoName, url, repoPath)
+	}
+	fmt.Printf("Cloning %s into %s...\n", url, filepath.ToSlash(dir))
+	if attempts, err := template.GitRetryPolicy(cfg).CloneRepoContext(ctx, url, repoPath); err != nil {
+		return fmt.Errorf("%w (%d attempt(s))", err, attempts)
+	}
+	return nil
+}
+
+// workspaceRepoDir returns the path, relative to the workspace at
+// workspacePath, of a new repo named name under the workspace's layout.
+func workspaceRepoDir(workspacePath, name string) string {
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return model.RepoDir("", name)
+	}
+	return proj.RepoDir(name)
+}
+
+// printStepFailures lists the clones and hooks that failed without aborting
+// the creation.
+func printStepFailures(failures []template.StepFailure) {
+	if len(failures) == 0 {
+		return
+	}
+	fmt.Println("  Failed steps:")
+	for _, f := range failures {
+		fmt.Printf("    - %s %s (%d attempt(s)): %s\n", f.Step, f.Name, f.Attempts, f.Error)
+	}
+}
+
 // createWithTemplateAndVars creates a workspace using pre-collected variables (from TUI prompts).
 func createWithTemplateAndVars(cfg *config.Config, owner, project, templateName string, vars map[string]string, extraRepoURLs []string) error {
 	opts := template.CreateOptions{
 		TemplateName: templateName,
 		Variables:    vars,
 		NoHooks:      newNoHooks,
-		DryRun:       newDryRun,
+		NoCI:         newNoCI,
+		DryRun:       dryRun,
 		Verbose:      true,
+		OnHookEvent:  printHookEvent,
 	}
 
-	result, err := template.CreateWorkspace(cfg, owner, project, opts)
+	ctx, stop := interruptContext()
+	defer stop()
+
+	result, err := template.CreateWorkspaceContext(ctx, cfg, owner, project, opts)
 	if err != nil {
 		return err
 	}
 
 	// Handle extra repo URLs not in template
-	if len(extraRepoURLs) > 0 && !newDryRun {
+	if len(extraRepoURLs) > 0 && !dryRun {
 		for _, url := range extraRepoURLs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			repoName := deriveRepoName(url)
-			repoPath := filepath.Join(result.WorkspacePath, "repos", repoName)
-
-			fmt.Printf("Cloning %s into repos/%s...\n", url, repoName)
-			if err := git.Clone(url, repoPath); err != nil {
+			if err := cloneOrQueue(ctx, cfg, result.WorkspaceSlug, repoName, url, workspaceRepoDir(result.WorkspacePath, repoName)); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to clone %s: %v\n", url, err)
 			}
 		}
@@ -175,7 +278,7 @@ func createWithTemplateAndVars(cfg *config.Config, owner, project, templateName
 		return enc.Encode(result)
 	}
 
-	if newDryRun {
+	if dryRun {
 		fmt.Println("Dry run - no changes made")
 		fmt.Printf("Would create workspace: %s\n", result.WorkspacePath)
 		fmt.Printf("Template: %s\n", result.TemplateUsed)
@@ -197,12 +300,20 @@ func createWithTemplateAndVars(cfg *config.Config, owner, project, templateName
 	if len(result.HooksRun) > 0 {
 		fmt.Printf("  Hooks run: %s\n", strings.Join(result.HooksRun, ", "))
 	}
+	if len(result.CIRepos) > 0 {
+		fmt.Printf("  CI set up: %s\n", strings.Join(result.CIRepos, ", "))
+	}
+	if len(result.PendingClones) > 0 {
+		fmt.Printf("  Clones queued (offline): %s; run 'co resume-network' when online\n", strings.Join(result.PendingClones, ", "))
+	}
+	printStepFailures(result.Failures)
 	if len(result.Warnings) > 0 {
 		fmt.Println("  Warnings:")
 		for _, w := range result.Warnings {
 			fmt.Printf("    - %s\n", w)
 		}
 	}
+	printShellEnvWritten(result.ShellEnvFiles, result.WorkspaceSlug)
 
 	// Rebuild the index
 	if err := rebuildIndex(cfg); err != nil {
@@ -223,15 +334,31 @@ func createWithTemplate(cfg *config.Config, owner, project string, extraRepoURLs
 	providedVars := parseVarFlags(newTemplateVars)
 
 	// Get built-in variables for checking
-	builtins := template.GetBuiltinVariables(owner, project, cfg.WorkspacePath(owner+"--"+project), cfg.CodeRoot)
+	slug := workspace.SchemeFor(cfg).Format(owner, project)
+	builtins := template.GetBuiltinVariables(owner, project, slug, cfg.WorkspacePath(slug), cfg.CodeRoot)
+
+	if err := template.CheckProvidedVars(tmpl, providedVars, builtins); err != nil {
+		return err
+	}
 
 	// Check for missing required variables and prompt
 	missing := template.GetMissingRequiredVars(tmpl, providedVars, builtins)
+	if len(missing) > 0 && !platform.IsTerminal(os.Stdin) {
+		names := make([]string, len(missing))
+		for i, v := range missing {
+			names[i] = v.Name
+		}
+		return fmt.Errorf("template '%s' requires %s; stdin is not a terminal to ask, so pass them with --var name=value", newTemplateName, strings.Join(names, ", "))
+	}
 	if len(missing) > 0 {
 		fmt.Printf("Template '%s' requires the following variables:\n\n", newTemplateName)
 		reader := bufio.NewReader(os.Stdin)
 
 		for _, v := range missing {
+			// Answers so far may decide that v does not apply
+			if !template.VarApplies(v, template.KnownValues(tmpl, providedVars, builtins)) {
+				continue
+			}
 			fmt.Printf("%s", v.Name)
 			if v.Description != "" {
 				fmt.Printf(" (%s)", v.Description)
@@ -259,23 +386,28 @@ func createWithTemplate(cfg *config.Config, owner, project string, extraRepoURLs
 		TemplateName: newTemplateName,
 		Variables:    providedVars,
 		NoHooks:      newNoHooks,
-		DryRun:       newDryRun,
+		NoCI:         newNoCI,
+		DryRun:       dryRun,
 		Verbose:      true,
+		OnHookEvent:  printHookEvent,
 	}
 
-	result, err := template.CreateWorkspace(cfg, owner, project, opts)
+	ctx, stop := interruptContext()
+	defer stop()
+
+	result, err := template.CreateWorkspaceContext(ctx, cfg, owner, project, opts)
 	if err != nil {
 		return err
 	}
 
 	// Handle extra repo URLs not in template
-	if len(extraRepoURLs) > 0 && !newDryRun {
+	if len(extraRepoURLs) > 0 && !dryRun {
 		for _, url := range extraRepoURLs {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			repoName := deriveRepoName(url)
-			repoPath := filepath.Join(result.WorkspacePath, "repos", repoName)
-
-			fmt.Printf("Cloning %s into repos/%s...\n", url, repoName)
-			if err := git.Clone(url, repoPath); err != nil {
+			if err := cloneOrQueue(ctx, cfg, result.WorkspaceSlug, repoName, url, workspaceRepoDir(result.WorkspacePath, repoName)); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to clone %s: %v\n", url, err)
 			}
 		}
@@ -288,7 +420,7 @@ func createWithTemplate(cfg *config.Config, owner, project string, extraRepoURLs
 		return enc.Encode(result)
 	}
 
-	if newDryRun {
+	if dryRun {
 		fmt.Println("Dry run - no changes made")
 		fmt.Printf("Would create workspace: %s\n", result.WorkspacePath)
 		fmt.Printf("Template: %s\n", result.TemplateUsed)
@@ -310,12 +442,20 @@ func createWithTemplate(cfg *config.Config, owner, project string, extraRepoURLs
 	if len(result.HooksRun) > 0 {
 		fmt.Printf("  Hooks run: %s\n", strings.Join(result.HooksRun, ", "))
 	}
+	if len(result.CIRepos) > 0 {
+		fmt.Printf("  CI set up: %s\n", strings.Join(result.CIRepos, ", "))
+	}
+	if len(result.PendingClones) > 0 {
+		fmt.Printf("  Clones queued (offline): %s; run 'co resume-network' when online\n", strings.Join(result.PendingClones, ", "))
+	}
+	printStepFailures(result.Failures)
 	if len(result.Warnings) > 0 {
 		fmt.Println("  Warnings:")
 		for _, w := range result.Warnings {
 			fmt.Printf("    - %s\n", w)
 		}
 	}
+	printShellEnvWritten(result.ShellEnvFiles, result.WorkspaceSlug)
 
 	// Rebuild the index
 	if err := rebuildIndex(cfg); err != nil {
@@ -413,7 +553,7 @@ func init() {
 	newCmd.Flags().StringVarP(&newTemplateName, "template", "t", "", "Template to use for workspace creation")
 	newCmd.Flags().StringArrayVarP(&newTemplateVars, "var", "v", nil, "Set template variable (key=value)")
 	newCmd.Flags().BoolVar(&newNoHooks, "no-hooks", false, "Skip running lifecycle hooks")
-	newCmd.Flags().BoolVar(&newDryRun, "dry-run", false, "Preview creation without making changes")
+	newCmd.Flags().BoolVar(&newNoCI, "no-ci", false, "Skip the template's CI setup (workflows, Actions, secrets)")
 	newCmd.Flags().BoolVar(&newListTemplates, "list-templates", false, "List available templates")
 	newCmd.Flags().StringVar(&newShowTemplate, "show-template", "", "Show template details")
 }
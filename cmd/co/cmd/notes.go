@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/notes"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var notesInitAll bool
+
+var notesCmd = &cobra.Command{
+	Use:   "notes <workspace-slug>",
+	Short: "Open or create workspace notes in a notes vault",
+	Long: `Connects workspaces to notes in an Obsidian vault or a directory of org files.
+
+Each workspace's note is either a note linked with 'co notes link', or a stub
+at <vault>/<dir>/<slug>.md (.org with "format": "org"). Running co notes with a
+workspace opens its note, writing the stub first if it does not exist yet.
+
+  {
+    "notes": { "vault": "~/Notes", "dir": "Workspaces", "open": "obsidian" }
+  }
+
+"open" is the command notes are opened with; "obsidian" opens them through an
+obsidian:// link. Without it, the editor is used, then the system opener.
+
+Subcommands:
+  co notes <workspace>                # Open the workspace's note
+  co notes init <workspace...>        # Write note stubs (--all for every workspace)
+  co notes link <workspace> <note>    # Link an existing note instead of a stub
+  co notes path <workspace>           # Print the note path`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !workspace.Exists(cfg, args[0]) {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, args[0])
+		}
+
+		path, created, err := notes.Stub(cfg, cfg.WorkspacePath(args[0]))
+		if err != nil {
+			return err
+		}
+		if created {
+			fmt.Printf("Created %s\n", path)
+		}
+
+		openCmd := notes.OpenCommand(cfg, path)
+		openCmd.Stdin = os.Stdin
+		openCmd.Stdout = os.Stdout
+		openCmd.Stderr = os.Stderr
+		return openCmd.Run()
+	},
+}
+
+var notesInitCmd = &cobra.Command{
+	Use:   "init [workspace...]",
+	Short: "Write note stubs into the notes vault",
+	Long: `Writes a note stub for each workspace whose note does not exist yet.
+Existing notes, including linked ones, are never overwritten.`,
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		slugs := args
+		if notesInitAll {
+			if len(slugs) > 0 {
+				return fmt.Errorf("--all cannot be combined with workspace arguments")
+			}
+			if slugs, err = workspace.ListWorkspaces(cfg); err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+		} else if len(slugs) == 0 {
+			return fmt.Errorf("specify workspaces or --all")
+		}
+		for _, slug := range slugs {
+			if !workspace.Exists(cfg, slug) {
+				return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+			}
+		}
+
+		if dryRun {
+			plan := model.NewPlan(fmt.Sprintf("Write note stubs for %d workspace(s)", len(slugs)))
+			for _, slug := range slugs {
+				proj, err := model.LoadProject(filepath.Join(cfg.WorkspacePath(slug), "project.json"))
+				if err != nil {
+					return fmt.Errorf("%s: failed to load project.json: %w", slug, err)
+				}
+				path, err := notes.Path(cfg, proj)
+				if err != nil {
+					return err
+				}
+				if _, err := os.Stat(path); err == nil {
+					plan.Skip(model.ActionWrite, path, "", "note exists")
+				} else {
+					plan.Add(model.ActionWrite, path, "", slug)
+				}
+			}
+			return printPlan(plan)
+		}
+
+		type stubResult struct {
+			Slug    string `json:"slug"`
+			Path    string `json:"path"`
+			Created bool   `json:"created"`
+		}
+		results := []stubResult{}
+		for _, slug := range slugs {
+			path, created, err := notes.Stub(cfg, cfg.WorkspacePath(slug))
+			if err != nil {
+				return fmt.Errorf("%s: %w", slug, err)
+			}
+			results = append(results, stubResult{Slug: slug, Path: path, Created: created})
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(results)
+		}
+		for _, r := range results {
+			if r.Created {
+				fmt.Printf("✓ %s: created %s\n", r.Slug, r.Path)
+			} else {
+				fmt.Printf("- %s: %s exists\n", r.Slug, r.Path)
+			}
+		}
+		return nil
+	},
+}
+
+var notesLinkCmd = &cobra.Command{
+	Use:   "link <workspace-slug> <note>",
+	Short: "Link an existing note to a workspace",
+	Long: `Records an existing note in the workspace's project.json, so co notes and
+the dashboard open it instead of a stub. Notes inside the vault are stored
+relative to it.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !workspace.Exists(cfg, args[0]) {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, args[0])
+		}
+
+		path, err := notes.Link(cfg, cfg.WorkspacePath(args[0]), args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Linked %s to %s\n", args[0], path)
+		return nil
+	},
+}
+
+var notesPathCmd = &cobra.Command{
+	Use:   "path <workspace-slug>",
+	Short: "Print the path of a workspace's note",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !workspace.Exists(cfg, args[0]) {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, args[0])
+		}
+
+		proj, err := model.LoadProject(filepath.Join(cfg.WorkspacePath(args[0]), "project.json"))
+		if err != nil {
+			return fmt.Errorf("failed to load project.json: %w", err)
+		}
+		path, err := notes.Path(cfg, proj)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			_, statErr := os.Stat(path)
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]any{
+				"slug":   args[0],
+				"path":   path,
+				"exists": statErr == nil,
+				"linked": proj.Note != "",
+			})
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+func init() {
+	takesSlugs(notesCmd, "first")
+	takesSlugs(notesInitCmd, "all")
+	takesSlugs(notesLinkCmd, "first")
+	takesSlugs(notesPathCmd, "first")
+	rootCmd.AddCommand(notesCmd)
+	notesCmd.AddCommand(notesInitCmd)
+	notesCmd.AddCommand(notesLinkCmd)
+	notesCmd.AddCommand(notesPathCmd)
+	notesInitCmd.Flags().BoolVar(&notesInitAll, "all", false, "write stubs for every workspace")
+}
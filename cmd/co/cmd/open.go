@@ -8,7 +8,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
-	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var openCmd = &cobra.Command{
@@ -24,8 +24,8 @@ var openCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		if !fs.WorkspaceExists(cfg.CodeRoot, slug) {
-			return fmt.Errorf("workspace not found: %s", slug)
+		if !workspace.Exists(cfg, slug) {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
 		}
 
 		workspacePath := cfg.WorkspacePath(slug)
@@ -47,5 +47,6 @@ var openCmd = &cobra.Command{
 }
 
 func init() {
+	takesSlugs(openCmd, "first")
 	rootCmd.AddCommand(openCmd)
 }
@@ -20,10 +20,8 @@ var (
 	partialShowFiles     bool
 	partialApplyVars     []string
 	partialApplyConflict string
-	partialApplyDryRun   bool
 	partialApplyNoHooks  bool
 	partialApplyForce    bool
-	partialApplyYes      bool
 )
 
 var partialCmd = &cobra.Command{
@@ -217,7 +215,8 @@ Flags:
       --no-hooks          Skip lifecycle hooks
       --force             Apply even if prerequisites fail
   -y, --yes               Accept all prompts automatically`,
-	Args: cobra.RangeArgs(1, 2),
+	Args:        cobra.RangeArgs(1, 2),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
@@ -268,7 +267,7 @@ Flags:
 				tmplVars := partialVarsToTemplate(p.Variables)
 				result, err := tui.RunVariablePromptWithSkip(tmplVars, seed, skip)
 				if err != nil {
-					return fmt.Errorf("variable prompt failed: %w", err)
+					return promptError("variable prompt failed", err, "pass them with --var name=value")
 				}
 				if result.Abort {
 					return fmt.Errorf("variable prompt cancelled")
@@ -291,10 +290,10 @@ Flags:
 			TargetPath:       absTargetPath,
 			Variables:        vars,
 			ConflictStrategy: partialApplyConflict,
-			DryRun:           partialApplyDryRun,
+			DryRun:           dryRun,
 			NoHooks:          partialApplyNoHooks,
 			Force:            partialApplyForce,
-			Yes:              partialApplyYes,
+			Yes:              assumeYes,
 		}
 
 		// Apply the partial
@@ -310,7 +309,7 @@ Flags:
 			return enc.Encode(result)
 		}
 
-		return formatApplyResult(result, partialApplyDryRun)
+		return formatApplyResult(result, dryRun)
 	},
 }
 
@@ -526,10 +525,8 @@ func init() {
 	// Apply flags
 	partialApplyCmd.Flags().StringArrayVarP(&partialApplyVars, "var", "v", nil, "set variable (key=value, can be repeated)")
 	partialApplyCmd.Flags().StringVar(&partialApplyConflict, "conflict", "", "conflict strategy (prompt|skip|overwrite|backup|merge)")
-	partialApplyCmd.Flags().BoolVar(&partialApplyDryRun, "dry-run", false, "preview changes without applying")
 	partialApplyCmd.Flags().BoolVar(&partialApplyNoHooks, "no-hooks", false, "skip lifecycle hooks")
 	partialApplyCmd.Flags().BoolVar(&partialApplyForce, "force", false, "apply even if prerequisites fail")
-	partialApplyCmd.Flags().BoolVarP(&partialApplyYes, "yes", "y", false, "accept all prompts automatically")
 }
 
 func listPartialFiles(partialPath string, p *partial.Partial) ([]string, error) {
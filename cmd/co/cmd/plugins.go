@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/plugin"
+)
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List installed plugins",
+	Long: `Lists plugins: executables named co-<name> on PATH, which run as
+'co <name>' the way git runs git-<name>. Built-in commands take precedence
+over plugins of the same name.
+
+A plugin may ship a manifest, co-<name>.json next to the executable:
+
+  {
+    "short": "Deploy a workspace",
+    "actions": [
+      {"key": "d", "label": "Deploy", "args": ["run", "{{SLUG}}"]}
+    ]
+  }
+
+"short" is shown in 'co help'. Each action is added to the dashboard's plugin
+menu (press p) and runs the plugin with its args, where {{SLUG}}, {{OWNER}},
+and {{PATH}} name the selected workspace. Plugins get CO_CODE_ROOT in their
+environment, actions also CO_WORKSPACE and CO_WORKSPACE_PATH.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plugins := plugin.Discover(os.Getenv("PATH"))
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if plugins == nil {
+				plugins = []plugin.Plugin{}
+			}
+			return enc.Encode(plugins)
+		}
+		if len(plugins) == 0 {
+			fmt.Println("No plugins found (executables named co-<name> on PATH)")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPATH\tACTIONS")
+		for _, p := range plugins {
+			labels := make([]string, len(p.Manifest.Actions))
+			for i, a := range p.Manifest.Actions {
+				labels[i] = a.Label
+			}
+			name := p.Name
+			if builtinCommand(p.Name) {
+				name += " (shadowed)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, p.Path, strings.Join(labels, ", "))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// builtinCommand reports whether name is a built-in command or alias.
+func builtinCommand(name string) bool {
+	if name == "help" || name == "completion" {
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.Annotations[pluginAnnotation] == "" && (c.Name() == name || c.HasAlias(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginsNeeded reports whether co run with args may need the plugin
+// commands: when args do not name a built-in command, and for help, which
+// lists them. Built-in commands, such as the prompt commands that run on
+// every shell prompt, skip searching PATH for plugins.
+func pluginsNeeded(args []string) bool {
+	if _, _, err := rootCmd.Find(args); err != nil {
+		return true
+	}
+	return slices.Contains(args, "--help") || slices.Contains(args, "-h")
+}
+
+// pluginAnnotation marks the commands added for plugins.
+const pluginAnnotation = "co/plugin"
+
+// addPluginCommands adds a subcommand for every plugin on PATH that does not
+// clash with a built-in command. Arguments are passed to the plugin as is.
+func addPluginCommands() {
+	for _, p := range plugin.Discover(os.Getenv("PATH")) {
+		if builtinCommand(p.Name) {
+			continue
+		}
+		p := p
+		short := p.Manifest.Short
+		if short == "" {
+			short = "Plugin " + p.Path
+		}
+		rootCmd.AddCommand(&cobra.Command{
+			Use:                p.Name,
+			Short:              short,
+			DisableFlagParsing: true,
+			Annotations:        map[string]string{pluginAnnotation: p.Path},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := config.Load(cfgFile)
+				if err != nil {
+					return fmt.Errorf("failed to load config: %w", err)
+				}
+				c := p.Command(cfg, cfgFile, args...)
+				c.Stdin = os.Stdin
+				c.Stdout = os.Stdout
+				c.Stderr = os.Stderr
+				if err := c.Run(); err != nil {
+					var exitErr *exec.ExitError
+					if errors.As(err, &exitErr) {
+						os.Exit(exitErr.ExitCode())
+					}
+					return fmt.Errorf("plugin %s failed: %w", p.Name, err)
+				}
+				return nil
+			},
+		})
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+}
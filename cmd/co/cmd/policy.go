@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var policyCheckAll bool
+
+type policyCheckResult struct {
+	Workspace  string                      `json:"workspace"`
+	Violations []workspace.PolicyViolation `json:"violations"`
+	Error      string                      `json:"error,omitempty"`
+}
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Check repos against the repo policy",
+	Long: `Checks the repos of workspaces against the repo policy in the config.
+
+  {
+    "repo_policy": {
+      "branch_pattern": "(feature|fix|chore)/[a-z0-9-]+",
+      "commit_pattern": "^(feat|fix|chore|docs|refactor|test)(\\(.+\\))?: ",
+      "commit_depth": 20,
+      "required_files": ["LICENSE", "CODEOWNERS|.github/CODEOWNERS"],
+      "default_branch": "main",
+      "protect_default_branch": true
+    }
+  }
+
+An owner's policy in "owners" can override any of these for its workspaces.
+co index records violations, and the workspace browser shows them.
+
+Subcommands:
+  co policy check <slug>...    # Check some workspaces
+  co policy check --all        # Check every workspace`,
+}
+
+var policyCheckCmd = &cobra.Command{
+	Use:   "check [workspace-slug...]",
+	Short: "Report repos that break the repo policy",
+	Long: `Reports the repos of workspaces that break the repo policy: branches not
+matching branch_pattern (the default branch is exempt), recent commit
+subjects not matching commit_pattern (merges are exempt), missing
+required_files, a default branch other than default_branch, and, with
+protect_default_branch, commits on the default branch that are not on the
+remote.
+
+Exits non-zero when there are violations, so it can run in CI.
+
+Examples:
+  co policy check acme--platform
+  co policy check --all --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		slugs := args
+		if policyCheckAll {
+			if len(slugs) > 0 {
+				return fmt.Errorf("--all cannot be combined with workspace arguments")
+			}
+			if slugs, err = workspace.ListWorkspaces(cfg); err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+		} else if len(slugs) == 0 {
+			return fmt.Errorf("specify workspaces or --all")
+		}
+		for _, slug := range slugs {
+			if !workspace.Exists(cfg, slug) {
+				return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+			}
+		}
+
+		results := []policyCheckResult{}
+		violations, failed := 0, 0
+		for _, slug := range slugs {
+			result := policyCheckResult{Workspace: slug, Violations: []workspace.PolicyViolation{}}
+			found, err := checkWorkspacePolicy(cfg, slug)
+			if err != nil {
+				result.Error = err.Error()
+				failed++
+			} else if len(found) > 0 {
+				result.Violations = found
+				violations += len(found)
+			}
+			results = append(results, result)
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return err
+			}
+		} else {
+			for _, r := range results {
+				switch {
+				case r.Error != "":
+					fmt.Fprintf(os.Stderr, "✗ %s: %s\n", r.Workspace, r.Error)
+				case len(r.Violations) == 0:
+					fmt.Printf("✓ %s\n", r.Workspace)
+				default:
+					fmt.Printf("✗ %s\n", r.Workspace)
+					for _, v := range r.Violations {
+						fmt.Printf("    %s\n", v)
+					}
+				}
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d workspace(s) could not be checked", failed)
+		}
+		if violations > 0 {
+			return fmt.Errorf("%d policy violation(s)", violations)
+		}
+		return nil
+	},
+}
+
+func checkWorkspacePolicy(cfg *config.Config, slug string) ([]workspace.PolicyViolation, error) {
+	workspacePath := cfg.WorkspacePath(slug)
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project.json: %w", err)
+	}
+	return workspace.CheckRepoPolicy(cfg, workspacePath, proj)
+}
+
+func init() {
+	takesSlugs(policyCheckCmd, "all")
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyCheckCmd)
+	policyCheckCmd.Flags().BoolVar(&policyCheckAll, "all", false, "check every workspace")
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var (
+	promptFormat        string
+	promptSegmentFormat string
+)
+
+const promptPlaceholders = `{icon}, {name} (the display name or the slug), {slug}, {owner}, {state},
+{repos}, {dirty} ("±2" with two dirty repos, empty when clean), and
+{dirty_count}`
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt [dir]",
+	Short: "Print the current workspace for a shell prompt",
+	Long: `Prints a prompt segment for the workspace the current directory (or dir)
+is in: its icon and display name, or its slug when it has no display name.
+Outside a workspace it prints nothing, so prompt themes can hide the segment.
+
+--format chooses the segment with the placeholders ` + promptPlaceholders + `.
+Surrounding spaces are trimmed, so "{icon} {name}" works without an icon.
+Repo counts come from the index, as of the last co index.
+
+Set the icon and display name with co describe --icon and --name. See also
+co prompt-segment, which shows the slug and dirty repos.
+
+Examples:
+  co prompt                              # 🚀 Billing API
+  co prompt --format "{slug}"            # acme--billing
+  co prompt --format "{icon} {owner}/{name}"
+
+Starship (~/.config/starship.toml):
+  [custom.co]
+  command = "co prompt"
+  when = true
+  format = "[$output]($style) "
+
+Powerlevel10k (~/.p10k.zsh), with co added to the prompt elements:
+  function prompt_co() {
+    local segment=$(co prompt 2>/dev/null)
+    [[ -n $segment ]] && p10k segment -t "$segment"
+  }`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printPromptSegment(args, promptFormat)
+	},
+}
+
+var promptSegmentCmd = &cobra.Command{
+	Use:   "prompt-segment [dir]",
+	Short: "Print the current workspace and its dirty repos for a shell prompt",
+	Long: `Prints a prompt segment for the workspace $PWD (or dir) is in: its slug
+and, when repos have uncommitted changes, their count, as in "acme--api ±2".
+Outside a workspace it prints nothing.
+
+It is meant to run on every prompt: the workspace is found by walking up to
+its project.json, and the dirty count is read from the index without running
+git, so it is as fresh as the last co index.
+
+--format takes the placeholders of co prompt: ` + promptPlaceholders + `.
+
+Examples:
+  co prompt-segment                           # acme--api ±2
+  co prompt-segment --format "{slug}"         # acme--api
+  co prompt-segment --format "{owner} {dirty}"
+
+Starship (~/.config/starship.toml):
+  [custom.co]
+  command = "co prompt-segment"
+  when = true
+  format = "[$output]($style) "`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printPromptSegment(args, promptSegmentFormat)
+	},
+}
+
+// printPromptSegment prints the segment of the workspace that dir, or $PWD
+// without args, is in, and nothing outside a workspace.
+func printPromptSegment(args []string, format string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dir, err := promptDir(args)
+	if err != nil {
+		return err
+	}
+	workspacePath := workspace.Containing(cfg, dir)
+	if workspacePath == "" {
+		return nil
+	}
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load project.json: %w", err)
+	}
+
+	// The index is only read when the segment needs it
+	var record *model.IndexRecord
+	if jsonOut || strings.Contains(format, "{repos}") || strings.Contains(format, "{dirty") {
+		if record, err = model.LookupRecord(cfg.IndexPath(), proj.Slug); err != nil {
+			return fmt.Errorf("failed to read index: %w", err)
+		}
+	}
+
+	if jsonOut {
+		out := map[string]any{
+			"slug":         proj.Slug,
+			"owner":        proj.Owner,
+			"icon":         proj.Icon,
+			"display_name": proj.DisplayName,
+			"path":         workspacePath,
+		}
+		if record != nil {
+			out["repos"] = record.RepoCount
+			out["dirty_repos"] = record.DirtyRepos
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	name := proj.DisplayName
+	if name == "" {
+		name = proj.Slug
+	}
+	repos, dirty, dirtyCount := "", "", ""
+	if record != nil {
+		repos = strconv.Itoa(record.RepoCount)
+		dirtyCount = strconv.Itoa(record.DirtyRepos)
+		if record.DirtyRepos > 0 {
+			dirty = "±" + dirtyCount
+		}
+	}
+	segment := strings.NewReplacer(
+		"{icon}", proj.Icon,
+		"{name}", name,
+		"{slug}", proj.Slug,
+		"{owner}", proj.Owner,
+		"{state}", string(proj.State),
+		"{repos}", repos,
+		"{dirty}", dirty,
+		"{dirty_count}", dirtyCount,
+	).Replace(format)
+	fmt.Println(strings.TrimSpace(segment))
+	return nil
+}
+
+// promptDir returns the directory to find the workspace of: dir when given,
+// or $PWD, which shells keep up to date, falling back to the working
+// directory.
+func promptDir(args []string) (string, error) {
+	if len(args) == 1 {
+		return filepath.Abs(args[0])
+	}
+	if pwd := os.Getenv("PWD"); filepath.IsAbs(pwd) {
+		return pwd, nil
+	}
+	return os.Getwd()
+}
+
+func init() {
+	promptCmd.Flags().StringVar(&promptFormat, "format", "{icon} {name}", "segment format (see co prompt --help for placeholders)")
+	promptSegmentCmd.Flags().StringVar(&promptSegmentFormat, "format", "{slug} {dirty}", "segment format (see co prompt --help for placeholders)")
+	rootCmd.AddCommand(promptCmd)
+	rootCmd.AddCommand(promptSegmentCmd)
+}
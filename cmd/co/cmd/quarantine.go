@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/quarantine"
+)
+
+var (
+	quarantineRestoreTo string
+	quarantinePurgeAll  bool
+)
+
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "Manage quarantined deletes",
+	Long: `Lists, restores, and purges folders held in quarantine.
+
+When quarantine is enabled in the config, co moves folders it deletes
+(co tmp rm, co tmp clean, --delete on archive and stash, and delete in the
+import browser) to _system/quarantine instead of removing them. Items are
+purged automatically once they are older than the configured number of days.
+
+  {
+    "quarantine": { "enabled": true, "days": 30 }
+  }
+
+Subcommands:
+  co quarantine list               # List quarantined items
+  co quarantine restore <id>       # Move an item back to where it was
+  co quarantine purge              # Purge expired items now
+  co quarantine purge <id>         # Purge one item
+  co quarantine purge --all        # Purge everything`,
+	Args: cobra.NoArgs,
+	RunE: runQuarantineList,
+}
+
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List quarantined items",
+	Args:  cobra.NoArgs,
+	RunE:  runQuarantineList,
+}
+
+var quarantineRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Restore a quarantined item",
+	Long: `Moves a quarantined item back to its original path, or to --to.
+Restoring never overwrites an existing path.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		entry, err := quarantine.Restore(cfg, args[0], quarantineRestoreTo)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entry)
+		}
+		fmt.Printf("Restored %s to %s\n", entry.Name, entry.Path)
+		return nil
+	},
+}
+
+var quarantinePurgeCmd = &cobra.Command{
+	Use:   "purge [id]",
+	Short: "Permanently remove quarantined items",
+	Long: `Permanently removes quarantined items.
+
+Without arguments, purges items older than the configured retention.
+With an id, purges that item; with --all, purges everything.
+Use --dry-run to list what would be purged.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var targets []quarantine.Entry
+		switch {
+		case len(args) == 1:
+			entry, err := quarantine.Get(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			targets = []quarantine.Entry{*entry}
+		default:
+			entries, err := quarantine.List(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to list quarantine: %w", err)
+			}
+			now := time.Now()
+			for _, entry := range entries {
+				if quarantinePurgeAll || entry.Expired(now) {
+					targets = append(targets, entry)
+				}
+			}
+		}
+
+		if dryRun {
+			plan := model.NewPlan(fmt.Sprintf("Purge %d quarantined item(s)", len(targets)))
+			for _, entry := range targets {
+				plan.Add(model.ActionDelete, entry.Path, "", "from "+entry.OriginalPath)
+			}
+			return printPlan(plan)
+		}
+
+		// Expired items were already due; anything else is an explicit early purge
+		if len(args) == 1 || quarantinePurgeAll {
+			if len(targets) == 0 {
+				fmt.Println("Quarantine is empty.")
+				return nil
+			}
+			ok, err := confirmOp(cfg, config.ConfirmDelete, fmt.Sprintf("Permanently delete %d quarantined item(s)?", len(targets)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Purge cancelled.")
+				return nil
+			}
+		}
+
+		purged := []quarantine.Entry{}
+		for _, entry := range targets {
+			if _, err := quarantine.Purge(cfg, entry.ID); err != nil {
+				return err
+			}
+			purged = append(purged, entry)
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(purged)
+		}
+		if len(purged) == 0 {
+			fmt.Println("Nothing to purge.")
+			return nil
+		}
+		for _, entry := range purged {
+			fmt.Printf("Purged %s (%s)\n", entry.ID, entry.OriginalPath)
+		}
+		return nil
+	},
+}
+
+func runQuarantineList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	entries, err := quarantine.List(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to list quarantine: %w", err)
+	}
+
+	if jsonOut {
+		if entries == nil {
+			entries = []quarantine.Entry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if !quarantine.Enabled(cfg) {
+		fmt.Println("Quarantine is disabled; deletes are permanent. Set \"quarantine\": {\"enabled\": true} in the config to enable it.")
+	}
+	if len(entries) == 0 {
+		fmt.Println("Quarantine is empty.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDELETED\tPURGE\tSIZE\tORIGINAL PATH")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.ID,
+			entry.DeletedAt.Local().Format("2006-01-02"),
+			entry.PurgeAt.Local().Format("2006-01-02"),
+			formatBytes(entry.Size),
+			entry.OriginalPath)
+	}
+	return w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(quarantineCmd)
+	quarantineCmd.AddCommand(quarantineListCmd)
+	quarantineCmd.AddCommand(quarantineRestoreCmd)
+	quarantineCmd.AddCommand(quarantinePurgeCmd)
+	quarantineRestoreCmd.Flags().StringVar(&quarantineRestoreTo, "to", "", "restore to this path instead of the original location")
+	quarantinePurgeCmd.Flags().BoolVar(&quarantinePurgeAll, "all", false, "purge every item, not just expired ones")
+}
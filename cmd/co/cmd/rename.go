@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
@@ -45,7 +44,7 @@ Examples:
 			// Interactive mode
 			result, err := tui.RunRenamePrompt(cfg)
 			if err != nil {
-				return err
+				return promptError("rename prompt failed", err, "run co rename <slug> <new-owner> <new-project>")
 			}
 			if result.Cancelled {
 				fmt.Println("Rename cancelled")
@@ -87,7 +86,7 @@ Examples:
 		idx.Remove(result.OldSlug)
 
 		// Add new entry by scanning
-		record, err := scanWorkspace(result.NewPath, result.NewSlug)
+		record, err := scanWorkspace(cfg, result.NewPath, result.NewSlug)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to scan renamed workspace: %v\n", err)
 		} else {
@@ -103,20 +102,23 @@ Examples:
 }
 
 // scanWorkspace scans a single workspace and returns an index record.
-func scanWorkspace(workspacePath, slug string) (*model.IndexRecord, error) {
-	parts := strings.SplitN(slug, "--", 2)
-	if len(parts) != 2 {
+func scanWorkspace(cfg *config.Config, workspacePath, slug string) (*model.IndexRecord, error) {
+	parsed, ok := workspace.SchemeFor(cfg).Parse(slug)
+	if !ok {
 		return nil, fmt.Errorf("invalid slug format: %s", slug)
 	}
 
 	record := model.NewIndexRecord(slug, workspacePath)
-	record.Owner = parts[0]
+	record.Owner = parsed.Owner
 
 	// Load project.json if it exists
 	projectPath := filepath.Join(workspacePath, "project.json")
 	if proj, err := model.LoadProject(projectPath); err == nil {
 		record.State = proj.State
 		record.Tags = proj.Tags
+		record.Description = proj.Description
+		record.Icon = proj.Icon
+		record.DisplayName = proj.DisplayName
 
 		// Scan repos
 		for _, repo := range proj.Repos {
@@ -146,5 +148,6 @@ func scanWorkspace(workspacePath, slug string) (*model.IndexRecord, error) {
 }
 
 func init() {
+	takesSlugs(renameCmd, "first")
 	rootCmd.AddCommand(renameCmd)
 }
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/template"
+)
+
+var resumeNetworkList bool
+
+var resumeNetworkCmd = &cobra.Command{
+	Use:   "resume-network",
+	Short: "Run the network work queued while offline",
+	Long: `Runs the network work deferred while co was offline: clones of template
+repos and repo URLs skipped by co new --offline.
+
+Clones that fail stay queued for the next run. Clones whose workspace no
+longer exists, or whose repo directory exists by now, are dropped.
+
+Examples:
+  co resume-network            # clone everything queued
+  co resume-network --list     # show the queue without running it
+  co resume-network --dry-run  # show what would be cloned`,
+	Args:        cobra.NoArgs,
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		if resumeNetworkList {
+			queue, err := template.LoadNetworkQueue(cfg)
+			if err != nil {
+				return err
+			}
+			return printNetworkQueue(queue)
+		}
+
+		result, err := template.ResumeNetwork(cfg, dryRun)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+
+		if len(result.Cloned)+len(result.Dropped)+len(result.Failed) == 0 {
+			fmt.Println("No queued network work.")
+			return nil
+		}
+		verb := "Cloned"
+		if dryRun {
+			verb = "Would clone"
+		}
+		for _, c := range result.Cloned {
+			fmt.Printf("%s %s into %s/repos/%s\n", verb, c.URL, c.Workspace, c.Repo)
+		}
+		for _, c := range result.Dropped {
+			fmt.Printf("Dropped %s/%s: workspace gone or repo already present\n", c.Workspace, c.Repo)
+		}
+		for _, f := range result.Failed {
+			fmt.Fprintf(os.Stderr, "Failed to clone %s (%d attempt(s)): %s\n", f.Name, f.Attempts, f.Error)
+		}
+		if len(result.Failed) > 0 {
+			return fmt.Errorf("%d clone(s) failed and remain queued", len(result.Failed))
+		}
+		return nil
+	},
+}
+
+func printNetworkQueue(queue *template.NetworkQueue) error {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(queue)
+	}
+	if len(queue.Clones) == 0 {
+		fmt.Println("No queued network work.")
+		return nil
+	}
+	fmt.Printf("Queued clones: %d\n", len(queue.Clones))
+	for _, c := range queue.Clones {
+		fmt.Printf("  %s/%s  %s  (queued %s)\n", c.Workspace, c.Repo, c.URL, c.QueuedAt.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(resumeNetworkCmd)
+	resumeNetworkCmd.Flags().BoolVarP(&resumeNetworkList, "list", "l", false, "list queued work without running it")
+}
@@ -1,12 +1,27 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/errfmt"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
 	"github.com/tormodhaugland/co/internal/partial"
+	"github.com/tormodhaugland/co/internal/script"
 	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/tui"
+	"github.com/tormodhaugland/co/internal/usage"
 )
 
 var (
@@ -14,6 +29,13 @@ var (
 	jsonOut   bool
 	jsonlOut  bool
 	robotHelp bool
+	dryRun    bool
+	assumeYes bool
+	offline   bool
+	plainMode bool
+
+	// discardUnsaved is bound by the commands that delete folders
+	discardUnsaved bool
 )
 
 var rootCmd = &cobra.Command{
@@ -32,19 +54,72 @@ Running 'co' without arguments launches the TUI.`,
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	if pluginsNeeded(os.Args[1:]) {
+		addPluginCommands()
+	}
+	// Errors are printed here, with a hint for those co knows
+	rootCmd.SilenceErrors = true
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	recordUsage(cmd, time.Since(start), err)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		if hint := errfmt.Hint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, "Hint:", hint)
+		}
+	}
+	return err
+}
+
+// recordUsage adds the run of cmd to the local usage statistics when
+// usage_stats is on. Only the command name is recorded, and a failure to
+// record never fails the command.
+func recordUsage(cmd *cobra.Command, d time.Duration, err error) {
+	// The prompt commands run on every shell prompt and would drown out the rest
+	if cmd == nil || !cmd.Runnable() || cmd == promptCmd || cmd == promptSegmentCmd {
+		return
+	}
+	cfg, loadErr := config.Load(cfgFile)
+	if loadErr != nil || !cfg.UsageStats {
+		return
+	}
+	name := strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name())
+	if name = strings.TrimSpace(name); name == "" {
+		name = "tui"
+	}
+	_ = usage.Record(cfg, name, d, err != nil, time.Now())
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.config/co/config.json)")
 	rootCmd.PersistentFlags().BoolVar(&jsonOut, "json", false, "output in JSON format")
 	rootCmd.PersistentFlags().BoolVar(&jsonlOut, "jsonl", false, "output in JSON Lines format")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show planned actions without making changes")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "skip confirmation prompts")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "do not use the network; queue clones for 'co resume-network'")
+	rootCmd.PersistentFlags().BoolVar(&plainMode, "plain", false, "plain TUIs for screen readers and dumb terminals: numbered menus, no colors")
 	rootCmd.PersistentFlags().BoolVar(&robotHelp, "robot-help", false, "print detailed robot helper guidance and exit")
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		if robotHelp {
 			fmt.Fprint(cmd.OutOrStdout(), robotHelpText())
 			os.Exit(0)
 		}
+		if dryRun && cmd.Annotations[dryRunAnnotation] == "" {
+			return fmt.Errorf("--dry-run is not supported by '%s'", cmd.CommandPath())
+		}
+		if offline {
+			// Through the environment, so hooks and plugins see it too
+			os.Setenv("CO_OFFLINE", "1")
+		}
+		if plainMode {
+			os.Setenv("CO_PLAIN", "1")
+		}
+		// Commands report a broken config themselves when they load it, so a
+		// nil config here only falls back to the environment
+		cfg, _ := config.Load(cfgFile)
+		fs.SetShared(cfg.IsShared())
+		tui.SetPlain(cfg.IsPlain())
+		resolveAliases(cfg, cmd, args)
 		return nil
 	}
 
@@ -58,6 +133,119 @@ func init() {
 		}, partialsDirs)
 		return err
 	})
+	script.Register()
+}
+
+// dryRunAnnotation marks commands that honor the global --dry-run flag.
+// Other commands reject it rather than silently making changes.
+const dryRunAnnotation = "co/dry-run"
+
+// supportsDryRun is the Annotations value for commands that honor --dry-run.
+var supportsDryRun = map[string]string{dryRunAnnotation: "true"}
+
+// slugArgsAnnotation marks commands whose arguments are workspace slugs:
+// "first" for the first argument, "rest" for all but the first, and "all"
+// for every one. Workspace aliases in them are resolved before the command
+// runs, so its RunE only sees slugs.
+const slugArgsAnnotation = "co/slug-args"
+
+// takesSlugs marks cmd with slugArgsAnnotation. The annotations are copied,
+// since commands share supportsDryRun.
+func takesSlugs(cmd *cobra.Command, which string) {
+	annotations := map[string]string{slugArgsAnnotation: which}
+	for k, v := range cmd.Annotations {
+		annotations[k] = v
+	}
+	cmd.Annotations = annotations
+}
+
+// resolveAliases replaces the workspace aliases among the slug arguments of
+// cmd in place; cobra passes the same slice on to RunE.
+func resolveAliases(cfg *config.Config, cmd *cobra.Command, args []string) {
+	from, to := 0, 0
+	switch cmd.Annotations[slugArgsAnnotation] {
+	case "first":
+		to = min(1, len(args))
+	case "rest":
+		from, to = min(1, len(args)), len(args)
+	case "all":
+		to = len(args)
+	}
+	for i := from; i < to; i++ {
+		args[i] = cfg.ResolveAlias(args[i])
+	}
+}
+
+// printPlan writes a dry-run plan as JSON with --json, or as the shared
+// human-readable report.
+func printPlan(plan *model.Plan) error {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	fmt.Print(plan.String())
+	return nil
+}
+
+// confirmOp asks the user to confirm op unless --yes was given or the
+// configured confirmation policy does not require it. JSON output cannot
+// share the terminal with a prompt, so it requires --yes instead.
+func confirmOp(cfg *config.Config, op config.ConfirmOp, message string) (bool, error) {
+	if assumeYes || !cfg.RequiresConfirm(op) {
+		return true, nil
+	}
+	if jsonOut || jsonlOut {
+		return false, fmt.Errorf("%s needs confirmation; rerun with --yes", op)
+	}
+	result, err := tui.RunConfirm(message)
+	if err != nil {
+		return false, fmt.Errorf("prompt failed (use --yes to skip): %w", err)
+	}
+	return !result.Aborted && result.Confirmed, nil
+}
+
+// interruptContext returns a context cancelled by Ctrl-C or SIGTERM, for
+// commands whose long operations (clones, copies, archives) stop cleanly.
+// After the first signal the default handling is back, so a second Ctrl-C
+// kills co outright.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// promptError wraps an error from an interactive prompt. When there is no
+// terminal to prompt on, it adds instead, which says how to do without.
+func promptError(what string, err error, instead string) error {
+	if errors.Is(err, tui.ErrNoTerminal) {
+		return fmt.Errorf("%s: %w; %s", what, err, instead)
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
+
+// discardUnsavedUsage is the help text for --discard-unsaved.
+const discardUnsavedUsage = "delete even if repos have uncommitted changes or unpushed commits"
+
+// confirmUnsaved lists repositories whose uncommitted changes or unpushed
+// commits would be lost and asks for an explicit extra confirmation. Neither
+// --yes nor the confirmation policy skips it; only --discard-unsaved does.
+func confirmUnsaved(unsaved []git.UnsavedWork) (bool, error) {
+	if len(unsaved) == 0 || discardUnsaved {
+		return true, nil
+	}
+	fmt.Fprintf(os.Stderr, "%d repo(s) have work that is not saved elsewhere:\n", len(unsaved))
+	for _, work := range unsaved {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", work.Path, work)
+	}
+	if assumeYes || jsonOut || jsonlOut {
+		return false, fmt.Errorf("refusing to discard unsaved work; rerun with --discard-unsaved")
+	}
+	result, err := tui.RunConfirm("Discard this work permanently?")
+	if err != nil {
+		return false, fmt.Errorf("prompt failed (use --discard-unsaved to skip): %w", err)
+	}
+	return !result.Aborted && result.Confirmed, nil
 }
 
 func exitWithError(msg string, code int) {
@@ -111,6 +299,7 @@ Common workflows
      co index
      co ls --json
      co show <slug> --json
+     co stats --json
      co cd <slug> [repo]
 
   2) Create a workspace
@@ -121,6 +310,7 @@ Common workflows
   3) Work with templates and partials
      co template list
      co template show <name>
+     co template globals [file]
      co partial list
      co partial show <name> --files
      co partial apply <name> [path] --dry-run
@@ -129,6 +319,7 @@ Common workflows
      co import <folder-path>
      co import <folder-path> --add-to <workspace-slug>
      co import -i <folder-path>   (interactive browser)
+     co migrate --from ghq|gopath|flat [root] --dry-run
 
   5) Sync and archive
      co sync <workspace-slug> <server> --dry-run
@@ -144,10 +335,40 @@ Command notes
   - co open <slug> opens the workspace in the configured editor.
   - co ls supports --owner, --state, --tag filters plus --json/--jsonl output.
   - co show exposes full workspace metadata and repo status.
+  - co serve runs a local HTTP+JSON API (bearer token in
+    $XDG_STATE_HOME/co/serve.json) for plugins that cannot shell out.
+  - co vscode install configures VS Code for the companion extension.
+  - co mcp serves list/find/search/create/import as MCP tools on stdio.
+  - Mutating commands lock the workspace; "is locked by" errors name the
+    holder. co unlock lists locks and removes stale ones.
+  - "shared": true (or CO_SHARED=1) is for code roots shared between users
+    or on NFS: umask/ACL-driven permissions and NFS-safe locks.
+  - --offline (or CO_OFFLINE=1) queues clones instead of cloning and refuses
+    other network access; co resume-network runs the queue later.
+  - --plain (or CO_PLAIN=1, "plain": true, TERM=dumb) turns TUIs into
+    numbered menus and line prompts on stderr for screen readers.
+  - Without a terminal on stdin, prompts fail naming the flags to use
+    instead (never hang); co with no args then lists workspaces like co ls.
+  - co graph <slug> shows repo dependencies (--format dot for Graphviz);
+    co run <slug> -- <cmd> runs a command in each repo, dependencies first.
+  - co hooks install <slug>|--all installs git hooks from _system/git-hooks
+    and the template into each repo; co hooks uninstall removes them.
+  - co policy check <slug>...|--all checks repos against repo_policy
+    (branch names, commit subjects, required files, default branch); it
+    exits non-zero on violations.
 
 Safety defaults
   - Destructive actions are opt-in: co sync --force, co archive --delete.
-  - Prefer --dry-run before any sync or import.
+  - Prefer --dry-run before any sync, import, archive, stash, or clean.
+    --dry-run is global; commands that cannot honor it reject it.
+  - Deletes and overwrites prompt per the config "confirm" policy; pass
+    --yes (required with --json) to run non-interactively.
+  - Deleting repos with uncommitted or unpushed work needs a second
+    confirmation that --yes does not skip; pass --discard-unsaved.
+  - With config "quarantine": {"enabled": true}, deletes move folders to
+    _system/quarantine; co quarantine list/restore/purge manages them.
+  - Home, the code root, _system, and config "protected_paths" are never
+    deleted, trashed, or stash-deleted.
 
 Exit codes
   0 success
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	runKeepGoing bool
+	runRepos     []string
+)
+
+type runRepoResult struct {
+	Repo     string `json:"repo"`
+	ExitCode int    `json:"exit_code"`
+	Duration string `json:"duration"`
+	Skipped  bool   `json:"skipped,omitempty"` // A dependency failed
+	Error    string `json:"error,omitempty"`
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <workspace-slug> -- <command> [args...]",
+	Short: "Run a command in each repo of a workspace, dependencies first",
+	Long: `Runs a command in the directory of each repo of a workspace, in dependency
+order: a repo runs after the repos it depends on (see co graph).
+
+A failure stops the run unless --keep-going is set, in which case only repos
+depending on the failed one, directly or not, are skipped. A dependency cycle
+is an error.
+
+Examples:
+  co run acme--platform -- make build
+  co run acme--platform --keep-going -- go test ./...
+  co run acme--platform --repos api,web -- npm ci
+  co run acme--platform --dry-run -- make   # show the order only`,
+	Args:        cobra.MinimumNArgs(2),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := args[0]
+		command := args[1:]
+
+		graph, err := loadDepGraph(slug)
+		if err != nil {
+			return err
+		}
+		order, err := graph.Order()
+		if err != nil {
+			return err
+		}
+		for _, w := range graph.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+
+		if len(runRepos) > 0 {
+			selected := make(map[string]bool, len(runRepos))
+			for _, r := range runRepos {
+				if _, ok := graph.Paths[r]; !ok {
+					return fmt.Errorf("repo not found in %s: %s", slug, r)
+				}
+				selected[r] = true
+			}
+			filtered := order[:0]
+			for _, r := range order {
+				if selected[r] {
+					filtered = append(filtered, r)
+				}
+			}
+			order = filtered
+		}
+
+		if dryRun {
+			fmt.Printf("Would run '%s' in:\n", strings.Join(command, " "))
+			for i, r := range order {
+				fmt.Printf("  %d. %s\n", i+1, r)
+			}
+			return nil
+		}
+
+		// Command output goes to stderr with --json so stdout stays parseable
+		var out io.Writer = os.Stdout
+		if jsonOut {
+			out = os.Stderr
+		}
+
+		failed := make(map[string]bool)
+		var results []runRepoResult
+		var failures int
+		for _, repo := range order {
+			result := runRepoResult{Repo: repo}
+			for _, d := range graph.DependenciesOf(repo) {
+				if failed[d.To] {
+					result.Skipped = true
+					result.Error = "dependency failed: " + d.To
+					break
+				}
+			}
+			if result.Skipped {
+				failed[repo] = true
+				results = append(results, result)
+				if !jsonOut {
+					fmt.Fprintf(out, "==> %s: skipped, %s\n", repo, result.Error)
+				}
+				continue
+			}
+
+			if !jsonOut {
+				fmt.Fprintf(out, "==> %s\n", repo)
+			}
+			start := time.Now()
+			c := exec.Command(command[0], command[1:]...)
+			c.Dir = graph.Paths[repo]
+			c.Stdin = os.Stdin
+			c.Stdout = out
+			c.Stderr = os.Stderr
+			runErr := c.Run()
+			result.Duration = time.Since(start).Round(time.Millisecond).String()
+			if runErr != nil {
+				var exitErr *exec.ExitError
+				if errors.As(runErr, &exitErr) {
+					result.ExitCode = exitErr.ExitCode()
+				} else {
+					result.ExitCode = -1
+				}
+				result.Error = runErr.Error()
+				failed[repo] = true
+				failures++
+			}
+			results = append(results, result)
+
+			if runErr != nil {
+				if !jsonOut {
+					fmt.Fprintf(os.Stderr, "Failed in %s: %v\n", repo, runErr)
+				}
+				if !runKeepGoing {
+					break
+				}
+			}
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return err
+			}
+		}
+		if failures > 0 {
+			return fmt.Errorf("command failed in %d repo(s)", failures)
+		}
+		return nil
+	},
+}
+
+func init() {
+	takesSlugs(runCmd, "first")
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().BoolVarP(&runKeepGoing, "keep-going", "k", false, "continue with repos not depending on a failed one")
+	runCmd.Flags().StringSliceVar(&runRepos, "repos", nil, "only run in these repos (comma-separated)")
+}
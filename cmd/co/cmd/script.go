@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/script"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+type scriptCheckResult struct {
+	Path      string              `json:"path"`
+	Functions []string            `json:"functions"`
+	Problems  map[string][]string `json:"problems,omitempty"`
+	Errors    []string            `json:"errors,omitempty"`
+}
+
+var scriptCmd = &cobra.Command{
+	Use:   "script",
+	Short: "Work with the co script of rules and hooks",
+	Long: `The co script is a Starlark file (default <code_root>/_system/co.star, or
+"script" in the config) with rules and event hooks. Starlark is a small
+Python dialect; scripts cannot read files or run commands. co calls these
+functions when the script defines them:
+
+  classify(source)               Suggest {"owner", "project", "template"} for
+                                 co import; source has path, name, and repos
+  validate_name(owner, project)  Return an error message to refuse a name
+  validate(ws)                   Return a list of problems (co script check)
+  on_create(ws)                  Run before project.json is written; return
+  on_import(ws)                  {"tags": [...], "state": "..."} to add tags
+  on_archive(ws)                 or change the state
+
+A workspace ws has slug, owner, project, path, state, tags, template, and
+repos (name, path, remote). The co module offers co.workspaces(),
+co.workspace(slug), co.exists(slug), co.slug(owner, project), and
+co.templates(). print() writes to stderr.`,
+}
+
+var scriptCheckCmd = &cobra.Command{
+	Use:   "check [workspace...]",
+	Short: "Load the co script and run its validate rule",
+	Long: `Loads the co script, lists the functions co will call, and runs
+validate(ws) for the given workspaces, or all of them. Exits non-zero when
+the script fails or reports problems.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		s, err := script.Load(cfg)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			return fmt.Errorf("no script at %s", cfg.ScriptPath())
+		}
+
+		result := scriptCheckResult{Path: s.Path(), Functions: []string{}}
+		for _, name := range script.Functions {
+			if s.Has(name) {
+				result.Functions = append(result.Functions, name)
+			}
+		}
+
+		slugs := args
+		if len(slugs) == 0 {
+			if slugs, err = workspace.ListWorkspaces(cfg); err != nil {
+				return fmt.Errorf("failed to list workspaces: %w", err)
+			}
+		}
+		if s.Has("validate") {
+			for _, slug := range slugs {
+				if !workspace.Exists(cfg, slug) {
+					return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+				}
+				path := cfg.WorkspacePath(slug)
+				proj, err := model.LoadProject(filepath.Join(path, "project.json"))
+				if err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", slug, err))
+					continue
+				}
+				problems, err := s.Validate(proj, path)
+				if err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", slug, err))
+					continue
+				}
+				if len(problems) > 0 {
+					if result.Problems == nil {
+						result.Problems = make(map[string][]string)
+					}
+					result.Problems[slug] = problems
+				}
+			}
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Script: %s\n", result.Path)
+			if len(result.Functions) == 0 {
+				fmt.Println("Defines none of the functions co calls")
+			} else {
+				fmt.Printf("Functions: %v\n", result.Functions)
+			}
+			for _, slug := range slugs {
+				for _, p := range result.Problems[slug] {
+					fmt.Printf("  %s: %s\n", slug, p)
+				}
+			}
+			for _, e := range result.Errors {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", e)
+			}
+		}
+
+		if len(result.Errors) > 0 || len(result.Problems) > 0 {
+			return fmt.Errorf("script check found %d problem workspace(s) and %d error(s)", len(result.Problems), len(result.Errors))
+		}
+		return nil
+	},
+}
+
+func init() {
+	takesSlugs(scriptCheckCmd, "all")
+	scriptCmd.AddCommand(scriptCheckCmd)
+	rootCmd.AddCommand(scriptCmd)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/server"
+	"github.com/tormodhaugland/co/pkg/co"
+)
+
+var (
+	serveAddr          string
+	serveToken         string
+	serveMaintainEvery time.Duration
+	serveQueueEvery    time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a local HTTP+JSON API for editor plugins and GUIs",
+	Long: `Starts a local HTTP server exposing workspace, template, and archive
+operations as a JSON API under /v1.
+
+Every request except GET /v1/health must send "Authorization: Bearer <token>".
+The token comes from --token, then $CO_SERVE_TOKEN, and is otherwise generated.
+The address and token are written to $XDG_STATE_HOME/co/serve.json (mode 0600)
+so local clients can find the server; the file is removed on shutdown.
+
+While serving, co also runs background jobs, one at a time and never
+alongside a mutating API call:
+  network-queue  Clones queued while offline (see co resume-network), every
+                 --queue-every while online
+  maintain       The co maintain upkeep (prune, purge, reindex), every
+                 --maintain-every; off unless set
+Pass 0 to turn a job off. GET /v1/jobs reports each job's last run.
+
+Endpoints:
+  GET  /v1/health                       Liveness check (no auth)
+  GET  /v1/workspaces?owner=&state=&tag= List workspaces from the index
+  POST /v1/workspaces                   Create a workspace
+  POST /v1/workspaces/{slug}/add        Add repos from a folder
+  POST /v1/workspaces/{slug}/template   Apply a template
+  POST /v1/workspaces/{slug}/archive    Archive a workspace
+  POST /v1/import                       Import a folder as a new workspace
+  POST /v1/stash                        Stash a file or folder
+  POST /v1/restore                      Restore an archive or stash
+  POST /v1/index                        Rebuild the index
+  GET  /v1/templates                    List templates
+  GET  /v1/archives                     List archives
+  GET  /v1/jobs                         Background jobs and their last run
+
+Examples:
+  co serve
+  co serve --addr 127.0.0.1:9000 --token secret
+  co serve --maintain-every 24h`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		token := serveToken
+		if token == "" {
+			token = os.Getenv("CO_SERVE_TOKEN")
+		}
+		if token == "" {
+			if token, err = server.GenerateToken(); err != nil {
+				return fmt.Errorf("failed to generate token: %w", err)
+			}
+		}
+
+		ln, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+		}
+		addr := ln.Addr().String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				fmt.Fprintf(os.Stderr, "Warning: listening on non-loopback address %s\n", addr)
+			}
+		}
+
+		info := server.Info{Addr: addr, Token: token, PID: os.Getpid(), StartedAt: time.Now()}
+		if err := server.WriteInfo(cfg, info); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write server info: %v\n", err)
+		}
+		defer server.RemoveInfo(cfg)
+
+		api := server.New(co.New(cfg), token)
+		srv := &http.Server{
+			Handler:           api,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		jobsDone := make(chan struct{})
+		go func() {
+			defer close(jobsDone)
+			api.RunJobs(ctx, server.JobOptions{
+				MaintainEvery: serveMaintainEvery,
+				QueueEvery:    serveQueueEvery,
+				Logf: func(format string, args ...any) {
+					fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+				},
+			})
+		}()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Printf("Listening on http://%s%s\n", addr, server.APIVersion)
+		fmt.Printf("Token written to %s\n", server.InfoPath(cfg))
+
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		<-jobsDone
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveAddr, "addr", server.DefaultAddr, "address to listen on")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "API token (default: $CO_SERVE_TOKEN or generated)")
+	serveCmd.Flags().DurationVar(&serveQueueEvery, "queue-every", 5*time.Minute, "how often to run the offline network queue (0 disables)")
+	serveCmd.Flags().DurationVar(&serveMaintainEvery, "maintain-every", 0, "how often to run co maintain (0 disables)")
+}
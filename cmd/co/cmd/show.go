@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var showCmd = &cobra.Command{
@@ -30,7 +32,7 @@ var showCmd = &cobra.Command{
 
 		record := idx.FindBySlug(slug)
 		if record == nil {
-			return fmt.Errorf("workspace not found: %s", slug)
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
 		}
 
 		if jsonOut {
@@ -40,8 +42,17 @@ var showCmd = &cobra.Command{
 		}
 
 		fmt.Printf("Workspace: %s\n", record.Slug)
+		if record.Icon != "" || record.DisplayName != "" {
+			fmt.Printf("Name:      %s\n", record.Label())
+		}
+		if record.Description != "" {
+			fmt.Printf("About:     %s\n", strings.ReplaceAll(record.Description, "\n", "\n           "))
+		}
 		fmt.Printf("Path:      %s\n", record.Path)
 		fmt.Printf("Owner:     %s\n", record.Owner)
+		if record.CreatedBy != "" {
+			fmt.Printf("Creator:   %s\n", record.CreatedBy)
+		}
 		fmt.Printf("State:     %s\n", record.State)
 		if len(record.Tags) > 0 {
 			fmt.Printf("Tags:      %v\n", record.Tags)
@@ -89,5 +100,6 @@ func formatBytes(bytes int64) string {
 }
 
 func init() {
+	takesSlugs(showCmd, "first")
 	rootCmd.AddCommand(showCmd)
 }
@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/archive"
 	"github.com/tormodhaugland/co/internal/config"
-	"github.com/tormodhaugland/co/internal/tui"
+	"github.com/tormodhaugland/co/internal/git"
 )
 
 var (
-	stashDelete bool
-	stashName   string
+	stashDelete         bool
+	stashName           string
+	stashIncludeSecrets bool
+	stashBundles        bool
+	stashRestoreTo      string
 )
 
 var stashCmd = &cobra.Command{
@@ -27,9 +32,31 @@ archiving any other folders you want to keep but not have cluttering
 your filesystem.
 
 The folder is compressed into a .tar.gz file in the archive directory.
-Use --delete to remove the original folder after archiving.
-Use --name to specify a custom name for the archive (defaults to folder name).`,
-	Args: cobra.ExactArgs(1),
+Use --delete to remove the original folder after archiving (asks first
+unless --yes is given or confirm.stash_delete is false). Repos with
+uncommitted changes or unpushed commits are listed and need a second
+confirmation, or --discard-unsaved.
+Use --name to specify a custom name for the archive (defaults to folder name).
+Files that likely hold secrets (.env, *.pem, id_rsa, ...) are left out of
+the archive unless --include-secrets is given or secrets.stash_include is set.
+Use --bundles to store each git repo as a git bundle of all its refs plus
+its untracked and changed files instead of its whole working tree. Archives
+of pushed repos become much smaller; ignored files in repos (build output,
+node_modules, ...) are not kept. Restoring clones the repos back.
+Use --dry-run to list the planned actions without archiving.
+
+Each archive gets a manifest next to it (<archive>.json) recording the
+source path, file count, total size, and the git repos it holds with their
+HEAD commits and remotes.
+
+Subcommands:
+  co stash list                # List stashes with their manifests
+  co stash verify <archive>    # Check an archive against its manifest
+  co stash restore <archive>   # Extract a stash where it came from
+
+To stash a folder named like a subcommand, give its path as ./list.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		sourcePath, err := filepath.Abs(args[0])
 		if err != nil {
@@ -49,13 +76,37 @@ Use --name to specify a custom name for the archive (defaults to folder name).`,
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
+		opts := archive.StashOptions{
+			Name:           stashName,
+			DeleteAfter:    stashDelete,
+			DryRun:         dryRun,
+			IncludeSecrets: stashIncludeSecrets,
+			Bundles:        stashBundles,
+		}
+
+		if dryRun {
+			result, err := archive.StashFolder(cfg, sourcePath, opts)
+			if err != nil {
+				return err
+			}
+			return printPlan(result.Plan)
+		}
+
 		// Confirm if deleting
 		if stashDelete {
-			result, err := tui.RunConfirm(fmt.Sprintf("Archive and DELETE '%s'?", sourcePath))
+			ok, err := confirmOp(cfg, config.ConfirmStashDelete, fmt.Sprintf("Archive and DELETE '%s'?", sourcePath))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Stash cancelled.")
+				return nil
+			}
+			ok, err = confirmUnsaved(git.FindUnsavedWork(sourcePath))
 			if err != nil {
-				return fmt.Errorf("prompt failed: %w", err)
+				return err
 			}
-			if result.Aborted || !result.Confirmed {
+			if !ok {
 				fmt.Println("Stash cancelled.")
 				return nil
 			}
@@ -63,11 +114,9 @@ Use --name to specify a custom name for the archive (defaults to folder name).`,
 
 		fmt.Printf("Archiving: %s\n", sourcePath)
 
-		opts := archive.StashOptions{
-			Name:        stashName,
-			DeleteAfter: stashDelete,
-		}
-		result, err := archive.StashFolder(cfg, sourcePath, opts)
+		ctx, stop := interruptContext()
+		defer stop()
+		result, err := archive.StashFolderContext(ctx, cfg, sourcePath, opts)
 		if err != nil {
 			return err
 		}
@@ -79,7 +128,12 @@ Use --name to specify a custom name for the archive (defaults to folder name).`,
 		}
 
 		fmt.Printf("Archive created: %s\n", result.ArchivePath)
-		if result.Deleted {
+		if len(result.SecretsExcluded) > 0 {
+			fmt.Printf("Left out %d likely secret file(s): %s\n", len(result.SecretsExcluded), strings.Join(result.SecretsExcluded, ", "))
+		}
+		if result.Quarantined != "" {
+			fmt.Printf("Quarantined: %s (%s)\n", result.SourcePath, result.Quarantined)
+		} else if result.Deleted {
 			fmt.Printf("Deleted: %s\n", result.SourcePath)
 		}
 
@@ -87,8 +141,178 @@ Use --name to specify a custom name for the archive (defaults to folder name).`,
 	},
 }
 
+var stashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stashes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		entries, err := archive.ListStashes(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to list stashes: %w", err)
+		}
+
+		if jsonOut {
+			if entries == nil {
+				entries = []archive.StashEntry{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+		if len(entries) == 0 {
+			fmt.Println("No stashes.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STASHED	ARCHIVE	FILES	SIZE	REPOS	SOURCE")
+		for _, entry := range entries {
+			files, size, repos, source := "-", formatBytes(entry.Size), "-", "-"
+			if m := entry.Manifest; m != nil {
+				files = fmt.Sprint(m.FileCount)
+				size = formatBytes(m.TotalSize)
+				repos = fmt.Sprint(len(m.Repos))
+				if m.Bundles {
+					repos += " (bundles)"
+				}
+				source = m.SourcePath
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				entry.StashedAt.Format("2006-01-02 15:04"),
+				filepath.Base(entry.Path),
+				files, size, repos, source)
+		}
+		return w.Flush()
+	},
+}
+
+var stashVerifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Check a stash archive against its manifest",
+	Long: `Reads the whole stash archive and compares it with its manifest: the
+number of files, their total size, and that every recorded git repo is in
+the archive. Stashes made before manifests were written are only checked to
+be readable. The archive can be given as a path or as its file name.
+
+Exits non-zero when the archive is unreadable or does not match.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		archivePath, err := resolveStash(cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		check, err := archive.VerifyStash(archivePath)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(check); err != nil {
+				return err
+			}
+		} else {
+			name := filepath.Base(archivePath)
+			switch {
+			case check.Manifest == nil:
+				fmt.Printf("✓ %s is readable (%d files, %s); it has no manifest to compare with\n", name, check.FileCount, formatBytes(check.TotalSize))
+			case check.OK():
+				fmt.Printf("✓ %s matches its manifest (%d files, %s, %d repos)\n", name, check.FileCount, formatBytes(check.TotalSize), len(check.Manifest.Repos))
+			default:
+				fmt.Printf("✗ %s\n", name)
+				for _, p := range check.Problems {
+					fmt.Printf("    %s\n", p)
+				}
+			}
+		}
+		if !check.OK() {
+			return fmt.Errorf("stash does not match its manifest")
+		}
+		return nil
+	},
+}
+
+var stashRestoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Extract a stash",
+	Long: `Extracts a stash back to the folder it was stashed from, as recorded in
+its manifest, or into the directory given by --to. Restoring to the original
+location is refused when that folder exists again. Stashes made before
+manifests were written need --to. The archive can be given as a path or as
+its file name.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		archivePath, err := resolveStash(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		destDir := stashRestoreTo
+		if destDir != "" {
+			if destDir, err = filepath.Abs(destDir); err != nil {
+				return fmt.Errorf("invalid path: %w", err)
+			}
+		}
+
+		result, err := archive.RestoreArchive(cfg, archivePath, archive.RestoreOptions{DestDir: destDir})
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+		fmt.Printf("Restored %s into %s\n", filepath.Base(archivePath), result.RestoredPath)
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		}
+		return nil
+	},
+}
+
+// resolveStash returns the path of the stash archive arg, which is a path
+// or the file name of an archive in the archive directory.
+func resolveStash(cfg *config.Config, arg string) (string, error) {
+	if _, err := os.Stat(arg); err == nil {
+		return filepath.Abs(arg)
+	}
+	entries, err := archive.ListStashes(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to list stashes: %w", err)
+	}
+	for _, entry := range entries {
+		if filepath.Base(entry.Path) == arg {
+			return entry.Path, nil
+		}
+	}
+	return "", fmt.Errorf("stash not found: %s", arg)
+}
+
 func init() {
 	stashCmd.Flags().BoolVar(&stashDelete, "delete", false, "delete folder after archiving")
+	stashCmd.Flags().BoolVar(&discardUnsaved, "discard-unsaved", false, discardUnsavedUsage)
+	stashCmd.Flags().BoolVar(&stashBundles, "bundles", false, "store git repos as bundles plus their untracked and changed files")
+	stashCmd.Flags().BoolVar(&stashIncludeSecrets, "include-secrets", false, "keep likely secret files (.env, *.pem, ...) in the archive")
 	stashCmd.Flags().StringVar(&stashName, "name", "", "custom name for the archive (defaults to folder name)")
 	rootCmd.AddCommand(stashCmd)
+	stashCmd.AddCommand(stashListCmd)
+	stashCmd.AddCommand(stashVerifyCmd)
+	stashCmd.AddCommand(stashRestoreCmd)
+	stashRestoreCmd.Flags().StringVar(&stashRestoreTo, "to", "", "extract into this directory instead of where the stash came from")
 }
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/archive"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/index"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/usage"
+)
+
+var (
+	statsChart       bool
+	statsNoLanguages bool
+	statsSelf        bool
+)
+
+const statsBarWidth = 30
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show workspace statistics",
+	Long: `Summarizes workspaces by owner, state, and template, along with repo counts,
+dirty repos, disk usage, languages, and archive volume.
+
+Statistics are computed from the index; run 'co index' first for fresh numbers.
+Use --chart to render bar charts and a 12-month activity sparkline.
+
+With --self, shows how you use co instead: runs, failures, and durations of
+each command, from the local statistics recorded while "usage_stats": true
+is set in the config. They are kept in $XDG_STATE_HOME/co/usage.json and
+never sent anywhere; only command names are recorded, not arguments.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if statsSelf {
+			return runSelfStats(cfg)
+		}
+
+		idx, err := model.LoadIndex(cfg.IndexPath())
+		if err != nil {
+			return fmt.Errorf("failed to load index: %w", err)
+		}
+
+		templates := make(map[string]string)
+		for _, r := range idx.Records {
+			proj, err := model.LoadProject(filepath.Join(r.Path, "project.json"))
+			if err == nil && proj.Template != "" {
+				templates[r.Slug] = proj.Template
+			}
+		}
+
+		stats := index.ComputeStats(idx.Records, templates, time.Now())
+
+		if !statsNoLanguages {
+			repoDirs := make([]string, 0, len(idx.Records))
+			for _, r := range idx.Records {
+				repos, err := model.LocateWorkspaceRepos(r.Path)
+				if err != nil {
+					continue
+				}
+				for _, repo := range repos {
+					repoDirs = append(repoDirs, filepath.Join(r.Path, filepath.FromSlash(repo.Path)))
+				}
+			}
+			stats.Languages = index.CountLanguages(repoDirs)
+		}
+
+		entries, err := archive.ListArchives(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to list archives: %w", err)
+		}
+		for _, e := range entries {
+			stats.Archives.Count++
+			if info, err := os.Stat(e.Path); err == nil {
+				stats.Archives.SizeBytes += info.Size()
+			}
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		}
+
+		printStats(stats)
+		return nil
+	},
+}
+
+func printStats(stats *index.Stats) {
+	fmt.Printf("Workspaces: %d", stats.Workspaces)
+	if stats.InvalidCount > 0 {
+		fmt.Printf(" (%d invalid)", stats.InvalidCount)
+	}
+	fmt.Println()
+	fmt.Printf("Repos: %d (%d dirty in %d workspaces)\n", stats.Repos, stats.DirtyRepos, stats.DirtyWorkspaces)
+	fmt.Printf("Disk usage: %s\n", formatBytes(stats.TotalSizeBytes))
+	fmt.Printf("Archives: %d (%s)\n", stats.Archives.Count, formatBytes(stats.Archives.SizeBytes))
+	if statsChart {
+		fmt.Printf("Activity (12 months): %s\n", sparkline(stats.Activity))
+	}
+
+	printStatSection("By owner", "OWNER", stats.ByOwner, true)
+	printStatSection("By state", "STATE", stats.ByState, true)
+	printStatSection("By template", "TEMPLATE", stats.ByTemplate, true)
+	if !statsNoLanguages {
+		printStatSection("Languages (files)", "LANGUAGE", stats.Languages, false)
+	}
+}
+
+func printStatSection(title, header string, counts []index.StatCount, showSize bool) {
+	if len(counts) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s:\n", title)
+
+	maxCount := 0
+	for _, c := range counts {
+		if c.Count > maxCount {
+			maxCount = c.Count
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	cols := []string{"  " + header, "COUNT"}
+	if showSize {
+		cols = append(cols, "SIZE")
+	}
+	if statsChart {
+		cols = append(cols, "")
+	}
+	fmt.Fprintln(w, strings.Join(cols, "\t"))
+
+	for _, c := range counts {
+		row := []string{"  " + c.Name, fmt.Sprintf("%d", c.Count)}
+		if showSize {
+			row = append(row, formatBytes(c.SizeBytes))
+		}
+		if statsChart {
+			row = append(row, statsBar(c.Count, maxCount, statsBarWidth))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// runSelfStats prints the local usage statistics of co itself.
+func runSelfStats(cfg *config.Config) error {
+	stats, err := usage.Load(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load usage statistics: %w", err)
+	}
+	commands := stats.Sorted()
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(commands)
+	}
+
+	if !cfg.UsageStats {
+		fmt.Println(`Usage statistics are off; set "usage_stats": true in the config to record them.`)
+	}
+	if len(commands) == 0 {
+		fmt.Println("No usage recorded yet.")
+		return nil
+	}
+
+	fmt.Printf("Usage since %s (local only, never sent anywhere):\n\n", stats.Since.Format("2006-01-02"))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	cols := []string{"  COMMAND", "RUNS", "FAILED", "AVG", "MAX", "LAST USED"}
+	if statsChart {
+		cols = append(cols, "AVG (12 MONTHS)")
+	}
+	fmt.Fprintln(w, strings.Join(cols, "\t"))
+	now := time.Now()
+	for _, c := range commands {
+		row := []string{
+			"  " + c.Name,
+			fmt.Sprintf("%d", c.Count),
+			fmt.Sprintf("%d", c.Failures),
+			formatStatDuration(c.Average()),
+			formatStatDuration(time.Duration(c.MaxMs) * time.Millisecond),
+			c.LastUsed.Format("2006-01-02"),
+		}
+		if statsChart {
+			row = append(row, sparkline(c.MonthlyAverages(now, 12)))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// formatStatDuration rounds a duration for display: to milliseconds below a
+// second, to a tenth of a second below a minute, and to seconds above.
+func formatStatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// statsBar renders an ASCII bar proportional to value/maxCount.
+func statsBar(value, maxCount, width int) string {
+	if maxCount == 0 {
+		return ""
+	}
+	n := value * width / maxCount
+	if n == 0 && value > 0 {
+		n = 1
+	}
+	return strings.Repeat("█", n)
+}
+
+// sparkline renders values as a single line of block characters.
+func sparkline(values []int) string {
+	ticks := []rune("▁▂▃▄▅▆▇█")
+
+	maxCount := 0
+	for _, v := range values {
+		if v > maxCount {
+			maxCount = v
+		}
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if maxCount == 0 {
+			sb.WriteRune(ticks[0])
+			continue
+		}
+		sb.WriteRune(ticks[v*(len(ticks)-1)/maxCount])
+	}
+	return sb.String()
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().BoolVar(&statsChart, "chart", false, "render bar charts and an activity sparkline")
+	statsCmd.Flags().BoolVar(&statsNoLanguages, "no-languages", false, "skip scanning repos for language counts")
+	statsCmd.Flags().BoolVar(&statsSelf, "self", false, "show local usage statistics of co itself (usage_stats config)")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/archive"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+var (
+	suggestArchiveDays  int
+	suggestArchiveApply bool
+)
+
+var suggestArchiveCmd = &cobra.Command{
+	Use:   "suggest-archive",
+	Short: "List inactive workspaces worth archiving",
+	Long: `Lists workspaces untouched for longer than archive.suggest_days (default:
+180) whose repos have no uncommitted changes and no unpushed commits, so
+archiving them loses nothing.
+
+Use --archive to move every suggested workspace to cold storage (see
+'co archive workspace'); it asks first unless --yes is given or
+confirm.stash_delete is false. Use --dry-run to list the planned actions.`,
+	Args:        cobra.NoArgs,
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		idx, err := model.LoadIndex(cfg.IndexPath())
+		if err != nil {
+			return fmt.Errorf("failed to load index (run 'co index' first): %w", err)
+		}
+
+		days := cfg.GetArchiveConfig().SuggestDays
+		if suggestArchiveDays > 0 {
+			days = suggestArchiveDays
+		}
+		suggestions := archive.Suggest(idx.Records, days, time.Now())
+
+		if !suggestArchiveApply {
+			if jsonOut {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(suggestions)
+			}
+			if len(suggestions) == 0 {
+				fmt.Printf("No clean workspaces inactive for %d+ days\n", days)
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SLUG\tLAST ACTIVE\tINACTIVE\tREPOS\tSIZE")
+			for _, s := range suggestions {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", s.Slug, s.LastActivity.Format("2006-01-02"), formatAge(s.InactiveDays), s.RepoCount, formatBytes(s.SizeBytes))
+			}
+			w.Flush()
+			fmt.Println("\nRun 'co suggest-archive --archive' to move them to cold storage.")
+			return nil
+		}
+
+		if len(suggestions) == 0 {
+			fmt.Printf("No clean workspaces inactive for %d+ days\n", days)
+			return nil
+		}
+
+		if dryRun {
+			plan := model.NewPlan(fmt.Sprintf("Move %d workspace(s) inactive for %d+ days to cold storage", len(suggestions), days))
+			for _, s := range suggestions {
+				result, err := archive.ColdStore(cfg, s.Slug, archive.ColdOptions{DryRun: true})
+				if err != nil {
+					plan.Skip(model.ActionArchive, s.Path, "", err.Error())
+					continue
+				}
+				plan.Actions = append(plan.Actions, result.Plan.Actions...)
+			}
+			return printPlan(plan)
+		}
+
+		ok, err := confirmOp(cfg, config.ConfirmStashDelete, fmt.Sprintf("Move %d workspace(s) inactive for %d+ days to cold storage?", len(suggestions), days))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+
+		reason := fmt.Sprintf("inactive for %d+ days", days)
+		var archived []string
+		for _, s := range suggestions {
+			if !jsonOut {
+				fmt.Printf("Archiving %s...\n", s.Slug)
+			}
+			if _, err := archive.ColdStore(cfg, s.Slug, archive.ColdOptions{Reason: reason}); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to archive %s: %v\n", s.Slug, err)
+				continue
+			}
+			archived = append(archived, s.Slug)
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]interface{}{
+				"archived": archived,
+				"count":    len(archived),
+			})
+		}
+		fmt.Printf("Moved %d workspace(s) to cold storage\n", len(archived))
+		return nil
+	},
+}
+
+func init() {
+	suggestArchiveCmd.Flags().IntVar(&suggestArchiveDays, "days", 0, "days of inactivity (default: archive.suggest_days)")
+	suggestArchiveCmd.Flags().BoolVar(&suggestArchiveApply, "archive", false, "move the suggested workspaces to cold storage")
+	rootCmd.AddCommand(suggestArchiveCmd)
+}
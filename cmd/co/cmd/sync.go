@@ -13,11 +13,11 @@ import (
 	"github.com/tormodhaugland/co/internal/model"
 	"github.com/tormodhaugland/co/internal/sync"
 	"github.com/tormodhaugland/co/internal/tui"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var (
 	syncForce        bool
-	syncDryRun       bool
 	syncNoGit        bool
 	syncIncludeEnv   bool
 	syncExcludes     []string
@@ -41,7 +41,8 @@ Use --exclude to add patterns, --include-env to sync .env files.
 Repos are always excluded from file transfer and cloned on the target.
 Use --interactive (-i) to launch a TUI for selecting files/directories
 to exclude before syncing. Navigate with j/k, toggle with space.`,
-	Args: cobra.RangeArgs(0, 2),
+	Args:        cobra.RangeArgs(0, 2),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Handle --list-excludes
 		if syncListExcludes {
@@ -79,12 +80,15 @@ to exclude before syncing. Navigate with j/k, toggle with space.`,
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		if err := cfg.CheckOnline("sync"); err != nil {
+			return err
+		}
 
 		// Resolve workspace slug with fuzzy matching
 		slug := query
-		if !fs.WorkspaceExists(cfg.CodeRoot, query) {
+		if !workspace.Exists(cfg, query) {
 			// Try fuzzy matching
-			workspaces, err := fs.ListWorkspaces(cfg.CodeRoot)
+			workspaces, err := workspace.ListWorkspaces(cfg)
 			if err != nil {
 				return fmt.Errorf("failed to list workspaces: %w", err)
 			}
@@ -112,7 +116,7 @@ to exclude before syncing. Navigate with j/k, toggle with space.`,
 
 		opts := sync.DefaultOptions()
 		opts.Force = syncForce
-		opts.DryRun = syncDryRun
+		opts.DryRun = dryRun
 		opts.NoGit = syncNoGit
 		opts.IncludeEnv = syncIncludeEnv
 		opts.ExcludePatterns = syncExcludes
@@ -164,6 +168,17 @@ to exclude before syncing. Navigate with j/k, toggle with space.`,
 			}
 		}
 
+		if syncForce && !dryRun {
+			ok, err := confirmOp(cfg, config.ConfirmOverwrite, fmt.Sprintf("Overwrite %s:%s/%s if it exists?", server.SSH, server.CodeRoot, slug))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Sync cancelled.")
+				return nil
+			}
+		}
+
 		fmt.Printf("Syncing %s to %s:%s/%s\n", slug, server.SSH, server.CodeRoot, slug)
 
 		result, err := sync.SyncWorkspace(localPath, server, slug, opts)
@@ -190,8 +205,8 @@ to exclude before syncing. Navigate with j/k, toggle with space.`,
 }
 
 func init() {
+	takesSlugs(syncCmd, "first")
 	syncCmd.Flags().BoolVar(&syncForce, "force", false, "sync even if remote path exists")
-	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "preview changes without syncing")
 	syncCmd.Flags().BoolVar(&syncNoGit, "no-git", false, "exclude .git directories")
 	syncCmd.Flags().BoolVar(&syncIncludeEnv, "include-env", false, "include .env files (overrides default exclude)")
 	syncCmd.Flags().StringArrayVar(&syncExcludes, "exclude", nil, "add an exclude pattern (repeatable)")
@@ -15,7 +15,6 @@ import (
 
 var (
 	syncBatchForce       bool
-	syncBatchDryRun      bool
 	syncBatchNoGit       bool
 	syncBatchIncludeEnv  bool
 	syncBatchExcludes    []string
@@ -27,7 +26,8 @@ var syncBatchCmd = &cobra.Command{
 	Short: "Interactively sync multiple workspaces to a remote server",
 	Long: `Select multiple workspaces, then sync each to a remote server.
 Repos are cloned on the target machine; existing repos are skipped.`,
-	Args: cobra.ExactArgs(1),
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		serverName := args[0]
 
@@ -35,6 +35,9 @@ Repos are cloned on the target machine; existing repos are skipped.`,
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		if err := cfg.CheckOnline("sync"); err != nil {
+			return err
+		}
 
 		idx, err := model.LoadIndex(cfg.IndexPath())
 		if err != nil {
@@ -43,7 +46,7 @@ Repos are cloned on the target machine; existing repos are skipped.`,
 
 		pickerResult, err := tui.RunSyncPicker(idx.Records)
 		if err != nil {
-			return fmt.Errorf("sync picker failed: %w", err)
+			return promptError("sync picker failed", err, "run co sync <slug> <server> for each workspace")
 		}
 		if pickerResult.Aborted || len(pickerResult.Slugs) == 0 {
 			fmt.Println("Sync cancelled.")
@@ -51,6 +54,16 @@ Repos are cloned on the target machine; existing repos are skipped.`,
 		}
 
 		server := cfg.GetServer(serverName)
+		if syncBatchForce && !dryRun {
+			ok, err := confirmOp(cfg, config.ConfirmOverwrite, fmt.Sprintf("Overwrite %d workspace(s) on %s if they exist?", len(pickerResult.Slugs), serverName))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Sync cancelled.")
+				return nil
+			}
+		}
 		results := make([]batchResult, 0, len(pickerResult.Slugs))
 
 		for _, slug := range pickerResult.Slugs {
@@ -67,7 +80,7 @@ Repos are cloned on the target machine; existing repos are skipped.`,
 
 			opts := sync.DefaultOptions()
 			opts.Force = syncBatchForce
-			opts.DryRun = syncBatchDryRun
+			opts.DryRun = dryRun
 			opts.NoGit = syncBatchNoGit
 			opts.IncludeEnv = syncBatchIncludeEnv
 			opts.ExcludePatterns = syncBatchExcludes
@@ -120,7 +133,6 @@ type batchResult struct {
 
 func init() {
 	syncBatchCmd.Flags().BoolVar(&syncBatchForce, "force", false, "sync even if remote path exists")
-	syncBatchCmd.Flags().BoolVar(&syncBatchDryRun, "dry-run", false, "preview changes without syncing")
 	syncBatchCmd.Flags().BoolVar(&syncBatchNoGit, "no-git", false, "exclude .git directories")
 	syncBatchCmd.Flags().BoolVar(&syncBatchIncludeEnv, "include-env", false, "include .env files (overrides default exclude)")
 	syncBatchCmd.Flags().StringArrayVar(&syncBatchExcludes, "exclude", nil, "add an exclude pattern (repeatable)")
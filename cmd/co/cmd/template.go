@@ -4,12 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/platform"
 	"github.com/tormodhaugland/co/internal/template"
 	"github.com/tormodhaugland/co/internal/tui"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var templateCmd = &cobra.Command{
@@ -23,7 +29,11 @@ where you can browse, inspect, create workspaces from, and validate templates.
 Subcommands are available for non-interactive use:
   list      - List all templates
   show      - Show template details
-  validate  - Validate templates`,
+  validate  - Validate templates
+  globals   - Browse and edit _global files
+  search    - Search the template catalog
+  install   - Install a template from the catalog
+  trust     - Allow the hooks of an installed template to run`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
@@ -109,6 +119,8 @@ var templateShowCmd = &cobra.Command{
 		if tmpl.Version != "" {
 			fmt.Printf("Version: %s\n", tmpl.Version)
 		}
+		trust, _ := template.TrustStatus(filepath.Join(cfg.TemplatesDir(), tmpl.Name))
+		fmt.Printf("Trust: %s\n", trust.Status())
 		fmt.Println()
 
 		if len(tmpl.Variables) > 0 {
@@ -148,6 +160,17 @@ var templateShowCmd = &cobra.Command{
 			fmt.Println()
 		}
 
+		if len(tmpl.Directories) > 0 {
+			fmt.Println("Directories:")
+			for _, dir := range tmpl.Directories {
+				fmt.Printf("  - %s/\n", dir)
+			}
+			if tmpl.IsStructure() {
+				fmt.Println("  (structure template: apply to existing workspaces with co apply-structure)")
+			}
+			fmt.Println()
+		}
+
 		hooks := template.ListHooks(tmpl)
 		if len(hooks) > 0 {
 			fmt.Println("Hooks:")
@@ -176,7 +199,8 @@ var templateShowCmd = &cobra.Command{
 var templateValidateCmd = &cobra.Command{
 	Use:   "validate [name]",
 	Short: "Validate templates",
-	Long:  `Validates one or all templates, checking for errors in the manifest and missing files.`,
+	Long: `Validates one or all templates, checking for errors in the manifest and missing files,
+and that template files suit the template's engine.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
@@ -224,9 +248,320 @@ var templateValidateCmd = &cobra.Command{
 	},
 }
 
+var templateGlobalsEdit bool
+
+var templateGlobalsCmd = &cobra.Command{
+	Use:   "globals [file]",
+	Short: "Browse and edit _global template files",
+	Long: `Lists _global files across all template directories, showing which
+templates override or skip each file and which workspaces received it.
+
+With a file argument, shows details for that file. Use --edit to open the
+winning source file in the configured editor.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		infos, err := template.ListGlobalFileInfos(cfg.AllTemplatesDirs())
+		if err != nil {
+			return fmt.Errorf("failed to list global files: %w", err)
+		}
+
+		refs, err := workspaceTemplateRefs(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to list workspaces: %w", err)
+		}
+		template.ResolveGlobalFileWorkspaces(infos, refs)
+
+		if len(args) == 0 {
+			if templateGlobalsEdit {
+				return fmt.Errorf("--edit requires a file argument")
+			}
+			return printGlobalFiles(cfg, infos)
+		}
+
+		info := template.FindGlobalFileInfo(infos, args[0])
+		if info == nil {
+			return fmt.Errorf("global file not found: %s", args[0])
+		}
+
+		if templateGlobalsEdit {
+			return editFile(cfg, info.SourcePath)
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+
+		fmt.Printf("File: %s\n", info.OutputPath)
+		fmt.Printf("Source: %s\n", info.SourcePath)
+		if info.IsTemplate {
+			fmt.Println("Template: yes (variables are substituted)")
+		}
+		for _, dir := range info.ShadowedIn {
+			fmt.Printf("Shadows: %s\n", filepath.Join(template.GetGlobalFilesPath(dir), info.SourceRel))
+		}
+		fmt.Println()
+		printNameList("Overridden by", info.OverriddenBy)
+		printNameList("Skipped by", info.SkippedBy)
+		printNameList("Workspaces", info.Workspaces)
+
+		return nil
+	},
+}
+
+// workspaceTemplateRefs returns every workspace that records the template it was created from.
+func workspaceTemplateRefs(cfg *config.Config) ([]template.WorkspaceTemplateRef, error) {
+	slugs, err := workspace.ListWorkspaces(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []template.WorkspaceTemplateRef
+	for _, slug := range slugs {
+		path := cfg.WorkspacePath(slug)
+		proj, err := model.LoadProject(filepath.Join(path, "project.json"))
+		if err != nil || proj.Template == "" {
+			continue
+		}
+		refs = append(refs, template.WorkspaceTemplateRef{
+			Slug:     slug,
+			Path:     path,
+			Template: proj.Template,
+		})
+	}
+	return refs, nil
+}
+
+func printGlobalFiles(cfg *config.Config, infos []template.GlobalFileInfo) error {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+
+	if len(infos) == 0 {
+		fmt.Println("No global files found")
+		fmt.Printf("\nGlobal files directory: %s\n", template.GetGlobalFilesPath(cfg.TemplatesDir()))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tSOURCE\tOVERRIDDEN BY\tSKIPPED BY\tWORKSPACES")
+	for _, info := range infos {
+		source := "primary"
+		if info.OriginDir != cfg.TemplatesDir() {
+			source = "fallback"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n",
+			info.OutputPath, source, joinOrDash(info.OverriddenBy), joinOrDash(info.SkippedBy), len(info.Workspaces))
+	}
+	w.Flush()
+
+	return nil
+}
+
+func printNameList(label string, names []string) {
+	if len(names) == 0 {
+		fmt.Printf("%s: none\n", label)
+		return
+	}
+	fmt.Printf("%s:\n", label)
+	for _, name := range names {
+		fmt.Printf("  - %s\n", name)
+	}
+}
+
+func joinOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ",")
+}
+
+// editFile opens a file in the configured editor, or the system opener.
+func editFile(cfg *config.Config, path string) error {
+	editCmd := platform.OpenCommand(path)
+	if cfg.Editor != "" {
+		editCmd = exec.Command(cfg.Editor, path)
+	}
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = os.Stdout
+	editCmd.Stderr = os.Stderr
+	return editCmd.Run()
+}
+
+var (
+	templateInstallForce bool
+	templateTrustRevoke  bool
+)
+
+var templateSearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search the template catalog",
+	Long: `Searches the template catalog set by "template_catalog" in the config, a
+static JSON index served over HTTP or read from a file. Matches names,
+descriptions, and tags; the most installed templates are listed first.
+Without a query, lists the whole catalog.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		catalog, err := template.FetchCatalog(cfg)
+		if err != nil {
+			return err
+		}
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		matches := catalog.Search(query)
+
+		if jsonOut {
+			if matches == nil {
+				matches = []template.CatalogEntry{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(matches)
+		}
+
+		if len(matches) == 0 {
+			fmt.Printf("No templates match %q\n", query)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tINSTALLS\tDESCRIPTION\tINSTALLED")
+		for _, e := range matches {
+			desc := e.Description
+			if len(desc) > 50 {
+				desc = desc[:47] + "..."
+			}
+			installed := ""
+			if _, err := os.Stat(filepath.Join(cfg.TemplatesDir(), e.Name)); err == nil {
+				installed = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", e.Name, e.Installs, desc, installed)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var templateInstallCmd = &cobra.Command{
+	Use:   "install <name>",
+	Short: "Install a template from the catalog",
+	Long: `Fetches a template from the template catalog into the templates directory
+(_system/templates). The template is validated before it is installed.
+Use --force to replace an installed template of the same name.
+
+Archives signed with minisign by a key in "template_trust.keys" are trusted.
+Other templates are installed untrusted: their hooks are blocked until you
+review them and run 'co template trust <name>'. With
+"template_trust.require_signature" they are not installed at all.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		catalog, err := template.FetchCatalog(cfg)
+		if err != nil {
+			return err
+		}
+		name := args[0]
+		if _, err := os.Stat(filepath.Join(cfg.TemplatesDir(), name)); err == nil && templateInstallForce {
+			ok, err := confirmOp(cfg, config.ConfirmOverwrite, fmt.Sprintf("Replace installed template '%s'?", name))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Install cancelled.")
+				return nil
+			}
+		}
+
+		result, err := catalog.Install(cfg, name, templateInstallForce)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		if result.Replaced {
+			fmt.Printf("Replaced template %s from %s\n", result.Name, result.Source)
+		} else {
+			fmt.Printf("Installed template %s from %s\n", result.Name, result.Source)
+		}
+		fmt.Printf("Path: %s\n", result.Path)
+		fmt.Printf("Trust: %s\n", result.Trust.Status())
+		if !result.Trust.Trusted {
+			fmt.Printf("Hooks are blocked until you review them and run 'co template trust %s'.\n", result.Name)
+		}
+		return nil
+	},
+}
+
+var templateTrustCmd = &cobra.Command{
+	Use:   "trust <name>",
+	Short: "Allow the hooks of an installed template to run",
+	Long: `Marks a template installed from the catalog as trusted, so its hooks run
+when it is used. Review the template's hooks first. Use --revoke to block
+them again. Templates you wrote yourself are always trusted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		name := args[0]
+		if err := template.SetTrusted(cfg.TemplatesDir(), name, !templateTrustRevoke); err != nil {
+			return err
+		}
+		record, _ := template.TrustStatus(filepath.Join(cfg.TemplatesDir(), name))
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(record)
+		}
+		if templateTrustRevoke {
+			fmt.Printf("Hooks of template %s are blocked\n", name)
+		} else {
+			fmt.Printf("Trusted template %s\n", name)
+		}
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(templateCmd)
 	templateCmd.AddCommand(templateListCmd)
 	templateCmd.AddCommand(templateShowCmd)
 	templateCmd.AddCommand(templateValidateCmd)
+	templateCmd.AddCommand(templateGlobalsCmd)
+	templateCmd.AddCommand(templateSearchCmd)
+	templateCmd.AddCommand(templateInstallCmd)
+	templateCmd.AddCommand(templateTrustCmd)
+
+	templateGlobalsCmd.Flags().BoolVarP(&templateGlobalsEdit, "edit", "e", false, "open the global file in the editor")
+	templateInstallCmd.Flags().BoolVar(&templateInstallForce, "force", false, "replace an installed template of the same name")
+	templateTrustCmd.Flags().BoolVar(&templateTrustRevoke, "revoke", false, "block the template's hooks again")
 }
@@ -12,11 +12,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
 	"github.com/tormodhaugland/co/internal/model"
-)
-
-var (
-	tmpCleanDryRun bool
+	"github.com/tormodhaugland/co/internal/quarantine"
 )
 
 var tmpCmd = &cobra.Command{
@@ -54,9 +52,12 @@ var tmpCleanCmd = &cobra.Command{
 	Long: `Removes temporary workspaces that have been inactive for longer
 than the configured threshold (default: 30 days).
 
-Use --dry-run to preview what would be removed without deleting.`,
-	Args: cobra.NoArgs,
-	RunE: runTmpClean,
+Use --dry-run to preview what would be removed without deleting.
+Repos with uncommitted changes or unpushed commits are listed and need a
+second confirmation, or --discard-unsaved.`,
+	Args:        cobra.NoArgs,
+	Annotations: supportsDryRun,
+	RunE:        runTmpClean,
 }
 
 var tmpRmCmd = &cobra.Command{
@@ -65,11 +66,15 @@ var tmpRmCmd = &cobra.Command{
 	Long: `Removes a specific temporary workspace.
 
 The name should be without the "tmp--" prefix.
+Use --dry-run to preview what would be removed without deleting.
+Repos with uncommitted changes or unpushed commits are listed and need a
+second confirmation, or --discard-unsaved.
 
 Example:
   co tmp rm experiment  # Removes ~/Code/tmp--experiment`,
-	Args: cobra.ExactArgs(1),
-	RunE: runTmpRemove,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE:        runTmpRemove,
 }
 
 func init() {
@@ -77,8 +82,8 @@ func init() {
 	tmpCmd.AddCommand(tmpLsCmd)
 	tmpCmd.AddCommand(tmpCleanCmd)
 	tmpCmd.AddCommand(tmpRmCmd)
-
-	tmpCleanCmd.Flags().BoolVar(&tmpCleanDryRun, "dry-run", false, "Preview what would be removed without deleting")
+	tmpCleanCmd.Flags().BoolVar(&discardUnsaved, "discard-unsaved", false, discardUnsavedUsage)
+	tmpRmCmd.Flags().BoolVar(&discardUnsaved, "discard-unsaved", false, discardUnsavedUsage)
 }
 
 func runTmpCreate(cmd *cobra.Command, args []string) error {
@@ -102,14 +107,13 @@ func runTmpCreate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create workspace directory with repos subdirectory
-	if err := os.MkdirAll(filepath.Join(workspacePath, "repos"), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(workspacePath, "repos"), fs.DirPerm()); err != nil {
 		return fmt.Errorf("failed to create workspace: %w", err)
 	}
 
 	// Create a minimal project.json
-	proj := model.NewProject("tmp", name)
+	proj := model.NewProject(slug, "tmp", name)
 	proj.State = model.StateTmp
-	proj.Slug = slug
 
 	if err := proj.Save(workspacePath); err != nil {
 		return fmt.Errorf("failed to save project.json: %w", err)
@@ -229,9 +233,14 @@ func runTmpClean(cmd *cobra.Command, args []string) error {
 		modTime := time.Unix(lastMod, 0)
 		days := int(now.Sub(modTime).Hours() / 24)
 
-		if days >= threshold {
-			stale = append(stale, slug)
+		if days < threshold {
+			continue
 		}
+		if err := cfg.CheckRemovable(workspacePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", slug, err)
+			continue
+		}
+		stale = append(stale, slug)
 	}
 
 	if len(stale) == 0 {
@@ -239,12 +248,32 @@ func runTmpClean(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	if tmpCleanDryRun {
-		fmt.Printf("Would remove %d tmp workspace(s) (inactive for %d+ days):\n", len(stale), threshold)
+	if dryRun {
+		plan := model.NewPlan(fmt.Sprintf("Remove %d tmp workspace(s) inactive for %d+ days", len(stale), threshold))
 		for _, slug := range stale {
-			name := strings.TrimPrefix(slug, "tmp--")
-			fmt.Printf("  %s\n", name)
+			quarantine.PlanDelete(cfg, plan, filepath.Join(cfg.CodeRoot, slug))
 		}
+		return printPlan(plan)
+	}
+
+	ok, err := confirmOp(cfg, config.ConfirmDelete, fmt.Sprintf("Remove %d tmp workspace(s) inactive for %d+ days?", len(stale), threshold))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Clean cancelled.")
+		return nil
+	}
+	var unsaved []git.UnsavedWork
+	for _, slug := range stale {
+		unsaved = append(unsaved, git.FindUnsavedWork(filepath.Join(cfg.CodeRoot, slug))...)
+	}
+	ok, err = confirmUnsaved(unsaved)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Clean cancelled.")
 		return nil
 	}
 
@@ -264,10 +293,15 @@ func runTmpClean(cmd *cobra.Command, args []string) error {
 		workspacePath := filepath.Join(cfg.CodeRoot, slug)
 		name := strings.TrimPrefix(slug, "tmp--")
 
-		if err := os.RemoveAll(workspacePath); err != nil {
+		entry, err := quarantine.Delete(cfg, workspacePath)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "  Warning: failed to remove %s: %v\n", name, err)
 			continue
 		}
+		if entry != nil {
+			fmt.Printf("  Quarantined: %s (%s)\n", name, entry.ID)
+			continue
+		}
 		fmt.Printf("  Removed: %s\n", name)
 	}
 
@@ -292,8 +326,35 @@ func runTmpRemove(cmd *cobra.Command, args []string) error {
 	if !fs.WorkspaceExists(cfg.CodeRoot, slug) {
 		return fmt.Errorf("tmp workspace does not exist: %s", name)
 	}
+	if err := cfg.CheckRemovable(workspacePath); err != nil {
+		return err
+	}
+
+	if dryRun {
+		plan := model.NewPlan(fmt.Sprintf("Remove tmp workspace %s", name))
+		quarantine.PlanDelete(cfg, plan, workspacePath)
+		return printPlan(plan)
+	}
+
+	ok, err := confirmOp(cfg, config.ConfirmDelete, fmt.Sprintf("Remove tmp workspace '%s'?", name))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Remove cancelled.")
+		return nil
+	}
+	ok, err = confirmUnsaved(git.FindUnsavedWork(workspacePath))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("Remove cancelled.")
+		return nil
+	}
 
-	if err := os.RemoveAll(workspacePath); err != nil {
+	entry, err := quarantine.Delete(cfg, workspacePath)
+	if err != nil {
 		return fmt.Errorf("failed to remove workspace: %w", err)
 	}
 
@@ -302,11 +363,18 @@ func runTmpRemove(cmd *cobra.Command, args []string) error {
 			"removed": slug,
 			"path":    workspacePath,
 		}
+		if entry != nil {
+			result["quarantined"] = entry.ID
+		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(result)
 	}
 
+	if entry != nil {
+		fmt.Printf("Moved tmp workspace %s to quarantine (%s)\n", name, entry.ID)
+		return nil
+	}
 	fmt.Printf("Removed tmp workspace: %s\n", name)
 	return nil
 }
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var trustCmd = &cobra.Command{
+	Use:   "trust <workspace-slug>",
+	Short: "Allow a workspace's .envrc or mise.toml",
+	Long: `Runs 'direnv allow' for the workspace's .envrc and 'mise trust' for its
+mise.toml, so the workspace env and tool versions load when you cd into it.
+
+co writes these files into new and imported workspaces when shell_env.generate
+is set in the config.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := args[0]
+
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !workspace.Exists(cfg, slug) {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+		}
+		workspacePath := cfg.WorkspacePath(slug)
+
+		type trusted struct {
+			Tool string `json:"tool"`
+			Path string `json:"path"`
+		}
+		var done []trusted
+		for _, t := range []struct {
+			file string
+			tool string
+			args []string
+		}{
+			{template.EnvrcFile, "direnv", []string{"allow"}},
+			{template.MiseFile, "mise", []string{"trust"}},
+		} {
+			path := filepath.Join(workspacePath, t.file)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if _, err := exec.LookPath(t.tool); err != nil {
+				return fmt.Errorf("%s has a %s but %s is not installed", slug, t.file, t.tool)
+			}
+			c := exec.Command(t.tool, append(t.args, path)...)
+			c.Stdout = os.Stderr
+			c.Stderr = os.Stderr
+			if err := c.Run(); err != nil {
+				return fmt.Errorf("%s %s failed: %w", t.tool, t.args[0], err)
+			}
+			done = append(done, trusted{Tool: t.tool, Path: path})
+		}
+		if len(done) == 0 {
+			return fmt.Errorf("%s has no %s or %s (set shell_env.generate in the config)", slug, template.EnvrcFile, template.MiseFile)
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(done)
+		}
+		for _, d := range done {
+			fmt.Printf("✓ %s: trusted %s\n", d.Tool, d.Path)
+		}
+		return nil
+	},
+}
+
+// printShellEnvWritten reports the shell env files written into a new
+// workspace.
+func printShellEnvWritten(paths []string, slug string) {
+	for _, path := range paths {
+		fmt.Printf("Wrote %s\n", path)
+	}
+	if len(paths) > 0 {
+		fmt.Printf("Run 'co trust %s' to allow it.\n", slug)
+	}
+}
+
+func init() {
+	takesSlugs(trustCmd, "first")
+	rootCmd.AddCommand(trustCmd)
+}
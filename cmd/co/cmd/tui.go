@@ -11,8 +11,15 @@ import (
 var tuiCmd = &cobra.Command{
 	Use:   "tui",
 	Short: "Launch the interactive TUI dashboard",
-	Long:  `Opens the terminal user interface for browsing and managing workspaces.`,
+	Long: `Opens the terminal user interface for browsing and managing workspaces.
+
+Without a terminal on stdin and stdout, as in CI or when piped, it lists the
+workspaces like 'co ls' instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if !tui.Terminal() {
+			return lsCmd.RunE(cmd, nil)
+		}
+
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
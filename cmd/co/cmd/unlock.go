@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/lock"
+)
+
+var (
+	unlockArchives bool
+	unlockAll      bool
+	unlockForce    bool
+)
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock [workspace-slug]",
+	Short: "List or remove workspace and archive locks",
+	Long: `Lists or removes the advisory locks co takes while mutating a workspace
+or the archive directory.
+
+Locks prevent two co processes (for example a watch daemon and an interactive
+import) from modifying the same workspace at once. Locks left behind by a
+process that has exited are detected and replaced automatically; use this
+command when a lock is stuck anyway, e.g. after a crash on another machine
+sharing the code root.
+
+Without arguments, lists current locks and whether they are stale.
+Removing a lock held by a running process requires --force.
+
+Examples:
+  co unlock                   # list locks
+  co unlock acme--webapp      # remove a workspace lock
+  co unlock --archives        # remove the archive directory lock
+  co unlock --all             # remove all stale locks
+  co unlock --all --force     # remove every lock`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		locks, err := lock.List(cfg.LocksDir())
+		if err != nil {
+			return fmt.Errorf("failed to list locks: %w", err)
+		}
+
+		if len(args) == 0 && !unlockArchives && !unlockAll {
+			return printLocks(locks)
+		}
+
+		var names []string
+		switch {
+		case unlockAll:
+			for _, l := range locks {
+				if l.Stale || unlockForce {
+					names = append(names, l.Name)
+				}
+			}
+		case unlockArchives:
+			names = []string{lock.ArchivesName}
+		default:
+			names = []string{lock.WorkspaceName(args[0])}
+		}
+
+		if !unlockAll {
+			for _, l := range locks {
+				if l.Name == names[0] && !l.Stale && !unlockForce {
+					return fmt.Errorf("%s is held by running process %d (%s); use --force to remove it anyway", l.Name, l.PID, l.Command)
+				}
+			}
+		}
+
+		for _, name := range names {
+			if err := lock.Remove(cfg.LocksDir(), name); err != nil {
+				return err
+			}
+			fmt.Printf("Removed lock %s\n", name)
+		}
+		if unlockAll && len(names) == 0 {
+			fmt.Println("No stale locks.")
+		}
+		return nil
+	},
+}
+
+func printLocks(locks []lock.Info) error {
+	if jsonOut {
+		if locks == nil {
+			locks = []lock.Info{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(locks)
+	}
+
+	if len(locks) == 0 {
+		fmt.Println("No locks held.")
+		return nil
+	}
+	for _, l := range locks {
+		status := "held"
+		if l.Stale {
+			status = "stale"
+		}
+		fmt.Printf("%-40s %-6s pid %d on %s since %s\n", l.Name, status, l.PID, l.Host, l.AcquiredAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("%-40s %s\n", "", l.Command)
+	}
+	return nil
+}
+
+func init() {
+	takesSlugs(unlockCmd, "first")
+	rootCmd.AddCommand(unlockCmd)
+	unlockCmd.Flags().BoolVar(&unlockArchives, "archives", false, "remove the archive directory lock")
+	unlockCmd.Flags().BoolVar(&unlockAll, "all", false, "remove all stale locks (with --force, all locks)")
+	unlockCmd.Flags().BoolVar(&unlockForce, "force", false, "remove locks even if the holding process is running")
+}
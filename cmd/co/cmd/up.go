@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var upCmd = &cobra.Command{
+	Use:   "up <workspace-slug>",
+	Short: "Launch a workspace's dev container or Nix shell",
+	Long: `Launches the dev environment of a workspace: 'devcontainer up' when it has a
+.devcontainer/devcontainer.json, or 'nix develop' when it has a flake.nix.
+
+Templates generate these files from their devcontainer and nix sections.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		slug := args[0]
+
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !workspace.Exists(cfg, slug) {
+			return fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+		}
+
+		upCmd, err := template.UpCommand(cfg.WorkspacePath(slug))
+		if err != nil {
+			return fmt.Errorf("%s: %w", slug, err)
+		}
+		upCmd.Stdin = os.Stdin
+		upCmd.Stdout = os.Stdout
+		upCmd.Stderr = os.Stderr
+		return upCmd.Run()
+	},
+}
+
+func init() {
+	takesSlugs(upCmd, "first")
+	rootCmd.AddCommand(upCmd)
+}
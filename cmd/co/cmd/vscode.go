@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/server"
+	"github.com/tormodhaugland/co/internal/vscode"
+)
+
+var (
+	vscodeAddr     string
+	vscodeInsiders bool
+	vscodeUserDir  string
+	vscodePrint    bool
+)
+
+var vscodeCmd = &cobra.Command{
+	Use:   "vscode",
+	Short: "Integrate co with VS Code",
+	Long: `Helpers for the companion VS Code extension.
+
+The extension talks to 'co serve' to list workspaces, open them
+(GET /v1/workspaces/{slug}/vscode), and trigger imports (POST /v1/import).
+
+Subcommands:
+  install   - Write the extension settings and co tasks into VS Code`,
+}
+
+var vscodeInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Write VS Code settings and tasks for the co extension",
+	Long: `Merges the settings the co extension needs (executable path, server URL,
+and server info file) into the VS Code user settings.json, and adds "co: ..."
+tasks (serve, index, import current folder, dashboard) to the user tasks.json.
+
+Existing settings and tasks are kept; co's own entries are replaced, so running
+install again is safe. Files containing comments cannot be merged; use --print
+to output the JSON and merge it by hand.
+
+Examples:
+  co vscode install
+  co vscode install --insiders
+  co vscode install --print`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to resolve co executable: %w", err)
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+
+		settings := vscode.Settings(exe, "http://"+vscodeAddr+server.APIVersion, server.InfoPath(cfg))
+		tasks := vscode.Tasks(exe)
+
+		if vscodePrint {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "    ")
+			return enc.Encode(map[string]any{
+				"settings": settings,
+				"tasks":    tasks,
+			})
+		}
+
+		userDir := vscodeUserDir
+		if userDir == "" {
+			userDir = vscode.UserDir(vscodeInsiders)
+		}
+
+		result, err := vscode.Install(userDir, settings, tasks)
+		if err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(result)
+		}
+
+		fmt.Printf("Updated settings: %s\n", result.SettingsPath)
+		fmt.Printf("Updated tasks:    %s\n", result.TasksPath)
+		fmt.Println("Run 'co serve' (or the \"co: serve\" task) so the extension can connect.")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vscodeCmd)
+	vscodeCmd.AddCommand(vscodeInstallCmd)
+
+	vscodeInstallCmd.Flags().StringVar(&vscodeAddr, "addr", server.DefaultAddr, "address co serve listens on")
+	vscodeInstallCmd.Flags().BoolVar(&vscodeInsiders, "insiders", false, "install into VS Code Insiders")
+	vscodeInstallCmd.Flags().StringVar(&vscodeUserDir, "user-dir", "", "VS Code user settings directory (default: platform location)")
+	vscodeInstallCmd.Flags().BoolVar(&vscodePrint, "print", false, "print the settings and tasks instead of writing them")
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+var whichRepo string
+
+var whichCmd = &cobra.Command{
+	Use:   "which <name>",
+	Short: "Print the path of a workspace or repo matching a name",
+	Long: `Resolves a workspace or repo name, exact or fuzzy, to its absolute path,
+for scripts and cd $(co which api). Unlike co cd, it never prompts.
+
+The name is tried as, in order:
+  1. a workspace slug
+  2. the name of a repo, when only one workspace has a repo of that name
+  3. a fuzzy match of a workspace slug
+  4. a fuzzy match of <slug>/<repo> across all workspaces
+
+Repos across workspaces are looked up in the index, so run co index after
+adding repos. --repo resolves the workspace first and then a repo inside it,
+exact or fuzzy, from the workspace itself.
+
+When several names match equally well, the one used is noted on stderr.
+
+Examples:
+  co which acme--api                # /code/acme--api
+  co which api                      # fuzzy workspace match
+  co which api-server               # a repo, found in the index
+  co which api --repo server        # a repo of the workspace matching api
+  cd "$(co which api --repo server)"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var slug, repo, path string
+		if whichRepo != "" {
+			if slug, err = resolveWorkspace(cfg, args[0]); err != nil {
+				return err
+			}
+			if repo, path, err = resolveRepo(cfg.WorkspacePath(slug), whichRepo); err != nil {
+				return fmt.Errorf("%w in %s", err, slug)
+			}
+		} else if slug, repo, path, err = resolveWhich(cfg, args[0]); err != nil {
+			return err
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]string{"slug": slug, "repo": repo, "path": path})
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+// resolveWhich resolves name to a workspace, or a repo in one, in the order
+// described by co which --help. repo is empty when name resolves to a
+// workspace.
+func resolveWhich(cfg *config.Config, name string) (slug, repo, path string, err error) {
+	if workspace.Exists(cfg, name) {
+		return name, "", cfg.WorkspacePath(name), nil
+	}
+
+	idx, err := model.LoadIndex(cfg.IndexPath())
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to load index: %w", err)
+	}
+	repoPaths := make(map[string]string) // "<slug>/<repo>" to its path
+	var exact []string
+	for _, r := range idx.Records {
+		for _, info := range r.Repos {
+			key := r.Slug + "/" + info.Name
+			repoPaths[key] = filepath.Join(r.Path, filepath.FromSlash(info.Path))
+			if info.Name == name {
+				exact = append(exact, key)
+			}
+		}
+	}
+	if len(exact) > 1 {
+		sort.Strings(exact)
+		return "", "", "", fmt.Errorf("several workspaces have a repo named %s (%s); name the workspace with --repo", name, strings.Join(exact, ", "))
+	}
+	if len(exact) == 1 {
+		slug, repo, _ = strings.Cut(exact[0], "/")
+		return slug, repo, repoPaths[exact[0]], nil
+	}
+
+	workspaces, err := workspace.ListWorkspaces(cfg)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	if best, ok := fuzzyBest(name, workspaces); ok {
+		return best, "", cfg.WorkspacePath(best), nil
+	}
+
+	keys := make([]string, 0, len(repoPaths))
+	for key := range repoPaths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if best, ok := fuzzyBest(name, keys); ok {
+		slug, repo, _ = strings.Cut(best, "/")
+		return slug, repo, repoPaths[best], nil
+	}
+	return "", "", "", fmt.Errorf("no workspace or repo found matching: %s", name)
+}
+
+// resolveRepo returns the name and path of the repo named name, or the best
+// fuzzy match of it, in the workspace at workspacePath. Repos are located
+// from its project.json, so flat layouts work, or under repos/ without one.
+func resolveRepo(workspacePath, name string) (string, string, error) {
+	specs, err := model.LocateWorkspaceRepos(workspacePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list repos: %w", err)
+	}
+	paths := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		paths[spec.Name] = filepath.Join(workspacePath, filepath.FromSlash(spec.Path))
+	}
+
+	if path, ok := paths[name]; ok {
+		return name, path, nil
+	}
+	names := make([]string, 0, len(paths))
+	for n := range paths {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	best, ok := fuzzyBest(name, names)
+	if !ok {
+		return "", "", fmt.Errorf("no repo found matching: %s", name)
+	}
+	return best, paths[best], nil
+}
+
+func init() {
+	takesSlugs(whichCmd, "first")
+	whichCmd.Flags().StringVarP(&whichRepo, "repo", "r", "", "resolve to this repo, exact or fuzzy, inside the workspace")
+	rootCmd.AddCommand(whichCmd)
+}
@@ -3,21 +3,32 @@ package archive
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/lock"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/quarantine"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
+// ErrArchiveCorrupt is returned, wrapped with the details, when an archive
+// cannot be read back.
+var ErrArchiveCorrupt = errors.New("archive is corrupt")
+
 type ArchiveMeta struct {
 	Schema      int       `json:"schema"`
 	Slug        string    `json:"slug"`
@@ -28,23 +39,44 @@ type ArchiveMeta struct {
 }
 
 type Result struct {
-	ArchivePath string `json:"archive_path"`
-	BundleCount int    `json:"bundle_count"`
-	FullArchive bool   `json:"full_archive,omitempty"`
-	Deleted     bool   `json:"deleted"`
-	Error       string `json:"error,omitempty"`
+	ArchivePath string      `json:"archive_path"`
+	BundleCount int         `json:"bundle_count"`
+	FullArchive bool        `json:"full_archive,omitempty"`
+	Deleted     bool        `json:"deleted"`
+	Quarantined string      `json:"quarantined,omitempty"` // Quarantine ID when the delete was quarantined
+	Error       string      `json:"error,omitempty"`
+	Plan        *model.Plan `json:"plan,omitempty"` // Set for dry runs
+
+	// Pruned lists older archives of the workspace removed under the owner's
+	// archive_retention_days policy
+	Pruned []string `json:"pruned,omitempty"`
+
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 type Options struct {
 	Reason      string
 	DeleteAfter bool
 	Full        bool
+	DryRun      bool // Report the planned actions without archiving
 }
 
 func ArchiveWorkspace(cfg *config.Config, slug string, opts Options) (*Result, error) {
+	return ArchiveWorkspaceContext(context.Background(), cfg, slug, opts)
+}
+
+// ArchiveWorkspaceContext archives like ArchiveWorkspace, stopping bundling
+// and packing when ctx is done. A cancelled archive is removed and the
+// workspace is left in place.
+func ArchiveWorkspaceContext(ctx context.Context, cfg *config.Config, slug string, opts Options) (*Result, error) {
 	workspacePath := cfg.WorkspacePath(slug)
-	if !fs.WorkspaceExists(cfg.CodeRoot, slug) {
-		return nil, fmt.Errorf("workspace not found: %s", slug)
+	if !workspace.Exists(cfg, slug) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+	}
+	if opts.DeleteAfter {
+		if err := cfg.CheckRemovable(workspacePath); err != nil {
+			return nil, err
+		}
 	}
 
 	now := time.Now()
@@ -52,40 +84,206 @@ func ArchiveWorkspace(cfg *config.Config, slug string, opts Options) (*Result, e
 	timestamp := now.Format("20060102-150405")
 
 	archiveDir := filepath.Join(cfg.ArchiveDir(), year)
+	if opts.DryRun {
+		return planArchive(cfg, slug, workspacePath, archiveDir, timestamp, opts), nil
+	}
+
+	wsLock, err := lock.Workspace(cfg, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer wsLock.Release()
+	archiveLock, err := lock.Archives(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer archiveLock.Release()
 	if err := fs.EnsureDir(archiveDir); err != nil {
 		return nil, fmt.Errorf("failed to create archive directory: %w", err)
 	}
+	var warnings []string
+	if err := runArchiveHook(cfg, workspacePath); err != nil {
+		warnings = append(warnings, fmt.Sprintf("archive hook failed: %v", err))
+	}
 
 	if opts.Full {
-		return archiveFullWorkspace(cfg, slug, workspacePath, archiveDir, timestamp, now, opts)
+		if err := checkArchiveSpace(archiveDir, workspacePath); err != nil {
+			return nil, err
+		}
+		result, err := archiveFullWorkspace(ctx, cfg, slug, workspacePath, archiveDir, timestamp, now, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Pruned = pruneArchives(cfg, slug, now)
+		result.Warnings = warnings
+		return result, nil
+	}
+
+	// Bundles are written to a temp directory before being packed
+	repos, _ := model.LocateWorkspaceRepos(workspacePath)
+	gitDirs := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		gitDirs = append(gitDirs, filepath.Join(workspacePath, filepath.FromSlash(repo.Path), ".git"))
+	}
+	if err := checkArchiveSpace(os.TempDir(), gitDirs...); err != nil {
+		return nil, err
+	}
+	if err := checkArchiveSpace(archiveDir, gitDirs...); err != nil {
+		return nil, err
 	}
 
-	return archiveBundlesOnly(cfg, slug, workspacePath, archiveDir, timestamp, now, opts)
+	result, err := archiveBundlesOnly(ctx, cfg, slug, workspacePath, archiveDir, timestamp, now, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Pruned = pruneArchives(cfg, slug, now)
+	result.Warnings = warnings
+	return result, nil
+}
+
+// runArchiveHook runs the archive event hook for the workspace at
+// workspacePath, saving project.json when the hook changed it so the change
+// is archived too.
+func runArchiveHook(cfg *config.Config, workspacePath string) error {
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return nil
+	}
+	state, tags := proj.State, strings.Join(proj.Tags, "\x00")
+	if err := workspace.RunEventHook(cfg, workspace.EventArchive, proj, workspacePath); err != nil {
+		return err
+	}
+	if proj.State == state && strings.Join(proj.Tags, "\x00") == tags {
+		return nil
+	}
+	return proj.Save(workspacePath)
+}
+
+// pruneArchives removes archives of slug older than its owner's
+// archive_retention_days. The newest archive is always kept. It returns the
+// paths removed.
+func pruneArchives(cfg *config.Config, slug string, now time.Time) []string {
+	entries, err := ListArchives(cfg)
+	if err != nil {
+		return nil
+	}
+	var own []ArchiveEntry
+	for _, e := range entries {
+		if e.Slug == slug {
+			own = append(own, e)
+		}
+	}
+	return pruneEntries(cfg, slug, own, now)
+}
+
+// PruneArchives applies every owner's archive_retention_days to all archives,
+// keeping the newest archive of each workspace. It returns the paths removed.
+func PruneArchives(cfg *config.Config, now time.Time) ([]string, error) {
+	l, err := lock.Archives(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	entries, err := ListArchives(cfg)
+	if err != nil {
+		return nil, err
+	}
+	bySlug := make(map[string][]ArchiveEntry)
+	var slugs []string
+	for _, e := range entries {
+		if _, ok := bySlug[e.Slug]; !ok {
+			slugs = append(slugs, e.Slug)
+		}
+		bySlug[e.Slug] = append(bySlug[e.Slug], e)
+	}
+	sort.Strings(slugs)
+
+	var pruned []string
+	for _, slug := range slugs {
+		pruned = append(pruned, pruneEntries(cfg, slug, bySlug[slug], now)...)
+	}
+	return pruned, nil
 }
 
-func archiveFullWorkspace(cfg *config.Config, slug, workspacePath, archiveDir, timestamp string, now time.Time, opts Options) (*Result, error) {
+// pruneEntries removes the archives of slug in own that are past the owner's
+// retention, keeping the newest and any holding a workspace in cold storage.
+func pruneEntries(cfg *config.Config, slug string, own []ArchiveEntry, now time.Time) []string {
+	days := cfg.GetOwnerPolicy(workspace.SchemeFor(cfg).Owner(slug)).ArchiveRetentionDays
+	if days <= 0 {
+		return nil
+	}
+	cold, _ := ListCold(cfg)
+	keep := make(map[string]bool, len(cold))
+	for _, c := range cold {
+		keep[c.ArchivePath] = true
+	}
+	sort.Slice(own, func(i, j int) bool { return own[i].ArchivedAt.After(own[j].ArchivedAt) })
+
+	cutoff := now.AddDate(0, 0, -days)
+	var pruned []string
+	for _, e := range own[min(1, len(own)):] {
+		if e.ArchivedAt.Before(cutoff) && !keep[e.Path] && os.Remove(e.Path) == nil {
+			pruned = append(pruned, e.Path)
+		}
+	}
+	return pruned
+}
+
+// planArchive describes what ArchiveWorkspace would do without touching disk.
+func planArchive(cfg *config.Config, slug, workspacePath, archiveDir, timestamp string, opts Options) *Result {
+	plan := model.NewPlan(fmt.Sprintf("Archive workspace %s", slug))
+	result := &Result{FullArchive: opts.Full, Plan: plan}
+
+	if opts.Full {
+		result.ArchivePath = filepath.Join(archiveDir, fmt.Sprintf("%s--%s--full.tar.gz", slug, timestamp))
+		plan.Add(model.ActionArchive, workspacePath, result.ArchivePath, "full workspace")
+	} else {
+		result.ArchivePath = filepath.Join(archiveDir, fmt.Sprintf("%s--%s.tar.gz", slug, timestamp))
+		repos, _ := model.LocateWorkspaceRepos(workspacePath)
+		for _, repo := range repos {
+			repoPath := filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
+			if !git.IsRepo(repoPath) {
+				continue
+			}
+			plan.Add(model.ActionArchive, repoPath, result.ArchivePath, "git bundle")
+			result.BundleCount++
+		}
+	}
+
+	if opts.DeleteAfter {
+		quarantine.PlanDelete(cfg, plan, workspacePath)
+	}
+	return result
+}
+
+func archiveFullWorkspace(ctx context.Context, cfg *config.Config, slug, workspacePath, archiveDir, timestamp string, now time.Time, opts Options) (*Result, error) {
 	result := &Result{FullArchive: true}
 
 	archiveName := fmt.Sprintf("%s--%s--full.tar.gz", slug, timestamp)
 	archivePath := filepath.Join(archiveDir, archiveName)
 
-	if err := createTarGz(workspacePath, archivePath); err != nil {
+	if err := createTarGz(ctx, workspacePath, archivePath); err != nil {
 		return nil, fmt.Errorf("failed to create full archive: %w", err)
 	}
 
 	result.ArchivePath = archivePath
 
 	if opts.DeleteAfter {
-		if err := os.RemoveAll(workspacePath); err != nil {
+		entry, err := quarantine.Delete(cfg, workspacePath)
+		if err != nil {
 			return nil, fmt.Errorf("failed to delete workspace: %w", err)
 		}
 		result.Deleted = true
+		if entry != nil {
+			result.Quarantined = entry.ID
+		}
 	}
 
 	return result, nil
 }
 
-func archiveBundlesOnly(cfg *config.Config, slug, workspacePath, archiveDir, timestamp string, now time.Time, opts Options) (*Result, error) {
+func archiveBundlesOnly(ctx context.Context, cfg *config.Config, slug, workspacePath, archiveDir, timestamp string, now time.Time, opts Options) (*Result, error) {
 	result := &Result{}
 
 	archiveName := fmt.Sprintf("%s--%s.tar.gz", slug, timestamp)
@@ -102,14 +300,15 @@ func archiveBundlesOnly(cfg *config.Config, slug, workspacePath, archiveDir, tim
 		return nil, fmt.Errorf("failed to copy project.json: %w", err)
 	}
 
-	repos, err := fs.ListRepos(workspacePath)
+	repos, err := model.LocateWorkspaceRepos(workspacePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list repos: %w", err)
 	}
 
 	bundleCount := 0
-	for _, repoName := range repos {
-		repoPath := filepath.Join(workspacePath, "repos", repoName)
+	for _, repo := range repos {
+		repoName := repo.Name
+		repoPath := filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
 		if !git.IsRepo(repoPath) {
 			continue
 		}
@@ -117,7 +316,7 @@ func archiveBundlesOnly(cfg *config.Config, slug, workspacePath, archiveDir, tim
 		bundleName := fmt.Sprintf("repos__%s.bundle", repoName)
 		bundlePath := filepath.Join(tmpDir, bundleName)
 
-		if err := git.CreateBundle(repoPath, bundlePath); err != nil {
+		if err := git.CreateBundleContext(ctx, repoPath, bundlePath); err != nil {
 			return nil, fmt.Errorf("failed to create bundle for %s: %w", repoName, err)
 		}
 		bundleCount++
@@ -133,12 +332,12 @@ func archiveBundlesOnly(cfg *config.Config, slug, workspacePath, archiveDir, tim
 
 	metaPath := filepath.Join(tmpDir, "archive-meta.json")
 	metaData, _ := json.MarshalIndent(meta, "", "  ")
-	if err := os.WriteFile(metaPath, metaData, 0644); err != nil {
+	if err := os.WriteFile(metaPath, metaData, fs.FilePerm()); err != nil {
 		return nil, fmt.Errorf("failed to write archive-meta.json: %w", err)
 	}
 
 	archivePath := filepath.Join(archiveDir, archiveName)
-	if err := createTarGz(tmpDir, archivePath); err != nil {
+	if err := createTarGz(ctx, tmpDir, archivePath); err != nil {
 		return nil, fmt.Errorf("failed to create archive: %w", err)
 	}
 
@@ -146,26 +345,63 @@ func archiveBundlesOnly(cfg *config.Config, slug, workspacePath, archiveDir, tim
 	result.BundleCount = bundleCount
 
 	if opts.DeleteAfter {
-		if err := os.RemoveAll(workspacePath); err != nil {
+		entry, err := quarantine.Delete(cfg, workspacePath)
+		if err != nil {
 			return nil, fmt.Errorf("failed to delete workspace: %w", err)
 		}
 		result.Deleted = true
+		if entry != nil {
+			result.Quarantined = entry.ID
+		}
 	}
 
 	return result, nil
 }
 
+// checkArchiveSpace fails early when archiveDir cannot hold the uncompressed
+// size of sources, used as an upper bound for the archive size.
+func checkArchiveSpace(archiveDir string, sources ...string) error {
+	var need int64
+	for _, src := range sources {
+		if size, err := fs.DirSize(src); err == nil {
+			need += size
+		}
+	}
+	return fs.CheckFreeSpace(archiveDir, need)
+}
+
 func copyFile(src, dst string) error {
 	data, err := os.ReadFile(src)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(dst, data, 0644)
+	return os.WriteFile(dst, data, fs.FilePerm())
+}
+
+func createTarGz(ctx context.Context, srcDir, dstPath string) error {
+	return runTar(ctx, dstPath, "-czf", dstPath, "-C", srcDir, ".")
+}
+
+// runTar runs tar with args to write the archive dstPath, killing tar and
+// removing the partial archive when ctx is done.
+func runTar(ctx context.Context, dstPath string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "tar", args...)
+	cmd.Env = tarEnv()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			os.Remove(dstPath)
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
 }
 
-func createTarGz(srcDir, dstPath string) error {
-	cmd := exec.Command("tar", "-czf", dstPath, "-C", srcDir, ".")
-	return cmd.Run()
+// tarEnv is the environment archives are created in. It stops macOS tar from
+// storing extended attributes as ._ files, which other systems restore as
+// clutter.
+func tarEnv() []string {
+	return append(os.Environ(), "COPYFILE_DISABLE=1")
 }
 
 type ArchiveEntry struct {
@@ -240,58 +476,158 @@ func ListArchives(cfg *config.Config) ([]ArchiveEntry, error) {
 
 // StashResult holds the result of a stash operation.
 type StashResult struct {
-	ArchivePath string `json:"archive_path"`
-	SourcePath  string `json:"source_path"`
-	Name        string `json:"name"`
-	Deleted     bool   `json:"deleted"`
+	ArchivePath string      `json:"archive_path"`
+	SourcePath  string      `json:"source_path"`
+	Name        string      `json:"name"`
+	Deleted     bool        `json:"deleted"`
+	Quarantined string      `json:"quarantined,omitempty"` // Quarantine ID when the delete was quarantined
+	Plan        *model.Plan `json:"plan,omitempty"`        // Set for dry runs
+
+	// SecretsExcluded lists likely secret files left out of the archive,
+	// relative to the stashed folder
+	SecretsExcluded []string `json:"secrets_excluded,omitempty"`
 }
 
 // StashOptions configures a stash operation.
 type StashOptions struct {
 	Name        string // Custom archive name (defaults to folder name)
 	DeleteAfter bool   // Delete source folder after archiving
+	DryRun      bool   // Report the planned actions without archiving
+
+	// Bundles stores each git repository with commits as a git bundle of
+	// all its refs plus its untracked and changed files, instead of its
+	// whole working tree. Ignored files in repositories (build output,
+	// dependencies) are not kept. Restoring clones the repositories back.
+	Bundles bool
+
+	// IncludeSecrets keeps likely secret files (.env, *.pem, id_rsa, ...) in
+	// the archive; they are left out unless this or secrets.stash_include is set
+	IncludeSecrets bool
 }
 
 // StashFolder archives any file or folder to the system archive directory.
 // Unlike ArchiveWorkspace, this works on arbitrary files/folders, not just workspaces.
+// Likely secret files inside a stashed folder are left out of the archive
+// unless opts.IncludeSecrets or secrets.stash_include is set; with
+// opts.DeleteAfter, a folder holding secrets is refused instead so they are not
+// lost.
 func StashFolder(cfg *config.Config, sourcePath string, opts StashOptions) (*StashResult, error) {
+	return StashFolderContext(context.Background(), cfg, sourcePath, opts)
+}
+
+// StashFolderContext stashes like StashFolder, stopping tar when ctx is done.
+// A cancelled stash is removed and the source is left in place.
+func StashFolderContext(ctx context.Context, cfg *config.Config, sourcePath string, opts StashOptions) (*StashResult, error) {
 	// Determine archive name
 	name := opts.Name
 	if name == "" {
 		name = filepath.Base(sourcePath)
 	}
-	name = SanitizeArchiveName(name)
-
 	now := time.Now()
+	name = StashName(cfg, sourcePath, name, now)
+
+	if opts.DeleteAfter {
+		if err := cfg.CheckRemovable(sourcePath); err != nil {
+			return nil, err
+		}
+	}
+
 	year := now.Format("2006")
 	timestamp := now.Format("20060102-150405")
 
+	// Create archive filename: name--timestamp--stash.tar.gz
 	archiveDir := filepath.Join(cfg.ArchiveDir(), year)
+	archiveName := fmt.Sprintf("%s--%s--stash.tar.gz", name, timestamp)
+	archivePath := filepath.Join(archiveDir, archiveName)
+
+	var secrets []string
+	sc := cfg.GetSecretsConfig()
+	if info, err := os.Stat(sourcePath); err == nil && info.IsDir() && !opts.IncludeSecrets && !sc.StashInclude {
+		if secrets, err = fs.FindSecrets(sourcePath, sc.Patterns); err != nil {
+			return nil, fmt.Errorf("failed to scan for secrets: %w", err)
+		}
+	}
+	// Deleting the source would lose the secrets left out of the archive
+	if opts.DeleteAfter && len(secrets) > 0 {
+		return nil, fmt.Errorf("%s has likely secret files that would be deleted without being archived: %s (archive them with --include-secrets or secrets.stash_include)",
+			sourcePath, strings.Join(secrets, ", "))
+	}
+
+	if opts.DryRun {
+		plan := model.NewPlan(fmt.Sprintf("Stash %s", sourcePath))
+		plan.Add(model.ActionArchive, sourcePath, archivePath, "")
+		if opts.Bundles {
+			repos, _ := stashRepos(cfg, sourcePath)
+			for _, rel := range repos {
+				plan.Add(model.ActionArchive, filepath.Join(sourcePath, rel), archivePath, "git bundle")
+			}
+		}
+		for _, rel := range secrets {
+			plan.Skip(model.ActionArchive, filepath.Join(sourcePath, rel), "", "likely secret")
+		}
+		if opts.DeleteAfter {
+			quarantine.PlanDelete(cfg, plan, sourcePath)
+		}
+		return &StashResult{ArchivePath: archivePath, SourcePath: sourcePath, Name: name, Plan: plan, SecretsExcluded: secrets}, nil
+	}
+
+	l, err := lock.Archives(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
 	if err := fs.EnsureDir(archiveDir); err != nil {
 		return nil, fmt.Errorf("failed to create archive directory: %w", err)
 	}
 
-	// Create archive filename: name--timestamp--stash.tar.gz
-	archiveName := fmt.Sprintf("%s--%s--stash.tar.gz", name, timestamp)
-	archivePath := filepath.Join(archiveDir, archiveName)
+	if err := checkArchiveSpace(archiveDir, sourcePath); err != nil {
+		return nil, err
+	}
 
-	// Create the tar.gz archive
-	cmd := exec.Command("tar", "-czf", archivePath, "-C", filepath.Dir(sourcePath), filepath.Base(sourcePath))
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to create archive: %w", err)
+	var manifest *StashManifest
+	if opts.Bundles {
+		if manifest, err = createBundleStash(ctx, cfg, sourcePath, archivePath, secrets, now); err != nil {
+			return nil, err
+		}
+	} else {
+		if manifest, err = buildManifest(cfg, sourcePath, secrets, now); err != nil {
+			return nil, fmt.Errorf("failed to read source: %w", err)
+		}
+
+		// Create the tar.gz archive. Secrets are excluded by their exact path
+		// within the archive, so example files next to them are kept.
+		args := []string{"-czf", archivePath}
+		for _, rel := range secrets {
+			args = append(args, "--exclude="+filepath.ToSlash(filepath.Join(filepath.Base(sourcePath), rel)))
+		}
+		args = append(args, "-C", filepath.Dir(sourcePath), filepath.Base(sourcePath))
+		if err := runTar(ctx, archivePath, args...); err != nil {
+			return nil, fmt.Errorf("failed to create archive: %w", err)
+		}
+	}
+	// The source is only deleted once the stash can be listed and verified
+	if err := writeManifest(archivePath, manifest); err != nil {
+		os.Remove(archivePath)
+		return nil, fmt.Errorf("failed to write stash manifest: %w", err)
 	}
 
 	result := &StashResult{
-		ArchivePath: archivePath,
-		SourcePath:  sourcePath,
-		Name:        name,
+		ArchivePath:     archivePath,
+		SourcePath:      sourcePath,
+		Name:            name,
+		SecretsExcluded: secrets,
 	}
 
 	if opts.DeleteAfter {
-		if err := os.RemoveAll(sourcePath); err != nil {
+		entry, err := quarantine.Delete(cfg, sourcePath)
+		if err != nil {
 			return nil, fmt.Errorf("failed to delete source: %w", err)
 		}
 		result.Deleted = true
+		if entry != nil {
+			result.Quarantined = entry.ID
+		}
 	}
 
 	return result, nil
@@ -317,6 +653,53 @@ func SanitizeArchiveName(s string) string {
 	return name
 }
 
+// StashSource holds the values of stash name placeholders that come from the
+// stashed folder's git repository; both are empty outside one.
+type StashSource struct {
+	Owner  string // Owner in the origin remote URL
+	Branch string // Branch checked out
+}
+
+// StashSourceOf reads the stash name placeholder values of the folder at path.
+func StashSourceOf(path string) StashSource {
+	var src StashSource
+	if !git.IsRepo(path) {
+		return src
+	}
+	if branch, err := git.CurrentBranch(path); err == nil && branch != "HEAD" {
+		src.Branch = branch
+	}
+	if remote, err := git.RemoteURL(path); err == nil {
+		src.Owner, _, _ = workspace.ParseRemoteURL(remote)
+	}
+	return src
+}
+
+// FormatStashName fills the placeholders of a stash name pattern and returns
+// the sanitized result. Placeholders without a value are left empty.
+func FormatStashName(pattern, name string, src StashSource, now time.Time) string {
+	value := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		// Keep the separators of branches like feature/login
+		return SanitizeArchiveName(strings.ReplaceAll(s, "/", "-"))
+	}
+	filled := strings.NewReplacer(
+		"{name}", value(name),
+		"{date}", now.Format("2006-01-02"),
+		"{owner}", value(src.Owner),
+		"{git_branch}", value(src.Branch),
+	).Replace(pattern)
+	return SanitizeArchiveName(filled)
+}
+
+// StashName returns the name of a stash of the folder at sourcePath, made
+// from the configured stash name pattern.
+func StashName(cfg *config.Config, sourcePath, name string, now time.Time) string {
+	return FormatStashName(cfg.GetArchiveConfig().StashName, name, StashSourceOf(sourcePath), now)
+}
+
 func readArchiveMeta(archivePath string) (*ArchiveMeta, error) {
 	file, err := os.Open(archivePath)
 	if err != nil {
@@ -326,7 +709,7 @@ func readArchiveMeta(archivePath string) (*ArchiveMeta, error) {
 
 	gzr, err := gzip.NewReader(file)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
 	}
 	defer gzr.Close()
 
@@ -338,13 +721,13 @@ func readArchiveMeta(archivePath string) (*ArchiveMeta, error) {
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
 		}
 
 		if strings.HasSuffix(header.Name, "archive-meta.json") {
 			var meta ArchiveMeta
 			if err := json.NewDecoder(tr).Decode(&meta); err != nil {
-				return nil, err
+				return nil, fmt.Errorf("%w: archive-meta.json: %v", ErrArchiveCorrupt, err)
 			}
 			return &meta, nil
 		}
@@ -352,3 +735,188 @@ func readArchiveMeta(archivePath string) (*ArchiveMeta, error) {
 
 	return nil, nil
 }
+
+// RestoreResult holds the result of a restore operation.
+type RestoreResult struct {
+	ArchivePath   string   `json:"archive_path"`
+	RestoredPath  string   `json:"restored_path"`
+	Slug          string   `json:"slug,omitempty"`
+	ReposRestored []string `json:"repos_restored,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// RestoreOptions configures a restore operation.
+type RestoreOptions struct {
+	// DestDir is the parent directory a stash is extracted into. It defaults
+	// to the parent of the stashed folder recorded in the stash manifest, and
+	// is required for stashes without one.
+	DestDir string
+}
+
+var stashFilePattern = regexp.MustCompile(`^(.+)--(\d{8}-\d{6})--stash\.tar\.gz$`)
+
+// RestoreArchive recreates the contents of an archive produced by ArchiveWorkspace
+// or StashFolder. Workspace archives are restored under the code root; bundle-only
+// archives have their repos cloned from the bundles and origin reset to the
+// recorded remote. Stash archives are extracted into opts.DestDir, or back
+// where they were stashed from, and checked against their manifest.
+func RestoreArchive(cfg *config.Config, archivePath string, opts RestoreOptions) (*RestoreResult, error) {
+	if _, err := os.Stat(archivePath); err != nil {
+		return nil, fmt.Errorf("archive not found: %w", err)
+	}
+	name := filepath.Base(archivePath)
+	result := &RestoreResult{ArchivePath: archivePath}
+
+	if stashFilePattern.MatchString(name) {
+		return restoreStash(archivePath, opts.DestDir, result)
+	}
+
+	matches := archiveFilePattern.FindStringSubmatch(name)
+	if matches == nil {
+		return nil, fmt.Errorf("unrecognized archive name: %s", name)
+	}
+	slug := matches[1]
+	l, err := lock.Workspace(cfg, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+	if workspace.Exists(cfg, slug) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrWorkspaceExists, slug)
+	}
+	workspacePath := cfg.WorkspacePath(slug)
+	result.Slug = slug
+	result.RestoredPath = workspacePath
+
+	if matches[3] == "--full" {
+		if err := fs.EnsureDir(workspacePath); err != nil {
+			return nil, fmt.Errorf("failed to create workspace: %w", err)
+		}
+		if err := extractTarGz(archivePath, workspacePath); err != nil {
+			return nil, fmt.Errorf("failed to extract archive: %w", err)
+		}
+		return result, nil
+	}
+
+	return restoreBundles(archivePath, workspacePath, result)
+}
+
+// restoreStash extracts a stash into destDir, or into the parent of the
+// folder it was stashed from, which must not exist again. The restored files
+// are compared with the manifest; differences are reported as warnings.
+func restoreStash(archivePath, destDir string, result *RestoreResult) (*RestoreResult, error) {
+	manifest, err := ReadManifest(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	if destDir == "" {
+		if manifest == nil {
+			return nil, fmt.Errorf("destination directory is required to restore a stash without a manifest")
+		}
+		if _, err := os.Lstat(manifest.SourcePath); err == nil {
+			return nil, fmt.Errorf("%s already exists; choose a destination directory", manifest.SourcePath)
+		}
+		destDir = filepath.Dir(manifest.SourcePath)
+	}
+	if err := fs.EnsureDir(destDir); err != nil {
+		return nil, fmt.Errorf("failed to create destination: %w", err)
+	}
+	if manifest != nil && manifest.Bundles {
+		return restoreBundleStash(archivePath, destDir, manifest, result)
+	}
+	if err := extractTarGz(archivePath, destDir); err != nil {
+		return nil, fmt.Errorf("failed to extract stash: %w", err)
+	}
+	result.RestoredPath = destDir
+	if manifest == nil {
+		return result, nil
+	}
+
+	count, size, err := countFiles(filepath.Join(destDir, filepath.Base(manifest.SourcePath)), nil)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("could not check restored files: %v", err))
+		return result, nil
+	}
+	if count != manifest.FileCount || size != manifest.TotalSize {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("restored %d files (%d bytes), manifest records %d files (%d bytes)",
+			count, size, manifest.FileCount, manifest.TotalSize))
+	}
+	return result, nil
+}
+
+func restoreBundles(archivePath, workspacePath string, result *RestoreResult) (*RestoreResult, error) {
+	tmpDir, err := os.MkdirTemp("", "co-restore-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractTarGz(archivePath, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	proj, err := model.LoadProject(filepath.Join(tmpDir, "project.json"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read project.json: %v", ErrArchiveCorrupt, err)
+	}
+
+	if err := fs.EnsureDir(filepath.Join(workspacePath, proj.RepoDir(""))); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	if err := copyFile(filepath.Join(tmpDir, "project.json"), filepath.Join(workspacePath, "project.json")); err != nil {
+		return nil, fmt.Errorf("failed to restore project.json: %w", err)
+	}
+
+	remotes := make(map[string]string)
+	paths := make(map[string]string) // Repos at paths of their own, as in the flat layout
+	for _, repo := range proj.Repos {
+		remotes[repo.Name] = repo.Remote
+		if repo.Path != "" {
+			paths[repo.Name] = repo.Path
+		}
+	}
+
+	bundles, err := filepath.Glob(filepath.Join(tmpDir, "repos__*.bundle"))
+	if err != nil {
+		return nil, err
+	}
+	for _, bundlePath := range bundles {
+		repoName := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(bundlePath), "repos__"), ".bundle")
+		repoDir, ok := paths[repoName]
+		if !ok {
+			repoDir = proj.RepoDir(repoName)
+		}
+		repoPath := filepath.Join(workspacePath, filepath.FromSlash(repoDir))
+		if err := git.Clone(bundlePath, repoPath); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to restore %s: %v", repoName, err))
+			continue
+		}
+		if remote := remotes[repoName]; remote != "" {
+			if err := git.SetRemote(repoPath, remote); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to set remote for %s: %v", repoName, err))
+			}
+		}
+		result.ReposRestored = append(result.ReposRestored, repoName)
+	}
+
+	return result, nil
+}
+
+// unreadableTar matches what tar and gzip print for archives they cannot
+// read, as opposed to failures writing the extracted files.
+var unreadableTar = regexp.MustCompile(`(?i)gzip|unexpected eof|not look like a tar|unrecognized archive|truncated`)
+
+func extractTarGz(archivePath, dstDir string) error {
+	out, err := exec.Command("tar", "-xzf", archivePath, "-C", dstDir).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	msg, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if unreadableTar.MatchString(msg) {
+		return fmt.Errorf("%w: %s: %s", ErrArchiveCorrupt, filepath.Base(archivePath), msg)
+	}
+	if msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
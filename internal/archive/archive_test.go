@@ -0,0 +1,142 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestStashFolderContextCancelled(t *testing.T) {
+	cfg := &config.Config{Schema: 1, CodeRoot: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "notes")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "todo.md"), []byte("todo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := StashFolderContext(ctx, cfg, src, StashOptions{DeleteAfter: true}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("StashFolderContext() error = %v, want context.Canceled", err)
+	}
+	if left, _ := filepath.Glob(filepath.Join(cfg.ArchiveDir(), "*", "*.tar.gz")); len(left) > 0 {
+		t.Errorf("a cancelled stash should leave no archive, found %v", left)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source should be kept: %v", err)
+	}
+}
+
+func TestRestoreArchiveCorrupt(t *testing.T) {
+	cfg := &config.Config{Schema: 1, CodeRoot: t.TempDir()}
+	dir := t.TempDir()
+	for _, name := range []string{"notes--20240101-120000--stash.tar.gz", "acme--app--20240101-120000.tar.gz"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("not an archive"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := RestoreArchive(cfg, path, RestoreOptions{DestDir: t.TempDir()})
+		if !errors.Is(err, ErrArchiveCorrupt) {
+			t.Errorf("RestoreArchive(%s) error = %v, want ErrArchiveCorrupt", name, err)
+		}
+	}
+}
+
+func TestArchiveFlatWorkspace(t *testing.T) {
+	cfg := &config.Config{Schema: 1, CodeRoot: t.TempDir()}
+	slug := "acme--app"
+	workspacePath := cfg.WorkspacePath(slug)
+	repo := filepath.Join(workspacePath, "api")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	proj := model.NewProject("acme--app", "acme", "app")
+	proj.RepoLayout = model.LayoutFlat
+	proj.AddRepo("api", "api", "")
+	if err := proj.Save(workspacePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	result, err := ArchiveWorkspace(cfg, slug, Options{DeleteAfter: true})
+	if err != nil {
+		t.Fatalf("ArchiveWorkspace() error = %v", err)
+	}
+	if result.BundleCount != 1 || !result.Deleted {
+		t.Fatalf("ArchiveWorkspace() = %+v, want one bundle and the workspace deleted", result)
+	}
+
+	restored, err := RestoreArchive(cfg, result.ArchivePath, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("RestoreArchive() error = %v", err)
+	}
+	if len(restored.ReposRestored) != 1 || len(restored.Warnings) > 0 {
+		t.Errorf("RestoreArchive() = %+v", restored)
+	}
+	if data, err := os.ReadFile(filepath.Join(repo, "main.go")); err != nil || string(data) != "package main" {
+		t.Errorf("api/main.go = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(workspacePath, "repos")); err == nil {
+		t.Error("a flat workspace should be restored without repos/")
+	}
+}
+
+func FuzzSanitizeArchiveName(f *testing.F) {
+	for _, seed := range []string{"My Folder", "../../etc/passwd", "__", "-", "a/b", "ÆØÅ", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		got := SanitizeArchiveName(name)
+		if got == "" {
+			t.Fatalf("SanitizeArchiveName(%q) is empty", name)
+		}
+		if strings.Trim(got, "abcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+			t.Errorf("SanitizeArchiveName(%q) = %q, has characters outside [a-z0-9_-]", name, got)
+		}
+		if strings.Trim(got, "-_") != got {
+			t.Errorf("SanitizeArchiveName(%q) = %q, starts or ends with - or _", name, got)
+		}
+	})
+}
+
+func TestFormatStashName(t *testing.T) {
+	now := time.Date(2026, 3, 2, 15, 4, 5, 0, time.UTC)
+	src := StashSource{Owner: "Acme", Branch: "feature/login"}
+	tests := []struct {
+		pattern string
+		name    string
+		src     StashSource
+		want    string
+	}{
+		{"{name}", "My Notes", src, "my-notes"},
+		{"{owner}--{name}--{git_branch}", "api", src, "acme--api--feature-login"},
+		{"{date}-{name}", "api", src, "2026-03-02-api"},
+		{"{owner}--{name}-{git_branch}", "notes", StashSource{}, "notes"},
+	}
+	for _, tt := range tests {
+		if got := FormatStashName(tt.pattern, tt.name, tt.src, now); got != tt.want {
+			t.Errorf("FormatStashName(%q, %q) = %q, want %q", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// bundleDir is the directory in a bundle stash that holds the git bundles,
+// next to the stashed folder.
+const bundleDir = "_bundles"
+
+// bundleName returns the name of the bundle of the repository at rel,
+// relative to the stashed folder.
+func bundleName(rel string) string {
+	if rel == "." {
+		return "repo.bundle"
+	}
+	return strings.ReplaceAll(filepath.ToSlash(rel), "/", "__") + ".bundle"
+}
+
+// stashRepos returns the git repositories in sourcePath that have commits,
+// relative to it, outermost first. Repositories without commits cannot be
+// bundled and are stashed like any other folder.
+func stashRepos(cfg *config.Config, sourcePath string) ([]string, error) {
+	scan, err := git.ScanGitRoots(sourcePath, workspace.GitScanOptions(cfg))
+	if err != nil {
+		return nil, err
+	}
+	var repos []string
+	for _, root := range scan.Roots {
+		rel, err := filepath.Rel(sourcePath, root)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // Reached through a followed symlink
+		}
+		if _, err := git.HeadCommit(root); err != nil {
+			continue
+		}
+		repos = append(repos, rel)
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+// createBundleStash writes a bundle stash of sourcePath to archivePath (see
+// StashOptions.Bundles) and returns its manifest.
+func createBundleStash(ctx context.Context, cfg *config.Config, sourcePath, archivePath string, secrets []string, now time.Time) (*StashManifest, error) {
+	if info, err := os.Stat(sourcePath); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("only folders can be stashed as bundles: %s", sourcePath)
+	}
+	if filepath.Base(sourcePath) == bundleDir {
+		return nil, fmt.Errorf("a folder named %s cannot be stashed as bundles", bundleDir)
+	}
+	staging, err := os.MkdirTemp("", "co-stash-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	repos, err := stageBundleStash(ctx, cfg, sourcePath, staging, secrets)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	m := &StashManifest{
+		Schema:          1,
+		SourcePath:      sourcePath,
+		StashedAt:       now,
+		CoVersion:       model.CoVersion(),
+		Bundles:         true,
+		Repos:           repos,
+		SecretsExcluded: secrets,
+	}
+	if m.FileCount, m.TotalSize, err = countFiles(staging, nil); err != nil {
+		return nil, err
+	}
+	if err := createTarGz(ctx, staging, archivePath); err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	return m, nil
+}
+
+// stageBundleStash lays out a bundle stash of sourcePath in staging: a git
+// bundle of every repository under _bundles/, and the folder itself under
+// its own name with each repository reduced to its working files (see
+// git.WorkingFiles). Ignored files inside repositories and the secrets are
+// left out. It returns the repositories stashed as bundles.
+func stageBundleStash(ctx context.Context, cfg *config.Config, sourcePath, staging string, secrets []string) ([]StashedRepo, error) {
+	rels, err := stashRepos(cfg, sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	isRepo := make(map[string]bool, len(rels))
+	for _, rel := range rels {
+		isRepo[rel] = true
+	}
+	skip := make(map[string]bool, len(secrets))
+	for _, rel := range secrets {
+		skip[rel] = true
+	}
+	dst := filepath.Join(staging, filepath.Base(sourcePath))
+
+	// Everything outside the repositories is copied as it is
+	err = filepath.WalkDir(sourcePath, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(sourcePath, p)
+		if skip[rel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if err := os.MkdirAll(filepath.Join(dst, rel), fs.DirPerm()); err != nil {
+				return err
+			}
+			if isRepo[rel] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return stageFile(p, filepath.Join(dst, rel), d)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(staging, bundleDir), fs.DirPerm()); err != nil {
+		return nil, err
+	}
+	var repos []StashedRepo
+	for _, rel := range rels {
+		repoPath := filepath.Join(sourcePath, rel)
+		repo := StashedRepo{Path: filepath.ToSlash(rel), Bundle: bundleName(rel)}
+		repo.Head, _ = git.HeadCommit(repoPath)
+		repo.Remote, _ = git.RemoteURL(repoPath)
+		if err := git.CreateBundleContext(ctx, repoPath, filepath.Join(staging, bundleDir, repo.Bundle)); err != nil {
+			return nil, fmt.Errorf("failed to create bundle for %s: %w", repo.Path, err)
+		}
+
+		files, err := git.WorkingFiles(repoPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range files {
+			if skip[filepath.Join(rel, name)] {
+				continue
+			}
+			src := filepath.Join(repoPath, name)
+			info, err := os.Lstat(src)
+			if err != nil {
+				return nil, err
+			}
+			target := filepath.Join(dst, rel, name)
+			if err := os.MkdirAll(filepath.Dir(target), fs.DirPerm()); err != nil {
+				return nil, err
+			}
+			if err := stageFile(src, target, dirEntry{info}); err != nil {
+				return nil, err
+			}
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// stageFile copies the file or symlink src to dst.
+func stageFile(src, dst string, d os.DirEntry) error {
+	if d.Type()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+	if !d.Type().IsRegular() {
+		return nil
+	}
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	return fs.CopyFile(src, dst, info.Mode().Perm())
+}
+
+// dirEntry adapts an os.FileInfo to os.DirEntry.
+type dirEntry struct{ os.FileInfo }
+
+func (e dirEntry) Type() os.FileMode          { return e.Mode().Type() }
+func (e dirEntry) Info() (os.FileInfo, error) { return e.FileInfo, nil }
+
+// restoreBundleStash recreates the folder of a bundle stash in destDir: each
+// repository is cloned from its bundle, outermost first, with origin set to
+// the recorded remote, and the stashed files are extracted on top.
+func restoreBundleStash(archivePath, destDir string, manifest *StashManifest, result *RestoreResult) (*RestoreResult, error) {
+	target := filepath.Join(destDir, filepath.Base(manifest.SourcePath))
+	if _, err := os.Lstat(target); err == nil {
+		return nil, fmt.Errorf("%s already exists", target)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "co-restore-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := extractTarGz(archivePath, tmpDir); err != nil {
+		return nil, fmt.Errorf("failed to extract stash: %w", err)
+	}
+
+	repos := append([]StashedRepo(nil), manifest.Repos...)
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].Path == "." || (repos[j].Path != "." && repos[i].Path < repos[j].Path)
+	})
+	for _, repo := range repos {
+		if repo.Bundle == "" {
+			continue
+		}
+		repoPath := filepath.Join(target, filepath.FromSlash(repo.Path))
+		if err := git.Clone(filepath.Join(tmpDir, bundleDir, repo.Bundle), repoPath); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to restore %s: %v", repo.Path, err))
+			continue
+		}
+		if repo.Remote != "" {
+			if err := git.SetRemote(repoPath, repo.Remote); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to set remote for %s: %v", repo.Path, err))
+			}
+		}
+		result.ReposRestored = append(result.ReposRestored, repo.Path)
+	}
+
+	_, err = fs.CopyTree(filepath.Join(tmpDir, filepath.Base(manifest.SourcePath)), target, fs.CopyOptions{Symlinks: fs.SymlinkPreserve})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore files: %w", err)
+	}
+	result.RestoredPath = destDir
+	return result, nil
+}
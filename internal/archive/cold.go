@@ -0,0 +1,205 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// ColdWorkspace is the record kept for a workspace moved to cold storage, so
+// it still shows up in listings and can be restored.
+type ColdWorkspace struct {
+	Slug        string             `json:"slug"`
+	ArchivePath string             `json:"archive_path"`
+	ArchivedAt  time.Time          `json:"archived_at"`
+	Reason      string             `json:"reason,omitempty"`
+	Record      *model.IndexRecord `json:"record"` // Index record from before the workspace was archived
+}
+
+// ColdOptions configures moving a workspace to cold storage.
+type ColdOptions struct {
+	Reason string
+	DryRun bool // Report the planned actions without archiving
+}
+
+// coldDir is where cold storage records are kept, one JSON file per slug.
+func coldDir(cfg *config.Config) string {
+	return filepath.Join(cfg.ArchiveDir(), "workspaces")
+}
+
+func coldRecordPath(cfg *config.Config, slug string) string {
+	return filepath.Join(coldDir(cfg), slug+".json")
+}
+
+// ColdStore archives the whole workspace, removes it from the code root, and
+// keeps a record of it so it is still listed, as archived, until restored
+// with RestoreCold.
+func ColdStore(cfg *config.Config, slug string, opts ColdOptions) (*Result, error) {
+	if !workspace.Exists(cfg, slug) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+	}
+	record := currentRecord(cfg, slug)
+
+	result, err := ArchiveWorkspace(cfg, slug, Options{
+		Reason:      opts.Reason,
+		DeleteAfter: true,
+		Full:        true,
+		DryRun:      opts.DryRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		result.Plan.Add(model.ActionWrite, coldRecordPath(cfg, slug), "", "cold storage record")
+		return result, nil
+	}
+
+	cold := ColdWorkspace{
+		Slug:        slug,
+		ArchivePath: result.ArchivePath,
+		ArchivedAt:  time.Now(),
+		Reason:      opts.Reason,
+		Record:      record,
+	}
+	if err := writeColdRecord(cfg, cold); err != nil {
+		return result, fmt.Errorf("workspace archived to %s but its record was not saved: %w", result.ArchivePath, err)
+	}
+	if idx, err := model.LoadIndex(cfg.IndexPath()); err == nil && idx.Remove(slug) {
+		_ = idx.Save(cfg.IndexPath())
+	}
+	return result, nil
+}
+
+// currentRecord returns the indexed record of slug, or a minimal one built
+// from project.json when the index has none.
+func currentRecord(cfg *config.Config, slug string) *model.IndexRecord {
+	if idx, err := model.LoadIndex(cfg.IndexPath()); err == nil {
+		if r := idx.FindBySlug(slug); r != nil {
+			return r
+		}
+	}
+	workspacePath := cfg.WorkspacePath(slug)
+	record := model.NewIndexRecord(slug, workspacePath)
+	if proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json")); err == nil {
+		record.Owner = proj.Owner
+		record.Tags = proj.Tags
+		record.RepoCount = len(proj.Repos)
+	}
+	return record
+}
+
+func writeColdRecord(cfg *config.Config, cold ColdWorkspace) error {
+	if err := fs.EnsureDir(coldDir(cfg)); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cold, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(coldRecordPath(cfg, cold.Slug), append(data, '\n'), fs.FilePerm())
+}
+
+// ListCold returns the workspaces in cold storage, sorted by slug.
+func ListCold(cfg *config.Config) ([]ColdWorkspace, error) {
+	files, err := os.ReadDir(coldDir(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var cold []ColdWorkspace
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(coldDir(cfg), file.Name()))
+		if err != nil {
+			continue
+		}
+		var c ColdWorkspace
+		if err := json.Unmarshal(data, &c); err != nil || c.Slug == "" {
+			continue
+		}
+		cold = append(cold, c)
+	}
+	sort.Slice(cold, func(i, j int) bool { return cold[i].Slug < cold[j].Slug })
+	return cold, nil
+}
+
+// IndexRecord returns the record listing c: its last indexed details, with
+// state archived and the archive as its path.
+func (c ColdWorkspace) IndexRecord() *model.IndexRecord {
+	r := model.NewIndexRecord(c.Slug, c.ArchivePath)
+	if c.Record != nil {
+		copied := *c.Record
+		r = &copied
+	}
+	r.Path = c.ArchivePath
+	r.State = model.StateArchived
+	r.DirtyRepos = 0
+	return r
+}
+
+// WithCold returns records with the cold-stored workspaces merged in. A cold
+// workspace replaces a stale index record of the same slug.
+func WithCold(records []*model.IndexRecord, cold []ColdWorkspace) []*model.IndexRecord {
+	if len(cold) == 0 {
+		return records
+	}
+	bySlug := make(map[string]*model.IndexRecord, len(cold))
+	for _, c := range cold {
+		bySlug[c.Slug] = c.IndexRecord()
+	}
+
+	merged := make([]*model.IndexRecord, 0, len(records)+len(cold))
+	for _, r := range records {
+		if c, ok := bySlug[r.Slug]; ok {
+			merged = append(merged, c)
+			delete(bySlug, r.Slug)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	for _, c := range cold {
+		if r, ok := bySlug[c.Slug]; ok {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// RestoreCold restores a workspace from cold storage into the code root and
+// drops its cold storage record.
+func RestoreCold(cfg *config.Config, slug string) (*RestoreResult, error) {
+	data, err := os.ReadFile(coldRecordPath(cfg, slug))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("workspace not in cold storage: %s", slug)
+		}
+		return nil, err
+	}
+	var cold ColdWorkspace
+	if err := json.Unmarshal(data, &cold); err != nil {
+		return nil, fmt.Errorf("invalid cold storage record for %s: %w", slug, err)
+	}
+
+	result, err := RestoreArchive(cfg, cold.ArchivePath, RestoreOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(coldRecordPath(cfg, slug)); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to remove cold storage record: %v", err))
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestColdStoreAndRestore(t *testing.T) {
+	cfg := &config.Config{Schema: 1, CodeRoot: t.TempDir()}
+	slug := "acme--app"
+	workspacePath := cfg.WorkspacePath(slug)
+	if err := os.MkdirAll(filepath.Join(workspacePath, "repos"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	proj := model.NewProject("acme--app", "acme", "app")
+	proj.Slug = slug
+	proj.Tags = []string{"client"}
+	if err := proj.Save(workspacePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspacePath, "notes.md"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ColdStore(cfg, slug, ColdOptions{Reason: "done"})
+	if err != nil {
+		t.Fatalf("ColdStore() error = %v", err)
+	}
+	if _, err := os.Stat(workspacePath); !os.IsNotExist(err) {
+		t.Error("workspace should be removed from the code root")
+	}
+
+	cold, err := ListCold(cfg)
+	if err != nil || len(cold) != 1 {
+		t.Fatalf("ListCold() = %v, %v; want one workspace", cold, err)
+	}
+	if cold[0].ArchivePath != result.ArchivePath || cold[0].Reason != "done" {
+		t.Errorf("cold record = %+v", cold[0])
+	}
+
+	live := model.NewIndexRecord("acme--web", cfg.WorkspacePath("acme--web"))
+	stale := model.NewIndexRecord(slug, workspacePath)
+	records := WithCold([]*model.IndexRecord{live, stale}, cold)
+	if len(records) != 2 {
+		t.Fatalf("WithCold() returned %d records, want 2", len(records))
+	}
+	r := records[1]
+	if r.Slug != slug || r.State != model.StateArchived || r.Path != result.ArchivePath || r.Owner != "acme" {
+		t.Errorf("cold record listed as %+v", r)
+	}
+
+	if _, err := RestoreCold(cfg, slug); err != nil {
+		t.Fatalf("RestoreCold() error = %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(workspacePath, "notes.md")); err != nil || string(data) != "notes" {
+		t.Errorf("notes.md not restored: %q, %v", data, err)
+	}
+	if cold, _ := ListCold(cfg); len(cold) != 0 {
+		t.Errorf("ListCold() after restore = %v, want none", cold)
+	}
+	if _, err := RestoreCold(cfg, slug); err == nil {
+		t.Error("RestoreCold() of a restored workspace should fail")
+	}
+}
@@ -0,0 +1,296 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// StashManifest describes what a stash archive holds. It is written next to
+// the archive as <archive>.json, so stashes can be listed, verified, and
+// restored to where they came from without unpacking them.
+type StashManifest struct {
+	Schema     int       `json:"schema"`
+	SourcePath string    `json:"source_path"`
+	StashedAt  time.Time `json:"stashed_at"`
+	FileCount  int       `json:"file_count"` // Regular files in the archive
+	TotalSize  int64     `json:"total_size"` // Bytes of those files, uncompressed
+	CoVersion  string    `json:"co_version"`
+
+	// Bundles is set for stashes holding git bundles and working files
+	// instead of whole repositories (see StashOptions.Bundles)
+	Bundles bool `json:"bundles,omitempty"`
+
+	// Repos lists the git repositories in the stashed folder
+	Repos []StashedRepo `json:"repos,omitempty"`
+
+	// SecretsExcluded lists likely secret files left out of the archive,
+	// relative to the stashed folder
+	SecretsExcluded []string `json:"secrets_excluded,omitempty"`
+}
+
+// StashedRepo is a git repository inside a stashed folder.
+type StashedRepo struct {
+	Path   string `json:"path"`             // Relative to the stashed folder; "." for the folder itself
+	Head   string `json:"head,omitempty"`   // Commit checked out; empty before the first commit
+	Remote string `json:"remote,omitempty"` // URL of origin
+	Bundle string `json:"bundle,omitempty"` // Bundle file under _bundles/ in a bundle stash
+}
+
+// StashEntry is a stash archive in the archive directory.
+type StashEntry struct {
+	Name      string         `json:"name"`
+	StashedAt time.Time      `json:"stashed_at"`
+	Path      string         `json:"path"`
+	Size      int64          `json:"size"`               // Size of the archive file
+	Manifest  *StashManifest `json:"manifest,omitempty"` // Nil for stashes made before manifests were written
+}
+
+// StashCheck is the result of verifying a stash archive.
+type StashCheck struct {
+	ArchivePath string         `json:"archive_path"`
+	Manifest    *StashManifest `json:"manifest,omitempty"`
+	FileCount   int            `json:"file_count"`
+	TotalSize   int64          `json:"total_size"`
+	Problems    []string       `json:"problems,omitempty"`
+}
+
+// OK reports whether the archive matched its manifest.
+func (c *StashCheck) OK() bool {
+	return len(c.Problems) == 0
+}
+
+// ManifestPath returns the path of the manifest of the stash archive at
+// archivePath.
+func ManifestPath(archivePath string) string {
+	return archivePath + ".json"
+}
+
+// ReadManifest reads the manifest of the stash archive at archivePath. It
+// returns nil without an error when the stash has none.
+func ReadManifest(archivePath string) (*StashManifest, error) {
+	data, err := os.ReadFile(ManifestPath(archivePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m StashManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestPath(archivePath), err)
+	}
+	return &m, nil
+}
+
+func writeManifest(archivePath string, m *StashManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ManifestPath(archivePath), append(data, '\n'), fs.FilePerm())
+}
+
+// buildManifest describes sourcePath as tar will archive it: regular files
+// without the secrets left out, and the git repositories found by the
+// configured scan.
+func buildManifest(cfg *config.Config, sourcePath string, secrets []string, now time.Time) (*StashManifest, error) {
+	m := &StashManifest{
+		Schema:          1,
+		SourcePath:      sourcePath,
+		StashedAt:       now,
+		CoVersion:       model.CoVersion(),
+		SecretsExcluded: secrets,
+	}
+
+	var err error
+	if m.FileCount, m.TotalSize, err = countFiles(sourcePath, secrets); err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(sourcePath); err != nil || !info.IsDir() {
+		return m, nil
+	}
+	scan, err := git.ScanGitRoots(sourcePath, workspace.GitScanOptions(cfg))
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range scan.Roots {
+		rel, _ := filepath.Rel(sourcePath, root)
+		repo := StashedRepo{Path: filepath.ToSlash(rel)}
+		repo.Head, _ = git.HeadCommit(root)
+		repo.Remote, _ = git.RemoteURL(root)
+		m.Repos = append(m.Repos, repo)
+	}
+	return m, nil
+}
+
+// countFiles returns the number and total size of the regular files at or
+// below root, leaving out the paths in excluded, which are relative to root.
+func countFiles(root string, excluded []string) (int, int64, error) {
+	skip := make(map[string]bool, len(excluded))
+	for _, rel := range excluded {
+		skip[rel] = true
+	}
+	count, size := 0, int64(0)
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		if skip[rel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		count++
+		size += info.Size()
+		return nil
+	})
+	return count, size, err
+}
+
+// ListStashes lists the stash archives in the archive directory with their
+// manifests, newest first.
+func ListStashes(cfg *config.Config) ([]StashEntry, error) {
+	years, err := os.ReadDir(cfg.ArchiveDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []StashEntry
+	for _, yearDir := range years {
+		if !yearDir.IsDir() {
+			continue
+		}
+		yearPath := filepath.Join(cfg.ArchiveDir(), yearDir.Name())
+		files, err := os.ReadDir(yearPath)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			matches := stashFilePattern.FindStringSubmatch(file.Name())
+			if file.IsDir() || matches == nil {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil {
+				continue
+			}
+			stashedAt, _ := time.ParseInLocation("20060102-150405", matches[2], time.Local)
+			entry := StashEntry{
+				Name:      matches[1],
+				StashedAt: stashedAt,
+				Path:      filepath.Join(yearPath, file.Name()),
+				Size:      info.Size(),
+			}
+			entry.Manifest, _ = ReadManifest(entry.Path)
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StashedAt.After(entries[j].StashedAt)
+	})
+	return entries, nil
+}
+
+// VerifyStash reads the whole stash archive at archivePath and compares it
+// with its manifest: the file count, their total size, and that every
+// recorded repository is in the archive. A stash without a manifest is only
+// checked to be readable. An unreadable archive returns ErrArchiveCorrupt.
+func VerifyStash(archivePath string) (*StashCheck, error) {
+	if !stashFilePattern.MatchString(filepath.Base(archivePath)) {
+		return nil, fmt.Errorf("not a stash archive: %s", filepath.Base(archivePath))
+	}
+	manifest, err := ReadManifest(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	check := &StashCheck{ArchivePath: archivePath, Manifest: manifest}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+	}
+	defer gzr.Close()
+
+	repos := map[string]bool{}
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(header.Name, "./"), "/")
+		if path.Base(name) == ".git" {
+			repos[path.Dir(name)] = true
+		} else if path.Dir(name) == bundleDir {
+			repos[name] = true
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Reading the contents checks them against the gzip checksum
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrArchiveCorrupt, err)
+		}
+		check.FileCount++
+		check.TotalSize += header.Size
+	}
+
+	if manifest == nil {
+		return check, nil
+	}
+	if check.FileCount != manifest.FileCount {
+		check.Problems = append(check.Problems, fmt.Sprintf("%d files, manifest records %d", check.FileCount, manifest.FileCount))
+	}
+	if check.TotalSize != manifest.TotalSize {
+		check.Problems = append(check.Problems, fmt.Sprintf("%d bytes, manifest records %d", check.TotalSize, manifest.TotalSize))
+	}
+	base := filepath.Base(manifest.SourcePath)
+	for _, repo := range manifest.Repos {
+		want := path.Join(base, repo.Path)
+		if repo.Bundle != "" {
+			want = path.Join(bundleDir, repo.Bundle)
+		}
+		if !repos[want] {
+			check.Problems = append(check.Problems, fmt.Sprintf("repo %s is missing", repo.Path))
+		}
+	}
+	return check, nil
+}
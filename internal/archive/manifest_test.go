@@ -0,0 +1,164 @@
+package archive
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+)
+
+func TestStashManifest(t *testing.T) {
+	cfg := &config.Config{Schema: 1, CodeRoot: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "api")
+	repo := filepath.Join(src, "service")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(src, "notes.md"):   "notes",
+		filepath.Join(src, ".env"):       "TOKEN=x",
+		filepath.Join(repo, "main.go"):   "package main",
+		filepath.Join(repo, "README.md"): "# service",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", "https://example.com/acme/service.git"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	head, err := exec.Command("git", "-C", repo, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := StashFolder(cfg, src, StashOptions{})
+	if err != nil {
+		t.Fatalf("StashFolder() error = %v", err)
+	}
+	m, err := ReadManifest(result.ArchivePath)
+	if err != nil || m == nil {
+		t.Fatalf("ReadManifest() = %v, %v", m, err)
+	}
+	if m.SourcePath != src || m.CoVersion == "" {
+		t.Errorf("manifest = %+v, want source %s and a co version", m, src)
+	}
+	// .env is left out of the archive, .git is not
+	if m.SecretsExcluded[0] != ".env" || m.FileCount < 3 {
+		t.Errorf("manifest counts %d files, secrets %v", m.FileCount, m.SecretsExcluded)
+	}
+	if len(m.Repos) != 1 || m.Repos[0].Path != "service" || m.Repos[0].Head != strings.TrimSpace(string(head)) ||
+		m.Repos[0].Remote != "https://example.com/acme/service.git" {
+		t.Errorf("manifest repos = %+v", m.Repos)
+	}
+
+	entries, err := ListStashes(cfg)
+	if err != nil || len(entries) != 1 || entries[0].Name != "api" || entries[0].Manifest == nil {
+		t.Fatalf("ListStashes() = %+v, %v", entries, err)
+	}
+
+	check, err := VerifyStash(result.ArchivePath)
+	if err != nil {
+		t.Fatalf("VerifyStash() error = %v", err)
+	}
+	if !check.OK() || check.FileCount != m.FileCount || check.TotalSize != m.TotalSize {
+		t.Errorf("VerifyStash() = %+v, want a match with %d files", check, m.FileCount)
+	}
+
+	m.FileCount++
+	m.Repos = append(m.Repos, StashedRepo{Path: "web"})
+	if err := writeManifest(result.ArchivePath, m); err != nil {
+		t.Fatal(err)
+	}
+	if check, err := VerifyStash(result.ArchivePath); err != nil || len(check.Problems) != 2 {
+		t.Errorf("VerifyStash() of a changed manifest = %+v, %v; want 2 problems", check, err)
+	}
+}
+
+func TestBundleStash(t *testing.T) {
+	cfg := &config.Config{Schema: 1, CodeRoot: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "api")
+	repo := filepath.Join(src, "service")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write(filepath.Join(src, "notes.md"), "notes")
+	write(filepath.Join(repo, "main.go"), "package main")
+	write(filepath.Join(repo, ".gitignore"), "node_modules/\n")
+	git("init", "-q")
+	git("remote", "add", "origin", "https://example.com/acme/service.git")
+	git("add", ".")
+	git("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+	write(filepath.Join(repo, "main.go"), "package main // changed")
+	write(filepath.Join(repo, "todo.txt"), "untracked")
+	write(filepath.Join(repo, "node_modules", "dep", "index.js"), "ignored")
+
+	result, err := StashFolder(cfg, src, StashOptions{Bundles: true, DeleteAfter: true})
+	if err != nil {
+		t.Fatalf("StashFolder() error = %v", err)
+	}
+	m, err := ReadManifest(result.ArchivePath)
+	if err != nil || m == nil || !m.Bundles || len(m.Repos) != 1 || m.Repos[0].Bundle == "" {
+		t.Fatalf("ReadManifest() = %+v, %v; want a bundle stash of one repo", m, err)
+	}
+	if check, err := VerifyStash(result.ArchivePath); err != nil || !check.OK() {
+		t.Errorf("VerifyStash() = %+v, %v", check, err)
+	}
+
+	restored, err := RestoreArchive(cfg, result.ArchivePath, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("RestoreArchive() error = %v", err)
+	}
+	if len(restored.ReposRestored) != 1 || len(restored.Warnings) > 0 {
+		t.Errorf("RestoreArchive() = %+v", restored)
+	}
+	for path, want := range map[string]string{
+		filepath.Join(src, "notes.md"):      "notes",
+		filepath.Join(repo, "main.go"):      "package main // changed",
+		filepath.Join(repo, "todo.txt"):     "untracked",
+		filepath.Join(repo, ".git", "HEAD"): "",
+		filepath.Join(repo, "node_modules"): "-",
+		filepath.Join(repo, ".gitignore"):   "node_modules/\n",
+	} {
+		data, err := os.ReadFile(path)
+		switch {
+		case want == "-":
+			if _, err := os.Stat(path); err == nil {
+				t.Errorf("%s should not be restored", path)
+			}
+		case err != nil:
+			t.Errorf("%s was not restored: %v", path, err)
+		case want != "" && string(data) != want:
+			t.Errorf("%s = %q, want %q", path, data, want)
+		}
+	}
+	out, err := exec.Command("git", "-C", repo, "remote", "get-url", "origin").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "https://example.com/acme/service.git" {
+		t.Errorf("restored origin = %q, %v", out, err)
+	}
+	if head, _ := exec.Command("git", "-C", repo, "rev-parse", "HEAD").Output(); strings.TrimSpace(string(head)) != m.Repos[0].Head {
+		t.Errorf("restored HEAD = %s, want %s", head, m.Repos[0].Head)
+	}
+}
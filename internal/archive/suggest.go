@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/index"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// Suggestion is a workspace worth archiving: untouched for a while, with
+// clean, fully pushed repos.
+type Suggestion struct {
+	Slug         string    `json:"slug"`
+	Path         string    `json:"path"`
+	LastActivity time.Time `json:"last_activity"`
+	InactiveDays int       `json:"inactive_days"`
+	RepoCount    int       `json:"repo_count"`
+	SizeBytes    int64     `json:"size_bytes"`
+}
+
+// Suggest returns the workspaces among records inactive for at least days
+// whose repos have no uncommitted changes or unpushed commits, least recently
+// active first. Archived and invalid records are skipped, and repos are
+// checked on disk rather than trusting the index.
+func Suggest(records []*model.IndexRecord, days int, now time.Time) []Suggestion {
+	cutoff := now.AddDate(0, 0, -days)
+	var suggestions []Suggestion
+	for _, r := range records {
+		if !r.Valid || r.State == model.StateArchived || r.DirtyRepos > 0 {
+			continue
+		}
+		last := index.LastActivity(r)
+		if last.IsZero() || last.After(cutoff) {
+			continue
+		}
+		if !fs.HasProjectJSON(r.Path) || len(git.FindUnsavedWork(r.Path)) > 0 {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Slug:         r.Slug,
+			Path:         r.Path,
+			LastActivity: last,
+			InactiveDays: int(now.Sub(last).Hours() / 24),
+			RepoCount:    r.RepoCount,
+			SizeBytes:    r.SizeBytes,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].LastActivity.Before(suggestions[j].LastActivity)
+	})
+	return suggestions
+}
@@ -0,0 +1,49 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestSuggest(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	record := func(slug string, daysAgo int) *model.IndexRecord {
+		path := filepath.Join(root, slug)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "project.json"), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		r := model.NewIndexRecord(slug, path)
+		r.State = model.StateActive
+		last := now.AddDate(0, 0, -daysAgo)
+		r.LastCommitAt = &last
+		return r
+	}
+
+	oldest := record("acme--oldest", 400)
+	old := record("acme--old", 200)
+	recent := record("acme--recent", 10)
+	dirty := record("acme--dirty", 300)
+	dirty.DirtyRepos = 1
+	archived := record("acme--archived", 300)
+	archived.State = model.StateArchived
+	missing := model.NewIndexRecord("acme--missing", filepath.Join(root, "acme--missing"))
+	missingAt := now.AddDate(0, 0, -300)
+	missing.LastCommitAt = &missingAt
+
+	got := Suggest([]*model.IndexRecord{old, recent, dirty, archived, missing, oldest}, 180, now)
+	if len(got) != 2 || got[0].Slug != "acme--oldest" || got[1].Slug != "acme--old" {
+		t.Fatalf("Suggest() = %+v, want acme--oldest then acme--old", got)
+	}
+	if got[1].InactiveDays != 200 {
+		t.Errorf("InactiveDays = %d, want 200", got[1].InactiveDays)
+	}
+}
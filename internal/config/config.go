@@ -2,8 +2,14 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ServerConfig struct {
@@ -36,6 +42,271 @@ type TmpConfig struct {
 	CleanupDays int `json:"cleanup_days,omitempty"`
 }
 
+// ArchiveConfig holds configuration for archive suggestions
+type ArchiveConfig struct {
+	// SuggestDays is the number of days of inactivity after which a workspace
+	// with clean, fully pushed repos is suggested for archiving (default: 180)
+	SuggestDays int `json:"suggest_days,omitempty"`
+
+	// StashName is the pattern of stash archive names, before the
+	// --<timestamp>--stash.tar.gz every stash ends in. Placeholders: {name}
+	// (the folder or given name), {date}, {owner} (from the origin remote),
+	// and {git_branch} (default: "{name}")
+	StashName string `json:"stash_name,omitempty"`
+}
+
+// QuarantineConfig holds configuration for safe-delete mode
+type QuarantineConfig struct {
+	// Enabled makes deletes move folders to _system/quarantine instead of
+	// removing them
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Days is how long quarantined items are kept before they are purged
+	// (default: 30)
+	Days int `json:"days,omitempty"`
+}
+
+// GitScanConfig controls how import sources are scanned for git repositories
+type GitScanConfig struct {
+	// MaxDepth is how many directory levels below the source folder are
+	// scanned (default: 4; -1 for unlimited)
+	MaxDepth int `json:"max_depth,omitempty"`
+
+	// FollowSymlinks descends into symlinked directories while scanning
+	FollowSymlinks bool `json:"follow_symlinks,omitempty"`
+
+	// Exclude lists directory names or glob patterns to skip, in addition to
+	// the built-in list (node_modules, vendor, build outputs, ...)
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// ImportBrowserConfig holds preferences for the interactive import browser
+type ImportBrowserConfig struct {
+	// Sort orders folder entries: "name", "size", "modified", or "git"
+	// (git repositories first) (default: "name")
+	Sort string `json:"sort,omitempty"`
+
+	// ShowHidden lists hidden files (dotfiles) when the browser opens
+	ShowHidden bool `json:"show_hidden,omitempty"`
+
+	// AlwaysShow lists dotfile names shown even while hidden files are hidden,
+	// in the browser and when offering extra files to import
+	// (default: .env, .gitignore, .git)
+	AlwaysShow []string `json:"always_show,omitempty"`
+
+	// StickyDefaults pre-selects the owner, template, extra-files
+	// destination, and post-import choice of the last import (default: true)
+	StickyDefaults *bool `json:"sticky_defaults,omitempty"`
+}
+
+// WorkspaceIndexConfig controls the index file co generates at each
+// workspace root
+type WorkspaceIndexConfig struct {
+	// File is the name of the file written at the workspace root, e.g.
+	// "README.md" (default: "INDEX.md")
+	File string `json:"file,omitempty"`
+
+	// Manual stops co from regenerating the file after import and add-to;
+	// run co index <workspace> instead
+	Manual bool `json:"manual,omitempty"`
+}
+
+// SecretsConfig controls how files that likely hold secrets (.env, *.pem,
+// id_rsa, ...) are handled
+type SecretsConfig struct {
+	// Patterns lists extra file names or glob patterns treated as secrets, in
+	// addition to the built-in list
+	Patterns []string `json:"patterns,omitempty"`
+
+	// StashInclude keeps secret files in stash archives instead of leaving
+	// them out
+	StashInclude bool `json:"stash_include,omitempty"`
+}
+
+// ShellEnvConfig controls the direnv or mise file written into new and
+// imported workspaces so tool versions and env vars follow the workspace
+type ShellEnvConfig struct {
+	// Generate is the file to write: "direnv" (.envrc) or "mise" (mise.toml);
+	// nothing is written when empty
+	Generate string `json:"generate,omitempty"`
+
+	// Tools maps tool names to versions, e.g. {"node": "{{NODE_VERSION}}"}
+	Tools map[string]string `json:"tools,omitempty"`
+
+	// Env holds extra environment variables
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// TemplateTrustConfig controls how templates installed from the catalog are
+// verified
+type TemplateTrustConfig struct {
+	// Keys lists trusted minisign public keys: the base64 line of a .pub file
+	Keys []string `json:"keys,omitempty"`
+
+	// RequireSignature refuses to install templates without a valid signature
+	// from one of Keys
+	RequireSignature bool `json:"require_signature,omitempty"`
+}
+
+// Modes of co hooks install.
+const (
+	GitHooksPathMode = "hooks_path" // Point core.hooksPath of each repo at the workspace's .githooks
+	GitHooksCopyMode = "copy"       // Copy the hooks into each repo's hooks directory
+)
+
+// GitHooksConfig sets where co hooks install takes git hooks from and how it
+// installs them
+type GitHooksConfig struct {
+	// Dir holds hook scripts named after the git hooks they implement
+	// (default: _system/git-hooks). "~" expands to home.
+	Dir string `json:"dir,omitempty"`
+
+	// Mode is "hooks_path" or "copy" (default: hooks_path)
+	Mode string `json:"mode,omitempty"`
+}
+
+// RetryConfig sets the timeouts and retries of git clones and fetches and of
+// template hooks
+type RetryConfig struct {
+	// GitTimeout limits each git clone or fetch attempt, e.g. "10m"
+	// (default: 10m)
+	GitTimeout string `json:"git_timeout,omitempty"`
+
+	// GitRetries is how many times a failed clone or fetch is retried
+	// (default: 2; -1 for none)
+	GitRetries int `json:"git_retries,omitempty"`
+
+	// HookTimeout limits hooks that set no timeout of their own
+	// (default: 5m)
+	HookTimeout string `json:"hook_timeout,omitempty"`
+
+	// HookRetries is how many times a failed hook is retried (default: 0)
+	HookRetries int `json:"hook_retries,omitempty"`
+
+	// Backoff is the wait before the first retry, doubled before each
+	// further retry (default: 2s)
+	Backoff string `json:"backoff,omitempty"`
+}
+
+// OwnerPolicy holds the defaults applied to, and the rules checked for, the
+// workspaces of one owner
+type OwnerPolicy struct {
+	// Template is applied to new and imported workspaces when none is given
+	Template string `json:"template,omitempty"`
+
+	// Tags are added to new and imported workspaces
+	Tags []string `json:"tags,omitempty"`
+
+	// ArchiveRetentionDays removes the owner's archives older than this many
+	// days whenever one of its workspaces is archived; the newest archive of
+	// each workspace is always kept (0 = keep everything)
+	ArchiveRetentionDays int `json:"archive_retention_days,omitempty"`
+
+	// ProjectPattern is a regular expression project names must match
+	ProjectPattern string `json:"project_pattern,omitempty"`
+
+	// RepoPolicy overrides the global repo_policy for the owner's workspaces;
+	// unset fields keep the global value
+	RepoPolicy *RepoPolicy `json:"repo_policy,omitempty"`
+
+	// RepoLayout overrides the global repo_layout for the owner's new
+	// workspaces
+	RepoLayout string `json:"repo_layout,omitempty"`
+}
+
+// RepoPolicy holds the rules co policy check enforces on the repos of
+// workspaces
+type RepoPolicy struct {
+	// BranchPattern is a regular expression local branch names other than
+	// the default branch must match
+	BranchPattern string `json:"branch_pattern,omitempty"`
+
+	// CommitPattern is a regular expression the subjects of recent commits
+	// must match; merge commits are exempt
+	CommitPattern string `json:"commit_pattern,omitempty"`
+
+	// CommitDepth is how many recent commits CommitPattern checks (default: 20)
+	CommitDepth int `json:"commit_depth,omitempty"`
+
+	// RequiredFiles must exist in each repo; "a|b" accepts either path,
+	// e.g. "CODEOWNERS|.github/CODEOWNERS"
+	RequiredFiles []string `json:"required_files,omitempty"`
+
+	// DefaultBranch is the name the default branch must have
+	DefaultBranch string `json:"default_branch,omitempty"`
+
+	// ProtectDefaultBranch forbids commits on the default branch that are not
+	// on its remote: changes land through pull requests instead
+	ProtectDefaultBranch bool `json:"protect_default_branch,omitempty"`
+}
+
+// Empty reports whether the policy has no rules.
+func (p RepoPolicy) Empty() bool {
+	return p.BranchPattern == "" && p.CommitPattern == "" && len(p.RequiredFiles) == 0 &&
+		p.DefaultBranch == "" && !p.ProtectDefaultBranch
+}
+
+// NotesConfig links workspaces to notes in an Obsidian vault or a directory
+// of org files
+type NotesConfig struct {
+	// Vault is the notes directory; the integration is off when empty
+	Vault string `json:"vault,omitempty"`
+
+	// Dir is the folder inside the vault holding workspace notes
+	// (default: "Workspaces")
+	Dir string `json:"dir,omitempty"`
+
+	// Format of new note stubs: "markdown" or "org" (default: "markdown")
+	Format string `json:"format,omitempty"`
+
+	// Open is the command notes are opened with; "obsidian" opens them through
+	// an obsidian:// link (default: the editor, then the system opener)
+	Open string `json:"open,omitempty"`
+}
+
+// DefaultSlugSeparator joins workspace slug segments unless configured otherwise.
+const DefaultSlugSeparator = "--"
+
+// SlugConfig holds configuration for the workspace slug scheme.
+// Parsing and formatting live in the workspace package (workspace.SchemeFor).
+type SlugConfig struct {
+	// Separator joins slug segments (default: "--")
+	Separator string `json:"separator,omitempty"`
+
+	// Category allows an optional leading org/category segment:
+	// category<sep>owner<sep>project
+	Category bool `json:"category,omitempty"`
+
+	// Nested stores workspaces as code_root/[category/]owner/project instead of
+	// code_root/<slug>
+	Nested bool `json:"nested,omitempty"`
+
+	// Unicode keeps non-ASCII letters and digits in slugs instead of
+	// transliterating or dropping them
+	Unicode bool `json:"unicode,omitempty"`
+
+	// AllowedChars lists extra ASCII punctuation allowed in slug segments
+	// (e.g. "._"); path separators and whitespace are ignored
+	AllowedChars string `json:"allowed_chars,omitempty"`
+}
+
+// ConfirmOp names an operation governed by the confirmation policy.
+type ConfirmOp string
+
+const (
+	ConfirmDelete      ConfirmOp = "delete"       // Deleting workspaces, folders, or files
+	ConfirmStashDelete ConfirmOp = "stash_delete" // Deleting the source after stashing or archiving it
+	ConfirmOverwrite   ConfirmOp = "overwrite"    // Replacing existing data, e.g. sync --force
+)
+
+// ConfirmConfig controls which operations ask for confirmation. Unset
+// fields default to true; --yes skips confirmation regardless.
+type ConfirmConfig struct {
+	Delete      *bool `json:"delete,omitempty"`
+	StashDelete *bool `json:"stash_delete,omitempty"`
+	Overwrite   *bool `json:"overwrite,omitempty"`
+}
+
 // IndexingConfig holds configuration for code indexing
 type IndexingConfig struct {
 	// ChunkMaxLines is the maximum number of lines per chunk (default: 100)
@@ -71,6 +342,63 @@ type Config struct {
 	Embeddings *EmbeddingsConfig       `json:"embeddings,omitempty"`
 	Indexing   *IndexingConfig         `json:"indexing,omitempty"`
 	Tmp        *TmpConfig              `json:"tmp,omitempty"`
+	Archive    *ArchiveConfig          `json:"archive,omitempty"`
+	Quarantine *QuarantineConfig       `json:"quarantine,omitempty"`
+	GitScan    *GitScanConfig          `json:"git_scan,omitempty"`
+	Browser    *ImportBrowserConfig    `json:"import_browser,omitempty"`
+	WSIndex    *WorkspaceIndexConfig   `json:"workspace_index,omitempty"`
+	Notes      *NotesConfig            `json:"notes,omitempty"`
+	Secrets    *SecretsConfig          `json:"secrets,omitempty"`
+	ShellEnv   *ShellEnvConfig         `json:"shell_env,omitempty"`
+	Owners     map[string]OwnerPolicy  `json:"owners,omitempty"`
+	Slug       *SlugConfig             `json:"slug,omitempty"`
+	Confirm    *ConfirmConfig          `json:"confirm,omitempty"`
+	Retry      *RetryConfig            `json:"retry,omitempty"`
+	GitHooks   *GitHooksConfig         `json:"git_hooks,omitempty"`
+	RepoPolicy *RepoPolicy             `json:"repo_policy,omitempty"`
+
+	// RepoLayout is where imported and template repos of new workspaces go:
+	// "nested" under repos/ (default) or "flat" directly in the workspace
+	// folder. Templates can set their own; each workspace records its layout
+	// in project.json.
+	RepoLayout string `json:"repo_layout,omitempty"`
+
+	// TemplateCatalog is the URL or path of a JSON catalog of community
+	// templates, used by co template search and install
+	TemplateCatalog string `json:"template_catalog,omitempty"`
+
+	TemplateTrust *TemplateTrustConfig `json:"template_trust,omitempty"`
+
+	// Script is the Starlark file holding user rules and event hooks
+	// (default: _system/co.star). "~" expands to home.
+	Script string `json:"script,omitempty"`
+
+	// Offline defers clones to co resume-network and refuses other network
+	// access; the --offline flag and CO_OFFLINE=1 turn it on for one run
+	Offline bool `json:"offline,omitempty"`
+
+	// Shared marks a code root shared between users or on network storage:
+	// created files and directories get their permissions from the umask and
+	// default ACLs, files are copied without filesystem clones, and locks are
+	// taken in a way that is safe on NFS. CO_SHARED=1 turns it on too.
+	Shared bool `json:"shared,omitempty"`
+
+	// Plain renders TUIs as numbered menus and line prompts without colors,
+	// box drawing, or spinners, for screen readers and dumb terminals; the
+	// --plain flag and CO_PLAIN=1 turn it on for one run
+	Plain bool `json:"plain,omitempty"`
+
+	// UsageStats records how often each command runs and how long it takes
+	// in the state dir, for co stats --self; they never leave this machine
+	UsageStats bool `json:"usage_stats,omitempty"`
+
+	// ProtectedPaths are directories co refuses to delete, trash, or
+	// stash-delete, including everything inside them. "~" expands to home.
+	ProtectedPaths []string `json:"protected_paths,omitempty"`
+
+	// Aliases are short names for workspaces, such as "api" for
+	// acme--payments-api, accepted wherever a slug is
+	Aliases map[string]string `json:"aliases,omitempty"`
 }
 
 const CurrentConfigSchema = 1
@@ -107,6 +435,9 @@ func Load(configPath string) (*Config, error) {
 		}
 
 		cfg.expandPaths()
+		if err := cfg.checkAliases(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
 		return &cfg, nil
 	}
 
@@ -140,6 +471,24 @@ func (c *Config) expandPaths() {
 		c.CodeRoot = filepath.Join(home, c.CodeRoot[1:])
 	}
 
+	if c.Notes != nil && len(c.Notes.Vault) > 0 && c.Notes.Vault[0] == '~' {
+		c.Notes.Vault = filepath.Join(home, c.Notes.Vault[1:])
+	}
+
+	if len(c.Script) > 0 && c.Script[0] == '~' {
+		c.Script = filepath.Join(home, c.Script[1:])
+	}
+
+	if c.GitHooks != nil && len(c.GitHooks.Dir) > 0 && c.GitHooks.Dir[0] == '~' {
+		c.GitHooks.Dir = filepath.Join(home, c.GitHooks.Dir[1:])
+	}
+
+	for i, p := range c.ProtectedPaths {
+		if len(p) > 0 && p[0] == '~' {
+			c.ProtectedPaths[i] = filepath.Join(home, p[1:])
+		}
+	}
+
 	for name, server := range c.Servers {
 		if server.CodeRoot == "" {
 			server.CodeRoot = "~/Code"
@@ -170,6 +519,16 @@ func (c *Config) ArchiveDir() string {
 	return filepath.Join(c.SystemDir(), "archive")
 }
 
+// LocksDir returns the directory holding advisory lock files.
+func (c *Config) LocksDir() string {
+	return filepath.Join(c.SystemDir(), "locks")
+}
+
+// QuarantineDir returns the directory holding quarantined deletes.
+func (c *Config) QuarantineDir() string {
+	return filepath.Join(c.SystemDir(), "quarantine")
+}
+
 func (c *Config) LogsDir() string {
 	return filepath.Join(c.SystemDir(), "logs")
 }
@@ -178,6 +537,61 @@ func (c *Config) CacheDir() string {
 	return filepath.Join(c.SystemDir(), "cache")
 }
 
+// NetworkQueuePath returns the file of network work deferred while offline.
+func (c *Config) NetworkQueuePath() string {
+	return filepath.Join(c.SystemDir(), "network-queue.json")
+}
+
+// IsOffline reports whether co must not use the network, because offline is
+// set in the config or CO_OFFLINE in the environment.
+func (c *Config) IsOffline() bool {
+	if on, err := strconv.ParseBool(os.Getenv("CO_OFFLINE")); err == nil && on {
+		return true
+	}
+	return c != nil && c.Offline
+}
+
+// IsShared reports whether the code root is shared, because shared is set
+// in the config or CO_SHARED is true.
+func (c *Config) IsShared() bool {
+	if on, err := strconv.ParseBool(os.Getenv("CO_SHARED")); err == nil && on {
+		return true
+	}
+	return c != nil && c.Shared
+}
+
+// IsPlain reports whether TUIs should run in plain mode, because plain is set
+// in the config, CO_PLAIN is true, or TERM is dumb.
+func (c *Config) IsPlain() bool {
+	if on, err := strconv.ParseBool(os.Getenv("CO_PLAIN")); err == nil && on {
+		return true
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return true
+	}
+	return c != nil && c.Plain
+}
+
+// ErrOffline is returned for network access refused in offline mode.
+var ErrOffline = errors.New("offline mode is on (offline config, --offline, or CO_OFFLINE)")
+
+// CheckOnline returns an error wrapping ErrOffline, naming what needed the
+// network, when co is offline.
+func (c *Config) CheckOnline(what string) error {
+	if c.IsOffline() {
+		return fmt.Errorf("cannot %s: %w", what, ErrOffline)
+	}
+	return nil
+}
+
+// ScriptPath returns the path of the Starlark rules and hooks file.
+func (c *Config) ScriptPath() string {
+	if c.Script != "" {
+		return c.Script
+	}
+	return filepath.Join(c.SystemDir(), "co.star")
+}
+
 // TemplatesDir returns the path to the primary templates directory.
 func (c *Config) TemplatesDir() string {
 	return filepath.Join(c.SystemDir(), "templates")
@@ -208,6 +622,16 @@ func (c *Config) FallbackPartialsDir() string {
 	return filepath.Join(xdgConfig, "co", "partials")
 }
 
+// StateDir returns the directory for persisted UI state ($XDG_STATE_HOME/co).
+func (c *Config) StateDir() string {
+	xdgState := os.Getenv("XDG_STATE_HOME")
+	if xdgState == "" {
+		home, _ := os.UserHomeDir()
+		xdgState = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(xdgState, "co")
+}
+
 // AllTemplatesDirs returns all template directories to search, in priority order.
 // Primary (_system/templates) is checked first, then fallback (XDG config).
 func (c *Config) AllTemplatesDirs() []string {
@@ -220,8 +644,21 @@ func (c *Config) AllPartialsDirs() []string {
 	return []string{c.PartialsDir(), c.FallbackPartialsDir()}
 }
 
+// WorkspacePath returns the directory of a workspace. With nested slug
+// directories each segment becomes a directory level; tmp workspaces always
+// live directly under the code root.
 func (c *Config) WorkspacePath(slug string) string {
-	return filepath.Join(c.CodeRoot, slug)
+	sc := c.GetSlugConfig()
+	if !sc.Nested || strings.HasPrefix(slug, "tmp--") {
+		return filepath.Join(c.CodeRoot, slug)
+	}
+
+	n := 2
+	if sc.Category {
+		n = 3
+	}
+	parts := strings.SplitN(slug, sc.Separator, n)
+	return filepath.Join(append([]string{c.CodeRoot}, parts...)...)
 }
 
 // VectorsDBPath returns the path to the vector search database
@@ -309,6 +746,137 @@ func (c *Config) GetIndexingConfig() IndexingConfig {
 	return cfg
 }
 
+// GetSlugConfig returns the slug config with defaults applied. Separators that
+// contain path separators or whitespace fall back to the default.
+func (c *Config) GetSlugConfig() SlugConfig {
+	cfg := SlugConfig{
+		Separator: DefaultSlugSeparator,
+	}
+
+	if c.Slug != nil {
+		if sep := c.Slug.Separator; sep != "" && !strings.ContainsAny(sep, "/\\ \t") {
+			cfg.Separator = sep
+		}
+		cfg.Category = c.Slug.Category
+		cfg.Nested = c.Slug.Nested
+		cfg.Unicode = c.Slug.Unicode
+		for _, r := range c.Slug.AllowedChars {
+			if r > ' ' && r < 0x7f && r != '/' && r != '\\' && !strings.ContainsRune(cfg.AllowedChars, r) {
+				cfg.AllowedChars += string(r)
+			}
+		}
+	}
+
+	return cfg
+}
+
+// RequiresConfirm reports whether op should ask for confirmation under the
+// configured policy. Every operation confirms unless explicitly disabled.
+func (c *Config) RequiresConfirm(op ConfirmOp) bool {
+	if c == nil || c.Confirm == nil {
+		return true
+	}
+
+	var setting *bool
+	switch op {
+	case ConfirmDelete:
+		setting = c.Confirm.Delete
+	case ConfirmStashDelete:
+		setting = c.Confirm.StashDelete
+	case ConfirmOverwrite:
+		setting = c.Confirm.Overwrite
+	}
+	return setting == nil || *setting
+}
+
+// ProtectedPathError is returned when an operation would remove a protected path.
+type ProtectedPathError struct {
+	Path      string // Path that would be removed
+	Protected string // Protected path it would remove or lies within
+}
+
+func (e *ProtectedPathError) Error() string {
+	if e.Path == e.Protected {
+		return fmt.Sprintf("refusing to remove protected path %s", e.Path)
+	}
+	return fmt.Sprintf("refusing to remove %s: protected path %s", e.Path, e.Protected)
+}
+
+// CheckRemovable returns a *ProtectedPathError if deleting path would remove a
+// protected location. The filesystem root, the home directory, the code root,
+// and their ancestors are always protected, as is everything in _system.
+// Configured ProtectedPaths protect their whole subtree as well.
+func (c *Config) CheckRemovable(path string) error {
+	path = resolvePath(path)
+
+	// Paths that may not be removed, nor any directory containing them
+	guarded := []string{string(filepath.Separator)}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		guarded = append(guarded, home)
+	}
+	// Paths that may not be removed, nor anything inside them
+	subtrees := make([]string, 0, len(c.ProtectedPaths)+1)
+	if c.CodeRoot != "" {
+		guarded = append(guarded, c.CodeRoot)
+		subtrees = append(subtrees, c.SystemDir())
+	}
+	subtrees = append(subtrees, c.ProtectedPaths...)
+
+	for _, g := range guarded {
+		if g = resolvePath(g); IsWithin(g, path) {
+			return &ProtectedPathError{Path: path, Protected: g}
+		}
+	}
+	for _, p := range subtrees {
+		if p == "" {
+			continue
+		}
+		if p = resolvePath(p); IsWithin(p, path) || IsWithin(path, p) {
+			return &ProtectedPathError{Path: path, Protected: p}
+		}
+	}
+	return nil
+}
+
+// IsWithin reports whether path is parent or lies below it. Both paths
+// should be absolute and clean.
+func IsWithin(path, parent string) bool {
+	rel, err := filepath.Rel(parent, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// InCodeRoot reports whether path is the code root or inside it, with
+// symlinks resolved in both, so a link to a workspace counts as inside.
+func (c *Config) InCodeRoot(path string) bool {
+	if c == nil || c.CodeRoot == "" {
+		return false
+	}
+	return IsWithin(resolvePath(path), resolvePath(c.CodeRoot))
+}
+
+// resolvePath makes path absolute and resolves symlinks in its longest
+// existing prefix so protections cannot be sidestepped through a link.
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.Clean(path)
+
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if dir == filepath.Dir(dir) {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
 // GetTmpConfig returns the tmp config with defaults applied
 func (c *Config) GetTmpConfig() TmpConfig {
 	cfg := TmpConfig{
@@ -323,3 +891,315 @@ func (c *Config) GetTmpConfig() TmpConfig {
 
 	return cfg
 }
+
+// GetArchiveConfig returns the archive config with defaults applied.
+func (c *Config) GetArchiveConfig() ArchiveConfig {
+	cfg := ArchiveConfig{
+		SuggestDays: 180,
+		StashName:   "{name}",
+	}
+
+	if c != nil && c.Archive != nil {
+		if c.Archive.SuggestDays > 0 {
+			cfg.SuggestDays = c.Archive.SuggestDays
+		}
+		if c.Archive.StashName != "" {
+			cfg.StashName = c.Archive.StashName
+		}
+	}
+
+	return cfg
+}
+
+// GetQuarantineConfig returns the quarantine config with defaults applied.
+// Quarantine is disabled unless configured.
+func (c *Config) GetQuarantineConfig() QuarantineConfig {
+	cfg := QuarantineConfig{
+		Days: 30,
+	}
+
+	if c != nil && c.Quarantine != nil {
+		cfg.Enabled = c.Quarantine.Enabled
+		if c.Quarantine.Days > 0 {
+			cfg.Days = c.Quarantine.Days
+		}
+	}
+
+	return cfg
+}
+
+// GetGitScanConfig returns the git scan config with defaults applied
+func (c *Config) GetGitScanConfig() GitScanConfig {
+	cfg := GitScanConfig{
+		MaxDepth: 4,
+	}
+
+	if c != nil && c.GitScan != nil {
+		if c.GitScan.MaxDepth != 0 {
+			cfg.MaxDepth = c.GitScan.MaxDepth
+		}
+		if cfg.MaxDepth < -1 {
+			cfg.MaxDepth = -1
+		}
+		cfg.FollowSymlinks = c.GitScan.FollowSymlinks
+		cfg.Exclude = c.GitScan.Exclude
+	}
+
+	return cfg
+}
+
+// GetImportBrowserConfig returns the import browser config with defaults applied
+func (c *Config) GetImportBrowserConfig() ImportBrowserConfig {
+	cfg := ImportBrowserConfig{
+		Sort:       "name",
+		AlwaysShow: []string{".env", ".gitignore", ".git"},
+	}
+
+	if c != nil && c.Browser != nil {
+		if c.Browser.Sort != "" {
+			cfg.Sort = c.Browser.Sort
+		}
+		cfg.ShowHidden = c.Browser.ShowHidden
+		if c.Browser.AlwaysShow != nil {
+			cfg.AlwaysShow = c.Browser.AlwaysShow
+		}
+		cfg.StickyDefaults = c.Browser.StickyDefaults
+	}
+
+	return cfg
+}
+
+// RemembersImportDefaults reports whether the import browser pre-selects the
+// choices of the last import. It does unless sticky_defaults is false.
+func (c *Config) RemembersImportDefaults() bool {
+	s := c.GetImportBrowserConfig().StickyDefaults
+	return s == nil || *s
+}
+
+// GetWorkspaceIndexConfig returns the workspace index config with defaults applied
+func (c *Config) GetWorkspaceIndexConfig() WorkspaceIndexConfig {
+	cfg := WorkspaceIndexConfig{
+		File: "INDEX.md",
+	}
+
+	if c != nil && c.WSIndex != nil {
+		if name := filepath.Base(c.WSIndex.File); c.WSIndex.File != "" && name != "." && name != string(filepath.Separator) {
+			cfg.File = name
+		}
+		cfg.Manual = c.WSIndex.Manual
+	}
+
+	return cfg
+}
+
+// GetNotesConfig returns the notes config with defaults applied. Notes are
+// disabled unless a vault is configured.
+func (c *Config) GetNotesConfig() NotesConfig {
+	cfg := NotesConfig{
+		Dir:    "Workspaces",
+		Format: "markdown",
+	}
+
+	if c != nil && c.Notes != nil {
+		cfg.Vault = c.Notes.Vault
+		if c.Notes.Dir != "" {
+			cfg.Dir = c.Notes.Dir
+		}
+		if c.Notes.Format == "org" {
+			cfg.Format = "org"
+		}
+		cfg.Open = c.Notes.Open
+	}
+
+	return cfg
+}
+
+// GetOwnerPolicy returns the policy configured for owner, matched without
+// regard to case, or the zero policy.
+func (c *Config) GetOwnerPolicy(owner string) OwnerPolicy {
+	if c == nil {
+		return OwnerPolicy{}
+	}
+	if policy, ok := c.Owners[owner]; ok {
+		return policy
+	}
+	for name, policy := range c.Owners {
+		if strings.EqualFold(name, owner) {
+			return policy
+		}
+	}
+	return OwnerPolicy{}
+}
+
+// GetRepoLayout returns the repo layout for new workspaces of owner: the
+// owner's, else the global one, else "nested".
+func (c *Config) GetRepoLayout(owner string) string {
+	if layout := c.GetOwnerPolicy(owner).RepoLayout; layout != "" {
+		return layout
+	}
+	if c != nil && c.RepoLayout != "" {
+		return c.RepoLayout
+	}
+	return "nested"
+}
+
+// ResolveAlias returns the slug that name is an alias of, or name itself
+// when it is not an alias. A name with the slug separator is always a slug,
+// so an alias can never hide a workspace.
+func (c *Config) ResolveAlias(name string) string {
+	if c != nil && !strings.Contains(name, c.GetSlugConfig().Separator) {
+		if slug := c.Aliases[name]; slug != "" {
+			return slug
+		}
+	}
+	return name
+}
+
+// checkAliases rejects aliases that are empty or could be workspace slugs.
+func (c *Config) checkAliases() error {
+	sep := c.GetSlugConfig().Separator
+	for alias, slug := range c.Aliases {
+		switch {
+		case alias == "" || slug == "":
+			return fmt.Errorf("invalid alias %q: alias and slug must not be empty", alias)
+		case strings.Contains(alias, sep):
+			return fmt.Errorf("invalid alias %q: it contains the slug separator %q, so it could name a workspace", alias, sep)
+		}
+	}
+	return nil
+}
+
+// AliasesOf returns the aliases of the workspace slug, sorted.
+func (c *Config) AliasesOf(slug string) []string {
+	if c == nil {
+		return nil
+	}
+	var aliases []string
+	for alias, target := range c.Aliases {
+		if target == slug {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// GetRepoPolicy returns the repo policy for the workspaces of owner: the
+// global repo_policy with the fields set in the owner's policy replacing
+// its own, and defaults applied.
+func (c *Config) GetRepoPolicy(owner string) RepoPolicy {
+	var policy RepoPolicy
+	if c != nil && c.RepoPolicy != nil {
+		policy = *c.RepoPolicy
+	}
+	if o := c.GetOwnerPolicy(owner).RepoPolicy; o != nil {
+		if o.BranchPattern != "" {
+			policy.BranchPattern = o.BranchPattern
+		}
+		if o.CommitPattern != "" {
+			policy.CommitPattern = o.CommitPattern
+		}
+		if o.CommitDepth > 0 {
+			policy.CommitDepth = o.CommitDepth
+		}
+		if o.RequiredFiles != nil {
+			policy.RequiredFiles = o.RequiredFiles
+		}
+		if o.DefaultBranch != "" {
+			policy.DefaultBranch = o.DefaultBranch
+		}
+		if o.ProtectDefaultBranch {
+			policy.ProtectDefaultBranch = true
+		}
+	}
+	if policy.CommitDepth <= 0 {
+		policy.CommitDepth = 20
+	}
+	return policy
+}
+
+// GetTemplateTrustConfig returns the template trust configuration.
+func (c *Config) GetTemplateTrustConfig() TemplateTrustConfig {
+	var cfg TemplateTrustConfig
+	if c != nil && c.TemplateTrust != nil {
+		cfg = *c.TemplateTrust
+	}
+	return cfg
+}
+
+// GetRetryConfig returns the retry config with defaults applied. Durations
+// that do not parse are replaced by their defaults.
+func (c *Config) GetRetryConfig() RetryConfig {
+	cfg := RetryConfig{
+		GitTimeout:  "10m",
+		GitRetries:  2,
+		HookTimeout: "5m",
+		Backoff:     "2s",
+	}
+
+	if c != nil && c.Retry != nil {
+		if validDuration(c.Retry.GitTimeout) {
+			cfg.GitTimeout = c.Retry.GitTimeout
+		}
+		if c.Retry.GitRetries != 0 {
+			cfg.GitRetries = max(c.Retry.GitRetries, 0)
+		}
+		if validDuration(c.Retry.HookTimeout) {
+			cfg.HookTimeout = c.Retry.HookTimeout
+		}
+		cfg.HookRetries = max(c.Retry.HookRetries, 0)
+		if validDuration(c.Retry.Backoff) {
+			cfg.Backoff = c.Retry.Backoff
+		}
+	}
+
+	return cfg
+}
+
+// GetGitHooksConfig returns the git hooks config with defaults applied. An
+// unknown mode is replaced by hooks_path.
+func (c *Config) GetGitHooksConfig() GitHooksConfig {
+	cfg := GitHooksConfig{
+		Dir:  filepath.Join(c.SystemDir(), "git-hooks"),
+		Mode: GitHooksPathMode,
+	}
+
+	if c.GitHooks != nil {
+		if c.GitHooks.Dir != "" {
+			cfg.Dir = c.GitHooks.Dir
+		}
+		if c.GitHooks.Mode == GitHooksCopyMode {
+			cfg.Mode = GitHooksCopyMode
+		}
+	}
+
+	return cfg
+}
+
+func validDuration(s string) bool {
+	d, err := time.ParseDuration(s)
+	return err == nil && d > 0
+}
+
+// GetShellEnvConfig returns the shell env config. Generate is "" unless set
+// to "direnv" or "mise".
+func (c *Config) GetShellEnvConfig() ShellEnvConfig {
+	var cfg ShellEnvConfig
+	if c != nil && c.ShellEnv != nil {
+		cfg = *c.ShellEnv
+		if cfg.Generate != "direnv" && cfg.Generate != "mise" {
+			cfg.Generate = ""
+		}
+	}
+	return cfg
+}
+
+// GetSecretsConfig returns the secrets config. Patterns holds only the
+// configured extra patterns; the built-in list is fs.SecretPatterns.
+func (c *Config) GetSecretsConfig() SecretsConfig {
+	var cfg SecretsConfig
+	if c != nil && c.Secrets != nil {
+		cfg = *c.Secrets
+	}
+	return cfg
+}
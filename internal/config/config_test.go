@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -90,6 +92,22 @@ func TestConfigFallbackPartialsDirWithoutXDG(t *testing.T) {
 	}
 }
 
+func TestConfigStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/xdg-state")
+	cfg := &Config{}
+	expected := filepath.Join("/tmp/xdg-state", "co")
+	if cfg.StateDir() != expected {
+		t.Errorf("StateDir() = %q, want %q", cfg.StateDir(), expected)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	home, _ := os.UserHomeDir()
+	expected = filepath.Join(home, ".local", "state", "co")
+	if cfg.StateDir() != expected {
+		t.Errorf("StateDir() without XDG = %q, want %q", cfg.StateDir(), expected)
+	}
+}
+
 func TestConfigAllPartialsDirs(t *testing.T) {
 	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg-config")
 	cfg := &Config{CodeRoot: "/home/user/Code"}
@@ -117,6 +135,250 @@ func TestConfigWorkspacePath(t *testing.T) {
 	}
 }
 
+func TestConfigWorkspacePathNested(t *testing.T) {
+	cfg := &Config{CodeRoot: "/code", Slug: &SlugConfig{Separator: ".", Category: true, Nested: true}}
+
+	tests := map[string]string{
+		"acme.api":      "/code/acme/api",
+		"work.acme.api": "/code/work/acme/api",
+		"tmp--scratch":  "/code/tmp--scratch",
+	}
+	for slug, want := range tests {
+		if got := cfg.WorkspacePath(slug); got != want {
+			t.Errorf("WorkspacePath(%q) = %q, want %q", slug, got, want)
+		}
+	}
+}
+
+func TestConfigGetSlugConfig(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.GetSlugConfig(); got.Separator != DefaultSlugSeparator || got.Category || got.Nested {
+		t.Errorf("GetSlugConfig() = %+v, want defaults", got)
+	}
+
+	cfg.Slug = &SlugConfig{Separator: "a/b", Nested: true}
+	if got := cfg.GetSlugConfig(); got.Separator != DefaultSlugSeparator || !got.Nested {
+		t.Errorf("GetSlugConfig() = %+v, want default separator with nesting", got)
+	}
+}
+
+func TestConfigGetRetryConfig(t *testing.T) {
+	cfg := &Config{}
+	want := RetryConfig{GitTimeout: "10m", GitRetries: 2, HookTimeout: "5m", Backoff: "2s"}
+	if got := cfg.GetRetryConfig(); got != want {
+		t.Errorf("GetRetryConfig() = %+v, want %+v", got, want)
+	}
+
+	cfg.Retry = &RetryConfig{GitTimeout: "soon", GitRetries: -1, HookTimeout: "90s", HookRetries: 3}
+	want = RetryConfig{GitTimeout: "10m", GitRetries: 0, HookTimeout: "90s", HookRetries: 3, Backoff: "2s"}
+	if got := cfg.GetRetryConfig(); got != want {
+		t.Errorf("GetRetryConfig() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConfigGetGitHooksConfig(t *testing.T) {
+	cfg := &Config{CodeRoot: "/code"}
+	want := GitHooksConfig{Dir: "/code/_system/git-hooks", Mode: GitHooksPathMode}
+	if got := cfg.GetGitHooksConfig(); got != want {
+		t.Errorf("GetGitHooksConfig() = %+v, want %+v", got, want)
+	}
+
+	cfg.GitHooks = &GitHooksConfig{Dir: "/hooks", Mode: "symlink"}
+	want = GitHooksConfig{Dir: "/hooks", Mode: GitHooksPathMode}
+	if got := cfg.GetGitHooksConfig(); got != want {
+		t.Errorf("GetGitHooksConfig() = %+v, want %+v", got, want)
+	}
+
+	cfg.GitHooks.Mode = GitHooksCopyMode
+	if got := cfg.GetGitHooksConfig(); got.Mode != GitHooksCopyMode {
+		t.Errorf("GetGitHooksConfig().Mode = %q, want copy", got.Mode)
+	}
+}
+
+func TestConfigGetRepoPolicy(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.GetRepoPolicy("acme"); !got.Empty() || got.CommitDepth != 20 {
+		t.Errorf("GetRepoPolicy() = %+v, want empty with default depth", got)
+	}
+
+	cfg.RepoPolicy = &RepoPolicy{BranchPattern: "feature/.+", RequiredFiles: []string{"LICENSE"}}
+	cfg.Owners = map[string]OwnerPolicy{"Acme": {RepoPolicy: &RepoPolicy{RequiredFiles: []string{}, CommitDepth: 5}}}
+	got := cfg.GetRepoPolicy("acme")
+	if got.BranchPattern != "feature/.+" || len(got.RequiredFiles) != 0 || got.CommitDepth != 5 {
+		t.Errorf("GetRepoPolicy(acme) = %+v, want the owner's overrides on the global policy", got)
+	}
+	if got := cfg.GetRepoPolicy("other"); len(got.RequiredFiles) != 1 {
+		t.Errorf("GetRepoPolicy(other) = %+v, want the global policy", got)
+	}
+}
+
+func TestConfigIsOffline(t *testing.T) {
+	t.Setenv("CO_OFFLINE", "")
+	cfg := &Config{}
+	if cfg.IsOffline() || cfg.CheckOnline("sync") != nil {
+		t.Error("IsOffline() = true by default")
+	}
+	cfg.Offline = true
+	if err := cfg.CheckOnline("sync"); !errors.Is(err, ErrOffline) {
+		t.Errorf("CheckOnline() = %v, want ErrOffline", err)
+	}
+	t.Setenv("CO_OFFLINE", "1")
+	if !(&Config{}).IsOffline() {
+		t.Error("IsOffline() should honor CO_OFFLINE")
+	}
+}
+
+func TestConfigIsShared(t *testing.T) {
+	t.Setenv("CO_SHARED", "")
+	if (&Config{}).IsShared() {
+		t.Error("IsShared() = true without config or environment")
+	}
+	if !(&Config{Shared: true}).IsShared() {
+		t.Error("IsShared() = false with shared set")
+	}
+	t.Setenv("CO_SHARED", "1")
+	if !(&Config{}).IsShared() {
+		t.Error("IsShared() = false with CO_SHARED=1")
+	}
+}
+
+func TestConfigIsPlain(t *testing.T) {
+	t.Setenv("CO_PLAIN", "")
+	t.Setenv("TERM", "xterm-256color")
+	if (&Config{}).IsPlain() {
+		t.Error("IsPlain() = true without config or environment")
+	}
+	if !(&Config{Plain: true}).IsPlain() {
+		t.Error("IsPlain() = false with plain set")
+	}
+	t.Setenv("TERM", "dumb")
+	if !(&Config{}).IsPlain() {
+		t.Error("IsPlain() = false with TERM=dumb")
+	}
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("CO_PLAIN", "1")
+	if !(&Config{}).IsPlain() {
+		t.Error("IsPlain() = false with CO_PLAIN=1")
+	}
+}
+
+func TestConfigAliases(t *testing.T) {
+	cfg := &Config{Aliases: map[string]string{
+		"api":  "acme--payments-api",
+		"pay":  "acme--payments-api",
+		"blog": "me--blog",
+	}}
+	if got := cfg.ResolveAlias("api"); got != "acme--payments-api" {
+		t.Errorf("ResolveAlias(api) = %q, want acme--payments-api", got)
+	}
+	if got := cfg.ResolveAlias("acme--web"); got != "acme--web" {
+		t.Errorf("ResolveAlias(acme--web) = %q, want the slug unchanged", got)
+	}
+	if got := cfg.AliasesOf("acme--payments-api"); len(got) != 2 || got[0] != "api" || got[1] != "pay" {
+		t.Errorf("AliasesOf() = %v, want [api pay]", got)
+	}
+	var none *Config
+	if none.ResolveAlias("api") != "api" || none.AliasesOf("me--blog") != nil {
+		t.Error("a nil config should have no aliases")
+	}
+
+	// Slugs win over aliases, and Load rejects aliases that could be slugs
+	cfg.Aliases["acme--web"] = "me--blog"
+	if got := cfg.ResolveAlias("acme--web"); got != "acme--web" {
+		t.Errorf("ResolveAlias(acme--web) = %q, want the slug to win over the alias", got)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	for _, aliases := range []string{`{"acme--web": "me--blog"}`, `{"": "me--blog"}`, `{"api": ""}`} {
+		if err := os.WriteFile(path, []byte(`{"aliases": `+aliases+`}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load() with aliases %s should fail", aliases)
+		}
+	}
+}
+
+func TestConfigRequiresConfirm(t *testing.T) {
+	var nilCfg *Config
+	if !nilCfg.RequiresConfirm(ConfirmDelete) {
+		t.Error("nil config should require confirmation")
+	}
+
+	cfg := &Config{}
+	for _, op := range []ConfirmOp{ConfirmDelete, ConfirmStashDelete, ConfirmOverwrite} {
+		if !cfg.RequiresConfirm(op) {
+			t.Errorf("RequiresConfirm(%s) = false, want true by default", op)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"confirm": {"delete": false, "overwrite": true}}`), cfg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if cfg.RequiresConfirm(ConfirmDelete) {
+		t.Error("RequiresConfirm(delete) = true, want false when disabled")
+	}
+	if !cfg.RequiresConfirm(ConfirmStashDelete) || !cfg.RequiresConfirm(ConfirmOverwrite) {
+		t.Error("unset and enabled operations should still require confirmation")
+	}
+}
+
+func TestConfigRemembersImportDefaults(t *testing.T) {
+	cfg := &Config{}
+	if !cfg.RemembersImportDefaults() {
+		t.Error("RemembersImportDefaults() = false, want true by default")
+	}
+	if err := json.Unmarshal([]byte(`{"import_browser": {"sticky_defaults": false}}`), cfg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if cfg.RemembersImportDefaults() {
+		t.Error("RemembersImportDefaults() = true, want false when disabled")
+	}
+}
+
+func TestConfigCheckRemovable(t *testing.T) {
+	base := t.TempDir()
+	docs := filepath.Join(base, "docs")
+	cfg := &Config{
+		CodeRoot:       filepath.Join(base, "Code"),
+		ProtectedPaths: []string{docs},
+	}
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{filepath.Join(base, "Code", "acme--app"), true},
+		{filepath.Join(base, "scratch"), true},
+		{filepath.Join(base, "Code"), false},
+		{base, false},
+		{"/", false},
+		{filepath.Join(base, "Code", "_system", "archive"), false},
+		{docs, false},
+		{filepath.Join(docs, "taxes"), false},
+	}
+	for _, tt := range tests {
+		err := cfg.CheckRemovable(tt.path)
+		if tt.allowed && err != nil {
+			t.Errorf("CheckRemovable(%s) = %v, want nil", tt.path, err)
+		}
+		var perr *ProtectedPathError
+		if !tt.allowed && !errors.As(err, &perr) {
+			t.Errorf("CheckRemovable(%s) = %v, want *ProtectedPathError", tt.path, err)
+		}
+	}
+
+	// Symlinks cannot be used to reach a protected directory
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(docs, link); err == nil {
+		if err := cfg.CheckRemovable(filepath.Join(link, "x")); err == nil {
+			t.Error("CheckRemovable through a symlink should be refused")
+		}
+	}
+}
+
 func TestConfigGetServer(t *testing.T) {
 	cfg := &Config{
 		Servers: map[string]ServerConfig{
@@ -5,9 +5,11 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/git"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 type MissingProject struct {
@@ -17,20 +19,21 @@ type MissingProject struct {
 	Name  string
 }
 
-func FindMissingProjects(codeRoot string) ([]MissingProject, error) {
-	workspaces, err := fs.ListWorkspaces(codeRoot)
+func FindMissingProjects(cfg *config.Config) ([]MissingProject, error) {
+	workspaces, err := workspace.ListWorkspaces(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	scheme := workspace.SchemeFor(cfg)
 	missing := make([]MissingProject, 0)
 	for _, slug := range workspaces {
-		workspacePath := filepath.Join(codeRoot, slug)
+		workspacePath := cfg.WorkspacePath(slug)
 		if fs.HasProjectJSON(workspacePath) {
 			continue
 		}
 
-		owner, name, ok := ParseSlug(slug)
+		parsed, ok := scheme.Parse(slug)
 		if !ok {
 			return nil, fmt.Errorf("invalid workspace slug: %s", slug)
 		}
@@ -38,43 +41,45 @@ func FindMissingProjects(codeRoot string) ([]MissingProject, error) {
 		missing = append(missing, MissingProject{
 			Slug:  slug,
 			Path:  workspacePath,
-			Owner: owner,
-			Name:  name,
+			Owner: parsed.Owner,
+			Name:  parsed.Project,
 		})
 	}
 
 	return missing, nil
 }
 
-func CreateProjectJSON(slug, workspacePath string) (*model.Project, error) {
-	project, err := BuildProject(slug, workspacePath)
+func CreateProjectJSON(entry MissingProject) (*model.Project, error) {
+	project, err := BuildProject(entry)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := project.Save(workspacePath); err != nil {
+	if err := project.Save(entry.Path); err != nil {
 		return nil, err
 	}
 
 	return project, nil
 }
 
-func BuildProject(slug, workspacePath string) (*model.Project, error) {
-	owner, name, ok := ParseSlug(slug)
-	if !ok {
-		return nil, fmt.Errorf("invalid workspace slug: %s", slug)
+func BuildProject(entry MissingProject) (*model.Project, error) {
+	if entry.Owner == "" || entry.Name == "" {
+		return nil, fmt.Errorf("invalid workspace slug: %s", entry.Slug)
 	}
 
-	project := model.NewProject(owner, name)
-	project.Slug = slug
+	project := model.NewProject(entry.Slug, entry.Owner, entry.Name)
+	workspacePath := entry.Path
 
-	repos, err := fs.ListRepos(workspacePath)
+	// Without project.json the layout is unknown, so repos are looked for
+	// both under repos/ and directly in the workspace folder
+	repos, err := (&model.Project{RepoLayout: model.LayoutFlat}).LocateRepos(workspacePath)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, repoName := range repos {
-		repoPath := filepath.Join(workspacePath, "repos", repoName)
+	for _, repo := range repos {
+		repoName := repo.Name
+		repoPath := filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
 
 		remote := ""
 		if git.IsRepo(repoPath) {
@@ -83,20 +88,24 @@ func BuildProject(slug, workspacePath string) (*model.Project, error) {
 			}
 		}
 
-		project.AddRepo(repoName, "repos/"+repoName, remote)
+		project.AddRepo(repoName, repo.Path, remote)
+		if !strings.HasPrefix(repo.Path, "repos/") {
+			project.RepoLayout = model.LayoutFlat
+		}
 	}
 
 	return project, nil
 }
 
+// ParseSlug splits a slug into owner and name using the default slug scheme.
 func ParseSlug(slug string) (string, string, bool) {
-	parts := strings.Split(slug, "--")
-	if len(parts) < 2 {
+	parsed, ok := workspace.DefaultSlugScheme().Parse(slug)
+	if !ok {
 		return "", "", false
 	}
 
-	owner := strings.TrimSpace(parts[0])
-	name := strings.TrimSpace(strings.Join(parts[1:], "--"))
+	owner := strings.TrimSpace(parsed.Owner)
+	name := strings.TrimSpace(parsed.Project)
 	if owner == "" || name == "" {
 		return "", "", false
 	}
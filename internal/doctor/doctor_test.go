@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/model"
 )
 
@@ -46,12 +47,12 @@ func TestFindMissingProjects(t *testing.T) {
 	if err := os.MkdirAll(filepath.Join(okPath, "repos"), 0o755); err != nil {
 		t.Fatalf("mkdir ok workspace: %v", err)
 	}
-	project := model.NewProject("oss", "present")
+	project := model.NewProject("oss--present", "oss", "present")
 	if err := project.Save(okPath); err != nil {
 		t.Fatalf("save project.json: %v", err)
 	}
 
-	missing, err := FindMissingProjects(tmpDir)
+	missing, err := FindMissingProjects(&config.Config{CodeRoot: tmpDir})
 	if err != nil {
 		t.Fatalf("FindMissingProjects error: %v", err)
 	}
@@ -89,7 +90,7 @@ func TestCreateProjectJSON(t *testing.T) {
 		t.Fatalf("mkdir web repo: %v", err)
 	}
 
-	project, err := CreateProjectJSON(slug, workspacePath)
+	project, err := CreateProjectJSON(MissingProject{Slug: slug, Path: workspacePath, Owner: "acme", Name: "app"})
 	if err != nil {
 		t.Fatalf("CreateProjectJSON error: %v", err)
 	}
@@ -0,0 +1,216 @@
+package envfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/template"
+)
+
+// ErrNoExample is returned when neither a workspace nor its repos have an
+// example env file to generate from.
+var ErrNoExample = errors.New("no example env file found (add .env.example to the workspace or its repos)")
+
+// FindExample returns the path of the first example env file in dir, or ""
+// when there is none.
+func FindExample(dir string) string {
+	for _, name := range ExampleNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Target is an env file with the example it is checked against: the
+// workspace's own .env, or a repo's.
+type Target struct {
+	Name    string `json:"name"`    // "." for the workspace root, else the repo name
+	Path    string `json:"path"`    // The .env file
+	Example string `json:"example"` // The example file
+}
+
+// Targets lists the workspace root and each repo that has an example env
+// file.
+func Targets(workspacePath string) ([]Target, error) {
+	var targets []Target
+	if example := FindExample(workspacePath); example != "" {
+		targets = append(targets, Target{Name: ".", Path: filepath.Join(workspacePath, ".env"), Example: example})
+	}
+	repos, err := model.LocateWorkspaceRepos(workspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repos: %w", err)
+	}
+	for _, repo := range repos {
+		dir := filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
+		if example := FindExample(dir); example != "" {
+			targets = append(targets, Target{Name: repo.Name, Path: filepath.Join(dir, ".env"), Example: example})
+		}
+	}
+	return targets, nil
+}
+
+// Generate builds the workspace .env from its example file, or from the
+// examples of its repos when the workspace has none. Example values are
+// templates: {{VAR}} placeholders are filled from the built-in template
+// variables, the workspace's template variables, and vars, and keys named in
+// vars are set to that value directly. Values already in the workspace .env
+// are kept, as are its keys missing from the examples.
+func Generate(cfg *config.Config, workspacePath string, vars map[string]string) (*File, error) {
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project.json: %w", err)
+	}
+
+	out, err := exampleLayout(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+
+	tmplVars := template.GetBuiltinVariables(proj.Owner, proj.Name, proj.Slug, workspacePath, cfg.CodeRoot)
+	for k, v := range proj.TemplateVars {
+		tmplVars[k] = v
+	}
+	for k, v := range vars {
+		tmplVars[k] = v
+	}
+	for _, key := range out.Keys() {
+		value, _ := out.Get(key)
+		if value, err = template.ProcessTemplateContent(value, tmplVars); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		out.Set(key, value)
+	}
+
+	existing, err := Load(filepath.Join(workspacePath, ".env"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if existing != nil {
+		var extra []string
+		for _, key := range uniqueKeys(existing) {
+			value, _ := existing.Get(key)
+			if _, ok := out.Get(key); !ok {
+				extra = append(extra, key)
+				continue
+			}
+			out.Set(key, value)
+		}
+		if len(extra) > 0 {
+			out.AddComment("Not in the example")
+			for _, key := range extra {
+				value, _ := existing.Get(key)
+				out.Set(key, value)
+			}
+		}
+	}
+
+	for key, value := range vars {
+		if _, ok := out.Get(key); ok {
+			out.Set(key, value)
+		}
+	}
+	return out, nil
+}
+
+// exampleLayout returns the workspace's example file, or the merged examples
+// of its repos with a comment naming each source. Keys repeated across repos
+// are kept once.
+func exampleLayout(workspacePath string) (*File, error) {
+	if example := FindExample(workspacePath); example != "" {
+		return Load(example)
+	}
+
+	targets, err := Targets(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	out := &File{}
+	for _, t := range targets {
+		example, err := Load(t.Example)
+		if err != nil {
+			return nil, err
+		}
+		rel, _ := filepath.Rel(workspacePath, t.Example)
+		if len(out.lines) > 0 {
+			out.lines = append(out.lines, line{})
+		}
+		out.AddComment("From " + filepath.ToSlash(rel))
+		for _, key := range uniqueKeys(example) {
+			if _, ok := out.Get(key); !ok {
+				value, _ := example.Get(key)
+				out.Set(key, value)
+			}
+		}
+	}
+	if len(out.lines) == 0 {
+		return nil, ErrNoExample
+	}
+	return out, nil
+}
+
+// RepoResult reports what SyncRepos did for one repo.
+type RepoResult struct {
+	Repo    string   `json:"repo"`
+	Path    string   `json:"path"`
+	Written bool     `json:"written"`           // Written, or would be with dryRun
+	Skipped string   `json:"skipped,omitempty"` // Why the repo's .env was not written
+	Missing []string `json:"missing"`           // Example keys with no value in the workspace .env
+}
+
+// SyncRepos writes a .env into each repo that has an example env file,
+// following the repo's example layout and taking values from the workspace
+// .env; keys the workspace .env lacks keep the example's value. Existing repo
+// .env files are kept unless overwrite is set. With dryRun nothing is
+// written.
+func SyncRepos(workspacePath string, overwrite, dryRun bool) ([]RepoResult, error) {
+	source, err := Load(filepath.Join(workspacePath, ".env"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("workspace has no .env; run co env generate first")
+		}
+		return nil, err
+	}
+
+	targets, err := Targets(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	results := []RepoResult{}
+	for _, t := range targets {
+		if t.Name == "." {
+			continue
+		}
+		result := RepoResult{Repo: t.Name, Path: t.Path, Missing: []string{}}
+
+		example, err := Load(t.Example)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range uniqueKeys(example) {
+			if value, ok := source.Get(key); ok {
+				example.Set(key, value)
+			} else {
+				result.Missing = append(result.Missing, key)
+			}
+		}
+
+		if _, err := os.Stat(t.Path); err == nil && !overwrite {
+			result.Skipped = "exists"
+		} else {
+			if !dryRun {
+				if err := os.WriteFile(t.Path, example.Bytes(), 0600); err != nil {
+					return nil, fmt.Errorf("failed to write %s: %w", t.Path, err)
+				}
+			}
+			result.Written = true
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,49 @@
+// Package errfmt renders errors for people. The CLI and the TUIs show the
+// whole message, never cut short, and for the errors co knows how to get
+// out of, a hint at what to do next.
+package errfmt
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tormodhaugland/co/internal/archive"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// hints says what to do about the errors of the internal packages, in the
+// order they are checked.
+var hints = []struct {
+	err  error
+	hint string
+}{
+	{workspace.ErrWorkspaceExists, "pick another owner or project, or add to the workspace with 'co import --add-to <slug>'"},
+	{workspace.ErrWorkspaceNotFound, "run 'co ls' to list workspaces"},
+	{git.ErrNotGitRepo, "run 'git init' there, or choose the folder that holds the repository"},
+	{archive.ErrArchiveCorrupt, "the file is damaged or was not written by co; try an older archive of the same workspace"},
+	{fs.ErrDestExists, "move the existing file out of the way, or choose another destination"},
+	{config.ErrOffline, "run without --offline and unset CO_OFFLINE to use the network"},
+	{context.Canceled, "what was done before the cancel is kept"},
+}
+
+// Hint returns what the user can do about err, or "" when co has no advice.
+func Hint(err error) string {
+	for _, h := range hints {
+		if errors.Is(err, h.err) {
+			return h.hint
+		}
+	}
+	return ""
+}
+
+// Message returns the message of err followed by its hint, if any, on one
+// line for status bars and result lists.
+func Message(err error) string {
+	if hint := Hint(err); hint != "" {
+		return err.Error() + " (" + hint + ")"
+	}
+	return err.Error()
+}
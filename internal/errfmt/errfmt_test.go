@@ -0,0 +1,25 @@
+package errfmt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+func TestMessage(t *testing.T) {
+	err := fmt.Errorf("import failed: %w", fmt.Errorf("%w: acme--app", workspace.ErrWorkspaceExists))
+	want := "import failed: workspace already exists: acme--app (pick another owner or project, or add to the workspace with 'co import --add-to <slug>')"
+	if got := Message(err); got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+
+	plain := errors.New("disk on fire")
+	if got := Message(plain); got != plain.Error() {
+		t.Errorf("Message() of an unknown error = %q, want the message alone", got)
+	}
+	if got := Hint(plain); got != "" {
+		t.Errorf("Hint() of an unknown error = %q, want none", got)
+	}
+}
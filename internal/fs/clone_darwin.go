@@ -0,0 +1,18 @@
+//go:build darwin
+
+package fs
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile creates dst as an APFS clone of src. clonefile(2) refuses to
+// replace an existing file, so the caller falls back to a plain copy then.
+func cloneFile(src, dst string, mode os.FileMode) error {
+	if err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW); err != nil {
+		return err
+	}
+	return os.Chmod(dst, mode)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package fs
+
+import (
+	"errors"
+	"os"
+)
+
+// cloneFile is not implemented on this platform.
+func cloneFile(src, dst string, mode os.FileMode) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,296 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy says how CopyTree handles symbolic links.
+type SymlinkPolicy int
+
+const (
+	SymlinkFollow   SymlinkPolicy = iota // Copy what the link points to (default)
+	SymlinkPreserve                      // Recreate the link itself
+	SymlinkSkip                          // Leave links out
+)
+
+// CollisionPolicy says what CopyTree does when a destination file exists.
+type CollisionPolicy int
+
+const (
+	CollisionOverwrite CollisionPolicy = iota // Replace the existing file (default)
+	CollisionSkip                             // Keep the existing file
+	CollisionBackup                           // Rename the existing file to .bak first
+	CollisionError                            // Stop with an error wrapping ErrDestExists
+)
+
+// ErrDestExists is returned by CopyTree under CollisionError.
+var ErrDestExists = errors.New("destination already exists")
+
+// CopyOptions configures CopyTree.
+type CopyOptions struct {
+	// Include limits the copy to files matching at least one pattern.
+	// Empty copies everything not excluded.
+	Include []string
+	// Exclude skips files and directories matching any pattern.
+	Exclude []string
+
+	Symlinks  SymlinkPolicy
+	Collision CollisionPolicy
+
+	// Progress is called after each file is copied, with its path relative
+	// to src and its size (optional)
+	Progress func(relPath string, size int64)
+}
+
+// CopyResult summarizes a CopyTree run.
+type CopyResult struct {
+	Files   int      // Files and links written
+	Bytes   int64    // Bytes in the files written
+	Skipped []string // Paths relative to src left out by collisions or symlink policy
+}
+
+// CopyTree copies the file or directory src to dst.
+//
+// Patterns use the same syntax as the sync exclude lists: a pattern ending in
+// "/" only matches directories, a pattern containing "/" is matched against
+// the slash-separated path relative to src, and any other pattern is matched
+// against the base name. Files that are hard links to each other within src
+// are hard-linked in dst too, and files are cloned where the filesystem
+// supports it (see CopyFile). Followed directory links are copied once each,
+// so link cycles terminate.
+func CopyTree(src, dst string, opts CopyOptions) (*CopyResult, error) {
+	return CopyTreeContext(context.Background(), src, dst, opts)
+}
+
+// CopyTreeContext copies like CopyTree, stopping with ctx's error before the
+// next file once ctx is done. What was copied so far is left in dst.
+func CopyTreeContext(ctx context.Context, src, dst string, opts CopyOptions) (*CopyResult, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &copier{
+		ctx:     ctx,
+		opts:    opts,
+		result:  &CopyResult{},
+		linked:  make(map[FileKey]string),
+		visited: make(map[FileKey]bool),
+	}
+	if err := c.copy(src, dst, "", info); err != nil {
+		return c.result, err
+	}
+	return c.result, nil
+}
+
+type copier struct {
+	ctx     context.Context
+	opts    CopyOptions
+	result  *CopyResult
+	linked  map[FileKey]string // first copy of each hard-linked source file
+	visited map[FileKey]bool   // directories entered, for link cycles
+}
+
+// copy copies src, whose Lstat info is given, to dst. rel is src's
+// slash-separated path relative to the tree root ("" for the root).
+func (c *copier) copy(src, dst, rel string, info os.FileInfo) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		switch c.opts.Symlinks {
+		case SymlinkSkip:
+			c.result.Skipped = append(c.result.Skipped, rel)
+			return nil
+		case SymlinkPreserve:
+			return c.copyLink(src, dst, rel)
+		}
+		target, err := os.Stat(src)
+		if err != nil {
+			return err
+		}
+		info = target
+	}
+
+	if rel != "" && c.excluded(rel, info.IsDir()) {
+		return nil
+	}
+
+	if info.IsDir() {
+		return c.copyDir(src, dst, rel, info)
+	}
+	if len(c.opts.Include) > 0 && !matchAny(c.opts.Include, rel, info.Name(), false) {
+		return nil
+	}
+	return c.copyFile(src, dst, rel, info)
+}
+
+func (c *copier) copyDir(src, dst, rel string, info os.FileInfo) error {
+	if key, ok := FileKeyOf(info); ok {
+		if c.visited[key] {
+			return nil
+		}
+		c.visited[key] = true
+	}
+	// With an include list, directories are only created to hold a match
+	if len(c.opts.Include) == 0 {
+		if err := os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		childInfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		childRel := path.Join(rel, entry.Name())
+		if err := c.copy(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), childRel, childInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *copier) copyFile(src, dst, rel string, info os.FileInfo) error {
+	if ok, err := c.resolveCollision(dst, rel); !ok || err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), DirPerm()); err != nil {
+		return err
+	}
+
+	var key FileKey
+	hardLinked := false
+	if info.Mode().IsRegular() && linkCount(info) > 1 {
+		key, hardLinked = FileKeyOf(info)
+	}
+	if first, ok := c.linked[key]; hardLinked && ok && os.Link(first, dst) == nil {
+		c.wrote(rel, info.Size())
+		return nil
+	}
+	if err := CopyFile(src, dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if _, ok := c.linked[key]; hardLinked && !ok {
+		c.linked[key] = dst
+	}
+	c.wrote(rel, info.Size())
+	return nil
+}
+
+func (c *copier) copyLink(src, dst, rel string) error {
+	if ok, err := c.resolveCollision(dst, rel); !ok || err != nil {
+		return err
+	}
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), DirPerm()); err != nil {
+		return err
+	}
+	if err := os.Symlink(target, dst); err != nil {
+		return err
+	}
+	c.wrote(rel, 0)
+	return nil
+}
+
+// resolveCollision applies the collision policy to dst and reports whether
+// the copy should go ahead.
+func (c *copier) resolveCollision(dst, rel string) (bool, error) {
+	info, err := os.Lstat(dst)
+	if err != nil {
+		return true, nil
+	}
+	switch c.opts.Collision {
+	case CollisionSkip:
+		c.result.Skipped = append(c.result.Skipped, rel)
+		return false, nil
+	case CollisionError:
+		return false, fmt.Errorf("%s: %w", dst, ErrDestExists)
+	case CollisionBackup:
+		backup := dst + ".bak"
+		for i := 1; ; i++ {
+			if _, err := os.Lstat(backup); os.IsNotExist(err) {
+				break
+			}
+			backup = fmt.Sprintf("%s.bak.%d", dst, i)
+		}
+		return true, os.Rename(dst, backup)
+	}
+	// A link or directory in the way can't be truncated like a file
+	if !info.Mode().IsRegular() {
+		return true, os.RemoveAll(dst)
+	}
+	return true, nil
+}
+
+func (c *copier) wrote(rel string, size int64) {
+	c.result.Files++
+	c.result.Bytes += size
+	if c.opts.Progress != nil {
+		c.opts.Progress(rel, size)
+	}
+}
+
+func (c *copier) excluded(rel string, isDir bool) bool {
+	return matchAny(c.opts.Exclude, rel, path.Base(rel), isDir)
+}
+
+// matchAny reports whether rel (with base name name) matches any pattern.
+func matchAny(patterns []string, rel, name string, isDir bool) bool {
+	for _, pattern := range patterns {
+		pattern, dirOnly := strings.CutSuffix(pattern, "/")
+		if dirOnly && !isDir {
+			continue
+		}
+		subject := name
+		if strings.Contains(pattern, "/") {
+			subject = rel
+		}
+		if ok, _ := path.Match(pattern, subject); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyFile copies src to dst, creating or truncating dst with mode. On
+// filesystems that support it (APFS, btrfs, XFS) dst is a clone sharing the
+// source's blocks, which is near-instant; elsewhere, and in shared mode,
+// the data is copied.
+func CopyFile(src, dst string, mode os.FileMode) error {
+	if !shared {
+		if err := cloneFile(src, dst, mode); err == nil {
+			return nil
+		}
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,25 @@
+//go:build !linux && !darwin
+
+package fs
+
+import "os"
+
+// FreeSpace is not implemented on this platform.
+func FreeSpace(path string) (uint64, error) {
+	return 0, ErrFreeSpaceUnsupported
+}
+
+// SameDevice is not implemented on this platform.
+func SameDevice(a, b string) (bool, error) {
+	return false, ErrFreeSpaceUnsupported
+}
+
+// FileKeyOf is not implemented on this platform.
+func FileKeyOf(info os.FileInfo) (FileKey, bool) {
+	return FileKey{}, false
+}
+
+// linkCount is not implemented on this platform.
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
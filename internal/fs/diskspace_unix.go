@@ -0,0 +1,56 @@
+//go:build linux || darwin
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// FreeSpace returns the number of bytes available to unprivileged users on
+// the filesystem containing path. Missing paths are resolved to their
+// nearest existing parent directory.
+func FreeSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(existingDir(path), &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
+
+// SameDevice reports whether a and b (or their nearest existing parents) are
+// on the same filesystem, meaning a rename between them needs no copy.
+func SameDevice(a, b string) (bool, error) {
+	infoA, err := os.Stat(existingDir(a))
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(existingDir(b))
+	if err != nil {
+		return false, err
+	}
+	statA, okA := infoA.Sys().(*syscall.Stat_t)
+	statB, okB := infoB.Sys().(*syscall.Stat_t)
+	if !okA || !okB {
+		return false, ErrFreeSpaceUnsupported
+	}
+	return statA.Dev == statB.Dev, nil
+}
+
+// FileKeyOf returns the device and inode of info.
+func FileKeyOf(info os.FileInfo) (FileKey, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return FileKey{}, false
+	}
+	return FileKey{Dev: uint64(st.Dev), Ino: uint64(st.Ino)}, true
+}
+
+// linkCount returns the number of hard links to the file described by info.
+func linkCount(info os.FileInfo) uint64 {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 1
+	}
+	return uint64(st.Nlink)
+}
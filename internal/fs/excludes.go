@@ -3,6 +3,7 @@ package fs
 import (
 	"bufio"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -99,6 +100,65 @@ var EnvExcludePatterns = []string{
 	".env.*",
 }
 
+// SecretPatterns match files and folders that likely hold secrets. They use
+// the exclude pattern syntax and are matched against base names.
+var SecretPatterns = []string{
+	".env", ".env.*", // Environment files
+	"*.pem", "*.key", "*.p12", "*.pfx", // Certificates and private keys
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", // SSH keys
+	"*.keystore", "*.jks", // Java keystores
+	".netrc", ".npmrc", ".pypirc", ".htpasswd", // Credential files
+	"credentials.json", "service-account*.json", // Cloud credentials
+	".secret*", "secrets/", // Secret files and folders
+	"*.tfstate", "*.tfstate.*", // Terraform state
+}
+
+// secretExampleSuffixes mark templates of secret files, such as
+// .env.example, which hold no secrets themselves.
+var secretExampleSuffixes = []string{".example", ".sample", ".template", ".dist"}
+
+// IsSecret reports whether a file or folder named name likely holds secrets,
+// by SecretPatterns and the extra patterns.
+func IsSecret(name string, isDir bool, extra []string) bool {
+	for _, suffix := range secretExampleSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return matchAny(SecretPatterns, name, name, isDir) || matchAny(extra, name, name, isDir)
+}
+
+// FindSecrets returns the paths below root, relative to it, of files and
+// folders that likely hold secrets (see IsSecret). Secret folders are listed
+// without their contents; .git and node_modules are not searched.
+func FindSecrets(root string, extra []string) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() && (name == ".git" || name == "node_modules") {
+			return filepath.SkipDir
+		}
+		if IsSecret(name, d.IsDir(), extra) {
+			rel, _ := filepath.Rel(root, path)
+			found = append(found, rel)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
 // ExcludeList holds the computed effective exclude list with source tracking.
 type ExcludeList struct {
 	Patterns []string
@@ -205,3 +205,60 @@ func TestTarExcludePattern(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSecret(t *testing.T) {
+	cases := []struct {
+		name  string
+		isDir bool
+		want  bool
+	}{
+		{".env", false, true},
+		{".env.local", false, true},
+		{".env.example", false, false},
+		{".env.sample", false, false},
+		{"server.pem", false, true},
+		{"id_rsa", false, true},
+		{"id_rsa.pub", false, false},
+		{"prod.tfstate", false, true},
+		{"secrets", true, true},
+		{"secrets", false, false},
+		{"README.md", false, false},
+		{"vault.kdbx", false, true},
+	}
+	extra := []string{"*.kdbx"}
+	for _, tc := range cases {
+		if got := IsSecret(tc.name, tc.isDir, extra); got != tc.want {
+			t.Errorf("IsSecret(%q, %v) = %v, want %v", tc.name, tc.isDir, got, tc.want)
+		}
+	}
+}
+
+func TestFindSecrets(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{
+		".env",
+		".env.example",
+		"app/config/server.key",
+		"app/main.go",
+		"secrets/token.txt",
+		".git/config",
+		"node_modules/pkg/.env",
+	} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := FindSecrets(root, nil)
+	if err != nil {
+		t.Fatalf("FindSecrets() error = %v", err)
+	}
+	want := []string{".env", "app/config/server.key", "secrets"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindSecrets() = %v, want %v", got, want)
+	}
+}
@@ -1,11 +1,15 @@
 package fs
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var workspacePattern = regexp.MustCompile(`^[a-z0-9-]+--[a-z0-9-]+(--(poc|demo|legacy|migration|infra))?$`)
@@ -109,12 +113,27 @@ func ListRepos(workspacePath string) ([]string, error) {
 }
 
 func CalculateSize(path string) (int64, error) {
+	return CalculateSizeStream(context.Background(), path, 0, nil)
+}
+
+// CalculateSizeStream calculates the size of path like CalculateSize, calling
+// progress with the running total at most once per interval while it walks.
+// It stops with ctx's error when ctx is cancelled.
+func CalculateSizeStream(ctx context.Context, path string, interval time.Duration, progress func(total int64)) (int64, error) {
 	var size int64
+	last := time.Now()
 
 	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil {
 			return nil
 		}
+		if progress != nil && time.Since(last) >= interval {
+			progress(size)
+			last = time.Now()
+		}
 
 		if d.IsDir() && shouldExcludeDir(d.Name()) {
 			return filepath.SkipDir
@@ -171,19 +190,142 @@ func GetLastModTime(path string) (int64, error) {
 	return latest, err
 }
 
+// ErrFreeSpaceUnsupported is returned by FreeSpace and SameDevice on
+// platforms where they are not implemented.
+var ErrFreeSpaceUnsupported = errors.New("free space check not supported on this platform")
+
+// InsufficientSpaceError reports that a destination filesystem cannot hold
+// the data about to be written to it.
+type InsufficientSpaceError struct {
+	Path      string
+	Need      int64
+	Available uint64
+}
+
+func (e *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("not enough free space in %s: need %s, %s available",
+		e.Path, FormatBytes(uint64(e.Need)), FormatBytes(e.Available))
+}
+
+// CheckFreeSpace returns an *InsufficientSpaceError if the filesystem
+// containing dest has fewer than need bytes available. Platforms without
+// free space support always pass.
+func CheckFreeSpace(dest string, need int64) error {
+	if need <= 0 {
+		return nil
+	}
+	avail, err := FreeSpace(dest)
+	if err != nil {
+		if errors.Is(err, ErrFreeSpaceUnsupported) {
+			return nil
+		}
+		return fmt.Errorf("failed to check free space in %s: %w", dest, err)
+	}
+	if uint64(need) > avail {
+		return &InsufficientSpaceError{Path: dest, Need: need, Available: avail}
+	}
+	return nil
+}
+
+// DirSize returns the total size of all regular files under path, including
+// directories that CalculateSize excludes. It is used to estimate how much
+// space a copy or archive of path needs.
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
+
+// existingDir returns path or its nearest existing ancestor.
+func existingDir(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// FormatBytes formats a byte count with a binary unit, e.g. "1.5 MB".
+func FormatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// shared is set for a code root shared between users; see SetShared.
+var shared bool
+
+// SetShared sets whether the code root is shared between users or on
+// network storage. Shared, co creates directories and files with modes
+// 0777 and 0666, leaving permissions to the umask and default ACLs, and
+// does not clone files, since clones carry the source's extended attributes.
+func SetShared(on bool) {
+	shared = on
+}
+
+// Shared reports whether SetShared turned shared mode on.
+func Shared() bool {
+	return shared
+}
+
+// DirPerm returns the mode co creates directories with, before the umask.
+func DirPerm() os.FileMode {
+	if shared {
+		return 0777
+	}
+	return 0755
+}
+
+// ExecPerm returns the mode co creates executable files, such as hook
+// scripts, with, before the umask.
+func ExecPerm() os.FileMode {
+	return DirPerm()
+}
+
+// FilePerm returns the mode co creates files with, before the umask.
+func FilePerm() os.FileMode {
+	if shared {
+		return 0666
+	}
+	return 0644
+}
+
 func EnsureDir(path string) error {
-	return os.MkdirAll(path, 0755)
+	return os.MkdirAll(path, DirPerm())
 }
 
 func CreateWorkspace(codeRoot, slug string) (string, error) {
 	workspacePath := filepath.Join(codeRoot, slug)
 
-	if err := os.MkdirAll(workspacePath, 0755); err != nil {
+	if err := os.MkdirAll(workspacePath, DirPerm()); err != nil {
 		return "", err
 	}
 
 	reposPath := filepath.Join(workspacePath, "repos")
-	if err := os.MkdirAll(reposPath, 0755); err != nil {
+	if err := os.MkdirAll(reposPath, DirPerm()); err != nil {
 		return "", err
 	}
 
@@ -193,3 +335,10 @@ func CreateWorkspace(codeRoot, slug string) (string, error) {
 func DefaultExcludes() []string {
 	return append([]string{}, BuiltinExcludes...)
 }
+
+// FileKey identifies a file by device and inode, independent of the path
+// used to reach it.
+type FileKey struct {
+	Dev uint64
+	Ino uint64
+}
@@ -1,6 +1,14 @@
 package fs
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
 
 func TestIsValidWorkspaceSlug(t *testing.T) {
 	tests := []struct {
@@ -93,3 +101,280 @@ func TestDefaultExcludes(t *testing.T) {
 		t.Error("DefaultExcludes() does not return a copy")
 	}
 }
+
+func TestDirSize(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]int{
+		"a.txt":                  10,
+		"node_modules/dep/b.js":  20,
+		".git/objects/pack/pack": 30,
+	}
+	for name, n := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, make([]byte, n), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	size, err := DirSize(root)
+	if err != nil {
+		t.Fatalf("DirSize: %v", err)
+	}
+	if size != 60 {
+		t.Errorf("DirSize() = %d, want 60 (excluded dirs included)", size)
+	}
+}
+
+func TestCalculateSizeStream(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("f%d", i)), make([]byte, 10), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	var totals []int64
+	size, err := CalculateSizeStream(context.Background(), root, 0, func(total int64) {
+		totals = append(totals, total)
+	})
+	if err != nil || size != 50 {
+		t.Fatalf("CalculateSizeStream() = %d, %v, want 50", size, err)
+	}
+	if len(totals) == 0 || !slices.IsSorted(totals) || totals[len(totals)-1] > size {
+		t.Errorf("progress totals = %v, want increasing running totals", totals)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CalculateSizeStream(ctx, root, 0, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled CalculateSizeStream() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "not", "created", "yet")
+
+	if err := CheckFreeSpace(dest, 0); err != nil {
+		t.Errorf("CheckFreeSpace(0) = %v, want nil", err)
+	}
+
+	if _, err := FreeSpace(dest); errors.Is(err, ErrFreeSpaceUnsupported) {
+		t.Skip("free space not supported on this platform")
+	}
+	if err := CheckFreeSpace(dest, 1); err != nil {
+		t.Errorf("CheckFreeSpace(1) = %v, want nil", err)
+	}
+
+	err := CheckFreeSpace(dest, 1<<62)
+	var spaceErr *InsufficientSpaceError
+	if !errors.As(err, &spaceErr) {
+		t.Fatalf("CheckFreeSpace(huge) = %v, want InsufficientSpaceError", err)
+	}
+	if spaceErr.Need != 1<<62 {
+		t.Errorf("Need = %d, want %d", spaceErr.Need, int64(1)<<62)
+	}
+}
+
+func TestCopyTreeHardLinks(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{"run.sh": "#!/bin/sh\n", "sub/data": "shared"})
+	if err := os.Chmod(filepath.Join(src, "run.sh"), 0o755); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	if err := os.Link(filepath.Join(src, "sub", "data"), filepath.Join(src, "twin")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	result, err := CopyTree(src, dst, CopyOptions{})
+	if err != nil {
+		t.Fatalf("CopyTree() error = %v", err)
+	}
+	if result.Files != 3 {
+		t.Errorf("Files = %d, want 3", result.Files)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "run.sh"))
+	if err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("run.sh = %v, %v, want mode 0755", info, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dst, "sub", "data"))
+	if err != nil || string(data) != "shared" {
+		t.Errorf("sub/data = %q, %v", data, err)
+	}
+
+	a, errA := os.Stat(filepath.Join(dst, "sub", "data"))
+	b, errB := os.Stat(filepath.Join(dst, "twin"))
+	if errA != nil || errB != nil {
+		t.Fatalf("stat: %v, %v", errA, errB)
+	}
+	if !os.SameFile(a, b) {
+		t.Error("hard-linked files were copied separately, want them linked in the copy")
+	}
+	if orig, _ := os.Stat(filepath.Join(src, "twin")); os.SameFile(orig, b) {
+		t.Error("copy is linked to the source, want an independent file")
+	}
+}
+
+func TestCopyTreeContextCancelled(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	dst := filepath.Join(t.TempDir(), "dst")
+	result, err := CopyTreeContext(ctx, src, dst, CopyOptions{
+		Progress: func(string, int64) { cancel() },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CopyTreeContext() error = %v, want context.Canceled", err)
+	}
+	if result.Files != 1 {
+		t.Errorf("copied %d files, want 1 before the cancel", result.Files)
+	}
+}
+
+func TestCopyTreeOptions(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	writeTree(t, src, map[string]string{
+		"README.md":           "readme",
+		"docs/guide.md":       "guide",
+		"docs/build/out.md":   "generated",
+		"node_modules/x/a.js": "dep",
+		"main.go":             "package main",
+	})
+	if err := os.Symlink("README.md", filepath.Join(src, "link.md")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(".", filepath.Join(src, "docs", "self")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	t.Run("filters and progress", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+		var progressed []string
+		result, err := CopyTree(src, dst, CopyOptions{
+			Include:  []string{"*.md"},
+			Exclude:  []string{"node_modules/", "docs/build"},
+			Progress: func(rel string, size int64) { progressed = append(progressed, rel) },
+		})
+		if err != nil {
+			t.Fatalf("CopyTree() error = %v", err)
+		}
+		for _, rel := range []string{"README.md", "link.md", "docs/guide.md"} {
+			if _, err := os.Stat(filepath.Join(dst, rel)); err != nil {
+				t.Errorf("%s not copied: %v", rel, err)
+			}
+		}
+		for _, rel := range []string{"main.go", "node_modules", "docs/build"} {
+			if _, err := os.Lstat(filepath.Join(dst, rel)); err == nil {
+				t.Errorf("%s copied, want it filtered out", rel)
+			}
+		}
+		if info, err := os.Lstat(filepath.Join(dst, "link.md")); err != nil || info.Mode()&os.ModeSymlink != 0 {
+			t.Errorf("link.md = %v, %v, want a followed regular file", info, err)
+		}
+		if len(progressed) != result.Files || result.Files != 3 {
+			t.Errorf("progress = %v, Files = %d, want 3 each", progressed, result.Files)
+		}
+	})
+
+	t.Run("preserve symlinks", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+		if _, err := CopyTree(src, dst, CopyOptions{Symlinks: SymlinkPreserve}); err != nil {
+			t.Fatalf("CopyTree() error = %v", err)
+		}
+		if target, err := os.Readlink(filepath.Join(dst, "link.md")); err != nil || target != "README.md" {
+			t.Errorf("link.md -> %q, %v, want README.md", target, err)
+		}
+	})
+
+	t.Run("skip symlinks", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+		result, err := CopyTree(src, dst, CopyOptions{Symlinks: SymlinkSkip})
+		if err != nil {
+			t.Fatalf("CopyTree() error = %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(dst, "link.md")); err == nil || len(result.Skipped) != 2 {
+			t.Errorf("Skipped = %v, want both links left out", result.Skipped)
+		}
+	})
+
+	t.Run("collisions", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+		writeTree(t, dst, map[string]string{"main.go": "mine"})
+		opts := CopyOptions{Include: []string{"main.go"}}
+
+		opts.Collision = CollisionError
+		if _, err := CopyTree(src, dst, opts); !errors.Is(err, ErrDestExists) {
+			t.Errorf("CollisionError: error = %v, want ErrDestExists", err)
+		}
+
+		opts.Collision = CollisionSkip
+		if result, err := CopyTree(src, dst, opts); err != nil || len(result.Skipped) != 1 {
+			t.Errorf("CollisionSkip: result = %+v, %v", result, err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dst, "main.go")); string(data) != "mine" {
+			t.Errorf("CollisionSkip overwrote main.go with %q", data)
+		}
+
+		opts.Collision = CollisionBackup
+		if _, err := CopyTree(src, dst, opts); err != nil {
+			t.Fatalf("CollisionBackup: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dst, "main.go.bak")); string(data) != "mine" {
+			t.Errorf("main.go.bak = %q, want the previous file", data)
+		}
+
+		opts.Collision = CollisionOverwrite
+		if _, err := CopyTree(src, dst, opts); err != nil {
+			t.Fatalf("CollisionOverwrite: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dst, "main.go")); string(data) != "package main" {
+			t.Errorf("main.go = %q, want the source file", data)
+		}
+	})
+}
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+func TestSharedPerms(t *testing.T) {
+	if DirPerm() != 0755 || FilePerm() != 0644 {
+		t.Errorf("perms = %o, %o; want 755, 644", DirPerm(), FilePerm())
+	}
+
+	SetShared(true)
+	defer SetShared(false)
+	if DirPerm() != 0777 || FilePerm() != 0666 {
+		t.Errorf("shared perms = %o, %o; want 777, 666 for the umask to narrow", DirPerm(), FilePerm())
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	if err := os.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst.txt")
+	if err := CopyFile(src, dst, 0644); err != nil {
+		t.Fatalf("CopyFile() in shared mode error = %v", err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "data" {
+		t.Errorf("copied data = %q", data)
+	}
+}
@@ -1,13 +1,35 @@
 package git
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io/fs"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	cofs "github.com/tormodhaugland/co/internal/fs"
 )
 
+// ErrNotGitRepo is returned, wrapped with the path, when a path that should
+// be in a git repository is not.
+var ErrNotGitRepo = errors.New("not a git repository")
+
+// repoError returns ErrNotGitRepo when a git command failed because
+// repoPath is not in a repository, and err otherwise.
+func repoError(repoPath string, err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), "not a git repository") {
+		return fmt.Errorf("%w: %s", ErrNotGitRepo, repoPath)
+	}
+	return err
+}
+
 type RepoInfo struct {
 	Path       string
 	Head       string
@@ -15,6 +37,29 @@ type RepoInfo struct {
 	Dirty      bool
 	Remote     string
 	LastCommit time.Time
+	Remotes    []Remote
+	Branches   []Branch
+}
+
+// Remote is a configured git remote.
+type Remote struct {
+	Name string
+	URL  string
+}
+
+// Branch is a local branch and how it compares to its upstream.
+type Branch struct {
+	Name     string
+	Current  bool   // Checked out in the working tree
+	Upstream string // Tracking branch, e.g. origin/main; empty if none
+	Gone     bool   // Upstream is configured but no longer exists
+	Ahead    int    // Commits not on the upstream, or on no remote if there is none
+	Behind   int    // Upstream commits not on the branch
+}
+
+// Pushed reports whether every commit on the branch exists on a remote.
+func (b Branch) Pushed() bool {
+	return b.Ahead == 0
 }
 
 func IsRepo(path string) bool {
@@ -53,14 +98,77 @@ func GetInfo(repoPath string) (*RepoInfo, error) {
 		info.LastCommit = lastCommit
 	}
 
+	if remotes, err := getRemotes(repoPath); err == nil {
+		info.Remotes = remotes
+	}
+	if branches, err := getBranches(repoPath); err == nil {
+		info.Branches = branches
+	}
+
 	return info, nil
 }
 
+func getRemotes(repoPath string) ([]Remote, error) {
+	cmd := exec.Command("git", "-C", repoPath, "remote", "-v")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var remotes []Remote
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "(fetch)" {
+			remotes = append(remotes, Remote{Name: fields[0], URL: fields[1]})
+		}
+	}
+	return remotes, nil
+}
+
+// getBranches lists local branches with ahead/behind counts. Branches
+// without an upstream count commits that are on no remote at all.
+func getBranches(repoPath string) ([]Branch, error) {
+	cmd := exec.Command("git", "-C", repoPath, "for-each-ref",
+		"--format=%(HEAD)%09%(refname:short)%09%(upstream:short)%09%(upstream:track)", "refs/heads")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var branches []Branch
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		b := Branch{Name: fields[1], Current: fields[0] == "*", Upstream: fields[2]}
+		track := strings.Trim(fields[3], "[]")
+		switch {
+		case track == "gone":
+			b.Gone = true
+		case b.Upstream != "":
+			for _, part := range strings.Split(track, ", ") {
+				if n, ok := strings.CutPrefix(part, "ahead "); ok {
+					b.Ahead, _ = strconv.Atoi(n)
+				} else if n, ok := strings.CutPrefix(part, "behind "); ok {
+					b.Behind, _ = strconv.Atoi(n)
+				}
+			}
+		}
+		if b.Upstream == "" || b.Gone {
+			cmd := exec.Command("git", "-C", repoPath, "rev-list", "--count", "refs/heads/"+b.Name, "--not", "--remotes")
+			if out, err := cmd.Output(); err == nil {
+				b.Ahead, _ = strconv.Atoi(strings.TrimSpace(string(out)))
+			}
+		}
+		branches = append(branches, b)
+	}
+	return branches, nil
+}
+
 func getHead(repoPath string) (string, error) {
 	cmd := exec.Command("git", "-C", repoPath, "rev-parse", "--short", "HEAD")
 	out, err := cmd.Output()
 	if err != nil {
-		return "", err
+		return "", repoError(repoPath, err)
 	}
 	return strings.TrimSpace(string(out)), nil
 }
@@ -83,6 +191,101 @@ func isDirty(repoPath string) bool {
 	return len(strings.TrimSpace(string(out))) > 0
 }
 
+// CurrentBranch returns the name of the branch checked out, or "HEAD" when
+// it is detached.
+func CurrentBranch(repoPath string) (string, error) {
+	return getBranch(repoPath)
+}
+
+// HeadCommit returns the full hash of the commit checked out.
+func HeadCommit(repoPath string) (string, error) {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", repoError(repoPath, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// WorkingFiles returns the files, relative to repoPath, that a clone of the
+// repository would not recreate: untracked files that are not ignored, and
+// tracked files changed since HEAD, staged or not. Nested repositories are
+// left out.
+func WorkingFiles(repoPath string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, args := range [][]string{
+		{"ls-files", "-z", "--others", "--exclude-standard"},
+		{"diff", "-z", "--name-only", "--no-renames", "HEAD"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).Output()
+		if err != nil {
+			return nil, repoError(repoPath, err)
+		}
+		for _, name := range strings.Split(string(out), "\x00") {
+			if name == "" || strings.HasSuffix(name, "/") || seen[name] {
+				continue
+			}
+			// Deleted files are listed by diff but have nothing to keep
+			if _, err := os.Lstat(filepath.Join(repoPath, name)); err != nil {
+				continue
+			}
+			seen[name] = true
+			files = append(files, filepath.FromSlash(name))
+		}
+	}
+	return files, nil
+}
+
+// RemoteURL returns the URL of the origin remote. Unlike GetInfo it works in
+// repositories without commits.
+func RemoteURL(repoPath string) (string, error) {
+	return getRemote(repoPath)
+}
+
+// ListBranches lists the local branches of a repository with how each
+// compares to its upstream.
+func ListBranches(repoPath string) ([]Branch, error) {
+	return getBranches(repoPath)
+}
+
+// ListRemotes lists the configured remotes of a repository.
+func ListRemotes(repoPath string) ([]Remote, error) {
+	return getRemotes(repoPath)
+}
+
+// DefaultBranch returns the default branch of a repository: the branch
+// origin/HEAD points at, else init.defaultBranch, main, or master if such a
+// branch exists, else the current branch.
+func DefaultBranch(repoPath string) string {
+	out, err := exec.Command("git", "-C", repoPath, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD").Output()
+	if err == nil {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(string(out)), "origin/"); ok {
+			return name
+		}
+	}
+	for _, name := range []string{GetConfig(repoPath, "init.defaultBranch"), "main", "master"} {
+		if name != "" && exec.Command("git", "-C", repoPath, "show-ref", "--verify", "--quiet", "refs/heads/"+name).Run() == nil {
+			return name
+		}
+	}
+	branch, _ := getBranch(repoPath)
+	return branch
+}
+
+// RecentSubjects returns the subjects of the last n commits of HEAD, newest
+// first, leaving out merge commits.
+func RecentSubjects(repoPath string, n int) ([]string, error) {
+	out, err := exec.Command("git", "-C", repoPath, "log", "--no-merges", fmt.Sprintf("-%d", n), "--format=%s").Output()
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimRight(string(out), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
 func getRemote(repoPath string) (string, error) {
 	cmd := exec.Command("git", "-C", repoPath, "remote", "get-url", "origin")
 	out, err := cmd.Output()
@@ -101,16 +304,362 @@ func getLastCommitTime(repoPath string) (time.Time, error) {
 	return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
 }
 
+// UnpushedCount returns the number of commits on local branches that are not on any remote.
+// A repository without commits reports zero.
+func UnpushedCount(repoPath string) (int, error) {
+	if _, err := getHead(repoPath); err != nil {
+		return 0, nil
+	}
+	cmd := exec.Command("git", "-C", repoPath, "rev-list", "--count", "--branches", "--not", "--remotes")
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// UnpushedBranches returns the local branches that have commits not on any remote.
+func UnpushedBranches(repoPath string) ([]string, error) {
+	if _, err := getHead(repoPath); err != nil {
+		return nil, nil
+	}
+	cmd := exec.Command("git", "-C", repoPath, "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for _, branch := range strings.Fields(string(out)) {
+		cmd := exec.Command("git", "-C", repoPath, "rev-list", "--count", "refs/heads/"+branch, "--not", "--remotes")
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := strconv.Atoi(strings.TrimSpace(string(out))); n > 0 {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// UnsavedWork describes work in a repository that exists nowhere else:
+// uncommitted changes and commits that are not on any remote.
+type UnsavedWork struct {
+	Path     string   `json:"path"`
+	Dirty    bool     `json:"dirty"`
+	Unpushed int      `json:"unpushed"`
+	Branches []string `json:"branches,omitempty"` // Local branches with unpushed commits
+}
+
+// Any reports whether the repository holds any unsaved work.
+func (u UnsavedWork) Any() bool {
+	return u.Dirty || u.Unpushed > 0
+}
+
+// String describes the unsaved work, e.g.
+// "uncommitted changes, 3 unpushed commits (main, feature)".
+func (u UnsavedWork) String() string {
+	var issues []string
+	if u.Dirty {
+		issues = append(issues, "uncommitted changes")
+	}
+	if u.Unpushed > 0 {
+		issue := fmt.Sprintf("%d unpushed commits", u.Unpushed)
+		if len(u.Branches) > 0 {
+			issue += " (" + strings.Join(u.Branches, ", ") + ")"
+		}
+		issues = append(issues, issue)
+	}
+	return strings.Join(issues, ", ")
+}
+
+// CheckUnsaved inspects a single repository for unsaved work.
+func CheckUnsaved(repoPath string) UnsavedWork {
+	work := UnsavedWork{Path: repoPath, Dirty: isDirty(repoPath)}
+	if n, err := UnpushedCount(repoPath); err == nil {
+		work.Unpushed = n
+	}
+	if work.Unpushed > 0 {
+		work.Branches, _ = UnpushedBranches(repoPath)
+	}
+	return work
+}
+
+// FindUnsavedWork checks every repository under path and returns those with
+// unsaved work, so callers can warn before deleting path.
+func FindUnsavedWork(path string) []UnsavedWork {
+	roots, err := FindGitRoots(path)
+	if err != nil {
+		return nil
+	}
+	sort.Strings(roots)
+	var unsaved []UnsavedWork
+	for _, root := range roots {
+		if work := CheckUnsaved(root); work.Any() {
+			unsaved = append(unsaved, work)
+		}
+	}
+	return unsaved
+}
+
 func CreateBundle(repoPath, bundlePath string) error {
-	cmd := exec.Command("git", "-C", repoPath, "bundle", "create", bundlePath, "--all")
-	return cmd.Run()
+	return CreateBundleContext(context.Background(), repoPath, bundlePath)
+}
+
+// CreateBundleContext bundles like CreateBundle, killing git when ctx is done.
+func CreateBundleContext(ctx context.Context, repoPath, bundlePath string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "bundle", "create", bundlePath, "--all")
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
 }
 
+// Clone clones url into destPath.
 func Clone(url, destPath string) error {
-	cmd := exec.Command("git", "clone", url, destPath)
+	return CloneContext(context.Background(), url, destPath)
+}
+
+// CloneContext clones like Clone, killing git when ctx is done.
+func CloneContext(ctx context.Context, url, destPath string) error {
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", url, destPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Fetch updates every remote of a repository, pruning deleted branches.
+func Fetch(repoPath string) error {
+	return FetchContext(context.Background(), repoPath)
+}
+
+// FetchContext fetches like Fetch, killing git when ctx is done.
+func FetchContext(ctx context.Context, repoPath string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "fetch", "--all", "--prune", "--quiet")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// SetRemote points the origin remote of a repository at url.
+func SetRemote(repoPath, url string) error {
+	cmd := exec.Command("git", "-C", repoPath, "remote", "set-url", "origin", url)
 	return cmd.Run()
 }
 
+// GetConfig returns the value of a config key of a repository, or "" when
+// it is not set.
+func GetConfig(repoPath, key string) string {
+	out, err := exec.Command("git", "-C", repoPath, "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// SetConfig sets a config key in the local config of a repository.
+func SetConfig(repoPath, key, value string) error {
+	return runGit(repoPath, "config", key, value)
+}
+
+// UnsetConfig removes a config key from the local config of a repository.
+// A key that is not set is not an error.
+func UnsetConfig(repoPath, key string) error {
+	if GetConfig(repoPath, key) == "" {
+		return nil
+	}
+	return runGit(repoPath, "config", "--unset-all", key)
+}
+
+// CommonDir returns the absolute path of the git directory shared by a
+// repository's worktrees.
+func CommonDir(repoPath string) (string, error) {
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "--git-common-dir").Output()
+	if err != nil {
+		return "", repoError(repoPath, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// Methods of SplitSubdir.
+const (
+	SplitSubtree    = "subtree"     // git subtree split, shipped with git
+	SplitFilterRepo = "filter-repo" // git filter-repo --subdirectory-filter, much faster on long histories
+)
+
+// DefaultSplitMethod returns SplitFilterRepo when git filter-repo is
+// installed, and SplitSubtree otherwise.
+func DefaultSplitMethod() string {
+	if exec.Command("git", "filter-repo", "--version").Run() == nil {
+		return SplitFilterRepo
+	}
+	return SplitSubtree
+}
+
+// SplitSubdir creates a repository at destPath, which must not exist, from
+// the history of the subdirectory prefix of the repository at repoPath, with
+// prefix as its root. The current branch of repoPath is split, and repoPath
+// is left as it was.
+func SplitSubdir(repoPath, prefix, destPath, method string) error {
+	prefix = filepath.ToSlash(filepath.Clean(prefix))
+	if prefix == "." || filepath.IsAbs(prefix) || prefix == ".." || strings.HasPrefix(prefix, "../") {
+		return fmt.Errorf("invalid subdirectory: %s", prefix)
+	}
+	if err := exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "HEAD:"+prefix).Run(); err != nil {
+		return fmt.Errorf("%s is not a directory in the current commit", prefix)
+	}
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("destination already exists: %s", destPath)
+	}
+
+	var err error
+	switch method {
+	case SplitSubtree:
+		err = splitSubtree(repoPath, prefix, destPath)
+	case SplitFilterRepo:
+		err = splitFilterRepo(repoPath, prefix, destPath)
+	default:
+		return fmt.Errorf("unknown split method: %s", method)
+	}
+	if err != nil {
+		os.RemoveAll(destPath)
+	}
+	return err
+}
+
+// splitSubtree splits prefix onto a temporary branch of repoPath and fetches
+// it into a new repository, under the name of the current branch.
+func splitSubtree(repoPath, prefix, destPath string) error {
+	branch := fmt.Sprintf("co-split-%d", time.Now().UnixNano())
+	if err := runGit("", "-C", repoPath, "subtree", "split", "-q", "--prefix="+prefix, "--branch", branch); err != nil {
+		return fmt.Errorf("git subtree split failed: %w", err)
+	}
+	defer exec.Command("git", "-C", repoPath, "branch", "-D", branch).Run()
+
+	head, err := getBranch(repoPath)
+	if err != nil || head == "HEAD" {
+		head = "main"
+	}
+	if err := runGit("", "init", "--quiet", destPath); err != nil {
+		return err
+	}
+	if err := runGit(destPath, "fetch", "--quiet", repoPath, branch); err != nil {
+		return err
+	}
+	return runGit(destPath, "checkout", "--quiet", "-B", head, "FETCH_HEAD")
+}
+
+// splitFilterRepo clones repoPath and rewrites the clone to hold only prefix.
+func splitFilterRepo(repoPath, prefix, destPath string) error {
+	if err := runGit("", "clone", "--quiet", "--no-local", repoPath, destPath); err != nil {
+		return err
+	}
+	if err := runGit(destPath, "filter-repo", "--quiet", "--force", "--subdirectory-filter", prefix); err != nil {
+		return fmt.Errorf("git filter-repo failed: %w", err)
+	}
+	return nil
+}
+
+// MergeSource is a repository to merge into a subdirectory of another.
+type MergeSource struct {
+	Path   string // Repository to merge
+	Prefix string // Subdirectory it goes to, slash-separated
+}
+
+// MergeRepos creates a repository at destPath, which must not exist, holding
+// each source under its prefix with its history: the current branch of each
+// is fetched and subtree-merged in. Either every source is merged or destPath
+// is removed.
+func MergeRepos(destPath string, sources []MergeSource) error {
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("destination already exists: %s", destPath)
+	}
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		prefix := filepath.ToSlash(filepath.Clean(src.Prefix))
+		if prefix == "." || prefix == ".." || strings.HasPrefix(prefix, "../") || filepath.IsAbs(prefix) || seen[prefix] {
+			return fmt.Errorf("invalid or duplicate subdirectory for %s: %s", src.Path, src.Prefix)
+		}
+		seen[prefix] = true
+	}
+
+	if err := mergeRepos(destPath, sources); err != nil {
+		os.RemoveAll(destPath)
+		return err
+	}
+	return nil
+}
+
+func mergeRepos(destPath string, sources []MergeSource) error {
+	if err := runGit("", "init", "--quiet", destPath); err != nil {
+		return err
+	}
+	// A merge needs a commit to merge into
+	if err := runGit(destPath, "commit", "--quiet", "--allow-empty", "-m", "Initial commit"); err != nil {
+		return err
+	}
+	for _, src := range sources {
+		prefix := filepath.ToSlash(filepath.Clean(src.Prefix))
+		if err := runGit(destPath, "fetch", "--quiet", "--no-tags", src.Path, "HEAD"); err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", src.Path, err)
+		}
+		if err := runGit(destPath, "merge", "--quiet", "-s", "ours", "--no-commit", "--allow-unrelated-histories", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", src.Path, err)
+		}
+		if err := runGit(destPath, "read-tree", "--prefix="+prefix+"/", "-u", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", src.Path, err)
+		}
+		msg := fmt.Sprintf("Merge %s into %s/", filepath.Base(src.Path), prefix)
+		if err := runGit(destPath, "commit", "--quiet", "-m", msg); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", src.Path, err)
+		}
+	}
+	return nil
+}
+
+// runGit runs git in dir (or the current directory when empty), returning
+// its output with the error.
+func runGit(dir string, args ...string) error {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// TrackedDirs lists the directories in the current commit of a repository
+// up to maxDepth levels deep, as slash-separated paths in tree order.
+func TrackedDirs(repoPath string, maxDepth int) ([]string, error) {
+	out, err := exec.Command("git", "-C", repoPath, "ls-tree", "-d", "-r", "-z", "--name-only", "HEAD").Output()
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(out), "\x00") {
+		if line != "" && strings.Count(line, "/") < maxDepth {
+			dirs = append(dirs, line)
+		}
+	}
+	return dirs, nil
+}
+
 // skipDirs contains directory names that should be skipped during git root scanning.
 // These are typically large generated/dependency directories that slow down scanning.
 var skipDirs = map[string]bool{
@@ -175,47 +724,138 @@ func FindGitRoots(basePath string) ([]string, error) {
 // A maxDepth of 0 only checks basePath itself, 1 checks immediate children, etc.
 // A maxDepth of -1 means no limit (scans entire tree).
 func FindGitRootsWithDepth(basePath string, maxDepth int) ([]string, error) {
-	var roots []string
-	seen := make(map[string]bool)
-	baseDepth := strings.Count(basePath, string(filepath.Separator))
+	result, err := ScanGitRoots(basePath, ScanOptions{MaxDepth: maxDepth})
+	return result.Roots, err
+}
 
-	err := filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
+// ScanOptions controls how ScanGitRoots walks a directory tree.
+type ScanOptions struct {
+	// MaxDepth limits how many levels below the base path are scanned;
+	// -1 means no limit.
+	MaxDepth int
 
-		if !d.IsDir() {
-			return nil
-		}
+	// FollowSymlinks descends into symlinked directories. Each directory is
+	// scanned at most once (tracked by device and inode), so symlink loops
+	// and links to the same folder are safe.
+	FollowSymlinks bool
+
+	// Exclude lists extra directory names or glob patterns (matched against
+	// the directory name) to skip, in addition to the built-in list.
+	Exclude []string
+}
+
+// ScanResult is the outcome of ScanGitRoots.
+type ScanResult struct {
+	Roots []string
+
+	// Truncated lists directories at the depth limit whose subdirectories
+	// were not scanned, so repositories below them may have been missed.
+	Truncated []string
+}
+
+// ScanGitRoots finds all git repositories under basePath according to opts.
+// Repositories are returned in lexical walk order.
+func ScanGitRoots(basePath string, opts ScanOptions) (ScanResult, error) {
+	s := &scanner{
+		opts:      opts,
+		seen:      make(map[string]bool),
+		visited:   make(map[cofs.FileKey]bool),
+		resolved:  make(map[string]bool),
+		truncated: make(map[string]bool),
+	}
+	s.walk(basePath, 0)
+
+	for dir := range s.truncated {
+		s.result.Truncated = append(s.result.Truncated, dir)
+	}
+	sort.Strings(s.result.Truncated)
+	return s.result, nil
+}
 
-		name := d.Name()
+type scanner struct {
+	opts      ScanOptions
+	result    ScanResult
+	seen      map[string]bool       // repo roots already recorded
+	visited   map[cofs.FileKey]bool // directories already walked
+	resolved  map[string]bool       // same, where inodes are unavailable
+	truncated map[string]bool
+}
+
+func (s *scanner) walk(dir string, depth int) {
+	if s.opts.FollowSymlinks && !s.visit(dir) {
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	// Most entries are files; only build paths for directories
+	for _, entry := range entries {
+		name := entry.Name()
+		isDir := entry.IsDir()
+		if !isDir && s.opts.FollowSymlinks && entry.Type()&fs.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+				isDir = true
+			}
+		}
+		if !isDir {
+			continue
+		}
 
 		// Check for .git first (before depth limit) since we want to find repos
 		// at the depth limit, and .git is one level deeper than the repo root
 		if name == ".git" {
-			repoRoot := filepath.Dir(path)
-			if !seen[repoRoot] {
-				seen[repoRoot] = true
-				roots = append(roots, repoRoot)
+			if !s.seen[dir] {
+				s.seen[dir] = true
+				s.result.Roots = append(s.result.Roots, dir)
 			}
-			return filepath.SkipDir
+			continue
 		}
 
 		// Skip known large/generated directories
-		if skipDirs[name] {
-			return filepath.SkipDir
+		if s.skip(name) {
+			continue
 		}
 
 		// Check depth limit (after .git check so we can find repos at maxDepth)
-		if maxDepth >= 0 {
-			currentDepth := strings.Count(path, string(filepath.Separator)) - baseDepth
-			if currentDepth > maxDepth {
-				return filepath.SkipDir
-			}
+		if s.opts.MaxDepth >= 0 && depth+1 > s.opts.MaxDepth {
+			s.truncated[dir] = true
+			continue
 		}
 
-		return nil
-	})
+		s.walk(filepath.Join(dir, name), depth+1)
+	}
+}
+
+// visit records dir as walked and reports whether it was new.
+func (s *scanner) visit(dir string) bool {
+	if info, err := os.Stat(dir); err == nil {
+		if key, ok := cofs.FileKeyOf(info); ok {
+			if s.visited[key] {
+				return false
+			}
+			s.visited[key] = true
+			return true
+		}
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil || s.resolved[resolved] {
+		return false
+	}
+	s.resolved[resolved] = true
+	return true
+}
 
-	return roots, err
+func (s *scanner) skip(name string) bool {
+	if skipDirs[name] {
+		return true
+	}
+	for _, pattern := range s.opts.Exclude {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
 }
@@ -1,9 +1,14 @@
 package git
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/tormodhaugland/co/internal/git/gittest"
 )
 
 func TestFindGitRootsWithDepth(t *testing.T) {
@@ -91,6 +96,62 @@ func TestFindGitRootsSkipsDirs(t *testing.T) {
 	}
 }
 
+func TestScanGitRootsOptions(t *testing.T) {
+	tmp := t.TempDir()
+	for _, dir := range []string{
+		"shallow/.git",
+		"a/b/deep/.git",
+		"scratch-old/repo/.git",
+	} {
+		if err := os.MkdirAll(filepath.Join(tmp, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outside, "linked", ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(tmp, "link")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	// A second link to the same folder is walked only once
+	if err := os.Symlink(outside, filepath.Join(tmp, "zlink")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	// A loop back to the base must not be walked forever
+	if err := os.Symlink(tmp, filepath.Join(tmp, "a", "loop")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	result, err := ScanGitRoots(tmp, ScanOptions{MaxDepth: 2, Exclude: []string{"scratch-*"}})
+	if err != nil {
+		t.Fatalf("ScanGitRoots: %v", err)
+	}
+	if len(result.Roots) != 1 || result.Roots[0] != filepath.Join(tmp, "shallow") {
+		t.Errorf("Roots = %v, want only shallow", result.Roots)
+	}
+	if len(result.Truncated) != 1 || result.Truncated[0] != filepath.Join(tmp, "a", "b") {
+		t.Errorf("Truncated = %v, want a/b", result.Truncated)
+	}
+
+	result, err = ScanGitRoots(tmp, ScanOptions{MaxDepth: -1, FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("ScanGitRoots: %v", err)
+	}
+	want := []string{
+		filepath.Join(tmp, "a", "b", "deep"),
+		filepath.Join(tmp, "link", "linked"),
+		filepath.Join(tmp, "scratch-old", "repo"),
+		filepath.Join(tmp, "shallow"),
+	}
+	if strings.Join(result.Roots, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Roots = %v, want %v", result.Roots, want)
+	}
+	if len(result.Truncated) != 0 {
+		t.Errorf("Truncated = %v, want none without a depth limit", result.Truncated)
+	}
+}
+
 func TestSkipDirsCompleteness(t *testing.T) {
 	// Ensure common problematic directories are in the skip list
 	mustSkip := []string{
@@ -113,3 +174,361 @@ func TestSkipDirsCompleteness(t *testing.T) {
 		}
 	}
 }
+
+func TestUnpushedCount(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmp := t.TempDir()
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	repo := filepath.Join(tmp, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	run(repo, "init", "-q")
+
+	// No commits yet
+	if n, err := UnpushedCount(repo); err != nil || n != 0 {
+		t.Fatalf("empty repo: UnpushedCount = %d, %v; want 0, nil", n, err)
+	}
+
+	run(repo, "commit", "-q", "--allow-empty", "-m", "one")
+	run(repo, "commit", "-q", "--allow-empty", "-m", "two")
+	if n, err := UnpushedCount(repo); err != nil || n != 2 {
+		t.Fatalf("no remote: UnpushedCount = %d, %v; want 2, nil", n, err)
+	}
+
+	// Push to a bare remote, then add one more commit
+	remote := filepath.Join(tmp, "remote.git")
+	run(tmp, "init", "-q", "--bare", remote)
+	run(repo, "remote", "add", "origin", remote)
+	run(repo, "push", "-q", "origin", "HEAD:refs/heads/main")
+	run(repo, "fetch", "-q", "origin")
+	if n, err := UnpushedCount(repo); err != nil || n != 0 {
+		t.Fatalf("after push: UnpushedCount = %d, %v; want 0, nil", n, err)
+	}
+
+	run(repo, "commit", "-q", "--allow-empty", "-m", "three")
+	if n, err := UnpushedCount(repo); err != nil || n != 1 {
+		t.Fatalf("after new commit: UnpushedCount = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestFindUnsavedWork(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmp := t.TempDir()
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	// clean is pushed, dirty has an untracked file, ahead has an unpushed branch
+	remote := filepath.Join(tmp, "remote.git")
+	run(tmp, "init", "-q", "--bare", remote)
+	for _, name := range []string{"clean", "dirty", "ahead"} {
+		repo := filepath.Join(tmp, "src", name)
+		if err := os.MkdirAll(repo, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		run(repo, "init", "-q")
+		run(repo, "commit", "-q", "--allow-empty", "-m", "one")
+		run(repo, "remote", "add", "origin", remote)
+		run(repo, "push", "-q", "origin", "HEAD:refs/heads/"+name)
+		run(repo, "fetch", "-q", "origin")
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "src", "dirty", "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	ahead := filepath.Join(tmp, "src", "ahead")
+	run(ahead, "checkout", "-q", "-b", "feature")
+	run(ahead, "commit", "-q", "--allow-empty", "-m", "two")
+
+	unsaved := FindUnsavedWork(filepath.Join(tmp, "src"))
+	if len(unsaved) != 2 {
+		t.Fatalf("FindUnsavedWork() = %+v, want 2 repos", unsaved)
+	}
+	if unsaved[0].Path != ahead || unsaved[0].Unpushed != 1 || len(unsaved[0].Branches) != 1 || unsaved[0].Branches[0] != "feature" {
+		t.Errorf("ahead = %+v, want 1 unpushed commit on feature", unsaved[0])
+	}
+	if got := unsaved[0].String(); got != "1 unpushed commits (feature)" {
+		t.Errorf("String() = %q", got)
+	}
+	if !unsaved[1].Dirty || unsaved[1].Unpushed != 0 {
+		t.Errorf("dirty = %+v, want uncommitted changes only", unsaved[1])
+	}
+}
+
+func TestGetInfoRemotesAndBranches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmp := t.TempDir()
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	remote := filepath.Join(tmp, "remote.git")
+	repo := filepath.Join(tmp, "repo")
+	run(tmp, "init", "-q", "--bare", remote)
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	run(repo, "init", "-q", "-b", "main")
+	run(repo, "commit", "-q", "--allow-empty", "-m", "one")
+	run(repo, "remote", "add", "origin", remote)
+	run(repo, "remote", "add", "backup", remote)
+	run(repo, "push", "-q", "-u", "origin", "main")
+	run(repo, "commit", "-q", "--allow-empty", "-m", "two")
+	run(repo, "checkout", "-q", "-b", "local")
+	run(repo, "commit", "-q", "--allow-empty", "-m", "three")
+
+	info, err := GetInfo(repo)
+	if err != nil {
+		t.Fatalf("GetInfo() error = %v", err)
+	}
+	if len(info.Remotes) != 2 || info.Remotes[0].Name != "backup" || info.Remotes[1].URL != remote {
+		t.Errorf("Remotes = %+v", info.Remotes)
+	}
+
+	branches := map[string]Branch{}
+	for _, b := range info.Branches {
+		branches[b.Name] = b
+	}
+	if b := branches["main"]; b.Upstream != "origin/main" || b.Ahead != 1 || b.Behind != 0 || b.Current || b.Pushed() {
+		t.Errorf("main = %+v, want 1 ahead of origin/main", b)
+	}
+	if b := branches["local"]; b.Upstream != "" || b.Ahead != 2 || !b.Current {
+		t.Errorf("local = %+v, want current with 2 commits on no remote", b)
+	}
+}
+
+func TestGetInfoNotGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("LC_ALL", "C")
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+
+	dir := t.TempDir()
+	if _, err := GetInfo(dir); !errors.Is(err, ErrNotGitRepo) {
+		t.Errorf("GetInfo() of a plain folder error = %v, want ErrNotGitRepo", err)
+	}
+	if _, err := CommonDir(dir); !errors.Is(err, ErrNotGitRepo) {
+		t.Errorf("CommonDir() of a plain folder error = %v, want ErrNotGitRepo", err)
+	}
+}
+
+func TestSplitSubdir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if out, _ := exec.Command("git", "subtree").CombinedOutput(); !strings.Contains(string(out), "usage") {
+		t.Skip("git subtree not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	tmp := t.TempDir()
+	repo := filepath.Join(tmp, "mono")
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(repo, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	run(repo, "init", "-q")
+	write("services/api/main.go", "package main\n")
+	write("web/index.html", "<html></html>\n")
+	run(repo, "add", ".")
+	run(repo, "commit", "-q", "-m", "initial")
+	write("services/api/README.md", "api\n")
+	run(repo, "add", ".")
+	run(repo, "commit", "-q", "-m", "api readme")
+
+	dirs, err := TrackedDirs(repo, 1)
+	if err != nil {
+		t.Fatalf("TrackedDirs: %v", err)
+	}
+	if strings.Join(dirs, ",") != "services,web" {
+		t.Errorf("TrackedDirs(1) = %v, want [services web]", dirs)
+	}
+	if dirs, _ := TrackedDirs(repo, 2); len(dirs) != 3 {
+		t.Errorf("TrackedDirs(2) = %v, want services, services/api, and web", dirs)
+	}
+
+	dest := filepath.Join(tmp, "api")
+	if err := SplitSubdir(repo, "services/api", dest, SplitSubtree); err != nil {
+		t.Fatalf("SplitSubdir: %v", err)
+	}
+	for _, name := range []string{"main.go", "README.md"} {
+		if _, err := os.Stat(filepath.Join(dest, name)); err != nil {
+			t.Errorf("%s missing from split repo: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "web")); !os.IsNotExist(err) {
+		t.Errorf("split repo contains web/")
+	}
+	if n := run(dest, "rev-list", "--count", "HEAD"); n != "2" {
+		t.Errorf("split repo has %s commits, want 2", n)
+	}
+	if branches := run(repo, "branch", "--list", "co-split-*"); branches != "" {
+		t.Errorf("temporary branch left in source: %s", branches)
+	}
+
+	if err := SplitSubdir(repo, "missing", filepath.Join(tmp, "missing"), SplitSubtree); err == nil {
+		t.Error("SplitSubdir of a missing directory succeeded")
+	}
+	if err := SplitSubdir(repo, "web", dest, SplitSubtree); err == nil {
+		t.Error("SplitSubdir into an existing directory succeeded")
+	}
+	if err := SplitSubdir(repo, "../x", filepath.Join(tmp, "x"), SplitSubtree); err == nil {
+		t.Error("SplitSubdir of a path outside the repo succeeded")
+	}
+}
+
+func TestMergeRepos(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	tmp := t.TempDir()
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	newRepo := func(name string, commits ...string) string {
+		t.Helper()
+		repo := filepath.Join(tmp, name)
+		if err := os.MkdirAll(repo, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		run(repo, "init", "-q")
+		for _, c := range commits {
+			if err := os.WriteFile(filepath.Join(repo, c+".txt"), []byte(c), 0o644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			run(repo, "add", ".")
+			run(repo, "commit", "-q", "-m", c)
+		}
+		return repo
+	}
+	one := newRepo("one", "a", "b")
+	two := newRepo("two", "c")
+	empty := newRepo("empty")
+
+	dest := filepath.Join(tmp, "merged")
+	if err := MergeRepos(dest, []MergeSource{{Path: one, Prefix: "one"}, {Path: two, Prefix: "exp/two"}}); err != nil {
+		t.Fatalf("MergeRepos: %v", err)
+	}
+	for _, name := range []string{"one/a.txt", "one/b.txt", "exp/two/c.txt"} {
+		if _, err := os.Stat(filepath.Join(dest, name)); err != nil {
+			t.Errorf("%s missing from merged repo: %v", name, err)
+		}
+	}
+	if status := run(dest, "status", "--porcelain"); status != "" {
+		t.Errorf("merged repo not clean:\n%s", status)
+	}
+	// Initial commit, two merges, and the three source commits
+	if n := run(dest, "rev-list", "--count", "HEAD"); n != "6" {
+		t.Errorf("merged repo has %s commits, want 6", n)
+	}
+	if log := run(dest, "log", "--format=%s", "--", "one/b.txt"); !strings.Contains(log, "Merge one into one/") {
+		t.Errorf("history of one/b.txt = %q, want the merge", log)
+	}
+
+	failed := filepath.Join(tmp, "failed")
+	if err := MergeRepos(failed, []MergeSource{{Path: one, Prefix: "one"}, {Path: empty, Prefix: "empty"}}); err == nil {
+		t.Error("MergeRepos with a repo without commits succeeded")
+	}
+	if _, err := os.Stat(failed); !os.IsNotExist(err) {
+		t.Error("failed merge left its destination behind")
+	}
+	if err := MergeRepos(failed, []MergeSource{{Path: one, Prefix: "x"}, {Path: two, Prefix: "x/"}}); err == nil {
+		t.Error("MergeRepos with duplicate subdirectories succeeded")
+	}
+}
+
+// BenchmarkScanGitRoots walks a synthetic tree of 100k entries and 1000
+// repos, made once for all runs. The scan is budgeted at 100ms for this tree;
+// the allocation budget is enforced by TestScanGitRootsBudget.
+func BenchmarkScanGitRoots(b *testing.B) {
+	root := b.TempDir()
+	gittest.SyntheticTree(b, root, 1000)
+
+	b.Run("100k", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if roots, _ := FindGitRoots(root); len(roots) != 1000 {
+				b.Fatalf("FindGitRoots() found %d repos, want 1000", len(roots))
+			}
+		}
+	})
+}
+
+// TestScanGitRootsBudget holds the scan to an allocation budget, which unlike
+// wall time is the same on every machine. The walk reads each directory once
+// and only builds paths for subdirectories, so files cost no allocations
+// beyond the two os.ReadDir makes for each entry.
+func TestScanGitRootsBudget(t *testing.T) {
+	root := t.TempDir()
+	gittest.SyntheticTree(t, root, 20)
+	const entries = 20 * 100
+
+	allocs := testing.AllocsPerRun(5, func() { _, _ = FindGitRoots(root) })
+	if perEntry := allocs / entries; perEntry > 2.5 {
+		t.Errorf("FindGitRoots() made %.0f allocations for %d entries (%.2f each), budget is 2.5 each", allocs, entries, perEntry)
+	}
+}
@@ -0,0 +1,38 @@
+// Package gittest provides fixtures for tests and benchmarks of code that
+// scans folders for git repos.
+package gittest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// SyntheticTree lays out repos git repos of about 100 entries each under
+// root: half directly under root, half in group directories of 50. The repos
+// are named repo-NNNN and the groups group-NN.
+func SyntheticTree(tb testing.TB, root string, repos int) {
+	tb.Helper()
+	for i := 0; i < repos; i++ {
+		repo := filepath.Join(root, fmt.Sprintf("repo-%04d", i))
+		if i%2 == 1 {
+			repo = filepath.Join(root, fmt.Sprintf("group-%02d", i/100), fmt.Sprintf("repo-%04d", i))
+		}
+		if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(repo, "src"), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		files := []string{".git/HEAD", "README.md"}
+		for j := 0; j < 96; j++ {
+			files = append(files, fmt.Sprintf("src/file-%02d.go", j))
+		}
+		for _, f := range files {
+			if err := os.WriteFile(filepath.Join(repo, f), nil, 0o644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package index
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// column is a field of an index record that co ls can output. text is the
+// human-readable form for tables; value is the machine-readable form for CSV
+// and JSON.
+type column struct {
+	text  func(r *model.IndexRecord) string
+	value func(r *model.IndexRecord) any
+}
+
+var columns = map[string]column{
+	"slug":  {func(r *model.IndexRecord) string { return r.Slug }, func(r *model.IndexRecord) any { return r.Slug }},
+	"owner": {func(r *model.IndexRecord) string { return r.Owner }, func(r *model.IndexRecord) any { return r.Owner }},
+	"state": {func(r *model.IndexRecord) string { return string(r.State) }, func(r *model.IndexRecord) any { return r.State }},
+	"path":  {func(r *model.IndexRecord) string { return r.Path }, func(r *model.IndexRecord) any { return r.Path }},
+	"repos": {
+		func(r *model.IndexRecord) string { return strconv.Itoa(r.RepoCount) },
+		func(r *model.IndexRecord) any { return r.RepoCount },
+	},
+	"dirty": {
+		func(r *model.IndexRecord) string { return strconv.Itoa(r.DirtyRepos) },
+		func(r *model.IndexRecord) any { return r.DirtyRepos },
+	},
+	"size": {
+		func(r *model.IndexRecord) string { return fs.FormatBytes(uint64(r.SizeBytes)) },
+		func(r *model.IndexRecord) any { return r.SizeBytes },
+	},
+	"last_active": {
+		func(r *model.IndexRecord) string { return formatActivity(LastActivity(r)) },
+		func(r *model.IndexRecord) any { return timeValue(LastActivity(r)) },
+	},
+	"last_commit": {
+		func(r *model.IndexRecord) string { return formatActivity(derefTime(r.LastCommitAt)) },
+		func(r *model.IndexRecord) any { return timeValue(derefTime(r.LastCommitAt)) },
+	},
+	"description": {
+		func(r *model.IndexRecord) string { return DescriptionLine(r.Description, 50) },
+		func(r *model.IndexRecord) any { return r.Description },
+	},
+	"tags": {
+		func(r *model.IndexRecord) string { return strings.Join(r.Tags, ",") },
+		func(r *model.IndexRecord) any {
+			if r.Tags == nil {
+				return []string{}
+			}
+			return r.Tags
+		},
+	},
+}
+
+// ColumnNames lists the columns accepted by ParseColumns, in display order.
+var ColumnNames = []string{"slug", "owner", "state", "path", "repos", "dirty", "size", "last_active", "last_commit", "tags", "description"}
+
+// DefaultColumns are the columns co ls shows when none are requested.
+var DefaultColumns = []string{"slug", "owner", "state", "repos", "dirty", "description"}
+
+// DescriptionLine returns the first line of a workspace description,
+// shortened to max runes with an ellipsis, for tables and lists.
+func DescriptionLine(description string, max int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	line = strings.TrimSpace(line)
+	if r := []rune(line); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return line
+}
+
+// ParseColumns parses a comma-separated column list such as
+// "slug,owner,repos,size". Empty returns DefaultColumns.
+func ParseColumns(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return DefaultColumns, nil
+	}
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(ColumnNames, ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ColumnText returns the human-readable value of a column, for tables.
+func ColumnText(r *model.IndexRecord, name string) string {
+	return columns[name].text(r)
+}
+
+// ColumnValues returns the selected columns of r keyed by column name, with
+// numbers, times, and tags kept as JSON values.
+func ColumnValues(r *model.IndexRecord, names []string) map[string]any {
+	values := make(map[string]any, len(names))
+	for _, name := range names {
+		values[name] = columns[name].value(r)
+	}
+	return values
+}
+
+// WriteCSV writes records as CSV with a header row. Sizes are in bytes,
+// times in RFC 3339, and tags separated by semicolons.
+func WriteCSV(w io.Writer, records []*model.IndexRecord, names []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(names); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := make([]string, len(names))
+		for i, name := range names {
+			switch v := columns[name].value(r).(type) {
+			case []string:
+				row[i] = strings.Join(v, ";")
+			case nil:
+				row[i] = ""
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// timeValue returns t in RFC 3339, or nil when it is unknown.
+func timeValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,60 @@
+package index
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	cols, err := ParseColumns("")
+	if err != nil || strings.Join(cols, ",") != strings.Join(DefaultColumns, ",") {
+		t.Errorf("ParseColumns(\"\") = %v, %v, want defaults", cols, err)
+	}
+	cols, err = ParseColumns("slug, Size ,last_active")
+	if err != nil || strings.Join(cols, ",") != "slug,size,last_active" {
+		t.Errorf("ParseColumns() = %v, %v", cols, err)
+	}
+	if _, err := ParseColumns("slug,bogus"); err == nil {
+		t.Error("ParseColumns() with an unknown column should fail")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	records := pageRecords("/code")
+	records[1].Tags = []string{"client", "web, frontend"}
+
+	var sb strings.Builder
+	if err := WriteCSV(&sb, records, []string{"slug", "repos", "size", "tags", "last_active"}); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	want := "slug,repos,size,tags,last_active\n" +
+		"solo--tool,1,2048,,2024-06-15T12:00:00Z\n" +
+		"acme--web,1,50,\"client;web, frontend\",2024-06-15T12:00:00Z\n" +
+		"acme--api,2,100,,2025-06-15T12:00:00Z\n"
+	if sb.String() != want {
+		t.Errorf("WriteCSV() =\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	values := ColumnValues(records[0], []string{"slug", "size", "last_commit", "tags"})
+	if values["size"] != int64(2048) || values["last_commit"] != "2024-06-15T12:00:00Z" || len(values["tags"].([]string)) != 0 {
+		t.Errorf("ColumnValues() = %v", values)
+	}
+	if ColumnText(records[0], "size") != "2.0 KB" {
+		t.Errorf("ColumnText(size) = %q", ColumnText(records[0], "size"))
+	}
+}
+
+func TestDescriptionColumn(t *testing.T) {
+	records := pageRecords("/code")
+	records[0].Description = "Billing API for the EU shop, split from the monolith in 2024\nOwned by payments"
+
+	if got := ColumnText(records[0], "description"); got != "Billing API for the EU shop, split from the monol…" {
+		t.Errorf("ColumnText(description) = %q", got)
+	}
+	if got := ColumnValues(records[0], []string{"description"})["description"]; got != records[0].Description {
+		t.Errorf("ColumnValues(description) = %q, want the whole description", got)
+	}
+	if got := DescriptionLine("  short\n", 50); got != "short" {
+		t.Errorf("DescriptionLine() = %q", got)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/git"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 type Builder struct {
@@ -35,7 +36,7 @@ func (b *Builder) SetProgress(fn func(done, total int)) {
 }
 
 func (b *Builder) Build() (*model.Index, error) {
-	workspaces, err := fs.ListWorkspaces(b.cfg.CodeRoot)
+	workspaces, err := workspace.ListWorkspaces(b.cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -95,27 +96,37 @@ func (b *Builder) buildRecord(slug string) *model.IndexRecord {
 	}
 
 	record.Owner = proj.Owner
+	record.CreatedBy = proj.CreatedBy
 	record.State = proj.State
 	record.Tags = proj.Tags
+	record.Description = proj.Description
+	record.Icon = proj.Icon
+	record.DisplayName = proj.DisplayName
 
-	repos, err := fs.ListRepos(workspacePath)
+	repos, err := proj.LocateRepos(workspacePath)
 	if err == nil {
 		record.RepoCount = len(repos)
 
 		var latestCommit time.Time
 		var dirtyCount int
 		repoSpecs := make([]model.RepoSpec, 0, len(repos))
+		dependsOn := make(map[string][]string) // Declared by hand; kept when repos are synced
+		for _, r := range proj.Repos {
+			dependsOn[r.Name] = r.DependsOn
+		}
 
-		for _, repoName := range repos {
-			repoPath := filepath.Join(workspacePath, "repos", repoName)
+		for _, located := range repos {
+			repoName := located.Name
+			repoPath := filepath.Join(workspacePath, filepath.FromSlash(located.Path))
 
 			var repoInfo model.IndexRepoInfo
 			repoInfo.Name = repoName
-			repoInfo.Path = "repos/" + repoName
+			repoInfo.Path = located.Path
 
 			repoSpec := model.RepoSpec{
-				Name: repoName,
-				Path: "repos/" + repoName,
+				Name:      repoName,
+				Path:      located.Path,
+				DependsOn: dependsOn[repoName],
 			}
 
 			if git.IsRepo(repoPath) {
@@ -154,6 +165,12 @@ func (b *Builder) buildRecord(slug string) *model.IndexRecord {
 		}
 	}
 
+	if violations, err := workspace.CheckRepoPolicy(b.cfg, workspacePath, proj); err == nil {
+		for _, v := range violations {
+			record.Violations = append(record.Violations, v.String())
+		}
+	}
+
 	size, err := fs.CalculateSize(workspacePath)
 	if err == nil {
 		record.SizeBytes = size
@@ -21,7 +21,7 @@ func TestBuilderSyncProjectRepos(t *testing.T) {
 		t.Fatalf("mkdir repo: %v", err)
 	}
 
-	proj := model.NewProject("acme", "app")
+	proj := model.NewProject("acme--app", "acme", "app")
 	proj.Repos = []model.RepoSpec{}
 	if err := proj.Save(workspacePath); err != nil {
 		t.Fatalf("save project.json: %v", err)
@@ -61,7 +61,7 @@ func TestBuilderNoProjectSync(t *testing.T) {
 		t.Fatalf("mkdir repo: %v", err)
 	}
 
-	proj := model.NewProject("acme", "app")
+	proj := model.NewProject("acme--app", "acme", "app")
 	proj.Repos = []model.RepoSpec{}
 	if err := proj.Save(workspacePath); err != nil {
 		t.Fatalf("save project.json: %v", err)
@@ -94,7 +94,7 @@ func TestBuilderProgress(t *testing.T) {
 			t.Fatalf("mkdir workspace: %v", err)
 		}
 		parts := strings.Split(slug, "--")
-		proj := model.NewProject(parts[0], parts[1])
+		proj := model.NewProject(slug, parts[0], parts[1])
 		if err := proj.Save(workspacePath); err != nil {
 			t.Fatalf("save project.json: %v", err)
 		}
@@ -0,0 +1,253 @@
+package index
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// PageFormat selects the output of the code root index page.
+type PageFormat string
+
+const (
+	PageMarkdown PageFormat = "markdown" // A single INDEX.md
+	PageHTML     PageFormat = "html"     // A single standalone index.html
+	PageSite     PageFormat = "site"     // index.html plus a page per workspace
+)
+
+// ParsePageFormat parses a page format name; "md" is accepted for markdown.
+func ParsePageFormat(s string) (PageFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "md", "markdown":
+		return PageMarkdown, nil
+	case "html":
+		return PageHTML, nil
+	case "site":
+		return PageSite, nil
+	}
+	return "", fmt.Errorf("invalid page format %q (must be markdown, html, or site)", s)
+}
+
+// OwnerGroup is one owner's section of the index page, co ls, and the
+// workspace browser.
+type OwnerGroup struct {
+	Owner      string
+	SizeBytes  int64
+	Repos      int
+	DirtyRepos int
+	Workspaces []*model.IndexRecord // Most recently active first
+}
+
+// Summary returns the group's count and totals, such as
+// "3 workspaces • 7 repos • 2 dirty • 1.2 MB".
+func (g OwnerGroup) Summary() string {
+	parts := []string{countNoun(len(g.Workspaces), "workspace"), countNoun(g.Repos, "repo")}
+	if g.DirtyRepos > 0 {
+		parts = append(parts, fmt.Sprintf("%d dirty", g.DirtyRepos))
+	}
+	parts = append(parts, fs.FormatBytes(uint64(g.SizeBytes)))
+	return strings.Join(parts, " • ")
+}
+
+func countNoun(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// GroupByOwner groups records by owner, sorted by owner name.
+func GroupByOwner(records []*model.IndexRecord) []OwnerGroup {
+	byOwner := make(map[string]*OwnerGroup)
+	for _, r := range records {
+		owner := r.Owner
+		if owner == "" {
+			owner = "(unknown)"
+		}
+		g, ok := byOwner[owner]
+		if !ok {
+			g = &OwnerGroup{Owner: owner}
+			byOwner[owner] = g
+		}
+		g.SizeBytes += r.SizeBytes
+		g.Repos += r.RepoCount
+		g.DirtyRepos += r.DirtyRepos
+		g.Workspaces = append(g.Workspaces, r)
+	}
+
+	groups := make([]OwnerGroup, 0, len(byOwner))
+	for _, g := range byOwner {
+		sort.SliceStable(g.Workspaces, func(i, j int) bool {
+			a, b := LastActivity(g.Workspaces[i]), LastActivity(g.Workspaces[j])
+			if !a.Equal(b) {
+				return a.After(b)
+			}
+			return g.Workspaces[i].Slug < g.Workspaces[j].Slug
+		})
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Owner < groups[j].Owner })
+	return groups
+}
+
+// LastActivity returns the later of a record's last commit and last
+// filesystem change, or the zero time when neither is known.
+func LastActivity(r *model.IndexRecord) time.Time {
+	var t time.Time
+	if r.LastCommitAt != nil {
+		t = *r.LastCommitAt
+	}
+	if r.LastFSChangeAt != nil && r.LastFSChangeAt.After(t) {
+		t = *r.LastFSChangeAt
+	}
+	return t
+}
+
+// RenderMarkdown renders the code root index page as markdown. Workspace
+// links are relative to codeRoot, where the page is meant to be written.
+func RenderMarkdown(records []*model.IndexRecord, codeRoot string) string {
+	groups := GroupByOwner(records)
+
+	var sb strings.Builder
+	sb.WriteString("# Workspaces\n\n")
+	sb.WriteString(fmt.Sprintf("%d workspaces from %d owners. Generated by `co index --root`.\n", len(records), len(groups)))
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprintf("\n## %s (%d, %s)\n\n", g.Owner, len(g.Workspaces), fs.FormatBytes(uint64(g.SizeBytes))))
+		sb.WriteString("| Workspace | State | Repos | Size | Last activity |\n")
+		sb.WriteString("|-----------|-------|-------|------|---------------|\n")
+		for _, r := range g.Workspaces {
+			sb.WriteString(fmt.Sprintf("| [%s](%s) | %s | %s | %s | %s |\n",
+				r.Slug, workspaceLink(r, codeRoot), r.State, repoSummary(r),
+				fs.FormatBytes(uint64(r.SizeBytes)), formatActivity(LastActivity(r))))
+		}
+	}
+	return sb.String()
+}
+
+// RenderHTML renders the code root index page as a standalone HTML page.
+// Workspace links are relative to codeRoot, like RenderMarkdown.
+func RenderHTML(records []*model.IndexRecord, codeRoot string) (string, error) {
+	return renderIndexHTML(records, func(r *model.IndexRecord) string {
+		return workspaceLink(r, codeRoot)
+	})
+}
+
+// WriteSite writes a small static site to dir: index.html with all
+// workspaces grouped by owner, and a page per workspace listing its repos.
+func WriteSite(dir string, records []*model.IndexRecord) error {
+	if err := os.MkdirAll(dir, fs.DirPerm()); err != nil {
+		return err
+	}
+	page, err := renderIndexHTML(records, sitePageName)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(page), fs.FilePerm()); err != nil {
+		return err
+	}
+	for _, r := range records {
+		var sb strings.Builder
+		if err := workspacePageTmpl.Execute(&sb, r); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, sitePageName(r)), []byte(sb.String()), fs.FilePerm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderIndexHTML(records []*model.IndexRecord, linkFor func(*model.IndexRecord) string) (string, error) {
+	data := struct {
+		Count  int
+		Groups []OwnerGroup
+		Link   func(*model.IndexRecord) string
+	}{len(records), GroupByOwner(records), linkFor}
+
+	var sb strings.Builder
+	if err := indexPageTmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// workspaceLink returns the workspace's path relative to codeRoot, as a
+// slash-separated directory link.
+func workspaceLink(r *model.IndexRecord, codeRoot string) string {
+	rel, err := filepath.Rel(codeRoot, r.Path)
+	if err != nil {
+		rel = r.Path
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+func sitePageName(r *model.IndexRecord) string {
+	return strings.ReplaceAll(r.Slug, "/", "_") + ".html"
+}
+
+func repoSummary(r *model.IndexRecord) string {
+	if r.DirtyRepos > 0 {
+		return fmt.Sprintf("%d (%d dirty)", r.RepoCount, r.DirtyRepos)
+	}
+	return fmt.Sprintf("%d", r.RepoCount)
+}
+
+func formatActivity(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02")
+}
+
+var pageFuncs = template.FuncMap{
+	"size":     func(n int64) string { return fs.FormatBytes(uint64(n)) },
+	"repos":    repoSummary,
+	"activity": func(r *model.IndexRecord) string { return formatActivity(LastActivity(r)) },
+	"fileURL":  func(path string) template.URL { return template.URL("file://" + filepath.ToSlash(path)) },
+}
+
+const pageStyle = `<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+th, td { text-align: left; padding: .3rem .6rem; border-bottom: 1px solid #ddd; }
+th { background: #f5f5f5; }
+.muted { color: #777; }
+</style>`
+
+var indexPageTmpl = template.Must(template.New("index").Funcs(pageFuncs).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Workspaces</title>` + pageStyle + `</head>
+<body>
+<h1>Workspaces</h1>
+<p class="muted">{{.Count}} workspaces from {{len .Groups}} owners. Generated by co index --root.</p>
+{{range .Groups}}
+<h2>{{.Owner}} <span class="muted">({{len .Workspaces}}, {{size .SizeBytes}})</span></h2>
+<table>
+<tr><th>Workspace</th><th>State</th><th>Repos</th><th>Size</th><th>Last activity</th></tr>
+{{range .Workspaces}}<tr><td><a href="{{call $.Link .}}">{{.Slug}}</a></td><td>{{.State}}</td><td>{{repos .}}</td><td>{{size .SizeBytes}}</td><td>{{activity .}}</td></tr>
+{{end}}</table>
+{{end}}
+</body></html>
+`))
+
+var workspacePageTmpl = template.Must(template.New("workspace").Funcs(pageFuncs).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Slug}}</title>` + pageStyle + `</head>
+<body>
+<p><a href="index.html">&larr; All workspaces</a></p>
+<h1>{{.Slug}}</h1>
+<p><a href="{{fileURL .Path}}">{{.Path}}</a></p>
+<p class="muted">{{.State}}{{if .Tags}} · {{range $i, $t := .Tags}}{{if $i}}, {{end}}{{$t}}{{end}}{{end}} · {{size .SizeBytes}} · last activity {{activity .}}</p>
+{{if .Error}}<p>Error: {{.Error}}</p>{{end}}
+<table>
+<tr><th>Repo</th><th>Branch</th><th>Status</th><th>Remote</th></tr>
+{{range .Repos}}<tr><td>{{.Name}}</td><td>{{.Branch}}</td><td>{{if .Dirty}}dirty{{else}}clean{{end}}</td><td>{{.Remote}}</td></tr>
+{{else}}<tr><td colspan="4" class="muted">No repositories</td></tr>
+{{end}}</table>
+</body></html>
+`))
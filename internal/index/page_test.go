@@ -0,0 +1,86 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func pageRecords(codeRoot string) []*model.IndexRecord {
+	now := time.Date(2025, time.June, 15, 12, 0, 0, 0, time.UTC)
+	old := now.AddDate(-1, 0, 0)
+	return []*model.IndexRecord{
+		{Slug: "solo--tool", Path: filepath.Join(codeRoot, "solo--tool"), Owner: "solo", State: model.StatePaused, RepoCount: 1, SizeBytes: 2048, LastCommitAt: &old},
+		{Slug: "acme--web", Path: filepath.Join(codeRoot, "acme--web"), Owner: "acme", State: model.StateActive, RepoCount: 1, SizeBytes: 50, LastFSChangeAt: &old},
+		{Slug: "acme--api", Path: filepath.Join(codeRoot, "acme--api"), Owner: "acme", State: model.StateActive, RepoCount: 2, DirtyRepos: 1, SizeBytes: 100, LastCommitAt: &old, LastFSChangeAt: &now,
+			Repos: []model.IndexRepoInfo{{Name: "server", Branch: "main", Dirty: true}, {Name: "<client>", Branch: "dev"}}},
+	}
+}
+
+func TestGroupByOwner(t *testing.T) {
+	groups := GroupByOwner(pageRecords("/code"))
+	if len(groups) != 2 || groups[0].Owner != "acme" || groups[1].Owner != "solo" {
+		t.Fatalf("groups = %+v, want acme then solo", groups)
+	}
+	if ws := groups[0].Workspaces; ws[0].Slug != "acme--api" || ws[1].Slug != "acme--web" {
+		t.Errorf("acme workspaces = %s, %s, want most recently active first", ws[0].Slug, ws[1].Slug)
+	}
+	if groups[0].SizeBytes != 150 {
+		t.Errorf("acme SizeBytes = %d, want 150", groups[0].SizeBytes)
+	}
+	if got, want := groups[0].Summary(), "2 workspaces • 3 repos • 1 dirty • 150 B"; got != want {
+		t.Errorf("acme Summary() = %q, want %q", got, want)
+	}
+	if got, want := groups[1].Summary(), "1 workspace • 1 repo • 2.0 KB"; got != want {
+		t.Errorf("solo Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderIndexPages(t *testing.T) {
+	codeRoot := t.TempDir()
+	records := pageRecords(codeRoot)
+
+	md := RenderMarkdown(records, codeRoot)
+	for _, want := range []string{
+		"3 workspaces from 2 owners",
+		"## acme (2, 150 B)",
+		"| [acme--api](acme--api/) | active | 2 (1 dirty) | 100 B | " + formatActivity(*records[2].LastFSChangeAt) + " |",
+		"| [solo--tool](solo--tool/) | paused | 1 | 2.0 KB | " + formatActivity(*records[0].LastCommitAt) + " |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	page, err := RenderHTML(records, codeRoot)
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	if !strings.Contains(page, `<a href="acme--api/">acme--api</a>`) {
+		t.Errorf("html missing workspace link:\n%s", page)
+	}
+
+	site := filepath.Join(codeRoot, "_system", "site")
+	if err := WriteSite(site, records); err != nil {
+		t.Fatalf("WriteSite() error = %v", err)
+	}
+	index, _ := os.ReadFile(filepath.Join(site, "index.html"))
+	if !strings.Contains(string(index), `<a href="acme--api.html">`) {
+		t.Errorf("site index missing page link:\n%s", index)
+	}
+	ws, err := os.ReadFile(filepath.Join(site, "acme--api.html"))
+	if err != nil {
+		t.Fatalf("workspace page: %v", err)
+	}
+	if !strings.Contains(string(ws), "&lt;client&gt;") || !strings.Contains(string(ws), "dirty") {
+		t.Errorf("workspace page missing escaped repos:\n%s", ws)
+	}
+
+	if _, err := ParsePageFormat("pdf"); err == nil {
+		t.Error("ParsePageFormat(pdf) should fail")
+	}
+}
@@ -0,0 +1,189 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/chunker"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// ActivityMonths is the number of months covered by Stats.Activity.
+const ActivityMonths = 12
+
+// StatCount is a named count used for the per-owner, per-template and per-language breakdowns.
+type StatCount struct {
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+}
+
+// ArchiveStats summarizes archived workspaces.
+type ArchiveStats struct {
+	Count     int   `json:"count"`
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// Stats is a portfolio overview computed from the index.
+type Stats struct {
+	Workspaces      int          `json:"workspaces"`
+	InvalidCount    int          `json:"invalid"`
+	Repos           int          `json:"repos"`
+	DirtyRepos      int          `json:"dirty_repos"`
+	DirtyWorkspaces int          `json:"dirty_workspaces"`
+	TotalSizeBytes  int64        `json:"total_size_bytes"`
+	ByOwner         []StatCount  `json:"by_owner"`
+	ByState         []StatCount  `json:"by_state"`
+	ByTemplate      []StatCount  `json:"by_template"`
+	Languages       []StatCount  `json:"languages,omitempty"`
+	Activity        []int        `json:"activity"` // Workspaces by month of last commit, oldest first
+	Archives        ArchiveStats `json:"archives"`
+}
+
+// ComputeStats aggregates index records into a Stats summary.
+// templates maps workspace slug to the template it was created from; missing entries count as "none".
+func ComputeStats(records []*model.IndexRecord, templates map[string]string, now time.Time) *Stats {
+	stats := &Stats{
+		Activity: make([]int, ActivityMonths),
+	}
+
+	owners := make(map[string]*StatCount)
+	states := make(map[string]*StatCount)
+	tmpls := make(map[string]*StatCount)
+
+	bump := func(m map[string]*StatCount, name string, size int64) {
+		c, ok := m[name]
+		if !ok {
+			c = &StatCount{Name: name}
+			m[name] = c
+		}
+		c.Count++
+		c.SizeBytes += size
+	}
+
+	for _, r := range records {
+		stats.Workspaces++
+		if !r.Valid {
+			stats.InvalidCount++
+		}
+		stats.Repos += r.RepoCount
+		stats.DirtyRepos += r.DirtyRepos
+		if r.DirtyRepos > 0 {
+			stats.DirtyWorkspaces++
+		}
+		stats.TotalSizeBytes += r.SizeBytes
+
+		owner := r.Owner
+		if owner == "" {
+			owner = "(unknown)"
+		}
+		bump(owners, owner, r.SizeBytes)
+
+		state := string(r.State)
+		if state == "" {
+			state = "(unknown)"
+		}
+		bump(states, state, r.SizeBytes)
+
+		tmpl := templates[r.Slug]
+		if tmpl == "" {
+			tmpl = "none"
+		}
+		bump(tmpls, tmpl, r.SizeBytes)
+
+		if r.LastCommitAt != nil {
+			if idx := activityBucket(*r.LastCommitAt, now); idx >= 0 {
+				stats.Activity[idx]++
+			}
+		}
+	}
+
+	stats.ByOwner = sortedCounts(owners)
+	stats.ByState = sortedCounts(states)
+	stats.ByTemplate = sortedCounts(tmpls)
+
+	return stats
+}
+
+// activityBucket returns the Activity index for t, or -1 if it falls outside the window.
+func activityBucket(t, now time.Time) int {
+	months := (now.Year()-t.Year())*12 + int(now.Month()) - int(t.Month())
+	if months < 0 || months >= ActivityMonths {
+		return -1
+	}
+	return ActivityMonths - 1 - months
+}
+
+// sortedCounts returns counts ordered by count descending, then name.
+func sortedCounts(m map[string]*StatCount) []StatCount {
+	result := make([]StatCount, 0, len(m))
+	for _, c := range m {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// CountLanguages walks the given directories and counts source files per language.
+// Directories matching the builtin sync excludes (node_modules/, vendor/, ...) are skipped.
+func CountLanguages(paths []string) []StatCount {
+	skip := excludedDirNames()
+	langs := make(map[string]*StatCount)
+
+	for _, root := range paths {
+		_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if path != root && skip[info.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			lang := chunker.DetectLanguage(info.Name())
+			if lang == "" {
+				return nil
+			}
+			c, ok := langs[lang]
+			if !ok {
+				c = &StatCount{Name: lang}
+				langs[lang] = c
+			}
+			c.Count++
+			c.SizeBytes += info.Size()
+			return nil
+		})
+	}
+
+	return sortedCounts(langs)
+}
+
+// excludedDirNames returns plain directory names from the builtin excludes, plus .git.
+func excludedDirNames() map[string]bool {
+	names := map[string]bool{".git": true}
+	for _, pattern := range fs.BuiltinExcludes {
+		if !strings.HasSuffix(pattern, "/") || strings.ContainsAny(pattern, "*?[") {
+			continue
+		}
+		name := strings.TrimSuffix(pattern, "/")
+		if strings.Contains(name, "/") {
+			continue
+		}
+		names[name] = true
+	}
+	return names
+}
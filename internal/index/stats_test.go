@@ -0,0 +1,103 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestComputeStats(t *testing.T) {
+	now := time.Date(2025, time.June, 15, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, -1, 0)
+	old := now.AddDate(-2, 0, 0)
+
+	records := []*model.IndexRecord{
+		{Slug: "acme--api", Owner: "acme", State: model.StateActive, RepoCount: 2, DirtyRepos: 1, SizeBytes: 100, LastCommitAt: &now, Valid: true},
+		{Slug: "acme--web", Owner: "acme", State: model.StateActive, RepoCount: 1, SizeBytes: 50, LastCommitAt: &recent, Valid: true},
+		{Slug: "solo--tool", Owner: "solo", State: model.StatePaused, RepoCount: 1, SizeBytes: 25, LastCommitAt: &old, Valid: true},
+		{Slug: "broken--ws", Valid: false},
+	}
+	templates := map[string]string{
+		"acme--api": "go-service",
+		"acme--web": "go-service",
+	}
+
+	stats := ComputeStats(records, templates, now)
+
+	if stats.Workspaces != 4 {
+		t.Errorf("Workspaces = %d, want 4", stats.Workspaces)
+	}
+	if stats.InvalidCount != 1 {
+		t.Errorf("InvalidCount = %d, want 1", stats.InvalidCount)
+	}
+	if stats.Repos != 4 {
+		t.Errorf("Repos = %d, want 4", stats.Repos)
+	}
+	if stats.DirtyRepos != 1 || stats.DirtyWorkspaces != 1 {
+		t.Errorf("DirtyRepos/DirtyWorkspaces = %d/%d, want 1/1", stats.DirtyRepos, stats.DirtyWorkspaces)
+	}
+	if stats.TotalSizeBytes != 175 {
+		t.Errorf("TotalSizeBytes = %d, want 175", stats.TotalSizeBytes)
+	}
+
+	if len(stats.ByOwner) != 3 || stats.ByOwner[0].Name != "acme" || stats.ByOwner[0].Count != 2 || stats.ByOwner[0].SizeBytes != 150 {
+		t.Errorf("ByOwner = %+v, want acme first with 2 workspaces and 150 bytes", stats.ByOwner)
+	}
+	if len(stats.ByTemplate) != 2 || stats.ByTemplate[0].Name != "go-service" || stats.ByTemplate[1].Name != "none" {
+		t.Errorf("ByTemplate = %+v, want go-service then none", stats.ByTemplate)
+	}
+
+	if len(stats.Activity) != ActivityMonths {
+		t.Fatalf("len(Activity) = %d, want %d", len(stats.Activity), ActivityMonths)
+	}
+	if stats.Activity[ActivityMonths-1] != 1 || stats.Activity[ActivityMonths-2] != 1 {
+		t.Errorf("Activity = %v, want one workspace in each of the last two months", stats.Activity)
+	}
+}
+
+func TestCountLanguages(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		"main.go":                   "package main",
+		"util.go":                   "package main",
+		"web/app.ts":                "export {}",
+		"node_modules/dep/index.js": "module.exports = {}",
+		".git/hooks/pre-commit.sh":  "#!/bin/sh",
+		"README.md":                 "# readme",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	langs := CountLanguages([]string{root})
+
+	counts := make(map[string]int)
+	for _, l := range langs {
+		counts[l.Name] = l.Count
+	}
+	if counts["go"] != 2 {
+		t.Errorf("go count = %d, want 2", counts["go"])
+	}
+	if counts["typescript"] != 1 {
+		t.Errorf("typescript count = %d, want 1", counts["typescript"])
+	}
+	if counts["javascript"] != 0 {
+		t.Errorf("javascript count = %d, want 0 (node_modules skipped)", counts["javascript"])
+	}
+	if counts["bash"] != 0 {
+		t.Errorf("bash count = %d, want 0 (.git skipped)", counts["bash"])
+	}
+	if langs[0].Name != "go" {
+		t.Errorf("first language = %q, want go", langs[0].Name)
+	}
+}
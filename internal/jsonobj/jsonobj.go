@@ -0,0 +1,102 @@
+// Package jsonobj edits JSON objects that people also edit by hand. An
+// Object keeps the order of its keys and the values it does not change, so
+// rewriting a file does not reshuffle it.
+package jsonobj
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrNotObject is returned by Parse for data that is not a JSON object.
+var ErrNotObject = errors.New("not a JSON object")
+
+// Object is a JSON object that keeps its key order.
+type Object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// New returns an empty object.
+func New() *Object {
+	return &Object{values: make(map[string]json.RawMessage)}
+}
+
+// Parse decodes a JSON object, which must be all of data. A key that appears
+// twice keeps its first position and its last value, as encoding/json does.
+func Parse(data []byte) (*Object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, ErrNotObject
+	}
+	o := New()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if _, seen := o.values[key]; !seen {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = raw
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON object")
+	}
+	return o, nil
+}
+
+// Get returns the raw value of key.
+func (o *Object) Get(key string) (json.RawMessage, bool) {
+	raw, ok := o.values[key]
+	return raw, ok
+}
+
+// Set sets key to value, marshaled as JSON. New keys go last.
+func (o *Object) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+	return nil
+}
+
+// Marshal encodes the object with every level indented by indent, ending in
+// a newline.
+func (o *Object) Marshal(indent string) ([]byte, error) {
+	var compact bytes.Buffer
+	compact.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			compact.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		compact.Write(k)
+		compact.WriteByte(':')
+		if err := json.Compact(&compact, o.values[key]); err != nil {
+			return nil, err
+		}
+	}
+	compact.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", indent); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
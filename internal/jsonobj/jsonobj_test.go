@@ -0,0 +1,40 @@
+package jsonobj
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestObject(t *testing.T) {
+	o, err := Parse([]byte(`{"z": 1, "a": {"b": [1, 2]}, "z": 3}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if raw, ok := o.Get("z"); !ok || string(raw) != "3" {
+		t.Errorf("Get(z) = %s, %v; want the last value", raw, ok)
+	}
+	if err := o.Set("m", []string{"x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Set("z", true); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := o.Marshal("  ")
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := "{\n  \"z\": true,\n  \"a\": {\n    \"b\": [\n      1,\n      2\n    ]\n  },\n  \"m\": [\n    \"x\"\n  ]\n}\n"
+	if string(data) != want {
+		t.Errorf("Marshal() = %q, want %q", data, want)
+	}
+
+	for _, bad := range []string{"[1]", "", `{"a": }`, `{"a": 1`, `{"a":1} x`, `{"a":1}{"b":2}`} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+	if _, err := Parse([]byte("[]")); !errors.Is(err, ErrNotObject) {
+		t.Errorf("Parse([]) error = %v, want ErrNotObject", err)
+	}
+}
@@ -0,0 +1,377 @@
+// Package lock provides advisory file locks that keep concurrent co processes
+// from mutating the same workspace or archive directory at once.
+//
+// A lock is a JSON file created exclusively under <code_root>/_system/locks,
+// through a hard link on shared code roots since that is atomic on NFS.
+// Locks held by a process that no longer exists on this host are considered
+// stale and are taken over automatically, by renaming a new lock file onto
+// them; locks from other hosts become stale after StaleAfter. Locks are not
+// re-entrant: goroutines of one process, such as the requests of co serve,
+// exclude each other like separate processes do.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+)
+
+// StaleAfter is how old a lock from another host must be before it is
+// considered stale.
+const StaleAfter = 24 * time.Hour
+
+// ArchivesName is the lock name guarding the archive directory.
+const ArchivesName = "archives"
+
+// Info describes the holder of a lock.
+type Info struct {
+	Name       string    `json:"name"`
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	Command    string    `json:"command"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	Path       string    `json:"path"`
+	Stale      bool      `json:"stale"`
+}
+
+// HeldError is returned when a lock is held by another live process.
+type HeldError struct {
+	Holder Info
+}
+
+func (e *HeldError) Error() string {
+	h := e.Holder
+	return fmt.Sprintf("%s is locked by %s (pid %d on %s, since %s); if that process is gone, run 'co unlock %s'",
+		describe(h.Name), h.Command, h.PID, h.Host, h.AcquiredAt.Local().Format("2006-01-02 15:04:05"), unlockArg(h.Name))
+}
+
+// Lock is an acquired lock. Release must be called when done.
+type Lock struct {
+	path     string
+	released bool
+}
+
+var (
+	// mu serializes lock operations of this process
+	mu sync.Mutex
+	// held records the lock file paths of the unreleased Locks of this process
+	held = make(map[string]bool)
+)
+
+// errTakeOverBusy is returned by takeOver while another process is taking
+// over the same stale lock.
+var errTakeOverBusy = errors.New("stale lock is being taken over")
+
+// Workspace acquires the lock for the workspace slug.
+func Workspace(cfg *config.Config, slug string) (*Lock, error) {
+	return Acquire(cfg.LocksDir(), WorkspaceName(slug))
+}
+
+// Archives acquires the lock for the archive directory.
+func Archives(cfg *config.Config) (*Lock, error) {
+	return Acquire(cfg.LocksDir(), ArchivesName)
+}
+
+// Acquire takes the named lock in dir, replacing it if stale. It returns a
+// *HeldError if another live process, or another Lock of this one, holds the
+// lock.
+func Acquire(dir, name string) (*Lock, error) {
+	if err := os.MkdirAll(dir, fs.DirPerm()); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	path := filepath.Join(dir, name+".lock")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if held[path] {
+		holder, err := read(path)
+		if err != nil {
+			holder = Info{Name: name, PID: os.Getpid(), Host: hostname(), Command: command(), Path: path}
+		}
+		return nil, &HeldError{Holder: holder}
+	}
+
+	// A few retries while a stale lock vanishes or is being taken over by
+	// another process; after that the lock counts as held.
+	for attempt := 0; attempt < 5; attempt++ {
+		err := create(path, name)
+		if err == nil {
+			held[path] = true
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock %s: %w", name, err)
+		}
+
+		err = takeOver(path, name)
+		if err == nil {
+			held[path] = true
+			return &Lock{path: path}, nil
+		}
+		if errors.Is(err, errTakeOverBusy) {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if os.IsNotExist(err) {
+			continue
+		}
+		return nil, err
+	}
+
+	holder, err := read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s", name)
+	}
+	return nil, &HeldError{Holder: holder}
+}
+
+// takeOver replaces the lock file at path with one of this process if it is
+// stale, returning a *HeldError if it is not. A new lock file is renamed onto
+// the stale one, so the lock never goes missing for another process to
+// create. Only one process may take over a lock at a time, as the holder of
+// path.takeover, and it checks again that the lock is stale under it; the
+// guard of a process that died taking over is removed after a minute.
+func takeOver(path, name string) error {
+	holder, err := read(path)
+	if err != nil {
+		return err
+	}
+	// A lock file of this process that it does not hold is left over from a
+	// process that had the same PID
+	if !holder.Stale && !(holder.PID == os.Getpid() && holder.Host == hostname()) {
+		return &HeldError{Holder: holder}
+	}
+
+	guard := path + ".takeover"
+	if err := create(guard, name); err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to take over stale lock %s: %w", name, err)
+		}
+		if stat, err := os.Stat(guard); err == nil && time.Since(stat.ModTime()) > time.Minute {
+			os.Remove(guard)
+		}
+		return errTakeOverBusy
+	}
+	defer os.Remove(guard)
+
+	holder, err = read(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return create(path, name)
+		}
+		return err
+	}
+	if !holder.Stale && !(holder.PID == os.Getpid() && holder.Host == hostname()) {
+		return &HeldError{Holder: holder}
+	}
+
+	tmp := fmt.Sprintf("%s.%s.%d.%d", path, hostname(), os.Getpid(), time.Now().UnixNano())
+	if err := os.WriteFile(tmp, lockData(name), fs.FilePerm()); err != nil {
+		return fmt.Errorf("failed to take over stale lock %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to take over stale lock %s: %w", name, err)
+	}
+	return nil
+}
+
+// Release gives up the lock, removing its lock file.
+func (l *Lock) Release() error {
+	if l == nil || l.released {
+		return nil
+	}
+	l.released = true
+
+	mu.Lock()
+	defer mu.Unlock()
+	delete(held, l.path)
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns all locks in dir, sorted by name.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var locks []Info
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".lock") {
+			continue
+		}
+		info, err := read(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		locks = append(locks, info)
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Name < locks[j].Name })
+	return locks, nil
+}
+
+// Remove deletes the named lock in dir regardless of its holder.
+func Remove(dir, name string) error {
+	err := os.Remove(filepath.Join(dir, name+".lock"))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no lock named %s", name)
+	}
+	return err
+}
+
+// WorkspaceName returns the lock name used for a workspace slug.
+func WorkspaceName(slug string) string {
+	return "workspace-" + slug
+}
+
+// lockData returns the contents of a lock file for name held by this process.
+func lockData(name string) []byte {
+	info := Info{
+		Name:       name,
+		PID:        os.Getpid(),
+		Host:       hostname(),
+		Command:    command(),
+		AcquiredAt: time.Now().UTC(),
+	}
+	data, _ := json.Marshal(info)
+	return data
+}
+
+func create(path, name string) error {
+	data := lockData(name)
+	if fs.Shared() {
+		return createLinked(path, data)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fs.FilePerm())
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// createLinked creates the lock file at path for a shared code root. O_EXCL
+// is not atomic on older NFS, but link is: the lock is written to a file of
+// its own and hard-linked into place. If the reply to a link that succeeded
+// is lost, the lock file being the same file shows that it did.
+func createLinked(path string, data []byte) error {
+	tmp := fmt.Sprintf("%s.%s.%d.%d", path, hostname(), os.Getpid(), time.Now().UnixNano())
+	if err := os.WriteFile(tmp, data, fs.FilePerm()); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	linkErr := os.Link(tmp, path)
+	if linkErr == nil {
+		return nil
+	}
+	tmpInfo, err := os.Stat(tmp)
+	if err != nil {
+		return linkErr
+	}
+	if lockInfo, err := os.Stat(path); err == nil && os.SameFile(tmpInfo, lockInfo) {
+		return nil
+	}
+	return linkErr
+}
+
+// read loads a lock file and determines whether it is stale. Unreadable
+// lock files are treated as stale once they are older than a minute, which
+// covers a process that died between creating and writing the file.
+func read(path string) (Info, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Info{}, err
+	}
+
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil || info.PID == 0 {
+		info = Info{
+			Name:       strings.TrimSuffix(filepath.Base(path), ".lock"),
+			Command:    "unknown",
+			AcquiredAt: stat.ModTime(),
+			Stale:      time.Since(stat.ModTime()) > time.Minute,
+		}
+	} else if info.Host == hostname() {
+		info.Stale = !processAlive(info.PID)
+	} else {
+		info.Stale = time.Since(info.AcquiredAt) > StaleAfter
+	}
+	info.Path = path
+	return info, nil
+}
+
+// processAlive reports whether pid refers to a running process. On Windows
+// FindProcess already fails for exited processes.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+func hostname() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+func command() string {
+	args := append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...)
+	cmd := strings.Join(args, " ")
+	if len(cmd) > 80 {
+		cmd = cmd[:77] + "..."
+	}
+	return cmd
+}
+
+func describe(name string) string {
+	if slug, ok := strings.CutPrefix(name, "workspace-"); ok {
+		return "workspace " + slug
+	}
+	if name == ArchivesName {
+		return "the archive directory"
+	}
+	return name
+}
+
+func unlockArg(name string) string {
+	if slug, ok := strings.CutPrefix(name, "workspace-"); ok {
+		return slug
+	}
+	if name == ArchivesName {
+		return "--archives"
+	}
+	return name
+}
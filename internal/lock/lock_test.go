@@ -0,0 +1,260 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/fs"
+)
+
+func writeLock(t *testing.T, dir, name string, info Info) {
+	t.Helper()
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".lock"), data, 0644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+}
+
+func TestAcquireRelease(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Acquire(dir, "workspace-acme--app")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	path := filepath.Join(dir, "workspace-acme--app.lock")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("lock file not created: %v", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("lock file still present after Release()")
+	}
+}
+
+// TestAcquireGoroutines races goroutines of one process for a lock: exactly
+// one may hold it at a time.
+func TestAcquireGoroutines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "archives.lock")
+
+	var wg sync.WaitGroup
+	locks := make([]*Lock, 8)
+	for i := range locks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l, err := Acquire(dir, "archives")
+			var held *HeldError
+			if err != nil && !errors.As(err, &held) {
+				t.Errorf("Acquire() error = %v, want *HeldError", err)
+			}
+			locks[i] = l
+		}(i)
+	}
+	wg.Wait()
+
+	var winner *Lock
+	for _, l := range locks {
+		if l == nil {
+			continue
+		}
+		if winner != nil {
+			t.Fatal("two goroutines acquired the lock")
+		}
+		winner = l
+	}
+	if winner == nil {
+		t.Fatal("no goroutine acquired the lock")
+	}
+	var held *HeldError
+	if _, err := Acquire(dir, "archives"); !errors.As(err, &held) || held.Holder.PID != os.Getpid() {
+		t.Fatalf("Acquire() while held error = %v, want *HeldError naming this process", err)
+	}
+
+	if err := winner.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := winner.Release(); err != nil {
+		t.Fatalf("second Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("lock file still present after Release()")
+	}
+	l, err := Acquire(dir, "archives")
+	if err != nil {
+		t.Fatalf("Acquire() after Release() error = %v", err)
+	}
+	l.Release()
+}
+
+// TestAcquireConcurrent races processes for a stale lock: exactly one may
+// take it over. The test binary runs itself as those processes.
+func TestAcquireConcurrent(t *testing.T) {
+	if dir := os.Getenv("CO_LOCK_TEST_DIR"); dir != "" {
+		if _, err := Acquire(dir, "workspace-acme--app"); err == nil {
+			os.Stdout.WriteString("acquired\n")
+			// Hold the lock until every process has tried
+			time.Sleep(2 * time.Second)
+		}
+		return
+	}
+
+	dir := t.TempDir()
+	writeLock(t, dir, "workspace-acme--app", Info{PID: 1 << 22, Host: hostname(), AcquiredAt: time.Now()})
+
+	const processes = 6
+	var wg sync.WaitGroup
+	outputs := make([]string, processes)
+	for i := 0; i < processes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestAcquireConcurrent$")
+			cmd.Env = append(os.Environ(), "CO_LOCK_TEST_DIR="+dir)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Errorf("helper process: %v\n%s", err, out)
+			}
+			outputs[i] = string(out)
+		}(i)
+	}
+	wg.Wait()
+
+	acquired := 0
+	for _, out := range outputs {
+		if strings.Contains(out, "acquired") {
+			acquired++
+		}
+	}
+	if acquired != 1 {
+		t.Errorf("%d processes acquired the lock, want 1", acquired)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("lock directory holds %d entries, want only the lock", len(entries))
+	}
+}
+
+func TestAcquireHeld(t *testing.T) {
+	dir := t.TempDir()
+	// The parent process is alive for the duration of the test.
+	writeLock(t, dir, "archives", Info{
+		Name:       "archives",
+		PID:        os.Getppid(),
+		Host:       hostname(),
+		Command:    "co stash",
+		AcquiredAt: time.Now(),
+	})
+
+	_, err := Acquire(dir, "archives")
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("Acquire() error = %v, want *HeldError", err)
+	}
+	if held.Holder.Command != "co stash" {
+		t.Errorf("holder command = %q, want %q", held.Holder.Command, "co stash")
+	}
+}
+
+func TestAcquireStale(t *testing.T) {
+	tests := []struct {
+		name string
+		info Info
+	}{
+		{
+			name: "dead process",
+			info: Info{PID: 1 << 22, Host: hostname(), AcquiredAt: time.Now()},
+		},
+		{
+			name: "old lock from other host",
+			info: Info{PID: 1, Host: "elsewhere", AcquiredAt: time.Now().Add(-2 * StaleAfter)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeLock(t, dir, "workspace-acme--app", tt.info)
+
+			l, err := Acquire(dir, "workspace-acme--app")
+			if err != nil {
+				t.Fatalf("Acquire() error = %v, want stale lock replaced", err)
+			}
+			defer l.Release()
+
+			locks, err := List(dir)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(locks) != 1 || locks[0].PID != os.Getpid() || locks[0].Stale {
+				t.Errorf("List() = %+v, want one live lock owned by this process", locks)
+			}
+		})
+	}
+}
+
+func TestListAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	writeLock(t, dir, "workspace-b--x", Info{Name: "workspace-b--x", PID: 1, Host: "elsewhere", AcquiredAt: time.Now()})
+	writeLock(t, dir, "archives", Info{Name: "archives", PID: 1, Host: "elsewhere", AcquiredAt: time.Now().Add(-2 * StaleAfter)})
+
+	locks, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(locks) != 2 || locks[0].Name != "archives" || !locks[0].Stale || locks[1].Stale {
+		t.Fatalf("List() = %+v", locks)
+	}
+
+	if err := Remove(dir, "archives"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if err := Remove(dir, "archives"); err == nil {
+		t.Error("Remove() of missing lock should fail")
+	}
+
+	if locks, _ := List(filepath.Join(dir, "missing")); locks != nil {
+		t.Errorf("List() of missing dir = %+v, want nil", locks)
+	}
+}
+
+func TestAcquireShared(t *testing.T) {
+	fs.SetShared(true)
+	defer fs.SetShared(false)
+	dir := t.TempDir()
+
+	l, err := Acquire(dir, "archives")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	locks, err := List(dir)
+	if err != nil || len(locks) != 1 || locks[0].PID != os.Getpid() {
+		t.Fatalf("List() = %+v, %v; want the lock of this process", locks, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("lock directory holds %d entries, want only the lock", len(entries))
+	}
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	writeLock(t, dir, "archives", Info{Name: "archives", PID: os.Getppid(), Host: hostname(), Command: "co stash", AcquiredAt: time.Now()})
+	var held *HeldError
+	if _, err := Acquire(dir, "archives"); !errors.As(err, &held) {
+		t.Fatalf("Acquire() of a held lock error = %v, want *HeldError", err)
+	}
+}
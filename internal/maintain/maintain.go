@@ -0,0 +1,179 @@
+// Package maintain runs the periodic upkeep co expects from a scheduler:
+// pruning old archives and quarantine, refreshing the index, and flagging
+// stale workspaces.
+package maintain
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/archive"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/index"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/quarantine"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// Options configures a maintenance run.
+type Options struct {
+	Fetch bool // Fetch the remotes of every repo before indexing
+}
+
+// Report describes what a maintenance run did. Steps that fail are recorded
+// in Errors and do not stop the steps after them.
+type Report struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	PrunedArchives   []string `json:"pruned_archives"`
+	PurgedQuarantine []string `json:"purged_quarantine"`
+	FetchedRepos     int      `json:"fetched_repos,omitempty"`
+	FetchSkipped     bool     `json:"fetch_skipped,omitempty"` // Fetch was asked for while offline
+
+	Workspaces     int              `json:"workspaces"`
+	TotalSizeBytes int64            `json:"total_size_bytes"`
+	Sizes          []WorkspaceSize  `json:"sizes"` // Largest first
+	Stale          []StaleWorkspace `json:"stale"` // Least recently active first
+
+	Errors []string `json:"errors,omitempty"`
+}
+
+// WorkspaceSize is the disk usage of one workspace.
+type WorkspaceSize struct {
+	Slug      string `json:"slug"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// StaleWorkspace is a workspace inactive for at least archive.suggest_days.
+// Archivable is set when its repos are clean and fully pushed, so archiving
+// it loses nothing.
+type StaleWorkspace struct {
+	Slug         string    `json:"slug"`
+	LastActivity time.Time `json:"last_activity"`
+	InactiveDays int       `json:"inactive_days"`
+	Archivable   bool      `json:"archivable"`
+}
+
+// Run performs a maintenance pass over cfg's code root.
+func Run(cfg *config.Config, opts Options) *Report {
+	now := time.Now()
+	report := &Report{
+		StartedAt:        now,
+		PrunedArchives:   []string{},
+		PurgedQuarantine: []string{},
+		Sizes:            []WorkspaceSize{},
+		Stale:            []StaleWorkspace{},
+	}
+	fail := func(step string, err error) {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", step, err))
+	}
+
+	if pruned, err := archive.PruneArchives(cfg, now); err != nil {
+		fail("prune archives", err)
+	} else if pruned != nil {
+		report.PrunedArchives = pruned
+	}
+
+	purged, err := quarantine.PurgeExpired(cfg)
+	for _, entry := range purged {
+		report.PurgedQuarantine = append(report.PurgedQuarantine, entry.OriginalPath)
+	}
+	if err != nil {
+		fail("purge quarantine", err)
+	}
+
+	if opts.Fetch && cfg.IsOffline() {
+		report.FetchSkipped = true
+	} else if opts.Fetch {
+		report.FetchedRepos = fetchAll(cfg, fail)
+	}
+
+	builder := index.NewBuilder(cfg)
+	idx, err := builder.Build()
+	if err != nil {
+		fail("index", err)
+		report.FinishedAt = time.Now()
+		return report
+	}
+	if err := builder.Save(idx); err != nil {
+		fail("save index", err)
+	}
+
+	report.Workspaces = len(idx.Records)
+	for _, r := range idx.Records {
+		report.TotalSizeBytes += r.SizeBytes
+		report.Sizes = append(report.Sizes, WorkspaceSize{Slug: r.Slug, SizeBytes: r.SizeBytes})
+	}
+	sort.Slice(report.Sizes, func(i, j int) bool {
+		if report.Sizes[i].SizeBytes != report.Sizes[j].SizeBytes {
+			return report.Sizes[i].SizeBytes > report.Sizes[j].SizeBytes
+		}
+		return report.Sizes[i].Slug < report.Sizes[j].Slug
+	})
+
+	days := cfg.GetArchiveConfig().SuggestDays
+	archivable := make(map[string]bool)
+	for _, s := range archive.Suggest(idx.Records, days, now) {
+		archivable[s.Slug] = true
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	for _, r := range idx.Records {
+		last := index.LastActivity(r)
+		if !r.Valid || last.IsZero() || last.After(cutoff) {
+			continue
+		}
+		report.Stale = append(report.Stale, StaleWorkspace{
+			Slug:         r.Slug,
+			LastActivity: last,
+			InactiveDays: int(now.Sub(last).Hours() / 24),
+			Archivable:   archivable[r.Slug],
+		})
+	}
+	sort.Slice(report.Stale, func(i, j int) bool {
+		return report.Stale[i].LastActivity.Before(report.Stale[j].LastActivity)
+	})
+
+	report.FinishedAt = time.Now()
+	return report
+}
+
+// fetchAll fetches every repo in every workspace and returns how many were
+// fetched.
+func fetchAll(cfg *config.Config, fail func(string, error)) int {
+	slugs, err := workspace.ListWorkspaces(cfg)
+	if err != nil {
+		fail("fetch", err)
+		return 0
+	}
+	fetched := 0
+	policy := template.GitRetryPolicy(cfg)
+	for _, slug := range slugs {
+		workspacePath := cfg.WorkspacePath(slug)
+		repos, err := model.LocateWorkspaceRepos(workspacePath)
+		if err != nil {
+			continue
+		}
+		for _, located := range repos {
+			repo := located.Name
+			repoPath := filepath.Join(workspacePath, filepath.FromSlash(located.Path))
+			if !git.IsRepo(repoPath) {
+				continue
+			}
+			_, err := policy.Do(func(ctx context.Context) error {
+				return git.FetchContext(ctx, repoPath)
+			}, nil)
+			if err != nil {
+				fail("fetch "+slug+"/"+repo, err)
+				continue
+			}
+			fetched++
+		}
+	}
+	return fetched
+}
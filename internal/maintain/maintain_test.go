@@ -0,0 +1,62 @@
+package maintain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestRun(t *testing.T) {
+	cfg := &config.Config{
+		Schema:   1,
+		CodeRoot: t.TempDir(),
+		Owners:   map[string]config.OwnerPolicy{"acme": {ArchiveRetentionDays: 30}},
+	}
+
+	workspacePath := cfg.WorkspacePath("acme--app")
+	if err := os.MkdirAll(filepath.Join(workspacePath, "repos"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	proj := model.NewProject("acme--app", "acme", "app")
+	proj.Slug = "acme--app"
+	if err := proj.Save(workspacePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two archives past retention: the older is pruned, the newest kept
+	archiveDir := filepath.Join(cfg.ArchiveDir(), "2020")
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	older := filepath.Join(archiveDir, "acme--old--20200101-120000.tar.gz")
+	newest := filepath.Join(archiveDir, "acme--old--20200201-120000.tar.gz")
+	other := filepath.Join(archiveDir, "beta--old--20200101-120000.tar.gz")
+	for _, path := range []string{older, newest, other} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := Run(cfg, Options{})
+	if len(report.Errors) > 0 {
+		t.Fatalf("Run() errors = %v", report.Errors)
+	}
+	if len(report.PrunedArchives) != 1 || report.PrunedArchives[0] != older {
+		t.Errorf("PrunedArchives = %v, want [%s]", report.PrunedArchives, older)
+	}
+	for _, path := range []string{newest, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(path), err)
+		}
+	}
+
+	if report.Workspaces != 1 || len(report.Sizes) != 1 || report.Sizes[0].Slug != "acme--app" {
+		t.Errorf("report = %+v, want one indexed workspace", report)
+	}
+	if _, err := os.Stat(cfg.IndexPath()); err != nil {
+		t.Errorf("index not saved: %v", err)
+	}
+}
@@ -0,0 +1,489 @@
+// Package mcp serves co operations as Model Context Protocol tools over stdio,
+// so AI coding agents can locate and organize workspaces with structured schemas.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/pkg/co"
+)
+
+// supportedVersions lists protocol revisions this server speaks, newest first.
+var supportedVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server answers MCP requests using a co client.
+type Server struct {
+	client *co.Client
+	tools  []tool
+}
+
+// New returns an MCP server backed by client.
+func New(client *co.Client) *Server {
+	s := &Server{client: client}
+	s.tools = s.registerTools()
+	return s
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes responses
+// to w until r is exhausted or ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	enc := json.NewEncoder(w)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handle(ctx, line); resp != nil {
+				if encErr := enc.Encode(resp); encErr != nil {
+					return encErr
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes one message. Notifications (no id) get no response.
+func (s *Server) handle(ctx context.Context, msg []byte) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}}
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: codeInvalidRequest, Message: "invalid JSON-RPC request"}
+		return resp
+	}
+
+	switch req.Method {
+	case "initialize":
+		resp.Result = s.initialize(req.Params)
+	case "ping":
+		resp.Result = struct{}{}
+	case "tools/list":
+		resp.Result = s.listTools()
+	case "tools/call":
+		result, err := s.callTool(ctx, req.Params)
+		if err != nil {
+			resp.Error = err
+		} else {
+			resp.Result = result
+		}
+	default:
+		resp.Error = &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+	return resp
+}
+
+func (s *Server) initialize(params json.RawMessage) any {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	json.Unmarshal(params, &p)
+
+	version := supportedVersions[0]
+	for _, v := range supportedVersions {
+		if v == p.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]string{"name": "co", "version": model.CoVersion()},
+		"instructions": "co manages code workspaces named owner--project under a single code root. " +
+			"Use find_workspace to locate a project by partial name and list_workspaces to browse.",
+	}
+}
+
+func (s *Server) listTools() any {
+	type toolInfo struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		InputSchema map[string]any `json:"inputSchema"`
+	}
+	tools := make([]toolInfo, 0, len(s.tools))
+	for _, t := range s.tools {
+		tools = append(tools, toolInfo{Name: t.name, Description: t.description, InputSchema: t.schema})
+	}
+	return map[string]any{"tools": tools}
+}
+
+// callTool runs a tool. Tool failures are reported in the result with isError
+// set, so the agent can see and react to them; only protocol problems are
+// JSON-RPC errors.
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (any, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+
+	var t *tool
+	for i := range s.tools {
+		if s.tools[i].name == p.Name {
+			t = &s.tools[i]
+			break
+		}
+	}
+	if t == nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+	}
+
+	args := p.Arguments
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	result, err := t.run(ctx, args)
+	if err != nil {
+		return map[string]any{
+			"content": []map[string]string{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}, nil
+	}
+
+	text, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("encoding result: %v", err)}
+	}
+	return map[string]any{
+		"content":           []map[string]string{{"type": "text", "text": string(text)}},
+		"structuredContent": result,
+		"isError":           false,
+	}, nil
+}
+
+// tool is a single MCP tool. run receives the raw arguments object and
+// returns a JSON object for structuredContent.
+type tool struct {
+	name        string
+	description string
+	schema      map[string]any
+	run         func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// objectSchema builds a JSON Schema object with the given properties.
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProp(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+var variablesProp = map[string]any{
+	"type":                 "object",
+	"description":          "Template variables (name to value)",
+	"additionalProperties": map[string]any{"type": "string"},
+}
+
+// decodeArgs unmarshals tool arguments, rejecting unknown fields.
+func decodeArgs(args json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// workspaceSummary is the agent-facing view of a workspace.
+type workspaceSummary struct {
+	Slug       string   `json:"slug"`
+	Owner      string   `json:"owner"`
+	State      string   `json:"state"`
+	Path       string   `json:"path"`
+	Repos      []string `json:"repos"`
+	DirtyRepos int      `json:"dirty_repos"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+func summarize(r *co.Workspace) workspaceSummary {
+	s := workspaceSummary{
+		Slug:       r.Slug,
+		Owner:      r.Owner,
+		State:      string(r.State),
+		Path:       r.Path,
+		Repos:      []string{},
+		DirtyRepos: r.DirtyRepos,
+		Tags:       r.Tags,
+	}
+	for _, repo := range r.Repos {
+		s.Repos = append(s.Repos, repo.Name)
+	}
+	return s
+}
+
+func (s *Server) registerTools() []tool {
+	return []tool{
+		{
+			name:        "list_workspaces",
+			description: "List workspaces from the co index, optionally filtered by owner, state, or tag.",
+			schema: objectSchema(map[string]any{
+				"owner": stringProp("Only workspaces of this owner"),
+				"state": map[string]any{"type": "string", "enum": []string{"active", "paused", "archived", "scratch", "tmp"}, "description": "Only workspaces in this state"},
+				"tag":   stringProp("Only workspaces with this tag"),
+			}),
+			run: s.listWorkspaces,
+		},
+		{
+			name:        "find_workspace",
+			description: "Fuzzy-find workspaces by partial name (e.g. 'api' matches 'acme--api-server') and return their paths.",
+			schema: objectSchema(map[string]any{
+				"query": stringProp("Partial workspace name"),
+				"limit": map[string]any{"type": "integer", "minimum": 1, "description": "Maximum matches (default 5)"},
+			}, "query"),
+			run: s.findWorkspace,
+		},
+		{
+			name:        "search_code",
+			description: "Semantic code search across workspaces indexed with 'co vector index'. Requires Ollama.",
+			schema: objectSchema(map[string]any{
+				"query":     stringProp("Natural language or code to search for"),
+				"workspace": stringProp("Restrict to one workspace slug"),
+				"limit":     map[string]any{"type": "integer", "minimum": 1, "description": "Maximum results (default 10)"},
+			}, "query"),
+			run: s.searchCode,
+		},
+		{
+			name:        "list_templates",
+			description: "List the templates available for creating workspaces.",
+			schema:      objectSchema(map[string]any{}),
+			run:         s.listTemplates,
+		},
+		{
+			name:        "create_workspace",
+			description: "Create a new workspace owner--project, optionally from a template.",
+			schema: objectSchema(map[string]any{
+				"owner":     stringProp("Workspace owner (lowercase, hyphens allowed)"),
+				"project":   stringProp("Project name (lowercase, hyphens allowed)"),
+				"template":  stringProp("Template name from list_templates"),
+				"variables": variablesProp,
+				"dry_run":   map[string]any{"type": "boolean", "description": "Report what would be created without changes"},
+			}, "owner", "project"),
+			run: s.createWorkspace,
+		},
+		{
+			name:        "import_folder",
+			description: "Import an existing folder as a new workspace, moving the git repositories inside it into the workspace.",
+			schema: objectSchema(map[string]any{
+				"source":    stringProp("Absolute path of the folder to import"),
+				"owner":     stringProp("Workspace owner"),
+				"project":   stringProp("Project name"),
+				"template":  stringProp("Template to apply after import"),
+				"variables": variablesProp,
+			}, "source", "owner", "project"),
+			run: s.importFolder,
+		},
+	}
+}
+
+func (s *Server) listWorkspaces(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Owner string `json:"owner"`
+		State string `json:"state"`
+		Tag   string `json:"tag"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	records, err := s.client.List(co.ListOptions{Owner: a.Owner, State: a.State, Tag: a.Tag})
+	if err != nil {
+		return nil, fmt.Errorf("%w (run 'co index' first)", err)
+	}
+	workspaces := make([]workspaceSummary, 0, len(records))
+	for _, r := range records {
+		workspaces = append(workspaces, summarize(r))
+	}
+	return map[string]any{"workspaces": workspaces}, nil
+}
+
+func (s *Server) findWorkspace(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if a.Limit <= 0 {
+		a.Limit = 5
+	}
+	slugs, err := s.client.Find(a.Query, a.Limit)
+	if err != nil {
+		return nil, err
+	}
+	type match struct {
+		Slug string `json:"slug"`
+		Path string `json:"path"`
+	}
+	matches := make([]match, 0, len(slugs))
+	for _, slug := range slugs {
+		matches = append(matches, match{Slug: slug, Path: s.client.Config().WorkspacePath(slug)})
+	}
+	return map[string]any{"matches": matches}, nil
+}
+
+func (s *Server) searchCode(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Query     string `json:"query"`
+		Workspace string `json:"workspace"`
+		Limit     int    `json:"limit"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	results, err := s.client.SearchCode(ctx, a.Query, co.SearchOptions{
+		Limit:          a.Limit,
+		Codebase:       a.Workspace,
+		IncludeContent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	repoDirs := make(map[string]map[string]string) // Codebase -> repo -> path in the workspace
+	for i := range results {
+		r := &results[i]
+		workspacePath := s.client.Config().WorkspacePath(r.Codebase)
+		dirs, ok := repoDirs[r.Codebase]
+		if !ok {
+			dirs = make(map[string]string)
+			repos, _ := model.LocateWorkspaceRepos(workspacePath)
+			for _, repo := range repos {
+				dirs[repo.Name] = filepath.FromSlash(repo.Path)
+			}
+			repoDirs[r.Codebase] = dirs
+		}
+		dir := dirs[r.Repo]
+		if dir == "" {
+			dir = model.RepoDir("", r.Repo)
+		}
+		r.FullPath = filepath.Join(workspacePath, dir, r.FilePath)
+	}
+	if results == nil {
+		results = []co.SearchResult{}
+	}
+	return map[string]any{"results": results}, nil
+}
+
+func (s *Server) listTemplates(ctx context.Context, args json.RawMessage) (any, error) {
+	templates, err := s.client.Templates()
+	if err != nil {
+		return nil, err
+	}
+	if templates == nil {
+		templates = []co.TemplateInfo{}
+	}
+	return map[string]any{"templates": templates}, nil
+}
+
+func (s *Server) createWorkspace(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Owner     string            `json:"owner"`
+		Project   string            `json:"project"`
+		Template  string            `json:"template"`
+		Variables map[string]string `json:"variables"`
+		DryRun    bool              `json:"dry_run"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	result, err := s.client.Create(a.Owner, a.Project, co.CreateOptions{
+		Template:  a.Template,
+		Variables: a.Variables,
+		DryRun:    a.DryRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !a.DryRun {
+		s.client.Reindex()
+	}
+	return result, nil
+}
+
+func (s *Server) importFolder(ctx context.Context, args json.RawMessage) (any, error) {
+	var a struct {
+		Source    string            `json:"source"`
+		Owner     string            `json:"owner"`
+		Project   string            `json:"project"`
+		Template  string            `json:"template"`
+		Variables map[string]string `json:"variables"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(a.Source) {
+		return nil, fmt.Errorf("source must be an absolute path: %s", a.Source)
+	}
+	result, err := s.client.Import(a.Source, co.ImportOptions{
+		Owner:     a.Owner,
+		Project:   a.Project,
+		Template:  a.Template,
+		Variables: a.Variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.client.Reindex()
+	return result, nil
+}
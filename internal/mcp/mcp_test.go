@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/pkg/co"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.CodeRoot = filepath.Join(t.TempDir(), "Code")
+	if err := os.MkdirAll(cfg.CodeRoot, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	return New(co.New(cfg))
+}
+
+// exchange sends messages through Serve and returns the decoded responses.
+func exchange(t *testing.T, s *Server, messages ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	in := strings.NewReader(strings.Join(messages, "\n") + "\n")
+	if err := s.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	var responses []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp map[string]any
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestInitializeAndListTools(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"bogus"}`,
+	)
+
+	// The notification gets no response
+	if len(responses) != 3 {
+		t.Fatalf("got %d responses, want 3", len(responses))
+	}
+
+	init := responses[0]["result"].(map[string]any)
+	if init["protocolVersion"] != "2024-11-05" {
+		t.Errorf("protocolVersion = %v, want the client's 2024-11-05", init["protocolVersion"])
+	}
+
+	tools := responses[1]["result"].(map[string]any)["tools"].([]any)
+	names := make(map[string]bool)
+	for _, tl := range tools {
+		tool := tl.(map[string]any)
+		names[tool["name"].(string)] = true
+		if tool["inputSchema"].(map[string]any)["type"] != "object" {
+			t.Errorf("tool %v schema is not an object", tool["name"])
+		}
+	}
+	for _, want := range []string{"list_workspaces", "find_workspace", "search_code", "list_templates", "create_workspace", "import_folder"} {
+		if !names[want] {
+			t.Errorf("tools/list missing %s", want)
+		}
+	}
+
+	if code := responses[2]["error"].(map[string]any)["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("unknown method error code = %v, want %d", code, codeMethodNotFound)
+	}
+}
+
+func TestCreateFindAndListWorkspaces(t *testing.T) {
+	s := newTestServer(t)
+
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_workspace","arguments":{"owner":"acme","project":"api-server"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"find_workspace","arguments":{"query":"api"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"list_workspaces","arguments":{"owner":"acme"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"create_workspace","arguments":{"owner":"acme","project":"api-server"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"find_workspace","arguments":{"qurey":"api"}}}`,
+	)
+	if len(responses) != 5 {
+		t.Fatalf("got %d responses, want 5", len(responses))
+	}
+
+	result := func(i int) map[string]any { return responses[i]["result"].(map[string]any) }
+
+	if result(0)["isError"] != false {
+		t.Fatalf("create_workspace failed: %v", result(0)["content"])
+	}
+
+	matches := result(1)["structuredContent"].(map[string]any)["matches"].([]any)
+	if len(matches) != 1 || matches[0].(map[string]any)["slug"] != "acme--api-server" {
+		t.Errorf("find_workspace matches = %v, want acme--api-server", matches)
+	}
+
+	workspaces := result(2)["structuredContent"].(map[string]any)["workspaces"].([]any)
+	if len(workspaces) != 1 || workspaces[0].(map[string]any)["slug"] != "acme--api-server" {
+		t.Errorf("list_workspaces = %v, want acme--api-server", workspaces)
+	}
+
+	// Tool failures are results with isError, not protocol errors
+	if result(3)["isError"] != true {
+		t.Errorf("duplicate create should report isError")
+	}
+	text := result(3)["content"].([]any)[0].(map[string]any)["text"].(string)
+	if !strings.Contains(text, "already exists") {
+		t.Errorf("duplicate create text = %q, want already exists", text)
+	}
+
+	if result(4)["isError"] != true {
+		t.Errorf("unknown argument should report isError")
+	}
+}
@@ -0,0 +1,123 @@
+// Package minisign verifies minisign signatures (https://jedisct1.github.io/minisign/),
+// used to check templates installed from a catalog.
+package minisign
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	algLegacy    = "Ed" // Signs the message itself
+	algPrehashed = "ED" // Signs the BLAKE2b-512 hash of the message
+
+	trustedCommentPrefix = "trusted comment: "
+)
+
+// ErrUntrustedKey is returned by Verify for a signature made with none of
+// the given keys.
+var ErrUntrustedKey = errors.New("untrusted key")
+
+// PublicKey is a minisign public key.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// KeyID formats the key ID the way minisign prints it.
+func (k PublicKey) KeyID() string {
+	return formatID(k.ID)
+}
+
+// ParsePublicKey parses a public key: the base64 line of a .pub file, or the
+// whole file including its untrusted comment.
+func ParsePublicKey(s string) (PublicKey, error) {
+	var pk PublicKey
+	line := lastLine(s)
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return pk, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != algLegacy {
+		return pk, errors.New("invalid public key: not a minisign Ed25519 key")
+	}
+	copy(pk.ID[:], raw[2:10])
+	pk.Key = ed25519.PublicKey(raw[10:])
+	return pk, nil
+}
+
+// Signature is a parsed .minisig file.
+type Signature struct {
+	Algorithm      string
+	KeyID          [8]byte
+	Signature      []byte
+	TrustedComment string
+	GlobalSig      []byte
+}
+
+// ParseSignature parses the contents of a .minisig file.
+func ParseSignature(data []byte) (*Signature, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(data), "\r\n", "\n")), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return nil, errors.New("invalid signature: not a minisign signature file")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return nil, errors.New("invalid signature: malformed signature line")
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, errors.New("invalid signature: malformed global signature")
+	}
+	sig := &Signature{
+		Algorithm:      string(raw[:2]),
+		Signature:      raw[10:],
+		TrustedComment: strings.TrimPrefix(lines[2], trustedCommentPrefix),
+		GlobalSig:      global,
+	}
+	copy(sig.KeyID[:], raw[2:10])
+	if sig.Algorithm != algLegacy && sig.Algorithm != algPrehashed {
+		return nil, fmt.Errorf("invalid signature: unsupported algorithm %q", sig.Algorithm)
+	}
+	return sig, nil
+}
+
+// Verify checks that sig signs message with one of keys and returns the key
+// that made it.
+func Verify(message []byte, sig *Signature, keys []PublicKey) (PublicKey, error) {
+	for _, key := range keys {
+		if key.ID != sig.KeyID {
+			continue
+		}
+		signed := message
+		if sig.Algorithm == algPrehashed {
+			sum := blake2b.Sum512(message)
+			signed = sum[:]
+		}
+		if !ed25519.Verify(key.Key, signed, sig.Signature) {
+			return key, errors.New("signature verification failed")
+		}
+		global := append(append([]byte{}, sig.Signature...), sig.TrustedComment...)
+		if !ed25519.Verify(key.Key, global, sig.GlobalSig) {
+			return key, errors.New("trusted comment verification failed")
+		}
+		return key, nil
+	}
+	return PublicKey{}, fmt.Errorf("%w: signed with key %s", ErrUntrustedKey, formatID(sig.KeyID))
+}
+
+// formatID prints a key ID as minisign does: the little-endian integer in hex.
+func formatID(id [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
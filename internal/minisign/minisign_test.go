@@ -0,0 +1,81 @@
+package minisign
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// sign produces a .minisig file and the .pub line for message.
+func sign(t *testing.T, message []byte, alg string) (sig []byte, pub string) {
+	t.Helper()
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	signed := message
+	if alg == algPrehashed {
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	}
+	signature := ed25519.Sign(sk, signed)
+	comment := "timestamp:1700000000\tfile:go-service.tar.gz"
+	global := ed25519.Sign(sk, append(append([]byte{}, signature...), comment...))
+
+	sigLine := append(append([]byte(alg), id...), signature...)
+	sig = []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(sigLine) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+	pub = "untrusted comment: minisign public key 0807060504030201\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte(algLegacy), id...), pk...)) + "\n"
+	return sig, pub
+}
+
+func TestVerify(t *testing.T) {
+	message := []byte("template archive")
+	for _, alg := range []string{algPrehashed, algLegacy} {
+		sigData, pub := sign(t, message, alg)
+		key, err := ParsePublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.KeyID() != "0807060504030201" {
+			t.Errorf("KeyID() = %s", key.KeyID())
+		}
+		sig, err := ParseSignature(sigData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Verify(message, sig, []PublicKey{key}); err != nil {
+			t.Errorf("%s: Verify() error = %v", alg, err)
+		}
+		if _, err := Verify([]byte("tampered"), sig, []PublicKey{key}); err == nil {
+			t.Errorf("%s: Verify() accepted a tampered message", alg)
+		}
+		if _, err := Verify(message, sig, nil); !errors.Is(err, ErrUntrustedKey) {
+			t.Errorf("%s: Verify() without trusted keys = %v, want ErrUntrustedKey", alg, err)
+		}
+
+		sig.TrustedComment = "forged"
+		if _, err := Verify(message, sig, []PublicKey{key}); err == nil {
+			t.Errorf("%s: Verify() accepted a forged trusted comment", alg)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := ParsePublicKey("not base64!"); err == nil {
+		t.Error("ParsePublicKey() accepted garbage")
+	}
+	if _, err := ParseSignature([]byte("untrusted comment: x\nAAAA\n")); err == nil {
+		t.Error("ParseSignature() accepted a truncated file")
+	}
+}
@@ -1,4 +1,5 @@
 // Package model defines the core data structures for code organization:
 // - Project: the project.json schema (minimal truth)
 // - IndexRecord: the index.jsonl record schema (computed observability)
+// - Plan: the dry-run report of actions a command would take
 package model
@@ -2,6 +2,7 @@ package model
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"os"
 	"time"
@@ -21,14 +22,19 @@ type IndexRecord struct {
 	Slug           string          `json:"slug"`
 	Path           string          `json:"path"`
 	Owner          string          `json:"owner"`
+	CreatedBy      string          `json:"created_by,omitempty"`
 	State          ProjectState    `json:"state"`
 	Tags           []string        `json:"tags,omitempty"`
+	Description    string          `json:"description,omitempty"`
+	Icon           string          `json:"icon,omitempty"`
+	DisplayName    string          `json:"display_name,omitempty"`
 	RepoCount      int             `json:"repo_count"`
 	LastCommitAt   *time.Time      `json:"last_commit_at,omitempty"`
 	LastFSChangeAt *time.Time      `json:"last_fs_change_at,omitempty"`
 	DirtyRepos     int             `json:"dirty_repos"`
 	SizeBytes      int64           `json:"size_bytes"`
 	Repos          []IndexRepoInfo `json:"repos"`
+	Violations     []string        `json:"policy_violations,omitempty"` // Repo policy violations, see co policy check
 	Valid          bool            `json:"valid"`
 	Error          string          `json:"error,omitempty"`
 }
@@ -46,6 +52,11 @@ func NewIndexRecord(slug, path string) *IndexRecord {
 	}
 }
 
+// Label returns how the workspace is shown in TUIs; see Label.
+func (r *IndexRecord) Label() string {
+	return Label(r.Icon, r.DisplayName, r.Slug)
+}
+
 func (r *IndexRecord) ToJSON() ([]byte, error) {
 	return json.Marshal(r)
 }
@@ -88,6 +99,37 @@ func LoadIndex(path string) (*Index, error) {
 	return index, nil
 }
 
+// LookupRecord returns the record of slug in the index file at path, or nil
+// when the index has none. Only the matching line is parsed, so it stays fast
+// on large indexes; it is meant for shell prompts and other hot paths.
+func LookupRecord(path, slug string) (*IndexRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	key, err := json.Marshal(slug)
+	if err != nil {
+		return nil, err
+	}
+	needle := append([]byte(`"slug":`), key...)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if !bytes.Contains(scanner.Bytes(), needle) {
+			continue
+		}
+		var record IndexRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil && record.Slug == slug {
+			return &record, nil
+		}
+	}
+	return nil, scanner.Err()
+}
+
 func (idx *Index) Save(path string) error {
 	tmpPath := path + ".tmp"
 	file, err := os.Create(tmpPath)
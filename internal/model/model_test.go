@@ -9,7 +9,7 @@ import (
 )
 
 func TestNewProject(t *testing.T) {
-	p := NewProject("myowner", "myproject")
+	p := NewProject("myowner--myproject", "myowner", "myproject")
 
 	if p.Schema != CurrentProjectSchema {
 		t.Errorf("Schema = %d, want %d", p.Schema, CurrentProjectSchema)
@@ -35,7 +35,7 @@ func TestNewProject(t *testing.T) {
 }
 
 func TestProjectAddRepo(t *testing.T) {
-	p := NewProject("owner", "project")
+	p := NewProject("owner--project", "owner", "project")
 	p.AddRepo("main", "repos/main", "git@github.com:owner/project.git")
 
 	if len(p.Repos) != 1 {
@@ -95,7 +95,7 @@ func TestProjectJSONRoundTrip(t *testing.T) {
 func TestProjectSaveAndLoad(t *testing.T) {
 	tmpDir := t.TempDir()
 
-	original := NewProject("testowner", "testproj")
+	original := NewProject("testowner--testproj", "testowner", "testproj")
 	original.Tags = []string{"test"}
 	original.AddRepo("main", "repos/main", "")
 
@@ -138,6 +138,24 @@ func TestLoadProjectInvalidJSON(t *testing.T) {
 	}
 }
 
+func TestLabel(t *testing.T) {
+	for _, tt := range []struct{ icon, name, want string }{
+		{"", "", "acme--api"},
+		{"🚀", "", "🚀 acme--api"},
+		{"", "Billing API", "Billing API"},
+		{"🇳🇴", "Billing API", "🇳🇴 Billing API"},
+	} {
+		if got := Label(tt.icon, tt.name, "acme--api"); got != tt.want {
+			t.Errorf("Label(%q, %q) = %q, want %q", tt.icon, tt.name, got, tt.want)
+		}
+	}
+	for icon, ok := range map[string]bool{"": true, "🚀": true, "👩\u200d💻": true, "\uf121": true, "a b": false, "toolongicon": false} {
+		if err := ValidIcon(icon); (err == nil) != ok {
+			t.Errorf("ValidIcon(%q) = %v, want ok %v", icon, err, ok)
+		}
+	}
+}
+
 func TestNewIndexRecord(t *testing.T) {
 	r := NewIndexRecord("owner--project", "/path/to/workspace")
 
@@ -303,6 +321,27 @@ func TestIndexSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestLookupRecord(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "index.jsonl")
+	if r, err := LookupRecord(indexPath, "acme--api"); r != nil || err != nil {
+		t.Errorf("LookupRecord() without an index = %v, %v; want nil, nil", r, err)
+	}
+
+	idx := NewIndex()
+	idx.Add(&IndexRecord{Slug: "acme--api-v2", Description: `"slug":"acme--api"`})
+	idx.Add(&IndexRecord{Slug: "acme--api", DirtyRepos: 2})
+	if err := idx.Save(indexPath); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LookupRecord(indexPath, "acme--api")
+	if err != nil || r == nil || r.Slug != "acme--api" || r.DirtyRepos != 2 {
+		t.Errorf("LookupRecord() = %+v, %v; want acme--api with 2 dirty repos", r, err)
+	}
+	if r, err := LookupRecord(indexPath, "acme--web"); r != nil || err != nil {
+		t.Errorf("LookupRecord() of a missing slug = %v, %v; want nil, nil", r, err)
+	}
+}
+
 func TestLoadIndexNotFound(t *testing.T) {
 	idx, err := LoadIndex("/nonexistent/index.jsonl")
 	if err != nil {
@@ -312,3 +351,37 @@ func TestLoadIndexNotFound(t *testing.T) {
 		t.Errorf("len(Records) = %d, want 0", len(idx.Records))
 	}
 }
+
+func TestPlanString(t *testing.T) {
+	plan := NewPlan("Archive workspace acme--app")
+	plan.Add(ActionArchive, "/code/acme--app", "/code/_system/archive/acme--app.tar.gz", "full workspace")
+	plan.Add(ActionDelete, "/code/acme--app", "", "")
+	plan.Skip(ActionMove, "/src/dirty", "", "no owner")
+
+	if got := plan.Count(ActionDelete); got != 1 {
+		t.Errorf("Count(delete) = %d, want 1", got)
+	}
+
+	want := `DRY-RUN: No changes will be made.
+
+Archive workspace acme--app
+
+Planned actions (2):
+  archive  /code/acme--app -> /code/_system/archive/acme--app.tar.gz (full workspace)
+  delete   /code/acme--app
+
+Skipped (1):
+  move     /src/dirty (no owner)
+`
+	if got := plan.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+
+	data, err := json.Marshal(NewPlan("empty"))
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if string(data) != `{"summary":"empty","actions":[]}` {
+		t.Errorf("empty plan JSON = %s", data)
+	}
+}
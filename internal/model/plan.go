@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ActionKind identifies the kind of change a planned action would make.
+type ActionKind string
+
+const (
+	ActionCreate  ActionKind = "create"
+	ActionMove    ActionKind = "move"
+	ActionCopy    ActionKind = "copy"
+	ActionWrite   ActionKind = "write"
+	ActionArchive ActionKind = "archive"
+	ActionDelete  ActionKind = "delete"
+)
+
+// PlannedAction is a single change a command would make.
+type PlannedAction struct {
+	Kind   ActionKind `json:"kind"`
+	Path   string     `json:"path"`
+	Target string     `json:"target,omitempty"`
+	Detail string     `json:"detail,omitempty"`
+}
+
+// Plan is the report produced by a dry run: what a command would do,
+// without having done any of it.
+type Plan struct {
+	Summary string          `json:"summary"`
+	Actions []PlannedAction `json:"actions"`
+	Skipped []PlannedAction `json:"skipped,omitempty"` // Detail holds the reason
+}
+
+// NewPlan creates an empty plan with a one-line summary.
+func NewPlan(summary string) *Plan {
+	return &Plan{Summary: summary, Actions: []PlannedAction{}}
+}
+
+// Add appends an action. Target and detail may be empty.
+func (p *Plan) Add(kind ActionKind, path, target, detail string) {
+	p.Actions = append(p.Actions, PlannedAction{Kind: kind, Path: path, Target: target, Detail: detail})
+}
+
+// Skip records an action that will not be taken, with the reason.
+func (p *Plan) Skip(kind ActionKind, path, target, reason string) {
+	p.Skipped = append(p.Skipped, PlannedAction{Kind: kind, Path: path, Target: target, Detail: reason})
+}
+
+// Count returns the number of actions of the given kind.
+func (p *Plan) Count(kind ActionKind) int {
+	n := 0
+	for _, a := range p.Actions {
+		if a.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// String renders the plan as the human-readable dry-run report shared by the
+// CLI and the TUIs.
+func (p *Plan) String() string {
+	var sb strings.Builder
+	sb.WriteString("DRY-RUN: No changes will be made.\n\n")
+	sb.WriteString(p.Summary + "\n")
+	if len(p.Actions) == 0 {
+		sb.WriteString("\nNothing to do.\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\nPlanned actions (%d):\n", len(p.Actions)))
+		writeActions(&sb, p.Actions)
+	}
+	if len(p.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf("\nSkipped (%d):\n", len(p.Skipped)))
+		writeActions(&sb, p.Skipped)
+	}
+	return sb.String()
+}
+
+func writeActions(sb *strings.Builder, actions []PlannedAction) {
+	for _, a := range actions {
+		line := fmt.Sprintf("  %-8s %s", a.Kind, a.Path)
+		if a.Target != "" {
+			line += " -> " + a.Target
+		}
+		if a.Detail != "" {
+			line += " (" + a.Detail + ")"
+		}
+		sb.WriteString(line + "\n")
+	}
+}
@@ -2,9 +2,18 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tormodhaugland/co/internal/fs"
 )
 
 type ProjectState string
@@ -18,9 +27,10 @@ const (
 )
 
 type RepoSpec struct {
-	Name   string `json:"name"`
-	Path   string `json:"path"`
-	Remote string `json:"remote,omitempty"`
+	Name      string   `json:"name"`
+	Path      string   `json:"path"`
+	Remote    string   `json:"remote,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"` // Names of repos of the workspace this repo needs
 }
 
 // ExcludeConfig represents exclude configuration for sync operations.
@@ -43,31 +53,118 @@ type Project struct {
 	State        ProjectState      `json:"state"`
 	Tags         []string          `json:"tags,omitempty"`
 	Created      string            `json:"created"`
+	CreatedBy    string            `json:"created_by,omitempty"` // User who created the workspace
 	Updated      string            `json:"updated"`
 	Repos        []RepoSpec        `json:"repos"`
 	Notes        string            `json:"notes,omitempty"`
+	Description  string            `json:"description,omitempty"`   // What the workspace is, shown in listings; see co describe
+	Icon         string            `json:"icon,omitempty"`          // Emoji or glyph shown before the name in TUIs and prompts
+	DisplayName  string            `json:"display_name,omitempty"`  // Human name shown instead of the slug
+	Note         string            `json:"note,omitempty"`          // Linked note, absolute or relative to the notes vault
 	Template     string            `json:"template,omitempty"`      // Template used to create workspace
 	TemplateVars map[string]string `json:"template_vars,omitempty"` // Variables used during creation
 	Sync         *SyncConfig       `json:"sync,omitempty"`          // Sync configuration
+
+	// RepoLayout is where new repos of the workspace go: LayoutNested
+	// (the default when empty) or LayoutFlat
+	RepoLayout string `json:"repo_layout,omitempty"`
+}
+
+// Repo layouts of a workspace.
+const (
+	LayoutNested = "nested" // Repos under repos/
+	LayoutFlat   = "flat"   // Repos directly in the workspace folder
+)
+
+// ValidLayout reports whether layout is a known repo layout or empty.
+func ValidLayout(layout string) bool {
+	return layout == "" || layout == LayoutNested || layout == LayoutFlat
+}
+
+// RepoDir returns the path, relative to the workspace, of a repo named name
+// under layout; with an empty name, that of the folder holding the repos.
+func RepoDir(layout, name string) string {
+	if layout == LayoutFlat {
+		return name
+	}
+	return filepath.Join("repos", name)
+}
+
+// Label returns how a workspace is shown in TUIs and prompts: its icon and
+// display name, falling back to the slug when it has no display name.
+func Label(icon, displayName, slug string) string {
+	name := displayName
+	if name == "" {
+		name = slug
+	}
+	if icon == "" {
+		return name
+	}
+	return icon + " " + name
+}
+
+// MaxIconLength caps the icon of a workspace, in runes: enough for emoji
+// sequences such as flags and skin tones, not for words.
+const MaxIconLength = 8
+
+// ValidIcon returns an error when icon cannot be a workspace icon: it has
+// spaces or control characters, or is longer than MaxIconLength runes. An
+// empty icon is valid.
+func ValidIcon(icon string) error {
+	if utf8.RuneCountInString(icon) > MaxIconLength {
+		return fmt.Errorf("icon %q is too long (at most %d characters, such as an emoji)", icon, MaxIconLength)
+	}
+	for _, r := range icon {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("icon %q must not contain spaces", icon)
+		}
+	}
+	return nil
 }
 
 const CurrentProjectSchema = 1
 
-func NewProject(owner, name string) *Project {
+// NewProject returns a new active project for the workspace slug, whose
+// segments are owner and name.
+func NewProject(slug, owner, name string) *Project {
 	now := time.Now().Format("2006-01-02")
 	return &Project{
-		Schema:  CurrentProjectSchema,
-		Slug:    owner + "--" + name,
-		Owner:   owner,
-		Name:    name,
-		State:   StateActive,
-		Tags:    []string{},
-		Created: now,
-		Updated: now,
-		Repos:   []RepoSpec{},
+		Schema:    CurrentProjectSchema,
+		Slug:      slug,
+		Owner:     owner,
+		Name:      name,
+		State:     StateActive,
+		Tags:      []string{},
+		Created:   now,
+		CreatedBy: CurrentUser(),
+		Updated:   now,
+		Repos:     []RepoSpec{},
 	}
 }
 
+// CurrentUser returns the login name of the user running co, or "" when it
+// cannot be determined.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, name := range []string{"USER", "USERNAME"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// CoVersion returns the module version co was built from, or "(devel)" for
+// builds outside a module download.
+func CoVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
 func LoadProject(path string) (*Project, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -91,7 +188,72 @@ func (p *Project) Save(workspacePath string) error {
 	}
 
 	projectPath := filepath.Join(workspacePath, "project.json")
-	return os.WriteFile(projectPath, append(data, '\n'), 0644)
+	return os.WriteFile(projectPath, append(data, '\n'), fs.FilePerm())
+}
+
+// RepoDir returns the path, relative to the workspace, of a new repo named
+// name under the project's layout.
+func (p *Project) RepoDir(name string) string {
+	return RepoDir(p.RepoLayout, name)
+}
+
+// LocateRepos returns the repos of the workspace at workspacePath, sorted by
+// name, with their paths relative to it: the directories under repos/, for
+// the flat layout the git repos directly in the workspace folder, and the
+// repos project.json records at other paths that exist.
+func (p *Project) LocateRepos(workspacePath string) ([]RepoSpec, error) {
+	names, err := fs.ListRepos(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var repos []RepoSpec
+	add := func(name, path string) {
+		if !seen[name] {
+			seen[name] = true
+			repos = append(repos, RepoSpec{Name: name, Path: filepath.ToSlash(path)})
+		}
+	}
+	for _, name := range names {
+		add(name, filepath.Join("repos", name))
+	}
+
+	if p.RepoLayout == LayoutFlat {
+		entries, err := os.ReadDir(workspacePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || e.Name() == "repos" {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(workspacePath, e.Name(), ".git")); err == nil {
+				add(e.Name(), e.Name())
+			}
+		}
+	}
+
+	for _, r := range p.Repos {
+		if r.Path == "" || seen[r.Name] {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(workspacePath, filepath.FromSlash(r.Path))); err == nil && info.IsDir() {
+			add(r.Name, r.Path)
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos, nil
+}
+
+// LocateWorkspaceRepos returns the repos of the workspace at workspacePath
+// as LocateRepos does for its project.json. Without a readable project.json,
+// only the repos under repos/ are found.
+func LocateWorkspaceRepos(workspacePath string) ([]RepoSpec, error) {
+	p, err := LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		p = &Project{}
+	}
+	return p.LocateRepos(workspacePath)
 }
 
 func (p *Project) AddRepo(name, path, remote string) {
@@ -0,0 +1,202 @@
+// Package notes links workspaces to notes kept outside the code root, in an
+// Obsidian vault or a directory of org files.
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/platform"
+)
+
+// ErrNotConfigured is returned when a workspace has no linked note and no
+// notes vault is configured to hold a stub.
+var ErrNotConfigured = errors.New("no notes vault configured (set notes.vault in the config)")
+
+// Path returns the workspace's note: the linked note if there is one,
+// otherwise <vault>/<dir>/<slug>.md (or .org). The file may not exist yet.
+func Path(cfg *config.Config, proj *model.Project) (string, error) {
+	nc := cfg.GetNotesConfig()
+	if proj.Note != "" {
+		if filepath.IsAbs(proj.Note) {
+			return proj.Note, nil
+		}
+		if nc.Vault == "" {
+			return "", fmt.Errorf("linked note %s is relative to the notes vault: %w", proj.Note, ErrNotConfigured)
+		}
+		return filepath.Join(nc.Vault, proj.Note), nil
+	}
+	if nc.Vault == "" {
+		return "", ErrNotConfigured
+	}
+
+	ext := ".md"
+	if nc.Format == "org" {
+		ext = ".org"
+	}
+	name := strings.ReplaceAll(proj.Slug, "/", "_") + ext
+	return filepath.Join(nc.Vault, nc.Dir, name), nil
+}
+
+// Stub writes a note stub for the workspace unless its note already exists,
+// and returns the note path and whether a stub was written.
+func Stub(cfg *config.Config, workspacePath string) (string, bool, error) {
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to load project.json: %w", err)
+	}
+	path, err := Path(cfg, proj)
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, false, nil
+	}
+
+	located, err := proj.LocateRepos(workspacePath)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list repos: %w", err)
+	}
+	repos := make([]string, len(located))
+	for i, repo := range located {
+		repos[i] = repo.Name
+	}
+
+	var content string
+	if strings.HasSuffix(path, ".org") {
+		content = renderOrg(proj, workspacePath, repos)
+	} else {
+		content = renderMarkdown(proj, workspacePath, repos)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), fs.DirPerm()); err != nil {
+		return "", false, err
+	}
+	// O_EXCL so a note created since the Stat above is never overwritten
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fs.FilePerm())
+	if err != nil {
+		if os.IsExist(err) {
+			return path, false, nil
+		}
+		return "", false, err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", false, err
+	}
+	return path, true, f.Close()
+}
+
+// Link records an existing note as the workspace's note in project.json.
+// Notes inside the vault are stored relative to it so the vault can move.
+func Link(cfg *config.Config, workspacePath, notePath string) (string, error) {
+	abs, err := filepath.Abs(notePath)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory, not a note", abs)
+	}
+
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return "", fmt.Errorf("failed to load project.json: %w", err)
+	}
+
+	proj.Note = abs
+	if vault := cfg.GetNotesConfig().Vault; vault != "" {
+		if rel, err := filepath.Rel(vault, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			proj.Note = rel
+		}
+	}
+	if err := proj.Save(workspacePath); err != nil {
+		return "", fmt.Errorf("failed to save project.json: %w", err)
+	}
+	return abs, nil
+}
+
+// OpenCommand returns the command that opens a note: notes.open if set
+// ("obsidian" opens it through an obsidian:// link), else the editor, else
+// the system's default application.
+func OpenCommand(cfg *config.Config, path string) *exec.Cmd {
+	open := cfg.GetNotesConfig().Open
+	switch {
+	case open == "obsidian":
+		return platform.OpenCommand(ObsidianURI(path))
+	case open != "":
+		args := strings.Fields(open)
+		return exec.Command(args[0], append(args[1:], path)...)
+	case cfg.Editor != "":
+		return exec.Command(cfg.Editor, path)
+	default:
+		return platform.OpenCommand(path)
+	}
+}
+
+// ObsidianURI returns the obsidian:// link opening the note at path.
+func ObsidianURI(path string) string {
+	return "obsidian://open?path=" + url.QueryEscape(path)
+}
+
+func renderMarkdown(proj *model.Project, workspacePath string, repos []string) string {
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	sb.WriteString(fmt.Sprintf("workspace: %s\n", proj.Slug))
+	sb.WriteString(fmt.Sprintf("owner: %s\n", proj.Owner))
+	sb.WriteString(fmt.Sprintf("path: %s\n", workspacePath))
+	if len(proj.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("tags: [%s]\n", strings.Join(proj.Tags, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("created: %s\n", proj.Created))
+	sb.WriteString("---\n\n")
+	sb.WriteString(fmt.Sprintf("# %s\n\n", proj.Slug))
+	sb.WriteString(fmt.Sprintf("[Workspace folder](%s)\n", (&url.URL{Scheme: "file", Path: filepath.ToSlash(workspacePath)}).String()))
+	if proj.Notes != "" {
+		sb.WriteString("\n" + proj.Notes + "\n")
+	}
+	if len(repos) > 0 {
+		sb.WriteString("\n## Repositories\n\n")
+		for _, repo := range repos {
+			sb.WriteString(fmt.Sprintf("- %s\n", repo))
+		}
+	}
+	sb.WriteString("\n## Notes\n\n")
+	return sb.String()
+}
+
+func renderOrg(proj *model.Project, workspacePath string, repos []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#+TITLE: %s\n", proj.Slug))
+	if len(proj.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("#+FILETAGS: :%s:\n", strings.Join(proj.Tags, ":")))
+	}
+	sb.WriteString(":PROPERTIES:\n")
+	sb.WriteString(fmt.Sprintf(":WORKSPACE: %s\n", proj.Slug))
+	sb.WriteString(fmt.Sprintf(":OWNER: %s\n", proj.Owner))
+	sb.WriteString(fmt.Sprintf(":CREATED: %s\n", proj.Created))
+	sb.WriteString(":END:\n\n")
+	sb.WriteString(fmt.Sprintf("[[file:%s][Workspace folder]]\n", workspacePath))
+	if proj.Notes != "" {
+		sb.WriteString("\n" + proj.Notes + "\n")
+	}
+	if len(repos) > 0 {
+		sb.WriteString("\n* Repositories\n")
+		for _, repo := range repos {
+			sb.WriteString(fmt.Sprintf("- %s\n", repo))
+		}
+	}
+	sb.WriteString("\n* Notes\n")
+	return sb.String()
+}
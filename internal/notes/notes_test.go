@@ -0,0 +1,92 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func testWorkspace(t *testing.T, format string) (*config.Config, string) {
+	t.Helper()
+	root := t.TempDir()
+	cfg := &config.Config{
+		CodeRoot: filepath.Join(root, "Code"),
+		Notes:    &config.NotesConfig{Vault: filepath.Join(root, "vault"), Format: format},
+	}
+	ws := cfg.WorkspacePath("acme--api")
+	if err := os.MkdirAll(filepath.Join(ws, "repos", "server"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	proj := model.NewProject("acme--api", "acme", "api")
+	proj.Tags = []string{"client"}
+	if err := proj.Save(ws); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	return cfg, ws
+}
+
+func TestStub(t *testing.T) {
+	for _, format := range []string{"markdown", "org"} {
+		cfg, ws := testWorkspace(t, format)
+
+		path, created, err := Stub(cfg, ws)
+		if err != nil {
+			t.Fatalf("%s: Stub() error = %v", format, err)
+		}
+		ext := ".md"
+		if format == "org" {
+			ext = ".org"
+		}
+		if want := filepath.Join(cfg.Notes.Vault, "Workspaces", "acme--api"+ext); path != want || !created {
+			t.Errorf("%s: Stub() = %s, %v, want %s, true", format, path, created, want)
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), "acme--api") || !strings.Contains(string(data), "- server") {
+			t.Errorf("%s: stub =\n%s", format, data)
+		}
+
+		// An existing note is never overwritten
+		os.WriteFile(path, []byte("mine"), 0644)
+		if _, created, err := Stub(cfg, ws); err != nil || created {
+			t.Errorf("%s: second Stub() created = %v, err = %v", format, created, err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "mine" {
+			t.Errorf("%s: note overwritten: %q", format, data)
+		}
+	}
+}
+
+func TestLink(t *testing.T) {
+	cfg, ws := testWorkspace(t, "")
+
+	inVault := filepath.Join(cfg.Notes.Vault, "Projects", "API.md")
+	os.MkdirAll(filepath.Dir(inVault), 0755)
+	os.WriteFile(inVault, []byte("# API"), 0644)
+
+	if _, err := Link(cfg, ws, inVault); err != nil {
+		t.Fatalf("Link() error = %v", err)
+	}
+	proj, _ := model.LoadProject(filepath.Join(ws, "project.json"))
+	if proj.Note != filepath.Join("Projects", "API.md") {
+		t.Errorf("Note = %q, want path relative to the vault", proj.Note)
+	}
+	if path, err := Path(cfg, proj); err != nil || path != inVault {
+		t.Errorf("Path() = %s, %v, want %s", path, err, inVault)
+	}
+	if path, created, _ := Stub(cfg, ws); path != inVault || created {
+		t.Errorf("Stub() with a linked note = %s, %v", path, created)
+	}
+
+	if _, err := Link(cfg, ws, filepath.Join(cfg.Notes.Vault, "missing.md")); err == nil {
+		t.Error("Link() to a missing note should fail")
+	}
+
+	cfg.Notes = nil
+	if _, err := Path(cfg, model.NewProject("acme--web", "acme", "web")); err != ErrNotConfigured {
+		t.Errorf("Path() without a vault error = %v, want ErrNotConfigured", err)
+	}
+}
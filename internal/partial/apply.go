@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/tormodhaugland/co/internal/platform"
 	"github.com/tormodhaugland/co/internal/template"
 )
 
@@ -218,6 +219,9 @@ func resolvePromptActions(plan *FilePlan, vars map[string]string) error {
 			continue
 		}
 
+		if !platform.IsTerminal(os.Stdin) {
+			return fmt.Errorf("%s already exists and stdin is not a terminal to ask; pass --conflict skip, overwrite, or backup, or --yes to skip", file.RelPath)
+		}
 		action, applyToAll, err := promptForConflict(*file, vars)
 		if err != nil {
 			return err
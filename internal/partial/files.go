@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/template"
 )
 
@@ -143,7 +144,7 @@ func determineAction(relPath string, existsInTarget, isPreserved bool, strategy
 func CopyFile(src, dest string, mode os.FileMode) error {
 	// Create parent directories
 	destDir := filepath.Dir(dest)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := os.MkdirAll(destDir, fs.DirPerm()); err != nil {
 		return fmt.Errorf("creating directory %s: %w", destDir, err)
 	}
 
@@ -181,7 +182,7 @@ func ProcessFile(srcPath, destPath string, isTemplate bool, vars map[string]stri
 
 	// Ensure destination directory exists
 	destDir := filepath.Dir(destPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := os.MkdirAll(destDir, fs.DirPerm()); err != nil {
 		return &FileProcessingError{SrcPath: srcPath, DestPath: destPath, Err: fmt.Errorf("creating directory: %w", err)}
 	}
 
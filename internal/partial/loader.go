@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/template"
 )
 
@@ -342,7 +343,7 @@ func countPartialFiles(partialPath string) int {
 
 // EnsurePartialsDir creates the partials directory if it doesn't exist.
 func EnsurePartialsDir(partialsDir string) error {
-	if err := os.MkdirAll(partialsDir, 0755); err != nil {
+	if err := os.MkdirAll(partialsDir, fs.DirPerm()); err != nil {
 		return fmt.Errorf("creating partials directory: %w", err)
 	}
 	return nil
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/tormodhaugland/co/internal/fs"
 	"gopkg.in/yaml.v3"
 )
 
@@ -112,7 +113,7 @@ func MergeFile(existingPath, partialPath, destPath string) error {
 		return fmt.Errorf("stat existing file: %w", err)
 	}
 
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(destPath), fs.DirPerm()); err != nil {
 		return fmt.Errorf("creating destination directory: %w", err)
 	}
 
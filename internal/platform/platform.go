@@ -0,0 +1,81 @@
+// Package platform provides OS-specific helpers for revealing paths in the
+// system file manager, starting shells, and detecting terminals.
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mattn/go-isatty"
+)
+
+// RevealCommand returns a command that shows path in the system file manager.
+// On macOS and Windows the item is selected in its parent folder; elsewhere the
+// containing directory is opened with xdg-open.
+func RevealCommand(path string) *exec.Cmd {
+	return revealCommand(runtime.GOOS, path)
+}
+
+func revealCommand(goos, path string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", "-R", path)
+	case "windows":
+		return exec.Command("explorer", "/select,"+path)
+	default:
+		dir := path
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			dir = filepath.Dir(path)
+		}
+		return exec.Command("xdg-open", dir)
+	}
+}
+
+// OpenCommand returns a command that opens path, or a URL, with its default
+// application.
+func OpenCommand(path string) *exec.Cmd {
+	return openCommand(runtime.GOOS, path)
+}
+
+func openCommand(goos, path string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", path)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		return exec.Command("xdg-open", path)
+	}
+}
+
+// Reveal shows path in the system file manager without waiting for it to exit.
+func Reveal(path string) error {
+	return RevealCommand(path).Start()
+}
+
+// ShellCommand returns an interactive shell command that starts in dir.
+// The user's $SHELL is preferred, falling back to %COMSPEC% on Windows and /bin/sh elsewhere.
+func ShellCommand(dir string) *exec.Cmd {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		if runtime.GOOS == "windows" {
+			shell = os.Getenv("COMSPEC")
+			if shell == "" {
+				shell = "cmd.exe"
+			}
+		} else {
+			shell = "/bin/sh"
+		}
+	}
+	cmd := exec.Command(shell)
+	cmd.Dir = dir
+	return cmd
+}
+
+// IsTerminal reports whether f is a terminal rather than a pipe, a file, or
+// /dev/null, including Cygwin and MSYS terminals on Windows.
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
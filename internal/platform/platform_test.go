@@ -0,0 +1,86 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRevealCommand(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "notes.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	tests := []struct {
+		goos string
+		path string
+		want string
+	}{
+		{"darwin", file, "open -R " + file},
+		{"windows", file, "explorer /select," + file},
+		{"linux", file, "xdg-open " + tmp},
+		{"linux", tmp, "xdg-open " + tmp},
+	}
+
+	for _, tt := range tests {
+		cmd := revealCommand(tt.goos, tt.path)
+		got := strings.Join(append([]string{filepath.Base(cmd.Path)}, cmd.Args[1:]...), " ")
+		if got != tt.want {
+			t.Errorf("revealCommand(%q, %q) = %q, want %q", tt.goos, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOpenCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"darwin", "open obsidian://open?path=x"},
+		{"windows", "rundll32 url.dll,FileProtocolHandler obsidian://open?path=x"},
+		{"linux", "xdg-open obsidian://open?path=x"},
+	}
+
+	for _, tt := range tests {
+		cmd := openCommand(tt.goos, "obsidian://open?path=x")
+		got := strings.Join(append([]string{filepath.Base(cmd.Path)}, cmd.Args[1:]...), " ")
+		if got != tt.want {
+			t.Errorf("openCommand(%q) = %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	t.Setenv("SHELL", "/bin/zsh")
+
+	cmd := ShellCommand("/tmp/work")
+	if cmd.Args[0] != "/bin/zsh" {
+		t.Errorf("shell = %q, want /bin/zsh", cmd.Args[0])
+	}
+	if cmd.Dir != "/tmp/work" {
+		t.Errorf("Dir = %q, want /tmp/work", cmd.Dir)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	for _, f := range []*os.File{r, w, devNull} {
+		if IsTerminal(f) {
+			t.Errorf("IsTerminal(%s) = true, want false", f.Name())
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package plugin finds co plugins: executables named co-<name> on PATH, run
+// as 'co <name>' the way git runs git-<name>. A plugin may ship a manifest,
+// co-<name>.json next to the executable, describing itself and the actions it
+// adds to the dashboard.
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// Prefix starts the file name of every plugin executable.
+const Prefix = "co-"
+
+// Manifest describes a plugin. Every field is optional.
+type Manifest struct {
+	Short   string   `json:"short,omitempty"`   // One-line description shown in 'co help'
+	Actions []Action `json:"actions,omitempty"` // Actions added to the dashboard's plugin menu
+}
+
+// Action is a dashboard action contributed by a plugin. Selecting it runs the
+// plugin with Args, where {{SLUG}}, {{OWNER}} and {{PATH}} are replaced with
+// the selected workspace.
+type Action struct {
+	Key   string   `json:"key,omitempty"` // Single key that runs the action from the menu
+	Label string   `json:"label"`
+	Args  []string `json:"args,omitempty"`
+}
+
+// Plugin is an executable found on PATH.
+type Plugin struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Manifest Manifest `json:"manifest"`
+}
+
+// Discover returns the plugins on pathList, a PATH-style list of
+// directories, sorted by name. As with command lookup, the first directory
+// holding a name wins.
+func Discover(pathList string) []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] || entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path, Manifest: loadManifest(path)})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the plugin name of an executable file name.
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, Prefix) || strings.HasSuffix(file, ".json") {
+		return "", false
+	}
+	name := strings.TrimPrefix(file, Prefix)
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(name)
+		if !strings.EqualFold(ext, ".exe") {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name, name != ""
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode()&0111 != 0
+}
+
+// loadManifest reads the manifest next to the executable at path. A missing
+// or invalid manifest gives an empty one.
+func loadManifest(path string) Manifest {
+	var m Manifest
+	data, err := os.ReadFile(strings.TrimSuffix(path, ".exe") + ".json")
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}
+	}
+	return m
+}
+
+// Command returns the command running the plugin with args. The plugin gets
+// CO_CODE_ROOT, and CO_CONFIG when a config file was given.
+func (p Plugin) Command(cfg *config.Config, cfgFile string, args ...string) *exec.Cmd {
+	cmd := exec.Command(p.Path, args...)
+	cmd.Env = append(os.Environ(), "CO_CODE_ROOT="+cfg.CodeRoot)
+	if cfgFile != "" {
+		cmd.Env = append(cmd.Env, "CO_CONFIG="+cfgFile)
+	}
+	return cmd
+}
+
+// ActionCommand returns the command running action for the workspace r. On
+// top of the Command environment, the plugin gets CO_WORKSPACE and
+// CO_WORKSPACE_PATH.
+func (p Plugin) ActionCommand(cfg *config.Config, action Action, r *model.IndexRecord) *exec.Cmd {
+	replacer := strings.NewReplacer("{{SLUG}}", r.Slug, "{{OWNER}}", r.Owner, "{{PATH}}", r.Path)
+	args := make([]string, len(action.Args))
+	for i, arg := range action.Args {
+		args[i] = replacer.Replace(arg)
+	}
+	cmd := p.Command(cfg, "", args...)
+	cmd.Env = append(cmd.Env, "CO_WORKSPACE="+r.Slug, "CO_WORKSPACE_PATH="+r.Path)
+	cmd.Dir = r.Path
+	return cmd
+}
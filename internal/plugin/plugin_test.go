@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins on Windows are .exe files")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(first, "co-deploy"), "#!/bin/sh\n", 0755)
+	writeFile(t, filepath.Join(first, "co-deploy.json"), `{"short": "Deploy", "actions": [{"key": "d", "label": "Deploy", "args": ["run", "{{SLUG}}"]}]}`, 0644)
+	writeFile(t, filepath.Join(first, "co-notes.txt"), "not executable", 0644)
+	writeFile(t, filepath.Join(first, "other"), "#!/bin/sh\n", 0755)
+	writeFile(t, filepath.Join(second, "co-deploy"), "#!/bin/sh\n", 0755)
+	writeFile(t, filepath.Join(second, "co-audit"), "#!/bin/sh\n", 0755)
+
+	plugins := Discover(first + string(os.PathListSeparator) + second)
+	if len(plugins) != 2 {
+		t.Fatalf("Discover() = %+v, want audit and deploy", plugins)
+	}
+	if plugins[0].Name != "audit" || plugins[1].Name != "deploy" {
+		t.Errorf("names = %s, %s; want audit, deploy", plugins[0].Name, plugins[1].Name)
+	}
+	deploy := plugins[1]
+	if deploy.Path != filepath.Join(first, "co-deploy") {
+		t.Errorf("deploy path = %s, want the first on PATH", deploy.Path)
+	}
+	if deploy.Manifest.Short != "Deploy" || len(deploy.Manifest.Actions) != 1 {
+		t.Errorf("deploy manifest = %+v", deploy.Manifest)
+	}
+}
+
+func TestActionCommand(t *testing.T) {
+	p := Plugin{Name: "deploy", Path: "/usr/local/bin/co-deploy"}
+	action := Action{Label: "Deploy", Args: []string{"run", "{{OWNER}}/{{SLUG}}", "--dir={{PATH}}"}}
+	r := &model.IndexRecord{Slug: "acme--app", Owner: "acme", Path: "/code/acme--app"}
+
+	cmd := p.ActionCommand(&config.Config{CodeRoot: "/code"}, action, r)
+	want := []string{"/usr/local/bin/co-deploy", "run", "acme/acme--app", "--dir=/code/acme--app"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("Args = %v, want %v", cmd.Args, want)
+	}
+	if cmd.Dir != r.Path {
+		t.Errorf("Dir = %s, want %s", cmd.Dir, r.Path)
+	}
+	env := cmd.Env[len(cmd.Env)-3:]
+	wantEnv := []string{"CO_CODE_ROOT=/code", "CO_WORKSPACE=acme--app", "CO_WORKSPACE_PATH=/code/acme--app"}
+	if !reflect.DeepEqual(env, wantEnv) {
+		t.Errorf("Env tail = %v, want %v", env, wantEnv)
+	}
+}
@@ -0,0 +1,280 @@
+// Package quarantine implements co's safe-delete mode. When enabled in the
+// config, deletes move folders into <code_root>/_system/quarantine instead of
+// removing them, and quarantined items are purged once they are older than the
+// configured number of days.
+//
+// Each quarantined item lives in its own directory named
+// <timestamp>--<name>, holding only the item under its original name. Its
+// metadata, recording where it came from and its size, is kept apart in
+// .meta/<timestamp>--<name>.json, so listing quarantine reads no item
+// contents and no item name can clash with it.
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// Entry describes a quarantined item.
+type Entry struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	PurgeAt      time.Time `json:"purge_at"`
+	Path         string    `json:"path"` // Item inside the quarantine directory
+	Size         int64     `json:"size"`
+}
+
+// Expired reports whether the entry is due to be purged.
+func (e Entry) Expired(now time.Time) bool {
+	return !now.Before(e.PurgeAt)
+}
+
+// meta is the on-disk record; PurgeAt is derived from the current config.
+type meta struct {
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	Size         int64     `json:"size"`
+}
+
+// metaDir is the directory in quarantine holding the metadata of entries.
+// Entry IDs start with a timestamp, so none is named like it.
+const metaDir = ".meta"
+
+// metaPath returns the path of the metadata of the entry id.
+func metaPath(cfg *config.Config, id string) string {
+	return filepath.Join(cfg.QuarantineDir(), metaDir, id+".json")
+}
+
+// remove deletes the entry id and its metadata.
+func remove(cfg *config.Config, id string) error {
+	if err := os.RemoveAll(filepath.Join(cfg.QuarantineDir(), id)); err != nil {
+		return err
+	}
+	if err := os.Remove(metaPath(cfg, id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Enabled reports whether deletes should go to quarantine.
+func Enabled(cfg *config.Config) bool {
+	return cfg.GetQuarantineConfig().Enabled
+}
+
+// Delete removes path, or moves it to quarantine when quarantine is enabled.
+// Expired quarantine entries are purged along the way. It returns the entry
+// when the item was quarantined and nil when it was removed outright.
+func Delete(cfg *config.Config, path string) (*Entry, error) {
+	if cfg == nil || !Enabled(cfg) {
+		return nil, os.RemoveAll(path)
+	}
+	entry, err := Move(cfg, path)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = PurgeExpired(cfg)
+	return entry, nil
+}
+
+// PlanDelete records the action Delete would take for path in a dry-run plan.
+func PlanDelete(cfg *config.Config, plan *model.Plan, path string) {
+	if cfg == nil || !Enabled(cfg) {
+		plan.Add(model.ActionDelete, path, "", "")
+		return
+	}
+	days := cfg.GetQuarantineConfig().Days
+	plan.Add(model.ActionMove, path, cfg.QuarantineDir(), fmt.Sprintf("quarantine, purged after %d days", days))
+}
+
+// Move moves path into quarantine regardless of whether quarantine is enabled.
+func Move(cfg *config.Config, path string) (*Entry, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(cfg.QuarantineDir(), metaDir), fs.DirPerm()); err != nil {
+		return nil, fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	now := time.Now()
+	name := filepath.Base(abs)
+	base := now.Format("20060102-150405") + "--" + name
+	id := base
+	dir := filepath.Join(cfg.QuarantineDir(), id)
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, fs.DirPerm())
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create quarantine entry: %w", err)
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+		dir = filepath.Join(cfg.QuarantineDir(), id)
+	}
+
+	size, _ := fs.DirSize(abs)
+	m := meta{Name: name, OriginalPath: abs, DeletedAt: now.UTC(), Size: size}
+	data, _ := json.MarshalIndent(m, "", "  ")
+	if err := os.WriteFile(metaPath(cfg, id), data, fs.FilePerm()); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write quarantine metadata: %w", err)
+	}
+
+	if same, err := fs.SameDevice(abs, dir); err == nil && !same {
+		if err := fs.CheckFreeSpace(dir, size); err != nil {
+			remove(cfg, id)
+			return nil, err
+		}
+	}
+	if err := workspace.MoveDir(abs, filepath.Join(dir, name)); err != nil {
+		remove(cfg, id)
+		return nil, fmt.Errorf("failed to move %s to quarantine: %w", abs, err)
+	}
+
+	entry := newEntry(cfg, id, m)
+	return &entry, nil
+}
+
+// List returns all quarantined items, oldest first.
+func List(cfg *config.Config) ([]Entry, error) {
+	dirs, err := os.ReadDir(cfg.QuarantineDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, d := range dirs {
+		if !d.IsDir() || d.Name() == metaDir {
+			continue
+		}
+		entry, err := load(cfg, d.Name())
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DeletedAt.Before(entries[j].DeletedAt) })
+	return entries, nil
+}
+
+// Get returns the quarantined item with the given ID.
+func Get(cfg *config.Config, id string) (*Entry, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid quarantine id: %q", id)
+	}
+	entry, err := load(cfg, id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no quarantined item with id %s", id)
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Restore moves a quarantined item back to dest, or to its original path
+// when dest is empty. It refuses to overwrite an existing path.
+func Restore(cfg *config.Config, id, dest string) (*Entry, error) {
+	entry, err := Get(cfg, id)
+	if err != nil {
+		return nil, err
+	}
+	if dest == "" {
+		dest = entry.OriginalPath
+	}
+	if dest, err = filepath.Abs(dest); err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(dest); err == nil {
+		return nil, fmt.Errorf("cannot restore to %s: path already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), fs.DirPerm()); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(dest), err)
+	}
+	if err := workspace.MoveDir(entry.Path, dest); err != nil {
+		return nil, fmt.Errorf("failed to restore %s: %w", id, err)
+	}
+	if err := remove(cfg, id); err != nil {
+		return nil, err
+	}
+	entry.Path = dest
+	return entry, nil
+}
+
+// Purge permanently removes a quarantined item.
+func Purge(cfg *config.Config, id string) (*Entry, error) {
+	entry, err := Get(cfg, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := remove(cfg, id); err != nil {
+		return nil, fmt.Errorf("failed to purge %s: %w", id, err)
+	}
+	return entry, nil
+}
+
+// PurgeExpired permanently removes every item older than the configured
+// retention and returns the purged entries.
+func PurgeExpired(cfg *config.Config) ([]Entry, error) {
+	entries, err := List(cfg)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var purged []Entry
+	for _, entry := range entries {
+		if !entry.Expired(now) {
+			continue
+		}
+		if _, err := Purge(cfg, entry.ID); err != nil {
+			return purged, err
+		}
+		purged = append(purged, entry)
+	}
+	return purged, nil
+}
+
+func load(cfg *config.Config, id string) (*Entry, error) {
+	data, err := os.ReadFile(metaPath(cfg, id))
+	if err != nil {
+		return nil, err
+	}
+	var m meta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid quarantine metadata for %s: %w", id, err)
+	}
+	entry := newEntry(cfg, id, m)
+	return &entry, nil
+}
+
+func newEntry(cfg *config.Config, id string, m meta) Entry {
+	days := cfg.GetQuarantineConfig().Days
+	return Entry{
+		ID:           id,
+		Name:         m.Name,
+		OriginalPath: m.OriginalPath,
+		DeletedAt:    m.DeletedAt,
+		PurgeAt:      m.DeletedAt.Add(time.Duration(days) * 24 * time.Hour),
+		Path:         filepath.Join(cfg.QuarantineDir(), id, m.Name),
+		Size:         m.Size,
+	}
+}
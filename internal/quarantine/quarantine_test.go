@@ -0,0 +1,160 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+)
+
+func testConfig(t *testing.T, enabled bool) (*config.Config, string) {
+	t.Helper()
+	root := t.TempDir()
+	cfg := &config.Config{
+		CodeRoot:   filepath.Join(root, "Code"),
+		Quarantine: &config.QuarantineConfig{Enabled: enabled, Days: 7},
+	}
+	src := filepath.Join(root, "src", "scratch")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "notes.txt"), []byte("keep me"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return cfg, src
+}
+
+func TestDeleteDisabledRemoves(t *testing.T) {
+	cfg, src := testConfig(t, false)
+
+	entry, err := Delete(cfg, src)
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if entry != nil {
+		t.Errorf("Delete() entry = %+v, want nil when quarantine is disabled", entry)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("%s should be removed", src)
+	}
+	if _, err := os.Stat(cfg.QuarantineDir()); !os.IsNotExist(err) {
+		t.Errorf("quarantine directory should not be created")
+	}
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	cfg, src := testConfig(t, true)
+
+	entry, err := Delete(cfg, src)
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if entry == nil {
+		t.Fatal("Delete() entry = nil, want quarantined entry")
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("%s should be moved away", src)
+	}
+	if want := entry.DeletedAt.Add(7 * 24 * time.Hour); !entry.PurgeAt.Equal(want) {
+		t.Errorf("PurgeAt = %v, want %v", entry.PurgeAt, want)
+	}
+
+	entries, err := List(cfg)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != entry.ID || entries[0].OriginalPath != src || entries[0].Size != 7 {
+		t.Fatalf("List() = %+v", entries)
+	}
+
+	// Restoring never overwrites
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := Restore(cfg, entry.ID, ""); err == nil {
+		t.Fatal("Restore() over an existing path should fail")
+	}
+	os.Remove(src)
+
+	if _, err := Restore(cfg, entry.ID, ""); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(src, "notes.txt"))
+	if err != nil || string(data) != "keep me" {
+		t.Errorf("restored content = %q, %v", data, err)
+	}
+	if entries, _ := List(cfg); len(entries) != 0 {
+		t.Errorf("List() after restore = %+v, want empty", entries)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	cfg, src := testConfig(t, true)
+
+	old, err := Move(cfg, src)
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	// Backdate the first entry past the retention period
+	data, _ := json.Marshal(meta{Name: old.Name, OriginalPath: old.OriginalPath, DeletedAt: time.Now().Add(-8 * 24 * time.Hour)})
+	if err := os.WriteFile(metaPath(cfg, old.ID), data, 0644); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	recent, err := Move(cfg, src)
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if recent.ID == old.ID {
+		t.Fatalf("Move() reused id %s", old.ID)
+	}
+
+	purged, err := PurgeExpired(cfg)
+	if err != nil {
+		t.Fatalf("PurgeExpired() error = %v", err)
+	}
+	if len(purged) != 1 || purged[0].ID != old.ID {
+		t.Fatalf("PurgeExpired() = %+v, want only %s", purged, old.ID)
+	}
+	entries, _ := List(cfg)
+	if len(entries) != 1 || entries[0].ID != recent.ID {
+		t.Errorf("List() = %+v, want only %s", entries, recent.ID)
+	}
+
+	if _, err := Get(cfg, "../escape"); err == nil {
+		t.Error("Get() with a path should fail")
+	}
+}
+
+func TestMoveKeepsMetadataApart(t *testing.T) {
+	cfg, src := testConfig(t, true)
+	// An item may have any name, including that of a metadata file
+	item := filepath.Join(src, "quarantine.json")
+	if err := os.WriteFile(item, []byte("{}"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	entry, err := Move(cfg, item)
+	if err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	entries, err := List(cfg)
+	if err != nil || len(entries) != 1 || entries[0].Name != "quarantine.json" || entries[0].Size != 2 {
+		t.Fatalf("List() = %+v, %v", entries, err)
+	}
+	if _, err := Restore(cfg, entry.ID, ""); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if data, err := os.ReadFile(item); err != nil || string(data) != "{}" {
+		t.Errorf("restored content = %q, %v", data, err)
+	}
+	if _, err := os.Stat(metaPath(cfg, entry.ID)); !os.IsNotExist(err) {
+		t.Errorf("metadata should be removed with the entry, stat error = %v", err)
+	}
+}
@@ -0,0 +1,423 @@
+// Package script runs the co script: a Starlark file (default
+// _system/co.star) holding user rules and event hooks. Scripts cannot read
+// files or run processes; they see workspaces and templates through the
+// predeclared co module.
+//
+// A script may define any of these functions:
+//
+//	classify(source)              suggestions for co import: a dict with
+//	                              "owner", "project", and "template", or None
+//	validate_name(owner, project) an error message, or None when allowed
+//	validate(ws)                  a list of problems with the workspace
+//	on_create(ws)                 run before project.json is written; may
+//	on_import(ws)                 return a dict with "tags" to add and a
+//	on_archive(ws)                new "state"
+package script
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Functions lists the functions co calls when a script defines them.
+var Functions = []string{"classify", "validate_name", "validate", "on_create", "on_import", "on_archive"}
+
+// maxSteps bounds the work of a single call so a runaway script cannot hang co.
+const maxSteps = 10_000_000
+
+// Script is a loaded co script. A nil *Script has no functions.
+type Script struct {
+	cfg     *config.Config
+	path    string
+	globals starlark.StringDict
+}
+
+// Classification holds the import suggestions returned by classify.
+type Classification struct {
+	Owner    string `json:"owner,omitempty"`
+	Project  string `json:"project,omitempty"`
+	Template string `json:"template,omitempty"`
+}
+
+// Load runs the script at cfg.ScriptPath(). It returns nil and no error when
+// there is no script.
+func Load(cfg *config.Config) (*Script, error) {
+	path := cfg.ScriptPath()
+	src, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	s := &Script{cfg: cfg, path: path}
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, s.thread(), path, src, starlark.StringDict{"co": s.module()})
+	if err != nil {
+		return nil, s.wrap(err)
+	}
+	s.globals = globals
+	return s, nil
+}
+
+// Path returns the file the script was loaded from.
+func (s *Script) Path() string {
+	if s == nil {
+		return ""
+	}
+	return s.path
+}
+
+// Has reports whether the script defines the function name.
+func (s *Script) Has(name string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.globals[name].(starlark.Callable)
+	return ok
+}
+
+// Classify calls classify for an import of sourcePath holding the git
+// repositories gitRoots. It returns nil when the script has no classify.
+func (s *Script) Classify(sourcePath string, gitRoots []string) (*Classification, error) {
+	if !s.Has("classify") {
+		return nil, nil
+	}
+	repos := make([]starlark.Value, len(gitRoots))
+	for i, root := range gitRoots {
+		remote := ""
+		if info, err := git.GetInfo(root); err == nil {
+			remote = info.Remote
+		}
+		repos[i] = repoValue(workspace.DeriveRepoName(root, sourcePath), root, remote)
+	}
+	source := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"path":  starlark.String(sourcePath),
+		"name":  starlark.String(filepath.Base(sourcePath)),
+		"repos": starlark.NewList(repos),
+	})
+
+	v, err := s.call("classify", source)
+	if err != nil || v == starlark.None {
+		return nil, err
+	}
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("classify must return a dict or None, got %s", v.Type())
+	}
+	c := &Classification{}
+	for key, field := range map[string]*string{"owner": &c.Owner, "project": &c.Project, "template": &c.Template} {
+		if *field, err = dictString(d, key); err != nil {
+			return nil, fmt.Errorf("classify: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// CheckName calls validate_name and returns its message as an error.
+func (s *Script) CheckName(owner, project string) error {
+	if !s.Has("validate_name") {
+		return nil
+	}
+	v, err := s.call("validate_name", starlark.String(owner), starlark.String(project))
+	if err != nil || v == starlark.None {
+		return err
+	}
+	msg, ok := starlark.AsString(v)
+	if !ok {
+		return fmt.Errorf("validate_name must return a string or None, got %s", v.Type())
+	}
+	if msg == "" {
+		return nil
+	}
+	return errors.New(msg)
+}
+
+// Validate calls validate for the workspace proj at path and returns the
+// problems it reports.
+func (s *Script) Validate(proj *model.Project, path string) ([]string, error) {
+	if !s.Has("validate") {
+		return nil, nil
+	}
+	v, err := s.call("validate", workspaceValue(proj, path))
+	if err != nil || v == starlark.None {
+		return nil, err
+	}
+	problems, err := stringList(v)
+	if err != nil {
+		return nil, fmt.Errorf("validate: %w", err)
+	}
+	return problems, nil
+}
+
+// OnEvent calls the on_<event> hook for proj at path and applies the tags
+// and state it returns to proj.
+func (s *Script) OnEvent(event string, proj *model.Project, path string) error {
+	name := "on_" + event
+	if !s.Has(name) {
+		return nil
+	}
+	v, err := s.call(name, workspaceValue(proj, path))
+	if err != nil || v == starlark.None {
+		return err
+	}
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return fmt.Errorf("%s must return a dict or None, got %s", name, v.Type())
+	}
+
+	if tags, found, _ := d.Get(starlark.String("tags")); found {
+		list, err := stringList(tags)
+		if err != nil {
+			return fmt.Errorf("%s: tags: %w", name, err)
+		}
+		for _, tag := range list {
+			if !slices.Contains(proj.Tags, tag) {
+				proj.Tags = append(proj.Tags, tag)
+			}
+		}
+	}
+	state, err := dictString(d, "state")
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if state != "" {
+		if !validState(model.ProjectState(state)) {
+			return fmt.Errorf("%s: unknown state %q", name, state)
+		}
+		proj.State = model.ProjectState(state)
+	}
+	return nil
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]*Script)
+)
+
+// loadCached loads the script once per script path.
+func loadCached(cfg *config.Config) (*Script, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	path := cfg.ScriptPath()
+	if s, ok := cache[path]; ok {
+		return s, nil
+	}
+	s, err := Load(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cache[path] = s
+	return s, nil
+}
+
+// Register installs the script's validate_name and event hooks as the
+// workspace package's rules.
+func Register() {
+	workspace.RegisterRules(workspace.Rules{
+		CheckName: func(cfg *config.Config, owner, project string) error {
+			s, err := loadCached(cfg)
+			if err != nil {
+				return err
+			}
+			return s.CheckName(owner, project)
+		},
+		OnEvent: func(cfg *config.Config, event string, proj *model.Project, path string) error {
+			s, err := loadCached(cfg)
+			if err != nil {
+				return err
+			}
+			return s.OnEvent(event, proj, path)
+		},
+	})
+}
+
+func (s *Script) thread() *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: "co",
+		Print: func(_ *starlark.Thread, msg string) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(s.path), msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	return thread
+}
+
+func (s *Script) call(name string, args ...starlark.Value) (starlark.Value, error) {
+	v, err := starlark.Call(s.thread(), s.globals[name], starlark.Tuple(args), nil)
+	if err != nil {
+		return nil, s.wrap(err)
+	}
+	return v, nil
+}
+
+// wrap adds the script position to evaluation errors, which carry it only in
+// their call stack.
+func (s *Script) wrap(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) && len(evalErr.CallStack) > 0 {
+		return fmt.Errorf("%s: %s", evalErr.CallStack.At(0).Pos, evalErr.Msg)
+	}
+	return err
+}
+
+// module returns the co module, the script's only view of co.
+func (s *Script) module() *starlarkstruct.Module {
+	return &starlarkstruct.Module{
+		Name: "co",
+		Members: starlark.StringDict{
+			"workspaces": starlark.NewBuiltin("co.workspaces", s.workspaces),
+			"workspace":  starlark.NewBuiltin("co.workspace", s.workspace),
+			"exists":     starlark.NewBuiltin("co.exists", s.exists),
+			"slug":       starlark.NewBuiltin("co.slug", s.slug),
+			"templates":  starlark.NewBuiltin("co.templates", s.templates),
+		},
+	}
+}
+
+// workspaces returns the slugs of all workspaces.
+func (s *Script) workspaces(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	slugs, err := workspace.ListWorkspaces(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return stringsValue(slugs), nil
+}
+
+// workspace returns the workspace with the given slug, or None.
+func (s *Script) workspace(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var slug string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &slug); err != nil {
+		return nil, err
+	}
+	path := s.cfg.WorkspacePath(slug)
+	proj, err := model.LoadProject(filepath.Join(path, "project.json"))
+	if err != nil {
+		return starlark.None, nil
+	}
+	return workspaceValue(proj, path), nil
+}
+
+func (s *Script) exists(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var slug string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &slug); err != nil {
+		return nil, err
+	}
+	return starlark.Bool(workspace.Exists(s.cfg, slug)), nil
+}
+
+// slug formats owner and project with the configured slug scheme.
+func (s *Script) slug(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var owner, project string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &owner, &project); err != nil {
+		return nil, err
+	}
+	return starlark.String(workspace.SchemeFor(s.cfg).Format(owner, project)), nil
+}
+
+// templates returns the names of the available templates.
+func (s *Script) templates(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	templates, err := template.ListTemplatesMulti(s.cfg.AllTemplatesDirs())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(templates))
+	for i, t := range templates {
+		names[i] = t.Name
+	}
+	return stringsValue(names), nil
+}
+
+// workspaceValue returns the struct scripts see for a workspace.
+func workspaceValue(proj *model.Project, path string) starlark.Value {
+	repos := make([]starlark.Value, len(proj.Repos))
+	for i, r := range proj.Repos {
+		repos[i] = repoValue(r.Name, filepath.Join(path, r.Path), r.Remote)
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"slug":     starlark.String(proj.Slug),
+		"owner":    starlark.String(proj.Owner),
+		"project":  starlark.String(proj.Name),
+		"path":     starlark.String(path),
+		"state":    starlark.String(proj.State),
+		"tags":     stringsValue(proj.Tags),
+		"template": starlark.String(proj.Template),
+		"repos":    starlark.NewList(repos),
+	})
+}
+
+func repoValue(name, path, remote string) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"name":   starlark.String(name),
+		"path":   starlark.String(path),
+		"remote": starlark.String(remote),
+	})
+}
+
+func stringsValue(values []string) *starlark.List {
+	elems := make([]starlark.Value, len(values))
+	for i, v := range values {
+		elems[i] = starlark.String(v)
+	}
+	return starlark.NewList(elems)
+}
+
+// stringList converts a list or tuple of strings.
+func stringList(v starlark.Value) ([]string, error) {
+	iterable, ok := v.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("want a list of strings, got %s", v.Type())
+	}
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var out []string
+	var elem starlark.Value
+	for iter.Next(&elem) {
+		str, ok := starlark.AsString(elem)
+		if !ok {
+			return nil, fmt.Errorf("want a list of strings, got a %s element", elem.Type())
+		}
+		out = append(out, str)
+	}
+	return out, nil
+}
+
+// dictString returns the string at key in d, or "" when key is missing or None.
+func dictString(d *starlark.Dict, key string) (string, error) {
+	v, found, _ := d.Get(starlark.String(key))
+	if !found || v == starlark.None {
+		return "", nil
+	}
+	str, ok := starlark.AsString(v)
+	if !ok {
+		return "", fmt.Errorf("%s: want a string, got %s", key, v.Type())
+	}
+	return str, nil
+}
+
+func validState(state model.ProjectState) bool {
+	switch state {
+	case model.StateActive, model.StatePaused, model.StateArchived, model.StateScratch, model.StateTmp:
+		return true
+	}
+	return false
+}
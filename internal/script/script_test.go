@@ -0,0 +1,140 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+const testScript = `
+def classify(source):
+    if source.name.startswith("acme-"):
+        return {"owner": "acme", "project": source.name[len("acme-"):], "template": "go"}
+    return None
+
+def validate_name(owner, project):
+    if owner == "acme" and project.startswith("tmp"):
+        return "acme projects may not start with tmp"
+
+def validate(ws):
+    problems = []
+    if not ws.repos:
+        problems.append("no repos")
+    return problems
+
+def on_create(ws):
+    return {"tags": ["new", ws.owner], "state": "paused"}
+
+def on_archive(ws):
+    fail("archiving %s is not allowed" % co.slug(ws.owner, ws.project))
+`
+
+func loadTestScript(t *testing.T, src string) *Script {
+	t.Helper()
+	cfg := &config.Config{CodeRoot: t.TempDir(), Script: filepath.Join(t.TempDir(), "co.star")}
+	if err := os.WriteFile(cfg.Script, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return s
+}
+
+func TestLoadMissing(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	s, err := Load(cfg)
+	if s != nil || err != nil {
+		t.Fatalf("Load() = %v, %v; want nil, nil", s, err)
+	}
+	if err := s.CheckName("acme", "app"); err != nil {
+		t.Errorf("nil script CheckName() = %v", err)
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir(), Script: filepath.Join(t.TempDir(), "co.star")}
+	if err := os.WriteFile(cfg.Script, []byte("load('x.star', 'y')\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(cfg); err == nil {
+		t.Fatal("Load() should fail: scripts cannot load other files")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	s := loadTestScript(t, testScript)
+
+	c, err := s.Classify("/src/acme-billing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Classification{Owner: "acme", Project: "billing", Template: "go"}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("Classify() = %+v, want %+v", c, want)
+	}
+
+	if c, err := s.Classify("/src/other", nil); c != nil || err != nil {
+		t.Errorf("Classify() = %+v, %v; want nil", c, err)
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	s := loadTestScript(t, testScript)
+	if err := s.CheckName("acme", "app"); err != nil {
+		t.Errorf("CheckName(acme, app) = %v", err)
+	}
+	if err := s.CheckName("acme", "tmp-app"); err == nil || !strings.Contains(err.Error(), "may not start with tmp") {
+		t.Errorf("CheckName(acme, tmp-app) = %v, want the script's message", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := loadTestScript(t, testScript)
+	proj := model.NewProject("acme--app", "acme", "app")
+	problems, err := s.Validate(proj, "/code/acme--app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(problems, []string{"no repos"}) {
+		t.Errorf("Validate() = %v", problems)
+	}
+}
+
+func TestOnEvent(t *testing.T) {
+	s := loadTestScript(t, testScript)
+	proj := model.NewProject("acme--app", "acme", "app")
+	proj.Tags = []string{"acme"}
+
+	if err := s.OnEvent("create", proj, "/code/acme--app"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(proj.Tags, []string{"acme", "new"}) {
+		t.Errorf("Tags = %v, want [acme new]", proj.Tags)
+	}
+	if proj.State != model.StatePaused {
+		t.Errorf("State = %s, want paused", proj.State)
+	}
+
+	if err := s.OnEvent("import", proj, "/code/acme--app"); err != nil {
+		t.Errorf("undefined hook OnEvent() = %v", err)
+	}
+
+	err := s.OnEvent("archive", proj, "/code/acme--app")
+	if err == nil || !strings.Contains(err.Error(), "archiving acme--app is not allowed") {
+		t.Errorf("OnEvent(archive) = %v, want the fail() message", err)
+	}
+}
+
+func TestOnEventBadState(t *testing.T) {
+	s := loadTestScript(t, "def on_import(ws):\n    return {\"state\": \"gone\"}\n")
+	if err := s.OnEvent("import", model.NewProject("acme--app", "acme", "app"), "/code/acme--app"); err == nil {
+		t.Error("OnEvent() should reject an unknown state")
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/tormodhaugland/co/internal/chunker"
 	"github.com/tormodhaugland/co/internal/embedder"
+	"github.com/tormodhaugland/co/internal/model"
 	"github.com/tormodhaugland/co/internal/vectordb"
 )
 
@@ -118,8 +119,7 @@ func (idx *Indexer) IndexCodebase(ctx context.Context, codebase, workspacePath s
 	// Phase 1: Scan for files
 	progress <- IndexProgress{Codebase: codebase, Phase: "scanning"}
 
-	reposPath := filepath.Join(workspacePath, "repos")
-	files, err := idx.scanFiles(codebase, reposPath)
+	files, err := idx.scanFiles(codebase, workspacePath)
 	if err != nil {
 		return fmt.Errorf("scanning files: %w", err)
 	}
@@ -273,12 +273,12 @@ type chunkWithMeta struct {
 	file  fileInfo
 }
 
-// scanFiles finds all indexable files in the repos directory
-func (idx *Indexer) scanFiles(codebase, reposPath string) ([]fileInfo, error) {
+// scanFiles finds all indexable files in the repos of the workspace
+func (idx *Indexer) scanFiles(codebase, workspacePath string) ([]fileInfo, error) {
 	var files []fileInfo
 
 	// First, list repos
-	repos, err := os.ReadDir(reposPath)
+	repos, err := model.LocateWorkspaceRepos(workspacePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -287,14 +287,11 @@ func (idx *Indexer) scanFiles(codebase, reposPath string) ([]fileInfo, error) {
 	}
 
 	for _, repo := range repos {
-		if !repo.IsDir() {
-			continue
-		}
-		if strings.HasPrefix(repo.Name(), ".") {
+		if strings.HasPrefix(repo.Name, ".") {
 			continue
 		}
 
-		repoPath := filepath.Join(reposPath, repo.Name())
+		repoPath := filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
 
 		err := filepath.WalkDir(repoPath, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
@@ -352,7 +349,7 @@ func (idx *Indexer) scanFiles(codebase, reposPath string) ([]fileInfo, error) {
 			files = append(files, fileInfo{
 				path:     path,
 				codebase: codebase,
-				repo:     repo.Name(),
+				repo:     repo.Name,
 				relPath:  relPath,
 				size:     info.Size(),
 			})
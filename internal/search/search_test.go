@@ -710,7 +710,7 @@ func TestScanFilesSkipsHiddenDirs(t *testing.T) {
 	os.WriteFile(filepath.Join(reposPath, "repo", ".hidden", "secret.go"), []byte("package secret"), 0644)
 	os.WriteFile(filepath.Join(reposPath, "repo", "visible", "public.go"), []byte("package public"), 0644)
 
-	files, err := indexer.scanFiles("test-codebase", reposPath)
+	files, err := indexer.scanFiles("test-codebase", tmpDir)
 	if err != nil {
 		t.Fatalf("scanFiles failed: %v", err)
 	}
@@ -743,7 +743,7 @@ func TestScanFilesSkipsNodeModules(t *testing.T) {
 	os.WriteFile(filepath.Join(reposPath, "repo", "node_modules", "pkg", "index.js"), []byte("module.exports = {}"), 0644)
 	os.WriteFile(filepath.Join(reposPath, "repo", "src", "app.js"), []byte("const x = 1"), 0644)
 
-	files, err := indexer.scanFiles("test-codebase", reposPath)
+	files, err := indexer.scanFiles("test-codebase", tmpDir)
 	if err != nil {
 		t.Fatalf("scanFiles failed: %v", err)
 	}
@@ -783,7 +783,7 @@ func TestScanFilesRespectsMaxFileSize(t *testing.T) {
 	}
 	os.WriteFile(filepath.Join(reposPath, "repo", "large.go"), largeContent, 0644)
 
-	files, err := indexer.scanFiles("test-codebase", reposPath)
+	files, err := indexer.scanFiles("test-codebase", tmpDir)
 	if err != nil {
 		t.Fatalf("scanFiles failed: %v", err)
 	}
@@ -797,6 +797,29 @@ func TestScanFilesRespectsMaxFileSize(t *testing.T) {
 	}
 }
 
+func TestScanFilesFlatLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := vectordb.Open(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	indexer := NewIndexer(db, NewMockEmbedder(768), DefaultIndexConfig())
+
+	workspacePath := filepath.Join(tmpDir, "workspace")
+	os.MkdirAll(filepath.Join(workspacePath, "api", ".git"), 0755)
+	os.WriteFile(filepath.Join(workspacePath, "api", "main.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(workspacePath, "project.json"), []byte(`{"schema": 1, "slug": "acme--api", "repo_layout": "flat"}`), 0644)
+
+	files, err := indexer.scanFiles("test-codebase", workspacePath)
+	if err != nil {
+		t.Fatalf("scanFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0].repo != "api" || files[0].relPath != "main.go" {
+		t.Errorf("scanFiles() = %+v, want api/main.go", files)
+	}
+}
+
 func TestSearchByCode(t *testing.T) {
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "test.db")
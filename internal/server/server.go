@@ -0,0 +1,467 @@
+// Package server exposes co operations over a local HTTP+JSON API so editor
+// plugins and GUIs can drive co without shelling out to the CLI.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/maintain"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/vscode"
+	"github.com/tormodhaugland/co/pkg/co"
+)
+
+// APIVersion is the path prefix of the current API.
+const APIVersion = "/v1"
+
+// DefaultAddr is the address co serve listens on unless told otherwise.
+const DefaultAddr = "127.0.0.1:7717"
+
+// infoFileName is the file under the state dir describing the running server.
+const infoFileName = "serve.json"
+
+// Server serves the co API for a single client configuration.
+type Server struct {
+	client *co.Client
+	token  string
+	mux    *http.ServeMux
+
+	// mu serializes mutating operations; they move and delete directories and
+	// are not safe to run concurrently.
+	mu sync.Mutex
+
+	jobsMu sync.Mutex
+	jobs   []*JobStatus
+}
+
+// New returns a server backed by client. Every request except the health check
+// must carry "Authorization: Bearer <token>".
+func New(client *co.Client, token string) *Server {
+	s := &Server{client: client, token: token, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET "+APIVersion+"/health", s.handleHealth)
+	s.mux.HandleFunc("GET "+APIVersion+"/workspaces", s.handleListWorkspaces)
+	s.mux.HandleFunc("POST "+APIVersion+"/workspaces", s.handleCreateWorkspace)
+	s.mux.HandleFunc("POST "+APIVersion+"/workspaces/{slug}/add", s.handleAddTo)
+	s.mux.HandleFunc("POST "+APIVersion+"/workspaces/{slug}/template", s.handleApplyTemplate)
+	s.mux.HandleFunc("POST "+APIVersion+"/workspaces/{slug}/archive", s.handleArchive)
+	s.mux.HandleFunc("GET "+APIVersion+"/workspaces/{slug}/vscode", s.handleVSCodeOpen)
+	s.mux.HandleFunc("POST "+APIVersion+"/import", s.handleImport)
+	s.mux.HandleFunc("POST "+APIVersion+"/stash", s.handleStash)
+	s.mux.HandleFunc("POST "+APIVersion+"/restore", s.handleRestore)
+	s.mux.HandleFunc("POST "+APIVersion+"/index", s.handleReindex)
+	s.mux.HandleFunc("GET "+APIVersion+"/templates", s.handleListTemplates)
+	s.mux.HandleFunc("GET "+APIVersion+"/archives", s.handleListArchives)
+	s.mux.HandleFunc("GET "+APIVersion+"/jobs", s.handleListJobs)
+
+	return s
+}
+
+// ServeHTTP authenticates the request and dispatches it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != APIVersion+"/health" && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// GenerateToken returns a random token for authenticating API clients.
+func GenerateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Info describes a running server so local clients can discover and reach it.
+type Info struct {
+	Addr      string    `json:"addr"`
+	Token     string    `json:"token"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// InfoPath returns the path of the server info file.
+func InfoPath(cfg *config.Config) string {
+	return filepath.Join(cfg.StateDir(), infoFileName)
+}
+
+// WriteInfo writes the server info file, readable only by the current user.
+func WriteInfo(cfg *config.Config, info Info) error {
+	path := InfoPath(cfg)
+	if err := os.MkdirAll(filepath.Dir(path), fs.DirPerm()); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// RemoveInfo removes the server info file.
+func RemoveInfo(cfg *config.Config) error {
+	err := os.Remove(InfoPath(cfg))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// JobOptions sets how often the background jobs run; zero disables a job.
+type JobOptions struct {
+	MaintainEvery time.Duration // Run co maintain (prune, purge, reindex)
+	QueueEvery    time.Duration // Run the offline network queue while online
+
+	// Logf, if set, receives job failures.
+	Logf func(format string, args ...any)
+}
+
+// JobStatus describes a background job and its last run.
+type JobStatus struct {
+	Name    string     `json:"name"`
+	Every   string     `json:"every"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// RunJobs runs the background jobs until ctx is done. Each run holds the same
+// lock as the mutating API calls, so jobs never race with them.
+func (s *Server) RunJobs(ctx context.Context, opts JobOptions) {
+	cfg := s.client.Config()
+	var wg sync.WaitGroup
+	start := func(name string, every time.Duration, job func() error) {
+		if every <= 0 {
+			return
+		}
+		status := &JobStatus{Name: name, Every: every.String()}
+		s.jobsMu.Lock()
+		s.jobs = append(s.jobs, status)
+		s.jobsMu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(every)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				s.mu.Lock()
+				err := job()
+				s.mu.Unlock()
+
+				s.jobsMu.Lock()
+				now := time.Now()
+				status.LastRun = &now
+				status.Error = ""
+				if err != nil {
+					status.Error = err.Error()
+				}
+				s.jobsMu.Unlock()
+				if err != nil && opts.Logf != nil {
+					opts.Logf("%s: %v", name, err)
+				}
+			}
+		}()
+	}
+
+	start("maintain", opts.MaintainEvery, func() error {
+		report := maintain.Run(cfg, maintain.Options{})
+		if len(report.Errors) > 0 {
+			return errors.New(strings.Join(report.Errors, "; "))
+		}
+		return nil
+	})
+	start("network-queue", opts.QueueEvery, func() error {
+		if cfg.IsOffline() {
+			return nil
+		}
+		result, err := template.ResumeNetwork(cfg, false)
+		if err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			return fmt.Errorf("%d queued clone(s) failed and stay queued", len(result.Failed))
+		}
+		return nil
+	})
+	wg.Wait()
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	s.jobsMu.Lock()
+	jobs := make([]JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	s.jobsMu.Unlock()
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	records, err := s.client.List(co.ListOptions{
+		Owner: q.Get("owner"),
+		State: q.Get("state"),
+		Tag:   q.Get("tag"),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if records == nil {
+		records = []*co.Workspace{}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+type createRequest struct {
+	Owner     string            `json:"owner"`
+	Project   string            `json:"project"`
+	Template  string            `json:"template,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+	NoHooks   bool              `json:"no_hooks,omitempty"`
+	DryRun    bool              `json:"dry_run,omitempty"`
+}
+
+func (s *Server) handleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.run(w, http.StatusCreated, func() (any, error) {
+		return s.client.Create(req.Owner, req.Project, co.CreateOptions{
+			Template:  req.Template,
+			Variables: req.Variables,
+			NoHooks:   req.NoHooks,
+			DryRun:    req.DryRun,
+		})
+	})
+}
+
+type importRequest struct {
+	Source         string            `json:"source"`
+	Owner          string            `json:"owner,omitempty"`
+	Project        string            `json:"project,omitempty"`
+	ExtraFiles     []string          `json:"extra_files,omitempty"`
+	ExtraFilesDest string            `json:"extra_files_dest,omitempty"`
+	Template       string            `json:"template,omitempty"`
+	Variables      map[string]string `json:"variables,omitempty"`
+	NoHooks        bool              `json:"no_hooks,omitempty"`
+}
+
+func (req importRequest) options() co.ImportOptions {
+	return co.ImportOptions{
+		Owner:          req.Owner,
+		Project:        req.Project,
+		ExtraFiles:     req.ExtraFiles,
+		ExtraFilesDest: req.ExtraFilesDest,
+		Template:       req.Template,
+		Variables:      req.Variables,
+		NoHooks:        req.NoHooks,
+	}
+}
+
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	var req importRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.run(w, http.StatusCreated, func() (any, error) {
+		return s.client.Import(req.Source, req.options())
+	})
+}
+
+func (s *Server) handleAddTo(w http.ResponseWriter, r *http.Request) {
+	var req importRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	slug := r.PathValue("slug")
+	s.run(w, http.StatusOK, func() (any, error) {
+		return s.client.AddTo(slug, req.Source, req.options())
+	})
+}
+
+type applyTemplateRequest struct {
+	Template  string            `json:"template"`
+	Variables map[string]string `json:"variables,omitempty"`
+	NoHooks   bool              `json:"no_hooks,omitempty"`
+	DryRun    bool              `json:"dry_run,omitempty"`
+}
+
+func (s *Server) handleApplyTemplate(w http.ResponseWriter, r *http.Request) {
+	var req applyTemplateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	slug := r.PathValue("slug")
+	s.run(w, http.StatusOK, func() (any, error) {
+		return s.client.ApplyTemplate(slug, req.Template, co.ApplyTemplateOptions{
+			Variables: req.Variables,
+			NoHooks:   req.NoHooks,
+			DryRun:    req.DryRun,
+		})
+	})
+}
+
+type archiveRequest struct {
+	Reason      string `json:"reason,omitempty"`
+	Full        bool   `json:"full,omitempty"`
+	DeleteAfter bool   `json:"delete_after,omitempty"`
+}
+
+func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	var req archiveRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	slug := r.PathValue("slug")
+	s.run(w, http.StatusOK, func() (any, error) {
+		return s.client.Archive(slug, co.ArchiveOptions{
+			Reason:      req.Reason,
+			Full:        req.Full,
+			DeleteAfter: req.DeleteAfter,
+		})
+	})
+}
+
+// handleVSCodeOpen describes how the VS Code extension should open a workspace.
+func (s *Server) handleVSCodeOpen(w http.ResponseWriter, r *http.Request) {
+	spec, err := vscode.Open(s.client.Config(), s.client.ResolveSlug(r.PathValue("slug")))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, spec)
+}
+
+type stashRequest struct {
+	Path        string `json:"path"`
+	Name        string `json:"name,omitempty"`
+	DeleteAfter bool   `json:"delete_after,omitempty"`
+}
+
+func (s *Server) handleStash(w http.ResponseWriter, r *http.Request) {
+	var req stashRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.run(w, http.StatusOK, func() (any, error) {
+		return s.client.Stash(req.Path, co.StashOptions{Name: req.Name, DeleteAfter: req.DeleteAfter})
+	})
+}
+
+type restoreRequest struct {
+	ArchivePath string `json:"archive_path"`
+	DestDir     string `json:"dest_dir,omitempty"`
+}
+
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request) {
+	var req restoreRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.run(w, http.StatusOK, func() (any, error) {
+		return s.client.Restore(req.ArchivePath, co.RestoreOptions{DestDir: req.DestDir})
+	})
+}
+
+func (s *Server) handleReindex(w http.ResponseWriter, r *http.Request) {
+	s.run(w, http.StatusOK, func() (any, error) {
+		return map[string]string{"status": "ok"}, s.client.Reindex()
+	})
+}
+
+func (s *Server) handleListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.client.Templates()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if templates == nil {
+		templates = []co.TemplateInfo{}
+	}
+	writeJSON(w, http.StatusOK, templates)
+}
+
+func (s *Server) handleListArchives(w http.ResponseWriter, r *http.Request) {
+	archives, err := s.client.Archives()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if archives == nil {
+		archives = []co.ArchiveEntry{}
+	}
+	writeJSON(w, http.StatusOK, archives)
+}
+
+// run executes a mutating operation while holding the server lock. Operation
+// failures are reported as 422 since they stem from the request, not the server.
+func (s *Server) run(w http.ResponseWriter, status int, op func() (any, error)) {
+	s.mu.Lock()
+	result, err := op()
+	s.mu.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+// decode parses a JSON request body, writing a 400 response on failure.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.Body == nil || r.ContentLength == 0 {
+		return true
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
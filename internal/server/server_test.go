@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/pkg/co"
+)
+
+func newTestServer(t *testing.T) (*Server, *config.Config) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.CodeRoot = filepath.Join(t.TempDir(), "Code")
+	if err := os.MkdirAll(cfg.CodeRoot, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	return New(co.New(cfg), "secret"), cfg
+}
+
+func doRequest(s *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServerAuth(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	if rec := doRequest(s, "GET", "/v1/health", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("health without token = %d, want 200", rec.Code)
+	}
+	if rec := doRequest(s, "GET", "/v1/templates", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("templates without token = %d, want 401", rec.Code)
+	}
+	if rec := doRequest(s, "GET", "/v1/templates", "wrong", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("templates with wrong token = %d, want 401", rec.Code)
+	}
+	if rec := doRequest(s, "GET", "/v1/templates", "secret", ""); rec.Code != http.StatusOK {
+		t.Errorf("templates with token = %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestServerCreateAndList(t *testing.T) {
+	s, cfg := newTestServer(t)
+
+	rec := doRequest(s, "POST", "/v1/workspaces", "secret", `{"owner":"acme","project":"api"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var created co.CreateResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.WorkspaceSlug != "acme--api" || created.WorkspacePath != cfg.WorkspacePath("acme--api") {
+		t.Errorf("created = %+v, want acme--api", created)
+	}
+
+	// Creating it again is a request error
+	rec = doRequest(s, "POST", "/v1/workspaces", "secret", `{"owner":"acme","project":"api"}`)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "already exists") {
+		t.Errorf("duplicate create = %d %s, want 422 already exists", rec.Code, rec.Body)
+	}
+
+	// Unknown fields are rejected
+	if rec := doRequest(s, "POST", "/v1/workspaces", "secret", `{"owner":"acme","projcet":"web"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown field = %d, want 400", rec.Code)
+	}
+
+	if rec := doRequest(s, "POST", "/v1/index", "secret", ""); rec.Code != http.StatusOK {
+		t.Fatalf("index = %d: %s", rec.Code, rec.Body)
+	}
+	rec = doRequest(s, "GET", "/v1/workspaces?owner=acme", "secret", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list = %d: %s", rec.Code, rec.Body)
+	}
+	var records []co.Workspace
+	if err := json.Unmarshal(rec.Body.Bytes(), &records); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(records) != 1 || records[0].Slug != "acme--api" {
+		t.Errorf("list = %+v, want acme--api", records)
+	}
+
+	rec = doRequest(s, "GET", "/v1/workspaces/acme--api/vscode", "secret", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"uri": "vscode://file`) {
+		t.Errorf("vscode open = %d %s, want 200 with a vscode uri", rec.Code, rec.Body)
+	}
+	if rec := doRequest(s, "GET", "/v1/workspaces/acme--missing/vscode", "secret", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("vscode open missing = %d, want 404", rec.Code)
+	}
+
+	// Archiving a missing workspace fails
+	if rec := doRequest(s, "POST", "/v1/workspaces/acme--missing/archive", "secret", "{}"); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("archive missing = %d, want 422", rec.Code)
+	}
+}
+
+func TestServerInfoFile(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := &config.Config{}
+
+	if err := WriteInfo(cfg, Info{Addr: "127.0.0.1:7717", Token: "secret"}); err != nil {
+		t.Fatalf("WriteInfo: %v", err)
+	}
+	st, err := os.Stat(InfoPath(cfg))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if st.Mode().Perm() != 0o600 {
+		t.Errorf("info file mode = %v, want 0600", st.Mode().Perm())
+	}
+
+	if err := RemoveInfo(cfg); err != nil {
+		t.Fatalf("RemoveInfo: %v", err)
+	}
+	if err := RemoveInfo(cfg); err != nil {
+		t.Errorf("RemoveInfo on missing file: %v", err)
+	}
+}
+
+func TestServerRunJobs(t *testing.T) {
+	s, _ := newTestServer(t)
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.RunJobs(ctx, JobOptions{QueueEvery: 10 * time.Millisecond})
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := doRequest(s, "GET", "/v1/jobs", "secret", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("jobs = %d, want 200: %s", rec.Code, rec.Body)
+		}
+		var jobs []JobStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &jobs); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(jobs) == 1 && jobs[0].Name == "network-queue" && jobs[0].LastRun != nil {
+			if jobs[0].Error != "" {
+				t.Errorf("network-queue error = %q", jobs[0].Error)
+			}
+			return
+		}
+		if len(jobs) > 1 {
+			t.Fatalf("jobs = %+v, want only network-queue (maintain is off)", jobs)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("network-queue never ran: %+v", jobs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -351,23 +351,10 @@ func resolveRepoClones(localPath string, project *model.Project) ([]repoClonePla
 }
 
 func discoverRepos(localPath string) ([]model.RepoSpec, error) {
-	repos, err := fs.ListRepos(localPath)
+	specs, err := model.LocateWorkspaceRepos(localPath)
 	if err != nil {
 		return nil, fmt.Errorf("list repos for sync: %w", err)
 	}
-
-	specs := make([]model.RepoSpec, 0, len(repos))
-	for _, repo := range repos {
-		repo = strings.TrimSpace(repo)
-		if repo == "" {
-			continue
-		}
-		specs = append(specs, model.RepoSpec{
-			Name: repo,
-			Path: path.Join("repos", repo),
-		})
-	}
-
 	return specs, nil
 }
 
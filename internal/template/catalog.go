@@ -0,0 +1,360 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/minisign"
+)
+
+// CatalogEntry describes a template offered by a catalog. Archive or Git
+// says where to get it.
+type CatalogEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Installs    int      `json:"installs,omitempty"`
+	Archive     string   `json:"archive,omitempty"`   // .tar.gz of the template directory, absolute or relative to the catalog
+	Signature   string   `json:"signature,omitempty"` // Minisign signature of Archive, absolute or relative to the catalog
+	Git         string   `json:"git,omitempty"`       // Repository with the template at its root
+}
+
+// Catalog is a static JSON index of community templates, served over HTTP
+// or read from a file.
+type Catalog struct {
+	Source    string         `json:"-"`
+	Templates []CatalogEntry `json:"templates"`
+}
+
+// InstallResult reports a template installed from a catalog.
+type InstallResult struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"`
+	Source   string      `json:"source"`
+	Replaced bool        `json:"replaced,omitempty"`
+	Trust    TrustRecord `json:"trust"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+var catalogClient = &http.Client{Timeout: 30 * time.Second}
+
+// FetchCatalog reads the catalog configured in template_catalog, an http(s)
+// URL or a file path. Remote catalogs cannot be read while offline.
+func FetchCatalog(cfg *config.Config) (*Catalog, error) {
+	source := cfg.TemplateCatalog
+	if source == "" {
+		return nil, fmt.Errorf("no template catalog configured (set template_catalog in the config)")
+	}
+	if isHTTP(source) {
+		if err := cfg.CheckOnline("fetch the template catalog"); err != nil {
+			return nil, err
+		}
+	}
+	r, err := openCatalogRef(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch catalog: %w", err)
+	}
+	defer r.Close()
+
+	var c Catalog
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("invalid catalog %s: %w", source, err)
+	}
+	c.Source = source
+	return &c, nil
+}
+
+// Search returns the templates whose name, description, or tags contain
+// query, ignoring case, most installed first. An empty query matches all.
+func (c *Catalog) Search(query string) []CatalogEntry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matches []CatalogEntry
+	for _, e := range c.Templates {
+		if query == "" || catalogMatch(e, query) {
+			matches = append(matches, e)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Installs != matches[j].Installs {
+			return matches[i].Installs > matches[j].Installs
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches
+}
+
+func catalogMatch(e CatalogEntry, query string) bool {
+	if strings.Contains(strings.ToLower(e.Name), query) || strings.Contains(strings.ToLower(e.Description), query) {
+		return true
+	}
+	for _, tag := range e.Tags {
+		if strings.Contains(strings.ToLower(tag), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the catalog entry named name.
+func (c *Catalog) Find(name string) (CatalogEntry, bool) {
+	for _, e := range c.Templates {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+// Install fetches the template name from the catalog into the primary
+// templates directory. An installed template of the same name is replaced
+// only with force. The template is validated before it is put in place.
+//
+// An archive signed by a key in template_trust.keys is trusted. Anything
+// else is installed untrusted, so its hooks do not run until it is trusted
+// with SetTrusted, or refused under template_trust.require_signature. A
+// signature that does not verify always fails the install.
+func (c *Catalog) Install(cfg *config.Config, name string, force bool) (*InstallResult, error) {
+	entry, ok := c.Find(name)
+	if !ok {
+		return nil, fmt.Errorf("template %q not found in catalog %s", name, c.Source)
+	}
+	if name != filepath.Base(name) || name == GlobalTemplateDir || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid template name in catalog: %q", name)
+	}
+
+	if entry.Git != "" || isHTTP(c.resolve(entry.Archive)) {
+		if err := cfg.CheckOnline("download template " + name); err != nil {
+			return nil, err
+		}
+	}
+
+	target := filepath.Join(cfg.TemplatesDir(), name)
+	_, statErr := os.Stat(target)
+	exists := statErr == nil
+	if exists && !force {
+		return nil, fmt.Errorf("template already installed: %s (use --force to replace it)", name)
+	}
+
+	if err := os.MkdirAll(cfg.TemplatesDir(), fs.DirPerm()); err != nil {
+		return nil, fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	records, err := LoadTrust(cfg.TemplatesDir())
+	if err != nil {
+		return nil, err
+	}
+	// Staging inside the templates directory keeps the final rename on one
+	// filesystem; hidden directories are not listed as templates.
+	staging, err := os.MkdirTemp(cfg.TemplatesDir(), ".install-"+name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	result := &InstallResult{Name: name, Path: target, Replaced: exists}
+	src := filepath.Join(staging, "src")
+	switch {
+	case entry.Archive != "":
+		result.Source = c.resolve(entry.Archive)
+		archivePath, err := downloadArchive(result.Source, staging)
+		if err != nil {
+			return nil, err
+		}
+		keyID, err := c.verifySignature(cfg, entry, archivePath)
+		if errors.Is(err, minisign.ErrUntrustedKey) {
+			result.Warnings = append(result.Warnings, err.Error())
+		} else if err != nil {
+			return nil, err
+		}
+		result.Trust.Signed, result.Trust.KeyID = keyID != "", keyID
+		if err := extractArchive(result.Source, archivePath, src); err != nil {
+			return nil, err
+		}
+	case entry.Git != "":
+		result.Source = entry.Git
+		if _, err := GitRetryPolicy(cfg).CloneRepo(result.Source, src); err != nil {
+			return nil, fmt.Errorf("failed to clone %s: %w", result.Source, err)
+		}
+		if err := os.RemoveAll(filepath.Join(src, ".git")); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("catalog entry %s has no archive or git source", name)
+	}
+	if !result.Trust.Signed && cfg.GetTemplateTrustConfig().RequireSignature {
+		return nil, fmt.Errorf("template %s is not signed by a trusted key (template_trust.require_signature)", name)
+	}
+
+	root, err := findTemplateRoot(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", result.Source, err)
+	}
+	staged := filepath.Join(staging, name)
+	if err := os.Rename(root, staged); err != nil {
+		return nil, err
+	}
+	if err := ValidateTemplateDir(staging, name); err != nil {
+		return nil, fmt.Errorf("template %s is invalid: %w", name, err)
+	}
+
+	if exists {
+		if err := os.RemoveAll(target); err != nil {
+			return nil, fmt.Errorf("failed to remove installed template: %w", err)
+		}
+	}
+	if err := os.Rename(staged, target); err != nil {
+		return nil, fmt.Errorf("failed to install template: %w", err)
+	}
+
+	result.Trust.Source = result.Source
+	result.Trust.Trusted = result.Trust.Signed
+	result.Trust.InstalledAt = time.Now()
+	records[name] = result.Trust
+	if err := SaveTrust(cfg.TemplatesDir(), records); err != nil {
+		// Without a record the template would count as local and trusted
+		os.RemoveAll(target)
+		return nil, fmt.Errorf("failed to record template trust: %w", err)
+	}
+	return result, nil
+}
+
+// verifySignature checks the entry's signature of the archive at
+// archivePath against template_trust.keys. It returns the signing key's ID,
+// or "" when the entry is unsigned.
+func (c *Catalog) verifySignature(cfg *config.Config, entry CatalogEntry, archivePath string) (string, error) {
+	if entry.Signature == "" {
+		return "", nil
+	}
+	var keys []minisign.PublicKey
+	for _, k := range cfg.GetTemplateTrustConfig().Keys {
+		key, err := minisign.ParsePublicKey(k)
+		if err != nil {
+			return "", fmt.Errorf("template_trust.keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	sigRef := c.resolve(entry.Signature)
+	r, err := openCatalogRef(sigRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to download signature %s: %w", sigRef, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, 64<<10))
+	if err != nil {
+		return "", fmt.Errorf("failed to download signature %s: %w", sigRef, err)
+	}
+	sig, err := minisign.ParseSignature(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", sigRef, err)
+	}
+	message, err := os.ReadFile(archivePath)
+	if err != nil {
+		return "", err
+	}
+	key, err := minisign.Verify(message, sig, keys)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", sigRef, err)
+	}
+	return key.KeyID(), nil
+}
+
+// resolve makes ref, as written in the catalog, absolute.
+func (c *Catalog) resolve(ref string) string {
+	if isHTTP(ref) || filepath.IsAbs(ref) {
+		return ref
+	}
+	if isHTTP(c.Source) {
+		base, err := url.Parse(c.Source)
+		if err != nil {
+			return ref
+		}
+		rel, err := url.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return base.ResolveReference(rel).String()
+	}
+	return filepath.Join(filepath.Dir(strings.TrimPrefix(c.Source, "file://")), ref)
+}
+
+// downloadArchive saves the archive at source into staging and returns its
+// path.
+func downloadArchive(source, staging string) (string, error) {
+	r, err := openCatalogRef(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", source, err)
+	}
+	defer r.Close()
+
+	archivePath := filepath.Join(staging, "template.tar.gz")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to download %s: %w", source, err)
+	}
+	return archivePath, f.Close()
+}
+
+func extractArchive(source, archivePath, dst string) error {
+	if err := os.MkdirAll(dst, fs.DirPerm()); err != nil {
+		return err
+	}
+	if out, err := exec.Command("tar", "-xzf", archivePath, "-C", dst).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to extract %s: %w: %s", source, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// findTemplateRoot returns dir when it holds template.json, or its only
+// subdirectory when that does, as archives often wrap their contents.
+func findTemplateRoot(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, TemplateManifestFile)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		sub := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(sub, TemplateManifestFile)); err == nil {
+			return sub, nil
+		}
+	}
+	return "", fmt.Errorf("no %s found", TemplateManifestFile)
+}
+
+func openCatalogRef(ref string) (io.ReadCloser, error) {
+	if !isHTTP(ref) {
+		return os.Open(strings.TrimPrefix(ref, "file://"))
+	}
+	resp, err := catalogClient.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", ref, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func isHTTP(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
@@ -0,0 +1,264 @@
+package template
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"golang.org/x/crypto/blake2b"
+)
+
+// tarGz packs files, a map of paths to contents, into a .tar.gz.
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func catalogServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	archive := tarGz(t, map[string]string{
+		"go-service/template.json":        `{"schema": 1, "name": "go-service", "description": "Go service"}`,
+		"go-service/files/README.md.tmpl": "# {{PROJECT}}\n",
+	})
+	broken := tarGz(t, map[string]string{"README.md": "no manifest"})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/catalog.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"templates": [
+			{"name": "go-service", "description": "Go service with CI", "tags": ["go"], "installs": 40, "archive": "archives/go-service.tar.gz"},
+			{"name": "web-app", "description": "React app", "tags": ["web", "typescript"], "installs": 90, "archive": "archives/web-app.tar.gz"},
+			{"name": "go-cli", "description": "Command line tool", "tags": ["go"], "installs": 90}
+		]}`))
+	})
+	mux.HandleFunc("/archives/go-service.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/archives/web-app.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(broken)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCatalogSearch(t *testing.T) {
+	server := catalogServer(t)
+	catalog, err := FetchCatalog(&config.Config{TemplateCatalog: server.URL + "/catalog.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"go-cli", "web-app", "go-service"}},
+		{"GO", []string{"go-cli", "go-service"}},
+		{"typescript", []string{"web-app"}},
+		{"ci", []string{"go-service"}},
+		{"rust", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range catalog.Search(tt.query) {
+			got = append(got, e.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFetchCatalogUnset(t *testing.T) {
+	if _, err := FetchCatalog(&config.Config{}); err == nil {
+		t.Error("FetchCatalog(\"\") should fail without a configured catalog")
+	}
+}
+
+func TestFetchCatalogOffline(t *testing.T) {
+	server := catalogServer(t)
+	cfg := &config.Config{TemplateCatalog: server.URL + "/catalog.json", Offline: true}
+	if _, err := FetchCatalog(cfg); !errors.Is(err, config.ErrOffline) {
+		t.Errorf("FetchCatalog() offline = %v, want ErrOffline", err)
+	}
+
+	// Local catalogs still work, but their remote archives do not
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	catalogJSON := `{"templates": [{"name": "remote", "archive": "` + server.URL + `/archives/go-service.tar.gz"}]}`
+	if err := os.WriteFile(path, []byte(catalogJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg = &config.Config{CodeRoot: t.TempDir(), TemplateCatalog: path, Offline: true}
+	catalog, err := FetchCatalog(cfg)
+	if err != nil {
+		t.Fatalf("FetchCatalog() of a file offline: %v", err)
+	}
+	if _, err := catalog.Install(cfg, "remote", false); !errors.Is(err, config.ErrOffline) {
+		t.Errorf("Install() offline = %v, want ErrOffline", err)
+	}
+}
+
+func TestCatalogInstall(t *testing.T) {
+	server := catalogServer(t)
+	catalog, err := FetchCatalog(&config.Config{TemplateCatalog: server.URL + "/catalog.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+
+	result, err := catalog.Install(cfg, "go-service", false)
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if result.Source != server.URL+"/archives/go-service.tar.gz" || result.Replaced {
+		t.Errorf("Install() = %+v", result)
+	}
+	if _, err := LoadTemplate(cfg.TemplatesDir(), "go-service"); err != nil {
+		t.Errorf("installed template does not load: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.Path, "files", "README.md.tmpl")); err != nil {
+		t.Errorf("template files missing: %v", err)
+	}
+
+	if _, err := catalog.Install(cfg, "go-service", false); err == nil {
+		t.Error("Install() over an installed template should fail without force")
+	}
+	if result, err := catalog.Install(cfg, "go-service", true); err != nil || !result.Replaced {
+		t.Errorf("Install(force) = %+v, %v; want replaced", result, err)
+	}
+
+	if _, err := catalog.Install(cfg, "web-app", false); err == nil {
+		t.Error("Install() of an archive without template.json should fail")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.TemplatesDir(), "web-app")); !os.IsNotExist(err) {
+		t.Error("a failed install should leave nothing behind")
+	}
+	if _, err := catalog.Install(cfg, "missing", false); err == nil {
+		t.Error("Install() of an unknown template should fail")
+	}
+
+	entries, err := os.ReadDir(cfg.TemplatesDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".install-") {
+			t.Errorf("staging directory %s left behind", e.Name())
+		}
+	}
+
+	// Unsigned templates are installed untrusted
+	if record, trusted := TrustStatus(result.Path); trusted || record == nil || record.Signed {
+		t.Errorf("TrustStatus() = %+v, %v; want an untrusted record", record, trusted)
+	}
+}
+
+// signArchive returns a minisign signature of data, made with a new key with
+// ID first..first+7, and the public key line.
+func signArchive(t *testing.T, data []byte, first byte) (string, string) {
+	t.Helper()
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := []byte{first, first + 1, first + 2, first + 3, first + 4, first + 5, first + 6, first + 7}
+	sum := blake2b.Sum512(data)
+	sig := ed25519.Sign(sk, sum[:])
+	comment := "timestamp:1700000000"
+	global := ed25519.Sign(sk, append(append([]byte{}, sig...), comment...))
+	minisig := "untrusted comment: signature\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("ED"), id...), sig...)) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+	pub := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), pk...))
+	return minisig, pub
+}
+
+func TestCatalogInstallSigned(t *testing.T) {
+	archive := tarGz(t, map[string]string{
+		"template.json": `{"schema": 1, "name": "signed", "description": "Signed template"}`,
+	})
+	minisig, pub := signArchive(t, archive, 1)
+	_, otherPub := signArchive(t, archive, 9)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"signed.tar.gz":         string(archive),
+		"signed.tar.gz.minisig": minisig,
+		"bad.minisig":           strings.Replace(minisig, "trusted comment: ", "trusted comment: x", 1),
+		"catalog.json": `{"templates": [
+			{"name": "signed", "description": "ok", "archive": "signed.tar.gz", "signature": "signed.tar.gz.minisig"},
+			{"name": "forged", "description": "bad", "archive": "signed.tar.gz", "signature": "bad.minisig"}
+		]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	catalog, err := FetchCatalog(&config.Config{TemplateCatalog: filepath.Join(dir, "catalog.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trusting := &config.Config{CodeRoot: t.TempDir(), TemplateTrust: &config.TemplateTrustConfig{Keys: []string{pub}}}
+	result, err := catalog.Install(trusting, "signed", false)
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if !result.Trust.Signed || !result.Trust.Trusted || result.Trust.KeyID != "0807060504030201" {
+		t.Errorf("Trust = %+v, want signed and trusted", result.Trust)
+	}
+	if _, err := catalog.Install(trusting, "forged", false); err == nil {
+		t.Error("Install() accepted a signature that does not verify")
+	}
+
+	// Signed with a key that is not configured: installed, but untrusted
+	other := &config.Config{CodeRoot: t.TempDir(), TemplateTrust: &config.TemplateTrustConfig{Keys: []string{otherPub}}}
+	result, err = catalog.Install(other, "signed", false)
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if result.Trust.Trusted || len(result.Warnings) == 0 {
+		t.Errorf("Install() = %+v, want untrusted with a warning", result)
+	}
+
+	strict := &config.Config{CodeRoot: t.TempDir(), TemplateTrust: &config.TemplateTrustConfig{Keys: []string{otherPub}, RequireSignature: true}}
+	if _, err := catalog.Install(strict, "signed", false); err == nil {
+		t.Error("Install() should refuse unverified templates under require_signature")
+	}
+}
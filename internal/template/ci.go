@@ -0,0 +1,165 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// TemplateCIDir is the directory holding a template's CI workflow files.
+const TemplateCIDir = "ci"
+
+// CIProviderGitHub is the only supported CI provider.
+const CIProviderGitHub = "github"
+
+// CISpec sets up CI for the repos a template creates: workflow files are
+// copied into each repo, and through the gh CLI Actions can be enabled and
+// repository secrets set. String values may use template variables.
+type CISpec struct {
+	Provider      string            `json:"provider,omitempty"`       // "github" (default)
+	Workflows     []string          `json:"workflows,omitempty"`      // Files in the template's ci/ directory, copied to .github/workflows/
+	Repos         []string          `json:"repos,omitempty"`          // Repos to set up (default: every template repo)
+	EnableActions bool              `json:"enable_actions,omitempty"` // Enable GitHub Actions on the remote repo
+	Secrets       map[string]string `json:"secrets,omitempty"`        // Secret name to value, e.g. {"NPM_TOKEN": "{{npm_token}}"}
+}
+
+// runGH runs the gh CLI with stdin as input. Tests replace it.
+var runGH = func(stdin string, args ...string) error {
+	cmd := exec.Command("gh", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("gh %s: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ghAvailable reports whether the gh CLI is installed. Tests replace it.
+var ghAvailable = func() bool {
+	_, err := exec.LookPath("gh")
+	return err == nil
+}
+
+// setupCI applies tmpl.CI to the template's repos in the workspace at
+// workspacePath. Failures
+// do not stop workspace creation; they are returned as warnings. It returns
+// the repos that were set up.
+func setupCI(tmpl *Template, templatePath, workspacePath string, vars map[string]string) (configured, warnings []string) {
+	spec := tmpl.CI
+	if spec.Provider != "" && spec.Provider != CIProviderGitHub {
+		return nil, []string{fmt.Sprintf("ci: unsupported provider %q", spec.Provider)}
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range spec.Repos {
+		selected[name] = true
+	}
+	needsAPI := spec.EnableActions || len(spec.Secrets) > 0
+	if needsAPI && !ghAvailable() {
+		warnings = append(warnings, "ci: gh CLI not installed; skipping GitHub setup (Actions, secrets)")
+		needsAPI = false
+	}
+
+	for _, repo := range tmpl.Repos {
+		if len(selected) > 0 && !selected[repo.Name] {
+			continue
+		}
+		repoPath := filepath.Join(workspacePath, tmpl.RepoDir(repo))
+		if _, err := os.Stat(repoPath); err != nil {
+			continue
+		}
+
+		ok := true
+		for _, wf := range spec.Workflows {
+			if err := copyWorkflow(filepath.Join(templatePath, TemplateCIDir, wf), repoPath, vars); err != nil {
+				warnings = append(warnings, fmt.Sprintf("ci: %s: %v", repo.Name, err))
+				ok = false
+			}
+		}
+
+		if needsAPI {
+			ghRepo := githubRepo(repo, repoPath, vars)
+			if ghRepo == "" {
+				warnings = append(warnings, fmt.Sprintf("ci: %s: no GitHub repo to set up (set \"github\" on the repo or add a github.com origin)", repo.Name))
+				ok = false
+			} else {
+				for _, w := range setupGitHub(spec, ghRepo, vars) {
+					warnings = append(warnings, fmt.Sprintf("ci: %s: %s", repo.Name, w))
+					ok = false
+				}
+			}
+		}
+
+		if ok {
+			configured = append(configured, repo.Name)
+		}
+	}
+	return configured, warnings
+}
+
+// copyWorkflow writes the workflow file src into repoPath/.github/workflows,
+// keeping a workflow the repo already has.
+func copyWorkflow(src, repoPath string, vars map[string]string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(repoPath, ".github", "workflows", filepath.Base(src))
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+	content, err := ProcessTemplateContent(string(data), vars)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), fs.DirPerm()); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, []byte(content), fs.FilePerm())
+}
+
+// githubRepo returns the owner/name of the GitHub repo CI is set up on: the
+// repo's "github" field, or its origin when that is on github.com.
+func githubRepo(repo TemplateRepo, repoPath string, vars map[string]string) string {
+	if repo.GitHub != "" {
+		name, _ := ProcessTemplateContent(repo.GitHub, vars)
+		return name
+	}
+	remote, err := git.RemoteURL(repoPath)
+	if err != nil || !strings.Contains(remote, "github.com") {
+		return ""
+	}
+	owner, name, ok := workspace.ParseRemoteURL(remote)
+	if !ok {
+		return ""
+	}
+	return owner + "/" + name
+}
+
+// setupGitHub enables Actions and sets secrets on ghRepo, returning a
+// warning for each step that failed.
+func setupGitHub(spec *CISpec, ghRepo string, vars map[string]string) []string {
+	var warnings []string
+	if spec.EnableActions {
+		if err := runGH("", "api", "--method", "PUT", "repos/"+ghRepo+"/actions/permissions", "-F", "enabled=true"); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	for _, name := range sortedKeys(spec.Secrets) {
+		value, _ := ProcessTemplateContent(spec.Secrets[name], vars)
+		if value == "" || variableRefPattern.MatchString(value) {
+			warnings = append(warnings, fmt.Sprintf("secret %s has no value", name))
+			continue
+		}
+		// The value goes through stdin so it never shows up in a process list
+		if err := runGH(value, "secret", "set", name, "--repo", ghRepo); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	return warnings
+}
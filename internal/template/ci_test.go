@@ -0,0 +1,118 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetupCI(t *testing.T) {
+	templatePath := t.TempDir()
+	workspacePath := t.TempDir()
+	reposPath := filepath.Join(workspacePath, "repos")
+	if err := os.MkdirAll(filepath.Join(templatePath, TemplateCIDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workflow := "name: ci\nenv:\n  APP: {{PROJECT}}\n"
+	if err := os.WriteFile(filepath.Join(templatePath, TemplateCIDir, "ci.yml"), []byte(workflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"api", "web", "docs"} {
+		if err := os.MkdirAll(filepath.Join(reposPath, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	origRunGH, origGHAvailable := runGH, ghAvailable
+	t.Cleanup(func() {
+		runGH, ghAvailable = origRunGH, origGHAvailable
+	})
+	var calls []string
+	runGH = func(stdin string, args ...string) error {
+		calls = append(calls, strings.Join(args, " ")+" <"+stdin)
+		return nil
+	}
+	ghAvailable = func() bool { return true }
+
+	tmpl := &Template{
+		Repos: []TemplateRepo{
+			{Name: "api", Init: true, GitHub: "{{OWNER}}/{{PROJECT}}-api"},
+			{Name: "web", Init: true},
+			{Name: "docs", Init: true},
+		},
+		CI: &CISpec{
+			Workflows:     []string{"ci.yml"},
+			Repos:         []string{"api", "web"},
+			EnableActions: true,
+			Secrets:       map[string]string{"DEPLOY_KEY": "{{deploy_key}}", "MISSING": "{{unset}}"},
+		},
+	}
+	vars := map[string]string{"OWNER": "acme", "PROJECT": "app", "deploy_key": "s3cret"}
+
+	configured, warnings := setupCI(tmpl, templatePath, workspacePath, vars)
+
+	data, err := os.ReadFile(filepath.Join(reposPath, "api", ".github", "workflows", "ci.yml"))
+	if err != nil || string(data) != "name: ci\nenv:\n  APP: app\n" {
+		t.Errorf("api workflow = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(reposPath, "docs", ".github")); !os.IsNotExist(err) {
+		t.Error("docs is not in ci.repos and should be left alone")
+	}
+
+	wantCalls := []string{
+		"api --method PUT repos/acme/app-api/actions/permissions -F enabled=true <",
+		"secret set DEPLOY_KEY --repo acme/app-api <s3cret",
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("gh calls = %q, want %q", calls, wantCalls)
+	}
+
+	// api is missing a secret value, and web has no GitHub repo
+	if len(configured) != 0 {
+		t.Errorf("configured = %v, want none", configured)
+	}
+	joined := strings.Join(warnings, "\n")
+	for _, want := range []string{"api: secret MISSING has no value", "web: no GitHub repo"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("warnings missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestSetupCIWorkflowsOnly(t *testing.T) {
+	templatePath := t.TempDir()
+	workspacePath := t.TempDir()
+	reposPath := filepath.Join(workspacePath, "repos")
+	if err := os.MkdirAll(filepath.Join(templatePath, TemplateCIDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templatePath, TemplateCIDir, "ci.yml"), []byte("name: ci\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(reposPath, "api", ".github", "workflows", "ci.yml")
+	if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, []byte("name: mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &Template{
+		Repos: []TemplateRepo{{Name: "api"}},
+		CI:    &CISpec{Workflows: []string{"ci.yml"}},
+	}
+	configured, warnings := setupCI(tmpl, templatePath, workspacePath, nil)
+	if !reflect.DeepEqual(configured, []string{"api"}) || len(warnings) != 0 {
+		t.Errorf("setupCI() = %v, %v", configured, warnings)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "name: mine\n" {
+		t.Errorf("existing workflow was overwritten: %q", data)
+	}
+
+	tmpl.CI.Provider = "gitlab"
+	if _, warnings := setupCI(tmpl, templatePath, workspacePath, nil); len(warnings) != 1 {
+		t.Errorf("unsupported provider warnings = %v", warnings)
+	}
+}
@@ -1,6 +1,7 @@
 package template
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -9,14 +10,25 @@ import (
 
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/fs"
-	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/lock"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 // CreateWorkspace creates a new workspace using a template.
 func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptions) (*CreateResult, error) {
+	return CreateWorkspaceContext(context.Background(), cfg, owner, project, opts)
+}
+
+// CreateWorkspaceContext creates like CreateWorkspace, killing clones and
+// hooks once ctx is done and stopping before the next step. What was created
+// so far is left in place, and the result is returned with ctx's error.
+func CreateWorkspaceContext(ctx context.Context, cfg *config.Config, owner, project string, opts CreateOptions) (*CreateResult, error) {
 	result := &CreateResult{
-		WorkspaceSlug: owner + "--" + project,
+		WorkspaceSlug: workspace.SchemeFor(cfg).Format(owner, project),
+	}
+	if err := workspace.CheckOwnerPolicy(cfg, owner, project); err != nil {
+		return nil, err
 	}
 
 	// Load template from primary or fallback directories
@@ -26,15 +38,19 @@ func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptio
 		return nil, err
 	}
 
+	if tmpl.RepoLayout == "" {
+		tmpl.RepoLayout = cfg.GetRepoLayout(owner)
+	}
+
 	templatePath := filepath.Join(templatesDir, opts.TemplateName)
 	workspacePath := cfg.WorkspacePath(result.WorkspaceSlug)
-	reposPath := filepath.Join(workspacePath, "repos")
+	reposPath := filepath.Join(workspacePath, model.RepoDir(tmpl.RepoLayout, ""))
 
 	result.WorkspacePath = workspacePath
 	result.TemplateUsed = opts.TemplateName
 
 	// Get built-in variables
-	builtins := GetBuiltinVariables(owner, project, workspacePath, cfg.CodeRoot)
+	builtins := GetBuiltinVariables(owner, project, result.WorkspaceSlug, workspacePath, cfg.CodeRoot)
 
 	// Resolve all variables
 	vars, err := ResolveVariables(tmpl, opts.Variables, builtins)
@@ -62,10 +78,11 @@ func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptio
 	if opts.Verbose {
 		output = os.Stdout
 	}
+	hooks := hookRunner{templatePath: templatePath, output: output, onEvent: opts.OnHookEvent, policy: HookRetryPolicy(cfg)}
 
-	// Run pre_create hook
+	// Run pre_create hook; nothing exists yet, so its failure aborts
 	if !opts.NoHooks && HasHook(tmpl, HookPreCreate) {
-		hookResult, err := RunHook(HookPreCreate, tmpl.Hooks.PreCreate, templatePath, hookEnv, output)
+		hookResult, _, err := hooks.run(ctx, HookPreCreate, tmpl.Hooks.PreCreate, hookEnv)
 		if err != nil {
 			return result, fmt.Errorf("pre_create hook failed: %w", err)
 		}
@@ -80,14 +97,26 @@ func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptio
 		templateFiles, _ := ListTemplateFiles(tmpl, templatePath)
 		result.GlobalFiles = len(globalFiles)
 		result.TemplateFiles = len(templateFiles)
+		if tmpl.DevContainer != nil {
+			result.TemplateFiles++
+		}
+		if tmpl.Nix != nil {
+			result.TemplateFiles++
+		}
 		result.FilesCreated = result.GlobalFiles + result.TemplateFiles
 		result.ReposCreated = len(tmpl.Repos)
 		result.Warnings = append(result.Warnings, "Dry run - no changes made")
 		return result, nil
 	}
 
+	l, err := lock.Workspace(cfg, result.WorkspaceSlug)
+	if err != nil {
+		return result, err
+	}
+	defer l.Release()
+
 	// Create workspace directory
-	workspacePath, err = fs.CreateWorkspace(cfg.CodeRoot, result.WorkspaceSlug)
+	workspacePath, err = workspace.CreateDir(cfg, result.WorkspaceSlug, tmpl.RepoLayout)
 	if err != nil {
 		return result, fmt.Errorf("creating workspace: %w", err)
 	}
@@ -98,36 +127,54 @@ func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptio
 	if err != nil {
 		return result, fmt.Errorf("processing files: %w", err)
 	}
+	devEnvCount, err := writeDevEnvFiles(tmpl, workspacePath, vars)
+	if err != nil {
+		return result, err
+	}
+	templateCount += devEnvCount
 	result.GlobalFiles = globalCount
 	result.TemplateFiles = templateCount
 	result.FilesCreated = globalCount + templateCount
 
+	if _, err := createDirectories(tmpl, workspacePath, false); err != nil {
+		return result, err
+	}
+
 	// Run post_create hook
 	if !opts.NoHooks && HasHook(tmpl, HookPostCreate) {
-		hookResult, err := RunHook(HookPostCreate, tmpl.Hooks.PostCreate, templatePath, hookEnv, output)
+		hookResult, attempts, err := hooks.run(ctx, HookPostCreate, tmpl.Hooks.PostCreate, hookEnv)
 		if err != nil {
-			return result, fmt.Errorf("post_create hook failed: %w", err)
-		}
-		if !hookResult.Skipped {
+			result.addFailure(string(HookPostCreate), tmpl.Hooks.PostCreate.Script, attempts, err)
+		} else if !hookResult.Skipped {
 			result.HooksRun = append(result.HooksRun, string(HookPostCreate))
 			hookEnv.PrevHookOutput = hookResult.Output
 		}
 	}
 
 	// Create/clone repositories
+	clonePolicy := GitRetryPolicy(cfg)
 	for _, repoSpec := range tmpl.Repos {
-		repoPath := filepath.Join(reposPath, repoSpec.Name)
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		repoPath := filepath.Join(workspacePath, tmpl.RepoDir(repoSpec))
 
-		if repoSpec.CloneURL != "" {
+		if repoSpec.CloneURL != "" && cfg.IsOffline() {
+			if err := QueueClone(cfg, result.WorkspaceSlug, repoSpec.Name, repoSpec.CloneURL, repoPath); err != nil {
+				result.addFailure("clone", repoSpec.Name, 0, err)
+				continue
+			}
+			result.PendingClones = append(result.PendingClones, repoSpec.Name)
+		} else if repoSpec.CloneURL != "" {
 			// Clone repository
-			if err := git.Clone(repoSpec.CloneURL, repoPath); err != nil {
-				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to clone %s: %v", repoSpec.Name, err))
+			if attempts, err := clonePolicy.CloneRepoContext(ctx, repoSpec.CloneURL, repoPath); err != nil {
+				result.addFailure("clone", repoSpec.Name, attempts, err)
 				continue
 			}
 			result.ReposCloned++
 		} else if repoSpec.Init {
 			// Initialize new repository
-			if err := os.MkdirAll(repoPath, 0755); err != nil {
+			if err := os.MkdirAll(repoPath, fs.DirPerm()); err != nil {
 				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to create %s: %v", repoSpec.Name, err))
 				continue
 			}
@@ -136,16 +183,30 @@ func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptio
 	}
 
 	// Run post_clone hook
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
 	if !opts.NoHooks && HasHook(tmpl, HookPostClone) {
-		hookResult, err := RunHook(HookPostClone, tmpl.Hooks.PostClone, templatePath, hookEnv, output)
+		hookResult, attempts, err := hooks.run(ctx, HookPostClone, tmpl.Hooks.PostClone, hookEnv)
 		if err != nil {
-			result.Warnings = append(result.Warnings, fmt.Sprintf("post_clone hook failed: %v", err))
+			result.addFailure(string(HookPostClone), tmpl.Hooks.PostClone.Script, attempts, err)
 		} else if !hookResult.Skipped {
 			result.HooksRun = append(result.HooksRun, string(HookPostClone))
 			hookEnv.PrevHookOutput = hookResult.Output
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	// Set up CI once repos exist and post_clone may have added remotes
+	if tmpl.CI != nil && !opts.NoCI {
+		configured, warnings := setupCI(tmpl, templatePath, workspacePath, vars)
+		result.CIRepos = configured
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
 	// Apply partials after repos and post_clone hook
 	if len(tmpl.Partials) > 0 {
 		if partialApplier == nil {
@@ -190,9 +251,10 @@ func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptio
 	}
 
 	// Create project.json
-	proj := model.NewProject(owner, project)
+	proj := model.NewProject(result.WorkspaceSlug, owner, project)
 	proj.Template = opts.TemplateName
 	proj.TemplateVars = vars
+	proj.RepoLayout = tmpl.RepoLayout
 
 	// Apply template defaults
 	if len(tmpl.Tags) > 0 {
@@ -201,21 +263,32 @@ func CreateWorkspace(cfg *config.Config, owner, project string, opts CreateOptio
 	if tmpl.State != "" {
 		proj.State = tmpl.State
 	}
+	workspace.ApplyOwnerDefaults(cfg, proj)
 
 	// Add repo specs
 	for _, repoSpec := range tmpl.Repos {
-		proj.AddRepo(repoSpec.Name, filepath.Join("repos", repoSpec.Name), repoSpec.CloneURL)
+		proj.AddRepo(repoSpec.Name, filepath.ToSlash(tmpl.RepoDir(repoSpec)), repoSpec.CloneURL)
+	}
+
+	if err := workspace.RunEventHook(cfg, workspace.EventCreate, proj, workspacePath); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("create hook failed: %v", err))
 	}
 
 	if err := proj.Save(workspacePath); err != nil {
 		return result, fmt.Errorf("saving project.json: %w", err)
 	}
 
+	shellEnvFiles, err := WriteShellEnv(cfg, workspacePath)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to write shell env: %v", err))
+	}
+	result.ShellEnvFiles = shellEnvFiles
+
 	// Run post_complete hook
 	if !opts.NoHooks && HasHook(tmpl, HookPostComplete) {
-		hookResult, err := RunHook(HookPostComplete, tmpl.Hooks.PostComplete, templatePath, hookEnv, output)
+		hookResult, attempts, err := hooks.run(ctx, HookPostComplete, tmpl.Hooks.PostComplete, hookEnv)
 		if err != nil {
-			result.Warnings = append(result.Warnings, fmt.Sprintf("post_complete hook failed: %v", err))
+			result.addFailure(string(HookPostComplete), tmpl.Hooks.PostComplete.Script, attempts, err)
 		} else if !hookResult.Skipped {
 			result.HooksRun = append(result.HooksRun, string(HookPostComplete))
 		}
@@ -288,11 +361,15 @@ func ApplyTemplateToExisting(cfg *config.Config, workspacePath, templateName str
 		TemplateUsed:  templateName,
 	}
 
-	// Extract owner and project from path
-	slug := filepath.Base(workspacePath)
-	owner, project := parseSlug(slug)
+	slug, owner, project := workspaceIdentity(cfg, workspacePath)
 	result.WorkspaceSlug = slug
 
+	l, err := lock.Workspace(cfg, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
 	// Load template from primary or fallback directories
 	templatesDirs := cfg.AllTemplatesDirs()
 	tmpl, templatesDir, err := LoadTemplateMulti(templatesDirs, templateName)
@@ -301,10 +378,14 @@ func ApplyTemplateToExisting(cfg *config.Config, workspacePath, templateName str
 	}
 
 	templatePath := filepath.Join(templatesDir, templateName)
-	reposPath := filepath.Join(workspacePath, "repos")
+	layout := ""
+	if proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json")); err == nil {
+		layout = proj.RepoLayout
+	}
+	reposPath := filepath.Join(workspacePath, model.RepoDir(layout, ""))
 
 	// Get built-in variables
-	builtins := GetBuiltinVariables(owner, project, workspacePath, cfg.CodeRoot)
+	builtins := GetBuiltinVariables(owner, project, slug, workspacePath, cfg.CodeRoot)
 
 	// Resolve all variables
 	vars, err := ResolveVariables(tmpl, opts.Variables, builtins)
@@ -317,10 +398,19 @@ func ApplyTemplateToExisting(cfg *config.Config, workspacePath, templateName str
 	if err != nil {
 		return result, fmt.Errorf("processing files: %w", err)
 	}
+	devEnvCount, err := writeDevEnvFiles(tmpl, workspacePath, vars)
+	if err != nil {
+		return result, err
+	}
+	templateCount += devEnvCount
 	result.GlobalFiles = globalCount
 	result.TemplateFiles = templateCount
 	result.FilesCreated = globalCount + templateCount
 
+	if _, err := createDirectories(tmpl, workspacePath, false); err != nil {
+		return result, err
+	}
+
 	// Create hook environment
 	hookEnv := HookEnv{
 		WorkspacePath: workspacePath,
@@ -343,9 +433,10 @@ func ApplyTemplateToExisting(cfg *config.Config, workspacePath, templateName str
 
 	// Run post_migrate hook
 	if !opts.NoHooks && HasHook(tmpl, HookPostMigrate) {
-		hookResult, err := RunHook(HookPostMigrate, tmpl.Hooks.PostMigrate, templatePath, hookEnv, output)
+		hooks := hookRunner{templatePath: templatePath, output: output, onEvent: opts.OnHookEvent, policy: HookRetryPolicy(cfg)}
+		hookResult, attempts, err := hooks.run(context.Background(), HookPostMigrate, tmpl.Hooks.PostMigrate, hookEnv)
 		if err != nil {
-			result.Warnings = append(result.Warnings, fmt.Sprintf("post_migrate hook failed: %v", err))
+			result.addFailure(string(HookPostMigrate), tmpl.Hooks.PostMigrate.Script, attempts, err)
 		} else if !hookResult.Skipped {
 			result.HooksRun = append(result.HooksRun, string(HookPostMigrate))
 		}
@@ -366,37 +457,86 @@ func ApplyTemplateToExisting(cfg *config.Config, workspacePath, templateName str
 
 	CleanupHookOutputFile(workspacePath)
 
+	// Tool versions may come from the template variables just saved
+	shellEnvFiles, err := WriteShellEnv(cfg, workspacePath)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to write shell env: %v", err))
+	}
+	result.ShellEnvFiles = shellEnvFiles
+
+	// The index file shows the template, so refresh it now that it changed
+	if err := workspace.RefreshIndex(cfg, workspacePath); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to update workspace index: %v", err))
+	}
+
 	return result, nil
 }
 
-// parseSlug extracts owner and project from a workspace slug.
-func parseSlug(slug string) (owner, project string) {
-	parts := splitSlug(slug)
-	if len(parts) >= 2 {
-		return parts[0], parts[1]
+// ApplyStructure applies a structure template (directories and files only,
+// see Template.IsStructure) to an existing workspace. Files that already
+// exist are kept unless opts.Overwrite is set, so applying the same
+// structure again is safe. Hooks are not run and project.json is left as is.
+func ApplyStructure(cfg *config.Config, workspacePath, templateName string, opts StructureOptions) (*StructureResult, error) {
+	slug, owner, project := workspaceIdentity(cfg, workspacePath)
+	result := &StructureResult{
+		WorkspacePath: workspacePath,
+		WorkspaceSlug: slug,
+		TemplateUsed:  templateName,
 	}
-	return slug, slug
-}
 
-// splitSlug splits a slug by "--".
-func splitSlug(slug string) []string {
-	result := []string{}
-	current := ""
-	i := 0
-	for i < len(slug) {
-		if i+1 < len(slug) && slug[i] == '-' && slug[i+1] == '-' {
-			if current != "" {
-				result = append(result, current)
-				current = ""
-			}
-			i += 2
-		} else {
-			current += string(slug[i])
-			i++
+	templatesDirs := cfg.AllTemplatesDirs()
+	tmpl, templatesDir, err := LoadTemplateMulti(templatesDirs, templateName)
+	if err != nil {
+		return nil, err
+	}
+	if !tmpl.IsStructure() {
+		return nil, fmt.Errorf("template %s defines repos or partials; only structure templates can be applied to existing workspaces", templateName)
+	}
+
+	if !opts.DryRun {
+		l, err := lock.Workspace(cfg, slug)
+		if err != nil {
+			return nil, err
 		}
+		defer l.Release()
+	}
+
+	builtins := GetBuiltinVariables(owner, project, slug, workspacePath, cfg.CodeRoot)
+	vars, err := ResolveVariables(tmpl, opts.Variables, builtins)
+	if err != nil {
+		return nil, fmt.Errorf("resolving variables: %w", err)
+	}
+
+	result.DirsCreated, err = createDirectories(tmpl, workspacePath, opts.DryRun)
+	if err != nil {
+		return result, err
+	}
+
+	templatePath := filepath.Join(templatesDir, templateName)
+	result.FilesWritten, result.FilesKept, err = processTemplateFiles(tmpl, templatePath, workspacePath, vars, !opts.Overwrite, opts.DryRun)
+	if err != nil {
+		return result, fmt.Errorf("processing files: %w", err)
+	}
+
+	return result, nil
+}
+
+// workspaceIdentity returns the slug, owner, and project of the workspace at
+// workspacePath from its project.json, falling back to the folder name.
+func workspaceIdentity(cfg *config.Config, workspacePath string) (slug, owner, project string) {
+	slug = filepath.Base(workspacePath)
+	owner, project = parseSlug(workspace.SchemeFor(cfg), slug)
+	if proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json")); err == nil && proj.Slug != "" {
+		slug, owner, project = proj.Slug, proj.Owner, proj.Name
 	}
-	if current != "" {
-		result = append(result, current)
+	return slug, owner, project
+}
+
+// parseSlug extracts owner and project from a workspace slug under scheme,
+// using the whole slug for both when it does not parse.
+func parseSlug(scheme workspace.SlugScheme, slug string) (owner, project string) {
+	if parsed, ok := scheme.Parse(slug); ok {
+		return parsed.Owner, parsed.Project
 	}
-	return result
+	return slug, slug
 }
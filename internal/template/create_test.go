@@ -1,14 +1,19 @@
 package template
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 // testConfig creates a test config with temp directories.
@@ -586,6 +591,281 @@ echo "Project: $CO_PROJECT" >> "$CO_WORKSPACE_PATH/hook-marker.txt"
 	}
 }
 
+func TestTryHook(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := testConfig(t, tmpDir)
+	templatesDir := cfg.TemplatesDir()
+
+	tmpl := &Template{
+		Schema:      1,
+		Name:        "tried",
+		Description: "Template with hooks to try",
+		Variables:   []TemplateVar{{Name: "port", Type: VarTypeInteger, Required: true}},
+		Repos:       []TemplateRepo{{Name: "api", Init: true}},
+		Hooks: TemplateHooks{
+			PostClone:    HookSpec{Script: "post-clone.sh"},
+			PostComplete: HookSpec{Script: "post-complete.sh"},
+		},
+	}
+	setupTestTemplate(t, templatesDir, "tried", tmpl)
+	setupTemplateFiles(t, templatesDir, "tried", map[string]string{"config.env.tmpl": "PORT={{port}}\n"})
+	setupHook(t, templatesDir, "tried", "post-clone.sh", `#!/bin/bash
+cat config.env
+ls "$CO_REPOS_PATH"
+echo "root $CO_CODE_ROOT"
+`)
+	setupHook(t, templatesDir, "tried", "post-complete.sh", "#!/bin/bash\necho broken >&2\nexit 3\n")
+
+	result, err := TryHook(context.Background(), cfg, "tried", HookPostClone)
+	if err != nil {
+		t.Fatalf("TryHook() error = %v", err)
+	}
+	for _, want := range []string{"PORT=0", "api"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output %q does not contain %q", result.Output, want)
+		}
+	}
+	root := strings.TrimSpace(result.Output[strings.Index(result.Output, "root ")+5:])
+	if strings.HasPrefix(root, tmpDir) {
+		t.Errorf("hook ran in the code root %s", root)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("temporary workspace %s was not removed", root)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 1 {
+		t.Errorf("code root has %d entries, want only _system", len(entries))
+	}
+
+	result, err = TryHook(context.Background(), cfg, "tried", HookPostComplete)
+	if err == nil || result.ExitCode != 3 || !strings.Contains(result.Output, "broken") {
+		t.Errorf("TryHook() of a failing hook = %+v, %v; want exit code 3", result, err)
+	}
+	if _, err := TryHook(context.Background(), cfg, "tried", HookPreCreate); err == nil {
+		t.Error("TryHook() of an undefined hook should fail")
+	}
+}
+
+func TestCreateWorkspaceHookEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := testConfig(t, tmpDir)
+	templatesDir := cfg.TemplatesDir()
+
+	tmpl := &Template{
+		Schema:      1,
+		Name:        "streaming",
+		Description: "Template with chatty hooks",
+		Hooks: TemplateHooks{
+			PostCreate: HookSpec{Script: "post-create.sh"},
+		},
+	}
+	setupTestTemplate(t, templatesDir, "streaming", tmpl)
+	setupHook(t, templatesDir, "streaming", "post-create.sh", "#!/bin/bash\necho one\necho oops >&2\nprintf last\n")
+
+	var events []HookEvent
+	opts := CreateOptions{
+		TemplateName: "streaming",
+		OnHookEvent:  func(e HookEvent) { events = append(events, e) },
+	}
+	if _, err := CreateWorkspace(cfg, "owner", "project", opts); err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5: %+v", len(events), events)
+	}
+	if events[0].Kind != HookStarted || events[0].Hook != HookPostCreate || events[0].Script != "post-create.sh" {
+		t.Errorf("first event = %+v, want post_create started", events[0])
+	}
+	var stdout, stderr []string
+	for _, e := range events[1:4] {
+		if e.Kind != HookOutput {
+			t.Errorf("event = %+v, want output", e)
+		}
+		if e.Stderr {
+			stderr = append(stderr, e.Line)
+		} else {
+			stdout = append(stdout, e.Line)
+		}
+	}
+	if strings.Join(stdout, ",") != "one,last" || strings.Join(stderr, ",") != "oops" {
+		t.Errorf("stdout = %v, stderr = %v", stdout, stderr)
+	}
+	last := events[4]
+	if last.Kind != HookFinished || last.Result == nil || last.Result.ExitCode != 0 || last.Result.Duration <= 0 {
+		t.Errorf("last event = %+v, want finished with exit code 0", last)
+	}
+}
+
+func TestRunObservedHookExitCode(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, TemplateHooksDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, TemplateHooksDir, "fail.sh"), []byte("#!/bin/bash\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var finished *HookResult
+	onEvent := func(e HookEvent) {
+		if e.Kind == HookFinished {
+			finished = e.Result
+		}
+	}
+	env := HookEnv{WorkspacePath: tmpDir, TemplatePath: tmpDir}
+	if _, err := runObservedHook(context.Background(), HookPostCreate, HookSpec{Script: "fail.sh"}, tmpDir, env, nil, onEvent); err == nil {
+		t.Error("runObservedHook() should fail for a non-zero exit")
+	}
+	if finished == nil || finished.ExitCode != 3 {
+		t.Errorf("finished = %+v, want exit code 3", finished)
+	}
+}
+
+func TestCreateWorkspaceRetriesAndFailures(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := testConfig(t, tmpDir)
+	cfg.Retry = &config.RetryConfig{GitRetries: 1, HookRetries: 1}
+	templatesDir := cfg.TemplatesDir()
+	slept := 0
+	retrySleep = func(time.Duration) { slept++ }
+	t.Cleanup(func() { retrySleep = time.Sleep })
+
+	tmpl := &Template{
+		Schema:      1,
+		Name:        "flaky",
+		Description: "Template with failing steps",
+		Repos:       []TemplateRepo{{Name: "missing", CloneURL: filepath.Join(tmpDir, "no-such-repo")}},
+		Hooks: TemplateHooks{
+			PostCreate: HookSpec{Script: "post-create.sh"},
+			PostClone:  HookSpec{Script: "post-clone.sh"},
+		},
+	}
+	setupTestTemplate(t, templatesDir, "flaky", tmpl)
+	// post_create fails on its first run only; post_clone always fails
+	setupHook(t, templatesDir, "flaky", "post-create.sh", "#!/bin/bash\nif [ -f first-run ]; then exit 0; fi\ntouch first-run\nexit 1\n")
+	setupHook(t, templatesDir, "flaky", "post-clone.sh", "#!/bin/bash\nexit 2\n")
+
+	result, err := CreateWorkspace(cfg, "owner", "project", CreateOptions{TemplateName: "flaky"})
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v; failed steps should not abort", err)
+	}
+	if len(result.HooksRun) != 1 || result.HooksRun[0] != "post_create" {
+		t.Errorf("HooksRun = %v, want post_create after its retry", result.HooksRun)
+	}
+	if len(result.Failures) != 2 {
+		t.Fatalf("Failures = %+v, want the clone and post_clone", result.Failures)
+	}
+	if f := result.Failures[0]; f.Step != "clone" || f.Name != "missing" || f.Attempts != 2 {
+		t.Errorf("Failures[0] = %+v, want clone of missing after 2 attempts", f)
+	}
+	if f := result.Failures[1]; f.Step != "post_clone" || f.Attempts != 2 || !strings.Contains(f.Error, "exit") {
+		t.Errorf("Failures[1] = %+v, want post_clone after 2 attempts", f)
+	}
+	if slept != 3 {
+		t.Errorf("slept %d times between attempts, want 3", slept)
+	}
+	if _, err := os.Stat(filepath.Join(result.WorkspacePath, "project.json")); err != nil {
+		t.Errorf("project.json should be written despite failures: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.WorkspacePath, "repos", "missing")); !os.IsNotExist(err) {
+		t.Error("a failed clone should leave nothing behind")
+	}
+}
+
+func TestCreateWorkspaceCancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := testConfig(t, tmpDir)
+	templatesDir := cfg.TemplatesDir()
+
+	tmpl := &Template{
+		Schema:      1,
+		Name:        "slow",
+		Description: "Template with a slow hook",
+		Repos:       []TemplateRepo{{Name: "missing", CloneURL: filepath.Join(tmpDir, "no-such-repo")}},
+		Hooks:       TemplateHooks{PostCreate: HookSpec{Script: "post-create.sh"}},
+	}
+	setupTestTemplate(t, templatesDir, "slow", tmpl)
+	setupHook(t, templatesDir, "slow", "post-create.sh", "#!/bin/bash\nsleep 30\n")
+
+	// Cancel as soon as the hook starts, as a cancel key would
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	onEvent := func(e HookEvent) {
+		if e.Kind == HookStarted {
+			cancel()
+		}
+	}
+	start := time.Now()
+	result, err := CreateWorkspaceContext(ctx, cfg, "owner", "project", CreateOptions{TemplateName: "slow", OnHookEvent: onEvent})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CreateWorkspaceContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancelled creation took %v; the hook should be killed", elapsed)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Step != "post_create" {
+		t.Errorf("Failures = %+v, want only the cancelled post_create", result.Failures)
+	}
+	if _, err := os.Stat(filepath.Join(result.WorkspacePath, "project.json")); !os.IsNotExist(err) {
+		t.Error("a cancelled creation should stop before writing project.json")
+	}
+}
+
+func TestCreateWorkspaceOffline(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := testConfig(t, tmpDir)
+	cfg.Offline = true
+	templatesDir := cfg.TemplatesDir()
+
+	remote := filepath.Join(tmpDir, "remote.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	tmpl := &Template{
+		Schema:      1,
+		Name:        "cloning",
+		Description: "Template with a repo to clone",
+		Repos:       []TemplateRepo{{Name: "api", CloneURL: remote}},
+	}
+	setupTestTemplate(t, templatesDir, "cloning", tmpl)
+
+	result, err := CreateWorkspace(cfg, "owner", "project", CreateOptions{TemplateName: "cloning"})
+	if err != nil {
+		t.Fatalf("CreateWorkspace() error = %v", err)
+	}
+	if len(result.PendingClones) != 1 || result.PendingClones[0] != "api" || result.ReposCloned != 0 {
+		t.Errorf("PendingClones = %v, ReposCloned = %d; want api queued", result.PendingClones, result.ReposCloned)
+	}
+	repoPath := filepath.Join(result.WorkspacePath, "repos", "api")
+	if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
+		t.Error("no clone should happen while offline")
+	}
+	queue, err := LoadNetworkQueue(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.Clones) != 1 || queue.Clones[0].Path != repoPath || queue.Clones[0].Workspace != result.WorkspaceSlug {
+		t.Fatalf("queue = %+v, want the api clone", queue.Clones)
+	}
+
+	if _, err := ResumeNetwork(cfg, false); !errors.Is(err, config.ErrOffline) {
+		t.Errorf("ResumeNetwork() offline = %v, want ErrOffline", err)
+	}
+	cfg.Offline = false
+	resumed, err := ResumeNetwork(cfg, false)
+	if err != nil {
+		t.Fatalf("ResumeNetwork() error = %v", err)
+	}
+	if len(resumed.Cloned) != 1 || len(resumed.Failed) != 0 {
+		t.Errorf("ResumeNetwork() = %+v, want one clone", resumed)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, ".git")); err != nil {
+		t.Errorf("repo not cloned: %v", err)
+	}
+	if _, err := os.Stat(cfg.NetworkQueuePath()); !os.IsNotExist(err) {
+		t.Error("an empty queue should be removed")
+	}
+}
+
 func TestCreateWorkspaceNoHooksFlag(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "create-test-*")
 	if err != nil {
@@ -846,7 +1126,7 @@ func TestApplyTemplateToExisting(t *testing.T) {
 	}
 
 	// Create existing project.json
-	proj := model.NewProject("owner", "project")
+	proj := model.NewProject("owner--project", "owner", "project")
 	if err := proj.Save(workspacePath); err != nil {
 		t.Fatalf("Failed to save project.json: %v", err)
 	}
@@ -919,7 +1199,7 @@ func TestApplyTemplateToExistingWithMigrateHook(t *testing.T) {
 	}
 
 	// Create existing project.json
-	proj := model.NewProject("owner", "project")
+	proj := model.NewProject("owner--project", "owner", "project")
 	if err := proj.Save(workspacePath); err != nil {
 		t.Fatalf("Failed to save project.json: %v", err)
 	}
@@ -1042,19 +1322,23 @@ This project uses Vue.
 func TestParseSlug(t *testing.T) {
 	tests := []struct {
 		slug        string
+		separator   string
 		wantOwner   string
 		wantProject string
 	}{
-		{"owner--project", "owner", "project"},
-		{"acme--web-app", "acme", "web-app"},
-		{"single", "single", "single"},
-		{"a--b--c", "a", "b"},
-		{"", "", ""},
+		{"owner--project", "--", "owner", "project"},
+		{"acme--web-app", "--", "acme", "web-app"},
+		{"single", "--", "single", "single"},
+		{"a--b--c", "--", "a", "b--c"},
+		{"a-b--c-d", "--", "a-b", "c-d"},
+		{"", "--", "", ""},
+		{"acme.web-app", ".", "acme", "web-app"},
+		{"acme--web", ".", "acme--web", "acme--web"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.slug, func(t *testing.T) {
-			owner, project := parseSlug(tt.slug)
+			owner, project := parseSlug(workspace.SlugScheme{Separator: tt.separator}, tt.slug)
 			if owner != tt.wantOwner {
 				t.Errorf("parseSlug(%q) owner = %q, want %q", tt.slug, owner, tt.wantOwner)
 			}
@@ -1065,30 +1349,82 @@ func TestParseSlug(t *testing.T) {
 	}
 }
 
-func TestSplitSlug(t *testing.T) {
-	tests := []struct {
-		slug string
-		want []string
-	}{
-		{"owner--project", []string{"owner", "project"}},
-		{"a--b--c", []string{"a", "b", "c"}},
-		{"single", []string{"single"}},
-		{"a-b--c-d", []string{"a-b", "c-d"}},
-		{"", []string{}},
+func TestApplyStructure(t *testing.T) {
+	cfg := testConfig(t, t.TempDir())
+	templatesDir := cfg.TemplatesDir()
+
+	workspacePath := cfg.WorkspacePath("owner--project")
+	if err := os.MkdirAll(filepath.Join(workspacePath, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspacePath, "docs", "README.md"), []byte("mine"), 0644); err != nil {
+		t.Fatalf("Failed to write README.md: %v", err)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.slug, func(t *testing.T) {
-			got := splitSlug(tt.slug)
-			if len(got) != len(tt.want) {
-				t.Errorf("splitSlug(%q) = %v, want %v", tt.slug, got, tt.want)
-				return
-			}
-			for i := range got {
-				if got[i] != tt.want[i] {
-					t.Errorf("splitSlug(%q)[%d] = %q, want %q", tt.slug, i, got[i], tt.want[i])
-				}
-			}
-		})
+	setupTestTemplate(t, templatesDir, "layout", &Template{
+		Schema:      1,
+		Name:        "layout",
+		Description: "Standard folders",
+		Directories: []string{"docs", "scripts", "infra/terraform"},
+	})
+	setupTemplateFiles(t, templatesDir, "layout", map[string]string{
+		"docs/README.md.tmpl":    "# {{PROJECT}}",
+		"docs/ARCHITECTURE.md":   "architecture",
+		"scripts/bootstrap.tmpl": "echo {{OWNER}}",
+	})
+	setupGlobalFiles(t, templatesDir, map[string]string{"global.txt": "global"})
+
+	// Dry run reports the plan without touching the workspace
+	result, err := ApplyStructure(cfg, workspacePath, "layout", StructureOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ApplyStructure(dry run) error = %v", err)
+	}
+	if len(result.DirsCreated) != 2 || len(result.FilesWritten) != 2 || len(result.FilesKept) != 1 {
+		t.Errorf("dry run result = %+v, want 2 dirs, 2 files, 1 kept", result)
+	}
+	if _, err := os.Stat(filepath.Join(workspacePath, "scripts")); !os.IsNotExist(err) {
+		t.Error("dry run created scripts/")
+	}
+
+	result, err = ApplyStructure(cfg, workspacePath, "layout", StructureOptions{})
+	if err != nil {
+		t.Fatalf("ApplyStructure() error = %v", err)
+	}
+	if result.WorkspaceSlug != "owner--project" {
+		t.Errorf("WorkspaceSlug = %q, want owner--project", result.WorkspaceSlug)
+	}
+	if info, err := os.Stat(filepath.Join(workspacePath, "infra", "terraform")); err != nil || !info.IsDir() {
+		t.Errorf("infra/terraform not created: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspacePath, "docs", "README.md")); string(data) != "mine" {
+		t.Errorf("docs/README.md = %q, want existing file kept", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspacePath, "scripts", "bootstrap")); string(data) != "echo owner" {
+		t.Errorf("scripts/bootstrap = %q, want substituted content", data)
+	}
+	if _, err := os.Stat(filepath.Join(workspacePath, "global.txt")); !os.IsNotExist(err) {
+		t.Error("global files should not be applied by a structure template")
+	}
+
+	// Applying again changes nothing; --overwrite replaces existing files
+	result, err = ApplyStructure(cfg, workspacePath, "layout", StructureOptions{})
+	if err != nil || len(result.DirsCreated) != 0 || len(result.FilesWritten) != 0 {
+		t.Errorf("second ApplyStructure() = %+v, %v, want nothing to do", result, err)
+	}
+	if _, err := ApplyStructure(cfg, workspacePath, "layout", StructureOptions{Overwrite: true}); err != nil {
+		t.Fatalf("ApplyStructure(overwrite) error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspacePath, "docs", "README.md")); string(data) != "# project" {
+		t.Errorf("docs/README.md = %q, want overwritten", data)
+	}
+
+	setupTestTemplate(t, templatesDir, "with-repos", &Template{
+		Schema:      1,
+		Name:        "with-repos",
+		Description: "Has repos",
+		Repos:       []TemplateRepo{{Name: "app", Init: true}},
+	})
+	if _, err := ApplyStructure(cfg, workspacePath, "with-repos", StructureOptions{}); err == nil {
+		t.Error("ApplyStructure() with a repo template should fail")
 	}
 }
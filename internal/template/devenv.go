@@ -0,0 +1,220 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/fs"
+)
+
+// Dev environment files generated from a template's devcontainer and nix
+// sections.
+const (
+	DevContainerFile = ".devcontainer/devcontainer.json"
+	FlakeFile        = "flake.nix"
+)
+
+// DefaultNixpkgs is the nixpkgs flake input used when a template's nix
+// section does not name one.
+const DefaultNixpkgs = "github:NixOS/nixpkgs/nixos-unstable"
+
+// ErrNoDevEnv is returned by UpCommand for a workspace with neither a dev
+// container nor a flake.
+var ErrNoDevEnv = errors.New("no .devcontainer/devcontainer.json or flake.nix found")
+
+// DevContainerSpec describes the .devcontainer/devcontainer.json a template
+// generates. String values may use template variables.
+type DevContainerSpec struct {
+	Image             string                 `json:"image,omitempty"`
+	Dockerfile        string                 `json:"dockerfile,omitempty"` // Relative to .devcontainer; used instead of image
+	Features          map[string]interface{} `json:"features,omitempty"`
+	ForwardPorts      []int                  `json:"forward_ports,omitempty"`
+	ContainerEnv      map[string]string      `json:"container_env,omitempty"`
+	PostCreateCommand string                 `json:"post_create_command,omitempty"`
+	Extensions        []string               `json:"extensions,omitempty"` // VS Code extension IDs
+}
+
+// NixSpec describes the flake.nix a template generates: a default dev shell
+// with the listed packages. String values may use template variables.
+type NixSpec struct {
+	Nixpkgs   string   `json:"nixpkgs,omitempty"`  // Flake URL of nixpkgs (default: DefaultNixpkgs)
+	Packages  []string `json:"packages,omitempty"` // nixpkgs attribute names, e.g. "nodejs_20"
+	ShellHook string   `json:"shell_hook,omitempty"`
+}
+
+// nixAttrPattern matches a nixpkgs attribute path such as python3Packages.pip.
+var nixAttrPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_'+-]*(\.[A-Za-z_][A-Za-z0-9_'+-]*)*$`)
+
+// writeDevEnvFiles writes the dev container and flake described by tmpl into
+// workspacePath. Files that already exist, e.g. ones the template ships
+// itself, are kept. It returns the number of files written.
+func writeDevEnvFiles(tmpl *Template, workspacePath string, vars map[string]string) (int, error) {
+	files := map[string][]byte{}
+	if tmpl.DevContainer != nil {
+		data, err := renderDevContainer(tmpl.DevContainer, vars)
+		if err != nil {
+			return 0, fmt.Errorf("devcontainer: %w", err)
+		}
+		files[DevContainerFile] = data
+	}
+	if tmpl.Nix != nil {
+		data, err := renderFlake(tmpl.Nix, vars)
+		if err != nil {
+			return 0, fmt.Errorf("nix: %w", err)
+		}
+		files[FlakeFile] = data
+	}
+
+	count := 0
+	for name, data := range files {
+		path := filepath.Join(workspacePath, filepath.FromSlash(name))
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), fs.DirPerm()); err != nil {
+			return count, err
+		}
+		if err := os.WriteFile(path, data, fs.FilePerm()); err != nil {
+			return count, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func renderDevContainer(spec *DevContainerSpec, vars map[string]string) ([]byte, error) {
+	sub := func(s string) string {
+		out, _ := ProcessTemplateContent(s, vars)
+		return out
+	}
+
+	type build struct {
+		Dockerfile string `json:"dockerfile"`
+	}
+	out := struct {
+		Name              string                 `json:"name"`
+		Image             string                 `json:"image,omitempty"`
+		Build             *build                 `json:"build,omitempty"`
+		Features          map[string]interface{} `json:"features,omitempty"`
+		ForwardPorts      []int                  `json:"forwardPorts,omitempty"`
+		ContainerEnv      map[string]string      `json:"containerEnv,omitempty"`
+		PostCreateCommand string                 `json:"postCreateCommand,omitempty"`
+		Customizations    map[string]interface{} `json:"customizations,omitempty"`
+	}{
+		Name:              vars["SLUG"],
+		Features:          spec.Features,
+		ForwardPorts:      spec.ForwardPorts,
+		PostCreateCommand: sub(spec.PostCreateCommand),
+	}
+
+	switch {
+	case spec.Dockerfile != "":
+		out.Build = &build{Dockerfile: sub(spec.Dockerfile)}
+	case spec.Image != "":
+		out.Image = sub(spec.Image)
+	default:
+		return nil, fmt.Errorf("image or dockerfile is required")
+	}
+	if len(spec.ContainerEnv) > 0 {
+		out.ContainerEnv = make(map[string]string, len(spec.ContainerEnv))
+		for k, v := range spec.ContainerEnv {
+			out.ContainerEnv[k] = sub(v)
+		}
+	}
+	if len(spec.Extensions) > 0 {
+		out.Customizations = map[string]interface{}{
+			"vscode": map[string]interface{}{"extensions": spec.Extensions},
+		}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func renderFlake(spec *NixSpec, vars map[string]string) ([]byte, error) {
+	sub := func(s string) string {
+		out, _ := ProcessTemplateContent(s, vars)
+		return out
+	}
+
+	nixpkgs := DefaultNixpkgs
+	if spec.Nixpkgs != "" {
+		nixpkgs = sub(spec.Nixpkgs)
+	}
+	var packages []string
+	for _, p := range spec.Packages {
+		p = sub(p)
+		if !nixAttrPattern.MatchString(p) {
+			return nil, fmt.Errorf("invalid package name %q", p)
+		}
+		packages = append(packages, p)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	fmt.Fprintf(&sb, "  description = %s;\n\n", nixString("Development shell for "+vars["SLUG"]))
+	fmt.Fprintf(&sb, "  inputs.nixpkgs.url = %s;\n\n", nixString(nixpkgs))
+	sb.WriteString("  outputs = { self, nixpkgs }:\n")
+	sb.WriteString("    let\n")
+	sb.WriteString("      systems = [ \"x86_64-linux\" \"aarch64-linux\" \"x86_64-darwin\" \"aarch64-darwin\" ];\n")
+	sb.WriteString("      forAllSystems = f: nixpkgs.lib.genAttrs systems (system: f nixpkgs.legacyPackages.${system});\n")
+	sb.WriteString("    in\n")
+	sb.WriteString("    {\n")
+	sb.WriteString("      devShells = forAllSystems (pkgs: {\n")
+	sb.WriteString("        default = pkgs.mkShell {\n")
+	fmt.Fprintf(&sb, "          packages = with pkgs; [ %s ];\n", strings.Join(packages, " "))
+	if hook := sub(spec.ShellHook); hook != "" {
+		sb.WriteString("          shellHook = ''\n")
+		for _, line := range strings.Split(strings.TrimRight(hook, "\n"), "\n") {
+			sb.WriteString("            " + nixIndentedLine(line) + "\n")
+		}
+		sb.WriteString("          '';\n")
+	}
+	sb.WriteString("        };\n")
+	sb.WriteString("      });\n")
+	sb.WriteString("    };\n")
+	sb.WriteString("}\n")
+	return []byte(sb.String()), nil
+}
+
+// nixString quotes s as a Nix string literal.
+func nixString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// nixIndentedLine escapes a line for a Nix indented (double single quote) string.
+func nixIndentedLine(s string) string {
+	return strings.NewReplacer("''", "'''", "${", "''${").Replace(s)
+}
+
+// UpCommand returns the command that launches a workspace's dev environment:
+// `devcontainer up` for a dev container, or `nix develop` for a flake.
+func UpCommand(workspacePath string) (*exec.Cmd, error) {
+	for _, name := range []string{DevContainerFile, ".devcontainer.json"} {
+		if _, err := os.Stat(filepath.Join(workspacePath, filepath.FromSlash(name))); err == nil {
+			if _, err := exec.LookPath("devcontainer"); err != nil {
+				return nil, fmt.Errorf("the devcontainer CLI is not installed (npm install -g @devcontainers/cli)")
+			}
+			return exec.Command("devcontainer", "up", "--workspace-folder", workspacePath), nil
+		}
+	}
+	if _, err := os.Stat(filepath.Join(workspacePath, FlakeFile)); err == nil {
+		if _, err := exec.LookPath("nix"); err != nil {
+			return nil, fmt.Errorf("nix is not installed")
+		}
+		cmd := exec.Command("nix", "develop", workspacePath)
+		cmd.Dir = workspacePath
+		return cmd, nil
+	}
+	return nil, ErrNoDevEnv
+}
@@ -0,0 +1,96 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteDevEnvFiles(t *testing.T) {
+	workspacePath := t.TempDir()
+	tmpl := &Template{
+		DevContainer: &DevContainerSpec{
+			Image:             "mcr.microsoft.com/devcontainers/go:{{GO_VERSION}}",
+			Features:          map[string]interface{}{"ghcr.io/devcontainers/features/node:1": map[string]interface{}{"version": "20"}},
+			ForwardPorts:      []int{8080},
+			ContainerEnv:      map[string]string{"APP": "{{PROJECT}}"},
+			PostCreateCommand: "make setup",
+			Extensions:        []string{"golang.go"},
+		},
+		Nix: &NixSpec{
+			Packages:  []string{"go_{{GO_VERSION_NIX}}", "nodejs_20"},
+			ShellHook: "echo '' ${HOME}",
+		},
+	}
+	vars := map[string]string{"SLUG": "acme--app", "PROJECT": "app", "GO_VERSION": "1.22", "GO_VERSION_NIX": "1_22"}
+
+	count, err := writeDevEnvFiles(tmpl, workspacePath, vars)
+	if err != nil {
+		t.Fatalf("writeDevEnvFiles() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+
+	data, err := os.ReadFile(filepath.Join(workspacePath, ".devcontainer", "devcontainer.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dc map[string]interface{}
+	if err := json.Unmarshal(data, &dc); err != nil {
+		t.Fatalf("devcontainer.json is not JSON: %v", err)
+	}
+	if dc["name"] != "acme--app" || dc["image"] != "mcr.microsoft.com/devcontainers/go:1.22" || dc["postCreateCommand"] != "make setup" {
+		t.Errorf("devcontainer.json = %s", data)
+	}
+	if env := dc["containerEnv"].(map[string]interface{}); env["APP"] != "app" {
+		t.Errorf("containerEnv = %v", env)
+	}
+
+	flake, err := os.ReadFile(filepath.Join(workspacePath, FlakeFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`description = "Development shell for acme--app";`,
+		`inputs.nixpkgs.url = "` + DefaultNixpkgs + `";`,
+		"packages = with pkgs; [ go_1_22 nodejs_20 ];",
+		"echo ''' ''${HOME}",
+	} {
+		if !strings.Contains(string(flake), want) {
+			t.Errorf("flake.nix missing %q:\n%s", want, flake)
+		}
+	}
+
+	// Existing files are kept
+	if err := os.WriteFile(filepath.Join(workspacePath, FlakeFile), []byte("{ }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(workspacePath, ".devcontainer")); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := writeDevEnvFiles(tmpl, workspacePath, vars); err != nil || count != 1 {
+		t.Errorf("second writeDevEnvFiles() = %d, %v; want 1 file", count, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspacePath, FlakeFile)); string(data) != "{ }\n" {
+		t.Errorf("flake.nix was overwritten: %s", data)
+	}
+}
+
+func TestWriteDevEnvFilesInvalid(t *testing.T) {
+	if _, err := writeDevEnvFiles(&Template{DevContainer: &DevContainerSpec{}}, t.TempDir(), nil); err == nil {
+		t.Error("devcontainer without image or dockerfile should fail")
+	}
+	if _, err := writeDevEnvFiles(&Template{Nix: &NixSpec{Packages: []string{"go; rm -rf"}}}, t.TempDir(), nil); err == nil {
+		t.Error("invalid nix package name should fail")
+	}
+}
+
+func TestUpCommandNoDevEnv(t *testing.T) {
+	if _, err := UpCommand(t.TempDir()); !errors.Is(err, ErrNoDevEnv) {
+		t.Errorf("UpCommand() error = %v, want ErrNoDevEnv", err)
+	}
+}
@@ -135,10 +135,11 @@ func scanFileForPlaceholders(filePath, relPath string, availableVars map[string]
 // truncateLine truncates a line to maxLen characters, adding ellipsis if needed.
 func truncateLine(line string, maxLen int) string {
 	line = strings.TrimSpace(line)
-	if len(line) <= maxLen {
+	runes := []rune(line)
+	if len(runes) <= maxLen {
 		return line
 	}
-	return line[:maxLen-3] + "..."
+	return string(runes[:maxLen-3]) + "..."
 }
 
 // GetUnresolvedPlaceholders returns only the placeholders that would be unresolved.
@@ -152,6 +153,20 @@ func (r *DiagnosticReport) GetUnresolvedPlaceholders() []UnresolvedPlaceholder {
 	return unresolved
 }
 
+// UnresolvedVarNames returns the names of the unresolved placeholders, each
+// once, in the order they are first found.
+func (r *DiagnosticReport) UnresolvedVarNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range r.Placeholders {
+		if !p.IsAvailable && !seen[p.VarName] {
+			seen[p.VarName] = true
+			names = append(names, p.VarName)
+		}
+	}
+	return names
+}
+
 // HasUnresolvedPlaceholders returns true if there are any unresolved placeholders.
 func (r *DiagnosticReport) HasUnresolvedPlaceholders() bool {
 	for _, p := range r.Placeholders {
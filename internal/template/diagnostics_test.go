@@ -3,7 +3,9 @@ package template
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestMatchWithDetails(t *testing.T) {
@@ -281,3 +283,53 @@ func TestGetFileMatchDetails(t *testing.T) {
 		})
 	}
 }
+
+func FuzzScanFileForPlaceholders(f *testing.F) {
+	for _, seed := range []string{
+		"port: {{port}}\nname: {{name}}",
+		"{{a}}{{b}}{{",
+		strings.Repeat("é", 50) + " {{name}}",
+		"{{ name }} {{1bad}} {{_ok}}",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, content string) {
+		path := filepath.Join(t.TempDir(), "file.txt")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		placeholders, err := scanFileForPlaceholders(path, "file.txt", map[string]string{"name": "x"})
+		if err != nil {
+			return // lines longer than the scanner buffer
+		}
+		lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+		for _, p := range placeholders {
+			if p.Line < 1 || p.Line > len(lines) || p.Column < 1 {
+				t.Fatalf("placeholder %+v is outside the content", p)
+			}
+			line := strings.TrimSuffix(lines[p.Line-1], "\r")
+			if !strings.HasPrefix(line[p.Column-1:], "{{"+p.VarName+"}}") {
+				t.Errorf("placeholder %+v does not point at {{%s}} in %q", p, p.VarName, line)
+			}
+			if p.IsAvailable != (p.VarName == "name") {
+				t.Errorf("placeholder %+v has IsAvailable = %v", p, p.IsAvailable)
+			}
+			if utf8.ValidString(content) && !utf8.ValidString(p.Context) {
+				t.Errorf("placeholder %+v has a context cut inside a character", p)
+			}
+		}
+	})
+}
+
+func TestUnresolvedVarNames(t *testing.T) {
+	report := &DiagnosticReport{Placeholders: []UnresolvedPlaceholder{
+		{VarName: "PORT"},
+		{VarName: "project", IsAvailable: true},
+		{VarName: "API_KEY"},
+		{VarName: "PORT"},
+	}}
+	got := report.UnresolvedVarNames()
+	if strings.Join(got, ",") != "PORT,API_KEY" {
+		t.Errorf("UnresolvedVarNames() = %v, want [PORT API_KEY]", got)
+	}
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	gotemplate "text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Template engines, chosen with "engine" in template.json. The engine renders
+// the template's own files; global files, hook environments, and the other
+// strings in template.json always use the simple engine.
+const (
+	EngineSimple = "simple" // {{VAR}} placeholders and {{#if}} blocks (the default)
+	EngineGo     = "go"     // Go text/template with the helpers in goTemplateFuncs
+)
+
+// RenderContent renders the content of a template file with engine, the
+// simple engine when empty. name identifies the file in errors.
+func RenderContent(engine, name, content string, vars map[string]string) (string, error) {
+	if engine != EngineGo {
+		return ProcessTemplateContent(content, vars)
+	}
+	tmpl, err := parseGoTemplate(name, content)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// parseGoTemplate parses content for the go engine. Variables are fields of
+// the data, as in {{.PROJECT}}; referring to one that does not exist is an
+// error rather than "<no value>".
+func parseGoTemplate(name, content string) (*gotemplate.Template, error) {
+	return gotemplate.New(name).Option("missingkey=error").Funcs(goTemplateFuncs).Parse(content)
+}
+
+// goTemplateFuncs are the helpers of the go engine. They are named after
+// their sprig counterparts and take arguments in the same order, so they
+// read the same in pipelines: {{.PORT | default "8080"}}.
+var goTemplateFuncs = gotemplate.FuncMap{
+	"default": func(def, value any) any {
+		if isEmptyValue(value) {
+			return def
+		}
+		return value
+	},
+	"empty":     isEmptyValue,
+	"truthy":    isTruthy,
+	"trim":      strings.TrimSpace,
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+	"replace":   func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":  func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix": func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix": func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"quote":     func(s string) string { return fmt.Sprintf("%q", s) },
+	"split":     func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":      func(sep string, list []string) string { return strings.Join(list, sep) },
+	"indent":    indent,
+	"nindent":   func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+	"toYaml": func(v any) (string, error) {
+		data, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(data), "\n"), err
+	},
+	"toJson": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// isEmptyValue reports whether v is nil or the zero value of its type, or an
+// empty slice or map.
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+// indent indents every line of s by spaces spaces.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+var (
+	// simpleSyntaxPattern matches the block syntax of the simple engine
+	simpleSyntaxPattern = regexp.MustCompile(`\{\{\s*(#if\s|/if\s*\}\})`)
+	// goSyntaxPattern matches actions only the go engine understands
+	goSyntaxPattern = regexp.MustCompile(`\{\{-?\s*(\.|\$|if\s|range\s|with\s|end\s*-?\}\}|define\s|block\s|template\s)`)
+)
+
+// CheckEngineCompatibility checks that the template files of tmpl, in the
+// template directory templatePath, suit its engine: with the go engine every
+// file must parse and not use the {{#if}} blocks of the simple engine, and
+// with the simple engine files must not use Go template actions, which it
+// would leave as they are. It returns one ValidationError per file.
+func CheckEngineCompatibility(tmpl *Template, templatePath string) []error {
+	filesPath := filepath.Join(templatePath, TemplateFilesDir)
+	extensions := tmpl.GetTemplateExtensions()
+
+	var errs []error
+	err := filepath.WalkDir(filesPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(filesPath, path)
+		if d.IsDir() || !ShouldIncludeFile(rel, tmpl.Files.Include, tmpl.Files.Exclude) || !IsTemplateFile(rel, extensions) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		field := TemplateFilesDir + "/" + filepath.ToSlash(rel)
+		content := string(data)
+
+		switch tmpl.Engine {
+		case EngineGo:
+			if simpleSyntaxPattern.MatchString(content) {
+				errs = append(errs, &ValidationError{
+					Field:  field,
+					Reason: "uses {{#if}} blocks of the simple engine; the go engine writes {{if .VAR}} ... {{end}}",
+				})
+			} else if _, err := parseGoTemplate(rel, content); err != nil {
+				errs = append(errs, &ValidationError{Field: field, Reason: err.Error()})
+			}
+		default:
+			if goSyntaxPattern.MatchString(content) {
+				errs = append(errs, &ValidationError{
+					Field:  field,
+					Reason: `uses Go template actions, which the simple engine leaves as they are; set "engine": "go"`,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	return errs
+}
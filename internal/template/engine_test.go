@@ -0,0 +1,95 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderContent(t *testing.T) {
+	vars := map[string]string{"PROJECT": "api", "PORT": "", "USE_DB": "yes", "TAGS": "web, api "}
+	tests := []struct {
+		engine  string
+		content string
+		want    string
+	}{
+		{EngineSimple, "# {{PROJECT}}{{#if USE_DB}} with a database{{/if}}", "# api with a database"},
+		{"", "{{PROJECT}} {{.PROJECT}}", "api {{.PROJECT}}"},
+		{EngineGo, "# {{.PROJECT | upper}}{{if truthy .USE_DB}} with a database{{end}}", "# API with a database"},
+		{EngineGo, `port: {{.PORT | default "8080" | quote}}`, `port: "8080"`},
+		{EngineGo, `tags:{{split "," .TAGS | toYaml | nindent 2}}`, "tags:\n  - web\n  - ' api '"},
+		{EngineGo, `{{.TAGS | trim | replace ", " "," | toJson}}`, `"web,api"`},
+	}
+	for _, tt := range tests {
+		got, err := RenderContent(tt.engine, "test", tt.content, vars)
+		if err != nil {
+			t.Errorf("RenderContent(%q, %q) error = %v", tt.engine, tt.content, err)
+		} else if got != tt.want {
+			t.Errorf("RenderContent(%q, %q) = %q, want %q", tt.engine, tt.content, got, tt.want)
+		}
+	}
+
+	if _, err := RenderContent(EngineGo, "test", "{{.MISSING}}", vars); err == nil {
+		t.Error("RenderContent() should fail on an unknown variable")
+	}
+}
+
+func TestCheckEngineCompatibility(t *testing.T) {
+	templatePath := t.TempDir()
+	for name, content := range map[string]string{
+		"legacy.md.tmpl": "{{#if USE_DB}}db{{/if}}",
+		"go.md.tmpl":     "{{/* note */}}{{if .USE_DB}}db{{end}}",
+		"broken.md.tmpl": "{{if .USE_DB}}db",
+		"plain.md":       "{{ .Values.image }}",
+	} {
+		path := filepath.Join(templatePath, TemplateFilesDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fields := func(engine string) []string {
+		t.Helper()
+		var fields []string
+		for _, err := range CheckEngineCompatibility(&Template{Engine: engine}, templatePath) {
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("CheckEngineCompatibility() error = %v", err)
+			}
+			fields = append(fields, verr.Field)
+		}
+		return fields
+	}
+	if got := strings.Join(fields(EngineGo), " "); got != "files/broken.md.tmpl files/legacy.md.tmpl" {
+		t.Errorf("go engine problems = %s", got)
+	}
+	if got := strings.Join(fields(""), " "); got != "files/broken.md.tmpl files/go.md.tmpl" {
+		t.Errorf("simple engine problems = %s", got)
+	}
+}
+
+func TestProcessTemplateFilesGoEngine(t *testing.T) {
+	templatePath := t.TempDir()
+	destDir := t.TempDir()
+	path := filepath.Join(templatePath, TemplateFilesDir, "config.yaml.tmpl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("name: {{.PROJECT}}\n{{- if .DB_NAME}}\ndb: {{.DB_NAME}}{{end}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &Template{Name: "svc", Engine: EngineGo}
+	if _, err := ProcessTemplateFiles(tmpl, templatePath, destDir, map[string]string{"PROJECT": "api", "DB_NAME": ""}); err != nil {
+		t.Fatalf("ProcessTemplateFiles() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(destDir, "config.yaml"))
+	if err != nil || string(data) != "name: api\n" {
+		t.Errorf("config.yaml = %q, %v", data, err)
+	}
+}
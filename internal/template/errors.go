@@ -213,3 +213,13 @@ func (e *MultiError) ErrorOrNil() error {
 	}
 	return e
 }
+
+// UntrustedTemplateError indicates a template whose hooks may not run
+// because it was installed from a catalog without being trusted.
+type UntrustedTemplateError struct {
+	Name string
+}
+
+func (e *UntrustedTemplateError) Error() string {
+	return fmt.Sprintf("template %s is not trusted; review its hooks and run 'co template trust %s', or use --no-hooks", e.Name, e.Name)
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/tormodhaugland/co/internal/fs"
 )
 
 // OriginType indicates where a file comes from.
@@ -288,7 +290,7 @@ func ProcessGlobalFiles(templatesDir, destPath string, vars map[string]string, s
 		destFilePath := filepath.Join(destPath, outputPath)
 
 		// Process the file
-		if err := processFile(srcPath, destFilePath, isTemplate, vars, extensions); err != nil {
+		if err := processFile(srcPath, destFilePath, isTemplate, EngineSimple, vars, extensions); err != nil {
 			return &FileProcessingError{SrcPath: srcPath, DestPath: destFilePath, Err: err}
 		}
 
@@ -301,20 +303,27 @@ func ProcessGlobalFiles(templatesDir, destPath string, vars map[string]string, s
 
 // ProcessTemplateFiles copies and processes files from a template's files directory.
 func ProcessTemplateFiles(tmpl *Template, templatePath, destPath string, vars map[string]string) (int, error) {
+	written, _, err := processTemplateFiles(tmpl, templatePath, destPath, vars, false, false)
+	return len(written), err
+}
+
+// processTemplateFiles copies and processes a template's files into destPath
+// and returns the workspace-relative paths written. With keepExisting, files
+// already present are left alone and returned as kept; with dryRun nothing
+// is written.
+func processTemplateFiles(tmpl *Template, templatePath, destPath string, vars map[string]string, keepExisting, dryRun bool) (written, kept []string, err error) {
 	filesPath := filepath.Join(templatePath, TemplateFilesDir)
 
 	// Check if files directory exists
 	if _, err := os.Stat(filesPath); os.IsNotExist(err) {
-		return 0, nil // No template files
+		return nil, nil, nil // No template files
 	}
 
 	extensions := tmpl.GetTemplateExtensions()
 	include := tmpl.Files.Include
 	exclude := tmpl.Files.Exclude
 
-	count := 0
-
-	err := filepath.Walk(filesPath, func(srcPath string, info os.FileInfo, err error) error {
+	err = filepath.Walk(filesPath, func(srcPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -357,23 +366,56 @@ func ProcessTemplateFiles(tmpl *Template, templatePath, destPath string, vars ma
 			return &PathTraversalError{Path: destFilePath, WorkspacePath: destPath}
 		}
 
+		outputPath = filepath.ToSlash(outputPath)
+		if keepExisting {
+			if _, err := os.Lstat(destFilePath); err == nil {
+				kept = append(kept, outputPath)
+				return nil
+			}
+		}
+
 		// Process the file
-		if err := processFile(srcPath, destFilePath, isTemplate, vars, extensions); err != nil {
-			return &FileProcessingError{SrcPath: srcPath, DestPath: destFilePath, Err: err}
+		if !dryRun {
+			if err := processFile(srcPath, destFilePath, isTemplate, tmpl.Engine, vars, extensions); err != nil {
+				return &FileProcessingError{SrcPath: srcPath, DestPath: destFilePath, Err: err}
+			}
 		}
 
-		count++
+		written = append(written, outputPath)
 		return nil
 	})
 
-	return count, err
+	return written, kept, err
+}
+
+// createDirectories creates the template's directories under destPath and
+// returns the ones that did not exist yet. With dryRun nothing is created.
+func createDirectories(tmpl *Template, destPath string, dryRun bool) ([]string, error) {
+	var created []string
+	for _, dir := range tmpl.Directories {
+		path := filepath.Join(destPath, dir)
+		if info, err := os.Stat(path); err == nil {
+			if !info.IsDir() {
+				return created, fmt.Errorf("%s exists and is not a directory", path)
+			}
+			continue
+		}
+		if !dryRun {
+			if err := os.MkdirAll(path, fs.DirPerm()); err != nil {
+				return created, fmt.Errorf("creating directory %s: %w", path, err)
+			}
+		}
+		created = append(created, filepath.ToSlash(filepath.Clean(dir)))
+	}
+	return created, nil
 }
 
-// processFile copies or processes a single file.
-func processFile(srcPath, destPath string, isTemplate bool, vars map[string]string, extensions []string) error {
+// processFile copies or processes a single file, rendering template files
+// with engine.
+func processFile(srcPath, destPath string, isTemplate bool, engine string, vars map[string]string, extensions []string) error {
 	// Ensure destination directory exists
 	destDir := filepath.Dir(destPath)
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := os.MkdirAll(destDir, fs.DirPerm()); err != nil {
 		return fmt.Errorf("creating directory %s: %w", destDir, err)
 	}
 
@@ -390,7 +432,7 @@ func processFile(srcPath, destPath string, isTemplate bool, vars map[string]stri
 			return fmt.Errorf("reading template: %w", err)
 		}
 
-		processed, err := ProcessTemplateContent(string(content), vars)
+		processed, err := RenderContent(engine, filepath.Base(srcPath), string(content), vars)
 		if err != nil {
 			return fmt.Errorf("processing template: %w", err)
 		}
@@ -610,7 +652,7 @@ func ProcessGlobalFilesMulti(templatesDirs []string, destPath string, vars map[s
 			destFilePath := filepath.Join(destPath, outputPath)
 
 			// Process the file
-			if err := processFile(srcPath, destFilePath, isTemplate, vars, extensions); err != nil {
+			if err := processFile(srcPath, destFilePath, isTemplate, EngineSimple, vars, extensions); err != nil {
 				return &FileProcessingError{SrcPath: srcPath, DestPath: destFilePath, Err: err}
 			}
 
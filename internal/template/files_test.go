@@ -548,7 +548,7 @@ func TestProcessFilePreservesPermissions(t *testing.T) {
 	}
 
 	// Process file
-	if err := processFile(srcFile, dstFile, false, nil, nil); err != nil {
+	if err := processFile(srcFile, dstFile, false, EngineSimple, nil, nil); err != nil {
 		t.Fatalf("processFile() error = %v", err)
 	}
 
@@ -900,7 +900,7 @@ func TestProcessFileCreatesDirectories(t *testing.T) {
 	// Destination with nested non-existent directories
 	dstFile := filepath.Join(tmpDir, "a", "b", "c", "dst.txt")
 
-	if err := processFile(srcFile, dstFile, false, nil, nil); err != nil {
+	if err := processFile(srcFile, dstFile, false, EngineSimple, nil, nil); err != nil {
 		t.Fatalf("processFile() error = %v", err)
 	}
 
@@ -932,7 +932,7 @@ func TestProcessFileAsTemplate(t *testing.T) {
 
 	vars := map[string]string{"NAME": "World"}
 
-	if err := processFile(srcFile, dstFile, true, vars, []string{".tmpl"}); err != nil {
+	if err := processFile(srcFile, dstFile, true, EngineSimple, vars, []string{".tmpl"}); err != nil {
 		t.Fatalf("processFile() error = %v", err)
 	}
 
@@ -0,0 +1,434 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// GitHooksStageDir is the directory of a workspace that core.hooksPath of
+// its repos points at in hooks_path mode.
+const GitHooksStageDir = ".githooks"
+
+// gitHooksManifest lists, in a repo's hooks directory, the hooks copied
+// there by co, so uninstall removes only those.
+const gitHooksManifest = ".co-installed"
+
+// gitHookBackupSuffix is appended to a repo's own hook when --force
+// replaces it in copy mode; uninstall restores it.
+const gitHookBackupSuffix = ".co-backup"
+
+// gitHookNames are the hooks git runs; other files in a hooks directory are
+// ignored.
+var gitHookNames = map[string]bool{
+	"applypatch-msg": true, "pre-applypatch": true, "post-applypatch": true,
+	"pre-commit": true, "pre-merge-commit": true, "prepare-commit-msg": true,
+	"commit-msg": true, "post-commit": true, "pre-rebase": true,
+	"post-checkout": true, "post-merge": true, "pre-push": true,
+	"pre-receive": true, "update": true, "proc-receive": true,
+	"post-receive": true, "post-update": true, "reference-transaction": true,
+	"push-to-checkout": true, "pre-auto-gc": true, "post-rewrite": true,
+	"sendemail-validate": true, "fsmonitor-watchman": true, "p4-changelist": true,
+	"p4-prepare-changelist": true, "p4-post-changelist": true, "p4-pre-submit": true,
+	"post-index-change": true,
+}
+
+// GitHooksOptions configures InstallGitHooks.
+type GitHooksOptions struct {
+	Mode   string // config.GitHooksPathMode or config.GitHooksCopyMode
+	Force  bool   // Replace a foreign core.hooksPath or existing hooks
+	DryRun bool
+}
+
+// GitHookRepoResult is what installing or uninstalling did in one repo.
+type GitHookRepoResult struct {
+	Repo      string   `json:"repo"`
+	Path      string   `json:"path"`
+	Installed []string `json:"installed,omitempty"` // Hooks copied, or all hooks when core.hooksPath was set
+	Removed   []string `json:"removed,omitempty"`   // Hooks removed on uninstall
+	Restored  []string `json:"restored,omitempty"`  // Backed up hooks put back on uninstall
+	Kept      []string `json:"kept,omitempty"`      // Existing hooks left in place
+	HooksPath string   `json:"hooks_path,omitempty"`
+	Skipped   string   `json:"skipped,omitempty"` // Why the repo was left alone
+	Error     string   `json:"error,omitempty"`
+}
+
+// GitHooksResult is the outcome of InstallGitHooks or UninstallGitHooks for
+// a workspace.
+type GitHooksResult struct {
+	WorkspaceSlug string              `json:"workspace"`
+	Mode          string              `json:"mode,omitempty"`
+	Hooks         []string            `json:"hooks,omitempty"`
+	Repos         []GitHookRepoResult `json:"repos"`
+}
+
+// Failed returns the number of repos that failed.
+func (r *GitHooksResult) Failed() int {
+	n := 0
+	for _, repo := range r.Repos {
+		if repo.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// GitHookSources returns the git hooks to install in a workspace, by hook
+// name: those in the git_hooks directory of the config, overridden by those
+// in the git-hooks directory of the workspace's template. A template that was
+// installed from a catalog and is not trusted may not supply hooks.
+func GitHookSources(cfg *config.Config, proj *model.Project) (map[string]string, error) {
+	sources := make(map[string]string)
+	dirs := []string{cfg.GetGitHooksConfig().Dir}
+	if proj != nil && proj.Template != "" {
+		if templatesDir, err := FindTemplateDir(cfg.AllTemplatesDirs(), proj.Template); err == nil {
+			templatePath := filepath.Join(templatesDir, proj.Template)
+			dir := filepath.Join(templatePath, TemplateGitHooksDir)
+			if _, err := os.Stat(dir); err == nil {
+				if _, trusted := TrustStatus(templatePath); !trusted {
+					return nil, &UntrustedTemplateError{Name: proj.Template}
+				}
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read git hooks: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !gitHookNames[entry.Name()] {
+				continue
+			}
+			sources[entry.Name()] = filepath.Join(dir, entry.Name())
+		}
+	}
+	return sources, nil
+}
+
+// InstallGitHooks installs the git hooks of GitHookSources into every repo of
+// the workspace slug. In hooks_path mode the hooks are copied to the
+// workspace's .githooks directory and core.hooksPath of each repo points
+// there; in copy mode they are copied into each repo's hooks directory.
+// Repos with their own core.hooksPath, and existing hooks not installed by
+// co, are left alone unless opts.Force is set.
+func InstallGitHooks(cfg *config.Config, slug string, opts GitHooksOptions) (*GitHooksResult, error) {
+	workspacePath := cfg.WorkspacePath(slug)
+	proj, repos, err := gitHookRepos(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := GitHookSources(cfg, proj)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no git hooks found in %s or the workspace's template", cfg.GetGitHooksConfig().Dir)
+	}
+
+	result := &GitHooksResult{WorkspaceSlug: slug, Mode: opts.Mode}
+	for name := range sources {
+		result.Hooks = append(result.Hooks, name)
+	}
+	sort.Strings(result.Hooks)
+
+	stageDir := filepath.Join(workspacePath, GitHooksStageDir)
+	if opts.Mode == config.GitHooksPathMode && !opts.DryRun {
+		if err := stageGitHooks(stageDir, sources); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, repo := range repos {
+		r := GitHookRepoResult{Repo: repo.name, Path: repo.path}
+		var err error
+		if opts.Mode == config.GitHooksCopyMode {
+			err = copyGitHooks(&r, stageDir, sources, result.Hooks, opts)
+		} else {
+			err = pointGitHooks(&r, stageDir, result.Hooks, opts)
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		result.Repos = append(result.Repos, r)
+	}
+	return result, nil
+}
+
+// UninstallGitHooks undoes InstallGitHooks in every repo of the workspace
+// slug, in either mode: core.hooksPath is unset where it points at the
+// workspace's .githooks, hooks copied by co are removed, and the hooks
+// they replaced are restored.
+func UninstallGitHooks(cfg *config.Config, slug string, dryRun bool) (*GitHooksResult, error) {
+	workspacePath := cfg.WorkspacePath(slug)
+	_, repos, err := gitHookRepos(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GitHooksResult{WorkspaceSlug: slug}
+	stageDir := filepath.Join(workspacePath, GitHooksStageDir)
+	for _, repo := range repos {
+		r := GitHookRepoResult{Repo: repo.name, Path: repo.path}
+		if err := removeGitHooks(&r, stageDir, dryRun); err != nil {
+			r.Error = err.Error()
+		}
+		result.Repos = append(result.Repos, r)
+	}
+
+	if !dryRun {
+		entries, _ := os.ReadDir(stageDir)
+		for _, entry := range entries {
+			if gitHookNames[entry.Name()] {
+				os.Remove(filepath.Join(stageDir, entry.Name()))
+			}
+		}
+		os.Remove(stageDir) // Only if empty
+	}
+	return result, nil
+}
+
+type gitHookRepo struct {
+	name string
+	path string
+}
+
+// gitHookRepos returns the project of a workspace, if it has one, and its git
+// repos: those in project.json and any others under repos/.
+func gitHookRepos(workspacePath string) (*model.Project, []gitHookRepo, error) {
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to load project.json: %w", err)
+	}
+
+	dirs := make(map[string]string)
+	located := &model.Project{}
+	if proj != nil {
+		located = proj
+		for _, r := range proj.Repos {
+			dirs[r.Name] = filepath.Join(workspacePath, r.Path)
+		}
+	}
+	onDisk, err := located.LocateRepos(workspacePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list repos: %w", err)
+	}
+	for _, repo := range onDisk {
+		if _, ok := dirs[repo.Name]; !ok {
+			dirs[repo.Name] = filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
+		}
+	}
+
+	var repos []gitHookRepo
+	for name, path := range dirs {
+		if git.IsRepo(path) {
+			repos = append(repos, gitHookRepo{name: name, path: path})
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].name < repos[j].name })
+	return proj, repos, nil
+}
+
+// stageGitHooks copies the hooks into dir, removing hooks left there by an
+// earlier install that are no longer sourced.
+func stageGitHooks(dir string, sources map[string]string) error {
+	if err := os.MkdirAll(dir, fs.DirPerm()); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, ok := sources[entry.Name()]; !ok && gitHookNames[entry.Name()] {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+	for name, src := range sources {
+		if err := copyGitHook(src, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pointGitHooks sets core.hooksPath of a repo to stageDir.
+func pointGitHooks(r *GitHookRepoResult, stageDir string, hooks []string, opts GitHooksOptions) error {
+	current := git.GetConfig(r.Path, "core.hooksPath")
+	if current != "" && !sameHooksPath(r.Path, current, stageDir) && !opts.Force {
+		r.Skipped = "core.hooksPath already set to " + current
+		return nil
+	}
+	r.HooksPath = stageDir
+	r.Installed = hooks
+	if opts.DryRun {
+		return nil
+	}
+	return git.SetConfig(r.Path, "core.hooksPath", stageDir)
+}
+
+// copyGitHooks copies the hooks into a repo's hooks directory.
+func copyGitHooks(r *GitHookRepoResult, stageDir string, sources map[string]string, hooks []string, opts GitHooksOptions) error {
+	current := git.GetConfig(r.Path, "core.hooksPath")
+	if current != "" && !sameHooksPath(r.Path, current, stageDir) && !opts.Force {
+		r.Skipped = "core.hooksPath set to " + current + ", so copied hooks would not run"
+		return nil
+	}
+
+	commonDir, err := git.CommonDir(r.Path)
+	if err != nil {
+		return fmt.Errorf("failed to find git directory: %w", err)
+	}
+	hooksDir := filepath.Join(commonDir, "hooks")
+	r.HooksPath = hooksDir
+	installed := readGitHooksManifest(hooksDir)
+
+	for _, name := range hooks {
+		dest := filepath.Join(hooksDir, name)
+		if _, err := os.Stat(dest); err == nil && !installed[name] {
+			if !opts.Force {
+				r.Kept = append(r.Kept, name)
+				continue
+			}
+			if !opts.DryRun {
+				if err := os.Rename(dest, dest+gitHookBackupSuffix); err != nil {
+					return fmt.Errorf("failed to back up %s: %w", name, err)
+				}
+			}
+		}
+		r.Installed = append(r.Installed, name)
+		if opts.DryRun {
+			continue
+		}
+		if err := copyGitHook(sources[name], dest); err != nil {
+			return err
+		}
+		installed[name] = true
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+	if current != "" {
+		if err := git.UnsetConfig(r.Path, "core.hooksPath"); err != nil {
+			return fmt.Errorf("failed to unset core.hooksPath: %w", err)
+		}
+	}
+	return writeGitHooksManifest(hooksDir, installed)
+}
+
+// removeGitHooks undoes both install modes in a repo.
+func removeGitHooks(r *GitHookRepoResult, stageDir string, dryRun bool) error {
+	if current := git.GetConfig(r.Path, "core.hooksPath"); current != "" && sameHooksPath(r.Path, current, stageDir) {
+		r.HooksPath = current
+		if !dryRun {
+			if err := git.UnsetConfig(r.Path, "core.hooksPath"); err != nil {
+				return fmt.Errorf("failed to unset core.hooksPath: %w", err)
+			}
+		}
+	}
+
+	commonDir, err := git.CommonDir(r.Path)
+	if err != nil {
+		return fmt.Errorf("failed to find git directory: %w", err)
+	}
+	hooksDir := filepath.Join(commonDir, "hooks")
+	installed := readGitHooksManifest(hooksDir)
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dest := filepath.Join(hooksDir, name)
+		r.Removed = append(r.Removed, name)
+		_, backupErr := os.Stat(dest + gitHookBackupSuffix)
+		if backupErr == nil {
+			r.Restored = append(r.Restored, name)
+		}
+		if dryRun {
+			continue
+		}
+		if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+		if backupErr == nil {
+			if err := os.Rename(dest+gitHookBackupSuffix, dest); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", name, err)
+			}
+		}
+	}
+	if r.HooksPath == "" && len(names) == 0 {
+		r.Skipped = "no hooks installed by co"
+	}
+	if dryRun || len(names) == 0 {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(hooksDir, gitHooksManifest)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// sameHooksPath reports whether a core.hooksPath value, relative to the
+// repo when not absolute, is dir.
+func sameHooksPath(repoPath, hooksPath, dir string) bool {
+	if !filepath.IsAbs(hooksPath) {
+		hooksPath = filepath.Join(repoPath, hooksPath)
+	}
+	return filepath.Clean(hooksPath) == filepath.Clean(dir)
+}
+
+func copyGitHook(src, dest string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read hook: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), fs.DirPerm()); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace hook: %w", err)
+	}
+	if err := os.WriteFile(dest, data, fs.ExecPerm()); err != nil {
+		return fmt.Errorf("failed to write hook: %w", err)
+	}
+	return nil
+}
+
+func readGitHooksManifest(hooksDir string) map[string]bool {
+	installed := make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(hooksDir, gitHooksManifest))
+	if err != nil {
+		return installed
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if name := strings.TrimSpace(line); gitHookNames[name] {
+			installed[name] = true
+		}
+	}
+	return installed
+}
+
+func writeGitHooksManifest(hooksDir string, installed map[string]bool) error {
+	names := make([]string, 0, len(installed))
+	for name := range installed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data := strings.Join(names, "\n") + "\n"
+	return os.WriteFile(filepath.Join(hooksDir, gitHooksManifest), []byte(data), fs.FilePerm())
+}
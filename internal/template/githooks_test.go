@@ -0,0 +1,156 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func setupGitHooksWorkspace(t *testing.T) (*config.Config, string) {
+	t.Helper()
+	hooksDir := t.TempDir()
+	for name, content := range map[string]string{
+		"pre-commit":        "#!/bin/sh\nexit 0\n",
+		"commit-msg":        "#!/bin/sh\nexit 0\n",
+		"pre-commit.sample": "ignored",
+		"README.md":         "ignored",
+	} {
+		if err := os.WriteFile(filepath.Join(hooksDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{CodeRoot: t.TempDir(), GitHooks: &config.GitHooksConfig{Dir: hooksDir}}
+	slug := "acme--api"
+	for _, repo := range []string{"api", "web"} {
+		if out, err := exec.Command("git", "init", "--quiet", filepath.Join(cfg.WorkspacePath(slug), "repos", repo)).CombinedOutput(); err != nil {
+			t.Fatalf("git init: %v: %s", err, out)
+		}
+	}
+	return cfg, slug
+}
+
+func TestInstallGitHooksPath(t *testing.T) {
+	cfg, slug := setupGitHooksWorkspace(t)
+	apiPath := filepath.Join(cfg.WorkspacePath(slug), "repos", "api")
+	webPath := filepath.Join(cfg.WorkspacePath(slug), "repos", "web")
+	if err := git.SetConfig(webPath, "core.hooksPath", "/elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := InstallGitHooks(cfg, slug, GitHooksOptions{Mode: config.GitHooksPathMode})
+	if err != nil {
+		t.Fatalf("InstallGitHooks() error = %v", err)
+	}
+	if want := []string{"commit-msg", "pre-commit"}; !reflect.DeepEqual(result.Hooks, want) {
+		t.Errorf("Hooks = %v, want %v", result.Hooks, want)
+	}
+	stageDir := filepath.Join(cfg.WorkspacePath(slug), GitHooksStageDir)
+	if got := git.GetConfig(apiPath, "core.hooksPath"); got != stageDir {
+		t.Errorf("api core.hooksPath = %q, want %q", got, stageDir)
+	}
+	if info, err := os.Stat(filepath.Join(stageDir, "pre-commit")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("staged pre-commit = %v, %v; want executable", info, err)
+	}
+	if got := git.GetConfig(webPath, "core.hooksPath"); got != "/elsewhere" || result.Repos[1].Skipped == "" {
+		t.Errorf("web core.hooksPath = %q, skipped %q; want its own path kept", got, result.Repos[1].Skipped)
+	}
+
+	if _, err := UninstallGitHooks(cfg, slug, false); err != nil {
+		t.Fatalf("UninstallGitHooks() error = %v", err)
+	}
+	if got := git.GetConfig(apiPath, "core.hooksPath"); got != "" {
+		t.Errorf("api core.hooksPath after uninstall = %q", got)
+	}
+	if got := git.GetConfig(webPath, "core.hooksPath"); got != "/elsewhere" {
+		t.Errorf("web core.hooksPath after uninstall = %q", got)
+	}
+	if _, err := os.Stat(stageDir); !os.IsNotExist(err) {
+		t.Errorf("%s should be removed, stat error = %v", stageDir, err)
+	}
+}
+
+func TestInstallGitHooksCopy(t *testing.T) {
+	cfg, slug := setupGitHooksWorkspace(t)
+	hooksDir := filepath.Join(cfg.WorkspacePath(slug), "repos", "api", ".git", "hooks")
+	own := filepath.Join(hooksDir, "pre-commit")
+	if err := os.WriteFile(own, []byte("#!/bin/sh\necho own\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := InstallGitHooks(cfg, slug, GitHooksOptions{Mode: config.GitHooksCopyMode})
+	if err != nil {
+		t.Fatalf("InstallGitHooks() error = %v", err)
+	}
+	api := result.Repos[0]
+	if !reflect.DeepEqual(api.Installed, []string{"commit-msg"}) || !reflect.DeepEqual(api.Kept, []string{"pre-commit"}) {
+		t.Errorf("api installed %v, kept %v; want commit-msg installed and pre-commit kept", api.Installed, api.Kept)
+	}
+
+	if _, err := InstallGitHooks(cfg, slug, GitHooksOptions{Mode: config.GitHooksCopyMode, Force: true}); err != nil {
+		t.Fatalf("InstallGitHooks() with force error = %v", err)
+	}
+	if data, _ := os.ReadFile(own); string(data) != "#!/bin/sh\nexit 0\n" {
+		t.Errorf("pre-commit = %q, want the installed hook", data)
+	}
+
+	result, err = UninstallGitHooks(cfg, slug, false)
+	if err != nil {
+		t.Fatalf("UninstallGitHooks() error = %v", err)
+	}
+	if !reflect.DeepEqual(result.Repos[0].Restored, []string{"pre-commit"}) {
+		t.Errorf("Restored = %v, want [pre-commit]", result.Repos[0].Restored)
+	}
+	if data, _ := os.ReadFile(own); string(data) != "#!/bin/sh\necho own\n" {
+		t.Errorf("pre-commit after uninstall = %q, want the repo's own hook", data)
+	}
+	for _, name := range []string{"commit-msg", gitHooksManifest, "pre-commit" + gitHookBackupSuffix} {
+		if _, err := os.Stat(filepath.Join(hooksDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed, stat error = %v", name, err)
+		}
+	}
+}
+
+func TestGitHookSourcesTrust(t *testing.T) {
+	cfg, _ := setupGitHooksWorkspace(t)
+	templatesDir := cfg.TemplatesDir()
+	dir := filepath.Join(templatesDir, "remote", TemplateGitHooksDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pre-push"), []byte("#!/bin/sh\nexit 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templatesDir, "remote", TemplateManifestFile), []byte(`{"name": "remote"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	proj := &model.Project{Template: "remote"}
+
+	records := map[string]TrustRecord{"remote": {Source: "https://example.com/remote.tar.gz", InstalledAt: time.Now()}}
+	if err := SaveTrust(templatesDir, records); err != nil {
+		t.Fatal(err)
+	}
+	var untrusted *UntrustedTemplateError
+	if _, err := GitHookSources(cfg, proj); !errors.As(err, &untrusted) {
+		t.Fatalf("GitHookSources() for an untrusted template = %v, want *UntrustedTemplateError", err)
+	}
+
+	if err := SetTrusted(templatesDir, "remote", true); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := GitHookSources(cfg, proj)
+	if err != nil {
+		t.Fatalf("GitHookSources() for a trusted template error = %v", err)
+	}
+	if _, ok := sources["pre-push"]; !ok {
+		t.Errorf("sources = %v, want the template's pre-push", sources)
+	}
+}
@@ -0,0 +1,169 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// GlobalFileInfo describes a single _global file and how templates and
+// workspaces relate to it.
+type GlobalFileInfo struct {
+	OutputPath   string   `json:"output_path"`             // Workspace-relative output path
+	SourcePath   string   `json:"source_path"`             // Absolute path to the winning source file
+	SourceRel    string   `json:"source_rel"`              // Path relative to the _global directory
+	OriginDir    string   `json:"origin_dir"`              // Templates directory the file comes from
+	IsTemplate   bool     `json:"is_template"`             // True if the source is a .tmpl file
+	ShadowedIn   []string `json:"shadowed_in,omitempty"`   // Lower-priority templates dirs with the same file
+	OverriddenBy []string `json:"overridden_by,omitempty"` // Templates whose files/ replace this file
+	SkippedBy    []string `json:"skipped_by,omitempty"`    // Templates that skip this file via skip_global_files
+	Workspaces   []string `json:"workspaces,omitempty"`    // Workspaces that received this file
+}
+
+// WorkspaceTemplateRef identifies a workspace and the template it was created with.
+type WorkspaceTemplateRef struct {
+	Slug     string
+	Path     string
+	Template string
+}
+
+// ListGlobalFileInfos returns every _global file across all template directories
+// with override and skip information for each template.
+// Files from earlier directories take precedence, matching ProcessGlobalFilesMulti.
+func ListGlobalFileInfos(templatesDirs []string) ([]GlobalFileInfo, error) {
+	extensions := []string{".tmpl"}
+	byOutput := make(map[string]*GlobalFileInfo)
+
+	for _, templatesDir := range templatesDirs {
+		globalPath := GetGlobalFilesPath(templatesDir)
+		if _, err := os.Stat(globalPath); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.Walk(globalPath, func(srcPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(globalPath, srcPath)
+			if err != nil {
+				return err
+			}
+
+			outputPath := relPath
+			isTemplate := IsTemplateFile(relPath, extensions)
+			if isTemplate {
+				outputPath = StripTemplateExtension(relPath, extensions)
+			}
+
+			// Earlier directory wins; remember where the file is shadowed
+			if existing, ok := byOutput[outputPath]; ok {
+				existing.ShadowedIn = append(existing.ShadowedIn, templatesDir)
+				return nil
+			}
+
+			byOutput[outputPath] = &GlobalFileInfo{
+				OutputPath: outputPath,
+				SourcePath: srcPath,
+				SourceRel:  relPath,
+				OriginDir:  templatesDir,
+				IsTemplate: isTemplate,
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walking global dir %s: %w", globalPath, err)
+		}
+	}
+
+	if len(byOutput) == 0 {
+		return []GlobalFileInfo{}, nil
+	}
+
+	listings, _, err := ListTemplateListingsMulti(templatesDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, listing := range listings {
+		tmpl, err := LoadTemplate(listing.SourceDir, listing.Info.Name)
+		if err != nil {
+			continue
+		}
+
+		skipAll := tmpl.ShouldSkipGlobal()
+		skipList := tmpl.GetSkippedGlobalFiles()
+		for _, info := range byOutput {
+			if skipAll || isSkippedGlobalFile(info.SourceRel, skipList) {
+				info.SkippedBy = append(info.SkippedBy, tmpl.Name)
+			}
+		}
+
+		overrides, err := GetOverriddenGlobalFiles(tmpl, templatesDirs, listing.TemplatePath)
+		if err != nil {
+			continue
+		}
+		for _, o := range overrides {
+			if info, ok := byOutput[o.OutputPath]; ok {
+				info.OverriddenBy = append(info.OverriddenBy, tmpl.Name)
+			}
+		}
+	}
+
+	result := make([]GlobalFileInfo, 0, len(byOutput))
+	for _, info := range byOutput {
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].OutputPath < result[j].OutputPath
+	})
+
+	return result, nil
+}
+
+// ResolveGlobalFileWorkspaces fills in GlobalFileInfo.Workspaces for each file.
+// A workspace counts as having received a global file when it was created from a
+// template that neither skips nor overrides the file, and the file exists on disk.
+func ResolveGlobalFileWorkspaces(infos []GlobalFileInfo, workspaces []WorkspaceTemplateRef) {
+	for i := range infos {
+		info := &infos[i]
+		info.Workspaces = nil
+		for _, ws := range workspaces {
+			if ws.Template == "" {
+				continue
+			}
+			if slices.Contains(info.SkippedBy, ws.Template) || slices.Contains(info.OverriddenBy, ws.Template) {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(ws.Path, info.OutputPath)); err != nil {
+				continue
+			}
+			info.Workspaces = append(info.Workspaces, ws.Slug)
+		}
+	}
+}
+
+// FindGlobalFileInfo returns the info for the given output or source-relative path.
+func FindGlobalFileInfo(infos []GlobalFileInfo, path string) *GlobalFileInfo {
+	for i := range infos {
+		if infos[i].OutputPath == path || infos[i].SourceRel == path {
+			return &infos[i]
+		}
+	}
+	return nil
+}
+
+// isSkippedGlobalFile mirrors the skip matching used when processing global files.
+func isSkippedGlobalFile(relPath string, skipList []string) bool {
+	for _, skip := range skipList {
+		if relPath == skip || filepath.Base(relPath) == skip {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListGlobalFileInfos(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "globals-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	primaryDir := filepath.Join(tmpDir, "primary")
+	fallbackDir := filepath.Join(tmpDir, "fallback")
+
+	setupGlobalFiles(t, primaryDir, map[string]string{
+		".editorconfig":  "root = true",
+		"AGENTS.md.tmpl": "# {{PROJECT}}",
+	})
+	setupGlobalFiles(t, fallbackDir, map[string]string{
+		".editorconfig": "fallback",
+		".gitignore":    "node_modules/",
+	})
+
+	setupTestTemplate(t, primaryDir, "go-service", &Template{Schema: 1, Name: "go-service", Description: "Go service"})
+	setupTemplateFiles(t, primaryDir, "go-service", map[string]string{
+		".gitignore": "bin/",
+	})
+	setupTestTemplate(t, primaryDir, "minimal", &Template{
+		Schema:          1,
+		Name:            "minimal",
+		Description:     "Minimal workspace",
+		SkipGlobalFiles: []interface{}{"AGENTS.md.tmpl"},
+	})
+
+	infos, err := ListGlobalFileInfos([]string{primaryDir, fallbackDir})
+	if err != nil {
+		t.Fatalf("ListGlobalFileInfos() error = %v", err)
+	}
+
+	if len(infos) != 3 {
+		t.Fatalf("got %d global files, want 3", len(infos))
+	}
+
+	editorconfig := FindGlobalFileInfo(infos, ".editorconfig")
+	if editorconfig == nil {
+		t.Fatal(".editorconfig not found")
+	}
+	if editorconfig.OriginDir != primaryDir {
+		t.Errorf(".editorconfig OriginDir = %q, want %q", editorconfig.OriginDir, primaryDir)
+	}
+	if !reflect.DeepEqual(editorconfig.ShadowedIn, []string{fallbackDir}) {
+		t.Errorf(".editorconfig ShadowedIn = %v, want [%s]", editorconfig.ShadowedIn, fallbackDir)
+	}
+
+	gitignore := FindGlobalFileInfo(infos, ".gitignore")
+	if gitignore == nil {
+		t.Fatal(".gitignore not found")
+	}
+	if !reflect.DeepEqual(gitignore.OverriddenBy, []string{"go-service"}) {
+		t.Errorf(".gitignore OverriddenBy = %v, want [go-service]", gitignore.OverriddenBy)
+	}
+
+	agents := FindGlobalFileInfo(infos, "AGENTS.md")
+	if agents == nil {
+		t.Fatal("AGENTS.md not found")
+	}
+	if !agents.IsTemplate {
+		t.Error("AGENTS.md should be marked as template")
+	}
+	if !reflect.DeepEqual(agents.SkippedBy, []string{"minimal"}) {
+		t.Errorf("AGENTS.md SkippedBy = %v, want [minimal]", agents.SkippedBy)
+	}
+	if FindGlobalFileInfo(infos, "AGENTS.md.tmpl") != agents {
+		t.Error("lookup by source-relative path should return the same entry")
+	}
+}
+
+func TestListGlobalFileInfosNoGlobalDir(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "globals-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	infos, err := ListGlobalFileInfos([]string{tmpDir})
+	if err != nil {
+		t.Fatalf("ListGlobalFileInfos() error = %v", err)
+	}
+	if len(infos) != 0 {
+		t.Errorf("got %d global files, want 0", len(infos))
+	}
+}
+
+func TestResolveGlobalFileWorkspaces(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "globals-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	mkWorkspace := func(slug string, files ...string) string {
+		path := filepath.Join(tmpDir, slug)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("Failed to create workspace: %v", err)
+		}
+		for _, f := range files {
+			if err := os.WriteFile(filepath.Join(path, f), []byte("x"), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", f, err)
+			}
+		}
+		return path
+	}
+
+	infos := []GlobalFileInfo{
+		{OutputPath: ".gitignore", OverriddenBy: []string{"go-service"}},
+		{OutputPath: "AGENTS.md", SkippedBy: []string{"minimal"}},
+	}
+	workspaces := []WorkspaceTemplateRef{
+		{Slug: "acme--api", Path: mkWorkspace("acme--api", ".gitignore", "AGENTS.md"), Template: "go-service"},
+		{Slug: "acme--web", Path: mkWorkspace("acme--web", ".gitignore", "AGENTS.md"), Template: "node"},
+		{Slug: "acme--tiny", Path: mkWorkspace("acme--tiny", ".gitignore"), Template: "minimal"},
+		{Slug: "acme--manual", Path: mkWorkspace("acme--manual", ".gitignore", "AGENTS.md")},
+	}
+
+	ResolveGlobalFileWorkspaces(infos, workspaces)
+
+	if want := []string{"acme--web", "acme--tiny"}; !reflect.DeepEqual(infos[0].Workspaces, want) {
+		t.Errorf(".gitignore Workspaces = %v, want %v", infos[0].Workspaces, want)
+	}
+	if want := []string{"acme--api", "acme--web"}; !reflect.DeepEqual(infos[1].Workspaces, want) {
+		t.Errorf("AGENTS.md Workspaces = %v, want %v", infos[1].Workspaces, want)
+	}
+}
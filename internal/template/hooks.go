@@ -10,7 +10,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 // HookType represents the type of lifecycle hook.
@@ -24,6 +30,123 @@ const (
 	HookPostMigrate  HookType = "post_migrate"
 )
 
+// lockedBuffer is a bytes.Buffer safe for concurrent writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// HookEventKind tells the events of a hook run apart.
+type HookEventKind int
+
+const (
+	HookStarted  HookEventKind = iota
+	HookOutput                 // One line of output
+	HookFinished               // Result is set
+)
+
+// HookEvent reports the progress of a hook to CreateOptions.OnHookEvent.
+type HookEvent struct {
+	Kind   HookEventKind
+	Hook   HookType
+	Script string
+	Line   string      // Output line, without the newline
+	Stderr bool        // Line was written to stderr
+	Result *HookResult // Exit code, duration, and error of the finished hook
+}
+
+// lineWriter calls emit for every line written to it.
+type lineWriter struct {
+	buf  []byte
+	emit func(line string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush emits a last line that did not end in a newline.
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// runObservedHook runs a hook, reporting its start, output lines, and result
+// to onEvent. Without onEvent, output goes to output as with RunHook.
+func runObservedHook(ctx context.Context, hookType HookType, spec HookSpec, templatePath string, env HookEnv, output io.Writer, onEvent func(HookEvent)) (*HookResult, error) {
+	if onEvent == nil {
+		return RunHookStreamsContext(ctx, hookType, spec, templatePath, env, output, output)
+	}
+
+	var mu sync.Mutex
+	send := func(e HookEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		e.Hook, e.Script = hookType, spec.Script
+		onEvent(e)
+	}
+	stdout := &lineWriter{emit: func(line string) { send(HookEvent{Kind: HookOutput, Line: line}) }}
+	stderr := &lineWriter{emit: func(line string) { send(HookEvent{Kind: HookOutput, Line: line, Stderr: true}) }}
+
+	send(HookEvent{Kind: HookStarted})
+	result, err := RunHookStreamsContext(ctx, hookType, spec, templatePath, env, stdout, stderr)
+	stdout.flush()
+	stderr.flush()
+	send(HookEvent{Kind: HookFinished, Result: result})
+	return result, err
+}
+
+// hookRunner runs the hooks of a template during creation, reporting them
+// to onEvent and retrying failed runs as policy allows.
+type hookRunner struct {
+	templatePath string
+	output       io.Writer
+	onEvent      func(HookEvent)
+	policy       RetryPolicy
+}
+
+// run runs one hook, giving it policy.Timeout when it sets no timeout of its
+// own, and killing it when ctx is done. It returns the result of the last
+// attempt and the number of attempts.
+func (h hookRunner) run(ctx context.Context, hookType HookType, spec HookSpec, env HookEnv) (*HookResult, int, error) {
+	if spec.Timeout == "" && h.policy.Timeout >= time.Second {
+		spec.Timeout = fmt.Sprintf("%ds", int(h.policy.Timeout.Seconds()))
+	}
+	runOnce := h.policy
+	runOnce.Timeout = 0 // RunHookStreams enforces spec.Timeout
+
+	var result *HookResult
+	attempts, err := runOnce.DoContext(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = runObservedHook(ctx, hookType, spec, h.templatePath, env, h.output, h.onEvent)
+		return err
+	}, retryableHookError)
+	return result, attempts, err
+}
+
 // HookResult contains the result of hook execution.
 type HookResult struct {
 	HookType HookType
@@ -118,6 +241,18 @@ func ParseTimeout(timeout string) time.Duration {
 
 // RunHook executes a hook script.
 func RunHook(hookType HookType, spec HookSpec, templatePath string, env HookEnv, output io.Writer) (*HookResult, error) {
+	return RunHookStreams(hookType, spec, templatePath, env, output, output)
+}
+
+// RunHookStreams runs a hook like RunHook, copying its stdout and stderr to
+// separate writers (either may be nil) as the hook writes them.
+func RunHookStreams(hookType HookType, spec HookSpec, templatePath string, env HookEnv, stdout, stderr io.Writer) (*HookResult, error) {
+	return RunHookStreamsContext(context.Background(), hookType, spec, templatePath, env, stdout, stderr)
+}
+
+// RunHookStreamsContext runs a hook like RunHookStreams, killing it when
+// parent is done and returning parent's error.
+func RunHookStreamsContext(parent context.Context, hookType HookType, spec HookSpec, templatePath string, env HookEnv, stdout, stderr io.Writer) (*HookResult, error) {
 	result := &HookResult{
 		HookType: hookType,
 		Script:   spec.Script,
@@ -128,6 +263,12 @@ func RunHook(hookType HookType, spec HookSpec, templatePath string, env HookEnv,
 		return result, nil
 	}
 
+	if _, trusted := TrustStatus(templatePath); !trusted {
+		err := &UntrustedTemplateError{Name: filepath.Base(templatePath)}
+		result.Error = err
+		return result, err
+	}
+
 	// Validate script
 	if err := ValidateHookScript(templatePath, spec); err != nil {
 		result.Error = err
@@ -138,22 +279,23 @@ func RunHook(hookType HookType, spec HookSpec, templatePath string, env HookEnv,
 	timeout := ParseTimeout(spec.Timeout)
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	// Prepare command
 	cmd := exec.CommandContext(ctx, "/bin/bash", scriptPath)
+	cmd.WaitDelay = time.Second // children of a killed hook may hold its output open
 	cmd.Dir = env.WorkspacePath
 	cmd.Env = BuildHookEnv(env)
 
-	// Capture output
-	var outputBuf bytes.Buffer
-	if output != nil {
-		cmd.Stdout = io.MultiWriter(&outputBuf, output)
-		cmd.Stderr = io.MultiWriter(&outputBuf, output)
-	} else {
-		cmd.Stdout = &outputBuf
-		cmd.Stderr = &outputBuf
+	// Capture output; stdout and stderr are copied concurrently
+	outputBuf := &lockedBuffer{}
+	cmd.Stdout, cmd.Stderr = io.Writer(outputBuf), io.Writer(outputBuf)
+	if stdout != nil {
+		cmd.Stdout = io.MultiWriter(outputBuf, stdout)
+	}
+	if stderr != nil {
+		cmd.Stderr = io.MultiWriter(outputBuf, stderr)
 	}
 
 	start := time.Now()
@@ -161,6 +303,10 @@ func RunHook(hookType HookType, spec HookSpec, templatePath string, env HookEnv,
 	result.Duration = time.Since(start)
 	result.Output = outputBuf.String()
 
+	if err := parent.Err(); err != nil {
+		result.Error = err
+		return result, err
+	}
 	if ctx.Err() == context.DeadlineExceeded {
 		result.Error = &HookTimeoutError{
 			HookType: string(hookType),
@@ -277,6 +423,117 @@ func ListHooks(tmpl *Template) []HookType {
 	return hooks
 }
 
+// HookTrigger describes when co runs hooks of hookType.
+func HookTrigger(hookType HookType) string {
+	switch hookType {
+	case HookPreCreate:
+		return "before the workspace is created; a failure aborts creation"
+	case HookPostCreate:
+		return "after the template files are written, before repos are cloned"
+	case HookPostClone:
+		return "after the template's repos are cloned or created"
+	case HookPostComplete:
+		return "after project.json is written, last in creation"
+	case HookPostMigrate:
+		return "after the template is applied to an existing workspace"
+	default:
+		return ""
+	}
+}
+
+// TryHook runs the hookType hook of the template name against a throwaway
+// workspace, to test the hook without creating one. The workspace is
+// rendered from the template into a temporary directory, which also stands
+// in for the code root, as the hook would find it: empty for pre_create,
+// with the template files from post_create on, and with the template's
+// repos as empty directories from post_clone on; nothing is cloned.
+// Variables without a default get a sample value of their type. The
+// directory is removed afterwards. As in creation, the template must be
+// trusted.
+func TryHook(ctx context.Context, cfg *config.Config, name string, hookType HookType) (*HookResult, error) {
+	templatesDirs := cfg.AllTemplatesDirs()
+	tmpl, templatesDir, err := LoadTemplateMulti(templatesDirs, name)
+	if err != nil {
+		return nil, err
+	}
+	spec := GetHookSpec(tmpl, hookType)
+	if spec.IsEmpty() {
+		return nil, fmt.Errorf("template %s has no %s hook", name, hookType)
+	}
+
+	codeRoot, err := os.MkdirTemp("", "co-hook-test-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(codeRoot)
+
+	owner, project := "co-test", name
+	slug := workspace.SchemeFor(cfg).Format(owner, project)
+	if tmpl.RepoLayout == "" {
+		tmpl.RepoLayout = cfg.GetRepoLayout(owner)
+	}
+	workspacePath := filepath.Join(codeRoot, slug)
+	if err := os.MkdirAll(workspacePath, fs.DirPerm()); err != nil {
+		return nil, err
+	}
+
+	provided := make(map[string]string)
+	for _, v := range tmpl.Variables {
+		if v.Default == nil {
+			provided[v.Name] = sampleValue(v)
+		}
+	}
+	vars, err := ResolveVariables(tmpl, provided, GetBuiltinVariables(owner, project, slug, workspacePath, codeRoot))
+	if err != nil {
+		return nil, fmt.Errorf("resolving variables: %w", err)
+	}
+
+	if hookType != HookPreCreate {
+		if _, _, err := ProcessAllFilesMulti(tmpl, templatesDirs, filepath.Join(templatesDir, name), workspacePath, vars); err != nil {
+			return nil, fmt.Errorf("processing files: %w", err)
+		}
+		if _, err := createDirectories(tmpl, workspacePath, false); err != nil {
+			return nil, err
+		}
+	}
+	if hookType != HookPreCreate && hookType != HookPostCreate {
+		for _, repo := range tmpl.Repos {
+			if err := os.MkdirAll(filepath.Join(workspacePath, tmpl.RepoDir(repo)), fs.DirPerm()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	env := HookEnv{
+		WorkspacePath: workspacePath,
+		WorkspaceSlug: slug,
+		Owner:         owner,
+		Project:       project,
+		CodeRoot:      codeRoot,
+		TemplateName:  name,
+		TemplatePath:  filepath.Join(templatesDir, name),
+		ReposPath:     filepath.Join(workspacePath, model.RepoDir(tmpl.RepoLayout, "")),
+		Variables:     vars,
+	}
+	return RunHookStreamsContext(ctx, hookType, spec, env.TemplatePath, env, nil, nil)
+}
+
+// sampleValue returns a value of the type of v, for running hooks without
+// asking for values. Validation patterns are not taken into account.
+func sampleValue(v TemplateVar) string {
+	switch v.Type {
+	case VarTypeBoolean:
+		return "false"
+	case VarTypeInteger:
+		return "0"
+	case VarTypeChoice:
+		if len(v.Choices) > 0 {
+			return v.Choices[0]
+		}
+	}
+	return "example"
+}
+
 // MakeScriptExecutable adds execute permissions to a script.
 func MakeScriptExecutable(scriptPath string) error {
 	info, err := os.Stat(scriptPath)
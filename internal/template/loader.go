@@ -6,7 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/jsonobj"
+	"github.com/tormodhaugland/co/internal/model"
 )
 
 // TemplateListing contains summary info plus source metadata for a template.
@@ -14,6 +19,8 @@ type TemplateListing struct {
 	Info         TemplateInfo `json:"info"`
 	SourceDir    string       `json:"source_dir"`
 	TemplatePath string       `json:"template_path"`
+	Trust        *TrustRecord `json:"trust,omitempty"` // Set for templates installed from a catalog
+	Trusted      bool         `json:"trusted"`         // Hooks may run
 }
 
 // templateNamePattern validates template names (lowercase alphanumeric with hyphens).
@@ -132,10 +139,14 @@ func ListTemplateListingsMulti(templatesDirs []string) ([]TemplateListing, []str
 				continue
 			}
 
+			templatePath := filepath.Join(dir, name)
+			trust, trusted := TrustStatus(templatePath)
 			listings = append(listings, TemplateListing{
 				Info:         tmpl.ToInfo(),
 				SourceDir:    dir,
-				TemplatePath: filepath.Join(dir, name),
+				TemplatePath: templatePath,
+				Trust:        trust,
+				Trusted:      trusted,
 			})
 			seen[name] = true
 		}
@@ -197,6 +208,55 @@ func LoadTemplate(templatesDir, name string) (*Template, error) {
 	return &tmpl, nil
 }
 
+// AddVariables appends vars to the variables of the template name in
+// templatesDir and rewrites its template.json. The other fields keep their
+// order and values; only the indentation is normalized. Variables the
+// template already declares are an error.
+func AddVariables(templatesDir, name string, vars []TemplateVar) error {
+	tmpl, err := LoadTemplate(templatesDir, name)
+	if err != nil {
+		return err
+	}
+	for _, v := range vars {
+		if slices.ContainsFunc(tmpl.Variables, func(d TemplateVar) bool { return d.Name == v.Name }) {
+			return &ValidationError{Field: "variables", Reason: fmt.Sprintf("%s is already declared", v.Name)}
+		}
+	}
+
+	manifestPath := filepath.Join(templatesDir, name, TemplateManifestFile)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return &InvalidManifestError{Path: manifestPath, Err: err}
+	}
+	obj, err := jsonobj.Parse(data)
+	if err != nil {
+		return &InvalidManifestError{Path: manifestPath, Err: err}
+	}
+
+	var declared []json.RawMessage
+	if raw, ok := obj.Get("variables"); ok {
+		if err := json.Unmarshal(raw, &declared); err != nil {
+			return &InvalidManifestError{Path: manifestPath, Err: err}
+		}
+	}
+	for _, v := range vars {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		declared = append(declared, raw)
+	}
+	if err := obj.Set("variables", declared); err != nil {
+		return err
+	}
+
+	out, err := obj.Marshal("  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, out, fs.FilePerm())
+}
+
 // LoadTemplateMulti loads a template by searching multiple directories in order.
 // Returns the template from the first directory where it's found.
 func LoadTemplateMulti(templatesDirs []string, name string) (*Template, string, error) {
@@ -364,6 +424,28 @@ func ValidateTemplate(tmpl *Template) error {
 				})
 			}
 		}
+
+		if v.PromptIf != "" && !whenHasOperator(v.PromptIf) {
+			errs.Add(&ValidationError{
+				Field:  fmt.Sprintf("variables[%d].prompt_if", i),
+				Reason: "must include == or !=",
+			})
+		}
+	}
+
+	// Conditions must refer to declared variables, without cycles
+	for i, v := range tmpl.Variables {
+		for _, dep := range v.DependsOn {
+			if !varNames[dep] {
+				errs.Add(&ValidationError{
+					Field:  fmt.Sprintf("variables[%d].depends_on", i),
+					Reason: fmt.Sprintf("unknown variable: %s", dep),
+				})
+			}
+		}
+	}
+	if _, err := OrderVariables(tmpl.Variables); err != nil {
+		errs.Add(&ValidationError{Field: "variables", Reason: err.Error()})
 	}
 
 	// Validate repos
@@ -392,6 +474,40 @@ func ValidateTemplate(tmpl *Template) error {
 				Reason: "must have either clone_url or init: true",
 			})
 		}
+
+		if r.Path != "" && !filepath.IsLocal(filepath.FromSlash(r.Path)) {
+			errs.Add(&ValidationError{
+				Field:  fmt.Sprintf("repos[%d].path", i),
+				Reason: "must be a relative path inside the workspace",
+			})
+		}
+	}
+
+	switch tmpl.Engine {
+	case "", EngineSimple, EngineGo:
+	default:
+		errs.Add(&ValidationError{
+			Field:  "engine",
+			Reason: fmt.Sprintf("must be %s or %s", EngineSimple, EngineGo),
+		})
+	}
+
+	if !model.ValidLayout(tmpl.RepoLayout) {
+		errs.Add(&ValidationError{
+			Field:  "repo_layout",
+			Reason: fmt.Sprintf("must be %s or %s", model.LayoutNested, model.LayoutFlat),
+		})
+	}
+
+	// Validate directories
+	for i, dir := range tmpl.Directories {
+		clean := filepath.Clean(dir)
+		if dir == "" || filepath.IsAbs(dir) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			errs.Add(&ValidationError{
+				Field:  fmt.Sprintf("directories[%d]", i),
+				Reason: "must be a relative path inside the workspace",
+			})
+		}
 	}
 
 	// Validate partial refs
@@ -438,6 +554,27 @@ func ValidateTemplate(tmpl *Template) error {
 	validateHookTimeout("post_complete", tmpl.Hooks.PostComplete)
 	validateHookTimeout("post_migrate", tmpl.Hooks.PostMigrate)
 
+	if tmpl.CI != nil {
+		if tmpl.CI.Provider != "" && tmpl.CI.Provider != CIProviderGitHub {
+			errs.Add(&ValidationError{
+				Field:  "ci.provider",
+				Reason: fmt.Sprintf("unsupported provider %q (supported: %s)", tmpl.CI.Provider, CIProviderGitHub),
+			})
+		}
+		repoNames := make(map[string]bool)
+		for _, repo := range tmpl.Repos {
+			repoNames[repo.Name] = true
+		}
+		for _, name := range tmpl.CI.Repos {
+			if !repoNames[name] {
+				errs.Add(&ValidationError{
+					Field:  "ci.repos",
+					Reason: fmt.Sprintf("%s is not a template repo", name),
+				})
+			}
+		}
+	}
+
 	return errs.ErrorOrNil()
 }
 
@@ -488,6 +625,23 @@ func ValidateTemplateDir(templatesDir, name string) error {
 	validateHookScript("post_complete", tmpl.Hooks.PostComplete)
 	validateHookScript("post_migrate", tmpl.Hooks.PostMigrate)
 
+	// Check template files suit the template's engine
+	for _, err := range CheckEngineCompatibility(tmpl, filepath.Join(templatesDir, name)) {
+		errs.Add(err)
+	}
+
+	// Check CI workflow files exist
+	if tmpl.CI != nil {
+		for _, wf := range tmpl.CI.Workflows {
+			if _, err := os.Stat(filepath.Join(templatesDir, name, TemplateCIDir, wf)); err != nil {
+				errs.Add(&ValidationError{
+					Field:  "ci.workflows",
+					Reason: fmt.Sprintf("workflow not found: %s", filepath.Join(TemplateCIDir, wf)),
+				})
+			}
+		}
+	}
+
 	return errs.ErrorOrNil()
 }
 
@@ -525,7 +679,7 @@ func parseTimeoutString(timeout string) (int, error) {
 
 // EnsureTemplatesDir creates the templates directory if it doesn't exist.
 func EnsureTemplatesDir(templatesDir string) error {
-	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+	if err := os.MkdirAll(templatesDir, fs.DirPerm()); err != nil {
 		return fmt.Errorf("creating templates directory: %w", err)
 	}
 	return nil
@@ -534,7 +688,7 @@ func EnsureTemplatesDir(templatesDir string) error {
 // EnsureGlobalDir creates the _global template directory if it doesn't exist.
 func EnsureGlobalDir(templatesDir string) error {
 	globalPath := GetGlobalFilesPath(templatesDir)
-	if err := os.MkdirAll(globalPath, 0755); err != nil {
+	if err := os.MkdirAll(globalPath, fs.DirPerm()); err != nil {
 		return fmt.Errorf("creating global templates directory: %w", err)
 	}
 	return nil
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -455,6 +456,39 @@ func TestListTemplateListingsMulti(t *testing.T) {
 	}
 }
 
+func TestAddVariables(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "svc", TemplateManifestFile)
+	if err := os.MkdirAll(filepath.Dir(manifest), 0755); err != nil {
+		t.Fatal(err)
+	}
+	original := `{"name": "svc", "description": "Service", "variables": [{"name": "port", "type": "integer", "default": 8080}], "x_notes": "kept"}`
+	if err := os.WriteFile(manifest, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := AddVariables(dir, "svc", []TemplateVar{{Name: "API_KEY", Type: VarTypeString, Required: true, Description: "Key"}})
+	if err != nil {
+		t.Fatalf("AddVariables() error = %v", err)
+	}
+	tmpl, err := LoadTemplate(dir, "svc")
+	if err != nil {
+		t.Fatalf("LoadTemplate() error = %v", err)
+	}
+	if len(tmpl.Variables) != 2 || tmpl.Variables[1].Name != "API_KEY" || !tmpl.Variables[1].Required {
+		t.Errorf("variables = %+v, want port and API_KEY", tmpl.Variables)
+	}
+	data, _ := os.ReadFile(manifest)
+	text := string(data)
+	if !strings.Contains(text, `"x_notes": "kept"`) || strings.Index(text, `"name"`) > strings.Index(text, `"description"`) {
+		t.Errorf("template.json lost fields or their order:\n%s", text)
+	}
+
+	if err := AddVariables(dir, "svc", []TemplateVar{{Name: "port"}}); err == nil {
+		t.Error("AddVariables() of a declared variable should fail")
+	}
+}
+
 // writeTestTemplate creates a minimal template manifest for tests.
 func writeTestTemplate(t *testing.T, dir, name, desc string) {
 	t.Helper()
@@ -0,0 +1,146 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/lock"
+)
+
+// networkQueueLock guards the network queue file.
+const networkQueueLock = "network-queue"
+
+// PendingClone is a repository clone deferred while offline.
+type PendingClone struct {
+	Workspace string    `json:"workspace"`
+	Repo      string    `json:"repo"`
+	URL       string    `json:"url"`
+	Path      string    `json:"path"`
+	QueuedAt  time.Time `json:"queued_at"`
+}
+
+// NetworkQueue is the network work deferred while offline, waiting for
+// co resume-network.
+type NetworkQueue struct {
+	Clones []PendingClone `json:"clones"`
+}
+
+// LoadNetworkQueue reads the network queue; a missing file is an empty queue.
+func LoadNetworkQueue(cfg *config.Config) (*NetworkQueue, error) {
+	q := &NetworkQueue{}
+	data, err := os.ReadFile(cfg.NetworkQueuePath())
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("invalid network queue %s: %w", cfg.NetworkQueuePath(), err)
+	}
+	return q, nil
+}
+
+// Save writes the network queue, removing the file once it is empty.
+func (q *NetworkQueue) Save(cfg *config.Config) error {
+	path := cfg.NetworkQueuePath()
+	if len(q.Clones) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), fs.DirPerm()); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), fs.FilePerm())
+}
+
+// QueueClone defers cloning url into path, repo name of workspace, until
+// co resume-network. A clone already queued for path is replaced.
+func QueueClone(cfg *config.Config, workspace, repo, url, path string) error {
+	l, err := lock.Acquire(cfg.LocksDir(), networkQueueLock)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	q, err := LoadNetworkQueue(cfg)
+	if err != nil {
+		return err
+	}
+	clones := q.Clones[:0]
+	for _, c := range q.Clones {
+		if c.Path != path {
+			clones = append(clones, c)
+		}
+	}
+	q.Clones = append(clones, PendingClone{Workspace: workspace, Repo: repo, URL: url, Path: path, QueuedAt: time.Now()})
+	return q.Save(cfg)
+}
+
+// ResumeResult reports what co resume-network did with the queue.
+type ResumeResult struct {
+	Cloned  []PendingClone `json:"cloned"`
+	Dropped []PendingClone `json:"dropped,omitempty"` // Workspace gone or repo already present
+	Failed  []StepFailure  `json:"failed,omitempty"`  // Still queued
+}
+
+// ResumeNetwork runs the queued network work. Clones that fail stay queued
+// for the next run; clones whose workspace is gone, or whose repo exists by
+// now, are dropped. With dryRun nothing is cloned or changed.
+func ResumeNetwork(cfg *config.Config, dryRun bool) (*ResumeResult, error) {
+	if err := cfg.CheckOnline("resume network work"); err != nil {
+		return nil, err
+	}
+	l, err := lock.Acquire(cfg.LocksDir(), networkQueueLock)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	q, err := LoadNetworkQueue(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResumeResult{}
+	policy := GitRetryPolicy(cfg)
+	var remaining []PendingClone
+	for _, c := range q.Clones {
+		if _, err := os.Stat(cfg.WorkspacePath(c.Workspace)); err != nil {
+			result.Dropped = append(result.Dropped, c)
+			continue
+		}
+		if _, err := os.Stat(c.Path); err == nil {
+			result.Dropped = append(result.Dropped, c)
+			continue
+		}
+		if dryRun {
+			result.Cloned = append(result.Cloned, c)
+			continue
+		}
+		attempts, err := policy.CloneRepo(c.URL, c.Path)
+		if err != nil {
+			result.Failed = append(result.Failed, StepFailure{Step: "clone", Name: c.Workspace + "/" + c.Repo, Attempts: attempts, Error: err.Error()})
+			remaining = append(remaining, c)
+			continue
+		}
+		result.Cloned = append(result.Cloned, c)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	q.Clones = remaining
+	return result, q.Save(cfg)
+}
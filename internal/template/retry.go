@@ -0,0 +1,119 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+)
+
+// RetryPolicy says how an operation that may fail transiently, a clone or a
+// hook, is retried.
+type RetryPolicy struct {
+	Attempts int           // Total attempts; below 1 means 1
+	Backoff  time.Duration // Wait before the second attempt, doubled before each further one
+	Timeout  time.Duration // Limit of one attempt; 0 for none
+}
+
+// retrySleep waits between attempts; tests replace it.
+var retrySleep = time.Sleep
+
+// GitRetryPolicy returns the policy for git clones and fetches in cfg.
+func GitRetryPolicy(cfg *config.Config) RetryPolicy {
+	rc := cfg.GetRetryConfig()
+	return RetryPolicy{
+		Attempts: rc.GitRetries + 1,
+		Backoff:  mustDuration(rc.Backoff),
+		Timeout:  mustDuration(rc.GitTimeout),
+	}
+}
+
+// HookRetryPolicy returns the policy for template hooks in cfg. Timeout
+// applies to hooks that set no timeout of their own.
+func HookRetryPolicy(cfg *config.Config) RetryPolicy {
+	rc := cfg.GetRetryConfig()
+	return RetryPolicy{
+		Attempts: rc.HookRetries + 1,
+		Backoff:  mustDuration(rc.Backoff),
+		Timeout:  mustDuration(rc.HookTimeout),
+	}
+}
+
+// mustDuration parses a duration the config has already validated.
+func mustDuration(s string) time.Duration {
+	d, _ := time.ParseDuration(s)
+	return d
+}
+
+// Do runs op until it succeeds, returns an error retryable rejects, or the
+// attempts run out. Each attempt gets a context that ends after Timeout. Do
+// returns the number of attempts made and the last error.
+func (p RetryPolicy) Do(op func(ctx context.Context) error, retryable func(error) bool) (int, error) {
+	return p.DoContext(context.Background(), op, retryable)
+}
+
+// DoContext runs op like Do, with attempt contexts derived from ctx. Once
+// ctx is done no further attempt is made, and ctx's error is returned.
+func (p RetryPolicy) DoContext(ctx context.Context, op func(ctx context.Context) error, retryable func(error) bool) (int, error) {
+	attempts := max(p.Attempts, 1)
+	backoff := p.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = p.attempt(ctx, op)
+		if err != nil && ctx.Err() != nil {
+			return attempt, ctx.Err()
+		}
+		if err == nil || attempt == attempts || (retryable != nil && !retryable(err)) {
+			return attempt, err
+		}
+		retrySleep(backoff)
+		if ctx.Err() != nil {
+			return attempt, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (p RetryPolicy) attempt(ctx context.Context, op func(ctx context.Context) error) error {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	return op(ctx)
+}
+
+// CloneRepo clones url into destPath under the policy, removing what a
+// failed attempt left behind before the next one unless destPath existed
+// already. It returns the number of attempts made.
+func (p RetryPolicy) CloneRepo(url, destPath string) (int, error) {
+	return p.CloneRepoContext(context.Background(), url, destPath)
+}
+
+// CloneRepoContext clones like CloneRepo, killing git and making no further
+// attempt once ctx is done.
+func (p RetryPolicy) CloneRepoContext(ctx context.Context, url, destPath string) (int, error) {
+	_, statErr := os.Stat(destPath)
+	existed := statErr == nil
+	return p.DoContext(ctx, func(ctx context.Context) error {
+		err := git.CloneContext(ctx, url, destPath)
+		if err != nil && !existed {
+			os.RemoveAll(destPath)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = errors.New("timed out after " + p.Timeout.String())
+		}
+		return err
+	}, nil)
+}
+
+// retryableHookError reports whether a failed hook is worth running again:
+// it ran and exited non-zero or timed out, rather than being refused.
+func retryableHookError(err error) bool {
+	var hookErr *HookError
+	var timeoutErr *HookTimeoutError
+	return (errors.As(err, &hookErr) && hookErr.ExitCode != 0) || errors.As(err, &timeoutErr)
+}
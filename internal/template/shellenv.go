@@ -0,0 +1,145 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// Shell env files, as chosen by shell_env.generate.
+const (
+	EnvrcFile        = ".envrc"
+	ToolVersionsFile = ".tool-versions"
+	MiseFile         = "mise.toml"
+)
+
+// WriteShellEnv writes the file that makes direnv or mise follow the
+// workspace, as chosen by shell_env.generate: an .envrc exporting the
+// workspace env (with a .tool-versions for the tools), or a mise.toml with
+// [env] and [tools]. Tool versions and env values are templates filled from
+// the built-in variables and the workspace's template variables; entries that
+// render empty or use an unset variable are left out. CO_WORKSPACE is always
+// set to the slug. Existing files are kept. It returns the paths written.
+func WriteShellEnv(cfg *config.Config, workspacePath string) ([]string, error) {
+	shellCfg := cfg.GetShellEnvConfig()
+	if shellCfg.Generate == "" {
+		return nil, nil
+	}
+
+	slug, owner, project := workspaceIdentity(cfg, workspacePath)
+	vars := GetBuiltinVariables(owner, project, slug, workspacePath, cfg.CodeRoot)
+	if proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json")); err == nil {
+		for k, v := range proj.TemplateVars {
+			vars[k] = v
+		}
+	}
+
+	env := renderValues(shellCfg.Env, vars)
+	env["CO_WORKSPACE"] = slug
+	tools := renderValues(shellCfg.Tools, vars)
+
+	files := map[string]string{}
+	if shellCfg.Generate == "mise" {
+		files[MiseFile] = renderMise(slug, env, tools)
+	} else {
+		files[EnvrcFile] = renderEnvrc(slug, env)
+		if len(tools) > 0 {
+			files[ToolVersionsFile] = renderToolVersions(tools)
+		}
+	}
+
+	var written []string
+	for _, name := range sortedKeys(files) {
+		path := filepath.Join(workspacePath, name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(files[name]), fs.FilePerm()); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// renderValues fills the templates in values, dropping entries that come out
+// empty or still reference a variable.
+func renderValues(values map[string]string, vars map[string]string) map[string]string {
+	out := make(map[string]string)
+	for key, value := range values {
+		rendered, err := ProcessTemplateContent(value, vars)
+		if err != nil || rendered == "" || variableRefPattern.MatchString(rendered) {
+			continue
+		}
+		out[key] = rendered
+	}
+	return out
+}
+
+func renderEnvrc(slug string, env map[string]string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Generated by co for %s\n", slug)
+	for _, key := range sortedKeys(env) {
+		fmt.Fprintf(&sb, "export %s=%s\n", key, shellQuote(env[key]))
+	}
+	return sb.String()
+}
+
+func renderToolVersions(tools map[string]string) string {
+	var sb strings.Builder
+	for _, tool := range sortedKeys(tools) {
+		fmt.Fprintf(&sb, "%s %s\n", tool, tools[tool])
+	}
+	return sb.String()
+}
+
+func renderMise(slug string, env, tools map[string]string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Generated by co for %s\n", slug)
+	sb.WriteString("[env]\n")
+	for _, key := range sortedKeys(env) {
+		fmt.Fprintf(&sb, "%s = %s\n", tomlKey(key), tomlString(env[key]))
+	}
+	if len(tools) > 0 {
+		sb.WriteString("\n[tools]\n")
+		for _, tool := range sortedKeys(tools) {
+			fmt.Fprintf(&sb, "%s = %s\n", tomlKey(tool), tomlString(tools[tool]))
+		}
+	}
+	return sb.String()
+}
+
+// shellQuote single-quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+func tomlString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
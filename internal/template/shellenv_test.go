@@ -0,0 +1,110 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func shellEnvWorkspace(t *testing.T, generate string) (*config.Config, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	cfg := testConfig(t, tmpDir)
+	cfg.ShellEnv = &config.ShellEnvConfig{
+		Generate: generate,
+		Tools:    map[string]string{"node": "{{NODE_VERSION}}", "python": "{{PYTHON_VERSION}}"},
+		Env:      map[string]string{"APP_NAME": "{{PROJECT}}", "GREETING": "it's {{OWNER}}"},
+	}
+
+	workspacePath := filepath.Join(tmpDir, "acme--app")
+	if err := os.MkdirAll(workspacePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	proj := model.NewProject("acme--app", "acme", "app")
+	proj.Slug = "acme--app"
+	proj.TemplateVars = map[string]string{"NODE_VERSION": "20"}
+	if err := proj.Save(workspacePath); err != nil {
+		t.Fatal(err)
+	}
+	return cfg, workspacePath
+}
+
+func TestWriteShellEnvDirenv(t *testing.T) {
+	cfg, workspacePath := shellEnvWorkspace(t, "direnv")
+
+	written, err := WriteShellEnv(cfg, workspacePath)
+	if err != nil {
+		t.Fatalf("WriteShellEnv() error = %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("written = %v, want .envrc and .tool-versions", written)
+	}
+
+	envrc, _ := os.ReadFile(filepath.Join(workspacePath, EnvrcFile))
+	want := "# Generated by co for acme--app\n" +
+		"export APP_NAME='app'\n" +
+		"export CO_WORKSPACE='acme--app'\n" +
+		"export GREETING='it'\\''s acme'\n"
+	if string(envrc) != want {
+		t.Errorf(".envrc =\n%s\nwant\n%s", envrc, want)
+	}
+
+	// PYTHON_VERSION is unset, so python is left out
+	tools, _ := os.ReadFile(filepath.Join(workspacePath, ToolVersionsFile))
+	if string(tools) != "node 20\n" {
+		t.Errorf(".tool-versions = %q, want %q", tools, "node 20\n")
+	}
+}
+
+func TestWriteShellEnvMise(t *testing.T) {
+	cfg, workspacePath := shellEnvWorkspace(t, "mise")
+
+	if _, err := WriteShellEnv(cfg, workspacePath); err != nil {
+		t.Fatalf("WriteShellEnv() error = %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(workspacePath, MiseFile))
+	want := "# Generated by co for acme--app\n" +
+		"[env]\n" +
+		"APP_NAME = \"app\"\n" +
+		"CO_WORKSPACE = \"acme--app\"\n" +
+		"GREETING = \"it's acme\"\n" +
+		"\n[tools]\n" +
+		"node = \"20\"\n"
+	if string(data) != want {
+		t.Errorf("mise.toml =\n%s\nwant\n%s", data, want)
+	}
+	if _, err := os.Stat(filepath.Join(workspacePath, EnvrcFile)); !os.IsNotExist(err) {
+		t.Error("mise should not write an .envrc")
+	}
+}
+
+func TestWriteShellEnvKeepsExisting(t *testing.T) {
+	cfg, workspacePath := shellEnvWorkspace(t, "mise")
+	path := filepath.Join(workspacePath, MiseFile)
+	if err := os.WriteFile(path, []byte("# mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := WriteShellEnv(cfg, workspacePath)
+	if err != nil {
+		t.Fatalf("WriteShellEnv() error = %v", err)
+	}
+	if len(written) != 0 {
+		t.Errorf("written = %v, want nothing", written)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# mine\n" {
+		t.Errorf("mise.toml was overwritten: %q", data)
+	}
+}
+
+func TestWriteShellEnvDisabled(t *testing.T) {
+	cfg, workspacePath := shellEnvWorkspace(t, "")
+
+	written, err := WriteShellEnv(cfg, workspacePath)
+	if err != nil || len(written) != 0 {
+		t.Errorf("WriteShellEnv() = %v, %v; want nothing written", written, err)
+	}
+}
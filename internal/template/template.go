@@ -1,6 +1,10 @@
 package template
 
-import "github.com/tormodhaugland/co/internal/model"
+import (
+	"path/filepath"
+
+	"github.com/tormodhaugland/co/internal/model"
+)
 
 // CurrentTemplateSchema is the current version of the template manifest schema.
 const CurrentTemplateSchema = 1
@@ -24,11 +28,23 @@ type Template struct {
 	Variables       []TemplateVar      `json:"variables,omitempty"`
 	Repos           []TemplateRepo     `json:"repos,omitempty"`
 	Files           TemplateFiles      `json:"files,omitempty"`
+	Directories     []string           `json:"directories,omitempty"` // created in the workspace, even when empty
 	Hooks           TemplateHooks      `json:"hooks,omitempty"`
 	Partials        []PartialRef       `json:"partials,omitempty"`
+	DevContainer    *DevContainerSpec  `json:"devcontainer,omitempty"` // generates .devcontainer/devcontainer.json
+	Nix             *NixSpec           `json:"nix,omitempty"`          // generates flake.nix
+	CI              *CISpec            `json:"ci,omitempty"`           // CI setup for the template's repos
 	Tags            []string           `json:"tags,omitempty"`
 	State           model.ProjectState `json:"state,omitempty"`
 	SkipGlobalFiles interface{}        `json:"skip_global_files,omitempty"` // bool or []string
+
+	// RepoLayout is where the template's repos go, model.LayoutNested or
+	// model.LayoutFlat (empty = the owner's configured repo_layout)
+	RepoLayout string `json:"repo_layout,omitempty"`
+
+	// Engine renders the template's files, EngineSimple or EngineGo
+	// (empty = EngineSimple)
+	Engine string `json:"engine,omitempty"`
 }
 
 // TemplateVar defines a variable that can be customized when using the template.
@@ -40,6 +56,13 @@ type TemplateVar struct {
 	Default     interface{} `json:"default,omitempty"`
 	Validation  string      `json:"validation,omitempty"` // regex pattern
 	Choices     []string    `json:"choices,omitempty"`    // for VarTypeChoice
+
+	// DependsOn and PromptIf make the variable conditional: it is only asked
+	// for when each DependsOn variable is true and the PromptIf condition,
+	// as in partial "when" ("{{DB_KIND}} != sqlite"), holds. Otherwise it is
+	// empty, also to the conditions of other variables (see VarApplies).
+	DependsOn []string `json:"depends_on,omitempty"`
+	PromptIf  string   `json:"prompt_if,omitempty"`
 }
 
 // TemplateRepo defines a repository to create or clone in the workspace.
@@ -48,6 +71,17 @@ type TemplateRepo struct {
 	CloneURL      string `json:"clone_url,omitempty"`
 	Init          bool   `json:"init,omitempty"`
 	DefaultBranch string `json:"default_branch,omitempty"`
+	GitHub        string `json:"github,omitempty"` // owner/name CI is set up on (default: the origin)
+	Path          string `json:"path,omitempty"`   // Relative to the workspace (default: from the layout)
+}
+
+// RepoDir returns the path, relative to the workspace, of the template repo
+// repo under the template's layout.
+func (t *Template) RepoDir(repo TemplateRepo) string {
+	if repo.Path != "" {
+		return filepath.Clean(filepath.FromSlash(repo.Path))
+	}
+	return model.RepoDir(t.RepoLayout, repo.Name)
 }
 
 // PartialRef defines a partial to apply during template creation.
@@ -106,8 +140,14 @@ type CreateOptions struct {
 	TemplateName string
 	Variables    map[string]string
 	NoHooks      bool
+	NoCI         bool // skip the template's CI setup
 	DryRun       bool
 	Verbose      bool
+
+	// OnHookEvent, when set, receives the start, output lines, and result of
+	// every hook as it runs, instead of Verbose printing the output. It may
+	// be called from other goroutines, one call at a time.
+	OnHookEvent func(HookEvent)
 }
 
 // PartialApplyOptions holds the partial apply parameters for template integration.
@@ -131,17 +171,33 @@ func RegisterPartialApplier(applier PartialApplier) {
 
 // CreateResult holds the result of template-based workspace creation.
 type CreateResult struct {
-	WorkspacePath string   `json:"workspace_path"`
-	WorkspaceSlug string   `json:"workspace_slug"`
-	TemplateUsed  string   `json:"template_used,omitempty"`
-	FilesCreated  int      `json:"files_created"`
-	GlobalFiles   int      `json:"global_files"`
-	TemplateFiles int      `json:"template_files"`
-	ReposCreated  int      `json:"repos_created"`
-	ReposCloned   int      `json:"repos_cloned"`
-	HooksRun      []string `json:"hooks_run,omitempty"`
-	HooksSkipped  []string `json:"hooks_skipped,omitempty"`
-	Warnings      []string `json:"warnings,omitempty"`
+	WorkspacePath string        `json:"workspace_path"`
+	WorkspaceSlug string        `json:"workspace_slug"`
+	TemplateUsed  string        `json:"template_used,omitempty"`
+	FilesCreated  int           `json:"files_created"`
+	GlobalFiles   int           `json:"global_files"`
+	TemplateFiles int           `json:"template_files"`
+	ReposCreated  int           `json:"repos_created"`
+	ReposCloned   int           `json:"repos_cloned"`
+	PendingClones []string      `json:"pending_clones,omitempty"` // Queued for co resume-network while offline
+	HooksRun      []string      `json:"hooks_run,omitempty"`
+	HooksSkipped  []string      `json:"hooks_skipped,omitempty"`
+	ShellEnvFiles []string      `json:"shell_env_files,omitempty"` // .envrc, .tool-versions, or mise.toml written
+	CIRepos       []string      `json:"ci_repos,omitempty"`        // Repos whose CI was set up
+	Failures      []StepFailure `json:"failures,omitempty"`        // Steps that failed without aborting the creation
+	Warnings      []string      `json:"warnings,omitempty"`
+}
+
+// StepFailure records a clone or hook that still failed after its retries.
+type StepFailure struct {
+	Step     string `json:"step"` // "clone" or a hook type
+	Name     string `json:"name"` // Repo name or hook script
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+func (r *CreateResult) addFailure(step, name string, attempts int, err error) {
+	r.Failures = append(r.Failures, StepFailure{Step: step, Name: name, Attempts: attempts, Error: err.Error()})
 }
 
 // TemplateInfo provides summary information about a template for listing.
@@ -184,6 +240,30 @@ func (t *Template) ToInfo() TemplateInfo {
 	}
 }
 
+// IsStructure reports whether the template only defines directories and
+// files, so it can be applied to existing workspaces with co apply-structure.
+func (t *Template) IsStructure() bool {
+	return len(t.Repos) == 0 && len(t.Partials) == 0
+}
+
+// StructureOptions holds options for applying a structure template to an
+// existing workspace.
+type StructureOptions struct {
+	Variables map[string]string
+	Overwrite bool // replace files that already exist instead of keeping them
+	DryRun    bool
+}
+
+// StructureResult holds the result of applying a structure template.
+type StructureResult struct {
+	WorkspacePath string   `json:"workspace_path"`
+	WorkspaceSlug string   `json:"workspace_slug"`
+	TemplateUsed  string   `json:"template_used"`
+	DirsCreated   []string `json:"dirs_created,omitempty"`
+	FilesWritten  []string `json:"files_written,omitempty"`
+	FilesKept     []string `json:"files_kept,omitempty"` // already present, left unchanged
+}
+
 // GetTemplateExtensions returns the template extensions to use, defaulting to [".tmpl"].
 func (t *Template) GetTemplateExtensions() []string {
 	if len(t.Files.TemplateExtensions) > 0 {
@@ -231,3 +311,7 @@ const TemplateFilesDir = "files"
 
 // TemplateHooksDir is the name of the directory containing hook scripts.
 const TemplateHooksDir = "hooks"
+
+// TemplateGitHooksDir is the name of the directory containing git hooks
+// installed by co hooks install.
+const TemplateGitHooksDir = "git-hooks"
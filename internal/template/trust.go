@@ -0,0 +1,101 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/fs"
+)
+
+// TrustFile records, in a templates directory, the trust of the templates
+// installed there from a catalog. Templates without a record were written
+// locally and are trusted.
+const TrustFile = ".trust.json"
+
+// TrustRecord describes a template installed from a catalog.
+type TrustRecord struct {
+	Source      string     `json:"source"`
+	Signed      bool       `json:"signed"`           // Verified against a key in template_trust.keys
+	KeyID       string     `json:"key_id,omitempty"` // Minisign key ID of the signer
+	Trusted     bool       `json:"trusted"`          // Hooks may run
+	InstalledAt time.Time  `json:"installed_at"`
+	TrustedAt   *time.Time `json:"trusted_at,omitempty"` // Set when trusted explicitly
+}
+
+// Status summarizes the record for display.
+func (r *TrustRecord) Status() string {
+	switch {
+	case r == nil:
+		return "local"
+	case !r.Trusted:
+		return "untrusted"
+	case r.Signed:
+		return "signed (" + r.KeyID + ")"
+	default:
+		return "trusted"
+	}
+}
+
+// LoadTrust reads the trust records of templatesDir, keyed by template name.
+func LoadTrust(templatesDir string) (map[string]TrustRecord, error) {
+	records := make(map[string]TrustRecord)
+	data, err := os.ReadFile(filepath.Join(templatesDir, TrustFile))
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", TrustFile, err)
+	}
+	return records, nil
+}
+
+// SaveTrust writes the trust records of templatesDir.
+func SaveTrust(templatesDir string, records map[string]TrustRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(templatesDir, TrustFile), append(data, '\n'), fs.FilePerm())
+}
+
+// TrustStatus returns the trust record of the template at templatePath, nil
+// for a local template, and whether its hooks may run. An unreadable trust
+// file trusts nothing installed.
+func TrustStatus(templatePath string) (*TrustRecord, bool) {
+	records, err := LoadTrust(filepath.Dir(templatePath))
+	if err != nil {
+		return &TrustRecord{}, false
+	}
+	record, ok := records[filepath.Base(templatePath)]
+	if !ok {
+		return nil, true
+	}
+	return &record, record.Trusted
+}
+
+// SetTrusted trusts, or stops trusting, the hooks of the template name
+// installed in templatesDir.
+func SetTrusted(templatesDir, name string, trusted bool) error {
+	records, err := LoadTrust(templatesDir)
+	if err != nil {
+		return err
+	}
+	record, ok := records[name]
+	if !ok {
+		return fmt.Errorf("template %s was not installed from a catalog; local templates are always trusted", name)
+	}
+	record.Trusted = trusted
+	record.TrustedAt = nil
+	if trusted {
+		now := time.Now()
+		record.TrustedAt = &now
+	}
+	records[name] = record
+	return SaveTrust(templatesDir, records)
+}
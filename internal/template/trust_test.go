@@ -0,0 +1,50 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrustBlocksHooks(t *testing.T) {
+	templatesDir := t.TempDir()
+	templatePath := filepath.Join(templatesDir, "remote")
+	if err := os.MkdirAll(filepath.Join(templatePath, "hooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(templatePath, "hooks", "setup.sh"), []byte("#!/bin/bash\necho ran\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	spec := HookSpec{Script: "hooks/setup.sh"}
+	env := HookEnv{WorkspacePath: t.TempDir()}
+
+	// Local templates are trusted
+	if _, err := RunHook(HookPostCreate, spec, templatePath, env, nil); err != nil {
+		t.Fatalf("RunHook() for a local template error = %v", err)
+	}
+
+	records := map[string]TrustRecord{"remote": {Source: "https://example.com/remote.tar.gz", InstalledAt: time.Now()}}
+	if err := SaveTrust(templatesDir, records); err != nil {
+		t.Fatal(err)
+	}
+	var untrusted *UntrustedTemplateError
+	if _, err := RunHook(HookPostCreate, spec, templatePath, env, nil); !errors.As(err, &untrusted) {
+		t.Fatalf("RunHook() for an untrusted template = %v, want *UntrustedTemplateError", err)
+	}
+
+	if err := SetTrusted(templatesDir, "remote", true); err != nil {
+		t.Fatal(err)
+	}
+	if record, trusted := TrustStatus(templatePath); !trusted || record.TrustedAt == nil {
+		t.Errorf("TrustStatus() = %+v, %v; want trusted", record, trusted)
+	}
+	if _, err := RunHook(HookPostCreate, spec, templatePath, env, nil); err != nil {
+		t.Errorf("RunHook() after trusting error = %v", err)
+	}
+
+	if err := SetTrusted(templatesDir, "local", true); err == nil {
+		t.Error("SetTrusted() should fail for a template not installed from a catalog")
+	}
+}
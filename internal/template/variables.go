@@ -6,24 +6,30 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
 // GetBuiltinVariables returns the built-in variables available to all templates.
-func GetBuiltinVariables(owner, project, workspacePath, codeRoot string) map[string]string {
+func GetBuiltinVariables(owner, project, slug, workspacePath, codeRoot string) map[string]string {
 	now := time.Now()
 
 	vars := map[string]string{
 		"OWNER":            owner,
 		"PROJECT":          project,
-		"SLUG":             owner + "--" + project,
+		"SLUG":             slug,
 		"CREATED_DATE":     now.Format("2006-01-02"),
 		"CREATED_DATETIME": now.Format(time.RFC3339),
 		"YEAR":             now.Format("2006"),
 		"CODE_ROOT":        codeRoot,
 		"WORKSPACE_PATH":   workspacePath,
+
+		// Where dev containers mount the workspace, and the Nix system of this machine
+		"CONTAINER_WORKSPACE": "/workspaces/" + filepath.Base(workspacePath),
+		"NIX_SYSTEM":          nixSystem(runtime.GOOS, runtime.GOARCH),
 	}
 
 	// Get home directory
@@ -42,6 +48,21 @@ func GetBuiltinVariables(owner, project, workspacePath, codeRoot string) map[str
 	return vars
 }
 
+// nixSystem returns the Nix system double for a Go platform, e.g.
+// "aarch64-darwin".
+func nixSystem(goos, goarch string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "arm64":
+		arch = "aarch64"
+	case "386":
+		arch = "i686"
+	}
+	return arch + "-" + goos
+}
+
 // getGitConfig retrieves a git config value.
 func getGitConfig(key string) string {
 	cmd := exec.Command("git", "config", "--get", key)
@@ -55,41 +76,191 @@ func getGitConfig(key string) string {
 // variableRefPattern matches {{VAR}} placeholders.
 var variableRefPattern = regexp.MustCompile(`\{\{([A-Za-z_][A-Za-z0-9_]*)\}\}`)
 
-// BuildDependencyGraph builds a dependency graph from variable defaults.
+// BuildDependencyGraph builds a dependency graph from variable defaults and
+// the depends_on and prompt_if conditions of the variables.
 // Returns a map where key is variable name and value is list of variables it depends on.
 func BuildDependencyGraph(vars []TemplateVar) map[string][]string {
 	graph := make(map[string][]string)
+	declared := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		declared[v.Name] = true
+	}
 
 	for _, v := range vars {
 		graph[v.Name] = []string{}
 
-		// Check if default contains variable references
-		if v.Default == nil {
-			continue
+		// Only add dependencies on other template variables
+		for _, refName := range varRefs(v) {
+			if declared[refName] && !slices.Contains(graph[v.Name], refName) {
+				graph[v.Name] = append(graph[v.Name], refName)
+			}
+		}
+	}
+
+	return graph
+}
+
+// varRefs returns the variables v refers to in its default, depends_on, and
+// prompt_if, in that order.
+func varRefs(v TemplateVar) []string {
+	var refs []string
+	if defaultStr, ok := v.Default.(string); ok {
+		for _, match := range variableRefPattern.FindAllStringSubmatch(defaultStr, -1) {
+			refs = append(refs, match[1])
+		}
+	}
+	return append(refs, conditionRefs(v)...)
+}
+
+// conditionRefs returns the variables the depends_on and prompt_if
+// conditions of v refer to.
+func conditionRefs(v TemplateVar) []string {
+	refs := slices.Clone(v.DependsOn)
+	for _, match := range variableRefPattern.FindAllStringSubmatch(v.PromptIf, -1) {
+		refs = append(refs, match[1])
+	}
+	return refs
+}
+
+// OrderVariables returns vars in declaration order, except that variables
+// come after those their defaults and conditions refer to, which is the
+// order to ask for them in. It returns a CyclicVariableError if variables
+// refer to each other in a cycle.
+func OrderVariables(vars []TemplateVar) ([]TemplateVar, error) {
+	graph := BuildDependencyGraph(vars)
+	if cycle := detectCycle(graph); cycle != nil {
+		return nil, &CyclicVariableError{Cycle: cycle}
+	}
+
+	byName := make(map[string]TemplateVar, len(vars))
+	for _, v := range vars {
+		byName[v.Name] = v
+	}
+	ordered := make([]TemplateVar, 0, len(vars))
+	added := make(map[string]bool, len(vars))
+	var add func(name string)
+	add = func(name string) {
+		if added[name] {
+			return
+		}
+		added[name] = true
+		for _, dep := range graph[name] {
+			add(dep)
+		}
+		ordered = append(ordered, byName[name])
+	}
+	for _, v := range vars {
+		add(v.Name)
+	}
+	return ordered, nil
+}
+
+// VarApplies reports whether v is to be asked for given the values of the
+// variables before it: each of its depends_on variables must be true, and
+// its prompt_if condition must hold. A variable that does not apply is not
+// asked for, is never required, and resolves to empty.
+func VarApplies(v TemplateVar, values map[string]string) bool {
+	for _, name := range v.DependsOn {
+		if !isTruthy(values[name]) {
+			return false
+		}
+	}
+	if v.PromptIf != "" {
+		holds, err := evaluatePartialWhen(v.PromptIf, values)
+		if err != nil || !holds {
+			return false
 		}
+	}
+	return true
+}
 
-		defaultStr, ok := v.Default.(string)
-		if !ok {
+// conditionsKnown reports whether values holds every declared variable the
+// conditions of v refer to, so VarApplies can tell whether v applies.
+func conditionsKnown(v TemplateVar, values map[string]string, declared map[string]bool) bool {
+	for _, name := range conditionRefs(v) {
+		if _, ok := values[name]; !ok && declared[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// describeConditions describes the depends_on and prompt_if conditions of v.
+func describeConditions(v TemplateVar) string {
+	var conds []string
+	for _, name := range v.DependsOn {
+		conds = append(conds, name+" is true")
+	}
+	if v.PromptIf != "" {
+		conds = append(conds, v.PromptIf)
+	}
+	return strings.Join(conds, " and ")
+}
+
+// KnownValues returns the values known before asking for any variables of
+// tmpl: builtins, provided values, and defaults. Variables that do not apply
+// (see VarApplies) are empty. Variables left to ask for are missing, as are
+// those whose conditions refer to them.
+func KnownValues(tmpl *Template, provided, builtins map[string]string) map[string]string {
+	values := make(map[string]string, len(builtins)+len(tmpl.Variables))
+	for k, v := range builtins {
+		values[k] = v
+	}
+	declared := make(map[string]bool, len(tmpl.Variables))
+	for _, v := range tmpl.Variables {
+		declared[v.Name] = true
+	}
+	order, err := OrderVariables(tmpl.Variables)
+	if err != nil {
+		order = tmpl.Variables
+	}
+
+	for _, v := range order {
+		if !conditionsKnown(v, values, declared) {
 			continue
 		}
+		if !VarApplies(v, values) {
+			values[v.Name] = ""
+			continue
+		}
+		if value, ok := provided[v.Name]; ok {
+			values[v.Name] = value
+		} else if v.Default != nil {
+			values[v.Name], _ = SubstituteVariables(fmt.Sprintf("%v", v.Default), values)
+		} else if _, ok := builtins[v.Name]; !ok && !v.Required {
+			values[v.Name] = ""
+		}
+	}
+	return values
+}
 
-		// Find all variable references in default
-		matches := variableRefPattern.FindAllStringSubmatch(defaultStr, -1)
-		for _, match := range matches {
-			if len(match) >= 2 {
-				refName := match[1]
-				// Only add dependency if it references another template variable
-				for _, otherVar := range vars {
-					if otherVar.Name == refName {
-						graph[v.Name] = append(graph[v.Name], refName)
-						break
-					}
-				}
+// CheckProvidedVars checks values given up front, as with --var, against the
+// variables of tmpl: the variables must not refer to each other in a cycle,
+// and values may only be given for variables that apply.
+func CheckProvidedVars(tmpl *Template, provided, builtins map[string]string) error {
+	if _, err := OrderVariables(tmpl.Variables); err != nil {
+		return err
+	}
+	values := KnownValues(tmpl, provided, builtins)
+	declared := make(map[string]bool, len(tmpl.Variables))
+	for _, v := range tmpl.Variables {
+		declared[v.Name] = true
+	}
+	for _, v := range tmpl.Variables {
+		value, ok := provided[v.Name]
+		// Whether v applies may only be known once other variables are asked for
+		if !ok || !conditionsKnown(v, values, declared) {
+			continue
+		}
+		if !VarApplies(v, values) {
+			return &InvalidVarValueError{
+				VarName: v.Name,
+				Value:   value,
+				Reason:  "only applies when " + describeConditions(v),
 			}
 		}
 	}
-
-	return graph
+	return nil
 }
 
 // TopologicalSort returns variables in dependency order (dependencies first).
@@ -335,6 +506,12 @@ func ResolveVariables(tmpl *Template, provided map[string]string, builtins map[s
 			continue
 		}
 
+		// Variables whose conditions do not hold are empty
+		if !VarApplies(varDef, resolved) {
+			resolved[varName] = ""
+			continue
+		}
+
 		// Check if value was provided
 		if value, ok := provided[varName]; ok {
 			// Validate provided value
@@ -466,15 +643,28 @@ func NormalizeBoolValue(value string) string {
 	}
 }
 
-// GetMissingRequiredVars returns a list of required variables that are not provided.
+// GetMissingRequiredVars returns a list of required variables that are not
+// provided, in the order to ask for them in (see OrderVariables). Variables
+// that do not apply are left out; those whose conditions refer to missing
+// variables are included, so check VarApplies before asking for each.
 func GetMissingRequiredVars(tmpl *Template, provided map[string]string, builtins map[string]string) []TemplateVar {
 	var missing []TemplateVar
 
-	for _, v := range tmpl.Variables {
+	known := KnownValues(tmpl, provided, builtins)
+	order, err := OrderVariables(tmpl.Variables)
+	if err != nil {
+		order = tmpl.Variables
+	}
+	for _, v := range order {
 		if !v.Required {
 			continue
 		}
 
+		// Check if known, which includes variables that do not apply
+		if _, ok := known[v.Name]; ok {
+			continue
+		}
+
 		// Check if provided
 		if _, ok := provided[v.Name]; ok {
 			continue
@@ -2,6 +2,7 @@ package template
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -478,11 +479,11 @@ func TestGetBuiltinVariables(t *testing.T) {
 	path := "/tmp/acme--webapp"
 	root := "/tmp"
 
-	vars := GetBuiltinVariables(owner, project, path, root)
+	vars := GetBuiltinVariables(owner, project, "acme--webapp", path, root)
 
 	expectedKeys := []string{
 		"OWNER", "PROJECT", "SLUG", "CREATED_DATE", "CREATED_DATETIME",
-		"YEAR", "CODE_ROOT", "WORKSPACE_PATH",
+		"YEAR", "CODE_ROOT", "WORKSPACE_PATH", "CONTAINER_WORKSPACE", "NIX_SYSTEM",
 	}
 
 	for _, key := range expectedKeys {
@@ -498,3 +499,120 @@ func TestGetBuiltinVariables(t *testing.T) {
 		t.Errorf("Expected SLUG=%s--%s, got %s", owner, project, vars["SLUG"])
 	}
 }
+
+func TestNixSystem(t *testing.T) {
+	cases := map[[2]string]string{
+		{"linux", "amd64"}:   "x86_64-linux",
+		{"darwin", "arm64"}:  "aarch64-darwin",
+		{"linux", "riscv64"}: "riscv64-linux",
+	}
+	for in, want := range cases {
+		if got := nixSystem(in[0], in[1]); got != want {
+			t.Errorf("nixSystem(%s, %s) = %s, want %s", in[0], in[1], got, want)
+		}
+	}
+}
+
+func FuzzProcessTemplateContent(f *testing.F) {
+	for _, seed := range []string{
+		"# {{name}}",
+		"{{#if ci}}ci: true{{/if}}",
+		`{{#if db == "postgres"}}pg{{/if}}{{#if db != "sqlite"}}!{{/if}}`,
+		"{{{{name}}}}",
+		"{{#if name}}{{#if name}}x{{/if}}{{/if}}",
+		"{{",
+		"}}{{/if}}",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, content string) {
+		if got, err := SubstituteVariables(content, nil); err != nil || got != content {
+			t.Errorf("SubstituteVariables(%q, nil) = %q, %v; want unchanged", content, got, err)
+		}
+		got, err := SubstituteVariables(content, map[string]string{"name": "X"})
+		if err != nil {
+			t.Fatalf("SubstituteVariables(%q) error = %v", content, err)
+		}
+		if strings.Contains(got, "{{name}}") {
+			t.Errorf("SubstituteVariables(%q) = %q, left {{name}} unresolved", content, got)
+		}
+		if _, err := ProcessTemplateContent(content, map[string]string{"name": "X", "ci": "true", "db": "postgres"}); err != nil {
+			t.Errorf("ProcessTemplateContent(%q) error = %v", content, err)
+		}
+	})
+}
+
+func TestConditionalVariables(t *testing.T) {
+	tmpl := &Template{
+		Variables: []TemplateVar{
+			{Name: "DB_NAME", Type: VarTypeString, Required: true, DependsOn: []string{"USE_DATABASE"}},
+			{Name: "DB_KIND", Type: VarTypeChoice, Choices: []string{"postgres", "sqlite"}, Default: "postgres", DependsOn: []string{"USE_DATABASE"}},
+			{Name: "DB_HOST", Type: VarTypeString, Required: true, DependsOn: []string{"USE_DATABASE"}, PromptIf: "{{DB_KIND}} != sqlite"},
+			{Name: "USE_DATABASE", Type: VarTypeBoolean, Required: true},
+		},
+	}
+
+	ordered, err := OrderVariables(tmpl.Variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, v := range ordered {
+		names = append(names, v.Name)
+	}
+	if want := []string{"USE_DATABASE", "DB_NAME", "DB_KIND", "DB_HOST"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("OrderVariables() = %v, want %v", names, want)
+	}
+
+	// Without USE_DATABASE, whether the others apply is not known yet
+	missing := GetMissingRequiredVars(tmpl, nil, nil)
+	if len(missing) != 3 || missing[0].Name != "USE_DATABASE" {
+		t.Errorf("GetMissingRequiredVars() = %+v, want USE_DATABASE first of 3", missing)
+	}
+	if missing := GetMissingRequiredVars(tmpl, map[string]string{"USE_DATABASE": "false"}, nil); len(missing) != 0 {
+		t.Errorf("GetMissingRequiredVars() without a database = %+v, want none", missing)
+	}
+
+	resolved, err := ResolveVariables(tmpl, map[string]string{"USE_DATABASE": "false", "DB_NAME": "app"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved["DB_NAME"] != "" || resolved["DB_KIND"] != "" || resolved["DB_HOST"] != "" {
+		t.Errorf("ResolveVariables() without a database = %v, want the database variables empty", resolved)
+	}
+	resolved, err = ResolveVariables(tmpl, map[string]string{"USE_DATABASE": "true", "DB_NAME": "app", "DB_KIND": "sqlite"}, nil)
+	if err != nil {
+		t.Fatalf("ResolveVariables() with sqlite error = %v", err)
+	}
+	if resolved["DB_NAME"] != "app" || resolved["DB_HOST"] != "" {
+		t.Errorf("ResolveVariables() with sqlite = %v", resolved)
+	}
+	if _, err := ResolveVariables(tmpl, map[string]string{"USE_DATABASE": "true", "DB_NAME": "app"}, nil); err == nil {
+		t.Error("ResolveVariables() should require DB_HOST for postgres")
+	}
+
+	if err := CheckProvidedVars(tmpl, map[string]string{"USE_DATABASE": "no", "DB_NAME": "app"}, nil); err == nil ||
+		!strings.Contains(err.Error(), "only applies when USE_DATABASE is true") {
+		t.Errorf("CheckProvidedVars() for a variable that does not apply = %v", err)
+	}
+	if err := CheckProvidedVars(tmpl, map[string]string{"DB_NAME": "app"}, nil); err != nil {
+		t.Errorf("CheckProvidedVars() before USE_DATABASE is asked = %v", err)
+	}
+
+	cyclic := &Template{Variables: []TemplateVar{
+		{Name: "A", DependsOn: []string{"B"}},
+		{Name: "B", PromptIf: "{{A}} == x"},
+	}}
+	if _, err := OrderVariables(cyclic.Variables); err == nil {
+		t.Error("OrderVariables() should detect a cycle")
+	}
+	if err := CheckProvidedVars(cyclic, map[string]string{"A": "1"}, nil); err == nil {
+		t.Error("CheckProvidedVars() should detect a cycle")
+	}
+	cyclic.Name, cyclic.Description = "cyclic", "Cyclic"
+	cyclic.Variables = append(cyclic.Variables, TemplateVar{Name: "C", DependsOn: []string{"MISSING"}})
+	err = ValidateTemplate(cyclic)
+	if err == nil || !strings.Contains(err.Error(), "circular variable reference") || !strings.Contains(err.Error(), "unknown variable: MISSING") {
+		t.Errorf("ValidateTemplate() = %v, want a cycle and an unknown variable", err)
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -92,6 +93,11 @@ func (m confirmModel) View() string {
 }
 
 func RunConfirm(message string) (ConfirmResult, error) {
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return ConfirmResult{Aborted: true}, err
+	} else if lines {
+		return runPlainConfirm(message), nil
+	}
 	m := newConfirmModel(message)
 	p := tea.NewProgram(m)
 
@@ -563,6 +563,11 @@ func (m excludePickerModel) renderNode(node *fileNode, isSelected bool) string {
 // RunExcludePicker runs the interactive exclude picker TUI.
 func RunExcludePicker(workspacePath string, defaultExcludes []string) (ExcludePickerResult, error) {
 	m := newExcludePickerModel(workspacePath, defaultExcludes)
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return ExcludePickerResult{Aborted: true}, err
+	} else if lines {
+		return runPlainExcludePicker(m), nil
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/tormodhaugland/co/internal/fs"
 )
 
 // Styles for extra files picker
@@ -22,6 +23,7 @@ var (
 	efPickerCheckedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("108")) // muted sage (included)
 	efPickerUncheckedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245")) // gray (not included)
 	efPickerDirStyle       = lipgloss.NewStyle().Bold(true)
+	efPickerSecretStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // orange (likely secret)
 )
 
 // ExtraFilesResult holds the result of the extra files picker.
@@ -38,6 +40,7 @@ type extraFileItem struct {
 	RelPath string // path relative to source folder
 	IsDir   bool   // true if directory
 	Checked bool   // true if selected for inclusion
+	Secret  bool   // true if the file, or something inside the folder, likely holds secrets
 }
 
 // extraFilesPickerModel is the Bubble Tea model for selecting extra files.
@@ -45,6 +48,7 @@ type extraFilesPickerModel struct {
 	sourcePath   string           // absolute path to source folder
 	items        []extraFileItem  // list of non-git files/folders
 	selected     int              // currently selected index
+	visual       visualRange      // visual range selection
 	width        int              // terminal width
 	height       int              // terminal height
 	scrollOffset int              // for scrolling long lists
@@ -57,8 +61,10 @@ type extraFilesPickerModel struct {
 }
 
 // FindNonGitItems finds files and folders in sourcePath that are not inside any git repository.
-// gitRoots is the list of git repository roots found in the source path.
-func FindNonGitItems(sourcePath string, gitRoots []string) ([]extraFileItem, error) {
+// gitRoots is the list of git repository roots found in the source path. Hidden
+// files are listed according to hidden. Items that likely hold secrets, by
+// fs.SecretPatterns and the extra secretPatterns, are flagged.
+func FindNonGitItems(sourcePath string, gitRoots []string, hidden HiddenPolicy, secretPatterns []string) ([]extraFileItem, error) {
 	var items []extraFileItem
 
 	// Build a set of git root paths for quick lookup
@@ -78,7 +84,7 @@ func FindNonGitItems(sourcePath string, gitRoots []string) ([]extraFileItem, err
 		fullPath := filepath.Join(sourcePath, name)
 
 		// Skip hidden files that are typically not useful
-		if strings.HasPrefix(name, ".") && name != ".env" && name != ".gitignore" {
+		if !hidden.Lists(name) {
 			continue
 		}
 
@@ -92,11 +98,17 @@ func FindNonGitItems(sourcePath string, gitRoots []string) ([]extraFileItem, err
 		}
 
 		if !isGitManaged {
+			secret := fs.IsSecret(name, entry.IsDir(), secretPatterns)
+			if !secret && entry.IsDir() {
+				found, _ := fs.FindSecrets(fullPath, secretPatterns)
+				secret = len(found) > 0
+			}
 			items = append(items, extraFileItem{
 				Name:    name,
 				RelPath: name,
 				IsDir:   entry.IsDir(),
 				Checked: false,
+				Secret:  secret,
 			})
 		}
 	}
@@ -143,6 +155,11 @@ func (m extraFilesPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc", "q":
+			if m.visual.active && msg.String() != "ctrl+c" {
+				// Cancel visual mode
+				m.visual.active = false
+				return m, nil
+			}
 			m.result.Aborted = true
 			m.done = true
 			return m, tea.Quit
@@ -194,11 +211,18 @@ func (m extraFilesPickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
+		case "V":
+			// Start visual mode, or check the range and leave it
+			if !m.visual.active {
+				m.visual.start(m.selected)
+				return m, nil
+			}
+			checkVisualRange(m.items, &m.visual, m.selected)
+			return m, nil
+
 		case "a":
 			// Select all
-			for i := range m.items {
-				m.items[i].Checked = true
-			}
+			selectAllExtraFiles(m.items)
 			return m, nil
 
 		case "n":
@@ -248,6 +272,15 @@ func (m extraFilesPickerModel) updateDestPrompt(msg tea.Msg) (tea.Model, tea.Cmd
 	return m, cmd
 }
 
+// checkVisualRange checks every item in the visual range and leaves visual mode.
+func checkVisualRange(items []extraFileItem, v *visualRange, cursor int) {
+	lo, hi := v.bounds(cursor, len(items))
+	for i := lo; i <= hi; i++ {
+		items[i].Checked = true
+	}
+	v.active = false
+}
+
 // getSelectedPaths returns the relative paths of all checked items.
 func (m *extraFilesPickerModel) getSelectedPaths() []string {
 	var paths []string
@@ -300,7 +333,7 @@ func (m extraFilesPickerModel) View() string {
 
 	for i := startIdx; i < endIdx; i++ {
 		item := m.items[i]
-		line := m.renderItem(item, i == m.selected)
+		line := m.renderItem(item, i == m.selected, m.visual.contains(i, m.selected, len(m.items)))
 		sb.WriteString(line + "\n")
 	}
 
@@ -317,9 +350,12 @@ func (m extraFilesPickerModel) View() string {
 		}
 	}
 	sb.WriteString(fmt.Sprintf("\n\n%d of %d selected", selectedCount, len(m.items)))
+	if warning := secretsWarning(m.items); warning != "" {
+		sb.WriteString("\n" + efPickerSecretStyle.Render(warning))
+	}
 
 	// Help
-	sb.WriteString("\n\n" + efPickerHelpStyle.Render("j/k: navigate • space: toggle • a: all • n: none"))
+	sb.WriteString("\n\n" + efPickerHelpStyle.Render("j/k: navigate • space: toggle • V: range • a: all but secrets • n: none"))
 	sb.WriteString("\n" + efPickerHelpStyle.Render("enter: continue • q/esc: skip extra files"))
 
 	return sb.String()
@@ -349,11 +385,13 @@ func (m extraFilesPickerModel) viewDestPrompt() string {
 }
 
 // renderItem renders a single item row.
-func (m extraFilesPickerModel) renderItem(item extraFileItem, isSelected bool) string {
+func (m extraFilesPickerModel) renderItem(item extraFileItem, isSelected, inVisualRange bool) string {
 	// Checkbox
 	var checkbox string
 	if item.Checked {
 		checkbox = "[x] "
+	} else if inVisualRange {
+		checkbox = "[◆] "
 	} else {
 		checkbox = "[ ] "
 	}
@@ -375,7 +413,43 @@ func (m extraFilesPickerModel) renderItem(item extraFileItem, isSelected bool) s
 		line = efPickerUncheckedStyle.Render(line)
 	}
 
-	return line
+	return line + secretLabel(item)
+}
+
+// selectAllExtraFiles checks every item except those that likely hold
+// secrets, which must be checked one by one.
+func selectAllExtraFiles(items []extraFileItem) {
+	for i := range items {
+		if !items[i].Secret {
+			items[i].Checked = true
+		}
+	}
+}
+
+// secretLabel flags an item that likely holds secrets.
+func secretLabel(item extraFileItem) string {
+	switch {
+	case !item.Secret:
+		return ""
+	case item.IsDir:
+		return efPickerSecretStyle.Render("  ⚠ contains secrets")
+	default:
+		return efPickerSecretStyle.Render("  ⚠ likely secret")
+	}
+}
+
+// secretsWarning returns a warning when checked items likely hold secrets.
+func secretsWarning(items []extraFileItem) string {
+	n := 0
+	for _, item := range items {
+		if item.Checked && item.Secret {
+			n++
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("⚠ %d selected item(s) likely hold secrets and will be copied into the workspace", n)
 }
 
 // RunExtraFilesPicker runs the interactive extra files picker TUI.
@@ -383,6 +457,11 @@ func RunExtraFilesPicker(sourcePath string, items []extraFileItem) (ExtraFilesRe
 	if len(items) == 0 {
 		return ExtraFilesResult{Confirmed: true}, nil
 	}
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return ExtraFilesResult{}, err
+	} else if lines {
+		return runPlainExtraFilesPicker(items), nil
+	}
 
 	m := newExtraFilesPickerModel(sourcePath, items)
 	p := tea.NewProgram(m, tea.WithAltScreen())
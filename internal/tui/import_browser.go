@@ -1,21 +1,30 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/tormodhaugland/co/internal/archive"
 	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/errfmt"
 	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/platform"
+	"github.com/tormodhaugland/co/internal/quarantine"
 	"github.com/tormodhaugland/co/internal/template"
 	"github.com/tormodhaugland/co/internal/workspace"
 )
@@ -30,11 +39,20 @@ type sourceNode struct {
 	IsExpanded  bool          // true if directory is expanded (shows children)
 	IsSelected  bool          // true if selected for batch operations
 	IsGitRepo   bool          // true if this directory is a git repository root
-	GitInfo     *git.RepoInfo // git info if IsGitRepo is true, nil otherwise
+	GitInfo     *git.RepoInfo // git info once loaded if IsGitRepo is true, nil otherwise
 	HasGitChild bool          // true if any descendant is a git repository
 	IsSymlink   bool          // true if this is a symbolic link
+	Size        int64         // file size (directories use the async size cache)
+	ModTime     time.Time     // entry mtime (directories use the async mtime cache)
 	Depth       int           // indentation depth in tree
 	Children    []*sourceNode // child nodes (only for directories)
+	EntryFilter string        // case-insensitive name filter for this directory's entries
+	AlwaysShow  []string      // dotfiles listed while hidden files are hidden (inherited from the root)
+	FollowLinks bool          // expand symlinked directories (inherited from the root)
+	SymlinkLoop bool          // symlink to one of its own ancestors; never expanded
+
+	pending []os.DirEntry // entries not loaded yet; large directories load in pages
+	moreOf  *sourceNode   // for the "more entries" placeholder, the directory it pages
 }
 
 // ImportBrowserState represents the current state of the import browser TUI.
@@ -43,12 +61,15 @@ type ImportBrowserState int
 const (
 	StateBrowse             ImportBrowserState = iota // Browsing the source folder tree
 	StateImportConfig                                 // Configuring import (owner/project input)
+	StateWorkspaceConflict                            // Choosing what to do when the workspace already exists
 	StateTemplateSelect                               // Selecting a template to apply
 	StateTemplateVars                                 // Prompting for template variables
+	StateSplitSelect                                  // Choosing subdirectories of a repo to split into repos
 	StateExtraFiles                                   // Selecting extra non-git files to include
 	StateImportPreview                                // Previewing import operation
 	StateImportExecute                                // Executing import operation
 	StatePostImport                                   // Post-import options (stash/delete source)
+	StateOpenWorkspace                                // Choosing how to open a workspace just created
 	StateStashConfirm                                 // Confirming stash operation
 	StateStashExecute                                 // Executing stash operation
 	StateAddToSelect                                  // Selecting workspace for add-to mode
@@ -58,8 +79,16 @@ const (
 	StateBatchStashConfirm                            // Confirming batch stash of multiple folders
 	StateBatchStashExecute                            // Executing batch stash
 	StateBatchStashSummary                            // Showing batch stash results
+	StateBatchAddToConfirm                            // Confirming batch add of multiple folders to one workspace
+	StateBatchAddToExecute                            // Executing batch add-to
+	StateBatchAddToSummary                            // Showing batch add-to results
+	StateBatchDeleteConfirm                           // Confirming batch delete/trash of multiple items
+	StateBatchDeleteExecute                           // Executing batch delete/trash
+	StateBatchDeleteSummary                           // Showing batch delete/trash results
 	StateDeleteConfirm                                // Confirming delete operation
 	StateTrashConfirm                                 // Confirming trash operation
+	StateFilePreview                                  // Quick-look preview of a file
+	StateFileOp                                       // Prompting for new folder / rename / move
 	StateComplete                                     // Operation completed
 )
 
@@ -70,10 +99,14 @@ func (s ImportBrowserState) String() string {
 		return "Browse"
 	case StateImportConfig:
 		return "Import Config"
+	case StateWorkspaceConflict:
+		return "Workspace Exists"
 	case StateTemplateSelect:
 		return "Template Select"
 	case StateTemplateVars:
 		return "Template Variables"
+	case StateSplitSelect:
+		return "Split Repo"
 	case StateExtraFiles:
 		return "Extra Files"
 	case StateImportPreview:
@@ -82,6 +115,8 @@ func (s ImportBrowserState) String() string {
 		return "Importing"
 	case StatePostImport:
 		return "Post Import"
+	case StateOpenWorkspace:
+		return "Open Workspace"
 	case StateStashConfirm:
 		return "Stash Confirm"
 	case StateStashExecute:
@@ -100,10 +135,26 @@ func (s ImportBrowserState) String() string {
 		return "Batch Stashing"
 	case StateBatchStashSummary:
 		return "Batch Stash Summary"
+	case StateBatchAddToConfirm:
+		return "Batch Add Confirm"
+	case StateBatchAddToExecute:
+		return "Batch Adding"
+	case StateBatchAddToSummary:
+		return "Batch Add Summary"
+	case StateBatchDeleteConfirm:
+		return "Batch Delete Confirm"
+	case StateBatchDeleteExecute:
+		return "Batch Deleting"
+	case StateBatchDeleteSummary:
+		return "Batch Delete Summary"
 	case StateDeleteConfirm:
 		return "Delete Confirm"
 	case StateTrashConfirm:
 		return "Trash Confirm"
+	case StateFilePreview:
+		return "File Preview"
+	case StateFileOp:
+		return "File Operation"
 	case StateComplete:
 		return "Complete"
 	default:
@@ -135,6 +186,10 @@ type ImportBrowserResult struct {
 	Success bool  // true if operation succeeded
 	Error   error // error if operation failed
 	Aborted bool  // true if user cancelled
+
+	// ChdirTo is the workspace the shell should change to, chosen after an
+	// import; the command hands it to the shell integration
+	ChdirTo string
 }
 
 // BatchImportItemResult holds the result of importing a single folder in a batch operation.
@@ -146,6 +201,18 @@ type BatchImportItemResult struct {
 	RepoCount     int    // Number of repos imported
 	Success       bool   // Whether this import succeeded
 	Error         error  // Error if import failed
+	TemplateError error  // Error if the batch template failed to apply (workspace still created)
+}
+
+// BatchAddToItemResult holds the result of adding a single folder to a workspace in a batch operation.
+type BatchAddToItemResult struct {
+	SourcePath   string // Source folder path
+	SourceName   string // Source folder name
+	RepoCount    int    // Number of repos added
+	SkippedCount int    // Number of repos skipped (e.g. name conflicts)
+	SourceEmpty  bool   // Whether the source was empty afterwards and removed
+	Success      bool   // Whether this add succeeded
+	Error        error  // Error if add failed
 }
 
 // BatchStashItemResult holds the result of stashing a single folder in a batch operation.
@@ -158,6 +225,35 @@ type BatchStashItemResult struct {
 	Error       error  // Error if stash failed
 }
 
+// BatchDeleteItemResult holds the result of deleting or trashing a single item in a batch.
+type BatchDeleteItemResult struct {
+	SourcePath string // Item path
+	SourceName string // Item name
+	Success    bool   // Whether this delete/trash succeeded
+	Error      error  // Error if delete/trash failed
+}
+
+// deleteRisk describes a git repo inside a delete target that holds work not saved elsewhere.
+type deleteRisk struct {
+	TargetName string // Name of the selected item containing the repo
+	git.UnsavedWork
+}
+
+// fileOpKind identifies a file-management action in the import browser.
+type fileOpKind int
+
+const (
+	fileOpMkdir  fileOpKind = iota // Create a new folder
+	fileOpRename                   // Rename the current entry
+	fileOpMove                     // Move entries into another directory
+	fileOpFilter                   // Filter the entries listed in a directory
+)
+
+// shellExitMsg is sent when a shell spawned from the browser exits.
+type shellExitMsg struct {
+	err error
+}
+
 // sizeResultMsg is sent when an async directory size calculation completes.
 type sizeResultMsg struct {
 	Path string
@@ -165,6 +261,25 @@ type sizeResultMsg struct {
 	Err  error
 }
 
+// sizeProgressMsg carries the running total of a streamed size calculation.
+type sizeProgressMsg struct {
+	Path   string
+	Size   int64
+	events chan tea.Msg
+}
+
+// sizeProgressInterval is how often a streamed size calculation reports its
+// running total.
+const sizeProgressInterval = 100 * time.Millisecond
+
+// sizeStream is the streamed size calculation for the selected directory.
+type sizeStream struct {
+	path   string
+	events chan tea.Msg
+	cancel context.CancelFunc
+	total  int64 // running total so far
+}
+
 // operationResultMsg is sent when an async operation (stash, delete, etc.) completes.
 type operationResultMsg struct {
 	Operation string // "stash", "delete", "trash", "import"
@@ -173,38 +288,140 @@ type operationResultMsg struct {
 	Err       error
 }
 
+// mtimeResultMsg is sent when an async last-modified scan of a directory completes.
+type mtimeResultMsg struct {
+	Path    string
+	ModTime time.Time
+	Err     error
+}
+
+// gitInfoResultMsg is sent when async git info collection for a repo completes.
+type gitInfoResultMsg struct {
+	Path string
+	Info *git.RepoInfo
+	Err  error
+}
+
 // spinnerTickMsg is sent to animate the loading spinner.
 type spinnerTickMsg struct{}
 
+// treeSortMode controls how sibling entries are ordered in the source tree.
+type treeSortMode int
+
+const (
+	treeSortName treeSortMode = iota
+	treeSortSize
+	treeSortMtime
+	treeSortGit // Git repos, then folders containing repos, then the rest
+	treeSortModeCount
+)
+
+// String returns a human-readable name for the sort mode.
+func (s treeSortMode) String() string {
+	switch s {
+	case treeSortSize:
+		return "size"
+	case treeSortMtime:
+		return "modified"
+	case treeSortGit:
+		return "git repos first"
+	default:
+		return "name"
+	}
+}
+
+// next returns the following sort mode, wrapping around.
+func (s treeSortMode) next() treeSortMode {
+	return (s + 1) % treeSortModeCount
+}
+
+// parseTreeSortMode maps a config value ("name", "size", "modified", "git")
+// to a sort mode, falling back to name for unknown values.
+func parseTreeSortMode(value string) treeSortMode {
+	switch strings.ToLower(value) {
+	case "size":
+		return treeSortSize
+	case "modified", "mtime":
+		return treeSortMtime
+	case "git":
+		return treeSortGit
+	default:
+		return treeSortName
+	}
+}
+
+// gitSortRank orders entries for treeSortGit: repos, folders containing
+// repos, other folders, then files.
+func gitSortRank(node *sourceNode) int {
+	switch {
+	case node.IsGitRepo:
+		return 0
+	case node.HasGitChild:
+		return 1
+	case node.IsDir:
+		return 2
+	default:
+		return 3
+	}
+}
+
 // spinnerFrames defines the animation frames for the loading spinner.
 var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 
-// maxSourceDirEntries limits entries per directory to keep UI responsive.
-const maxSourceDirEntries = 500
+// staleThreshold is how long a folder must be untouched to count as stale.
+const staleThreshold = 365 * 24 * time.Hour
+
+// sourceDirPageSize is how many entries of a directory are loaded at a time
+// to keep the UI responsive; the rest load as the listing is scrolled.
+const sourceDirPageSize = 500
+
+// HiddenPolicy decides which hidden files (dotfiles) the import browser and
+// the extra files picker list.
+type HiddenPolicy struct {
+	ShowAll    bool     // List every hidden file
+	AlwaysShow []string // Dotfiles listed even when hidden files are hidden
+}
+
+// HiddenPolicyFor returns the hidden-file policy configured under
+// "import_browser".
+func HiddenPolicyFor(cfg *config.Config) HiddenPolicy {
+	bc := cfg.GetImportBrowserConfig()
+	return HiddenPolicy{ShowAll: bc.ShowHidden, AlwaysShow: bc.AlwaysShow}
+}
 
-// gitScanMaxDepth controls how deep the initial git repository scan goes.
-// This improves startup performance for large directory trees.
-// Set to -1 for unlimited depth (not recommended for large trees).
-const gitScanMaxDepth = 4
+// Lists reports whether an entry with the given name is listed.
+func (p HiddenPolicy) Lists(name string) bool {
+	return p.ShowAll || !strings.HasPrefix(name, ".") || slices.Contains(p.AlwaysShow, name)
+}
 
-// buildSourceTree creates the root node and detects git repositories.
-// It scans for git repos first (up to gitScanMaxDepth levels), then builds the tree structure.
+// buildSourceTree creates the root node using the default git scan options
+// and hidden-file policy.
 // If showHidden is true, hidden files (dotfiles) are included in the tree.
 func buildSourceTree(rootPath string, showHidden bool) (*sourceNode, error) {
+	hidden := HiddenPolicyFor(nil)
+	hidden.ShowAll = showHidden
+	root, _, err := scanSourceTree(rootPath, workspace.GitScanOptions(nil), hidden)
+	return root, err
+}
+
+// scanSourceTree scans rootPath for git repositories according to opts, then
+// creates the root node and its immediate children. The scan depth is limited
+// by default to keep startup fast on large trees; the scan result reports
+// where the limit cut it short.
+func scanSourceTree(rootPath string, opts git.ScanOptions, hidden HiddenPolicy) (*sourceNode, git.ScanResult, error) {
 	info, err := os.Stat(rootPath)
 	if err != nil {
-		return nil, err
+		return nil, git.ScanResult{}, err
 	}
 
-	// Find git repositories up to a limited depth for performance
-	gitRoots, err := git.FindGitRootsWithDepth(rootPath, gitScanMaxDepth)
+	scan, err := git.ScanGitRoots(rootPath, opts)
 	if err != nil {
-		return nil, err
+		return nil, scan, err
 	}
 
 	// Build set for quick lookup
 	gitRootSet := make(map[string]bool)
-	for _, root := range gitRoots {
+	for _, root := range scan.Roots {
 		gitRootSet[root] = true
 	}
 
@@ -215,30 +432,31 @@ func buildSourceTree(rootPath string, showHidden bool) (*sourceNode, error) {
 		RelPath:    ".",
 		IsDir:      info.IsDir(),
 		IsExpanded: true, // Root is expanded by default
-		Depth:      0,
+		Depth:       0,
+		AlwaysShow:  hidden.AlwaysShow,
+		FollowLinks: opts.FollowSymlinks,
 	}
 
-	// Check if root itself is a git repo
+	// Check if root itself is a git repo; git info is loaded asynchronously
 	if gitRootSet[rootPath] {
 		root.IsGitRepo = true
-		if gitInfo, err := git.GetInfo(rootPath); err == nil {
-			root.GitInfo = gitInfo
-		}
 	}
 
 	// Load immediate children and mark HasGitChild
 	if root.IsDir {
-		loadSourceChildren(root, gitRootSet, showHidden)
-		root.HasGitChild = hasGitDescendant(root, gitRootSet)
+		loadSourceChildren(root, gitRootSet, hidden.ShowAll)
+		root.HasGitChild = !root.IsGitRepo && len(scan.Roots) > 0
 	}
 
-	return root, nil
+	return root, scan, nil
 }
 
-// loadSourceChildren loads the immediate children of a directory node.
-// If showHidden is false, hidden files (dotfiles) are excluded except for common useful ones.
+// loadSourceChildren loads the immediate children of a directory node, one
+// page at a time (see loadMoreChildren).
+// If showHidden is false, hidden files (dotfiles) are excluded except for the
+// node's AlwaysShow names.
 func loadSourceChildren(node *sourceNode, gitRootSet map[string]bool, showHidden bool) {
-	if !node.IsDir || node.IsSymlink {
+	if !node.IsDir || (node.IsSymlink && !node.FollowLinks) {
 		return
 	}
 
@@ -258,84 +476,169 @@ func loadSourceChildren(node *sourceNode, gitRootSet map[string]bool, showHidden
 		return entries[i].Name() < entries[j].Name()
 	})
 
-	node.Children = make([]*sourceNode, 0, len(entries))
-	addedCount := 0
-
+	hidden := HiddenPolicy{ShowAll: showHidden, AlwaysShow: node.AlwaysShow}
+	filterLower := strings.ToLower(node.EntryFilter)
+	node.pending = make([]os.DirEntry, 0, len(entries))
 	for _, entry := range entries {
 		name := entry.Name()
 
-		// Skip hidden files unless showHidden is true
-		// Always show .env, .gitignore, and .git for git detection
-		if !showHidden && strings.HasPrefix(name, ".") && name != ".env" && name != ".gitignore" && name != ".git" {
+		if !hidden.Lists(name) {
 			continue
 		}
-
-		if addedCount >= maxSourceDirEntries {
-			// Add placeholder for truncated list
-			node.Children = append(node.Children, &sourceNode{
-				Name:    "... more entries not shown",
-				RelPath: "",
-				Depth:   node.Depth + 1,
-			})
-			break
+		if filterLower != "" && !strings.Contains(strings.ToLower(name), filterLower) {
+			continue
 		}
+		node.pending = append(node.pending, entry)
+	}
 
-		childPath := filepath.Join(node.Path, name)
-		relPath := name
-		if node.RelPath != "." {
-			relPath = filepath.Join(node.RelPath, name)
-		}
+	node.Children = make([]*sourceNode, 0, min(len(node.pending), sourceDirPageSize)+1)
+	loadMoreChildren(node, gitRootSet)
+}
 
-		// Check for symlink
-		fileInfo, err := entry.Info()
-		if err != nil {
-			continue
-		}
-		isSymlink := fileInfo.Mode()&os.ModeSymlink != 0
+// loadMoreChildren loads the next page of a directory's pending entries,
+// ending the listing with a placeholder while entries remain.
+func loadMoreChildren(node *sourceNode, gitRootSet map[string]bool) {
+	if n := len(node.Children); n > 0 && node.Children[n-1].moreOf == node {
+		node.Children = node.Children[:n-1]
+	}
 
-		// For symlinks, don't follow them (prevent infinite loops)
-		isDir := entry.IsDir() && !isSymlink
+	page := node.pending
+	if len(page) > sourceDirPageSize {
+		page = page[:sourceDirPageSize]
+	}
+	node.pending = node.pending[len(page):]
 
-		child := &sourceNode{
-			Name:      name,
-			Path:      childPath,
-			RelPath:   relPath,
-			IsDir:     isDir,
-			IsSymlink: isSymlink,
-			Depth:     node.Depth + 1,
+	gitParents := gitParentNames(node, gitRootSet)
+	for _, entry := range page {
+		if child := newSourceChild(node, entry, gitRootSet, gitParents); child != nil {
+			node.Children = append(node.Children, child)
 		}
+	}
+
+	if len(node.pending) > 0 {
+		node.Children = append(node.Children, &sourceNode{
+			Name:    fmt.Sprintf("... %d more entries (scroll to load)", len(node.pending)),
+			RelPath: "",
+			Depth:   node.Depth + 1,
+			moreOf:  node,
+		})
+	} else {
+		node.pending = nil
+	}
+}
 
-		// Check if this is a git repo
-		if isDir && gitRootSet[childPath] {
-			child.IsGitRepo = true
-			if gitInfo, err := git.GetInfo(childPath); err == nil {
-				child.GitInfo = gitInfo
+// gitParentNames returns the names of node's entries that have a git
+// repository somewhere below them, so that each child of a large directory
+// needs a lookup rather than a pass over every repository.
+func gitParentNames(node *sourceNode, gitRootSet map[string]bool) map[string]bool {
+	sep := string(filepath.Separator)
+	prefix := node.Path + sep
+	names := make(map[string]bool)
+	for gitRoot := range gitRootSet {
+		if rest, ok := strings.CutPrefix(gitRoot, prefix); ok {
+			if name, _, below := strings.Cut(rest, sep); below {
+				names[name] = true
 			}
 		}
+	}
+	return names
+}
+
+// newSourceChild creates the tree node for a directory entry of node, or nil
+// if the entry can no longer be read. gitParents holds the names from
+// gitParentNames.
+func newSourceChild(node *sourceNode, entry os.DirEntry, gitRootSet map[string]bool, gitParents map[string]bool) *sourceNode {
+	name := entry.Name()
+	childPath := filepath.Join(node.Path, name)
+	relPath := name
+	if node.RelPath != "." {
+		relPath = filepath.Join(node.RelPath, name)
+	}
+
+	// Check for symlink
+	fileInfo, err := entry.Info()
+	if err != nil {
+		return nil
+	}
+	isSymlink := fileInfo.Mode()&os.ModeSymlink != 0
 
-		// Check if any descendant is a git repo (for display purposes)
-		if isDir {
-			child.HasGitChild = hasGitDescendant(child, gitRootSet)
+	// Symlinked directories are only followed in follow mode, and never
+	// when they point back at one of their own ancestors
+	isDir := entry.IsDir() && !isSymlink
+	loop := false
+	if isSymlink && node.FollowLinks {
+		if target, err := os.Stat(childPath); err == nil && target.IsDir() {
+			loop = isSymlinkLoop(childPath, target)
+			isDir = !loop
 		}
+	}
 
-		node.Children = append(node.Children, child)
-		addedCount++
+	child := &sourceNode{
+		Name:        name,
+		Path:        childPath,
+		RelPath:     relPath,
+		IsDir:       isDir,
+		IsSymlink:   isSymlink,
+		Size:        fileInfo.Size(),
+		ModTime:     fileInfo.ModTime(),
+		Depth:       node.Depth + 1,
+		AlwaysShow:  node.AlwaysShow,
+		FollowLinks: node.FollowLinks,
+		SymlinkLoop: loop,
 	}
-}
 
-// hasGitDescendant checks if any path in gitRootSet is a descendant of node.
-func hasGitDescendant(node *sourceNode, gitRootSet map[string]bool) bool {
-	if node.IsGitRepo {
-		return false // Don't count self
+	// Check if this is a git repo; git info is loaded asynchronously
+	if isDir && gitRootSet[childPath] {
+		child.IsGitRepo = true
 	}
 
-	prefix := node.Path + string(filepath.Separator)
-	for gitRoot := range gitRootSet {
-		if strings.HasPrefix(gitRoot, prefix) {
+	// Check if any descendant is a git repo (for display purposes)
+	if isDir && !child.IsGitRepo {
+		child.HasGitChild = gitParents[name]
+	}
+
+	return child
+}
+
+// isSymlinkLoop reports whether the directory target, reached through the
+// symlink at path, is path's own parent or one of its ancestors, so following
+// it would recurse forever.
+func isSymlinkLoop(path string, target os.FileInfo) bool {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil && os.SameFile(info, target) {
 			return true
 		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
 	}
-	return false
+}
+
+// gitRootIndex holds the detected git repositories sorted by path. The
+// repositories below a folder sort next to each other, so a binary search
+// finds them without testing every repository.
+type gitRootIndex struct {
+	sorted []string
+}
+
+// newGitRootIndex indexes the repositories in gitRootSet.
+func newGitRootIndex(gitRootSet map[string]bool) *gitRootIndex {
+	sorted := make([]string, 0, len(gitRootSet))
+	for gitRoot := range gitRootSet {
+		sorted = append(sorted, gitRoot)
+	}
+	sort.Strings(sorted)
+	return &gitRootIndex{sorted: sorted}
+}
+
+// below returns the repositories strictly beneath dir, sorted. The slice
+// belongs to the index and must not be modified.
+func (ix *gitRootIndex) below(dir string) []string {
+	prefix := dir + string(filepath.Separator)
+	start := sort.SearchStrings(ix.sorted, prefix)
+	rest := ix.sorted[start:]
+	end := sort.Search(len(rest), func(i int) bool { return !strings.HasPrefix(rest[i], prefix) })
+	return rest[:end]
 }
 
 // expandNode expands a directory node, loading its children if needed.
@@ -372,6 +675,63 @@ func (node *sourceNode) toggleExpand(gitRootSet map[string]bool, showHidden bool
 	}
 }
 
+// sortSourceChildren orders children in place: directories first (git repos
+// first with treeSortGit), then by mode.
+// sizeOf and modTimeOf report a node's size and mtime and whether the value is known;
+// nodes with unknown values sort after known ones. Placeholder entries stay last.
+func sortSourceChildren(children []*sourceNode, mode treeSortMode, sizeOf func(*sourceNode) (int64, bool), modTimeOf func(*sourceNode) (time.Time, bool)) {
+	sort.SliceStable(children, func(i, j int) bool {
+		a, b := children[i], children[j]
+
+		// Keep the "more entries" placeholder at the end
+		if (a.Path == "") != (b.Path == "") {
+			return b.Path == ""
+		}
+		if mode == treeSortGit {
+			if ra, rb := gitSortRank(a), gitSortRank(b); ra != rb {
+				return ra < rb
+			}
+		}
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+
+		switch mode {
+		case treeSortSize:
+			as, aok := sizeOf(a)
+			bs, bok := sizeOf(b)
+			if aok != bok {
+				return aok
+			}
+			if as != bs {
+				return as > bs
+			}
+		case treeSortMtime:
+			at, aok := modTimeOf(a)
+			bt, bok := modTimeOf(b)
+			if aok != bok {
+				return aok
+			}
+			if !at.Equal(bt) {
+				return at.After(bt)
+			}
+		}
+
+		return a.Name < b.Name
+	})
+}
+
+// sortSourceTree applies sortSourceChildren to every loaded directory in the tree.
+func sortSourceTree(node *sourceNode, mode treeSortMode, sizeOf func(*sourceNode) (int64, bool), modTimeOf func(*sourceNode) (time.Time, bool)) {
+	if len(node.Children) == 0 {
+		return
+	}
+	sortSourceChildren(node.Children, mode, sizeOf, modTimeOf)
+	for _, child := range node.Children {
+		sortSourceTree(child, mode, sizeOf, modTimeOf)
+	}
+}
+
 // flattenSourceTree flattens the tree into a display list.
 // Only includes expanded directories' children.
 func flattenSourceTree(root *sourceNode) []*sourceNode {
@@ -401,6 +761,7 @@ type sourceTreeScroller struct {
 	selected     int
 	scrollOffset int
 	height       int // visible lines for scrolling
+	generation   int // bumped whenever flatTree is replaced
 }
 
 // newSourceTreeScroller creates a new scroller with the given tree.
@@ -415,6 +776,7 @@ func newSourceTreeScroller(flatTree []*sourceNode, visibleHeight int) *sourceTre
 // updateTree updates the flat tree and adjusts selection if needed.
 func (s *sourceTreeScroller) updateTree(flatTree []*sourceNode) {
 	s.flatTree = flatTree
+	s.generation++
 	// Ensure selected is still valid
 	if s.selected >= len(s.flatTree) {
 		s.selected = len(s.flatTree) - 1
@@ -425,6 +787,23 @@ func (s *sourceTreeScroller) updateTree(flatTree []*sourceNode) {
 	s.ensureVisible()
 }
 
+// replaceSubtree replaces the rows below the node at index i that belong to
+// its subtree with its currently visible descendants.
+func (s *sourceTreeScroller) replaceSubtree(i int) {
+	node := s.flatTree[i]
+	end := i + 1
+	for end < len(s.flatTree) && s.flatTree[end].Depth > node.Depth {
+		end++
+	}
+	var rows []*sourceNode
+	if node.IsDir && node.IsExpanded {
+		for _, child := range node.Children {
+			flattenSourceNode(child, &rows)
+		}
+	}
+	s.updateTree(slices.Replace(s.flatTree, i+1, end, rows...))
+}
+
 // selectByPath finds and selects a node by its path.
 // If the exact path is not found, it tries to select a sibling in the same parent directory,
 // or falls back to the parent directory itself.
@@ -555,27 +934,40 @@ func (s *sourceTreeScroller) isSelected(index int) bool {
 	return index == s.selected
 }
 
-// getSelectedNodes returns all nodes that have been selected for batch operations.
-// This traverses the entire tree (not just visible/flat nodes) to find selected items.
+// getSelectedNodes returns the nodes selected for batch operations, in tree
+// order. Nodes inside a selected directory are left out, since operating on
+// the directory covers them; otherwise a batch would delete or move them
+// twice and count their size twice.
 func (s *sourceTreeScroller) getSelectedNodes() []*sourceNode {
 	var selected []*sourceNode
+	dirs := make(map[string]bool)
 	for _, node := range s.flatTree {
 		if node.IsSelected {
 			selected = append(selected, node)
+			if node.IsDir {
+				dirs[node.Path] = true
+			}
+		}
+	}
+	var top []*sourceNode
+	for _, node := range selected {
+		covered := false
+		for dir := filepath.Dir(node.Path); !covered; dir = filepath.Dir(dir) {
+			covered = dirs[dir]
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+		if !covered {
+			top = append(top, node)
 		}
 	}
-	return selected
+	return top
 }
 
 // getSelectedCount returns the number of nodes selected for batch operations.
 func (s *sourceTreeScroller) getSelectedCount() int {
-	count := 0
-	for _, node := range s.flatTree {
-		if node.IsSelected {
-			count++
-		}
-	}
-	return count
+	return len(s.getSelectedNodes())
 }
 
 // clearAllSelections clears the IsSelected flag on all nodes.
@@ -585,6 +977,86 @@ func (s *sourceTreeScroller) clearAllSelections() {
 	}
 }
 
+// selectAllDirs selects every visible directory except the root.
+func (s *sourceTreeScroller) selectAllDirs() {
+	for _, node := range s.flatTree {
+		if node.IsDir && node.Depth > 0 {
+			node.IsSelected = true
+		}
+	}
+}
+
+// invertDirSelection toggles the selection of every visible directory except the root.
+func (s *sourceTreeScroller) invertDirSelection() {
+	for _, node := range s.flatTree {
+		if node.IsDir && node.Depth > 0 {
+			node.IsSelected = !node.IsSelected
+		}
+	}
+}
+
+// selectSiblingDirs selects the current node and all visible directories at the same level
+// under the same parent.
+func (s *sourceTreeScroller) selectSiblingDirs() {
+	node := s.selectedNode()
+	if node == nil || node.Depth == 0 {
+		return
+	}
+
+	// Find the sibling range: bounded by the nearest shallower nodes on either side
+	start := s.selected
+	for start > 0 && s.flatTree[start-1].Depth >= node.Depth {
+		start--
+	}
+	end := s.selected
+	for end < len(s.flatTree)-1 && s.flatTree[end+1].Depth >= node.Depth {
+		end++
+	}
+
+	for i := start; i <= end; i++ {
+		sibling := s.flatTree[i]
+		if sibling.Depth == node.Depth && sibling.IsDir {
+			sibling.IsSelected = true
+		}
+	}
+}
+
+// visualRange tracks a vim-style visual (range) selection anchored at a list index.
+type visualRange struct {
+	active bool
+	anchor int
+}
+
+// start begins a visual selection anchored at the cursor.
+func (v *visualRange) start(cursor int) {
+	v.active = true
+	v.anchor = cursor
+}
+
+// bounds returns the inclusive range between the anchor and cursor, clamped to n items.
+func (v visualRange) bounds(cursor, n int) (int, int) {
+	lo, hi := v.anchor, cursor
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > n-1 {
+		hi = n - 1
+	}
+	return lo, hi
+}
+
+// contains reports whether index i lies within the active range.
+func (v visualRange) contains(i, cursor, n int) bool {
+	if !v.active {
+		return false
+	}
+	lo, hi := v.bounds(cursor, n)
+	return i >= lo && i <= hi
+}
+
 // Styles for the import browser
 var (
 	ibTitleStyle = lipgloss.NewStyle().
@@ -636,6 +1108,13 @@ var (
 
 	ibSuccessStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("40"))
+
+	ibVisualStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+
+	ibStaleStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("243")).
+			Italic(true)
 )
 
 // ImportBrowserPane represents which pane is focused.
@@ -652,8 +1131,12 @@ type ImportBrowserModel struct {
 	rootPath   string
 	root       *sourceNode
 	gitRootSet map[string]bool
+	gitIndex   *gitRootIndex // gitRootSet sorted; rebuilt by indexGitRoots when the set changes
 	scroller   *sourceTreeScroller
 
+	scanOpts      git.ScanOptions
+	scanTruncated map[string]bool // directories at the scan depth limit
+
 	state      ImportBrowserState
 	activePane ImportBrowserPane
 	width      int
@@ -663,9 +1146,10 @@ type ImportBrowserModel struct {
 	messageIsError bool
 
 	// Loading state for async operations
-	loading        bool   // True when an async operation is in progress
-	loadingMessage string // Description of what's being done
-	spinnerFrame   int    // Current spinner animation frame
+	loading        bool               // True when an async operation is in progress
+	loadingMessage string             // Description of what's being done
+	loadingCancel  context.CancelFunc // Stops the async operation; nil if it can't be stopped
+	spinnerFrame   int                // Current spinner animation frame
 
 	// Import config state
 	importTarget   *sourceNode     // The folder being imported
@@ -673,41 +1157,97 @@ type ImportBrowserModel struct {
 	projectInput   textinput.Model // Project input field
 	configFocusIdx int             // 0 = owner, 1 = project
 	configError    string          // Validation error
+	lastOwner      string          // Owner of the last workspace created, the default for the next
+	defaults       importDefaults  // Other choices of the last import, the defaults for the next
+
+	// Name suggestions for the import config form
+	configSuggestions []string // Free variants of a project name that is taken
+	configSuggestIdx  int      // Next suggestion ctrl+n fills in
+	configSimilar     []string // Existing workspaces with similar names
+	configPackageName string   // Name in the package metadata the project was suggested from
+	configPackageFile string   // File configPackageName was read from, "" when suggested from the folder name
 
 	// Stash config state
-	stashTarget      *sourceNode     // The folder being stashed
-	stashNameInput   textinput.Model // Custom archive name input
-	stashDeleteAfter bool            // Whether to delete after stashing
-	stashFocusIdx    int             // 0 = name, 1 = delete option
-	stashError       string          // Stash validation error
+	stashTarget      *sourceNode         // The folder being stashed
+	stashNameInput   textinput.Model     // Custom archive name input
+	stashDeleteAfter bool                // Whether to delete after stashing
+	stashFocusIdx    int                 // 0 = name, 1 = delete option
+	stashError       string              // Stash validation error
+	stashRisks       []deleteRisk        // Repos whose work is lost if the source is deleted
+	stashSource      archive.StashSource // Git values for the stash name pattern
 
 	// Delete/trash state
-	deleteTarget  *sourceNode // The folder being deleted/trashed
-	deleteIsTrash bool        // True if using trash, false if permanent delete
+	deleteTarget  *sourceNode  // The folder being deleted/trashed
+	deleteIsTrash bool         // True if using trash, false if permanent delete
+	deleteRisks   []deleteRisk // Repos with uncommitted or unpushed work
 
 	// Extra files state
 	extraFilesItems        []extraFileItem  // Non-git items found
 	extraFilesSelected     int              // Currently selected item index
+	extraFilesVisual       visualRange      // Visual range selection in the extra files list
 	extraFilesScrollOffset int              // Scroll offset for long lists
 	extraFilesShowDest     bool             // Show destination prompt
 	extraFilesDestInput    textinput.Model  // Destination subfolder input
 	extraFilesResult       ExtraFilesResult // Selected files result
 
+	// Selected extra files identical to files in the imported repos, and
+	// whether to leave them out of the import
+	extraDuplicates []workspace.ExtraDuplicate
+	skipDuplicates  bool
+
+	// Monorepo split state
+	splitItems        []splitItem     // Subdirectories of the imported repo
+	splitSelected     int             // Currently selected item index
+	splitScrollOffset int             // Scroll offset for long lists
+	splitKeepSource   bool            // Also import the repo itself, not only its splits
+	splitRenaming     bool            // Editing the repo name of the selected item
+	splitNameInput    textinput.Model // Repo name input
+	splitError        string          // Validation error
+
+	// Merge state
+	mergeRepos bool // Merge the imported repos into one repo named after the project
+
+	// Repo names in the import preview
+	repoNames     map[string]string // Names chosen for git roots, overriding the derived name
+	repoSelected  int               // Repo selected in the preview
+	repoRenaming  bool              // Editing the name of the selected repo
+	repoNameInput textinput.Model   // Repo name input
+	repoError     string            // Validation error
+
+	// Workspace conflict state
+	conflictSlug     string // Slug of the workspace that already exists
+	conflictFreeSlug string // First free slug with a numeric suffix
+	conflictOption   int    // Index into conflictOptions()
+
 	// Post-import state
 	postImportSourcePath string // Source path that was imported
 	postImportOption     int    // 0=keep, 1=stash, 2=delete
 
+	// Open workspace state, offered after a successful import
+	openSlugs  []string // Workspaces just created
+	openIdx    int      // Workspace the actions apply to
+	openOption int      // Index into openWorkspaceOptions()
+
 	// Add-to-workspace state
 	addToWorkspaces   []string // List of available workspaces
 	addToSelected     int      // Currently selected workspace index
 	addToScrollOffset int      // Scroll offset for workspace list
 	addToTargetSlug   string   // Selected workspace slug
 
+	// Add-to filter, opened with /, matching slugs and workspace aliases
+	addToAll       []string        // All workspaces; addToWorkspaces holds the matches
+	addToFilter    textinput.Model // Filter text input
+	addToFiltering bool            // True while the filter takes keys
+
 	// Template selection state
 	templateInfos        []template.TemplateInfo // Available templates
 	templateSelected     int                     // Currently selected template index
 	templateScrollOffset int                     // Scroll offset for template list
 	selectedTemplate     string                  // Selected template name (empty = no template)
+	templateForBatch     bool                    // Template is chosen for every workspace in a batch import
+
+	// Files and repos of each template, loaded when it is first selected
+	templatePreviews map[string]*templatePreview
 
 	// Template variable prompting state
 	templateVars         []template.TemplateVar // Variables to prompt for
@@ -721,14 +1261,44 @@ type ImportBrowserModel struct {
 	// Size cache for directories
 	sizeCache   map[string]int64    // path -> size in bytes
 	sizePending map[string]struct{} // paths with in-flight size calculations
+	sizeStream  *sizeStream         // streamed calculation for the selected directory
+
+	// Last-modified cache for directories (newest file mtime beneath the path)
+	mtimeCache   map[string]time.Time // path -> newest mtime
+	mtimePending map[string]struct{}  // paths with in-flight mtime scans
+
+	// Git info for repos in the tree, collected in the background
+	gitInfoCache   map[string]*git.RepoInfo // repo path -> info
+	gitInfoPending map[string]struct{}      // repo paths with in-flight collection
+	gitInfoTree    *sourceTreeScroller      // scroller whose tree git info was last requested for
+	gitInfoGen     int                      // generation of that tree
 
 	// Display options
-	showHidden bool // Show hidden files (dotfiles)
+	showHidden bool         // Show hidden files (dotfiles)
+	alwaysShow []string     // Dotfiles shown even when hidden files are not
+	columnView bool         // Show size, modified, and repo-count columns in the tree
+	sortMode   treeSortMode // Sibling ordering in the tree
 
 	// Filter state
 	filterActive bool            // True when filter mode is active
 	filterInput  textinput.Model // Filter text input
 	filterText   string          // Current filter text (cached from input)
+	staleOnly    bool            // Only show entries untouched for longer than staleThreshold
+
+	// Visual mode (range selection in the tree)
+	visual visualRange
+
+	// File preview state (shares the template explorer's file viewer loading)
+	previewViewport viewport.Model // Scrollable file content
+	previewFile     fileContentMsg // Loaded file (content, size, binary/large flags)
+	previewLoading  bool           // True while the file is being read
+
+	// File operation state (new folder, rename, move)
+	fileOp        fileOpKind      // Which operation is being prompted for
+	fileOpTarget  *sourceNode     // Parent for new folder, entry for rename
+	fileOpSources []*sourceNode   // Entries being moved
+	fileOpInput   textinput.Model // Name or destination input
+	fileOpError   string          // Validation or filesystem error
 
 	// Dry-run mode
 	dryRun bool // If true, show what would happen without making changes
@@ -738,31 +1308,58 @@ type ImportBrowserModel struct {
 	batchImportResults []BatchImportItemResult // Results of each batch import
 	batchImportCurrent int                     // Index of currently importing folder
 	batchOwner         string                  // Owner for all batch imports
+	batchProjects      []string                // Project name of each folder, editable before import
+	batchFocus         int                     // 0 = owner, i+1 = project name of folder i
+
+	// Batch add-to state
+	batchAddToTargets []*sourceNode          // Folders selected for batch add-to
+	batchAddToResults []BatchAddToItemResult // Results of each batch add
+	batchAddToCurrent int                    // Index of currently adding folder
 
 	// Batch stash state
 	batchStashTargets     []*sourceNode          // Folders selected for batch stash
 	batchStashResults     []BatchStashItemResult // Results of each batch stash
 	batchStashCurrent     int                    // Index of currently stashing folder
 	batchStashDeleteAfter bool                   // Whether to delete folders after stashing
+	batchStashRisks       []deleteRisk           // Repos whose work is lost if sources are deleted
+
+	// Stop a running batch import or stash after the current folder
+	batchStopping bool
+
+	// Batch delete/trash state (deleteIsTrash selects trash vs permanent delete)
+	batchDeleteTargets []*sourceNode           // Items selected for batch delete/trash
+	batchDeleteRisks   []deleteRisk            // Repos with uncommitted or unpushed work
+	batchDeleteResults []BatchDeleteItemResult // Results of each delete/trash
+	batchDeleteCurrent int                     // Index of currently deleting item
 
 	result ImportBrowserResult
 }
 
 // NewImportBrowser creates a new import browser model.
 func NewImportBrowser(cfg *config.Config, rootPath string) (*ImportBrowserModel, error) {
-	// Build the source tree (default: hidden files not shown)
-	showHidden := false
-	root, err := buildSourceTree(rootPath, showHidden)
+	// The browser deletes and moves what it shows, so it must never operate on
+	// workspaces; those are archived, not deleted.
+	if cfg.InCodeRoot(rootPath) {
+		return nil, fmt.Errorf("cannot browse %s: it is inside the code root %s; use co archive for workspaces", rootPath, cfg.CodeRoot)
+	}
+
+	// Build the source tree (hidden files per the configured policy)
+	hidden := HiddenPolicyFor(cfg)
+	scanOpts := workspace.GitScanOptions(cfg)
+	root, scan, err := scanSourceTree(rootPath, scanOpts, hidden)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build source tree: %w", err)
 	}
 
 	// Build git root set for expand operations
-	gitRoots, _ := git.FindGitRoots(rootPath)
 	gitRootSet := make(map[string]bool)
-	for _, r := range gitRoots {
+	for _, r := range scan.Roots {
 		gitRootSet[r] = true
 	}
+	scanTruncated := make(map[string]bool)
+	for _, dir := range scan.Truncated {
+		scanTruncated[dir] = true
+	}
 
 	// Flatten tree and create scroller
 	flatTree := flattenSourceTree(root)
@@ -773,6 +1370,7 @@ func NewImportBrowser(cfg *config.Config, rootPath string) (*ImportBrowserModel,
 	ownerInput.Placeholder = "owner"
 	ownerInput.CharLimit = 64
 	ownerInput.Width = 30
+	setOwnerCompletion(&ownerInput, cfg)
 
 	projectInput := textinput.New()
 	projectInput.Placeholder = "project"
@@ -791,6 +1389,18 @@ func NewImportBrowser(cfg *config.Config, rootPath string) (*ImportBrowserModel,
 	extraFilesDestInput.CharLimit = 128
 	extraFilesDestInput.Width = 50
 
+	// Initialize text input for split repo names
+	splitNameInput := textinput.New()
+	splitNameInput.Placeholder = "repo name"
+	splitNameInput.CharLimit = 64
+	splitNameInput.Width = 30
+
+	// Initialize text input for repo names in the import preview
+	repoNameInput := textinput.New()
+	repoNameInput.Placeholder = "repo name"
+	repoNameInput.CharLimit = 64
+	repoNameInput.Width = 30
+
 	// Initialize text input for filter
 	filterInput := textinput.New()
 	filterInput.Placeholder = "filter..."
@@ -803,11 +1413,14 @@ func NewImportBrowser(cfg *config.Config, rootPath string) (*ImportBrowserModel,
 	templateVarInput.CharLimit = 256
 	templateVarInput.Width = 40
 
-	return &ImportBrowserModel{
+	m := &ImportBrowserModel{
 		cfg:                 cfg,
 		rootPath:            rootPath,
 		root:                root,
 		gitRootSet:          gitRootSet,
+		gitIndex:            newGitRootIndex(gitRootSet),
+		scanOpts:            scanOpts,
+		scanTruncated:       scanTruncated,
 		scroller:            scroller,
 		state:               StateBrowse,
 		activePane:          IBPaneTree,
@@ -815,22 +1428,58 @@ func NewImportBrowser(cfg *config.Config, rootPath string) (*ImportBrowserModel,
 		projectInput:        projectInput,
 		stashNameInput:      stashNameInput,
 		extraFilesDestInput: extraFilesDestInput,
+		splitNameInput:      splitNameInput,
+		repoNameInput:       repoNameInput,
 		filterInput:         filterInput,
 		templateVarInput:    templateVarInput,
 		templateVarValues:   make(map[string]string),
 		sizeCache:           make(map[string]int64),
 		sizePending:         make(map[string]struct{}),
-	}, nil
+		mtimeCache:          make(map[string]time.Time),
+		mtimePending:        make(map[string]struct{}),
+		gitInfoCache:        make(map[string]*git.RepoInfo),
+		gitInfoPending:      make(map[string]struct{}),
+		sortMode:            parseTreeSortMode(cfg.GetImportBrowserConfig().Sort),
+		showHidden:          hidden.ShowAll,
+		alwaysShow:          hidden.AlwaysShow,
+	}
+	m.addToFilter = textinput.New()
+	m.addToFilter.Placeholder = "slug or alias"
+	m.addToFilter.CharLimit = 64
+	m.addToFilter.Width = 30
+	m.refreshTree()
+	return m, nil
 }
 
 // Init implements tea.Model.
 func (m ImportBrowserModel) Init() tea.Cmd {
-	// Start async size calculation for initially selected item
-	return m.triggerSelectedSizeCalc()
+	// Start async size and mtime scans for initially selected item
+	return tea.Batch(m.triggerSelectedScans(), m.triggerGitInfo())
 }
 
-// Update implements tea.Model.
+// Update implements tea.Model. When an update changes the tree, git info is
+// requested for repos that became visible in it (expanded, refreshed,
+// filtered in, or paged in).
 func (m ImportBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	next, ok := model.(ImportBrowserModel)
+	if !ok {
+		return model, cmd
+	}
+	if next.state == StateBrowse {
+		next.loadVisiblePages()
+	}
+	if next.scroller == nil || (next.scroller == next.gitInfoTree && next.scroller.generation == next.gitInfoGen) {
+		return next, cmd
+	}
+	next.gitInfoTree, next.gitInfoGen = next.scroller, next.scroller.generation
+	if gitCmd := next.triggerGitInfo(); gitCmd != nil {
+		return next, tea.Batch(cmd, gitCmd)
+	}
+	return next, cmd
+}
+
+func (m ImportBrowserModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -841,13 +1490,91 @@ func (m ImportBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			visibleHeight = 5
 		}
 		m.scroller.setHeight(visibleHeight)
+		if m.state == StateFilePreview {
+			m.resizePreview()
+		}
+		return m, nil
+
+	case batchImportItemMsg:
+		return m.handleBatchImportItem(msg)
+
+	case batchStashItemMsg:
+		return m.handleBatchStashItem(msg)
+
+	case tmuxExitMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("tmux failed: %v", msg.err)
+			m.messageIsError = true
+		}
+		return m, nil
+
+	case shellExitMsg:
+		// Returned from a spawned shell; the tree may have changed
+		m.refresh()
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Shell exited: %v", msg.err)
+			m.messageIsError = true
+		}
+		return m, m.triggerTreeScans()
+
+	case fileContentMsg:
+		// Async file preview load completed
+		if m.state == StateFilePreview && msg.path == m.previewFile.path {
+			m.previewFile = msg
+			m.previewLoading = false
+			m.previewViewport.SetContent(formatPreviewContent(msg))
+			m.previewViewport.GotoTop()
+		}
 		return m, nil
 
+	case sizeProgressMsg:
+		// Running total of the streamed calculation; stale streams are dropped
+		if m.sizeStream == nil || m.sizeStream.events != msg.events {
+			return m, nil
+		}
+		m.sizeStream.total = msg.Size
+		return m, waitForSizeEvent(msg.events)
+
 	case sizeResultMsg:
-		// Async size calculation completed
+		// Async size calculation completed. A cancelled stream already gave
+		// up its pending mark, which a newer calculation may now hold.
+		if errors.Is(msg.Err, context.Canceled) {
+			return m, nil
+		}
 		delete(m.sizePending, msg.Path)
+		if m.sizeStream != nil && m.sizeStream.path == msg.Path {
+			m.sizeStream = nil
+		}
 		if msg.Err == nil {
 			m.sizeCache[msg.Path] = msg.Size
+			if m.sortMode == treeSortSize {
+				m.resortTree()
+			}
+		}
+		return m, nil
+
+	case gitInfoResultMsg:
+		// Async git info collection completed. Failures are cached as nil so
+		// they are not retried until the next refresh.
+		delete(m.gitInfoPending, msg.Path)
+		if m.gitInfoCache == nil {
+			m.gitInfoCache = make(map[string]*git.RepoInfo)
+		}
+		m.gitInfoCache[msg.Path] = msg.Info
+		m.applyGitInfo()
+		return m, nil
+
+	case mtimeResultMsg:
+		// Async last-modified scan completed
+		delete(m.mtimePending, msg.Path)
+		if msg.Err == nil {
+			if m.mtimeCache == nil {
+				m.mtimeCache = make(map[string]time.Time)
+			}
+			m.mtimeCache[msg.Path] = msg.ModTime
+			if m.sortMode == treeSortMtime || m.staleOnly {
+				m.resortTree()
+			}
 		}
 		return m, nil
 
@@ -855,6 +1582,10 @@ func (m ImportBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Async operation completed
 		m.loading = false
 		m.loadingMessage = ""
+		if m.loadingCancel != nil {
+			m.loadingCancel()
+			m.loadingCancel = nil
+		}
 		m.message = msg.Message
 		m.messageIsError = !msg.Success
 		if msg.Success {
@@ -875,8 +1606,12 @@ func (m ImportBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		// Ignore key presses while loading
+		// Ignore key presses while loading, except esc to cancel
 		if m.loading {
+			if msg.String() == "esc" && m.loadingCancel != nil {
+				m.loadingCancel()
+				m.loadingMessage = "Cancelling..."
+			}
 			return m, nil
 		}
 		return m.handleKeyPress(msg)
@@ -892,10 +1627,14 @@ func (m ImportBrowserModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		return m.handleBrowseKeys(msg)
 	case StateImportConfig:
 		return m.handleImportConfigKeys(msg)
+	case StateWorkspaceConflict:
+		return m.handleWorkspaceConflictKeys(msg)
 	case StateTemplateSelect:
 		return m.handleTemplateSelectKeys(msg)
 	case StateTemplateVars:
 		return m.handleTemplateVarsKeys(msg)
+	case StateSplitSelect:
+		return m.handleSplitSelectKeys(msg)
 	case StateImportPreview:
 		return m.handleImportPreviewKeys(msg)
 	case StateStashConfirm:
@@ -904,18 +1643,34 @@ func (m ImportBrowserModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 		return m.handleExtraFilesKeys(msg)
 	case StatePostImport:
 		return m.handlePostImportKeys(msg)
+	case StateOpenWorkspace:
+		return m.handleOpenWorkspaceKeys(msg)
 	case StateAddToSelect:
 		return m.handleAddToSelectKeys(msg)
 	case StateBatchImportConfirm:
 		return m.handleBatchImportConfirmKeys(msg)
+	case StateBatchImportExecute, StateBatchStashExecute:
+		return m.handleBatchExecuteKeys(msg)
 	case StateBatchImportSummary:
 		return m.handleBatchImportSummaryKeys(msg)
 	case StateBatchStashConfirm:
 		return m.handleBatchStashConfirmKeys(msg)
 	case StateBatchStashSummary:
 		return m.handleBatchStashSummaryKeys(msg)
+	case StateBatchAddToConfirm:
+		return m.handleBatchAddToConfirmKeys(msg)
+	case StateBatchAddToSummary:
+		return m.handleBatchAddToSummaryKeys(msg)
+	case StateBatchDeleteConfirm:
+		return m.handleBatchDeleteConfirmKeys(msg)
+	case StateBatchDeleteSummary:
+		return m.handleBatchDeleteSummaryKeys(msg)
 	case StateDeleteConfirm, StateTrashConfirm:
 		return m.handleDeleteConfirmKeys(msg)
+	case StateFilePreview:
+		return m.handleFilePreviewKeys(msg)
+	case StateFileOp:
+		return m.handleFileOpKeys(msg)
 	default:
 		// Other states will be handled in future tasks
 		return m, nil
@@ -924,15 +1679,22 @@ func (m ImportBrowserModel) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd)
 
 // handleImportPreviewKeys handles keyboard input in import preview state.
 func (m ImportBrowserModel) handleImportPreviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.repoRenaming {
+		return m.handleRepoRenameKeys(msg)
+	}
+
 	switch msg.String() {
 	case "ctrl+c":
 		m.result.Aborted = true
 		return m, tea.Quit
 
 	case "esc":
+		m.repoError = ""
 		// Go back - to extra files if there were any, otherwise to config/workspace select
 		if len(m.extraFilesItems) > 0 {
 			m.state = StateExtraFiles
+		} else if len(m.splitItems) > 0 && m.addToTargetSlug == "" {
+			m.state = StateSplitSelect
 		} else if m.addToTargetSlug != "" {
 			// Add-to mode: go back to workspace selection
 			m.state = StateAddToSelect
@@ -944,6 +1706,12 @@ func (m ImportBrowserModel) handleImportPreviewKeys(msg tea.KeyMsg) (tea.Model,
 
 	case "enter":
 		// Execute import or add-to (or dry-run)
+		if i, err := m.validateRepoNames(); err != nil {
+			m.repoSelected = i
+			m.repoError = err.Error()
+			return m, nil
+		}
+		m.repoError = ""
 		if m.dryRun {
 			return m.executeDryRun()
 		}
@@ -956,53 +1724,263 @@ func (m ImportBrowserModel) handleImportPreviewKeys(msg tea.KeyMsg) (tea.Model,
 		// Toggle dry-run mode
 		m.dryRun = !m.dryRun
 		return m, nil
-	}
-	return m, nil
-}
 
-// executeImport performs the actual import operation using the workspace package.
-func (m ImportBrowserModel) executeImport() (tea.Model, tea.Cmd) {
-	if m.importTarget == nil {
-		m.message = "No folder selected for import"
-		m.messageIsError = true
+	case "x":
+		// Toggle skipping extra files the repos already hold
+		if len(m.selectedDuplicates()) > 0 {
+			m.skipDuplicates = !m.skipDuplicates
+		}
 		return m, nil
-	}
 
-	m.state = StateImportExecute
+	case "M":
+		// Toggle merging the repos into one
+		if m.canMerge() {
+			m.mergeRepos = !m.mergeRepos
+		}
+		return m, nil
 
-	// Get git roots under the import target
-	var gitRoots []string
-	if m.importTarget.IsGitRepo {
-		gitRoots = []string{m.importTarget.Path}
-	} else {
-		prefix := m.importTarget.Path + string(filepath.Separator)
-		for gitRoot := range m.gitRootSet {
-			if strings.HasPrefix(gitRoot, prefix) {
-				gitRoots = append(gitRoots, gitRoot)
-			}
+	case "j", "down":
+		if m.repoSelected < len(m.importRoots())-1 {
+			m.repoSelected++
 		}
-	}
+		return m, nil
+
+	case "k", "up":
+		if m.repoSelected > 0 {
+			m.repoSelected--
+		}
+		return m, nil
+
+	case "r":
+		// Rename the selected repo
+		roots := m.importRoots()
+		if m.repoSelected >= len(roots) {
+			return m, nil
+		}
+		m.repoRenaming = true
+		m.repoNameInput.SetValue(m.importRepoName(roots[m.repoSelected]))
+		m.repoNameInput.CursorEnd()
+		return m, m.repoNameInput.Focus()
+	}
+	return m, nil
+}
+
+// handleRepoRenameKeys handles keyboard input while renaming a repo in the
+// import preview. An empty name goes back to the derived one.
+func (m ImportBrowserModel) handleRepoRenameKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc":
+		m.repoRenaming = false
+		m.repoNameInput.Blur()
+		return m, nil
+
+	case "enter":
+		root := m.importRoots()[m.repoSelected]
+		name := strings.TrimSpace(m.repoNameInput.Value())
+		if name == "" {
+			name = workspace.DeriveRepoName(root, m.importTarget.Path)
+		}
+		if err := validateEntryName(name); err != nil {
+			m.repoError = err.Error()
+			return m, nil
+		}
+		if m.repoNames == nil {
+			m.repoNames = make(map[string]string)
+		}
+		if name == workspace.DeriveRepoName(root, m.importTarget.Path) {
+			delete(m.repoNames, root)
+		} else {
+			m.repoNames[root] = name
+		}
+		m.repoRenaming = false
+		m.repoError = ""
+		m.repoNameInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.repoNameInput, cmd = m.repoNameInput.Update(msg)
+	return m, cmd
+}
+
+// importRepoName returns the name the repo at root gets in the workspace:
+// the one chosen in the preview, or the one derived from its path.
+func (m ImportBrowserModel) importRepoName(root string) string {
+	if name := m.repoNames[root]; name != "" {
+		return name
+	}
+	return workspace.DeriveRepoName(root, m.importTarget.Path)
+}
+
+// duplicateRepoNames returns the names more than one imported repo would
+// get, counting split repos and, when merging, only the merged repos.
+func (m ImportBrowserModel) duplicateRepoNames() map[string]bool {
+	count := make(map[string]int)
+	for _, root := range m.importRoots() {
+		count[m.importRepoName(root)]++
+	}
+	if m.mergeInto() == "" {
+		for _, split := range m.selectedSplits() {
+			count[split.Name]++
+		}
+	}
+	dups := make(map[string]bool)
+	for name, n := range count {
+		if n > 1 {
+			dups[name] = true
+		}
+	}
+	return dups
+}
+
+// existingRepoNames returns the names of the repos already in the workspace
+// the preview adds to; they are skipped. It is empty for new workspaces.
+func (m ImportBrowserModel) existingRepoNames() map[string]bool {
+	names := make(map[string]bool)
+	if m.addToTargetSlug == "" {
+		return names
+	}
+	proj, err := model.LoadProject(filepath.Join(m.cfg.WorkspacePath(m.addToTargetSlug), "project.json"))
+	if err != nil {
+		return names
+	}
+	for _, r := range proj.Repos {
+		names[r.Name] = true
+	}
+	return names
+}
+
+// selectedDuplicates returns the selected extra files identical to files in
+// the imported repos.
+func (m ImportBrowserModel) selectedDuplicates() []workspace.ExtraDuplicate {
+	selected := make(map[string]bool, len(m.extraFilesResult.SelectedPaths))
+	for _, path := range m.extraFilesResult.SelectedPaths {
+		selected[path] = true
+	}
+	var dups []workspace.ExtraDuplicate
+	for _, dup := range m.extraDuplicates {
+		if selected[dup.Path] {
+			dups = append(dups, dup)
+		}
+	}
+	return dups
+}
+
+// importExtraFiles returns the extra files to import: the selected ones,
+// without those identical to repo files when skipping them.
+func (m ImportBrowserModel) importExtraFiles() []string {
+	if !m.skipDuplicates {
+		return m.extraFilesResult.SelectedPaths
+	}
+	skip := make(map[string]bool)
+	for _, dup := range m.selectedDuplicates() {
+		skip[dup.Path] = true
+	}
+	var paths []string
+	for _, path := range m.extraFilesResult.SelectedPaths {
+		if !skip[path] {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// importRepoDir returns the path, relative to the workspace, of the
+// imported repo named name: under the layout of the workspace added to, or
+// the configured one of the new workspace's owner.
+func (m ImportBrowserModel) importRepoDir(name string) string {
+	if m.addToTargetSlug != "" {
+		proj, err := model.LoadProject(filepath.Join(m.cfg.WorkspacePath(m.addToTargetSlug), "project.json"))
+		if err != nil {
+			return filepath.ToSlash(model.RepoDir("", name))
+		}
+		return filepath.ToSlash(proj.RepoDir(name))
+	}
+	owner := ""
+	if parsed, ok := workspace.SchemeFor(m.cfg).Parse(m.result.WorkspaceSlug); ok {
+		owner = parsed.Owner
+	}
+	return filepath.ToSlash(model.RepoDir(m.cfg.GetRepoLayout(owner), name))
+}
+
+// validateRepoNames checks that the imported repos get distinct names. On
+// failure it returns the index of the first repo to rename.
+func (m ImportBrowserModel) validateRepoNames() (int, error) {
+	if m.importTarget == nil {
+		return 0, nil
+	}
+	dups := m.duplicateRepoNames()
+	for i, root := range m.importRoots() {
+		if name := m.importRepoName(root); dups[name] {
+			return i, fmt.Errorf("two repos would be named %s; press r to rename one", name)
+		}
+	}
+	return 0, nil
+}
+
+// canMerge reports whether the import preview can merge the repos being
+// imported into one: a new workspace with more than one repo.
+func (m ImportBrowserModel) canMerge() bool {
+	return m.importTarget != nil && m.addToTargetSlug == "" && len(m.importRoots()) > 1
+}
+
+// mergeInto returns the repo the import merges its repos into, or "".
+func (m ImportBrowserModel) mergeInto() string {
+	if !m.mergeRepos || !m.canMerge() {
+		return ""
+	}
+	if parsed, ok := workspace.SchemeFor(m.cfg).Parse(m.result.WorkspaceSlug); ok {
+		return parsed.Project
+	}
+	return ""
+}
+
+// executeImport performs the actual import operation using the workspace package.
+func (m ImportBrowserModel) executeImport() (tea.Model, tea.Cmd) {
+	if m.importTarget == nil {
+		m.message = "No folder selected for import"
+		m.messageIsError = true
+		return m, nil
+	}
+
+	m.state = StateImportExecute
+
+	// Get git roots under the import target
+	gitRoots := m.importRoots()
 
 	// Parse owner and project from slug
-	parts := strings.SplitN(m.result.WorkspaceSlug, "--", 2)
-	if len(parts) != 2 {
+	parsed, ok := workspace.SchemeFor(m.cfg).Parse(m.result.WorkspaceSlug)
+	if !ok {
 		m.message = "Invalid workspace slug"
 		m.messageIsError = true
 		m.state = StateImportConfig
 		return m, m.ownerInput.Focus()
 	}
-	owner, project := parts[0], parts[1]
+	owner, project := parsed.Owner, parsed.Project
 
 	// Build import options with progress callbacks
 	var progressMessages []string
 	opts := workspace.ImportOptions{
 		Owner:          owner,
 		Project:        project,
-		ExtraFiles:     m.extraFilesResult.SelectedPaths,
+		ExtraFiles:     m.importExtraFiles(),
 		ExtraFilesDest: m.extraFilesResult.DestSubfolder,
+		Splits:         m.selectedSplits(),
+		MergeInto:      m.mergeInto(),
+		RepoNames:      m.repoNames,
 		OnRepoMove: func(repoName, srcPath, dstPath string) {
 			progressMessages = append(progressMessages, fmt.Sprintf("Moving repo: %s", repoName))
 		},
+		OnRepoSplit: func(repoName, srcPath, dir string) {
+			progressMessages = append(progressMessages, fmt.Sprintf("Splitting %s into repo: %s", dir, repoName))
+		},
+		OnRepoMerge: func(repoName, srcPath, into string) {
+			progressMessages = append(progressMessages, fmt.Sprintf("Merging %s into repo: %s", repoName, into))
+		},
 		OnFileCopy: func(relPath, dstPath string) {
 			progressMessages = append(progressMessages, fmt.Sprintf("Copying: %s", relPath))
 		},
@@ -1014,19 +1992,24 @@ func (m ImportBrowserModel) executeImport() (tea.Model, tea.Cmd) {
 	// Execute the import
 	result, err := workspace.CreateWorkspace(m.cfg, m.importTarget.Path, gitRoots, opts)
 	if err != nil {
-		m.message = fmt.Sprintf("Import failed: %v", err)
+		m.message = "Import failed: " + errfmt.Message(err)
 		m.messageIsError = true
 		m.state = StateImportPreview
 		return m, nil
 	}
 
 	// Store results
+	m.rememberOwner(strings.ToLower(strings.TrimSpace(m.ownerInput.Value())))
 	m.result.Action = "import"
 	m.result.Success = true
 	m.result.WorkspacePath = result.WorkspacePath
 	m.result.WorkspaceSlug = result.WorkspaceSlug
-	m.result.ReposImported = result.ReposImported
+	m.result.ReposImported = append(result.ReposSplit, result.ReposImported...)
 	m.result.FilesImported = result.FilesCopied
+	if len(result.Errors) > 0 {
+		m.message = fmt.Sprintf("Workspace created with errors: %s", strings.Join(result.Errors, "; "))
+		m.messageIsError = true
+	}
 
 	// Apply template if one was selected
 	if m.selectedTemplate != "" {
@@ -1046,6 +2029,10 @@ func (m ImportBrowserModel) executeImport() (tea.Model, tea.Cmd) {
 			m.result.TemplateFilesCreated = templateResult.FilesCreated
 		}
 	}
+	if _, err := template.WriteShellEnv(m.cfg, result.WorkspacePath); err != nil {
+		m.message = fmt.Sprintf("Workspace created but shell env failed: %v", err)
+		m.messageIsError = true
+	}
 
 	// Check if source is now empty - if so, just clean up and go to browse
 	if result.SourceEmpty {
@@ -1057,14 +2044,13 @@ func (m ImportBrowserModel) executeImport() (tea.Model, tea.Cmd) {
 			m.message = fmt.Sprintf("Created workspace: %s", result.WorkspaceSlug)
 		}
 		m.messageIsError = false
-		m.state = StateBrowse
 		m.importTarget = nil
-		return m, nil
+		return m.startOpenWorkspace([]string{result.WorkspaceSlug})
 	}
 
 	// Source still has content - offer post-import options
 	m.postImportSourcePath = m.importTarget.Path
-	m.postImportOption = 0 // Default to "keep"
+	m.postImportOption = m.defaultPostImportOption()
 	m.state = StatePostImport
 
 	return m, nil
@@ -1079,50 +2065,40 @@ func (m ImportBrowserModel) executeDryRun() (tea.Model, tea.Cmd) {
 	}
 
 	// Get git roots under the import target
-	var gitRoots []string
-	if m.importTarget.IsGitRepo {
-		gitRoots = []string{m.importTarget.Path}
-	} else {
-		prefix := m.importTarget.Path + string(filepath.Separator)
-		for gitRoot := range m.gitRootSet {
-			if strings.HasPrefix(gitRoot, prefix) {
-				gitRoots = append(gitRoots, gitRoot)
-			}
-		}
-	}
-
-	// Build summary of what would happen
-	var sb strings.Builder
-	sb.WriteString("DRY-RUN: No changes will be made.\n\n")
+	gitRoots := m.importRoots()
 
+	// Build the plan of what would happen
+	var plan *model.Plan
 	if m.addToTargetSlug != "" {
-		sb.WriteString(fmt.Sprintf("Would add to existing workspace: %s\n", m.addToTargetSlug))
+		plan = model.NewPlan(fmt.Sprintf("Would add to existing workspace: %s", m.addToTargetSlug))
 	} else {
-		sb.WriteString(fmt.Sprintf("Would create new workspace: %s\n", m.result.WorkspaceSlug))
+		plan = model.NewPlan(fmt.Sprintf("Would create new workspace: %s", m.result.WorkspaceSlug))
+		plan.Add(model.ActionCreate, m.result.WorkspaceSlug, "", "")
 	}
 
-	sb.WriteString(fmt.Sprintf("Source: %s\n\n", m.importTarget.Path))
-
-	if len(gitRoots) > 0 {
-		sb.WriteString(fmt.Sprintf("Repositories to move (%d):\n", len(gitRoots)))
-		for _, root := range gitRoots {
-			repoName := workspace.DeriveRepoName(root, m.importTarget.Path)
-			sb.WriteString(fmt.Sprintf("  - %s -> repos/%s\n", filepath.Base(root), repoName))
+	for _, split := range m.selectedSplits() {
+		plan.Add(model.ActionCreate, filepath.Join(split.Repo, split.Dir), m.importRepoDir(split.Name), "split with history")
+	}
+	into := m.mergeInto()
+	for _, root := range gitRoots {
+		repoName := m.importRepoName(root)
+		if into != "" {
+			plan.Add(model.ActionCreate, root, m.importRepoDir(into)+"/"+repoName, "merge with history")
+		} else {
+			plan.Add(model.ActionMove, root, m.importRepoDir(repoName), "")
 		}
 	}
 
-	if len(m.extraFilesResult.SelectedPaths) > 0 {
-		sb.WriteString(fmt.Sprintf("\nExtra files to copy (%d):\n", len(m.extraFilesResult.SelectedPaths)))
-		dest := m.extraFilesResult.DestSubfolder
+	dest := m.extraFilesResult.DestSubfolder
+	for _, path := range m.importExtraFiles() {
 		if dest == "" {
-			dest = "(project root)"
-		}
-		for _, path := range m.extraFilesResult.SelectedPaths {
-			sb.WriteString(fmt.Sprintf("  - %s -> %s/%s\n", path, dest, path))
+			plan.Add(model.ActionCopy, path, path, "project root")
+		} else {
+			plan.Add(model.ActionCopy, path, dest+"/"+path, "")
 		}
 	}
 
-	m.message = sb.String()
+	m.message = plan.String()
 	m.messageIsError = false
 	m.dryRun = false // Reset dry-run after showing results
 
@@ -1146,22 +2122,13 @@ func (m ImportBrowserModel) executeAddToWorkspace() (tea.Model, tea.Cmd) {
 	m.state = StateImportExecute
 
 	// Get git roots under the import target
-	var gitRoots []string
-	if m.importTarget.IsGitRepo {
-		gitRoots = []string{m.importTarget.Path}
-	} else {
-		prefix := m.importTarget.Path + string(filepath.Separator)
-		for gitRoot := range m.gitRootSet {
-			if strings.HasPrefix(gitRoot, prefix) {
-				gitRoots = append(gitRoots, gitRoot)
-			}
-		}
-	}
+	gitRoots := m.gitRootsFor(m.importTarget)
 
 	// Build import options with progress callbacks
 	opts := workspace.ImportOptions{
-		ExtraFiles:     m.extraFilesResult.SelectedPaths,
+		ExtraFiles:     m.importExtraFiles(),
 		ExtraFilesDest: m.extraFilesResult.DestSubfolder,
+		RepoNames:      m.repoNames,
 		OnRepoMove: func(repoName, srcPath, dstPath string) {
 			// Progress: moving repo
 		},
@@ -1179,7 +2146,7 @@ func (m ImportBrowserModel) executeAddToWorkspace() (tea.Model, tea.Cmd) {
 	// Execute the add-to operation
 	result, err := workspace.AddToWorkspace(m.cfg, m.importTarget.Path, gitRoots, m.addToTargetSlug, opts)
 	if err != nil {
-		m.message = fmt.Sprintf("Add to workspace failed: %v", err)
+		m.message = "Add to workspace failed: " + errfmt.Message(err)
 		m.messageIsError = true
 		m.state = StateImportPreview
 		return m, nil
@@ -1209,7 +2176,7 @@ func (m ImportBrowserModel) executeAddToWorkspace() (tea.Model, tea.Cmd) {
 
 	// Source still has content - offer post-import options
 	m.postImportSourcePath = m.importTarget.Path
-	m.postImportOption = 0 // Default to "keep"
+	m.postImportOption = m.defaultPostImportOption()
 	m.state = StatePostImport
 
 	return m, nil
@@ -1222,6 +2189,90 @@ func (m *ImportBrowserModel) clearAddToState() {
 	m.addToTargetSlug = ""
 	m.addToSelected = 0
 	m.addToScrollOffset = 0
+	m.addToAll = nil
+	m.addToFiltering = false
+}
+
+// setAddToWorkspaces offers workspaces in the add-to selector, unfiltered.
+func (m *ImportBrowserModel) setAddToWorkspaces(workspaces []string) {
+	m.addToAll = workspaces
+	m.addToWorkspaces = workspaces
+	m.addToSelected = 0
+	m.addToScrollOffset = 0
+	m.addToTargetSlug = ""
+	m.addToFiltering = false
+	m.addToFilter.SetValue("")
+	m.addToFilter.Blur()
+}
+
+// applyAddToFilter narrows the add-to selector to the workspaces whose slug
+// contains the filter text or that have an alias starting with it. The
+// workspace the text is an alias of comes first.
+func (m *ImportBrowserModel) applyAddToFilter() {
+	m.addToSelected = 0
+	m.addToScrollOffset = 0
+	text := strings.ToLower(strings.TrimSpace(m.addToFilter.Value()))
+	if text == "" {
+		m.addToWorkspaces = m.addToAll
+		return
+	}
+	aliasOf := m.cfg.ResolveAlias(text)
+	var matches []string
+	for _, ws := range m.addToAll {
+		if ws == aliasOf && aliasOf != text {
+			matches = append([]string{ws}, matches...)
+			continue
+		}
+		if strings.Contains(strings.ToLower(ws), text) {
+			matches = append(matches, ws)
+			continue
+		}
+		for _, alias := range m.cfg.AliasesOf(ws) {
+			if strings.HasPrefix(strings.ToLower(alias), text) {
+				matches = append(matches, ws)
+				break
+			}
+		}
+	}
+	m.addToWorkspaces = matches
+}
+
+// handleAddToFilterKeys handles keyboard input while the add-to filter is
+// open. Enter selects the first match unless another was moved to.
+func (m ImportBrowserModel) handleAddToFilterKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc":
+		// Close the filter and show all workspaces again
+		m.addToFiltering = false
+		m.addToFilter.SetValue("")
+		m.addToFilter.Blur()
+		m.applyAddToFilter()
+		return m, nil
+
+	case "enter":
+		m.addToFiltering = false
+		m.addToFilter.Blur()
+		return m.handleAddToSelectKeys(msg)
+
+	case "down", "up":
+		m.addToFiltering = false
+		next, _ := m.handleAddToSelectKeys(msg)
+		m = next.(ImportBrowserModel)
+		m.addToFiltering = true
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	before := m.addToFilter.Value()
+	m.addToFilter, cmd = m.addToFilter.Update(msg)
+	if m.addToFilter.Value() != before {
+		m.applyAddToFilter()
+	}
+	return m, cmd
 }
 
 // handlePostImportKeys handles keyboard input in post-import options state.
@@ -1264,6 +2315,7 @@ func (m ImportBrowserModel) handlePostImportKeys(msg tea.KeyMsg) (tea.Model, tea
 
 // executePostImportAction executes the selected post-import action on the source folder.
 func (m ImportBrowserModel) executePostImportAction() (tea.Model, tea.Cmd) {
+	m.defaults.PostImport = postImportChoices[m.postImportOption]
 	switch m.postImportOption {
 	case 0: // Keep - do nothing
 		m.message = fmt.Sprintf("Created workspace: %s (source kept)", m.result.WorkspaceSlug)
@@ -1276,7 +2328,7 @@ func (m ImportBrowserModel) executePostImportAction() (tea.Model, tea.Cmd) {
 		}
 		result, err := archive.StashFolder(m.cfg, m.postImportSourcePath, opts)
 		if err != nil {
-			m.message = fmt.Sprintf("Stash failed: %v", err)
+			m.message = "Stash failed: " + errfmt.Message(err)
 			m.messageIsError = true
 			return m, nil
 		}
@@ -1286,26 +2338,189 @@ func (m ImportBrowserModel) executePostImportAction() (tea.Model, tea.Cmd) {
 		m.messageIsError = false
 
 	case 2: // Delete
-		if err := os.RemoveAll(m.postImportSourcePath); err != nil {
+		if err := m.checkRemovable(m.postImportSourcePath); err != nil {
+			m.message = err.Error()
+			m.messageIsError = true
+			return m, nil
+		}
+		entry, err := quarantine.Delete(m.cfg, m.postImportSourcePath)
+		if err != nil {
 			m.message = fmt.Sprintf("Delete failed: %v", err)
 			m.messageIsError = true
 			return m, nil
 		}
 		m.message = fmt.Sprintf("Created workspace: %s (source deleted)", m.result.WorkspaceSlug)
+		if entry != nil {
+			m.message = fmt.Sprintf("Created workspace: %s (source quarantined)", m.result.WorkspaceSlug)
+		}
 		m.messageIsError = false
 	}
 
-	// Refresh tree and return to browse
+	// Refresh tree and return to browse, or offer to open a new workspace
 	m.refresh()
 	m.state = StateBrowse
 	m.importTarget = nil
 	m.postImportSourcePath = ""
+	if m.result.Action == "import" {
+		return m.startOpenWorkspace([]string{m.result.WorkspaceSlug})
+	}
+
+	return m, nil
+}
+
+// openWorkspaceOption is a way to open a workspace just created.
+type openWorkspaceOption int
+
+const (
+	openBrowse openWorkspaceOption = iota // Return to the browser
+	openEditor                            // Open the workspace in the editor
+	openTmux                              // Start or attach a tmux session in the workspace
+	openChdir                             // Quit and change the shell's directory to the workspace
+)
+
+// openWorkspaceOptions returns the options offered after an import. A tmux
+// session is only offered when tmux is installed.
+func (m ImportBrowserModel) openWorkspaceOptions() []openWorkspaceOption {
+	options := []openWorkspaceOption{openBrowse, openEditor}
+	if _, err := exec.LookPath("tmux"); err == nil {
+		options = append(options, openTmux)
+	}
+	return append(options, openChdir)
+}
+
+// openWorkspaceLabel returns the text of the option in the open workspace
+// dialog.
+func (m ImportBrowserModel) openWorkspaceLabel(option openWorkspaceOption) string {
+	switch option {
+	case openEditor:
+		return "Open in editor"
+	case openTmux:
+		return "Start a tmux session"
+	case openChdir:
+		return "Quit and cd there (shell integration)"
+	}
+	return "Back to browser"
+}
+
+// startOpenWorkspace offers to open one of the workspaces just created.
+func (m ImportBrowserModel) startOpenWorkspace(slugs []string) (tea.Model, tea.Cmd) {
+	m.openSlugs = slugs
+	m.openIdx = 0
+	m.openOption = 0
+	m.state = StateOpenWorkspace
+	return m, nil
+}
+
+// handleOpenWorkspaceKeys handles keyboard input in the dialog offered after
+// an import.
+func (m ImportBrowserModel) handleOpenWorkspaceKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	options := m.openWorkspaceOptions()
+	switch key := msg.String(); key {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc", "q":
+		m.openSlugs = nil
+		m.state = StateBrowse
+		return m, nil
+
+	case "j", "down":
+		if m.openOption < len(options)-1 {
+			m.openOption++
+		}
+		return m, nil
+
+	case "k", "up":
+		if m.openOption > 0 {
+			m.openOption--
+		}
+		return m, nil
+
+	case "l", "right":
+		m.openIdx = (m.openIdx + 1) % len(m.openSlugs)
+		return m, nil
+
+	case "h", "left":
+		m.openIdx = (m.openIdx + len(m.openSlugs) - 1) % len(m.openSlugs)
+		return m, nil
+
+	case "1", "2", "3", "4":
+		if i := int(key[0] - '1'); i < len(options) {
+			m.openOption = i
+		}
+		return m, nil
+
+	case "enter":
+		slug := m.openSlugs[m.openIdx]
+		m.openSlugs = nil
+		m.state = StateBrowse
+		switch options[m.openOption] {
+		case openEditor:
+			m.openInEditor(slug)
+		case openTmux:
+			return m, tmuxSession(slug, m.cfg.WorkspacePath(slug))
+		case openChdir:
+			m.result.ChdirTo = m.cfg.WorkspacePath(slug)
+			return m, tea.Quit
+		}
+		return m, nil
+	}
 
 	return m, nil
 }
 
+// openInEditor opens the workspace in the configured editor, or the system
+// opener, and reports the outcome in the status message.
+func (m *ImportBrowserModel) openInEditor(slug string) {
+	path := m.cfg.WorkspacePath(slug)
+	cmd := platform.OpenCommand(path)
+	if m.cfg.Editor != "" {
+		cmd = exec.Command(m.cfg.Editor, path)
+	}
+	if err := cmd.Start(); err != nil {
+		m.message = fmt.Sprintf("Failed to open %s: %v", slug, err)
+		m.messageIsError = true
+		return
+	}
+	m.message = fmt.Sprintf("Opened %s", slug)
+	m.messageIsError = false
+}
+
+// tmuxExitMsg is sent when the tmux session started from the browser is
+// left or could not be started.
+type tmuxExitMsg struct {
+	err error
+}
+
+// tmuxSession attaches to a tmux session named after the workspace, creating
+// it in dir if needed. Inside tmux the client switches to the session
+// instead of nesting one.
+func tmuxSession(slug, dir string) tea.Cmd {
+	// tmux does not allow '.' or ':' in session names
+	name := strings.NewReplacer(".", "_", ":", "_").Replace(slug)
+	if os.Getenv("TMUX") == "" {
+		cmd := exec.Command("tmux", "new-session", "-A", "-s", name, "-c", dir)
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return tmuxExitMsg{err: err}
+		})
+	}
+	return func() tea.Msg {
+		if exec.Command("tmux", "has-session", "-t", "="+name).Run() != nil {
+			if err := exec.Command("tmux", "new-session", "-d", "-s", name, "-c", dir).Run(); err != nil {
+				return tmuxExitMsg{err: err}
+			}
+		}
+		return tmuxExitMsg{err: exec.Command("tmux", "switch-client", "-t", "="+name).Run()}
+	}
+}
+
 // handleAddToSelectKeys handles keyboard input in workspace selection state.
 func (m ImportBrowserModel) handleAddToSelectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.addToFiltering {
+		return m.handleAddToFilterKeys(msg)
+	}
+
 	switch msg.String() {
 	case "ctrl+c":
 		m.result.Aborted = true
@@ -1316,8 +2531,13 @@ func (m ImportBrowserModel) handleAddToSelectKeys(msg tea.KeyMsg) (tea.Model, te
 		m.state = StateBrowse
 		m.importTarget = nil
 		m.addToWorkspaces = nil
+		m.batchAddToTargets = nil
 		return m, nil
 
+	case "/":
+		m.addToFiltering = true
+		return m, m.addToFilter.Focus()
+
 	case "j", "down":
 		if m.addToSelected < len(m.addToWorkspaces)-1 {
 			m.addToSelected++
@@ -1348,8 +2568,15 @@ func (m ImportBrowserModel) handleAddToSelectKeys(msg tea.KeyMsg) (tea.Model, te
 		// Select workspace and proceed
 		if m.addToSelected < len(m.addToWorkspaces) {
 			m.addToTargetSlug = m.addToWorkspaces[m.addToSelected]
+
+			// Batch mode: confirm all folders against the chosen workspace
+			if len(m.batchAddToTargets) > 0 {
+				m.state = StateBatchAddToConfirm
+				return m, nil
+			}
+
 			m.result.WorkspaceSlug = m.addToTargetSlug
-			m.result.WorkspacePath = filepath.Join(m.cfg.CodeRoot, m.addToTargetSlug)
+			m.result.WorkspacePath = m.cfg.WorkspacePath(m.addToTargetSlug)
 
 			// Check for extra files before proceeding to preview
 			return m.checkForExtraFilesAddTo()
@@ -1383,20 +2610,10 @@ func (m ImportBrowserModel) checkForExtraFilesAddTo() (tea.Model, tea.Cmd) {
 	}
 
 	// Get git roots under the import target
-	var gitRoots []string
-	if m.importTarget.IsGitRepo {
-		gitRoots = []string{m.importTarget.Path}
-	} else {
-		prefix := m.importTarget.Path + string(filepath.Separator)
-		for gitRoot := range m.gitRootSet {
-			if strings.HasPrefix(gitRoot, prefix) {
-				gitRoots = append(gitRoots, gitRoot)
-			}
-		}
-	}
+	gitRoots := m.gitRootsFor(m.importTarget)
 
 	// Find non-git items
-	items, err := FindNonGitItems(m.importTarget.Path, gitRoots)
+	items, err := FindNonGitItems(m.importTarget.Path, gitRoots, m.hiddenPolicy(), m.cfg.GetSecretsConfig().Patterns)
 	if err != nil || len(items) == 0 {
 		// No extra files or error finding them, skip to preview
 		m.extraFilesResult = ExtraFilesResult{} // Clear previous results
@@ -1409,7 +2626,8 @@ func (m ImportBrowserModel) checkForExtraFilesAddTo() (tea.Model, tea.Cmd) {
 	m.extraFilesSelected = 0
 	m.extraFilesScrollOffset = 0
 	m.extraFilesShowDest = false
-	m.extraFilesDestInput.SetValue("")
+	_, defaults := m.stickyDefaults()
+	m.extraFilesDestInput.SetValue(defaults.ExtraFilesDest)
 	m.extraFilesResult = ExtraFilesResult{}
 	m.state = StateExtraFiles
 
@@ -1457,17 +2675,20 @@ func (m *ImportBrowserModel) applyFilter() {
 	// Rebuild flat tree from root
 	flatTree := flattenSourceTree(m.root)
 
-	if m.filterText == "" {
+	if m.filterText == "" && !m.staleOnly {
 		// No filter, show all
 		m.scroller.updateTree(flatTree)
 		return
 	}
 
-	// Filter nodes by name (case-insensitive)
+	// Filter nodes by name (case-insensitive) and staleness
 	filterLower := strings.ToLower(m.filterText)
 	var filtered []*sourceNode
 
 	for _, node := range flatTree {
+		if m.staleOnly && !m.isStale(node) {
+			continue
+		}
 		if strings.Contains(strings.ToLower(node.Name), filterLower) {
 			filtered = append(filtered, node)
 		}
@@ -1483,49 +2704,150 @@ func (m ImportBrowserModel) handleBrowseKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		return m.handleFilterKeys(msg)
 	}
 
+	if m.visual.active {
+		switch msg.String() {
+		case "esc":
+			// Cancel visual mode without selecting
+			m.visual.active = false
+			return m, nil
+		case "V", " ":
+			// Select the range and leave visual mode
+			m.applyVisualSelection()
+			return m, m.triggerSelectionSizes()
+		case "i", "s", "S", "a", "d", "t":
+			// Batch operations act on the range plus any existing selection
+			m.applyVisualSelection()
+		}
+	}
+
 	switch msg.String() {
 	case "q", "ctrl+c":
 		m.result.Aborted = true
 		return m, tea.Quit
 
-	case "/":
-		// Enter filter mode
-		m.filterActive = true
-		m.filterInput.SetValue("")
-		m.filterText = ""
+	case "V":
+		// Enter visual mode: selection extends from here as the cursor moves
+		m.visual.start(m.scroller.selected)
+		return m, nil
+
+	case "f":
+		// Reveal the selected path in the system file manager
+		node := m.scroller.selectedNode()
+		if node != nil {
+			if err := platform.Reveal(node.Path); err != nil {
+				m.message = fmt.Sprintf("Reveal failed: %v", err)
+				m.messageIsError = true
+			}
+		}
+		return m, nil
+
+	case "x":
+		// Open a shell in the selected directory (or the file's directory)
+		node := m.scroller.selectedNode()
+		if node == nil {
+			return m, nil
+		}
+		dir := node.Path
+		if !node.IsDir {
+			dir = filepath.Dir(node.Path)
+		}
+		return m, tea.ExecProcess(platform.ShellCommand(dir), func(err error) tea.Msg {
+			return shellExitMsg{err: err}
+		})
+
+	case "n":
+		// Create a new folder in the current directory
+		node := m.scroller.selectedNode()
+		if node == nil {
+			return m, nil
+		}
+		parent := node
+		if !node.IsDir || (node != m.root && !node.IsExpanded) {
+			parent = m.parentOf(node)
+		}
+		return m.startFileOp(fileOpMkdir, parent, nil)
+
+	case "F":
+		// Filter the entries of the current folder
+		node := m.scroller.selectedNode()
+		if node == nil {
+			return m, nil
+		}
+		dir := node
+		if node.moreOf != nil {
+			dir = node.moreOf
+		} else if !node.IsDir || (node != m.root && !node.IsExpanded && node.EntryFilter == "") {
+			dir = m.parentOf(node)
+		}
+		return m.startFileOp(fileOpFilter, dir, nil)
+
+	case "R":
+		// Rename the selected entry
+		node := m.scroller.selectedNode()
+		if node != nil && node != m.root {
+			return m.startFileOp(fileOpRename, node, nil)
+		}
+		return m, nil
+
+	case "m":
+		// Move selected entries (or the current one) into another directory
+		sources := m.scroller.getSelectedNodes()
+		if len(sources) == 0 {
+			if node := m.scroller.selectedNode(); node != nil && node != m.root {
+				sources = []*sourceNode{node}
+			}
+		}
+		if len(sources) > 0 {
+			return m.startFileOp(fileOpMove, nil, sources)
+		}
+		return m, nil
+
+	case "p":
+		// Quick-look preview of the selected file
+		node := m.scroller.selectedNode()
+		if node != nil && !node.IsDir {
+			return m.startFilePreview(node.Path)
+		}
+		return m, nil
+
+	case "/":
+		// Enter filter mode
+		m.filterActive = true
+		m.filterInput.SetValue("")
+		m.filterText = ""
 		return m, m.filterInput.Focus()
 
 	case "j", "down":
 		m.scroller.moveDown()
-		return m, m.triggerSelectedSizeCalc()
+		return m, m.triggerSelectedScans()
 
 	case "k", "up":
 		m.scroller.moveUp()
-		return m, m.triggerSelectedSizeCalc()
+		return m, m.triggerSelectedScans()
 
 	case "g":
 		m.scroller.moveToTop()
-		return m, m.triggerSelectedSizeCalc()
+		return m, m.triggerSelectedScans()
 
 	case "G":
 		m.scroller.moveToBottom()
-		return m, m.triggerSelectedSizeCalc()
+		return m, m.triggerSelectedScans()
 
 	case "l", "right":
 		node := m.scroller.selectedNode()
 		if node != nil && node.IsDir && !node.IsExpanded {
 			node.expandNode(m.gitRootSet, m.showHidden)
-			m.refreshTree()
+			m.refreshExpanded(node)
 		} else if m.activePane == IBPaneTree {
 			m.activePane = IBPaneDetails
 		}
-		return m, m.triggerSelectedSizeCalc()
+		return m, tea.Batch(m.triggerSelectedScans(), m.triggerTreeScans())
 
 	case "h", "left":
 		node := m.scroller.selectedNode()
 		if node != nil && node.IsDir && node.IsExpanded {
 			node.collapseNode()
-			m.refreshTree()
+			m.refreshExpanded(node)
 		} else if m.activePane == IBPaneDetails {
 			m.activePane = IBPaneTree
 		}
@@ -1535,9 +2857,9 @@ func (m ImportBrowserModel) handleBrowseKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		node := m.scroller.selectedNode()
 		if node != nil && node.IsDir {
 			node.toggleExpand(m.gitRootSet, m.showHidden)
-			m.refreshTree()
+			m.refreshExpanded(node)
 		}
-		return m, m.triggerSelectedSizeCalc()
+		return m, tea.Batch(m.triggerSelectedScans(), m.triggerTreeScans())
 
 	case " ":
 		// Toggle selection for batch operations
@@ -1547,6 +2869,21 @@ func (m ImportBrowserModel) handleBrowseKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		}
 		return m, nil
 
+	case "A":
+		// Select all visible directories
+		m.scroller.selectAllDirs()
+		return m, m.triggerSelectionSizes()
+
+	case "*":
+		// Select all sibling directories of the current node
+		m.scroller.selectSiblingDirs()
+		return m, m.triggerSelectionSizes()
+
+	case "I":
+		// Invert selection of visible directories
+		m.scroller.invertDirSelection()
+		return m, m.triggerSelectionSizes()
+
 	case "tab":
 		// Switch panes
 		if m.activePane == IBPaneTree {
@@ -1554,12 +2891,48 @@ func (m ImportBrowserModel) handleBrowseKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		} else {
 			m.activePane = IBPaneTree
 		}
-		return m, m.triggerSelectedSizeCalc()
+		return m, m.triggerSelectedScans()
 
 	case "r":
 		// Refresh tree
 		m.refresh()
-		return m, nil
+		return m, m.triggerTreeScans()
+
+	case "ctrl+r":
+		// Rescan only the selected folder
+		m.rescanSubtree()
+		return m, m.triggerTreeScans()
+
+	case "u":
+		// Toggle stale filter (untouched for over a year)
+		m.staleOnly = !m.staleOnly
+		m.applyFilter()
+		if m.staleOnly {
+			m.message = "Showing stale entries (untouched >1y)"
+		} else {
+			m.message = "Showing all entries"
+		}
+		m.messageIsError = false
+		return m, m.triggerTreeScans()
+
+	case "c":
+		// Toggle column view (size, modified, repos)
+		m.columnView = !m.columnView
+		if m.columnView {
+			m.message = "Column view on"
+		} else {
+			m.message = "Column view off"
+		}
+		m.messageIsError = false
+		return m, m.triggerTreeScans()
+
+	case "o":
+		// Cycle sort mode: name → size → modified → git repos first
+		m.sortMode = m.sortMode.next()
+		m.resortTree()
+		m.message = fmt.Sprintf("Sort: %s", m.sortMode)
+		m.messageIsError = false
+		return m, m.triggerTreeScans()
 
 	case ".":
 		// Toggle hidden files
@@ -1619,6 +2992,11 @@ func (m ImportBrowserModel) handleBrowseKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		return m, nil
 
 	case "a":
+		// Check if multiple folders are selected for batch add-to
+		selectedNodes := m.scroller.getSelectedNodes()
+		if len(selectedNodes) > 1 {
+			return m.startBatchAddTo(selectedNodes)
+		}
 		// Add selected folder to existing workspace
 		node := m.scroller.selectedNode()
 		if node != nil && node.IsDir {
@@ -1627,21 +3005,49 @@ func (m ImportBrowserModel) handleBrowseKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd
 		return m, nil
 
 	case "d":
+		// Check if multiple items are selected for batch delete
+		selectedNodes := m.scroller.getSelectedNodes()
+		if len(selectedNodes) > 1 {
+			return m.startBatchDelete(selectedNodes, false)
+		}
 		// Delete selected item (permanent)
 		node := m.scroller.selectedNode()
 		if node != nil && node != m.root {
+			if err := m.checkRemovable(node.Path); err != nil {
+				m.message = err.Error()
+				m.messageIsError = true
+				return m, nil
+			}
 			m.deleteTarget = node
 			m.deleteIsTrash = false
+			m.deleteRisks = m.collectDeleteRisks([]*sourceNode{node})
+			if len(m.deleteRisks) == 0 && !m.cfg.RequiresConfirm(config.ConfirmDelete) {
+				return m.executeDelete()
+			}
 			m.state = StateDeleteConfirm
 		}
 		return m, nil
 
 	case "t":
+		// Check if multiple items are selected for batch trash
+		selectedNodes := m.scroller.getSelectedNodes()
+		if len(selectedNodes) > 1 {
+			return m.startBatchDelete(selectedNodes, true)
+		}
 		// Trash selected item (move to system trash)
 		node := m.scroller.selectedNode()
 		if node != nil && node != m.root {
+			if err := m.checkRemovable(node.Path); err != nil {
+				m.message = err.Error()
+				m.messageIsError = true
+				return m, nil
+			}
 			m.deleteTarget = node
 			m.deleteIsTrash = true
+			m.deleteRisks = m.collectDeleteRisks([]*sourceNode{node})
+			if len(m.deleteRisks) == 0 && !m.cfg.RequiresConfirm(config.ConfirmDelete) {
+				return m.executeDelete()
+			}
 			m.state = StateTrashConfirm
 		}
 		return m, nil
@@ -1656,11 +3062,24 @@ func (m *ImportBrowserModel) startImport(node *sourceNode) {
 	m.importTarget = node
 	m.configFocusIdx = 0
 	m.configError = ""
-
-	// Pre-populate project name from folder name
-	suggestedProject := sanitizeForSlug(node.Name)
+	m.configSuggestions = nil
+	m.configSimilar = nil
+	m.splitItems = nil
+	m.splitKeepSource = false
+	m.mergeRepos = false
+	m.repoNames = nil
+	m.repoSelected = 0
+	m.repoRenaming = false
+	m.repoError = ""
+
+	// Pre-populate project name from package metadata or the folder name,
+	// and the owner last used
+	var suggestedProject string
+	suggestedProject, m.configPackageName, m.configPackageFile = m.suggestProject(node)
 	m.projectInput.SetValue(suggestedProject)
-	m.ownerInput.SetValue("")
+	owner, _ := m.stickyDefaults()
+	m.ownerInput.SetValue(owner)
+	m.updateConfigSuggestions()
 }
 
 // startBatchImport initializes batch import for multiple selected folders.
@@ -1669,8 +3088,16 @@ func (m ImportBrowserModel) startBatchImport(nodes []*sourceNode) (tea.Model, te
 	m.batchImportResults = nil
 	m.batchImportCurrent = 0
 	m.batchOwner = ""
+	m.batchProjects = make([]string, len(nodes))
+	for i, node := range nodes {
+		m.batchProjects[i], _, _ = m.suggestProject(node)
+	}
+	m.batchFocus = 0
+	m.selectedTemplate = ""
+	m.templateVarValues = make(map[string]string)
 	m.state = StateBatchImportConfirm
-	m.ownerInput.SetValue("")
+	owner, _ := m.stickyDefaults()
+	m.ownerInput.SetValue(owner)
 	return m, m.ownerInput.Focus()
 }
 
@@ -1684,9 +3111,35 @@ func (m ImportBrowserModel) handleBatchImportConfirmKeys(msg tea.KeyMsg) (tea.Mo
 	case "esc":
 		// Cancel batch import, go back to browse
 		m.batchImportTargets = nil
+		m.selectedTemplate = ""
 		m.state = StateBrowse
 		return m, nil
 
+	case "tab":
+		if m.batchFocus == 0 && canCompleteOwner(&m.ownerInput) {
+			var cmd tea.Cmd
+			m.ownerInput, cmd = m.ownerInput.Update(msg)
+			return m, cmd
+		}
+		// Pick a template (and shared variables) for every workspace
+		m.templateForBatch = true
+		m.batchFocus = 0
+		m.ownerInput.Blur()
+		m.projectInput.Blur()
+		return m.startTemplateSelect()
+
+	case "up":
+		if m.batchFocus > 0 {
+			return m, m.focusBatchField(m.batchFocus - 1)
+		}
+		return m, nil
+
+	case "down":
+		if m.batchFocus < len(m.batchImportTargets) {
+			return m, m.focusBatchField(m.batchFocus + 1)
+		}
+		return m, nil
+
 	case "enter":
 		// Validate owner is set
 		owner := strings.TrimSpace(m.ownerInput.Value())
@@ -1694,11 +3147,33 @@ func (m ImportBrowserModel) handleBatchImportConfirmKeys(msg tea.KeyMsg) (tea.Mo
 			m.configError = "Owner is required"
 			return m, nil
 		}
-		if !isValidSlugPart(owner) {
+		scheme := workspace.SchemeFor(m.cfg)
+		if !scheme.ValidPart(owner) {
 			m.configError = "Owner must be lowercase letters, numbers, and hyphens"
 			return m, nil
 		}
 
+		// Validate the project names; folders without one are skipped
+		used := make(map[string]string)
+		for i, node := range m.batchImportTargets {
+			project := strings.ToLower(strings.TrimSpace(m.batchProject(i)))
+			if project == "" {
+				continue
+			}
+			if !scheme.ValidPart(project) {
+				m.configError = fmt.Sprintf("%s: project must be lowercase alphanumeric with hyphens", node.Name)
+				return m, m.focusBatchField(i + 1)
+			}
+			if other, ok := used[project]; ok {
+				m.configError = fmt.Sprintf("%s and %s would both be imported as %s", other, node.Name, project)
+				return m, m.focusBatchField(i + 1)
+			}
+			used[project] = node.Name
+			if i < len(m.batchProjects) {
+				m.batchProjects[i] = project
+			}
+		}
+
 		// Start batch import execution
 		m.batchOwner = owner
 		m.configError = ""
@@ -1707,45 +3182,287 @@ func (m ImportBrowserModel) handleBatchImportConfirmKeys(msg tea.KeyMsg) (tea.Mo
 
 	// Handle text input
 	var cmd tea.Cmd
+	if m.batchFocus > 0 {
+		m.projectInput, cmd = m.projectInput.Update(msg)
+		m.batchProjects[m.batchFocus-1] = m.projectInput.Value()
+		return m, cmd
+	}
 	m.ownerInput, cmd = m.ownerInput.Update(msg)
 	return m, cmd
 }
 
-// executeBatchImport processes all selected folders and imports them.
+// focusBatchField moves the focus of the batch import form to the owner
+// (0) or to the project name of folder i-1, edited in projectInput.
+func (m *ImportBrowserModel) focusBatchField(i int) tea.Cmd {
+	m.batchFocus = i
+	if i == 0 {
+		m.projectInput.Blur()
+		return m.ownerInput.Focus()
+	}
+	m.ownerInput.Blur()
+	m.projectInput.SetValue(m.batchProject(i - 1))
+	m.projectInput.CursorEnd()
+	return m.projectInput.Focus()
+}
+
+// batchProject returns the project name folder i of the batch import is
+// imported as. It is derived from the folder name until edited.
+func (m ImportBrowserModel) batchProject(i int) string {
+	if i < len(m.batchProjects) {
+		return m.batchProjects[i]
+	}
+	return sanitizeForSlug(m.cfg, m.batchImportTargets[i].Name)
+}
+
+// batchImportItemMsg reports the result of importing one folder of a batch.
+type batchImportItemMsg struct {
+	result BatchImportItemResult
+}
+
+// batchStashItemMsg reports the result of stashing one folder of a batch.
+type batchStashItemMsg struct {
+	result BatchStashItemResult
+}
+
+// executeBatchImport imports the selected folders one at a time in the
+// background, so the import can be stopped between folders.
 func (m ImportBrowserModel) executeBatchImport() (tea.Model, tea.Cmd) {
 	m.state = StateBatchImportExecute
 	m.batchImportResults = make([]BatchImportItemResult, 0, len(m.batchImportTargets))
+	m.batchImportCurrent = 0
+	m.batchStopping = false
+	return m, m.importBatchItem(0)
+}
 
-	for i, node := range m.batchImportTargets {
-		m.batchImportCurrent = i
-
-		// Create workspace slug from owner and folder name
-		project := sanitizeForSlug(node.Name)
-		_ = fmt.Sprintf("%s--%s", m.batchOwner, project) // slug used for reference
+// importBatchItem returns a command that imports folder i of the batch. It
+// only uses values copied here, as the model keeps changing meanwhile.
+func (m ImportBrowserModel) importBatchItem(i int) tea.Cmd {
+	node := m.batchImportTargets[i]
+	cfg := m.cfg
+	owner := m.batchOwner
+	project := m.batchProject(i)
+	gitRoots := m.gitRootsFor(node)
+	templateName := m.selectedTemplate
+	templateVars := maps.Clone(m.templateVarValues)
 
-		// Get git roots under this node
-		var gitRoots []string
-		if node.IsGitRepo {
-			gitRoots = []string{node.Path}
-		} else {
-			prefix := node.Path + string(filepath.Separator)
-			for gitRoot := range m.gitRootSet {
-				if strings.HasPrefix(gitRoot, prefix) {
-					gitRoots = append(gitRoots, gitRoot)
-				}
-			}
+	return func() tea.Msg {
+		itemResult := BatchImportItemResult{
+			SourcePath: node.Path,
+			SourceName: node.Name,
+		}
+		if project == "" {
+			itemResult.Error = fmt.Errorf("no project name for %q", node.Name)
+			return batchImportItemMsg{result: itemResult}
 		}
 
-		// Build import options
+		// Execute the import
 		opts := workspace.ImportOptions{
-			Owner:   m.batchOwner,
+			Owner:   owner,
 			Project: project,
 		}
+		result, err := workspace.CreateWorkspace(cfg, node.Path, gitRoots, opts)
+		if err != nil {
+			itemResult.Error = err
+			return batchImportItemMsg{result: itemResult}
+		}
+		itemResult.Success = true
+		itemResult.WorkspaceSlug = result.WorkspaceSlug
+		itemResult.WorkspacePath = result.WorkspacePath
+		itemResult.RepoCount = len(result.ReposImported)
+
+		// Apply the batch template with shared values plus per-workspace builtins
+		if templateName != "" {
+			vars := make(map[string]string, len(templateVars)+2)
+			for k, v := range templateVars {
+				vars[k] = v
+			}
+			vars["owner"] = owner
+			vars["project"] = project
+			templateOpts := template.CreateOptions{
+				TemplateName: templateName,
+				Variables:    vars,
+			}
+			if _, templateErr := template.ApplyTemplateToExisting(cfg, result.WorkspacePath, templateName, templateOpts); templateErr != nil {
+				itemResult.TemplateError = templateErr
+			}
+		}
+		// Best effort: co trust reports a missing file later
+		template.WriteShellEnv(cfg, result.WorkspacePath)
 
-		// Execute the import
-		result, err := workspace.CreateWorkspace(m.cfg, node.Path, gitRoots, opts)
+		// Clean up empty source if applicable
+		if result.SourceEmpty {
+			workspace.RemoveEmptySource(node.Path)
+		}
+		return batchImportItemMsg{result: itemResult}
+	}
+}
 
-		itemResult := BatchImportItemResult{
+// handleBatchImportItem records the result of one folder and imports the
+// next, or shows the summary once all are done or the import was stopped.
+func (m ImportBrowserModel) handleBatchImportItem(msg batchImportItemMsg) (tea.Model, tea.Cmd) {
+	if msg.result.Success {
+		m.rememberOwner(m.batchOwner)
+	}
+	m.batchImportResults = append(m.batchImportResults, msg.result)
+
+	if next := len(m.batchImportResults); next < len(m.batchImportTargets) && !m.batchStopping {
+		m.batchImportCurrent = next
+		return m, m.importBatchItem(next)
+	}
+
+	// Clear selections and refresh tree
+	m.scroller.clearAllSelections()
+	m.refresh()
+
+	// Go to summary
+	m.state = StateBatchImportSummary
+	return m, nil
+}
+
+// handleBatchExecuteKeys handles keyboard input while a batch import or
+// stash runs. Stopping waits for the current folder, since quitting halfway
+// through it would leave it partly moved.
+func (m ImportBrowserModel) handleBatchExecuteKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "esc", "q":
+		m.batchStopping = true
+	}
+	return m, nil
+}
+
+// handleBatchImportSummaryKeys handles keyboard input in batch import summary state.
+func (m ImportBrowserModel) handleBatchImportSummaryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "enter", "esc", "q":
+		// Return to browse
+		m.batchImportTargets = nil
+		m.batchImportResults = nil
+		m.state = StateBrowse
+		return m, nil
+
+	case "r":
+		// Resume a stopped import with the folders not imported yet
+		next := len(m.batchImportResults)
+		if next >= len(m.batchImportTargets) {
+			return m, nil
+		}
+		m.state = StateBatchImportExecute
+		m.batchImportCurrent = next
+		m.batchStopping = false
+		return m, m.importBatchItem(next)
+
+	case "o":
+		// Offer to open one of the workspaces created
+		var slugs []string
+		for _, r := range m.batchImportResults {
+			if r.Success {
+				slugs = append(slugs, r.WorkspaceSlug)
+			}
+		}
+		if len(slugs) == 0 {
+			return m, nil
+		}
+		m.batchImportTargets = nil
+		m.batchImportResults = nil
+		return m.startOpenWorkspace(slugs)
+	}
+
+	return m, nil
+}
+
+// gitRootsFor returns the detected git repositories at or beneath node.
+func (m ImportBrowserModel) gitRootsFor(node *sourceNode) []string {
+	if node.IsGitRepo {
+		return []string{node.Path}
+	}
+	return slices.Clone(m.gitRoots().below(node.Path))
+}
+
+// gitRoots returns the index of gitRootSet. Models made without
+// NewImportBrowser index the set on each call.
+func (m ImportBrowserModel) gitRoots() *gitRootIndex {
+	if m.gitIndex == nil {
+		return newGitRootIndex(m.gitRootSet)
+	}
+	return m.gitIndex
+}
+
+// indexGitRoots rebuilds the index after gitRootSet changes.
+func (m *ImportBrowserModel) indexGitRoots() {
+	m.gitIndex = newGitRootIndex(m.gitRootSet)
+}
+
+// startBatchAddTo initializes batch add-to for multiple selected folders.
+// Only directories are added; the user picks a single target workspace first.
+func (m ImportBrowserModel) startBatchAddTo(nodes []*sourceNode) (tea.Model, tea.Cmd) {
+	var dirs []*sourceNode
+	for _, node := range nodes {
+		if node.IsDir {
+			dirs = append(dirs, node)
+		}
+	}
+	if len(dirs) == 0 {
+		m.message = "No folders selected"
+		m.messageIsError = true
+		return m, nil
+	}
+
+	workspaces, err := workspace.ListWorkspaces(m.cfg)
+	if err != nil {
+		m.message = fmt.Sprintf("Failed to list workspaces: %v", err)
+		m.messageIsError = true
+		return m, nil
+	}
+	if len(workspaces) == 0 {
+		m.message = "No existing workspaces found. Use 'i' to create a new workspace."
+		m.messageIsError = true
+		return m, nil
+	}
+
+	m.batchAddToTargets = dirs
+	m.batchAddToResults = nil
+	m.batchAddToCurrent = 0
+	m.importTarget = nil
+	m.setAddToWorkspaces(workspaces)
+	m.state = StateAddToSelect
+	return m, nil
+}
+
+// handleBatchAddToConfirmKeys handles keyboard input in batch add-to confirm state.
+func (m ImportBrowserModel) handleBatchAddToConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc", "q":
+		// Back to workspace selection
+		m.addToTargetSlug = ""
+		m.state = StateAddToSelect
+		return m, nil
+
+	case "enter":
+		return m.executeBatchAddTo()
+	}
+
+	return m, nil
+}
+
+// executeBatchAddTo adds all selected folders to the chosen workspace.
+func (m ImportBrowserModel) executeBatchAddTo() (tea.Model, tea.Cmd) {
+	m.state = StateBatchAddToExecute
+	m.batchAddToResults = make([]BatchAddToItemResult, 0, len(m.batchAddToTargets))
+
+	for i, node := range m.batchAddToTargets {
+		m.batchAddToCurrent = i
+
+		result, err := workspace.AddToWorkspace(m.cfg, node.Path, m.gitRootsFor(node), m.addToTargetSlug, workspace.ImportOptions{})
+
+		itemResult := BatchAddToItemResult{
 			SourcePath: node.Path,
 			SourceName: node.Name,
 		}
@@ -1755,17 +3472,17 @@ func (m ImportBrowserModel) executeBatchImport() (tea.Model, tea.Cmd) {
 			itemResult.Error = err
 		} else {
 			itemResult.Success = true
-			itemResult.WorkspaceSlug = result.WorkspaceSlug
-			itemResult.WorkspacePath = result.WorkspacePath
 			itemResult.RepoCount = len(result.ReposImported)
+			itemResult.SkippedCount = len(result.ReposSkipped)
 
 			// Clean up empty source if applicable
 			if result.SourceEmpty {
 				workspace.RemoveEmptySource(node.Path)
+				itemResult.SourceEmpty = true
 			}
 		}
 
-		m.batchImportResults = append(m.batchImportResults, itemResult)
+		m.batchAddToResults = append(m.batchAddToResults, itemResult)
 	}
 
 	// Clear selections and refresh tree
@@ -1773,12 +3490,12 @@ func (m ImportBrowserModel) executeBatchImport() (tea.Model, tea.Cmd) {
 	m.refresh()
 
 	// Go to summary
-	m.state = StateBatchImportSummary
+	m.state = StateBatchAddToSummary
 	return m, nil
 }
 
-// handleBatchImportSummaryKeys handles keyboard input in batch import summary state.
-func (m ImportBrowserModel) handleBatchImportSummaryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// handleBatchAddToSummaryKeys handles keyboard input in batch add-to summary state.
+func (m ImportBrowserModel) handleBatchAddToSummaryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c":
 		m.result.Aborted = true
@@ -1786,8 +3503,9 @@ func (m ImportBrowserModel) handleBatchImportSummaryKeys(msg tea.KeyMsg) (tea.Mo
 
 	case "enter", "esc", "q":
 		// Return to browse
-		m.batchImportTargets = nil
-		m.batchImportResults = nil
+		m.batchAddToTargets = nil
+		m.batchAddToResults = nil
+		m.clearAddToState()
 		m.state = StateBrowse
 		return m, nil
 	}
@@ -1801,6 +3519,7 @@ func (m ImportBrowserModel) startBatchStash(nodes []*sourceNode, deleteAfter boo
 	m.batchStashResults = nil
 	m.batchStashCurrent = 0
 	m.batchStashDeleteAfter = deleteAfter
+	m.batchStashRisks = m.collectDeleteRisks(nodes)
 	m.state = StateBatchStashConfirm
 	return m, nil
 }
@@ -1815,6 +3534,7 @@ func (m ImportBrowserModel) handleBatchStashConfirmKeys(msg tea.KeyMsg) (tea.Mod
 	case "esc", "q":
 		// Cancel batch stash, go back to browse
 		m.batchStashTargets = nil
+		m.batchStashRisks = nil
 		m.state = StateBrowse
 		return m, nil
 
@@ -1824,33 +3544,51 @@ func (m ImportBrowserModel) handleBatchStashConfirmKeys(msg tea.KeyMsg) (tea.Mod
 		return m, nil
 
 	case "enter":
-		// Start batch stash execution
+		// Start batch stash execution; deleting unsaved work requires '!'
+		if m.batchStashDeleteAfter && len(m.batchStashRisks) > 0 {
+			return m, nil
+		}
+		return m.executeBatchStash()
+
+	case "!":
 		return m.executeBatchStash()
 	}
 
 	return m, nil
 }
 
-// executeBatchStash processes all selected folders and stashes them.
+// executeBatchStash stashes the selected folders one at a time in the
+// background, so the stash can be stopped between folders.
 func (m ImportBrowserModel) executeBatchStash() (tea.Model, tea.Cmd) {
 	m.state = StateBatchStashExecute
 	m.batchStashResults = make([]BatchStashItemResult, 0, len(m.batchStashTargets))
+	m.batchStashCurrent = 0
+	m.batchStopping = false
+	return m, m.stashBatchItem(0)
+}
 
-	for i, node := range m.batchStashTargets {
-		m.batchStashCurrent = i
+// stashBatchItem returns a command that stashes folder i of the batch.
+func (m ImportBrowserModel) stashBatchItem(i int) tea.Cmd {
+	node := m.batchStashTargets[i]
+	opts := archive.StashOptions{
+		Name:        node.Name,
+		DeleteAfter: m.batchStashDeleteAfter,
+	}
 
-		opts := archive.StashOptions{
-			Name:        node.Name,
-			DeleteAfter: m.batchStashDeleteAfter,
+	return func() tea.Msg {
+		var result *archive.StashResult
+		var err error
+		if opts.DeleteAfter {
+			err = m.checkRemovable(node.Path)
+		}
+		if err == nil {
+			result, err = archive.StashFolder(m.cfg, node.Path, opts)
 		}
-
-		result, err := archive.StashFolder(m.cfg, node.Path, opts)
 
 		itemResult := BatchStashItemResult{
 			SourcePath: node.Path,
 			SourceName: node.Name,
 		}
-
 		if err != nil {
 			itemResult.Success = false
 			itemResult.Error = err
@@ -1859,8 +3597,18 @@ func (m ImportBrowserModel) executeBatchStash() (tea.Model, tea.Cmd) {
 			itemResult.ArchivePath = result.ArchivePath
 			itemResult.Deleted = result.Deleted
 		}
+		return batchStashItemMsg{result: itemResult}
+	}
+}
+
+// handleBatchStashItem records the result of one folder and stashes the
+// next, or shows the summary once all are done or the stash was stopped.
+func (m ImportBrowserModel) handleBatchStashItem(msg batchStashItemMsg) (tea.Model, tea.Cmd) {
+	m.batchStashResults = append(m.batchStashResults, msg.result)
 
-		m.batchStashResults = append(m.batchStashResults, itemResult)
+	if next := len(m.batchStashResults); next < len(m.batchStashTargets) && !m.batchStopping {
+		m.batchStashCurrent = next
+		return m, m.stashBatchItem(next)
 	}
 
 	// Clear selections and refresh tree
@@ -1882,27 +3630,169 @@ func (m ImportBrowserModel) handleBatchStashSummaryKeys(msg tea.KeyMsg) (tea.Mod
 	case "enter", "esc", "q":
 		// Return to browse
 		m.batchStashTargets = nil
+		m.batchStashRisks = nil
 		m.batchStashResults = nil
 		m.state = StateBrowse
 		return m, nil
+
+	case "r":
+		// Resume a stopped stash with the folders not stashed yet
+		next := len(m.batchStashResults)
+		if next >= len(m.batchStashTargets) {
+			return m, nil
+		}
+		m.state = StateBatchStashExecute
+		m.batchStashCurrent = next
+		m.batchStopping = false
+		return m, m.stashBatchItem(next)
 	}
 
 	return m, nil
 }
 
-// sanitizeForSlug converts a string to a valid slug part.
-func sanitizeForSlug(s string) string {
-	s = strings.ToLower(s)
-	var result strings.Builder
-	for _, c := range s {
-		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' {
-			result.WriteRune(c)
-		} else if c == '_' || c == ' ' {
-			result.WriteRune('-')
+// startBatchDelete initializes batch delete (or trash) for multiple selected items.
+// Repos with uncommitted changes or unpushed commits are collected up front so the
+// confirm view can require an explicit override; that check is kept even when the
+// confirmation policy disables delete prompts.
+func (m ImportBrowserModel) startBatchDelete(nodes []*sourceNode, trash bool) (tea.Model, tea.Cmd) {
+	for _, node := range nodes {
+		if err := m.checkRemovable(node.Path); err != nil {
+			m.message = err.Error()
+			m.messageIsError = true
+			return m, nil
 		}
 	}
-	return result.String()
-}
+	m.batchDeleteTargets = nodes
+	m.batchDeleteResults = nil
+	m.batchDeleteCurrent = 0
+	m.deleteIsTrash = trash
+	m.batchDeleteRisks = m.collectDeleteRisks(nodes)
+	if len(m.batchDeleteRisks) == 0 && !m.cfg.RequiresConfirm(config.ConfirmDelete) {
+		return m.executeBatchDelete()
+	}
+	m.state = StateBatchDeleteConfirm
+	return m, nil
+}
+
+// collectDeleteRisks checks every git repo under the given nodes for dirty state
+// and unpushed commits.
+func (m ImportBrowserModel) collectDeleteRisks(nodes []*sourceNode) []deleteRisk {
+	var risks []deleteRisk
+	for _, node := range nodes {
+		if !node.IsDir {
+			continue
+		}
+		for _, repoPath := range m.gitRootsFor(node) {
+			if work := git.CheckUnsaved(repoPath); work.Any() {
+				risks = append(risks, deleteRisk{TargetName: node.Name, UnsavedWork: work})
+			}
+		}
+	}
+	return risks
+}
+
+// handleBatchDeleteConfirmKeys handles keyboard input in batch delete/trash confirm state.
+// When any target holds uncommitted or unpushed work, plain confirmation is refused
+// and '!' must be pressed to proceed.
+func (m ImportBrowserModel) handleBatchDeleteConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc", "n", "N", "q":
+		// Cancel, return to browse
+		m.batchDeleteTargets = nil
+		m.batchDeleteRisks = nil
+		m.state = StateBrowse
+		return m, nil
+
+	case "y", "Y", "enter":
+		if len(m.batchDeleteRisks) > 0 {
+			return m, nil
+		}
+		return m.executeBatchDelete()
+
+	case "!":
+		// Override safety check
+		return m.executeBatchDelete()
+	}
+
+	return m, nil
+}
+
+// executeBatchDelete deletes or trashes all selected items.
+func (m ImportBrowserModel) executeBatchDelete() (tea.Model, tea.Cmd) {
+	m.state = StateBatchDeleteExecute
+	m.batchDeleteResults = make([]BatchDeleteItemResult, 0, len(m.batchDeleteTargets))
+
+	for i, node := range m.batchDeleteTargets {
+		m.batchDeleteCurrent = i
+
+		err := m.checkRemovable(node.Path)
+		if err == nil && m.deleteIsTrash {
+			err = trashPath(node.Path)
+		} else if err == nil {
+			_, err = quarantine.Delete(m.cfg, node.Path)
+		}
+
+		m.batchDeleteResults = append(m.batchDeleteResults, BatchDeleteItemResult{
+			SourcePath: node.Path,
+			SourceName: node.Name,
+			Success:    err == nil,
+			Error:      err,
+		})
+	}
+
+	// Clear selections and refresh tree
+	m.scroller.clearAllSelections()
+	m.refresh()
+
+	// Go to summary
+	m.state = StateBatchDeleteSummary
+	return m, nil
+}
+
+// handleBatchDeleteSummaryKeys handles keyboard input in batch delete/trash summary state.
+func (m ImportBrowserModel) handleBatchDeleteSummaryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "enter", "esc", "q":
+		// Return to browse
+		m.batchDeleteTargets = nil
+		m.batchDeleteRisks = nil
+		m.batchDeleteResults = nil
+		m.state = StateBrowse
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// sanitizeForSlug converts a string to a valid slug part.
+func sanitizeForSlug(cfg *config.Config, s string) string {
+	return workspace.SchemeFor(cfg).Sanitize(s)
+}
+
+// suggestProject returns the project name suggested for importing node.
+// A name in the folder's package metadata (go.mod, package.json,
+// pyproject.toml, Cargo.toml) is preferred over the folder name; it is
+// returned with the file it was read from when it differs from the folder
+// name.
+func (m ImportBrowserModel) suggestProject(node *sourceNode) (project, packageName, packageFile string) {
+	folder := sanitizeForSlug(m.cfg, node.Name)
+	if node.IsDir {
+		if name, file := workspace.PackageName(node.Path); name != "" {
+			if project := sanitizeForSlug(m.cfg, name); project != "" && project != folder {
+				return project, name, file
+			}
+		}
+	}
+	return folder, "", ""
+}
 
 // handleImportConfigKeys handles keyboard input in import config state.
 func (m ImportBrowserModel) handleImportConfigKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -1921,6 +3811,12 @@ func (m ImportBrowserModel) handleImportConfigKeys(msg tea.KeyMsg) (tea.Model, t
 		return m, nil
 
 	case "tab", "down":
+		if msg.String() == "tab" && m.configFocusIdx == 0 && canCompleteOwner(&m.ownerInput) {
+			var cmd tea.Cmd
+			m.ownerInput, cmd = m.ownerInput.Update(msg)
+			m.updateConfigSuggestions()
+			return m, cmd
+		}
 		// Move to next field
 		m.configFocusIdx = (m.configFocusIdx + 1) % 2
 		m.ownerInput.Blur()
@@ -1953,20 +3849,27 @@ func (m ImportBrowserModel) handleImportConfigKeys(msg tea.KeyMsg) (tea.Model, t
 			m.configError = "project is required"
 			return m, nil
 		}
-		if !isValidSlugPart(owner) {
+		scheme := workspace.SchemeFor(m.cfg)
+		if !scheme.ValidPart(owner) {
 			m.configError = "owner must be lowercase alphanumeric with hyphens"
 			return m, nil
 		}
-		if !isValidSlugPart(project) {
+		if !scheme.ValidPart(project) {
 			m.configError = "project must be lowercase alphanumeric with hyphens"
 			return m, nil
 		}
 
-		// Check if workspace already exists
-		slug := owner + "--" + project
-		workspacePath := filepath.Join(m.cfg.CodeRoot, slug)
+		// Offer a way out if the workspace already exists
+		slug := scheme.Format(owner, project)
+		workspacePath := m.cfg.WorkspacePath(slug)
 		if _, err := os.Stat(workspacePath); err == nil {
-			m.configError = fmt.Sprintf("workspace already exists: %s", slug)
+			m.conflictSlug = slug
+			m.conflictFreeSlug = scheme.Format(owner, workspace.FreeProject(m.cfg, owner, project))
+			m.conflictOption = 0
+			m.configError = ""
+			m.ownerInput.Blur()
+			m.projectInput.Blur()
+			m.state = StateWorkspaceConflict
 			return m, nil
 		}
 
@@ -1977,6 +3880,18 @@ func (m ImportBrowserModel) handleImportConfigKeys(msg tea.KeyMsg) (tea.Model, t
 
 		// Proceed to template selection (which may skip to extra files if no templates)
 		return m.startTemplateSelect()
+
+	case "ctrl+n":
+		// Fill in the next suggested project name
+		if len(m.configSuggestions) == 0 {
+			return m, nil
+		}
+		name := m.configSuggestions[m.configSuggestIdx%len(m.configSuggestions)]
+		m.configSuggestIdx++
+		m.projectInput.SetValue(name)
+		m.projectInput.CursorEnd()
+		m.updateConfigSuggestions()
+		return m, nil
 	}
 
 	// Update the focused input
@@ -1986,29 +3901,194 @@ func (m ImportBrowserModel) handleImportConfigKeys(msg tea.KeyMsg) (tea.Model, t
 	} else {
 		m.projectInput, cmd = m.projectInput.Update(msg)
 	}
+	m.updateConfigSuggestions()
 	return m, cmd
 }
 
+// maxSimilarShown limits how many similar workspaces the import config form
+// lists.
+const maxSimilarShown = 5
+
+// updateConfigSuggestions refreshes the suggested project names and similar
+// workspaces for the owner and project typed into the import config form.
+func (m *ImportBrowserModel) updateConfigSuggestions() {
+	owner := strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
+	project := strings.ToLower(strings.TrimSpace(m.projectInput.Value()))
+	scheme := workspace.SchemeFor(m.cfg)
+	if !scheme.ValidPart(owner) || !scheme.ValidPart(project) {
+		m.configSuggestions, m.configSimilar = nil, nil
+		return
+	}
+
+	// Keep the suggestions while one of them is filled in, so ctrl+n cycles
+	if !slices.Contains(m.configSuggestions, project) {
+		m.configSuggestions = workspace.SuggestProjects(m.cfg, owner, project, time.Now())
+		m.configSuggestIdx = 0
+	}
+	m.configSimilar, _ = workspace.SimilarWorkspaces(m.cfg, owner, project)
+}
+
+// workspaceConflictOption is a way out of importing into a workspace that
+// already exists.
+type workspaceConflictOption int
+
+const (
+	conflictUseFreeName workspaceConflictOption = iota // Import under the first free suffixed name
+	conflictAddTo                                      // Add the folder to the existing workspace
+	conflictOpen                                       // Open the existing workspace instead
+)
+
+// conflictOptions returns the options of the workspace conflict dialog.
+// Adding to or opening the existing path is only offered when it is a
+// workspace directory.
+func (m ImportBrowserModel) conflictOptions() []workspaceConflictOption {
+	options := []workspaceConflictOption{conflictUseFreeName}
+	if workspace.Exists(m.cfg, m.conflictSlug) {
+		options = append(options, conflictAddTo, conflictOpen)
+	}
+	return options
+}
+
+// conflictLabel returns the text of the option in the workspace conflict dialog.
+func (m ImportBrowserModel) conflictLabel(option workspaceConflictOption) string {
+	switch option {
+	case conflictAddTo:
+		return fmt.Sprintf("Add to %s instead", m.conflictSlug)
+	case conflictOpen:
+		return fmt.Sprintf("Open %s", m.conflictSlug)
+	}
+	return fmt.Sprintf("Import as %s", m.conflictFreeSlug)
+}
+
+// handleWorkspaceConflictKeys handles keyboard input in the dialog shown
+// when the workspace to import into already exists.
+func (m ImportBrowserModel) handleWorkspaceConflictKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	options := m.conflictOptions()
+	switch key := msg.String(); key {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc", "q":
+		// Back to editing the name
+		m.state = StateImportConfig
+		if m.configFocusIdx == 0 {
+			return m, m.ownerInput.Focus()
+		}
+		return m, m.projectInput.Focus()
+
+	case "j", "down":
+		if m.conflictOption < len(options)-1 {
+			m.conflictOption++
+		}
+		return m, nil
+
+	case "k", "up":
+		if m.conflictOption > 0 {
+			m.conflictOption--
+		}
+		return m, nil
+
+	case "1", "2", "3":
+		if i := int(key[0] - '1'); i < len(options) {
+			m.conflictOption = i
+		}
+		return m, nil
+
+	case "enter":
+		switch options[m.conflictOption] {
+		case conflictAddTo:
+			// Continue as if the workspace had been picked with 'a'
+			m.splitItems = nil
+			m.addToTargetSlug = m.conflictSlug
+			m.result.WorkspaceSlug = m.conflictSlug
+			m.result.WorkspacePath = m.cfg.WorkspacePath(m.conflictSlug)
+			return m.checkForExtraFilesAddTo()
+
+		case conflictOpen:
+			m.openInEditor(m.conflictSlug)
+			m.importTarget = nil
+			m.state = StateBrowse
+			return m, nil
+		}
+
+		// Import under the free name, as if it had been typed
+		parsed, _ := workspace.SchemeFor(m.cfg).Parse(m.conflictFreeSlug)
+		m.projectInput.SetValue(parsed.Project)
+		m.result.WorkspaceSlug = m.conflictFreeSlug
+		m.result.WorkspacePath = m.cfg.WorkspacePath(m.conflictFreeSlug)
+		return m.startTemplateSelect()
+	}
+
+	return m, nil
+}
+
+// rememberOwner makes owner the default of the next import and adds it to
+// the owner completions.
+func (m *ImportBrowserModel) rememberOwner(owner string) {
+	m.lastOwner = owner
+	addOwnerCompletion(&m.ownerInput, owner)
+}
+
+// postImportChoices are the names of the post-import options, as remembered
+// in the session.
+var postImportChoices = []string{"keep", "stash", "delete"}
+
+// stickyDefaults returns the owner and other choices of the last import to
+// pre-select in the next one, or none when sticky_defaults is off.
+func (m ImportBrowserModel) stickyDefaults() (string, importDefaults) {
+	if !m.cfg.RemembersImportDefaults() {
+		return "", importDefaults{}
+	}
+	return m.lastOwner, m.defaults
+}
+
+// defaultPostImportOption returns the post-import option pre-selected after
+// an import: the one chosen last time, except that delete is never
+// pre-selected, so removing a source always takes a deliberate choice.
+func (m ImportBrowserModel) defaultPostImportOption() int {
+	if _, defaults := m.stickyDefaults(); defaults.PostImport == "stash" {
+		return 1
+	}
+	return 0
+}
+
 // startTemplateSelect initializes the template selection state.
 func (m ImportBrowserModel) startTemplateSelect() (tea.Model, tea.Cmd) {
 	// Load available templates from all template directories
 	templateInfos, err := template.ListTemplateInfosMulti(m.cfg.AllTemplatesDirs())
 	if err != nil {
 		// If we can't load templates, skip to extra files check
-		return m.checkForExtraFiles()
+		return m.finishTemplateSelection()
 	}
 
 	// If no templates available, skip to extra files check
 	if len(templateInfos) == 0 {
-		return m.checkForExtraFiles()
+		if m.templateForBatch {
+			m.message = "No templates available"
+			m.messageIsError = true
+		}
+		return m.finishTemplateSelection()
 	}
 
 	m.templateInfos = templateInfos
+	m.templatePreviews = make(map[string]*templatePreview)
 	m.templateSelected = 0 // Start at "No template" option
 	m.templateScrollOffset = 0
 	m.selectedTemplate = ""
 	m.state = StateTemplateSelect
 
+	// Or at the template used last time
+	if _, defaults := m.stickyDefaults(); defaults.Template != "" {
+		for i, info := range templateInfos {
+			if info.Name == defaults.Template {
+				m.templateSelected = i + 1
+				m.ensureTemplateVisible()
+				break
+			}
+		}
+	}
+
 	return m, nil
 }
 
@@ -2020,7 +4100,12 @@ func (m ImportBrowserModel) handleTemplateSelectKeys(msg tea.KeyMsg) (tea.Model,
 		return m, tea.Quit
 
 	case "esc", "q":
-		// Cancel, return to import config
+		// Cancel, return to batch confirm or import config
+		if m.templateForBatch {
+			m.templateForBatch = false
+			m.state = StateBatchImportConfirm
+			return m, m.ownerInput.Focus()
+		}
 		m.state = StateImportConfig
 		return m, m.ownerInput.Focus()
 
@@ -2055,12 +4140,14 @@ func (m ImportBrowserModel) handleTemplateSelectKeys(msg tea.KeyMsg) (tea.Model,
 		if m.templateSelected == 0 {
 			// "No template" selected
 			m.selectedTemplate = ""
+			m.defaults.Template = ""
 			// No variables to prompt, go to extra files
-			return m.checkForExtraFiles()
+			return m.finishTemplateSelection()
 		}
 
 		// Template selected (index is offset by 1 due to "No template" option)
 		m.selectedTemplate = m.templateInfos[m.templateSelected-1].Name
+		m.defaults.Template = m.selectedTemplate
 
 		// Check if template has variables that need prompting
 		return m.startTemplateVars()
@@ -2073,7 +4160,7 @@ func (m ImportBrowserModel) handleTemplateSelectKeys(msg tea.KeyMsg) (tea.Model,
 func (m ImportBrowserModel) startTemplateVars() (tea.Model, tea.Cmd) {
 	if m.selectedTemplate == "" {
 		// No template selected, skip to extra files
-		return m.checkForExtraFiles()
+		return m.finishTemplateSelection()
 	}
 
 	// Load the template to get its variables
@@ -2089,7 +4176,13 @@ func (m ImportBrowserModel) startTemplateVars() (tea.Model, tea.Cmd) {
 	var varsToPrompt []template.TemplateVar
 	builtinVars := m.getBuiltinVariables()
 
-	for _, v := range tmpl.Variables {
+	order, err := template.OrderVariables(tmpl.Variables)
+	if err != nil {
+		m.message = fmt.Sprintf("Template variables: %v", err)
+		m.messageIsError = true
+		return m, nil
+	}
+	for _, v := range order {
 		// Skip if already has a builtin value
 		if _, ok := builtinVars[v.Name]; ok {
 			continue
@@ -2098,15 +4191,16 @@ func (m ImportBrowserModel) startTemplateVars() (tea.Model, tea.Cmd) {
 	}
 
 	// If no variables need prompting, skip to extra files
-	if len(varsToPrompt) == 0 {
+	first := skipInapplicableVars(varsToPrompt, 0, builtinVars)
+	if first == len(varsToPrompt) {
 		// Store template values (just builtins for now)
 		m.templateVarValues = builtinVars
-		return m.checkForExtraFiles()
+		return m.finishTemplateSelection()
 	}
 
 	// Initialize variable prompting state
 	m.templateVars = varsToPrompt
-	m.templateVarIndex = 0
+	m.templateVarIndex = first
 	m.templateVarValues = builtinVars
 	m.templateVarError = ""
 	m.setupCurrentTemplateVar()
@@ -2115,14 +4209,33 @@ func (m ImportBrowserModel) startTemplateVars() (tea.Model, tea.Cmd) {
 	return m, m.templateVarInput.Focus()
 }
 
+// finishTemplateSelection continues after a template (or none) has been chosen.
+// Batch imports return to the batch confirm view; single imports check for extra files.
+func (m ImportBrowserModel) finishTemplateSelection() (tea.Model, tea.Cmd) {
+	if m.templateForBatch {
+		m.templateForBatch = false
+		m.state = StateBatchImportConfirm
+		return m, m.ownerInput.Focus()
+	}
+	return m.startSplitSelect()
+}
+
 // getBuiltinVariables returns the built-in variables for the import context.
+// In a batch import, owner and project differ per workspace and are filled in
+// when the template is applied, so they are never prompted for.
 func (m *ImportBrowserModel) getBuiltinVariables() map[string]string {
 	vars := make(map[string]string)
 
+	if m.templateForBatch {
+		vars["owner"] = strings.TrimSpace(m.ownerInput.Value())
+		vars["project"] = ""
+		return vars
+	}
+
 	// Extract owner and project from workspace slug
-	if parts := strings.SplitN(m.result.WorkspaceSlug, "--", 2); len(parts) == 2 {
-		vars["owner"] = parts[0]
-		vars["project"] = parts[1]
+	if parsed, ok := workspace.SchemeFor(m.cfg).Parse(m.result.WorkspaceSlug); ok {
+		vars["owner"] = parsed.Owner
+		vars["project"] = parsed.Project
 	}
 
 	return vars
@@ -2167,7 +4280,7 @@ func (m *ImportBrowserModel) setupCurrentTemplateVar() {
 func (m ImportBrowserModel) handleTemplateVarsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.templateVarIndex >= len(m.templateVars) {
 		// All variables collected, proceed
-		return m.checkForExtraFiles()
+		return m.finishTemplateSelection()
 	}
 
 	v := m.templateVars[m.templateVarIndex]
@@ -2211,9 +4324,9 @@ func (m ImportBrowserModel) handleTemplateVarBoolKeys(msg tea.KeyMsg, v template
 			m.templateVarValues[v.Name] = "false"
 		}
 		m.templateVarError = ""
-		m.templateVarIndex++
+		m.templateVarIndex = skipInapplicableVars(m.templateVars, m.templateVarIndex+1, m.templateVarValues)
 		if m.templateVarIndex >= len(m.templateVars) {
-			return m.checkForExtraFiles()
+			return m.finishTemplateSelection()
 		}
 		m.setupCurrentTemplateVar()
 	}
@@ -2234,9 +4347,9 @@ func (m ImportBrowserModel) handleTemplateVarChoiceKeys(msg tea.KeyMsg, v templa
 	case "enter":
 		m.templateVarValues[v.Name] = v.Choices[m.templateVarChoiceIdx]
 		m.templateVarError = ""
-		m.templateVarIndex++
+		m.templateVarIndex = skipInapplicableVars(m.templateVars, m.templateVarIndex+1, m.templateVarValues)
 		if m.templateVarIndex >= len(m.templateVars) {
-			return m.checkForExtraFiles()
+			return m.finishTemplateSelection()
 		}
 		m.setupCurrentTemplateVar()
 	}
@@ -2274,9 +4387,9 @@ func (m ImportBrowserModel) handleTemplateVarTextKeys(msg tea.KeyMsg, v template
 		m.templateVarValues[v.Name] = value
 		m.templateVarError = ""
 		m.templateVarInput.SetValue("")
-		m.templateVarIndex++
+		m.templateVarIndex = skipInapplicableVars(m.templateVars, m.templateVarIndex+1, m.templateVarValues)
 		if m.templateVarIndex >= len(m.templateVars) {
-			return m.checkForExtraFiles()
+			return m.finishTemplateSelection()
 		}
 		m.setupCurrentTemplateVar()
 		return m, m.templateVarInput.Focus()
@@ -2305,7 +4418,7 @@ func (m *ImportBrowserModel) ensureTemplateVisible() {
 // startAddToWorkspace initializes the add-to-workspace state for the selected folder.
 func (m ImportBrowserModel) startAddToWorkspace(node *sourceNode) (tea.Model, tea.Cmd) {
 	// Load available workspaces
-	workspaces, err := fs.ListWorkspaces(m.cfg.CodeRoot)
+	workspaces, err := workspace.ListWorkspaces(m.cfg)
 	if err != nil {
 		m.message = fmt.Sprintf("Failed to list workspaces: %v", err)
 		m.messageIsError = true
@@ -2320,10 +4433,8 @@ func (m ImportBrowserModel) startAddToWorkspace(node *sourceNode) (tea.Model, te
 
 	m.state = StateAddToSelect
 	m.importTarget = node
-	m.addToWorkspaces = workspaces
-	m.addToSelected = 0
-	m.addToScrollOffset = 0
-	m.addToTargetSlug = ""
+	m.splitItems = nil
+	m.setAddToWorkspaces(workspaces)
 
 	return m, nil
 }
@@ -2335,6 +4446,8 @@ func (m *ImportBrowserModel) startStash(node *sourceNode, deleteAfter bool) {
 	m.stashDeleteAfter = deleteAfter
 	m.stashFocusIdx = 0
 	m.stashError = ""
+	m.stashRisks = m.collectDeleteRisks([]*sourceNode{node})
+	m.stashSource = archive.StashSourceOf(node.Path)
 
 	// Pre-populate archive name from item name
 	suggestedName := archive.SanitizeArchiveName(node.Name)
@@ -2374,31 +4487,46 @@ func (m ImportBrowserModel) handleStashConfirmKeys(msg tea.KeyMsg) (tea.Model, t
 		m.stashNameInput.Blur()
 		return m, nil
 
-	case " ":
-		// Toggle delete option when focused on it
-		if m.stashFocusIdx == 1 {
-			m.stashDeleteAfter = !m.stashDeleteAfter
+	case "enter":
+		// Execute stash; deleting unsaved work requires '!', typed with
+		// the delete option focused so it cannot come from the name
+		if m.stashDeleteAfter && len(m.stashRisks) > 0 {
+			m.stashError = "source has unsaved work; press ! to stash and delete anyway"
+			m.stashFocusIdx = 1
+			m.stashNameInput.Blur()
+			return m, nil
 		}
-		return m, nil
+		return m.executeStash()
+	}
 
-	case "d", "D":
-		// Quick toggle delete option
-		m.stashDeleteAfter = !m.stashDeleteAfter
+	// Keys other than the above go to the name input when it is focused
+	if m.stashFocusIdx == 1 {
+		switch msg.String() {
+		case " ", "d", "D":
+			m.stashDeleteAfter = !m.stashDeleteAfter
+		case "!":
+			if m.stashDeleteAfter && len(m.stashRisks) > 0 {
+				return m.executeStash()
+			}
+		}
 		return m, nil
-
-	case "enter":
-		// Execute stash
-		return m.executeStash()
 	}
 
-	// Update the name input if focused
+	var cmd tea.Cmd
+	m.stashNameInput, cmd = m.stashNameInput.Update(msg)
+	return m, cmd
+}
+
+// stashHelp returns the key help of the stash form. Space, d, and ! toggle
+// and confirm only on the delete option; on the name they are typed.
+func (m ImportBrowserModel) stashHelp() string {
 	if m.stashFocusIdx == 0 {
-		var cmd tea.Cmd
-		m.stashNameInput, cmd = m.stashNameInput.Update(msg)
-		return m, cmd
+		return "tab: switch field • enter: stash • esc: cancel"
 	}
-
-	return m, nil
+	if m.stashDeleteAfter && len(m.stashRisks) > 0 {
+		return "tab: switch field • space/d: toggle delete • !: stash and delete anyway • esc: cancel"
+	}
+	return "tab: switch field • space/d: toggle delete • enter: stash • esc: cancel"
 }
 
 // executeStash performs the actual stash operation asynchronously.
@@ -2419,6 +4547,12 @@ func (m ImportBrowserModel) executeStash() (tea.Model, tea.Cmd) {
 	targetPath := m.stashTarget.Path
 	targetName := m.stashTarget.Name
 	deleteAfter := m.stashDeleteAfter
+	if deleteAfter {
+		if err := m.checkRemovable(targetPath); err != nil {
+			m.stashError = err.Error()
+			return m, nil
+		}
+	}
 
 	// Set loading state
 	m.loading = true
@@ -2427,6 +4561,8 @@ func (m ImportBrowserModel) executeStash() (tea.Model, tea.Cmd) {
 		m.loadingMessage = fmt.Sprintf("Stashing and deleting: %s...", targetName)
 	}
 	m.spinnerFrame = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	m.loadingCancel = cancel
 
 	// Return commands: one for the operation, one for spinner animation
 	operationCmd := func() tea.Msg {
@@ -2435,12 +4571,15 @@ func (m ImportBrowserModel) executeStash() (tea.Model, tea.Cmd) {
 			DeleteAfter: deleteAfter,
 		}
 
-		result, err := archive.StashFolder(cfg, targetPath, opts)
+		result, err := archive.StashFolderContext(ctx, cfg, targetPath, opts)
+		if errors.Is(err, context.Canceled) {
+			return operationResultMsg{Operation: "stash", Message: "Stash cancelled", Err: err}
+		}
 		if err != nil {
 			return operationResultMsg{
 				Operation: "stash",
 				Success:   false,
-				Message:   fmt.Sprintf("Stash failed: %v", err),
+				Message:   "Stash failed: " + errfmt.Message(err),
 				Err:       err,
 			}
 		}
@@ -2449,6 +4588,9 @@ func (m ImportBrowserModel) executeStash() (tea.Model, tea.Cmd) {
 		if result.Deleted {
 			msg += " (source deleted)"
 		}
+		if n := len(result.SecretsExcluded); n > 0 {
+			msg += fmt.Sprintf(" (%d likely secret file(s) left out)", n)
+		}
 		return operationResultMsg{
 			Operation: "stash",
 			Success:   true,
@@ -2459,74 +4601,521 @@ func (m ImportBrowserModel) executeStash() (tea.Model, tea.Cmd) {
 	return m, tea.Batch(operationCmd, m.spinnerTick())
 }
 
-// handleDeleteConfirmKeys handles keyboard input in delete/trash confirm states.
-func (m ImportBrowserModel) handleDeleteConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// parentOf returns the parent directory node of node, or the root if not found.
+func (m ImportBrowserModel) parentOf(node *sourceNode) *sourceNode {
+	var find func(n *sourceNode) *sourceNode
+	find = func(n *sourceNode) *sourceNode {
+		for _, child := range n.Children {
+			if child == node {
+				return n
+			}
+			if found := find(child); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	if parent := find(m.root); parent != nil {
+		return parent
+	}
+	return m.root
+}
+
+// startFileOp prompts for the name or destination of a file operation.
+func (m ImportBrowserModel) startFileOp(op fileOpKind, target *sourceNode, sources []*sourceNode) (tea.Model, tea.Cmd) {
+	input := textinput.New()
+	input.CharLimit = 256
+	input.Width = 50
+	switch op {
+	case fileOpMkdir:
+		input.Placeholder = "folder name"
+	case fileOpRename:
+		input.Placeholder = "new name"
+		input.SetValue(target.Name)
+	case fileOpMove:
+		input.Placeholder = "destination directory (relative to browse root)"
+	case fileOpFilter:
+		input.Placeholder = "text in entry names (empty shows all)"
+		input.SetValue(target.EntryFilter)
+	}
+
+	m.fileOp = op
+	m.fileOpTarget = target
+	m.fileOpSources = sources
+	m.fileOpInput = input
+	m.fileOpError = ""
+	m.state = StateFileOp
+	return m, m.fileOpInput.Focus()
+}
+
+// handleFileOpKeys handles keyboard input in the file operation prompt.
+func (m ImportBrowserModel) handleFileOpKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c":
 		m.result.Aborted = true
 		return m, tea.Quit
 
-	case "esc", "n", "N":
+	case "esc":
 		// Cancel, return to browse
 		m.state = StateBrowse
-		m.deleteTarget = nil
+		m.fileOpTarget = nil
+		m.fileOpSources = nil
+		m.fileOpError = ""
 		return m, nil
 
-	case "y", "Y", "enter":
-		// Confirm delete/trash
-		return m.executeDelete()
+	case "enter":
+		return m.executeFileOp()
 	}
 
-	return m, nil
+	var cmd tea.Cmd
+	m.fileOpInput, cmd = m.fileOpInput.Update(msg)
+	return m, cmd
 }
 
-// executeDelete performs the delete or trash operation.
-func (m ImportBrowserModel) executeDelete() (tea.Model, tea.Cmd) {
-	if m.deleteTarget == nil {
+// executeFileOp performs the prompted file operation and refreshes the tree.
+func (m ImportBrowserModel) executeFileOp() (tea.Model, tea.Cmd) {
+	value := strings.TrimSpace(m.fileOpInput.Value())
+
+	if m.fileOp == fileOpFilter {
+		m.setEntryFilter(m.fileOpTarget, value)
 		m.state = StateBrowse
-		return m, nil
+		m.fileOpTarget = nil
+		m.fileOpError = ""
+		return m, m.triggerTreeScans()
 	}
 
-	targetPath := m.deleteTarget.Path
-	targetName := m.deleteTarget.Name
-
-	var err error
-	if m.deleteIsTrash {
-		err = trashPath(targetPath)
-	} else {
-		err = os.RemoveAll(targetPath)
+	var (
+		newPath string
+		message string
+		err     error
+	)
+	switch m.fileOp {
+	case fileOpMkdir:
+		newPath, err = createFolder(m.fileOpTarget.Path, value)
+		message = fmt.Sprintf("Created folder: %s", value)
+	case fileOpRename:
+		if err = m.checkRemovable(m.fileOpTarget.Path); err == nil {
+			newPath, err = renameEntry(m.fileOpTarget.Path, value)
+		}
+		message = fmt.Sprintf("Renamed %s to %s", m.fileOpTarget.Name, value)
+	case fileOpMove:
+		dest := value
+		if dest != "" && !filepath.IsAbs(dest) {
+			dest = filepath.Join(m.rootPath, dest)
+		}
+		paths := make([]string, len(m.fileOpSources))
+		for i, node := range m.fileOpSources {
+			paths[i] = node.Path
+			if err == nil {
+				err = m.checkRemovable(node.Path)
+			}
+		}
+		if err == nil && m.cfg.InCodeRoot(dest) {
+			err = fmt.Errorf("cannot move into the code root; use co import")
+		}
+		var moved []string
+		if err == nil {
+			moved, err = moveEntries(paths, dest)
+		}
+		if len(moved) > 0 {
+			newPath = moved[0]
+		}
+		if err != nil && len(moved) > 0 {
+			// Partial move: return to the refreshed tree and report what happened
+			m.scroller.clearAllSelections()
+			m.refresh()
+			m.message = fmt.Sprintf("Moved %d of %d item(s): %v", len(moved), len(paths), err)
+			m.messageIsError = true
+			m.state = StateBrowse
+			m.fileOpSources = nil
+			m.fileOpError = ""
+			return m, nil
+		}
+		message = fmt.Sprintf("Moved %d item(s) to %s", len(moved), value)
 	}
 
 	if err != nil {
-		if m.deleteIsTrash {
-			m.message = fmt.Sprintf("Trash failed: %v", err)
-		} else {
-			m.message = fmt.Sprintf("Delete failed: %v", err)
-		}
-		m.messageIsError = true
-		m.state = StateBrowse
-		m.deleteTarget = nil
+		m.fileOpError = err.Error()
 		return m, nil
 	}
 
-	// Determine item type for message
-	itemType := "folder"
-	if m.deleteTarget != nil && !m.deleteTarget.IsDir {
-		itemType = "file"
+	if m.fileOp == fileOpMove {
+		m.scroller.clearAllSelections()
 	}
-
-	// Success - refresh tree and show message
 	m.refresh()
-	if m.deleteIsTrash {
-		m.message = fmt.Sprintf("Moved %s to trash: %s", itemType, targetName)
+	m.revealPath(newPath)
+	m.message = message
+	m.messageIsError = false
+	m.state = StateBrowse
+	m.fileOpTarget = nil
+	m.fileOpSources = nil
+	m.fileOpError = ""
+	return m, m.triggerSelectedScans()
+}
+
+// setEntryFilter lists only the entries of dir whose names contain filter,
+// reloading its children; an empty filter lists everything again.
+func (m *ImportBrowserModel) setEntryFilter(dir *sourceNode, filter string) {
+	filters := make(map[string]string)
+	collectEntryFilters(dir, filters)
+	expandedPaths := make(map[string]bool)
+	collectExpandedPathsRecursive(dir, expandedPaths)
+	expandedPaths[dir.Path] = true
+
+	dir.EntryFilter = filter
+	delete(filters, dir.Path)
+	dir.Children = nil
+	dir.IsExpanded = false
+	restoreExpandedPathsRecursive(dir, expandedPaths, filters, m.gitRootSet, m.showHidden)
+
+	m.sortTree()
+	m.applyFilter()
+	m.scroller.selectByPath(dir.Path)
+
+	rel, _ := filepath.Rel(m.rootPath, dir.Path)
+	if filter == "" {
+		m.message = fmt.Sprintf("Showing all entries in %s", rel)
 	} else {
-		m.message = fmt.Sprintf("Deleted %s: %s", itemType, targetName)
+		m.message = fmt.Sprintf("Showing entries in %s matching %q", rel, filter)
 	}
 	m.messageIsError = false
-	m.state = StateBrowse
-	m.deleteTarget = nil
+}
 
-	return m, nil
+// hiddenPolicy returns the hidden-file policy currently in effect.
+func (m ImportBrowserModel) hiddenPolicy() HiddenPolicy {
+	return HiddenPolicy{ShowAll: m.showHidden, AlwaysShow: m.alwaysShow}
+}
+
+// loadVisiblePages loads the next page of any directory whose "more entries"
+// placeholder has scrolled into view.
+func (m *ImportBrowserModel) loadVisiblePages() {
+	if m.scroller == nil || m.root == nil {
+		return
+	}
+	end := min(m.scroller.scrollOffset+m.scroller.height, len(m.scroller.flatTree))
+	loaded := false
+	for i := m.scroller.scrollOffset; i < end; i++ {
+		if dir := m.scroller.flatTree[i].moreOf; dir != nil {
+			loadMoreChildren(dir, m.gitRootSet)
+			loaded = true
+		}
+	}
+	if !loaded {
+		return
+	}
+
+	// A selected placeholder is replaced by the first newly loaded entry
+	selectedPath := ""
+	if node := m.scroller.selectedNode(); node != nil {
+		selectedPath = node.Path
+	}
+	m.sortTree()
+	m.applyFilter()
+	if selectedPath != "" {
+		m.scroller.selectByPath(selectedPath)
+	}
+}
+
+// revealPath expands the ancestors of path in the tree and moves the cursor to it.
+func (m *ImportBrowserModel) revealPath(path string) {
+	rel, err := filepath.Rel(m.rootPath, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+
+	node := m.root
+	parts := strings.Split(rel, string(filepath.Separator))
+	for _, part := range parts[:len(parts)-1] {
+		var next *sourceNode
+		node.expandNode(m.gitRootSet, m.showHidden)
+		for _, child := range node.Children {
+			if child.Name == part {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return
+		}
+		node = next
+	}
+	node.expandNode(m.gitRootSet, m.showHidden)
+
+	m.refreshTree()
+	m.scroller.selectByPath(path)
+}
+
+// validateEntryName checks that name is a single, non-empty path component.
+func validateEntryName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid name: %s", name)
+	}
+	return nil
+}
+
+// createFolder creates a new folder named name inside parent.
+func createFolder(parent, name string) (string, error) {
+	if err := validateEntryName(name); err != nil {
+		return "", err
+	}
+	path := filepath.Join(parent, name)
+	if err := os.Mkdir(path, fs.DirPerm()); err != nil {
+		if os.IsExist(err) {
+			return "", fmt.Errorf("already exists: %s", name)
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+// renameEntry renames path to newName within the same directory.
+func renameEntry(path, newName string) (string, error) {
+	if err := validateEntryName(newName); err != nil {
+		return "", err
+	}
+	newPath := filepath.Join(filepath.Dir(path), newName)
+	if newPath == path {
+		return path, nil
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return "", fmt.Errorf("already exists: %s", newName)
+	}
+	if err := os.Rename(path, newPath); err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
+
+// moveEntries moves each path into destDir, stopping at the first failure.
+// It returns the new paths of the entries that were moved.
+func moveEntries(paths []string, destDir string) ([]string, error) {
+	if destDir == "" {
+		return nil, fmt.Errorf("destination is required")
+	}
+	info, err := os.Stat(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("destination not found: %s", destDir)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("destination is not a directory: %s", destDir)
+	}
+
+	var moved []string
+	for _, src := range paths {
+		if destDir == src || strings.HasPrefix(destDir, src+string(filepath.Separator)) {
+			return moved, fmt.Errorf("cannot move %s into itself", filepath.Base(src))
+		}
+		dst := filepath.Join(destDir, filepath.Base(src))
+		if dst == src {
+			continue
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			return moved, fmt.Errorf("already exists in destination: %s", filepath.Base(src))
+		}
+		if err := os.Rename(src, dst); err != nil {
+			return moved, err
+		}
+		moved = append(moved, dst)
+	}
+	return moved, nil
+}
+
+// startFilePreview opens the quick-look preview and starts loading the file.
+func (m ImportBrowserModel) startFilePreview(path string) (tea.Model, tea.Cmd) {
+	m.state = StateFilePreview
+	m.previewFile = fileContentMsg{path: path}
+	m.previewLoading = true
+	m.previewViewport = viewport.New(0, 0)
+	m.resizePreview()
+	if fs.IsSecret(filepath.Base(path), false, m.cfg.GetSecretsConfig().Patterns) {
+		// Never read likely secrets into the terminal
+		m.previewFile.isSecret = true
+		m.previewLoading = false
+		m.previewViewport.SetContent(formatPreviewContent(m.previewFile))
+		return m, nil
+	}
+	m.previewViewport.SetContent("Loading...")
+	return m, func() tea.Msg {
+		return readFileForViewer(path)
+	}
+}
+
+// resizePreview fits the preview viewport to the window, leaving room for header and help.
+func (m *ImportBrowserModel) resizePreview() {
+	width := m.width - 4
+	if width < 20 {
+		width = 20
+	}
+	height := m.height - 6
+	if height < 5 {
+		height = 5
+	}
+	m.previewViewport.Width = width
+	m.previewViewport.Height = height
+}
+
+// handleFilePreviewKeys handles keyboard input in the file preview state.
+func (m ImportBrowserModel) handleFilePreviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc", "q", "p":
+		// Close preview, return to browse
+		m.state = StateBrowse
+		m.previewFile = fileContentMsg{}
+		m.previewLoading = false
+		return m, nil
+
+	case "j", "down":
+		m.previewViewport.LineDown(1)
+	case "k", "up":
+		m.previewViewport.LineUp(1)
+	case "d":
+		m.previewViewport.HalfViewDown()
+	case "u":
+		m.previewViewport.HalfViewUp()
+	case "g":
+		m.previewViewport.GotoTop()
+	case "G":
+		m.previewViewport.GotoBottom()
+	default:
+		m.previewViewport, cmd = m.previewViewport.Update(msg)
+	}
+
+	return m, cmd
+}
+
+// formatPreviewContent formats a loaded file for the preview viewport with line numbers.
+func formatPreviewContent(msg fileContentMsg) string {
+	if msg.err != nil {
+		return fmt.Sprintf("Error loading file:\n%s", msg.err)
+	}
+	if msg.isSecret {
+		return "Likely secret file\n\nContent is not shown."
+	}
+	if msg.isBinary {
+		return fmt.Sprintf("Binary file (%s)\n\nCannot display binary content.", humanizeFileSize(msg.size))
+	}
+	if msg.isLarge {
+		return fmt.Sprintf("File too large to display (%s)\n\nMaximum viewable size: %s", humanizeFileSize(msg.size), humanizeFileSize(maxFileViewerSize))
+	}
+	if msg.content == "" {
+		return "(empty file)"
+	}
+
+	lines := strings.Split(strings.TrimSuffix(msg.content, "\n"), "\n")
+	width := len(fmt.Sprintf("%d", len(lines)))
+	var sb strings.Builder
+	for i, line := range lines {
+		sb.WriteString(fmt.Sprintf("%*d │ %s\n", width, i+1, line))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// handleDeleteConfirmKeys handles keyboard input in delete/trash confirm states.
+// Like batch delete, a target holding uncommitted or unpushed work needs '!'.
+func (m ImportBrowserModel) handleDeleteConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc", "n", "N":
+		// Cancel, return to browse
+		m.state = StateBrowse
+		m.deleteTarget = nil
+		m.deleteRisks = nil
+		return m, nil
+
+	case "y", "Y", "enter":
+		// Confirm delete/trash; unsaved work requires '!'
+		if len(m.deleteRisks) > 0 {
+			return m, nil
+		}
+		return m.executeDelete()
+
+	case "!":
+		// Override safety check
+		return m.executeDelete()
+	}
+
+	return m, nil
+}
+
+// executeDelete performs the delete or trash operation.
+func (m ImportBrowserModel) executeDelete() (tea.Model, tea.Cmd) {
+	if m.deleteTarget == nil {
+		m.state = StateBrowse
+		return m, nil
+	}
+
+	targetPath := m.deleteTarget.Path
+	targetName := m.deleteTarget.Name
+
+	var quarantined *quarantine.Entry
+	err := m.checkRemovable(targetPath)
+	if err == nil && m.deleteIsTrash {
+		err = trashPath(targetPath)
+	} else if err == nil {
+		quarantined, err = quarantine.Delete(m.cfg, targetPath)
+	}
+
+	if err != nil {
+		if m.deleteIsTrash {
+			m.message = fmt.Sprintf("Trash failed: %v", err)
+		} else {
+			m.message = fmt.Sprintf("Delete failed: %v", err)
+		}
+		m.messageIsError = true
+		m.state = StateBrowse
+		m.deleteTarget = nil
+		m.deleteRisks = nil
+		return m, nil
+	}
+
+	// Determine item type for message
+	itemType := "folder"
+	if m.deleteTarget != nil && !m.deleteTarget.IsDir {
+		itemType = "file"
+	}
+
+	// Success - refresh tree and show message
+	m.refresh()
+	if m.deleteIsTrash {
+		m.message = fmt.Sprintf("Moved %s to trash: %s", itemType, targetName)
+	} else if quarantined != nil {
+		m.message = fmt.Sprintf("Moved %s to quarantine: %s (purged after %d days)", itemType, targetName, m.cfg.GetQuarantineConfig().Days)
+	} else {
+		m.message = fmt.Sprintf("Deleted %s: %s", itemType, targetName)
+	}
+	m.messageIsError = false
+	m.state = StateBrowse
+	m.deleteTarget = nil
+	m.deleteRisks = nil
+
+	return m, nil
+}
+
+// checkRemovable refuses to delete protected paths and anything inside the
+// code root, so a browser rooted above the code root cannot remove workspaces.
+func (m ImportBrowserModel) checkRemovable(path string) error {
+	if m.cfg == nil {
+		return nil
+	}
+	if err := m.cfg.CheckRemovable(path); err != nil {
+		return err
+	}
+	if m.cfg.InCodeRoot(path) {
+		return fmt.Errorf("refusing to remove %s: it is inside the code root; use co archive", path)
+	}
+	return nil
 }
 
 // trashPath moves a file or directory to the system trash.
@@ -2590,20 +5179,10 @@ func (m ImportBrowserModel) checkForExtraFiles() (tea.Model, tea.Cmd) {
 	}
 
 	// Get git roots under the import target
-	var gitRoots []string
-	if m.importTarget.IsGitRepo {
-		gitRoots = []string{m.importTarget.Path}
-	} else {
-		prefix := m.importTarget.Path + string(filepath.Separator)
-		for gitRoot := range m.gitRootSet {
-			if strings.HasPrefix(gitRoot, prefix) {
-				gitRoots = append(gitRoots, gitRoot)
-			}
-		}
-	}
+	gitRoots := m.gitRootsFor(m.importTarget)
 
 	// Find non-git items
-	items, err := FindNonGitItems(m.importTarget.Path, gitRoots)
+	items, err := FindNonGitItems(m.importTarget.Path, gitRoots, m.hiddenPolicy(), m.cfg.GetSecretsConfig().Patterns)
 	if err != nil || len(items) == 0 {
 		// No extra files or error finding them, skip to preview
 		m.extraFilesResult = ExtraFilesResult{} // Clear previous results
@@ -2616,13 +5195,215 @@ func (m ImportBrowserModel) checkForExtraFiles() (tea.Model, tea.Cmd) {
 	m.extraFilesSelected = 0
 	m.extraFilesScrollOffset = 0
 	m.extraFilesShowDest = false
-	m.extraFilesDestInput.SetValue("")
+	_, defaults := m.stickyDefaults()
+	m.extraFilesDestInput.SetValue(defaults.ExtraFilesDest)
 	m.extraFilesResult = ExtraFilesResult{}
 	m.state = StateExtraFiles
 
 	return m, nil
 }
 
+// splitItem is a subdirectory of the imported repo offered for splitting
+// into a repo of its own.
+type splitItem struct {
+	Dir     string // Slash-separated path relative to the repo
+	Name    string // Name of the repo it becomes
+	Checked bool
+}
+
+// splitCandidateDepth is how deep below the repo root subdirectories are
+// offered for splitting.
+const splitCandidateDepth = 2
+
+// startSplitSelect offers to split subdirectories of the imported folder into
+// repos of their own when the folder is a single repo, and otherwise moves on
+// to the extra files check.
+func (m ImportBrowserModel) startSplitSelect() (tea.Model, tea.Cmd) {
+	if m.importTarget == nil || !m.importTarget.IsGitRepo {
+		m.splitItems = nil
+		return m.checkForExtraFiles()
+	}
+
+	dirs, err := git.TrackedDirs(m.importTarget.Path, splitCandidateDepth)
+	if err != nil {
+		m.splitItems = nil
+		return m.checkForExtraFiles()
+	}
+	var items []splitItem
+	for _, dir := range dirs {
+		if strings.HasPrefix(dir, ".") || strings.Contains(dir, "/.") {
+			continue
+		}
+		items = append(items, splitItem{Dir: dir, Name: workspace.SplitRepoName(dir)})
+	}
+	if len(items) == 0 {
+		m.splitItems = nil
+		return m.checkForExtraFiles()
+	}
+
+	m.splitItems = items
+	m.splitSelected = 0
+	m.splitScrollOffset = 0
+	m.splitKeepSource = false
+	m.splitRenaming = false
+	m.splitError = ""
+	m.state = StateSplitSelect
+	return m, nil
+}
+
+// handleSplitSelectKeys handles keyboard input in the split selection state.
+func (m ImportBrowserModel) handleSplitSelectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.splitRenaming {
+		return m.handleSplitRenameKeys(msg)
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc", "q":
+		// Import the repo whole
+		for i := range m.splitItems {
+			m.splitItems[i].Checked = false
+		}
+		m.splitError = ""
+		return m.checkForExtraFiles()
+
+	case "enter":
+		if err := m.validateSplits(); err != nil {
+			m.splitError = err.Error()
+			return m, nil
+		}
+		m.splitError = ""
+		return m.checkForExtraFiles()
+
+	case "j", "down":
+		if m.splitSelected < len(m.splitItems)-1 {
+			m.splitSelected++
+			m.ensureSplitVisible()
+		}
+		return m, nil
+
+	case "k", "up":
+		if m.splitSelected > 0 {
+			m.splitSelected--
+			m.ensureSplitVisible()
+		}
+		return m, nil
+
+	case "g":
+		m.splitSelected = 0
+		m.splitScrollOffset = 0
+		return m, nil
+
+	case "G":
+		m.splitSelected = len(m.splitItems) - 1
+		m.ensureSplitVisible()
+		return m, nil
+
+	case " ":
+		m.splitItems[m.splitSelected].Checked = !m.splitItems[m.splitSelected].Checked
+		return m, nil
+
+	case "r":
+		m.splitRenaming = true
+		m.splitNameInput.SetValue(m.splitItems[m.splitSelected].Name)
+		m.splitNameInput.CursorEnd()
+		return m, m.splitNameInput.Focus()
+
+	case "m":
+		m.splitKeepSource = !m.splitKeepSource
+		return m, nil
+	}
+	return m, nil
+}
+
+// handleSplitRenameKeys handles keyboard input while renaming a split repo.
+func (m ImportBrowserModel) handleSplitRenameKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.result.Aborted = true
+		return m, tea.Quit
+
+	case "esc":
+		m.splitRenaming = false
+		m.splitNameInput.Blur()
+		return m, nil
+
+	case "enter":
+		name := strings.TrimSpace(m.splitNameInput.Value())
+		if err := validateEntryName(name); err != nil {
+			m.splitError = err.Error()
+			return m, nil
+		}
+		m.splitItems[m.splitSelected].Name = name
+		m.splitItems[m.splitSelected].Checked = true
+		m.splitRenaming = false
+		m.splitError = ""
+		m.splitNameInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.splitNameInput, cmd = m.splitNameInput.Update(msg)
+	return m, cmd
+}
+
+// validateSplits checks that the chosen splits become distinct repos.
+func (m ImportBrowserModel) validateSplits() error {
+	names := make(map[string]bool)
+	if m.splitKeepSource {
+		names[workspace.DeriveRepoName(m.importTarget.Path, m.importTarget.Path)] = true
+	}
+	for _, split := range m.selectedSplits() {
+		if names[split.Name] {
+			return fmt.Errorf("two repos would be named %s; press r to rename one", split.Name)
+		}
+		names[split.Name] = true
+	}
+	return nil
+}
+
+// selectedSplits returns the splits chosen for the imported repo.
+func (m ImportBrowserModel) selectedSplits() []workspace.RepoSplit {
+	if m.importTarget == nil {
+		return nil
+	}
+	var splits []workspace.RepoSplit
+	for _, item := range m.splitItems {
+		if item.Checked {
+			splits = append(splits, workspace.RepoSplit{Repo: m.importTarget.Path, Dir: item.Dir, Name: item.Name})
+		}
+	}
+	return splits
+}
+
+// importRoots returns the git roots the import moves into the workspace:
+// those under the import target, less a repo that is only split.
+func (m ImportBrowserModel) importRoots() []string {
+	if m.importTarget.IsGitRepo {
+		if len(m.selectedSplits()) > 0 && !m.splitKeepSource {
+			return nil
+		}
+		return []string{m.importTarget.Path}
+	}
+	return m.gitRootsFor(m.importTarget)
+}
+
+func (m *ImportBrowserModel) ensureSplitVisible() {
+	visibleLines := m.height - 12
+	if visibleLines < 5 {
+		visibleLines = 5
+	}
+
+	if m.splitSelected < m.splitScrollOffset {
+		m.splitScrollOffset = m.splitSelected
+	} else if m.splitSelected >= m.splitScrollOffset+visibleLines {
+		m.splitScrollOffset = m.splitSelected - visibleLines + 1
+	}
+}
+
 // handleExtraFilesKeys handles keyboard input in extra files selection state.
 func (m ImportBrowserModel) handleExtraFilesKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Handle destination prompt mode
@@ -2636,6 +5417,11 @@ func (m ImportBrowserModel) handleExtraFilesKeys(msg tea.KeyMsg) (tea.Model, tea
 		return m, tea.Quit
 
 	case "esc", "q":
+		if m.extraFilesVisual.active {
+			// Cancel visual mode
+			m.extraFilesVisual.active = false
+			return m, nil
+		}
 		// Skip extra files, go directly to preview
 		m.extraFilesResult.Confirmed = true
 		m.extraFilesResult.SelectedPaths = nil
@@ -2687,11 +5473,18 @@ func (m ImportBrowserModel) handleExtraFilesKeys(msg tea.KeyMsg) (tea.Model, tea
 		}
 		return m, nil
 
+	case "V":
+		// Start visual mode, or check the range and leave it
+		if !m.extraFilesVisual.active {
+			m.extraFilesVisual.start(m.extraFilesSelected)
+			return m, nil
+		}
+		checkVisualRange(m.extraFilesItems, &m.extraFilesVisual, m.extraFilesSelected)
+		return m, nil
+
 	case "a":
 		// Select all
-		for i := range m.extraFilesItems {
-			m.extraFilesItems[i].Checked = true
-		}
+		selectAllExtraFiles(m.extraFilesItems)
 		return m, nil
 
 	case "n":
@@ -2726,6 +5519,9 @@ func (m ImportBrowserModel) handleExtraFilesDestKeys(msg tea.KeyMsg) (tea.Model,
 		m.extraFilesResult.SelectedPaths = m.getExtraFilesSelectedPaths()
 		m.extraFilesResult.DestSubfolder = dest
 		m.extraFilesResult.Confirmed = true
+		m.defaults.ExtraFilesDest = dest
+		m.extraDuplicates, _ = workspace.FindDuplicateExtras(m.importTarget.Path, m.extraFilesResult.SelectedPaths, m.importRoots())
+		m.skipDuplicates = false
 
 		m.state = StateImportPreview
 		return m, nil
@@ -2763,10 +5559,66 @@ func (m *ImportBrowserModel) ensureExtraFilesVisible() {
 
 // refreshTree updates the flat tree after expand/collapse.
 func (m *ImportBrowserModel) refreshTree() {
+	m.sortTree()
 	flatTree := flattenSourceTree(m.root)
 	m.scroller.updateTree(flatTree)
 }
 
+// refreshExpanded updates the flat tree after the selected node was expanded
+// or collapsed. Only that node's rows change, so they are spliced in or out
+// rather than sorting and flattening the whole tree again.
+func (m *ImportBrowserModel) refreshExpanded(node *sourceNode) {
+	if m.filterText != "" || m.staleOnly || m.scroller.selectedNode() != node {
+		m.refreshTree()
+		return
+	}
+	if node.IsExpanded {
+		sortSourceTree(node, m.sortMode, m.nodeSize, m.nodeModTime)
+	}
+	m.scroller.replaceSubtree(m.scroller.selected)
+}
+
+// sortTree reorders loaded children according to the current sort mode.
+func (m *ImportBrowserModel) sortTree() {
+	if m.root == nil {
+		return
+	}
+	sortSourceTree(m.root, m.sortMode, m.nodeSize, m.nodeModTime)
+}
+
+// resortTree re-sorts the tree and keeps the cursor on the same path.
+func (m *ImportBrowserModel) resortTree() {
+	var selectedPath string
+	if node := m.scroller.selectedNode(); node != nil {
+		selectedPath = node.Path
+	}
+	m.sortTree()
+	m.applyFilter()
+	if selectedPath != "" {
+		m.scroller.selectByPath(selectedPath)
+	}
+}
+
+// nodeSize returns the size of a node and whether it is known.
+// Directory sizes come from the async size cache.
+func (m *ImportBrowserModel) nodeSize(node *sourceNode) (int64, bool) {
+	if !node.IsDir {
+		return node.Size, true
+	}
+	size, ok := m.sizeCache[node.Path]
+	return size, ok
+}
+
+// nodeModTime returns the last-modified time of a node and whether it is known.
+// Directory times come from the async mtime cache (newest file beneath the directory).
+func (m *ImportBrowserModel) nodeModTime(node *sourceNode) (time.Time, bool) {
+	if !node.IsDir {
+		return node.ModTime, !node.ModTime.IsZero()
+	}
+	t, ok := m.mtimeCache[node.Path]
+	return t, ok
+}
+
 // refresh rebuilds the entire tree from the filesystem.
 // It preserves the current selection position and expansion state.
 func (m *ImportBrowserModel) refresh() {
@@ -2776,10 +5628,14 @@ func (m *ImportBrowserModel) refresh() {
 		previousPath = node.Path
 	}
 
-	// Collect all expanded paths from the current tree
+	// Collect all expanded paths and folder filters from the current tree
 	expandedPaths := m.collectExpandedPaths()
+	filters := make(map[string]string)
+	if m.root != nil {
+		collectEntryFilters(m.root, filters)
+	}
 
-	root, err := buildSourceTree(m.rootPath, m.showHidden)
+	root, scan, err := scanSourceTree(m.rootPath, m.scanOpts, m.hiddenPolicy())
 	if err != nil {
 		m.message = fmt.Sprintf("Refresh failed: %v", err)
 		m.messageIsError = true
@@ -2787,16 +5643,23 @@ func (m *ImportBrowserModel) refresh() {
 	}
 
 	// Rebuild git root set
-	gitRoots, _ := git.FindGitRoots(m.rootPath)
 	m.gitRootSet = make(map[string]bool)
-	for _, r := range gitRoots {
+	for _, r := range scan.Roots {
 		m.gitRootSet[r] = true
 	}
+	m.indexGitRoots()
+	m.scanTruncated = make(map[string]bool)
+	for _, dir := range scan.Truncated {
+		m.scanTruncated[dir] = true
+	}
 
 	m.root = root
 
+	// Repos may have changed; collect their git info again
+	m.gitInfoCache = make(map[string]*git.RepoInfo)
+
 	// Restore expansion state to the new tree
-	m.restoreExpandedPaths(expandedPaths)
+	m.restoreExpandedPaths(expandedPaths, filters)
 
 	m.refreshTree()
 
@@ -2809,6 +5672,102 @@ func (m *ImportBrowserModel) refresh() {
 	m.messageIsError = false
 }
 
+// rescanSubtree reloads only the selected folder (or the folder containing the
+// selected file) and rescans it for git repositories, leaving the rest of the
+// tree untouched. The scan depth counts from that folder, so rescanning a
+// folder marked at the scan depth limit finds repos below it.
+func (m *ImportBrowserModel) rescanSubtree() {
+	node := m.scroller.selectedNode()
+	if node == nil {
+		return
+	}
+	if !node.IsDir {
+		node = m.parentOf(node)
+	}
+	if node == m.root {
+		m.refresh()
+		return
+	}
+	previousPath := m.scroller.selectedNode().Path
+
+	scan, err := git.ScanGitRoots(node.Path, m.scanOpts)
+	if err != nil {
+		m.message = fmt.Sprintf("Rescan failed: %v", err)
+		m.messageIsError = true
+		return
+	}
+
+	// Replace what the previous scan found below this folder
+	for r := range m.gitRootSet {
+		if config.IsWithin(r, node.Path) {
+			delete(m.gitRootSet, r)
+		}
+	}
+	for _, r := range scan.Roots {
+		m.gitRootSet[r] = true
+	}
+	for dir := range m.scanTruncated {
+		if config.IsWithin(dir, node.Path) {
+			delete(m.scanTruncated, dir)
+		}
+	}
+	for _, dir := range scan.Truncated {
+		m.scanTruncated[dir] = true
+	}
+
+	// Drop cached results for the subtree and for the folders above it,
+	// whose sizes and ages include it
+	stale := func(path string) bool {
+		return config.IsWithin(path, node.Path) || config.IsWithin(node.Path, path)
+	}
+	for path := range m.sizeCache {
+		if stale(path) {
+			delete(m.sizeCache, path)
+		}
+	}
+	if m.sizeStream != nil && stale(m.sizeStream.path) {
+		m.cancelSizeStream()
+	}
+	for path := range m.mtimeCache {
+		if stale(path) {
+			delete(m.mtimeCache, path)
+		}
+	}
+	for path := range m.gitInfoCache {
+		if config.IsWithin(path, node.Path) {
+			delete(m.gitInfoCache, path)
+		}
+	}
+
+	// Reload the subtree, keeping its expansion state and folder filters
+	expandedPaths := make(map[string]bool)
+	collectExpandedPathsRecursive(node, expandedPaths)
+	filters := make(map[string]string)
+	collectEntryFilters(node, filters)
+	node.Children = nil
+	node.IsExpanded = false
+	node.GitInfo = nil
+	node.IsGitRepo = m.gitRootSet[node.Path]
+	restoreExpandedPathsRecursive(node, expandedPaths, filters, m.gitRootSet, m.showHidden)
+
+	// Repos may have appeared or disappeared, so the markers on this folder
+	// and its ancestors can change
+	m.indexGitRoots()
+	for n := node; ; n = m.parentOf(n) {
+		n.HasGitChild = !n.IsGitRepo && len(m.gitIndex.below(n.Path)) > 0
+		if n == m.root {
+			break
+		}
+	}
+
+	m.refreshTree()
+	m.scroller.selectByPath(previousPath)
+
+	rel, _ := filepath.Rel(m.rootPath, node.Path)
+	m.message = fmt.Sprintf("Rescanned %s (%d repos)", rel, len(scan.Roots))
+	m.messageIsError = false
+}
+
 // collectExpandedPaths returns a set of paths for all expanded directories.
 func (m *ImportBrowserModel) collectExpandedPaths() map[string]bool {
 	expanded := make(map[string]bool)
@@ -2828,21 +5787,38 @@ func collectExpandedPathsRecursive(node *sourceNode, expanded map[string]bool) {
 	}
 }
 
-// restoreExpandedPaths expands directories in the new tree that were previously expanded.
-func (m *ImportBrowserModel) restoreExpandedPaths(expandedPaths map[string]bool) {
+// collectEntryFilters walks the loaded tree and collects folder entry filters.
+func collectEntryFilters(node *sourceNode, filters map[string]string) {
+	if node.EntryFilter != "" {
+		filters[node.Path] = node.EntryFilter
+	}
+	for _, child := range node.Children {
+		collectEntryFilters(child, filters)
+	}
+}
+
+// restoreExpandedPaths expands directories in the new tree that were previously
+// expanded and reapplies folder entry filters (filters may be nil).
+func (m *ImportBrowserModel) restoreExpandedPaths(expandedPaths map[string]bool, filters map[string]string) {
 	if m.root != nil {
-		restoreExpandedPathsRecursive(m.root, expandedPaths, m.gitRootSet, m.showHidden)
+		restoreExpandedPathsRecursive(m.root, expandedPaths, filters, m.gitRootSet, m.showHidden)
 	}
 }
 
 // restoreExpandedPathsRecursive walks the new tree and expands matching paths.
-func restoreExpandedPathsRecursive(node *sourceNode, expandedPaths map[string]bool, gitRootSet map[string]bool, showHidden bool) {
+func restoreExpandedPathsRecursive(node *sourceNode, expandedPaths map[string]bool, filters map[string]string, gitRootSet map[string]bool, showHidden bool) {
+	if filter := filters[node.Path]; node.IsDir && filter != "" && filter != node.EntryFilter {
+		// Children loaded without the filter (e.g. the root) are reloaded
+		node.EntryFilter = filter
+		node.Children = nil
+		node.IsExpanded = false
+	}
 	if node.IsDir && expandedPaths[node.Path] {
 		// Expand this node (load its children if not already loaded)
 		node.expandNode(gitRootSet, showHidden)
 		// Recursively restore children
 		for _, child := range node.Children {
-			restoreExpandedPathsRecursive(child, expandedPaths, gitRootSet, showHidden)
+			restoreExpandedPathsRecursive(child, expandedPaths, filters, gitRootSet, showHidden)
 		}
 	}
 }
@@ -2866,7 +5842,11 @@ func (m ImportBrowserModel) renderLoadingView() string {
 	sb.WriteString(fmt.Sprintf("  %s %s\n", spinner, m.loadingMessage))
 
 	sb.WriteString("\n\n")
-	sb.WriteString(ibHelpStyle.Render("Please wait..."))
+	if m.loadingCancel != nil {
+		sb.WriteString(ibHelpStyle.Render("Please wait... (esc to cancel)"))
+	} else {
+		sb.WriteString(ibHelpStyle.Render("Please wait..."))
+	}
 
 	return sb.String()
 }
@@ -2885,10 +5865,14 @@ func (m ImportBrowserModel) View() string {
 	switch m.state {
 	case StateImportConfig:
 		return m.renderImportConfigView()
+	case StateWorkspaceConflict:
+		return m.renderWorkspaceConflictView()
 	case StateTemplateSelect:
 		return m.renderTemplateSelectView()
 	case StateTemplateVars:
 		return m.renderTemplateVarsView()
+	case StateSplitSelect:
+		return m.renderSplitSelectView()
 	case StateImportPreview:
 		return m.renderImportPreviewView()
 	case StateStashConfirm:
@@ -2897,6 +5881,8 @@ func (m ImportBrowserModel) View() string {
 		return m.renderExtraFilesView()
 	case StatePostImport:
 		return m.renderPostImportView()
+	case StateOpenWorkspace:
+		return m.renderOpenWorkspaceView()
 	case StateAddToSelect:
 		return m.renderAddToSelectView()
 	case StateBatchImportConfirm:
@@ -2911,10 +5897,26 @@ func (m ImportBrowserModel) View() string {
 		return m.renderBatchStashExecuteView()
 	case StateBatchStashSummary:
 		return m.renderBatchStashSummaryView()
+	case StateBatchAddToConfirm:
+		return m.renderBatchAddToConfirmView()
+	case StateBatchAddToExecute:
+		return m.renderBatchAddToExecuteView()
+	case StateBatchAddToSummary:
+		return m.renderBatchAddToSummaryView()
+	case StateBatchDeleteConfirm:
+		return m.renderBatchDeleteConfirmView()
+	case StateBatchDeleteExecute:
+		return m.renderBatchDeleteExecuteView()
+	case StateBatchDeleteSummary:
+		return m.renderBatchDeleteSummaryView()
 	case StateDeleteConfirm:
 		return m.renderDeleteConfirmView()
 	case StateTrashConfirm:
 		return m.renderTrashConfirmView()
+	case StateFilePreview:
+		return m.renderFilePreviewView()
+	case StateFileOp:
+		return m.renderFileOpView()
 	default:
 		return m.renderBrowseView()
 	}
@@ -2963,16 +5965,9 @@ func (m ImportBrowserModel) renderImportConfigView() string {
 		sb.WriteString(fmt.Sprintf("Source: %s\n", m.importTarget.Path))
 
 		// Count git repos in target
-		repoCount := 0
-		if m.importTarget.IsGitRepo {
-			repoCount = 1
-		} else {
-			prefix := m.importTarget.Path + string(filepath.Separator)
-			for gitRoot := range m.gitRootSet {
-				if strings.HasPrefix(gitRoot, prefix) {
-					repoCount++
-				}
-			}
+		repoCount := 1
+		if !m.importTarget.IsGitRepo {
+			repoCount = len(m.gitRoots().below(m.importTarget.Path))
 		}
 
 		if repoCount == 0 {
@@ -2990,6 +5985,11 @@ func (m ImportBrowserModel) renderImportConfigView() string {
 		ownerLabel = ibSelectedStyle.Render(ownerLabel)
 	}
 	sb.WriteString(ownerLabel + m.ownerInput.View() + "\n")
+	if m.configFocusIdx == 0 {
+		if matches := renderOwnerMatches(&m.ownerInput); matches != "" {
+			sb.WriteString(ibHelpStyle.Render(matches) + "\n")
+		}
+	}
 
 	// Project input
 	projectLabel := "Project: "
@@ -2998,11 +5998,41 @@ func (m ImportBrowserModel) renderImportConfigView() string {
 	}
 	sb.WriteString(projectLabel + m.projectInput.View() + "\n")
 
+	// Explain where the suggested project came from
+	if m.configPackageFile != "" {
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Suggested from %q in %s (folder name %q)", m.configPackageName, m.configPackageFile, m.importTarget.Name)) + "\n")
+	} else if m.importTarget != nil {
+		switch suggested := sanitizeForSlug(m.cfg, m.importTarget.Name); suggested {
+		case m.importTarget.Name:
+		case "":
+			sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Folder name %q has no slug-safe characters; enter a project name", m.importTarget.Name)) + "\n")
+		default:
+			sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Suggested from folder name %q", m.importTarget.Name)) + "\n")
+		}
+	}
+
 	// Show resulting slug
 	owner := strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
 	project := strings.ToLower(strings.TrimSpace(m.projectInput.Value()))
 	if owner != "" && project != "" {
-		sb.WriteString(fmt.Sprintf("\nWorkspace: %s--%s\n", owner, project))
+		sb.WriteString(fmt.Sprintf("\nWorkspace: %s\n", workspace.SchemeFor(m.cfg).Format(owner, project)))
+	}
+
+	// Suggest free names before enter runs into the existing workspace
+	if len(m.configSuggestions) > 0 {
+		if !slices.Contains(m.configSuggestions, project) {
+			sb.WriteString(ibErrorStyle.Render("This workspace already exists") + "\n")
+		}
+		sb.WriteString(ibHelpStyle.Render("Suggestions: "+strings.Join(m.configSuggestions, ", ")+" (ctrl+n: use next)") + "\n")
+	}
+	if len(m.configSimilar) > 0 {
+		similar := m.configSimilar
+		more := ""
+		if len(similar) > maxSimilarShown {
+			more = fmt.Sprintf(" (+%d more)", len(similar)-maxSimilarShown)
+			similar = similar[:maxSimilarShown]
+		}
+		sb.WriteString(ibHelpStyle.Render("Similar: "+strings.Join(similar, ", ")+more) + "\n")
 	}
 
 	// Error
@@ -3011,7 +6041,38 @@ func (m ImportBrowserModel) renderImportConfigView() string {
 	}
 
 	// Help
-	sb.WriteString("\n" + ibHelpStyle.Render("tab: next field • enter: confirm • esc: cancel"))
+	help := "tab: next field • enter: confirm • esc: cancel"
+	if len(m.configSuggestions) > 0 {
+		help = "tab: next field • ctrl+n: suggested name • enter: confirm • esc: cancel"
+	}
+	sb.WriteString("\n" + ibHelpStyle.Render(help))
+
+	return sb.String()
+}
+
+// renderWorkspaceConflictView renders the dialog shown when the workspace to
+// import into already exists.
+func (m ImportBrowserModel) renderWorkspaceConflictView() string {
+	var sb strings.Builder
+
+	sb.WriteString(ibHeaderStyle.Render("Workspace Already Exists") + "\n\n")
+	sb.WriteString(fmt.Sprintf("Workspace: %s\n", m.conflictSlug))
+	sb.WriteString(fmt.Sprintf("Path: %s\n\n", m.cfg.WorkspacePath(m.conflictSlug)))
+	if !workspace.Exists(m.cfg, m.conflictSlug) {
+		sb.WriteString(ibHelpStyle.Render("(the path is taken by a file, not a workspace)") + "\n\n")
+	}
+
+	sb.WriteString("What would you like to do?\n\n")
+	for i, option := range m.conflictOptions() {
+		line := fmt.Sprintf("[%d] %s", i+1, m.conflictLabel(option))
+		if i == m.conflictOption {
+			sb.WriteString(ibSelectedStyle.Render("> "+line) + "\n")
+		} else {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+
+	sb.WriteString("\n" + ibHelpStyle.Render("j/k: select • 1/2/3: quick select • enter: confirm • esc: edit name"))
 
 	return sb.String()
 }
@@ -3024,7 +6085,11 @@ func (m ImportBrowserModel) renderTemplateSelectView() string {
 	sb.WriteString(ibHelpStyle.Render("Choose a template to apply to the workspace, or skip.") + "\n\n")
 
 	// Show workspace info
-	sb.WriteString(fmt.Sprintf("Workspace: %s\n\n", m.result.WorkspaceSlug))
+	if m.templateForBatch {
+		sb.WriteString(fmt.Sprintf("Workspaces: %d (batch import)\n\n", len(m.batchImportTargets)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Workspace: %s\n\n", m.result.WorkspaceSlug))
+	}
 
 	// Calculate visible area
 	visibleLines := m.height - 12
@@ -3035,6 +6100,8 @@ func (m ImportBrowserModel) renderTemplateSelectView() string {
 	// Total items = "No template" + actual templates
 	totalItems := 1 + len(m.templateInfos)
 
+	var list strings.Builder
+
 	// Render items
 	startIdx := m.templateScrollOffset
 	endIdx := startIdx + visibleLines
@@ -3054,12 +6121,25 @@ func (m ImportBrowserModel) renderTemplateSelectView() string {
 			tmpl := m.templateInfos[i-1]
 			line = m.renderTemplateItem(tmpl.Name, tmpl.Description, tmpl.VarCount, tmpl.RepoCount, isSelected)
 		}
-		sb.WriteString(line + "\n")
+		list.WriteString(line + "\n")
 	}
 
 	// Scroll indicator
 	if totalItems > visibleLines {
-		sb.WriteString(fmt.Sprintf("\n(%d/%d)", m.templateSelected+1, totalItems))
+		list.WriteString(fmt.Sprintf("\n(%d/%d)", m.templateSelected+1, totalItems))
+	}
+
+	// Preview of the selected template beside the list, or below it when
+	// the terminal is narrow
+	if m.width >= 80 {
+		listWidth := m.width/2 - 2
+		previewWidth := m.width - listWidth - 4
+		previewPane := ibPaneStyle.Width(previewWidth).Height(visibleLines)
+		sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(listWidth).Render(list.String()),
+			previewPane.Render(m.renderTemplatePreview(visibleLines))))
+	} else {
+		sb.WriteString(list.String() + "\n\n" + m.renderTemplatePreview(visibleLines))
 	}
 
 	// Help
@@ -3068,6 +6148,91 @@ func (m ImportBrowserModel) renderTemplateSelectView() string {
 	return sb.String()
 }
 
+// templatePreview is what a template adds to a workspace.
+type templatePreview struct {
+	files []template.OutputMapping // Files created, global files included
+	repos []template.TemplateRepo  // Repos cloned or initialized
+	err   error
+}
+
+// templatePreviewFor returns the preview of the template name, loading it
+// the first time it is asked for.
+func (m ImportBrowserModel) templatePreviewFor(name string) *templatePreview {
+	if p, ok := m.templatePreviews[name]; ok {
+		return p
+	}
+	p := &templatePreview{}
+	tmpl, dir, err := template.LoadTemplateMulti(m.cfg.AllTemplatesDirs(), name)
+	if err == nil {
+		p.repos = tmpl.Repos
+		p.files, err = template.BuildOutputMapping(tmpl, m.cfg.AllTemplatesDirs(), filepath.Join(dir, name))
+	}
+	p.err = err
+	if m.templatePreviews != nil {
+		m.templatePreviews[name] = p
+	}
+	return p
+}
+
+// renderTemplatePreview lists the files and repos the selected template
+// would add to the workspace, in at most height lines.
+func (m ImportBrowserModel) renderTemplatePreview(height int) string {
+	var sb strings.Builder
+	if m.templateSelected == 0 || m.templateSelected > len(m.templateInfos) {
+		sb.WriteString(ibHeaderStyle.Render("No template") + "\n\n")
+		sb.WriteString(ibHelpStyle.Render("Only the imported repos and files go into the workspace."))
+		return sb.String()
+	}
+
+	name := m.templateInfos[m.templateSelected-1].Name
+	sb.WriteString(ibHeaderStyle.Render(name) + "\n\n")
+	p := m.templatePreviewFor(name)
+	if p.err != nil {
+		sb.WriteString(ibErrorStyle.Render("Preview unavailable: " + errfmt.Message(p.err)))
+		return sb.String()
+	}
+
+	// Repos are few and say the most, so they come first and always fit
+	lines := []string{}
+	if len(p.repos) > 0 {
+		lines = append(lines, fmt.Sprintf("Repos (%d):", len(p.repos)))
+		for _, r := range p.repos {
+			switch {
+			case r.CloneURL != "":
+				lines = append(lines, fmt.Sprintf("  repos/%s ← %s", r.Name, r.CloneURL))
+			case r.Init:
+				lines = append(lines, fmt.Sprintf("  repos/%s (new repo)", r.Name))
+			default:
+				lines = append(lines, "  repos/"+r.Name)
+			}
+		}
+		lines = append(lines, "")
+	}
+
+	if len(p.files) == 0 {
+		lines = append(lines, "Files: none")
+	} else {
+		lines = append(lines, fmt.Sprintf("Files (%d):", len(p.files)))
+		room := height - 2 - len(lines) - 1
+		for i, f := range p.files {
+			if i == room && i < len(p.files)-1 {
+				lines = append(lines, ibHelpStyle.Render(fmt.Sprintf("  ... and %d more", len(p.files)-i)))
+				break
+			}
+			line := "  " + f.OutputPath
+			switch {
+			case f.IsOverride:
+				line += ibHelpStyle.Render(" (overrides global)")
+			case f.OriginType == template.OriginGlobal:
+				line += ibHelpStyle.Render(" (global)")
+			}
+			lines = append(lines, line)
+		}
+	}
+	sb.WriteString(strings.Join(lines, "\n"))
+	return sb.String()
+}
+
 // renderTemplateItem renders a single template item in the selection list.
 func (m ImportBrowserModel) renderTemplateItem(name, description string, varCount, repoCount int, isSelected bool) string {
 	prefix := "  "
@@ -3112,7 +6277,11 @@ func (m ImportBrowserModel) renderTemplateVarsView() string {
 	}
 
 	// Show workspace and template context
-	sb.WriteString(fmt.Sprintf("Workspace: %s\n", m.result.WorkspaceSlug))
+	if m.templateForBatch {
+		sb.WriteString(fmt.Sprintf("Workspaces: %d (batch import, shared values)\n", len(m.batchImportTargets)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Workspace: %s\n", m.result.WorkspaceSlug))
+	}
 	sb.WriteString(fmt.Sprintf("Template:  %s\n\n", m.selectedTemplate))
 
 	// Check bounds
@@ -3229,16 +6398,25 @@ func (m ImportBrowserModel) renderStashConfirmView() string {
 	}
 	sb.WriteString(deleteLabel + deleteValue + "\n")
 
-	// Preview archive name
+	// Preview archive name, filled in from the stash name pattern
 	name := strings.TrimSpace(m.stashNameInput.Value())
 	if name == "" && m.stashTarget != nil {
-		name = archive.SanitizeArchiveName(m.stashTarget.Name)
+		name = m.stashTarget.Name
 	}
+	pattern := m.cfg.GetArchiveConfig().StashName
+	name = archive.FormatStashName(pattern, name, m.stashSource, time.Now())
 	sb.WriteString(fmt.Sprintf("\nArchive: %s--<timestamp>--stash.tar.gz\n", name))
+	if pattern != "{name}" {
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("(name pattern: %s)", pattern)) + "\n")
+	}
 
 	// Warning if deleting
 	if m.stashDeleteAfter {
 		sb.WriteString("\n" + ibErrorStyle.Render("WARNING: Source folder will be DELETED after archiving!") + "\n")
+		if len(m.stashRisks) > 0 {
+			sb.WriteString("\n")
+			m.renderDeleteRisks(&sb, m.stashRisks)
+		}
 	}
 
 	// Error
@@ -3247,7 +6425,73 @@ func (m ImportBrowserModel) renderStashConfirmView() string {
 	}
 
 	// Help
-	sb.WriteString("\n" + ibHelpStyle.Render("tab: switch field • space/d: toggle delete • enter: stash • esc: cancel"))
+	sb.WriteString("\n" + ibHelpStyle.Render(m.stashHelp()))
+
+	return sb.String()
+}
+
+// renderSplitSelectView renders the choice of subdirectories to split into
+// repos of their own.
+func (m ImportBrowserModel) renderSplitSelectView() string {
+	var sb strings.Builder
+
+	sb.WriteString(ibHeaderStyle.Render("Split Repo") + "\n")
+	sb.WriteString(ibHelpStyle.Render("Select subdirectories to split, with their history, into repos of their own. Skip to import the repo whole.") + "\n\n")
+
+	visibleLines := m.height - 12
+	if visibleLines < 5 {
+		visibleLines = 5
+	}
+	startIdx := m.splitScrollOffset
+	endIdx := min(startIdx+visibleLines, len(m.splitItems))
+	for i := startIdx; i < endIdx; i++ {
+		item := m.splitItems[i]
+		checkbox := "[ ] "
+		if item.Checked {
+			checkbox = "[x] "
+		}
+		line := checkbox + ibDirStyle.Render(item.Dir+"/")
+		if item.Checked || i == m.splitSelected {
+			line += " → repos/" + item.Name
+		}
+		switch {
+		case i == m.splitSelected:
+			line = ibSelectedStyle.Render(line)
+		case item.Checked:
+			line = ibSuccessStyle.Render(line)
+		default:
+			line = ibHelpStyle.Render(line)
+		}
+		sb.WriteString(line + "\n")
+	}
+	if len(m.splitItems) > visibleLines {
+		sb.WriteString(fmt.Sprintf("\n(%d/%d)", m.splitSelected+1, len(m.splitItems)))
+	}
+
+	selected := len(m.selectedSplits())
+	sb.WriteString(fmt.Sprintf("\n\n%d of %d selected", selected, len(m.splitItems)))
+	if selected > 0 {
+		if m.splitKeepSource {
+			sb.WriteString(" • the repo itself is imported too")
+		} else {
+			sb.WriteString(" • the repo itself stays in place")
+		}
+	}
+
+	if m.splitRenaming {
+		sb.WriteString("\n\nRepo name for " + m.splitItems[m.splitSelected].Dir + ":\n")
+		sb.WriteString(m.splitNameInput.View())
+	}
+	if m.splitError != "" {
+		sb.WriteString("\n" + ibErrorStyle.Render("Error: "+m.splitError))
+	}
+
+	if m.splitRenaming {
+		sb.WriteString("\n\n" + ibHelpStyle.Render("enter: confirm name • esc: cancel"))
+	} else {
+		sb.WriteString("\n\n" + ibHelpStyle.Render("j/k: navigate • space: toggle • r: rename • m: also import the repo itself"))
+		sb.WriteString("\n" + ibHelpStyle.Render("enter: continue • q/esc: skip splitting"))
+	}
 
 	return sb.String()
 }
@@ -3279,7 +6523,8 @@ func (m ImportBrowserModel) renderExtraFilesView() string {
 
 	for i := startIdx; i < endIdx; i++ {
 		item := m.extraFilesItems[i]
-		line := m.renderExtraFileItem(item, i == m.extraFilesSelected)
+		inRange := m.extraFilesVisual.contains(i, m.extraFilesSelected, len(m.extraFilesItems))
+		line := m.renderExtraFileItem(item, i == m.extraFilesSelected, inRange)
 		sb.WriteString(line + "\n")
 	}
 
@@ -3296,9 +6541,12 @@ func (m ImportBrowserModel) renderExtraFilesView() string {
 		}
 	}
 	sb.WriteString(fmt.Sprintf("\n\n%d of %d selected", selectedCount, len(m.extraFilesItems)))
+	if warning := secretsWarning(m.extraFilesItems); warning != "" {
+		sb.WriteString("\n" + efPickerSecretStyle.Render(warning))
+	}
 
 	// Help
-	sb.WriteString("\n\n" + ibHelpStyle.Render("j/k: navigate • space: toggle • a: all • n: none"))
+	sb.WriteString("\n\n" + ibHelpStyle.Render("j/k: navigate • space: toggle • V: range • a: all but secrets • n: none"))
 	sb.WriteString("\n" + ibHelpStyle.Render("enter: continue • q/esc: skip extra files"))
 
 	return sb.String()
@@ -3328,11 +6576,13 @@ func (m ImportBrowserModel) renderExtraFilesDestView() string {
 }
 
 // renderExtraFileItem renders a single extra file item.
-func (m ImportBrowserModel) renderExtraFileItem(item extraFileItem, isSelected bool) string {
+func (m ImportBrowserModel) renderExtraFileItem(item extraFileItem, isSelected, inVisualRange bool) string {
 	// Checkbox
 	var checkbox string
 	if item.Checked {
 		checkbox = "[x] "
+	} else if inVisualRange {
+		checkbox = "[◆] "
 	} else {
 		checkbox = "[ ] "
 	}
@@ -3343,18 +6593,58 @@ func (m ImportBrowserModel) renderExtraFileItem(item extraFileItem, isSelected b
 		name = ibDirStyle.Render(name + "/")
 	}
 
-	line := checkbox + name
+	line := checkbox + name
+
+	// Apply styling
+	if isSelected {
+		line = ibSelectedStyle.Render(line)
+	} else if item.Checked {
+		line = ibSuccessStyle.Render(line)
+	} else {
+		line = ibHelpStyle.Render(line)
+	}
+
+	return line + secretLabel(item)
+}
+
+// renderOpenWorkspaceView renders the dialog offered after an import.
+func (m ImportBrowserModel) renderOpenWorkspaceView() string {
+	var sb strings.Builder
+
+	sb.WriteString(ibHeaderStyle.Render("Open Workspace") + "\n\n")
+	if m.message != "" {
+		style := ibSuccessStyle
+		if m.messageIsError {
+			style = ibErrorStyle
+		}
+		sb.WriteString(style.Render(m.message) + "\n\n")
+	}
+
+	slug := m.openSlugs[m.openIdx]
+	if len(m.openSlugs) > 1 {
+		sb.WriteString(fmt.Sprintf("Workspace: %s (%d of %d)\n", slug, m.openIdx+1, len(m.openSlugs)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Workspace: %s\n", slug))
+	}
+	sb.WriteString(fmt.Sprintf("Path: %s\n\n", m.cfg.WorkspacePath(slug)))
+
+	sb.WriteString("What would you like to do?\n\n")
+	for i, option := range m.openWorkspaceOptions() {
+		line := fmt.Sprintf("[%d] %s", i+1, m.openWorkspaceLabel(option))
+		if i == m.openOption {
+			sb.WriteString(ibSelectedStyle.Render("> "+line) + "\n")
+		} else {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
 
-	// Apply styling
-	if isSelected {
-		line = ibSelectedStyle.Render(line)
-	} else if item.Checked {
-		line = ibSuccessStyle.Render(line)
-	} else {
-		line = ibHelpStyle.Render(line)
+	help := "j/k: select • 1-4: quick select • enter: confirm • esc: back to browse"
+	if len(m.openSlugs) > 1 {
+		help = "h/l: workspace • " + help
 	}
+	sb.WriteString("\n" + ibHelpStyle.Render(help))
 
-	return line
+	return sb.String()
 }
 
 // renderPostImportView renders the post-import options view.
@@ -3426,23 +6716,20 @@ func (m ImportBrowserModel) renderAddToSelectView() string {
 	var sb strings.Builder
 
 	sb.WriteString(ibHeaderStyle.Render("Add to Existing Workspace") + "\n")
-	sb.WriteString(ibHelpStyle.Render("Select a workspace to add the folder to.") + "\n\n")
+	if len(m.batchAddToTargets) > 0 {
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Select a workspace to add %d folders to.", len(m.batchAddToTargets))) + "\n\n")
+	} else {
+		sb.WriteString(ibHelpStyle.Render("Select a workspace to add the folder to.") + "\n\n")
+	}
 
 	// Show source info
 	if m.importTarget != nil {
 		sb.WriteString(fmt.Sprintf("Source: %s\n", m.importTarget.Path))
 
 		// Count repos
-		repoCount := 0
-		if m.importTarget.IsGitRepo {
-			repoCount = 1
-		} else {
-			prefix := m.importTarget.Path + string(filepath.Separator)
-			for gitRoot := range m.gitRootSet {
-				if strings.HasPrefix(gitRoot, prefix) {
-					repoCount++
-				}
-			}
+		repoCount := 1
+		if !m.importTarget.IsGitRepo {
+			repoCount = len(m.gitRoots().below(m.importTarget.Path))
 		}
 		if repoCount > 0 {
 			sb.WriteString(fmt.Sprintf("Repos:  %d\n", repoCount))
@@ -3451,6 +6738,12 @@ func (m ImportBrowserModel) renderAddToSelectView() string {
 	}
 
 	sb.WriteString("Workspaces:\n")
+	if m.addToFiltering || m.addToFilter.Value() != "" {
+		sb.WriteString("Filter: " + m.addToFilter.View() + "\n")
+	}
+	if len(m.addToWorkspaces) == 0 {
+		sb.WriteString(ibHelpStyle.Render("  No workspace matches") + "\n")
+	}
 
 	// Calculate visible area
 	visibleLines := m.height - 14
@@ -3467,6 +6760,9 @@ func (m ImportBrowserModel) renderAddToSelectView() string {
 
 	for i := startIdx; i < endIdx; i++ {
 		ws := m.addToWorkspaces[i]
+		if aliases := m.cfg.AliasesOf(ws); len(aliases) > 0 {
+			ws += " (" + strings.Join(aliases, ", ") + ")"
+		}
 		prefix := "  "
 		if i == m.addToSelected {
 			prefix = "> "
@@ -3482,7 +6778,7 @@ func (m ImportBrowserModel) renderAddToSelectView() string {
 	}
 
 	// Help
-	sb.WriteString("\n\n" + ibHelpStyle.Render("j/k: navigate • g/G: top/bottom • enter: select • esc: cancel"))
+	sb.WriteString("\n\n" + ibHelpStyle.Render("j/k: navigate • g/G: top/bottom • /: filter by slug or alias • enter: select • esc: cancel"))
 
 	return sb.String()
 }
@@ -3494,29 +6790,78 @@ func (m ImportBrowserModel) renderBatchImportConfirmView() string {
 	sb.WriteString(ibHeaderStyle.Render("Batch Import") + "\n")
 	sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Import %d folders as separate workspaces", len(m.batchImportTargets))) + "\n\n")
 
-	// List folders to import
+	// List folders to import, scrolled to keep the focused one in view
+	owner := strings.TrimSpace(m.ownerInput.Value())
+	scheme := workspace.SchemeFor(m.cfg)
+	uses := make(map[string]int)
+	for i := range m.batchImportTargets {
+		uses[strings.ToLower(strings.TrimSpace(m.batchProject(i)))]++
+	}
 	sb.WriteString("Folders to import:\n")
 	maxShow := 10
-	for i, node := range m.batchImportTargets {
-		if i >= maxShow {
-			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.batchImportTargets)-maxShow))
+	start := max(0, m.batchFocus-maxShow)
+	if start > 0 {
+		sb.WriteString(fmt.Sprintf("  ... %d more above\n", start))
+	}
+	for i := start; i < len(m.batchImportTargets); i++ {
+		node := m.batchImportTargets[i]
+		if i >= start+maxShow {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.batchImportTargets)-i))
 			break
 		}
-		sb.WriteString(fmt.Sprintf("  • %s\n", node.Name))
+		if m.batchFocus == i+1 {
+			sb.WriteString(fmt.Sprintf("%s %s\n", ibSelectedStyle.Render("> "+node.Name+" →"), m.projectInput.View()))
+			continue
+		}
+		project := strings.ToLower(strings.TrimSpace(m.batchProject(i)))
+		var note string
+		switch {
+		case project == "":
+			note = ibErrorStyle.Render("(no project name, skipped)")
+		case uses[project] > 1:
+			note = ibErrorStyle.Render("(duplicate)")
+		case owner != "" && workspace.Exists(m.cfg, scheme.Format(owner, project)):
+			note = ibErrorStyle.Render("(exists)")
+		}
+		line := fmt.Sprintf("  • %s", node.Name)
+		if project != "" && project != node.Name {
+			line += " → " + project
+		}
+		if note != "" {
+			line += " " + note
+		}
+		sb.WriteString(line + "\n")
 	}
 	sb.WriteString("\n")
 
 	// Owner input (shared for all)
 	sb.WriteString("Owner (for all workspaces):\n")
 	sb.WriteString(m.ownerInput.View() + "\n")
+	if matches := renderOwnerMatches(&m.ownerInput); matches != "" {
+		sb.WriteString(ibHelpStyle.Render(matches) + "\n")
+	}
 
 	// Show example slug
-	if len(m.batchImportTargets) > 0 {
-		owner := strings.TrimSpace(m.ownerInput.Value())
-		if owner != "" {
-			example := fmt.Sprintf("%s--%s", owner, sanitizeForSlug(m.batchImportTargets[0].Name))
-			sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Example: %s", example)) + "\n")
+	if len(m.batchImportTargets) > 0 && owner != "" {
+		example := scheme.Format(owner, m.batchProject(0))
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Example: %s", example)) + "\n")
+	}
+
+	// Template applied to every workspace
+	if m.selectedTemplate != "" {
+		sb.WriteString(fmt.Sprintf("\nTemplate: %s\n", ibSuccessStyle.Render(m.selectedTemplate)))
+		var names []string
+		for k := range m.templateVarValues {
+			if k != "owner" && k != "project" {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("  %s = %s", k, m.templateVarValues[k])) + "\n")
 		}
+	} else {
+		sb.WriteString("\nTemplate: none\n")
 	}
 
 	// Error message
@@ -3525,7 +6870,7 @@ func (m ImportBrowserModel) renderBatchImportConfirmView() string {
 	}
 
 	// Help
-	sb.WriteString("\n" + ibHelpStyle.Render("enter: start import • esc: cancel"))
+	sb.WriteString("\n" + ibHelpStyle.Render("↑/↓: owner/project names • enter: start import • tab: choose template • esc: cancel"))
 
 	return sb.String()
 }
@@ -3547,15 +6892,40 @@ func (m ImportBrowserModel) renderBatchImportExecuteView() string {
 	if m.batchImportCurrent < len(m.batchImportTargets) {
 		sb.WriteString(fmt.Sprintf("Current: %s\n", m.batchImportTargets[m.batchImportCurrent].Name))
 	}
+	sb.WriteString("\n" + m.renderBatchStopping())
 
 	return sb.String()
 }
 
+// renderBatchStopping renders the help line of a running batch import or
+// stash, or that it stops once the current folder is done.
+func (m ImportBrowserModel) renderBatchStopping() string {
+	if m.batchStopping {
+		return ibErrorStyle.Render("Stopping after the current folder...")
+	}
+	return ibHelpStyle.Render("esc: stop after the current folder")
+}
+
+// renderBatchStopped renders how far a stopped batch import or stash got and
+// how to resume it, or nothing when every folder was done.
+func renderBatchStopped(done, total int) string {
+	if done >= total {
+		return ""
+	}
+	return ibErrorStyle.Render(fmt.Sprintf("Stopped after %d of %d folders; press r to resume with the remaining %d", done, total, total-done)) + "\n\n"
+}
+
 // renderBatchImportSummaryView renders the batch import results summary.
 func (m ImportBrowserModel) renderBatchImportSummaryView() string {
 	var sb strings.Builder
 
-	sb.WriteString(ibHeaderStyle.Render("Batch Import Complete") + "\n\n")
+	stopped := len(m.batchImportResults) < len(m.batchImportTargets)
+	if stopped {
+		sb.WriteString(ibHeaderStyle.Render("Batch Import Stopped") + "\n\n")
+		sb.WriteString(renderBatchStopped(len(m.batchImportResults), len(m.batchImportTargets)))
+	} else {
+		sb.WriteString(ibHeaderStyle.Render("Batch Import Complete") + "\n\n")
+	}
 
 	// Count successes and failures
 	successCount := 0
@@ -3591,20 +6961,131 @@ func (m ImportBrowserModel) renderBatchImportSummaryView() string {
 
 		if r.Success {
 			sb.WriteString(fmt.Sprintf("  ✓ %s → %s (%d repos)\n", r.SourceName, r.WorkspaceSlug, r.RepoCount))
-		} else {
-			errMsg := "unknown error"
-			if r.Error != nil {
-				errMsg = r.Error.Error()
-				// Truncate long error messages
-				if len(errMsg) > 50 {
-					errMsg = errMsg[:47] + "..."
-				}
+			if r.TemplateError != nil {
+				sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("    template failed: %v", r.TemplateError)) + "\n")
 			}
-			sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("  ✗ %s: %s", r.SourceName, errMsg)) + "\n")
+		} else {
+			sb.WriteString(m.renderItemError(r.SourceName, r.Error) + "\n")
 		}
 	}
 
 	// Help
+	help := "enter/esc: return to browse"
+	if successCount > 0 {
+		help = "o: open a workspace • " + help
+	}
+	if stopped {
+		help = "r: resume • " + help
+	}
+	sb.WriteString("\n" + ibHelpStyle.Render(help))
+
+	return sb.String()
+}
+
+// renderItemError renders the failure of one item of a batch operation. The
+// whole message is shown, wrapped to the width of the view.
+func (m ImportBrowserModel) renderItemError(name string, err error) string {
+	msg := "unknown error"
+	if err != nil {
+		msg = errfmt.Message(err)
+	}
+	return ibErrorStyle.Width(max(m.width, 40)).PaddingLeft(2).Render(fmt.Sprintf("✗ %s: %s", name, msg))
+}
+
+// renderBatchAddToConfirmView renders the batch add-to confirmation view.
+func (m ImportBrowserModel) renderBatchAddToConfirmView() string {
+	var sb strings.Builder
+
+	sb.WriteString(ibHeaderStyle.Render("Batch Add to Workspace") + "\n")
+	sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Add %d folders to %s", len(m.batchAddToTargets), m.addToTargetSlug)) + "\n\n")
+
+	sb.WriteString("Folders to add:\n")
+	maxShow := 10
+	for i, node := range m.batchAddToTargets {
+		if i >= maxShow {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.batchAddToTargets)-maxShow))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  • %s (%d repos)\n", node.Name, len(m.gitRootsFor(node))))
+	}
+
+	sb.WriteString("\n" + ibHelpStyle.Render("Repos whose names already exist in the workspace are skipped."))
+	sb.WriteString("\n\n" + ibHelpStyle.Render("enter: start • esc: back to workspace selection"))
+
+	return sb.String()
+}
+
+// renderBatchAddToExecuteView renders the batch add-to progress view.
+func (m ImportBrowserModel) renderBatchAddToExecuteView() string {
+	var sb strings.Builder
+
+	sb.WriteString(ibHeaderStyle.Render("Batch Add in Progress...") + "\n\n")
+
+	total := len(m.batchAddToTargets)
+	current := m.batchAddToCurrent + 1
+	if current > total {
+		current = total
+	}
+
+	sb.WriteString(fmt.Sprintf("Adding folder %d of %d to %s...\n", current, total, m.addToTargetSlug))
+
+	if m.batchAddToCurrent < len(m.batchAddToTargets) {
+		sb.WriteString(fmt.Sprintf("Current: %s\n", m.batchAddToTargets[m.batchAddToCurrent].Name))
+	}
+
+	return sb.String()
+}
+
+// renderBatchAddToSummaryView renders the batch add-to results summary.
+func (m ImportBrowserModel) renderBatchAddToSummaryView() string {
+	var sb strings.Builder
+
+	sb.WriteString(ibHeaderStyle.Render("Batch Add Complete") + "\n")
+	sb.WriteString(ibHelpStyle.Render("Workspace: "+m.addToTargetSlug) + "\n\n")
+
+	successCount := 0
+	failCount := 0
+	for _, r := range m.batchAddToResults {
+		if r.Success {
+			successCount++
+		} else {
+			failCount++
+		}
+	}
+
+	if failCount == 0 {
+		sb.WriteString(ibSuccessStyle.Render(fmt.Sprintf("All %d folders added!", successCount)) + "\n\n")
+	} else if successCount == 0 {
+		sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("All %d adds failed!", failCount)) + "\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("%s, %s\n\n",
+			ibSuccessStyle.Render(fmt.Sprintf("%d succeeded", successCount)),
+			ibErrorStyle.Render(fmt.Sprintf("%d failed", failCount))))
+	}
+
+	sb.WriteString("Results:\n")
+	maxShow := 15
+	for i, r := range m.batchAddToResults {
+		if i >= maxShow {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.batchAddToResults)-maxShow))
+			break
+		}
+
+		if r.Success {
+			line := fmt.Sprintf("  ✓ %s (%d repos", r.SourceName, r.RepoCount)
+			if r.SkippedCount > 0 {
+				line += fmt.Sprintf(", %d skipped", r.SkippedCount)
+			}
+			line += ")"
+			if !r.SourceEmpty {
+				line += " — source kept"
+			}
+			sb.WriteString(line + "\n")
+		} else {
+			sb.WriteString(m.renderItemError(r.SourceName, r.Error) + "\n")
+		}
+	}
+
 	sb.WriteString("\n" + ibHelpStyle.Render("enter/esc: return to browse"))
 
 	return sb.String()
@@ -3640,51 +7121,269 @@ func (m ImportBrowserModel) renderBatchStashConfirmView() string {
 	// Warning if deleting
 	if m.batchStashDeleteAfter {
 		sb.WriteString("\n" + ibErrorStyle.Render("WARNING: All source items will be DELETED after archiving!") + "\n")
+		if len(m.batchStashRisks) > 0 {
+			sb.WriteString("\n")
+			m.renderDeleteRisks(&sb, m.batchStashRisks)
+			sb.WriteString(ibHelpStyle.Render("d/space: toggle delete • !: stash and delete anyway • esc: cancel"))
+			return sb.String()
+		}
+	}
+
+	// Help
+	sb.WriteString("\n" + ibHelpStyle.Render("d/space: toggle delete • enter: start stash • esc: cancel"))
+
+	return sb.String()
+}
+
+// renderBatchStashExecuteView renders the batch stash progress view.
+func (m ImportBrowserModel) renderBatchStashExecuteView() string {
+	var sb strings.Builder
+
+	sb.WriteString(ibHeaderStyle.Render("Batch Stash in Progress...") + "\n\n")
+
+	total := len(m.batchStashTargets)
+	current := m.batchStashCurrent + 1
+	if current > total {
+		current = total
+	}
+
+	sb.WriteString(fmt.Sprintf("Stashing item %d of %d...\n", current, total))
+
+	if m.batchStashCurrent < len(m.batchStashTargets) {
+		sb.WriteString(fmt.Sprintf("Current: %s\n", m.batchStashTargets[m.batchStashCurrent].Name))
+	}
+	sb.WriteString("\n" + m.renderBatchStopping())
+
+	return sb.String()
+}
+
+// renderBatchStashSummaryView renders the batch stash results summary.
+func (m ImportBrowserModel) renderBatchStashSummaryView() string {
+	var sb strings.Builder
+
+	stopped := len(m.batchStashResults) < len(m.batchStashTargets)
+	if stopped {
+		sb.WriteString(ibHeaderStyle.Render("Batch Stash Stopped") + "\n\n")
+		sb.WriteString(renderBatchStopped(len(m.batchStashResults), len(m.batchStashTargets)))
+	} else {
+		sb.WriteString(ibHeaderStyle.Render("Batch Stash Complete") + "\n\n")
+	}
+
+	// Count successes and failures
+	successCount := 0
+	failCount := 0
+	deletedCount := 0
+	for _, r := range m.batchStashResults {
+		if r.Success {
+			successCount++
+			if r.Deleted {
+				deletedCount++
+			}
+		} else {
+			failCount++
+		}
+	}
+
+	// Summary line
+	if failCount == 0 {
+		summary := fmt.Sprintf("All %d stashes succeeded!", successCount)
+		if deletedCount > 0 {
+			summary += fmt.Sprintf(" (%d sources deleted)", deletedCount)
+		}
+		sb.WriteString(ibSuccessStyle.Render(summary) + "\n\n")
+	} else if successCount == 0 {
+		sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("All %d stashes failed!", failCount)) + "\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("%s, %s\n\n",
+			ibSuccessStyle.Render(fmt.Sprintf("%d succeeded", successCount)),
+			ibErrorStyle.Render(fmt.Sprintf("%d failed", failCount))))
+	}
+
+	// Detailed results
+	sb.WriteString("Results:\n")
+	maxShow := 15
+	for i, r := range m.batchStashResults {
+		if i >= maxShow {
+			remaining := len(m.batchStashResults) - maxShow
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", remaining))
+			break
+		}
+
+		if r.Success {
+			archiveName := filepath.Base(r.ArchivePath)
+			suffix := ""
+			if r.Deleted {
+				suffix = " (deleted)"
+			}
+			sb.WriteString(fmt.Sprintf("  ✓ %s → %s%s\n", r.SourceName, archiveName, suffix))
+		} else {
+			sb.WriteString(m.renderItemError(r.SourceName, r.Error) + "\n")
+		}
+	}
+
+	// Help
+	help := "enter/esc: return to browse"
+	if stopped {
+		help = "r: resume • " + help
+	}
+	sb.WriteString("\n" + ibHelpStyle.Render(help))
+
+	return sb.String()
+}
+
+// renderBatchDeleteConfirmView renders the batch delete/trash confirmation view.
+func (m ImportBrowserModel) renderBatchDeleteConfirmView() string {
+	var sb strings.Builder
+
+	if m.deleteIsTrash {
+		sb.WriteString(ibHeaderStyle.Render("Batch Move to Trash") + "\n")
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Move %d items to your system's trash", len(m.batchDeleteTargets))) + "\n\n")
+	} else if quarantine.Enabled(m.cfg) {
+		sb.WriteString(ibHeaderStyle.Render("Batch Delete to Quarantine") + "\n")
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Move %d items to quarantine, purged after %d days", len(m.batchDeleteTargets), m.cfg.GetQuarantineConfig().Days)) + "\n\n")
+	} else {
+		sb.WriteString(ibErrorStyle.Render("⚠ BATCH PERMANENT DELETE") + "\n")
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Permanently delete %d items", len(m.batchDeleteTargets))) + "\n\n")
+	}
+
+	// List items
+	sb.WriteString("Items:\n")
+	maxShow := 10
+	for i, node := range m.batchDeleteTargets {
+		if i >= maxShow {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.batchDeleteTargets)-maxShow))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  • %s\n", node.Name))
+	}
+	sb.WriteString("\n")
+
+	// Safety check results
+	m.renderDeleteRisks(&sb, m.batchDeleteRisks)
+
+	if !m.deleteIsTrash && !quarantine.Enabled(m.cfg) {
+		sb.WriteString(ibErrorStyle.Render("This action cannot be undone!") + "\n\n")
+	}
+
+	// Help
+	if len(m.batchDeleteRisks) > 0 {
+		sb.WriteString(ibHelpStyle.Render("!: proceed anyway • n/esc: cancel"))
+	} else {
+		sb.WriteString(ibHelpStyle.Render("y/enter: confirm • n/esc: cancel"))
+	}
+
+	return sb.String()
+}
+
+// writeGitRemotesAndBranches renders every remote and local branch of a repo,
+// marking branches with commits that are not on any remote.
+func writeGitRemotesAndBranches(sb *strings.Builder, info *git.RepoInfo) {
+	if len(info.Remotes) == 0 {
+		sb.WriteString(ibGitDirtyStyle.Render("Remote: none") + "\n")
+	} else {
+		sb.WriteString("Remotes:\n")
+		for _, r := range info.Remotes {
+			sb.WriteString(fmt.Sprintf("  %s  %s\n", r.Name, r.URL))
+		}
+	}
+
+	if len(info.Branches) == 0 {
+		return
+	}
+	sb.WriteString("Branches:\n")
+	for _, b := range info.Branches {
+		marker := " "
+		if b.Current {
+			marker = "*"
+		}
+		var status []string
+		switch {
+		case b.Gone:
+			status = append(status, "upstream gone")
+		case b.Upstream == "":
+			status = append(status, "no upstream")
+		default:
+			status = append(status, b.Upstream)
+		}
+		if b.Ahead > 0 {
+			status = append(status, fmt.Sprintf("%d unpushed", b.Ahead))
+		}
+		if b.Behind > 0 {
+			status = append(status, fmt.Sprintf("%d behind", b.Behind))
+		}
+		line := fmt.Sprintf("%s %s (%s)", marker, b.Name, strings.Join(status, ", "))
+		if b.Pushed() {
+			sb.WriteString(line + "\n")
+		} else {
+			sb.WriteString(ibGitDirtyStyle.Render(line) + "\n")
+		}
+	}
+}
+
+// renderDeleteRisks lists repos whose unsaved work would be lost.
+func (m ImportBrowserModel) renderDeleteRisks(sb *strings.Builder, risks []deleteRisk) {
+	if len(risks) == 0 {
+		return
+	}
+	maxShow := 10
+	sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("%d repos have work that is not saved elsewhere:", len(risks))) + "\n")
+	for i, r := range risks {
+		if i >= maxShow {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(risks)-maxShow))
+			break
+		}
+		rel, err := filepath.Rel(m.rootPath, r.Path)
+		if err != nil {
+			rel = r.Path
+		}
+		sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("  ✗ %s: %s", rel, r.String())) + "\n")
 	}
-
-	// Help
-	sb.WriteString("\n" + ibHelpStyle.Render("d/space: toggle delete • enter: start stash • esc: cancel"))
-
-	return sb.String()
+	sb.WriteString("\n")
 }
 
-// renderBatchStashExecuteView renders the batch stash progress view.
-func (m ImportBrowserModel) renderBatchStashExecuteView() string {
+// renderBatchDeleteExecuteView renders the batch delete/trash progress view.
+func (m ImportBrowserModel) renderBatchDeleteExecuteView() string {
 	var sb strings.Builder
 
-	sb.WriteString(ibHeaderStyle.Render("Batch Stash in Progress...") + "\n\n")
+	verb := "Deleting"
+	if m.deleteIsTrash {
+		verb = "Trashing"
+	}
+	sb.WriteString(ibHeaderStyle.Render(fmt.Sprintf("Batch %s in Progress...", verb)) + "\n\n")
 
-	total := len(m.batchStashTargets)
-	current := m.batchStashCurrent + 1
+	total := len(m.batchDeleteTargets)
+	current := m.batchDeleteCurrent + 1
 	if current > total {
 		current = total
 	}
 
-	sb.WriteString(fmt.Sprintf("Stashing item %d of %d...\n", current, total))
+	sb.WriteString(fmt.Sprintf("%s item %d of %d...\n", verb, current, total))
 
-	if m.batchStashCurrent < len(m.batchStashTargets) {
-		sb.WriteString(fmt.Sprintf("Current: %s\n", m.batchStashTargets[m.batchStashCurrent].Name))
+	if m.batchDeleteCurrent < len(m.batchDeleteTargets) {
+		sb.WriteString(fmt.Sprintf("Current: %s\n", m.batchDeleteTargets[m.batchDeleteCurrent].Name))
 	}
 
 	return sb.String()
 }
 
-// renderBatchStashSummaryView renders the batch stash results summary.
-func (m ImportBrowserModel) renderBatchStashSummaryView() string {
+// renderBatchDeleteSummaryView renders the batch delete/trash results summary.
+func (m ImportBrowserModel) renderBatchDeleteSummaryView() string {
 	var sb strings.Builder
 
-	sb.WriteString(ibHeaderStyle.Render("Batch Stash Complete") + "\n\n")
+	noun := "deletes"
+	header := "Batch Delete Complete"
+	if m.deleteIsTrash {
+		noun = "moves to trash"
+		header = "Batch Trash Complete"
+	}
+	sb.WriteString(ibHeaderStyle.Render(header) + "\n\n")
 
 	// Count successes and failures
 	successCount := 0
 	failCount := 0
-	deletedCount := 0
-	for _, r := range m.batchStashResults {
+	for _, r := range m.batchDeleteResults {
 		if r.Success {
 			successCount++
-			if r.Deleted {
-				deletedCount++
-			}
 		} else {
 			failCount++
 		}
@@ -3692,13 +7391,9 @@ func (m ImportBrowserModel) renderBatchStashSummaryView() string {
 
 	// Summary line
 	if failCount == 0 {
-		summary := fmt.Sprintf("All %d stashes succeeded!", successCount)
-		if deletedCount > 0 {
-			summary += fmt.Sprintf(" (%d sources deleted)", deletedCount)
-		}
-		sb.WriteString(ibSuccessStyle.Render(summary) + "\n\n")
+		sb.WriteString(ibSuccessStyle.Render(fmt.Sprintf("All %d %s succeeded!", successCount, noun)) + "\n\n")
 	} else if successCount == 0 {
-		sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("All %d stashes failed!", failCount)) + "\n\n")
+		sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("All %d %s failed!", failCount, noun)) + "\n\n")
 	} else {
 		sb.WriteString(fmt.Sprintf("%s, %s\n\n",
 			ibSuccessStyle.Render(fmt.Sprintf("%d succeeded", successCount)),
@@ -3708,30 +7403,17 @@ func (m ImportBrowserModel) renderBatchStashSummaryView() string {
 	// Detailed results
 	sb.WriteString("Results:\n")
 	maxShow := 15
-	for i, r := range m.batchStashResults {
+	for i, r := range m.batchDeleteResults {
 		if i >= maxShow {
-			remaining := len(m.batchStashResults) - maxShow
+			remaining := len(m.batchDeleteResults) - maxShow
 			sb.WriteString(fmt.Sprintf("  ... and %d more\n", remaining))
 			break
 		}
 
 		if r.Success {
-			archiveName := filepath.Base(r.ArchivePath)
-			suffix := ""
-			if r.Deleted {
-				suffix = " (deleted)"
-			}
-			sb.WriteString(fmt.Sprintf("  ✓ %s → %s%s\n", r.SourceName, archiveName, suffix))
+			sb.WriteString(fmt.Sprintf("  ✓ %s\n", r.SourceName))
 		} else {
-			errMsg := "unknown error"
-			if r.Error != nil {
-				errMsg = r.Error.Error()
-				// Truncate long error messages
-				if len(errMsg) > 50 {
-					errMsg = errMsg[:47] + "..."
-				}
-			}
-			sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("  ✗ %s: %s", r.SourceName, errMsg)) + "\n")
+			sb.WriteString(m.renderItemError(r.SourceName, r.Error) + "\n")
 		}
 	}
 
@@ -3745,7 +7427,12 @@ func (m ImportBrowserModel) renderBatchStashSummaryView() string {
 func (m ImportBrowserModel) renderDeleteConfirmView() string {
 	var sb strings.Builder
 
-	sb.WriteString(ibErrorStyle.Render("⚠ PERMANENT DELETE") + "\n\n")
+	quarantined := quarantine.Enabled(m.cfg)
+	if quarantined {
+		sb.WriteString(ibHeaderStyle.Render("Delete to Quarantine") + "\n\n")
+	} else {
+		sb.WriteString(ibErrorStyle.Render("⚠ PERMANENT DELETE") + "\n\n")
+	}
 
 	if m.deleteTarget != nil {
 		itemType := "Folder"
@@ -3760,8 +7447,20 @@ func (m ImportBrowserModel) renderDeleteConfirmView() string {
 	if m.deleteTarget != nil && !m.deleteTarget.IsDir {
 		itemDesc = "this file"
 	}
-	sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("This will PERMANENTLY delete %s.", itemDesc)) + "\n")
-	sb.WriteString(ibErrorStyle.Render("This action cannot be undone!") + "\n\n")
+	m.renderDeleteRisks(&sb, m.deleteRisks)
+
+	if quarantined {
+		sb.WriteString(fmt.Sprintf("This will move %s to quarantine.\n", itemDesc))
+		sb.WriteString(fmt.Sprintf("Restore it with 'co quarantine restore' within %d days.\n\n", m.cfg.GetQuarantineConfig().Days))
+	} else {
+		sb.WriteString(ibErrorStyle.Render(fmt.Sprintf("This will PERMANENTLY delete %s.", itemDesc)) + "\n")
+		sb.WriteString(ibErrorStyle.Render("This action cannot be undone!") + "\n\n")
+	}
+
+	if len(m.deleteRisks) > 0 {
+		sb.WriteString(ibHelpStyle.Render("!: delete anyway • n/esc: cancel"))
+		return sb.String()
+	}
 
 	sb.WriteString("Are you sure you want to continue?\n\n")
 
@@ -3770,6 +7469,71 @@ func (m ImportBrowserModel) renderDeleteConfirmView() string {
 	return sb.String()
 }
 
+// renderFileOpView renders the new folder / rename / move prompt.
+func (m ImportBrowserModel) renderFileOpView() string {
+	var sb strings.Builder
+
+	switch m.fileOp {
+	case fileOpMkdir:
+		sb.WriteString(ibHeaderStyle.Render("New Folder") + "\n\n")
+		sb.WriteString(fmt.Sprintf("In:     %s\n\n", m.fileOpTarget.Path))
+		sb.WriteString("Name:   " + m.fileOpInput.View() + "\n")
+	case fileOpRename:
+		sb.WriteString(ibHeaderStyle.Render("Rename") + "\n\n")
+		sb.WriteString(fmt.Sprintf("Path:   %s\n\n", m.fileOpTarget.Path))
+		sb.WriteString("Name:   " + m.fileOpInput.View() + "\n")
+	case fileOpMove:
+		sb.WriteString(ibHeaderStyle.Render(fmt.Sprintf("Move %d item(s)", len(m.fileOpSources))) + "\n\n")
+		maxShow := 10
+		for i, node := range m.fileOpSources {
+			if i >= maxShow {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(m.fileOpSources)-maxShow))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  • %s\n", node.Name))
+		}
+		sb.WriteString("\nInto:   " + m.fileOpInput.View() + "\n")
+		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Relative paths are resolved from %s", m.rootPath)) + "\n")
+	case fileOpFilter:
+		sb.WriteString(ibHeaderStyle.Render("Filter Folder") + "\n\n")
+		sb.WriteString(fmt.Sprintf("In:     %s\n\n", m.fileOpTarget.Path))
+		sb.WriteString("Show:   " + m.fileOpInput.View() + "\n")
+		sb.WriteString(ibHelpStyle.Render("Only entries whose names contain this text are listed; leave empty to list all") + "\n")
+	}
+
+	if m.fileOpError != "" {
+		sb.WriteString("\n" + ibErrorStyle.Render("Error: "+m.fileOpError) + "\n")
+	}
+
+	sb.WriteString("\n" + ibHelpStyle.Render("enter: confirm • esc: cancel"))
+
+	return sb.String()
+}
+
+// renderFilePreviewView renders the quick-look file preview.
+func (m ImportBrowserModel) renderFilePreviewView() string {
+	var sb strings.Builder
+
+	header := "Preview: " + filepath.Base(m.previewFile.path)
+	if m.previewFile.size > 0 {
+		header += fmt.Sprintf(" (%s)", humanizeFileSize(m.previewFile.size))
+	}
+	sb.WriteString(ibHeaderStyle.Render(header) + "\n")
+	sb.WriteString(ibHelpStyle.Render(m.previewFile.path) + "\n\n")
+
+	sb.WriteString(m.previewViewport.View())
+
+	// Show scroll position if content is scrollable
+	if !m.previewLoading && m.previewViewport.TotalLineCount() > m.previewViewport.VisibleLineCount() {
+		percent := int(m.previewViewport.ScrollPercent() * 100)
+		sb.WriteString(fmt.Sprintf("\n%d%%", percent))
+	}
+
+	sb.WriteString("\n" + ibHelpStyle.Render("j/k: scroll • d/u: half page • g/G: top/bottom • p/esc: close"))
+
+	return sb.String()
+}
+
 // renderTrashConfirmView renders the trash confirmation dialog.
 func (m ImportBrowserModel) renderTrashConfirmView() string {
 	var sb strings.Builder
@@ -3789,9 +7553,16 @@ func (m ImportBrowserModel) renderTrashConfirmView() string {
 	if m.deleteTarget != nil && !m.deleteTarget.IsDir {
 		itemDesc = "this file"
 	}
+	m.renderDeleteRisks(&sb, m.deleteRisks)
+
 	sb.WriteString(fmt.Sprintf("This will move %s to your system's trash.\n", itemDesc))
 	sb.WriteString("You can recover it from the trash if needed.\n\n")
 
+	if len(m.deleteRisks) > 0 {
+		sb.WriteString(ibHelpStyle.Render("!: trash anyway • n/esc: cancel"))
+		return sb.String()
+	}
+
 	sb.WriteString("Move to trash?\n\n")
 
 	sb.WriteString(ibHelpStyle.Render("y/enter: confirm • n/esc: cancel"))
@@ -3816,23 +7587,52 @@ func (m ImportBrowserModel) renderImportPreviewView() string {
 	if m.importTarget != nil {
 		sb.WriteString(fmt.Sprintf("\nSource: %s\n", m.importTarget.Path))
 
-		// Count and list repos
-		var repos []string
-		if m.importTarget.IsGitRepo {
-			repos = append(repos, m.importTarget.Name)
-		} else {
-			prefix := m.importTarget.Path + string(filepath.Separator)
-			for gitRoot := range m.gitRootSet {
-				if strings.HasPrefix(gitRoot, prefix) {
-					repos = append(repos, filepath.Base(gitRoot))
+		// List repos with the names they get in the workspace
+		roots := m.importRoots()
+		if len(roots) > 0 {
+			sb.WriteString(fmt.Sprintf("\nRepositories (%d):\n", len(roots)))
+			dups := m.duplicateRepoNames()
+			existing := m.existingRepoNames()
+			for i, root := range roots {
+				rel, err := filepath.Rel(m.importTarget.Path, root)
+				if err != nil || rel == "." {
+					rel = filepath.Base(root)
+				}
+				name := m.importRepoName(root)
+				line := fmt.Sprintf("%s → %s", rel, m.importRepoDir(name))
+				switch {
+				case dups[name]:
+					line += " (duplicate)"
+				case existing[name]:
+					line += " (exists, skipped)"
 				}
+				if i == m.repoSelected && (len(roots) > 1 || m.repoRenaming) {
+					sb.WriteString(ibSelectedStyle.Render("> "+line) + "\n")
+				} else if dups[name] {
+					sb.WriteString("  " + ibErrorStyle.Render(line) + "\n")
+				} else {
+					sb.WriteString("  " + line + "\n")
+				}
+			}
+			if m.repoRenaming {
+				sb.WriteString("\nRepo name:\n" + m.repoNameInput.View() + "\n")
 			}
+			if m.repoError != "" {
+				sb.WriteString(ibErrorStyle.Render("Error: "+m.repoError) + "\n")
+			}
+		}
+		if into := m.mergeInto(); into != "" {
+			sb.WriteString(fmt.Sprintf("\nMerge into: %s\n", ibSuccessStyle.Render(m.importRepoDir(into))))
+			sb.WriteString(ibHelpStyle.Render("  one subdirectory per repo, history kept; the repos stay in place") + "\n")
 		}
 
-		if len(repos) > 0 {
-			sb.WriteString(fmt.Sprintf("\nRepositories (%d):\n", len(repos)))
-			for _, repo := range repos {
-				sb.WriteString(fmt.Sprintf("  • %s\n", repo))
+		if splits := m.selectedSplits(); len(splits) > 0 {
+			sb.WriteString(fmt.Sprintf("\nSplit into repos (%d):\n", len(splits)))
+			for _, split := range splits {
+				sb.WriteString(fmt.Sprintf("  • %s/ → %s\n", split.Dir, m.importRepoDir(split.Name)))
+			}
+			if !m.splitKeepSource {
+				sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("  %s stays in place", m.importTarget.Name)) + "\n")
 			}
 		}
 	}
@@ -3852,8 +7652,29 @@ func (m ImportBrowserModel) renderImportPreviewView() string {
 			dest = dest + "/"
 		}
 		sb.WriteString(fmt.Sprintf("  Destination: %s\n", dest))
+		dups := m.selectedDuplicates()
+		same := make(map[string]string, len(dups))
+		var saved int64
+		for _, dup := range dups {
+			same[dup.Path] = dup.Same
+			saved += dup.Size
+		}
 		for _, path := range m.extraFilesResult.SelectedPaths {
-			sb.WriteString(fmt.Sprintf("  • %s\n", path))
+			switch {
+			case same[path] == "":
+				sb.WriteString(fmt.Sprintf("  • %s\n", path))
+			case m.skipDuplicates:
+				sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("  • %s (skipped, same as %s)", path, same[path])) + "\n")
+			default:
+				sb.WriteString(fmt.Sprintf("  • %s %s\n", path, ibGitDirtyStyle.Render("(same as "+same[path]+")")))
+			}
+		}
+		if len(dups) > 0 {
+			if m.skipDuplicates {
+				sb.WriteString(ibSuccessStyle.Render(fmt.Sprintf("  Skipping %d duplicate(s) saves %s; they stay in the source folder", len(dups), formatSize(saved))) + "\n")
+			} else {
+				sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("  %d file(s) (%s) already in the repos; x skips them", len(dups), formatSize(saved))) + "\n")
+			}
 		}
 	}
 
@@ -3867,10 +7688,31 @@ func (m ImportBrowserModel) renderImportPreviewView() string {
 		sb.WriteString("\n" + m.message)
 	}
 
+	if m.repoRenaming {
+		sb.WriteString("\n" + ibHelpStyle.Render("enter: confirm name (empty: derived name) • esc: cancel"))
+		return sb.String()
+	}
+
+	mergeHelp := ""
+	if m.canMerge() {
+		mergeHelp = " • M: merge repos into one"
+	}
+	if roots := m.importRoots(); len(roots) > 1 {
+		mergeHelp = " • j/k, r: rename repo" + mergeHelp
+	} else if len(roots) == 1 {
+		mergeHelp = " • r: rename repo" + mergeHelp
+	}
+	if len(m.selectedDuplicates()) > 0 {
+		if m.skipDuplicates {
+			mergeHelp += " • x: keep duplicates"
+		} else {
+			mergeHelp += " • x: skip duplicates"
+		}
+	}
 	if m.dryRun {
-		sb.WriteString("\n" + ibHelpStyle.Render("enter: show dry-run • d: disable dry-run • esc: back"))
+		sb.WriteString("\n" + ibHelpStyle.Render("enter: show dry-run • d: disable dry-run"+mergeHelp+" • esc: back"))
 	} else {
-		sb.WriteString("\n" + ibHelpStyle.Render("enter: execute import • d: dry-run • esc: back"))
+		sb.WriteString("\n" + ibHelpStyle.Render("enter: execute import • d: dry-run"+mergeHelp+" • esc: back"))
 	}
 
 	return sb.String()
@@ -3880,7 +7722,15 @@ func (m ImportBrowserModel) renderImportPreviewView() string {
 func (m ImportBrowserModel) renderTreePane() string {
 	var sb strings.Builder
 
-	sb.WriteString(ibHeaderStyle.Render("Source Folder") + "\n")
+	header := "Source Folder"
+	if m.sortMode != treeSortName {
+		header += fmt.Sprintf(" (sort: %s)", m.sortMode)
+	}
+	sb.WriteString(ibHeaderStyle.Render(header) + "\n")
+
+	if m.columnView {
+		sb.WriteString(m.padTreeLine("", ibHelpStyle.Render(fmt.Sprintf("%9s %10s %6s", "SIZE", "MODIFIED", "REPOS"))) + "\n")
+	}
 
 	// Show filter input if active
 	if m.filterActive {
@@ -3888,11 +7738,15 @@ func (m ImportBrowserModel) renderTreePane() string {
 	} else if m.filterText != "" {
 		sb.WriteString(ibHelpStyle.Render(fmt.Sprintf("Filter: %s (esc to clear)", m.filterText)) + "\n")
 	}
+	if m.staleOnly {
+		sb.WriteString(ibStaleStyle.Render("Stale only: untouched >1y (u to clear)") + "\n")
+	}
 
 	start, end := m.scroller.visibleRange()
 	for i := start; i < end; i++ {
 		node := m.scroller.flatTree[i]
-		line := m.renderNode(node, m.scroller.isSelected(i))
+		inRange := m.visual.contains(i, m.scroller.selected, len(m.scroller.flatTree))
+		line := m.renderNode(node, m.scroller.isSelected(i), inRange)
 		sb.WriteString(line + "\n")
 	}
 
@@ -3904,8 +7758,58 @@ func (m ImportBrowserModel) renderTreePane() string {
 	return sb.String()
 }
 
+// isStale reports whether a node's newest modification is older than staleThreshold.
+// Nodes with an unknown modification time are not considered stale.
+func (m ImportBrowserModel) isStale(node *sourceNode) bool {
+	if node.Path == "" {
+		return false
+	}
+	t, ok := m.nodeModTime(node)
+	return ok && time.Since(t) > staleThreshold
+}
+
+// renderTreeColumns renders the size, modified, and repo-count columns for a node.
+func (m ImportBrowserModel) renderTreeColumns(node *sourceNode) string {
+	if node.Path == "" {
+		return ""
+	}
+
+	size := "…"
+	if s, ok := m.nodeSize(node); ok {
+		size = formatSize(s)
+	} else if !node.IsDir {
+		size = "—"
+	}
+
+	modified := "…"
+	if t, ok := m.nodeModTime(node); ok {
+		modified = formatAge(t)
+	} else if !node.IsDir {
+		modified = "—"
+	}
+
+	repos := ""
+	if node.IsDir {
+		if count := m.countReposUnder(node.Path); count > 0 {
+			repos = fmt.Sprintf("%d", count)
+		}
+	}
+
+	return fmt.Sprintf("%9s %10s %6s", size, modified, repos)
+}
+
+// padTreeLine pads a rendered tree line so that columns align at the right edge of the pane.
+func (m ImportBrowserModel) padTreeLine(line, columns string) string {
+	paneWidth := m.width/2 - 4 // Tree pane width minus border and padding
+	gap := paneWidth - lipgloss.Width(line) - lipgloss.Width(columns)
+	if gap < 1 {
+		gap = 1
+	}
+	return line + strings.Repeat(" ", gap) + columns
+}
+
 // renderNode renders a single tree node.
-func (m ImportBrowserModel) renderNode(node *sourceNode, isSelected bool) string {
+func (m ImportBrowserModel) renderNode(node *sourceNode, isSelected, inVisualRange bool) string {
 	// Indentation
 	indent := strings.Repeat("  ", node.Depth)
 
@@ -3921,18 +7825,24 @@ func (m ImportBrowserModel) renderNode(node *sourceNode, isSelected bool) string
 		icon = "  "
 	}
 
-	// Selection marker
+	// Selection marker (visual range shown as pending selection)
 	selectMarker := "  "
 	if node.IsSelected {
 		selectMarker = "● "
+	} else if inVisualRange && node != m.root {
+		selectMarker = ibVisualStyle.Render("◆ ")
 	}
 
 	// Name with styling
 	name := node.Name
 	var styledName string
 
-	if node.IsSymlink {
-		styledName = ibSymlinkStyle.Render(name + " →")
+	if node.IsSymlink && !node.IsDir {
+		suffix := " →"
+		if node.SymlinkLoop {
+			suffix = " ↻"
+		}
+		styledName = ibSymlinkStyle.Render(name + suffix)
 	} else if node.IsGitRepo {
 		gitInfo := ""
 		if node.GitInfo != nil {
@@ -3953,12 +7863,32 @@ func (m ImportBrowserModel) renderNode(node *sourceNode, isSelected bool) string
 			suffix = " •"
 		}
 		styledName = ibDirStyle.Render(name + "/" + suffix)
+		if node.EntryFilter != "" {
+			styledName += ibHelpStyle.Render(fmt.Sprintf(" [filter: %s]", node.EntryFilter))
+		}
+		if m.scanTruncated[node.Path] {
+			styledName += ibHelpStyle.Render(" …")
+		}
 	} else {
 		styledName = ibFileStyle.Render(name)
 	}
+	if node.IsSymlink && node.IsDir {
+		styledName += ibSymlinkStyle.Render(" →")
+	}
 
 	line := fmt.Sprintf("%s%s%s%s", indent, selectMarker, icon, styledName)
 
+	// Mark stale directories (age is only known once scanned)
+	if node.IsDir && !m.columnView && m.isStale(node) {
+		if t, ok := m.nodeModTime(node); ok {
+			line += ibStaleStyle.Render(" ⏲ " + formatAge(t))
+		}
+	}
+
+	if m.columnView {
+		line = m.padTreeLine(line, ibHelpStyle.Render(m.renderTreeColumns(node)))
+	}
+
 	if isSelected {
 		line = ibSelectedStyle.Render(line)
 	}
@@ -3966,6 +7896,25 @@ func (m ImportBrowserModel) renderNode(node *sourceNode, isSelected bool) string
 	return line
 }
 
+// formatAge formats a time as a compact relative age (e.g. "3d ago", "5mo ago").
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/(24*365)))
+	}
+}
+
 // formatSize formats a byte count as a human-readable string.
 func formatSize(bytes int64) string {
 	const (
@@ -4035,13 +7984,232 @@ func (m *ImportBrowserModel) triggerSizeCalc(path string) tea.Cmd {
 	}
 }
 
-// triggerSelectedSizeCalc triggers async size calculation for the currently selected node.
-func (m *ImportBrowserModel) triggerSelectedSizeCalc() tea.Cmd {
+// streamSize starts a streamed size calculation for the selected directory,
+// cancelling the one for the previous selection. The details pane shows its
+// running total until the final sizeResultMsg arrives.
+func (m *ImportBrowserModel) streamSize(path string) tea.Cmd {
+	if m.sizeStream != nil && m.sizeStream.path == path {
+		return nil
+	}
+	m.cancelSizeStream()
+	if _, ok := m.sizeCache[path]; ok {
+		return nil
+	}
+	if _, ok := m.sizePending[path]; ok {
+		return nil
+	}
+
+	m.sizePending[path] = struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan tea.Msg, 1)
+	m.sizeStream = &sizeStream{path: path, events: events, cancel: cancel}
+
+	go func() {
+		defer close(events)
+		size, err := fs.CalculateSizeStream(ctx, path, sizeProgressInterval, func(total int64) {
+			// Drop totals the view has not caught up with; a newer one follows
+			select {
+			case events <- sizeProgressMsg{Path: path, Size: total, events: events}:
+			default:
+			}
+		})
+		select {
+		case events <- sizeResultMsg{Path: path, Size: size, Err: err}:
+		case <-ctx.Done():
+		}
+	}()
+	return waitForSizeEvent(events)
+}
+
+// cancelSizeStream stops the streamed size calculation, if any.
+func (m *ImportBrowserModel) cancelSizeStream() {
+	if m.sizeStream == nil {
+		return
+	}
+	m.sizeStream.cancel()
+	delete(m.sizePending, m.sizeStream.path)
+	m.sizeStream = nil
+}
+
+// waitForSizeEvent waits for the next message of a streamed size calculation.
+// Its message is nil once the stream is closed.
+func waitForSizeEvent(events chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// triggerMtimeCalc starts an async last-modified scan for a directory if not already cached or pending.
+// Returns a tea.Cmd that will send an mtimeResultMsg when complete.
+func (m *ImportBrowserModel) triggerMtimeCalc(path string) tea.Cmd {
+	if m.mtimePending == nil {
+		m.mtimePending = make(map[string]struct{})
+	}
+	if _, ok := m.mtimeCache[path]; ok {
+		return nil
+	}
+	if _, ok := m.mtimePending[path]; ok {
+		return nil
+	}
+
+	m.mtimePending[path] = struct{}{}
+
+	return func() tea.Msg {
+		unix, err := fs.GetLastModTime(path)
+		if err != nil {
+			return mtimeResultMsg{Path: path, Err: err}
+		}
+		return mtimeResultMsg{Path: path, ModTime: time.Unix(unix, 0)}
+	}
+}
+
+// gitInfoWorkers bounds how many repos have git info collected at once.
+const gitInfoWorkers = 8
+
+// gitInfoSlots is the semaphore shared by all git info collections.
+var gitInfoSlots = make(chan struct{}, gitInfoWorkers)
+
+// triggerGitInfo starts async git info collection for repos in the visible
+// tree that have neither cached nor in-flight info, and attaches cached info
+// to nodes rebuilt since it was collected. Collection runs on at most
+// gitInfoWorkers goroutines at a time; results arrive as gitInfoResultMsg.
+func (m *ImportBrowserModel) triggerGitInfo() tea.Cmd {
+	if m.scroller == nil {
+		return nil
+	}
+	if m.gitInfoCache == nil {
+		m.gitInfoCache = make(map[string]*git.RepoInfo)
+	}
+	if m.gitInfoPending == nil {
+		m.gitInfoPending = make(map[string]struct{})
+	}
+	m.applyGitInfo()
+
+	var cmds []tea.Cmd
+	for _, node := range m.scroller.flatTree {
+		if !node.IsGitRepo || node.GitInfo != nil {
+			continue
+		}
+		if _, ok := m.gitInfoCache[node.Path]; ok {
+			continue // Collection failed; don't retry until refresh
+		}
+		if _, ok := m.gitInfoPending[node.Path]; ok {
+			continue
+		}
+		m.gitInfoPending[node.Path] = struct{}{}
+
+		path := node.Path
+		cmds = append(cmds, func() tea.Msg {
+			gitInfoSlots <- struct{}{}
+			defer func() { <-gitInfoSlots }()
+			info, err := git.GetInfo(path)
+			return gitInfoResultMsg{Path: path, Info: info, Err: err}
+		})
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	return tea.Batch(cmds...)
+}
+
+// applyGitInfo attaches cached git info to repo nodes in the visible tree.
+func (m *ImportBrowserModel) applyGitInfo() {
+	if m.scroller == nil {
+		return
+	}
+	for _, node := range m.scroller.flatTree {
+		if node.IsGitRepo && node.GitInfo == nil {
+			node.GitInfo = m.gitInfoCache[node.Path]
+		}
+	}
+}
+
+// applyVisualSelection selects every node in the visual range (except the root)
+// and leaves visual mode.
+func (m *ImportBrowserModel) applyVisualSelection() {
+	lo, hi := m.visual.bounds(m.scroller.selected, len(m.scroller.flatTree))
+	for i := lo; i <= hi; i++ {
+		if node := m.scroller.flatTree[i]; node != m.root {
+			node.IsSelected = true
+		}
+	}
+	m.visual.active = false
+}
+
+// triggerSelectionSizes starts async size calculations for selected directories
+// so the selection total can be shown.
+func (m *ImportBrowserModel) triggerSelectionSizes() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, node := range m.scroller.getSelectedNodes() {
+		if node.IsDir {
+			cmds = append(cmds, m.triggerSizeCalc(node.Path))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// selectionSize returns the total size of selected nodes and whether every size is known.
+func (m *ImportBrowserModel) selectionSize() (int64, bool) {
+	var total int64
+	complete := true
+	for _, node := range m.scroller.getSelectedNodes() {
+		size, ok := m.nodeSize(node)
+		if !ok {
+			complete = false
+			continue
+		}
+		total += size
+	}
+	return total, complete
+}
+
+// triggerTreeScans starts async size and mtime scans for the directories currently in the tree
+// when the column view or a size/mtime sort mode needs them.
+func (m *ImportBrowserModel) triggerTreeScans() tea.Cmd {
+	needSize := m.columnView || m.sortMode == treeSortSize
+	needMtime := m.columnView || m.sortMode == treeSortMtime || m.staleOnly
+	if !needSize && !needMtime {
+		return nil
+	}
+
+	// When the stale filter hides unscanned entries, scan the whole loaded tree
+	nodes := m.scroller.flatTree
+	if m.staleOnly {
+		nodes = flattenSourceTree(m.root)
+	}
+
+	var cmds []tea.Cmd
+	for _, node := range nodes {
+		if !node.IsDir || node.Path == "" || node.IsSymlink {
+			continue
+		}
+		if needSize {
+			cmds = append(cmds, m.triggerSizeCalc(node.Path))
+		}
+		if needMtime {
+			cmds = append(cmds, m.triggerMtimeCalc(node.Path))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// countReposUnder returns the number of detected git repositories at or beneath path.
+func (m *ImportBrowserModel) countReposUnder(path string) int {
+	count := len(m.gitRoots().below(path))
+	if m.gitRootSet[path] {
+		count++
+	}
+	return count
+}
+
+// triggerSelectedScans triggers async size and mtime scans for the currently selected node.
+func (m *ImportBrowserModel) triggerSelectedScans() tea.Cmd {
 	node := m.scroller.selectedNode()
-	if node == nil || !node.IsDir {
+	if node == nil || !node.IsDir || node.Path == "" {
+		m.cancelSizeStream()
 		return nil
 	}
-	return m.triggerSizeCalc(node.Path)
+	return tea.Batch(m.streamSize(node.Path), m.triggerMtimeCalc(node.Path))
 }
 
 // renderDetailsPane renders the details pane for the selected item.
@@ -4069,14 +8237,32 @@ func (m *ImportBrowserModel) renderDetailsPane() string {
 	// Show size (async for directories)
 	if size, cached, pending := m.getSizeStatus(node.Path, node.IsDir); cached {
 		sb.WriteString(fmt.Sprintf("Size:   %s\n", formatSize(size)))
+	} else if pending && m.sizeStream != nil && m.sizeStream.path == node.Path && m.sizeStream.total > 0 {
+		sb.WriteString(fmt.Sprintf("Size:   %s+ (Calculating...)\n", formatSize(m.sizeStream.total)))
 	} else if pending {
 		sb.WriteString("Size:   Calculating...\n")
 	} else if node.IsDir {
 		sb.WriteString("Size:   —\n") // Will be calculated async
 	}
 
+	// Show last modification (newest file beneath directories, async)
+	if t, ok := m.nodeModTime(node); ok {
+		modified := fmt.Sprintf("Modified: %s (%s)", formatAge(t), t.Format("2006-01-02"))
+		if m.isStale(node) {
+			sb.WriteString(ibStaleStyle.Render(modified+" — stale") + "\n")
+		} else {
+			sb.WriteString(modified + "\n")
+		}
+	} else if _, pending := m.mtimePending[node.Path]; pending {
+		sb.WriteString("Modified: Scanning...\n")
+	}
+
 	if node.IsSymlink {
-		sb.WriteString("Note:   Symbolic link\n")
+		if node.SymlinkLoop {
+			sb.WriteString(ibErrorStyle.Render("Note:   Symbolic link loop (points at its own parent folder)") + "\n")
+		} else {
+			sb.WriteString("Note:   Symbolic link\n")
+		}
 		// Show symlink target
 		if target, err := os.Readlink(node.Path); err == nil {
 			sb.WriteString(fmt.Sprintf("Target: %s\n", target))
@@ -4092,23 +8278,18 @@ func (m *ImportBrowserModel) renderDetailsPane() string {
 			} else {
 				sb.WriteString("Status: Clean\n")
 			}
-			if node.GitInfo.Remote != "" {
-				sb.WriteString(fmt.Sprintf("Remote: %s\n", node.GitInfo.Remote))
-			}
+			writeGitRemotesAndBranches(&sb, node.GitInfo)
 		}
 	} else if node.HasGitChild {
 		sb.WriteString("\n" + ibDirStyle.Render("Contains git repositories") + "\n")
 	}
+	if m.scanTruncated[node.Path] {
+		sb.WriteString("\n" + ibHelpStyle.Render(fmt.Sprintf("Scan depth %d reached: deeper repositories may exist.\nRaise git_scan.max_depth or use co import --scan-depth.", m.scanOpts.MaxDepth)) + "\n")
+	}
 
 	// Count git repos if directory
 	if node.IsDir && !node.IsGitRepo {
-		repoCount := 0
-		for gitRoot := range m.gitRootSet {
-			if strings.HasPrefix(gitRoot, node.Path+string(filepath.Separator)) || gitRoot == node.Path {
-				repoCount++
-			}
-		}
-		if repoCount > 0 {
+		if repoCount := m.countReposUnder(node.Path); repoCount > 0 {
 			sb.WriteString(fmt.Sprintf("\nRepos:  %d\n", repoCount))
 		}
 	}
@@ -4144,11 +8325,15 @@ func (m ImportBrowserModel) renderHelp() string {
 	case StateBrowse:
 		if m.filterActive {
 			help = "type to filter • enter: confirm • esc: clear"
+		} else if m.visual.active {
+			help = "-- VISUAL -- j/k: extend • V/space: select range • i/s/S/a/d/t: act on range • esc: cancel"
 		} else {
-			help = "j/k: nav • space: select • /: filter • i: import • a: add • s/S: stash • .: hidden • q: quit"
+			help = "j/k: nav • space: select • V: range • p: preview • n/R/m: mkdir/rename/move • F: folder filter • f/x: reveal/shell • A/*/I: all/siblings/invert • /: filter • i: import • a: add • s/S: stash • c: columns • o: sort • u: stale • r/^r: refresh all/folder • .: hidden • q: quit"
 		}
 	case StateImportConfig:
 		help = "tab: next field • enter: confirm • esc: cancel"
+	case StateWorkspaceConflict:
+		help = "j/k: select • 1/2/3: quick select • enter: confirm • esc: back"
 	case StateTemplateSelect:
 		help = "j/k: navigate • g/G: top/bottom • enter: select • esc: back"
 	case StateTemplateVars:
@@ -4165,32 +8350,64 @@ func (m ImportBrowserModel) renderHelp() string {
 		} else {
 			help = "enter: continue • esc: back"
 		}
+	case StateSplitSelect:
+		if m.splitRenaming {
+			help = "enter: confirm name • esc: cancel"
+		} else {
+			help = "j/k: navigate • space: toggle • r: rename • m: keep repo • enter: continue • q/esc: skip"
+		}
 	case StateImportPreview:
 		if m.dryRun {
-			help = "enter: show dry-run • d: disable dry-run • esc: back"
+			help = "enter: show dry-run • d: disable dry-run • j/k: select repo • r: rename repo • M: merge repos • esc: back"
 		} else {
-			help = "enter: execute import • d: dry-run • esc: back"
+			help = "enter: execute import • d: dry-run • j/k: select repo • r: rename repo • M: merge repos • esc: back"
 		}
 	case StateStashConfirm:
-		help = "tab: switch field • space/d: toggle delete • enter: stash • esc: cancel"
+		help = m.stashHelp()
 	case StateExtraFiles:
 		if m.extraFilesShowDest {
 			help = "enter: confirm • esc: back to selection"
 		} else {
-			help = "j/k: navigate • space: toggle • a: all • n: none • enter: continue • q/esc: skip"
+			help = "j/k: navigate • space: toggle • V: range • a: all • n: none • enter: continue • q/esc: skip"
 		}
 	case StatePostImport:
 		help = "j/k: select • 1/2/3: quick select • enter: confirm"
+	case StateOpenWorkspace:
+		help = "j/k: select • 1-4: quick select • h/l: workspace • enter: confirm • esc: back to browse"
 	case StateAddToSelect:
 		help = "j/k: navigate • g/G: top/bottom • enter: select • esc: cancel"
 	case StateBatchImportConfirm:
-		help = "enter: start import • esc: cancel"
+		help = "↑/↓: owner/project names • enter: start import • tab: choose template • esc: cancel"
+	case StateBatchImportExecute, StateBatchStashExecute:
+		help = "esc: stop after the current folder"
 	case StateBatchImportSummary:
-		help = "enter/esc: return to browse"
+		help = "o: open a workspace • enter/esc: return to browse"
+		if len(m.batchImportResults) < len(m.batchImportTargets) {
+			help = "r: resume • " + help
+		}
 	case StateBatchStashConfirm:
-		help = "d/space: toggle delete • enter: start stash • esc: cancel"
+		if m.batchStashDeleteAfter && len(m.batchStashRisks) > 0 {
+			help = "d/space: toggle delete • !: stash and delete anyway • esc: cancel"
+		} else {
+			help = "d/space: toggle delete • enter: start stash • esc: cancel"
+		}
 	case StateBatchStashSummary:
 		help = "enter/esc: return to browse"
+		if len(m.batchStashResults) < len(m.batchStashTargets) {
+			help = "r: resume • " + help
+		}
+	case StateBatchAddToConfirm:
+		help = "enter: start • esc: back"
+	case StateBatchAddToSummary:
+		help = "enter/esc: return to browse"
+	case StateBatchDeleteConfirm:
+		if len(m.batchDeleteRisks) > 0 {
+			help = "!: proceed anyway • n/esc: cancel"
+		} else {
+			help = "y/enter: confirm • n/esc: cancel"
+		}
+	case StateBatchDeleteSummary:
+		help = "enter/esc: return to browse"
 	default:
 		help = "q: quit"
 	}
@@ -4198,7 +8415,12 @@ func (m ImportBrowserModel) renderHelp() string {
 	// Add selection count for browse state
 	if m.state == StateBrowse && !m.filterActive {
 		if count := m.scroller.getSelectedCount(); count > 0 {
-			help = fmt.Sprintf("[%d selected] %s", count, help)
+			size, complete := m.selectionSize()
+			sizeStr := formatSize(size)
+			if !complete {
+				sizeStr += "+"
+			}
+			help = fmt.Sprintf("[%d selected, %s] %s", count, sizeStr, help)
 		}
 	}
 
@@ -4207,10 +8429,17 @@ func (m ImportBrowserModel) renderHelp() string {
 
 // RunImportBrowser runs the interactive import browser TUI.
 func RunImportBrowser(cfg *config.Config, rootPath string) (ImportBrowserResult, error) {
+	if err := requireFullScreen("import browser", "'co import <path>' for each folder"); err != nil {
+		return ImportBrowserResult{Error: err}, err
+	}
 	m, err := NewImportBrowser(cfg, rootPath)
 	if err != nil {
 		return ImportBrowserResult{Error: err}, err
 	}
+	session := loadSession(cfg)
+	m.applySession(&session.ImportBrowser)
+	m.lastOwner = session.LastOwner
+	m.defaults = session.ImportBrowser.Defaults
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	finalModel, err := p.Run()
@@ -4218,6 +8447,15 @@ func RunImportBrowser(cfg *config.Config, rootPath string) (ImportBrowserResult,
 		return ImportBrowserResult{Error: err}, err
 	}
 
-	result := finalModel.(ImportBrowserModel).result
-	return result, nil
+	final := finalModel.(ImportBrowserModel)
+	updateSession(cfg, func(s *tuiSession) {
+		final.recordSession(&s.ImportBrowser)
+		if final.lastOwner != "" {
+			s.LastOwner = final.lastOwner
+		}
+		if cfg.RemembersImportDefaults() {
+			s.ImportBrowser.Defaults = final.defaults
+		}
+	})
+	return final.result, nil
 }
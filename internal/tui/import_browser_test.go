@@ -1,12 +1,22 @@
 package tui
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/git/gittest"
+	"github.com/tormodhaugland/co/internal/model"
 	"github.com/tormodhaugland/co/internal/template"
 )
 
@@ -635,8 +645,59 @@ func TestBuildSourceTreeSymlink(t *testing.T) {
 	}
 }
 
-// Note: TestLoadSourceChildrenTruncation is not included because maxSourceDirEntries
-// is a const and cannot be modified in tests without refactoring to use dependency injection.
+// TestLargeDirectoryPaging tests that entries past the first page load as the
+// listing is scrolled, and that a folder filter narrows what is loaded.
+func TestLargeDirectoryPaging(t *testing.T) {
+	srcRoot := t.TempDir()
+	total := 2*sourceDirPageSize + 3
+	for i := 0; i < total; i++ {
+		if err := os.WriteFile(filepath.Join(srcRoot, fmt.Sprintf("f%04d", i)), nil, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	m, err := NewImportBrowser(&config.Config{CodeRoot: filepath.Join(t.TempDir(), "Code")}, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	if got := len(m.root.Children); got != sourceDirPageSize+1 {
+		t.Fatalf("initial children = %d, want one page plus placeholder", got)
+	}
+
+	// Jumping to the bottom brings the placeholder into view and loads the next page
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}})
+	next := result.(ImportBrowserModel)
+	if got := len(next.root.Children); got != 2*sourceDirPageSize+1 {
+		t.Fatalf("children after scrolling = %d, want two pages plus placeholder", got)
+	}
+	if node := next.scroller.selectedNode(); node == nil || node.Name != fmt.Sprintf("f%04d", sourceDirPageSize) {
+		t.Errorf("selected = %+v, want first entry of the new page", node)
+	}
+	last := next.root.Children[len(next.root.Children)-1]
+	if last.moreOf != next.root || !strings.Contains(last.Name, "3 more") {
+		t.Errorf("placeholder = %q, want 3 more entries", last.Name)
+	}
+
+	// Filter the folder; the filter survives a refresh
+	next.scroller.selectByPath(srcRoot)
+	result, _ = next.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'F'}})
+	next = result.(ImportBrowserModel)
+	next.fileOpInput.SetValue("F100")
+	result, _ = next.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	next = result.(ImportBrowserModel)
+	next.refresh()
+
+	var names []string
+	for _, child := range next.root.Children {
+		names = append(names, child.Name)
+	}
+	if strings.Join(names, ",") != "f1000,f1001,f1002" {
+		t.Errorf("filtered children = %v", names)
+	}
+	if next.root.EntryFilter != "F100" {
+		t.Errorf("EntryFilter = %q after refresh", next.root.EntryFilter)
+	}
+}
 
 // TestToggleExpand tests the toggleExpand functionality.
 func TestToggleExpand(t *testing.T) {
@@ -683,12 +744,14 @@ func TestImportBrowserStateString(t *testing.T) {
 	}{
 		{StateBrowse, "Browse"},
 		{StateImportConfig, "Import Config"},
+		{StateWorkspaceConflict, "Workspace Exists"},
 		{StateTemplateSelect, "Template Select"},
 		{StateTemplateVars, "Template Variables"},
 		{StateExtraFiles, "Extra Files"},
 		{StateImportPreview, "Import Preview"},
 		{StateImportExecute, "Importing"},
 		{StatePostImport, "Post Import"},
+		{StateOpenWorkspace, "Open Workspace"},
 		{StateStashConfirm, "Stash Confirm"},
 		{StateStashExecute, "Stashing"},
 		{StateAddToSelect, "Add To Workspace"},
@@ -698,8 +761,16 @@ func TestImportBrowserStateString(t *testing.T) {
 		{StateBatchStashConfirm, "Batch Stash Confirm"},
 		{StateBatchStashExecute, "Batch Stashing"},
 		{StateBatchStashSummary, "Batch Stash Summary"},
+		{StateBatchAddToConfirm, "Batch Add Confirm"},
+		{StateBatchAddToExecute, "Batch Adding"},
+		{StateBatchAddToSummary, "Batch Add Summary"},
+		{StateBatchDeleteConfirm, "Batch Delete Confirm"},
+		{StateBatchDeleteExecute, "Batch Deleting"},
+		{StateBatchDeleteSummary, "Batch Delete Summary"},
 		{StateDeleteConfirm, "Delete Confirm"},
 		{StateTrashConfirm, "Trash Confirm"},
+		{StateFilePreview, "File Preview"},
+		{StateFileOp, "File Operation"},
 		{StateComplete, "Complete"},
 		{ImportBrowserState(999), "Unknown"},
 	}
@@ -745,6 +816,58 @@ func TestStartImport(t *testing.T) {
 	}
 }
 
+// TestStartImportUnsluggableName tests the project suggested for folder
+// names without ASCII letters.
+func TestStartImportUnsluggableName(t *testing.T) {
+	model, err := NewImportBrowser(&config.Config{CodeRoot: filepath.Join(t.TempDir(), "Code")}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+
+	model.startImport(&sourceNode{Name: "ूप्रोजेक्ट", Path: t.TempDir(), IsDir: true})
+	if got := model.projectInput.Value(); got != "u-e1082e78" {
+		t.Errorf("projectInput = %q, want a name derived from the folder", got)
+	}
+
+	model.startImport(&sourceNode{Name: "@@@", Path: t.TempDir(), IsDir: true})
+	if got := model.projectInput.Value(); got != "" {
+		t.Errorf("projectInput = %q, want empty", got)
+	}
+	if view := model.renderImportConfigView(); !strings.Contains(view, "no slug-safe characters") {
+		t.Errorf("config form should ask for a project name:\n%s", view)
+	}
+}
+
+// TestStartImportPackageName tests that a name in package metadata is
+// suggested over the folder name.
+func TestStartImportPackageName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "old-checkout")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "@acme/Storefront"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := NewImportBrowser(&config.Config{CodeRoot: filepath.Join(t.TempDir(), "Code")}, filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	node := &sourceNode{Name: "old-checkout", Path: dir, IsDir: true}
+
+	model.startImport(node)
+	if got := model.projectInput.Value(); got != "storefront" {
+		t.Errorf("projectInput = %q, want the package name", got)
+	}
+	if view := model.renderImportConfigView(); !strings.Contains(view, `Suggested from "Storefront" in package.json`) {
+		t.Errorf("config form should say where the name came from:\n%s", view)
+	}
+
+	batch, _ := model.startBatchImport([]*sourceNode{node, {Name: "notes", Path: t.TempDir(), IsDir: true}})
+	if got := batch.(ImportBrowserModel).batchProjects; got[0] != "storefront" || got[1] != "notes" {
+		t.Errorf("batchProjects = %v, want the package name and the folder name", got)
+	}
+}
+
 // TestStartStash tests the transition from Browse to StashConfirm state.
 func TestStartStash(t *testing.T) {
 	model := &ImportBrowserModel{
@@ -793,11 +916,17 @@ func TestSanitizeForSlug(t *testing.T) {
 		{"my project", "my-project"},
 		{"My Project 123", "my-project-123"},
 		{"project@#$%", "project"},
+		{"prosjektø", "prosjekto"},
+		{"Café Crème", "cafe-creme"},
+		{"проект", "proekt"},
+		{"ूप्रोजेक्ट", "u-e1082e78"},
+		{"@@@", ""},
+		{"my__project", "my-project"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			if got := sanitizeForSlug(tt.input); got != tt.expected {
+			if got := sanitizeForSlug(nil, tt.input); got != tt.expected {
 				t.Errorf("sanitizeForSlug(%q) = %q, want %q", tt.input, got, tt.expected)
 			}
 		})
@@ -1008,6 +1137,32 @@ func TestAddToWorkspaceNavigation(t *testing.T) {
 	}
 }
 
+// TestAddToWorkspaceFilter tests narrowing the selector by slug and alias.
+func TestAddToWorkspaceFilter(t *testing.T) {
+	cfg := &config.Config{Aliases: map[string]string{"pay": "acme--payments-api"}}
+	m := &ImportBrowserModel{cfg: cfg, state: StateAddToSelect, addToFilter: textinput.New()}
+	m.setAddToWorkspaces([]string{"acme--paywall", "acme--payments-api", "me--blog"})
+
+	m.addToFilter.SetValue("blog")
+	m.applyAddToFilter()
+	if len(m.addToWorkspaces) != 1 || m.addToWorkspaces[0] != "me--blog" {
+		t.Errorf("slug filter = %v, want [me--blog]", m.addToWorkspaces)
+	}
+
+	// The workspace "pay" is an alias of comes before slugs containing it
+	m.addToFilter.SetValue("pay")
+	m.applyAddToFilter()
+	if len(m.addToWorkspaces) != 2 || m.addToWorkspaces[0] != "acme--payments-api" {
+		t.Errorf("alias filter = %v, want acme--payments-api first", m.addToWorkspaces)
+	}
+
+	m.addToFilter.SetValue("")
+	m.applyAddToFilter()
+	if len(m.addToWorkspaces) != 3 {
+		t.Errorf("empty filter = %v, want all workspaces", m.addToWorkspaces)
+	}
+}
+
 // TestClearAddToState tests the state cleanup function.
 func TestClearAddToState(t *testing.T) {
 	model := &ImportBrowserModel{
@@ -1107,6 +1262,120 @@ func TestFormatSize(t *testing.T) {
 	}
 }
 
+// TestFormatAge tests compact relative age formatting.
+func TestFormatAge(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		t        time.Time
+		expected string
+	}{
+		{now, "just now"},
+		{now.Add(-5 * time.Minute), "5m ago"},
+		{now.Add(-3 * time.Hour), "3h ago"},
+		{now.Add(-4 * 24 * time.Hour), "4d ago"},
+		{now.Add(-65 * 24 * time.Hour), "2mo ago"},
+		{now.Add(-800 * 24 * time.Hour), "2y ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := formatAge(tt.t); got != tt.expected {
+				t.Errorf("formatAge() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSortSourceChildren tests name, size, mtime, and git ordering of tree siblings.
+func TestSortSourceChildren(t *testing.T) {
+	now := time.Now()
+	newNodes := func() []*sourceNode {
+		return []*sourceNode{
+			{Name: "b.txt", Path: "/r/b.txt", Size: 10, ModTime: now.Add(-time.Hour)},
+			{Name: "alpha", Path: "/r/alpha", IsDir: true},
+			{Name: "... more entries not shown"},
+			{Name: "beta", Path: "/r/beta", IsDir: true, HasGitChild: true},
+			{Name: "gamma", Path: "/r/gamma", IsDir: true, IsGitRepo: true},
+			{Name: "a.txt", Path: "/r/a.txt", Size: 50, ModTime: now.Add(-48 * time.Hour)},
+		}
+	}
+
+	sizes := map[string]int64{"/r/alpha": 100, "/r/beta": 900}
+	mtimes := map[string]time.Time{"/r/alpha": now, "/r/gamma": now.Add(-24 * time.Hour)}
+	m := &ImportBrowserModel{sizeCache: sizes, mtimeCache: mtimes}
+
+	names := func(nodes []*sourceNode) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		mode treeSortMode
+		want []string
+	}{
+		{treeSortName, []string{"alpha", "beta", "gamma", "a.txt", "b.txt", "... more entries not shown"}},
+		// gamma's size is unknown so it sorts after known sizes
+		{treeSortSize, []string{"beta", "alpha", "gamma", "a.txt", "b.txt", "... more entries not shown"}},
+		// beta's mtime is unknown so it sorts after known mtimes
+		{treeSortMtime, []string{"alpha", "gamma", "beta", "b.txt", "a.txt", "... more entries not shown"}},
+		// Repos, then folders containing repos, then the rest by name
+		{treeSortGit, []string{"gamma", "beta", "alpha", "a.txt", "b.txt", "... more entries not shown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			nodes := newNodes()
+			sortSourceChildren(nodes, tt.mode, m.nodeSize, m.nodeModTime)
+			got := names(nodes)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("order = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestTreeSortModeNext tests cycling through sort modes.
+func TestTreeSortModeNext(t *testing.T) {
+	mode := treeSortName
+	for _, want := range []treeSortMode{treeSortSize, treeSortMtime, treeSortGit, treeSortName} {
+		mode = mode.next()
+		if mode != want {
+			t.Errorf("next() = %s, want %s", mode, want)
+		}
+	}
+}
+
+// TestParseTreeSortMode tests mapping the configured sort to a mode.
+func TestParseTreeSortMode(t *testing.T) {
+	tests := map[string]treeSortMode{
+		"":         treeSortName,
+		"name":     treeSortName,
+		"size":     treeSortSize,
+		"Modified": treeSortMtime,
+		"git":      treeSortGit,
+		"bogus":    treeSortName,
+	}
+	for value, want := range tests {
+		if got := parseTreeSortMode(value); got != want {
+			t.Errorf("parseTreeSortMode(%q) = %s, want %s", value, got, want)
+		}
+	}
+
+	cfg := &config.Config{Browser: &config.ImportBrowserConfig{Sort: "git"}}
+	m, err := NewImportBrowser(cfg, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	if m.sortMode != treeSortGit {
+		t.Errorf("sortMode = %s, want git repos first from config", m.sortMode)
+	}
+}
+
 // TestGetSizeStatus tests async size calculation and caching.
 func TestGetSizeStatus(t *testing.T) {
 	tmp := t.TempDir()
@@ -1284,6 +1553,56 @@ func TestApplyFilter(t *testing.T) {
 	}
 }
 
+// TestApplyFilterStaleOnly tests the stale (untouched >1y) filter.
+func TestApplyFilterStaleOnly(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-2 * staleThreshold)
+
+	nodes := []*sourceNode{
+		{Name: "old-project", Path: "/r/old-project", IsDir: true},
+		{Name: "new-project", Path: "/r/new-project", IsDir: true},
+		{Name: "unscanned", Path: "/r/unscanned", IsDir: true},
+		{Name: "old-notes.txt", Path: "/r/old-notes.txt", ModTime: old},
+		{Name: "new-notes.txt", Path: "/r/new-notes.txt", ModTime: now},
+	}
+	root := &sourceNode{Name: "r", Path: "/r", IsDir: true, IsExpanded: true, Children: nodes}
+
+	model := &ImportBrowserModel{
+		root:     root,
+		scroller: newSourceTreeScroller(flattenSourceTree(root), 10),
+		mtimeCache: map[string]time.Time{
+			"/r/old-project": old,
+			"/r/new-project": now,
+		},
+	}
+
+	model.staleOnly = true
+	model.applyFilter()
+
+	var names []string
+	for _, n := range model.scroller.flatTree {
+		names = append(names, n.Name)
+	}
+	if len(names) != 2 || names[0] != "old-project" || names[1] != "old-notes.txt" {
+		t.Errorf("stale filter = %v, want [old-project old-notes.txt]", names)
+	}
+
+	// Combined with text filter
+	model.filterText = "notes"
+	model.applyFilter()
+	if len(model.scroller.flatTree) != 1 || model.scroller.flatTree[0].Name != "old-notes.txt" {
+		t.Errorf("expected only old-notes.txt with text+stale filter, got %d nodes", len(model.scroller.flatTree))
+	}
+
+	// Newly scanned results are picked up on re-filter
+	model.filterText = ""
+	model.mtimeCache["/r/unscanned"] = old
+	model.applyFilter()
+	if len(model.scroller.flatTree) != 3 {
+		t.Errorf("expected 3 stale nodes after scan, got %d", len(model.scroller.flatTree))
+	}
+}
+
 // TestBuildSourceTreeHiddenFiles tests hidden file filtering.
 func TestBuildSourceTreeHiddenFiles(t *testing.T) {
 	tmp := t.TempDir()
@@ -1437,6 +1756,147 @@ func TestMultiSelect(t *testing.T) {
 	}
 }
 
+// TestSelectionBulkKeys tests select-all, sibling selection, and invert on the flat tree.
+func TestSelectionBulkKeys(t *testing.T) {
+	a1 := &sourceNode{Name: "a1", Path: "/root/a/a1", IsDir: true, Depth: 2}
+	a2 := &sourceNode{Name: "a2", Path: "/root/a/a2", IsDir: true, Depth: 2}
+	aFile := &sourceNode{Name: "a.txt", Path: "/root/a/a.txt", Depth: 2}
+	a := &sourceNode{Name: "a", Path: "/root/a", IsDir: true, IsExpanded: true, Depth: 1, Children: []*sourceNode{a1, a2, aFile}}
+	b := &sourceNode{Name: "b", Path: "/root/b", IsDir: true, Depth: 1}
+	root := &sourceNode{Name: "root", Path: "/root", IsDir: true, IsExpanded: true, Children: []*sourceNode{a, b}}
+
+	scroller := newSourceTreeScroller(flattenSourceTree(root), 20)
+	names := func() []string {
+		var out []string
+		for _, n := range scroller.getSelectedNodes() {
+			out = append(out, n.Name)
+		}
+		return out
+	}
+
+	// Siblings of a2 are a1 and a2 (files and other levels excluded)
+	for i, n := range scroller.flatTree {
+		if n == a2 {
+			scroller.selected = i
+		}
+	}
+	scroller.selectSiblingDirs()
+	if got := strings.Join(names(), ","); got != "a1,a2" {
+		t.Errorf("after siblings, selected = %s, want a1,a2", got)
+	}
+
+	// Invert flips visible directories but never the root or files
+	scroller.invertDirSelection()
+	if got := strings.Join(names(), ","); got != "a,b" {
+		t.Errorf("after invert, selected = %s, want a,b", got)
+	}
+
+	// Select all picks every visible directory except the root; a batch
+	// covers a1 and a2 through a, so they are left out
+	scroller.selectAllDirs()
+	if !a1.IsSelected || !a2.IsSelected {
+		t.Error("select all should select a1 and a2")
+	}
+	if got := strings.Join(names(), ","); got != "a,b" {
+		t.Errorf("after select all, selected = %s, want a,b", got)
+	}
+
+	// Selection total is incomplete until directory sizes are known, and
+	// does not count a1 and a2 again
+	m := ImportBrowserModel{scroller: scroller, sizeCache: map[string]int64{}, sizePending: map[string]struct{}{}}
+	if _, complete := m.selectionSize(); complete {
+		t.Error("selectionSize should be incomplete without cached sizes")
+	}
+	m.sizeCache = map[string]int64{"/root/a": 10, "/root/a/a1": 4, "/root/a/a2": 6, "/root/b": 5}
+	if size, complete := m.selectionSize(); size != 15 || !complete {
+		t.Errorf("selectionSize() = %d, %v; want 15, true", size, complete)
+	}
+}
+
+// TestVisualRange tests visual range bounds and membership.
+func TestVisualRange(t *testing.T) {
+	var v visualRange
+	if v.contains(0, 0, 5) {
+		t.Error("inactive range should contain nothing")
+	}
+
+	v.start(3)
+	if lo, hi := v.bounds(1, 5); lo != 1 || hi != 3 {
+		t.Errorf("bounds(1) = %d,%d, want 1,3", lo, hi)
+	}
+	if lo, hi := v.bounds(9, 5); lo != 3 || hi != 4 {
+		t.Errorf("bounds(9) = %d,%d, want 3,4 (clamped)", lo, hi)
+	}
+	if !v.contains(2, 1, 5) || v.contains(4, 1, 5) {
+		t.Error("contains should match the anchor..cursor range")
+	}
+}
+
+// TestVisualModeSelectsRange tests selecting a contiguous range with V in the tree.
+func TestVisualModeSelectsRange(t *testing.T) {
+	tmp := t.TempDir()
+	for _, d := range []string{"a", "b", "c", "d"} {
+		if err := os.MkdirAll(filepath.Join(tmp, d), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", d, err)
+		}
+	}
+
+	root, err := buildSourceTree(tmp, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+
+	m := ImportBrowserModel{
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    tmp,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet:  make(map[string]bool),
+		height:      30,
+		width:       80,
+	}
+
+	press := func(r rune) {
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = result.(ImportBrowserModel)
+	}
+
+	// Start on "a", extend over "b" and "c", then select
+	press('j')
+	press('V')
+	if !m.visual.active {
+		t.Fatal("V should enter visual mode")
+	}
+	press('j')
+	press('j')
+	if m.scroller.getSelectedCount() != 0 {
+		t.Error("range should not be selected until V is pressed again")
+	}
+	press('V')
+	if m.visual.active {
+		t.Error("second V should leave visual mode")
+	}
+
+	var names []string
+	for _, n := range m.scroller.getSelectedNodes() {
+		names = append(names, n.Name)
+	}
+	if got := strings.Join(names, ","); got != "a,b,c" {
+		t.Errorf("selected = %s, want a,b,c", got)
+	}
+
+	// Esc cancels a range without selecting
+	press('V')
+	press('j')
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(ImportBrowserModel)
+	if m.visual.active || m.scroller.getSelectedCount() != 3 {
+		t.Errorf("esc should cancel visual mode; active=%v selected=%d", m.visual.active, m.scroller.getSelectedCount())
+	}
+}
+
 // TestMultiSelectIncludesFiles tests that both files and directories can be selected.
 func TestMultiSelectIncludesFiles(t *testing.T) {
 	tmp := t.TempDir()
@@ -1463,8 +1923,9 @@ func TestMultiSelectIncludesFiles(t *testing.T) {
 	flatTree := flattenSourceTree(root)
 	scroller := newSourceTreeScroller(flatTree, 20)
 
-	// Mark all nodes as selected (simulating user selecting everything)
-	for _, node := range flatTree {
+	// Mark all nodes but the root as selected (simulating user selecting
+	// everything; the root cannot be selected)
+	for _, node := range flatTree[1:] {
 		node.IsSelected = true
 	}
 
@@ -1473,8 +1934,8 @@ func TestMultiSelectIncludesFiles(t *testing.T) {
 
 	// Count total selected (should include both files and directories)
 	count := scroller.getSelectedCount()
-	if count != len(flatTree) {
-		t.Errorf("selected count (%d) should match total node count (%d)", count, len(flatTree))
+	if count != len(flatTree)-1 {
+		t.Errorf("selected count (%d) should match the node count without the root (%d)", count, len(flatTree)-1)
 	}
 
 	// Verify we got both files and directories
@@ -2122,6 +2583,45 @@ func TestIntegrationStashFlow(t *testing.T) {
 	}
 }
 
+// TestStashConfirmNameKeys tests that the keys the stash form binds are
+// typed into the archive name while it has focus, and that '!' confirms
+// deleting unsaved work only from the delete option.
+func TestStashConfirmNameKeys(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "work")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m := ImportBrowserModel{
+		cfg:              &config.Config{CodeRoot: t.TempDir()},
+		state:            StateStashConfirm,
+		stashTarget:      &sourceNode{Name: "work", Path: src, IsDir: true},
+		stashDeleteAfter: true,
+		stashRisks:       []deleteRisk{{TargetName: "work"}},
+		stashNameInput:   textinput.New(),
+	}
+	m.stashNameInput.Focus()
+	press := func(key tea.KeyMsg) {
+		result, _ := m.Update(key)
+		m = result.(ImportBrowserModel)
+	}
+
+	for _, r := range "d! x" {
+		press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := m.stashNameInput.Value(); got != "d! x" || m.loading || !m.stashDeleteAfter {
+		t.Fatalf("name = %q, loading = %v, delete = %v; want the keys typed into the name", got, m.loading, m.stashDeleteAfter)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.loading || m.stashFocusIdx != 1 || m.stashError == "" {
+		t.Fatalf("enter should ask to confirm on the delete option, focus = %d, error = %q", m.stashFocusIdx, m.stashError)
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'!'}})
+	if !m.loading {
+		t.Error("! on the delete option should start the stash")
+	}
+}
+
 // TestIntegrationMultiSelectFlow tests multi-selection via key presses.
 func TestIntegrationMultiSelectFlow(t *testing.T) {
 	tmp := t.TempDir()
@@ -2190,6 +2690,99 @@ func TestIntegrationMultiSelectFlow(t *testing.T) {
 	}
 }
 
+// TestIntegrationBatchAddToFlow tests adding multiple selected folders to one workspace.
+func TestIntegrationBatchAddToFlow(t *testing.T) {
+	tmp := t.TempDir()
+	codeRoot := filepath.Join(tmp, "code")
+	srcRoot := filepath.Join(tmp, "src")
+
+	// Existing workspace
+	wsPath := filepath.Join(codeRoot, "acme--app")
+	if err := os.MkdirAll(filepath.Join(wsPath, "repos"), 0o755); err != nil {
+		t.Fatalf("mkdir workspace: %v", err)
+	}
+	if err := model.NewProject("acme--app", "acme", "app").Save(wsPath); err != nil {
+		t.Fatalf("save project.json: %v", err)
+	}
+
+	// Two source folders that are git repos
+	for _, name := range []string{"one", "two"} {
+		gitDir := filepath.Join(srcRoot, name, ".git")
+		if err := os.MkdirAll(gitDir, 0o755); err != nil {
+			t.Fatalf("mkdir .git: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+			t.Fatalf("write HEAD: %v", err)
+		}
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+	for _, child := range root.Children {
+		child.IsSelected = true
+	}
+
+	m := ImportBrowserModel{
+		cfg:         &config.Config{CodeRoot: codeRoot},
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    srcRoot,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet: map[string]bool{
+			filepath.Join(srcRoot, "one"): true,
+			filepath.Join(srcRoot, "two"): true,
+		},
+		height: 30,
+		width:  80,
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = result.(ImportBrowserModel)
+	if m.state != StateAddToSelect {
+		t.Fatalf("after 'a', state = %s, want Add To Workspace", m.state)
+	}
+	if len(m.batchAddToTargets) != 2 {
+		t.Fatalf("batchAddToTargets = %d, want 2", len(m.batchAddToTargets))
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchAddToConfirm {
+		t.Fatalf("after selecting workspace, state = %s, want Batch Add Confirm", m.state)
+	}
+	if m.addToTargetSlug != "acme--app" {
+		t.Errorf("addToTargetSlug = %q, want acme--app", m.addToTargetSlug)
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchAddToSummary {
+		t.Fatalf("after confirm, state = %s, want Batch Add Summary", m.state)
+	}
+
+	if len(m.batchAddToResults) != 2 {
+		t.Fatalf("batchAddToResults = %d, want 2", len(m.batchAddToResults))
+	}
+	for _, r := range m.batchAddToResults {
+		if !r.Success || r.RepoCount != 1 {
+			t.Errorf("%s: Success=%v RepoCount=%d, want success with 1 repo (err: %v)", r.SourceName, r.Success, r.RepoCount, r.Error)
+		}
+		if _, err := os.Stat(filepath.Join(wsPath, "repos", r.SourceName)); err != nil {
+			t.Errorf("expected repo %s in workspace: %v", r.SourceName, err)
+		}
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBrowse || m.batchAddToTargets != nil {
+		t.Errorf("expected browse state with cleared batch targets, got %s", m.state)
+	}
+}
+
 // TestIntegrationPostImportNavigation tests post-import option navigation.
 func TestIntegrationPostImportNavigation(t *testing.T) {
 	model := ImportBrowserModel{
@@ -2358,3 +2951,1830 @@ func TestIntegrationWindowResize(t *testing.T) {
 		t.Errorf("expected height=40, got %d", m.height)
 	}
 }
+
+func TestIntegrationBatchDeleteRequiresOverrideForUnpushedWork(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	srcRoot := t.TempDir()
+
+	// A repo with a local commit that was never pushed, and a plain folder
+	repoPath := filepath.Join(srcRoot, "work")
+	plainPath := filepath.Join(srcRoot, "scratch")
+	for _, dir := range []string{repoPath, plainPath} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"commit", "-q", "--allow-empty", "-m", "local only"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+	for _, child := range root.Children {
+		child.IsSelected = true
+	}
+
+	m := ImportBrowserModel{
+		cfg:         &config.Config{},
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    srcRoot,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet:  map[string]bool{repoPath: true},
+		height:      30,
+		width:       80,
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchDeleteConfirm {
+		t.Fatalf("after 'd', state = %s, want Batch Delete Confirm", m.state)
+	}
+	if len(m.batchDeleteRisks) != 1 || m.batchDeleteRisks[0].Unpushed != 1 {
+		t.Fatalf("batchDeleteRisks = %+v, want one repo with 1 unpushed commit", m.batchDeleteRisks)
+	}
+	if !strings.Contains(m.View(), "1 unpushed commits") {
+		t.Error("confirm view should list the unpushed commits")
+	}
+
+	// Plain confirmation is refused
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchDeleteConfirm {
+		t.Fatalf("after 'y', state = %s, want Batch Delete Confirm", m.state)
+	}
+	if _, err := os.Stat(repoPath); err != nil {
+		t.Fatalf("repo should still exist after refused confirm: %v", err)
+	}
+
+	// Explicit override proceeds
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'!'}})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchDeleteSummary {
+		t.Fatalf("after '!', state = %s, want Batch Delete Summary", m.state)
+	}
+	if len(m.batchDeleteResults) != 2 {
+		t.Fatalf("batchDeleteResults = %d, want 2", len(m.batchDeleteResults))
+	}
+	for _, r := range m.batchDeleteResults {
+		if !r.Success {
+			t.Errorf("delete %s failed: %v", r.SourceName, r.Error)
+		}
+		if _, err := os.Stat(r.SourcePath); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", r.SourcePath)
+		}
+	}
+}
+
+func TestIntegrationBatchImportWithTemplate(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	codeRoot := filepath.Join(tmp, "code")
+	srcRoot := filepath.Join(tmp, "src")
+	cfg := &config.Config{CodeRoot: codeRoot}
+
+	// Template with one shared variable and one templated file
+	tmplDir := filepath.Join(cfg.TemplatesDir(), "svc")
+	if err := os.MkdirAll(filepath.Join(tmplDir, "files"), 0o755); err != nil {
+		t.Fatalf("mkdir template: %v", err)
+	}
+	manifest := `{"schema": 1, "name": "svc", "description": "Service", "variables": [{"name": "team", "type": "string", "default": "core"}]}`
+	if err := os.WriteFile(filepath.Join(tmplDir, "template.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write template.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmplDir, "files", "TEAM.md.tmpl"), []byte("{{team}}/{{PROJECT}}"), 0o644); err != nil {
+		t.Fatalf("write template file: %v", err)
+	}
+
+	// Two source folders that are git repos
+	for _, name := range []string{"one", "two"} {
+		gitDir := filepath.Join(srcRoot, name, ".git")
+		if err := os.MkdirAll(gitDir, 0o755); err != nil {
+			t.Fatalf("mkdir .git: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+			t.Fatalf("write HEAD: %v", err)
+		}
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+
+	m := ImportBrowserModel{
+		cfg:              cfg,
+		state:            StateBrowse,
+		root:             root,
+		scroller:         newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:         srcRoot,
+		sizeCache:        make(map[string]int64),
+		sizePending:      make(map[string]struct{}),
+		gitRootSet:       map[string]bool{filepath.Join(srcRoot, "one"): true, filepath.Join(srcRoot, "two"): true},
+		ownerInput:       textinput.New(),
+		projectInput:     textinput.New(),
+		templateVarInput: textinput.New(),
+		height:           30,
+		width:            80,
+	}
+
+	result, _ := m.startBatchImport(root.Children)
+	m = result.(ImportBrowserModel)
+	m.ownerInput.SetValue("acme")
+
+	// Tab opens the template picker
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = result.(ImportBrowserModel)
+	if m.state != StateTemplateSelect {
+		t.Fatalf("after tab, state = %s, want Template Select", m.state)
+	}
+
+	// Pick the template; the shared variable is prompted, owner/project are not
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m = result.(ImportBrowserModel)
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateTemplateVars || len(m.templateVars) != 1 || m.templateVars[0].Name != "team" {
+		t.Fatalf("state = %s, vars = %+v; want Template Variables prompting for team", m.state, m.templateVars)
+	}
+
+	m.templateVarInput.SetValue("platform")
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchImportConfirm {
+		t.Fatalf("after variables, state = %s, want Batch Import Confirm", m.state)
+	}
+	if m.selectedTemplate != "svc" {
+		t.Fatalf("selectedTemplate = %q, want svc", m.selectedTemplate)
+	}
+
+	result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	// Each folder is imported by a command that starts the next
+	for cmd != nil {
+		result, cmd = m.Update(cmd())
+		m = result.(ImportBrowserModel)
+	}
+	if m.state != StateBatchImportSummary {
+		t.Fatalf("after confirm, state = %s, want Batch Import Summary", m.state)
+	}
+
+	for _, r := range m.batchImportResults {
+		if !r.Success || r.TemplateError != nil {
+			t.Fatalf("import %s: err=%v templateErr=%v", r.SourceName, r.Error, r.TemplateError)
+		}
+		data, err := os.ReadFile(filepath.Join(r.WorkspacePath, "TEAM.md"))
+		if err != nil {
+			t.Fatalf("read TEAM.md: %v", err)
+		}
+		want := "platform/" + r.SourceName
+		if string(data) != want {
+			t.Errorf("%s TEAM.md = %q, want %q", r.SourceName, data, want)
+		}
+	}
+}
+
+func TestIntegrationFilePreview(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "notes.txt"), []byte("first\nsecond\n"), 0o644); err != nil {
+		t.Fatalf("write notes.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "blob.bin"), []byte{0x00, 0x01, 0x02}, 0o644); err != nil {
+		t.Fatalf("write blob.bin: %v", err)
+	}
+
+	root, err := buildSourceTree(tmp, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+
+	m := ImportBrowserModel{
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    tmp,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet:  make(map[string]bool),
+		height:      30,
+		width:       80,
+	}
+
+	preview := func(name string) {
+		t.Helper()
+		if !m.scroller.selectByPath(filepath.Join(tmp, name)) {
+			t.Fatalf("%s not found in tree", name)
+		}
+		result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+		m = result.(ImportBrowserModel)
+		if m.state != StateFilePreview {
+			t.Fatalf("after 'p', state = %s, want File Preview", m.state)
+		}
+		if cmd == nil {
+			t.Fatal("expected a load command")
+		}
+		result, _ = m.Update(cmd())
+		m = result.(ImportBrowserModel)
+	}
+
+	preview("notes.txt")
+	if view := m.View(); !strings.Contains(view, "1 │ first") || !strings.Contains(view, "2 │ second") {
+		t.Errorf("preview should show numbered lines, got:\n%s", view)
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBrowse {
+		t.Fatalf("after esc, state = %s, want Browse", m.state)
+	}
+
+	preview("blob.bin")
+	if !m.previewFile.isBinary || !strings.Contains(m.View(), "Binary file") {
+		t.Error("binary file should be detected and not displayed")
+	}
+}
+
+func TestFileOperations(t *testing.T) {
+	tmp := t.TempDir()
+
+	// createFolder validates names and refuses existing entries
+	path, err := createFolder(tmp, "inbox")
+	if err != nil {
+		t.Fatalf("createFolder: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		t.Fatalf("inbox should be a directory: %v", err)
+	}
+	if _, err := createFolder(tmp, "inbox"); err == nil {
+		t.Error("createFolder should fail for an existing folder")
+	}
+	for _, bad := range []string{"", ".", "..", "a/b"} {
+		if _, err := createFolder(tmp, bad); err == nil {
+			t.Errorf("createFolder(%q) should fail", bad)
+		}
+	}
+
+	// renameEntry refuses to overwrite
+	if err := os.Mkdir(filepath.Join(tmp, "other"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := renameEntry(path, "other"); err == nil {
+		t.Error("renameEntry should not overwrite an existing entry")
+	}
+	renamed, err := renameEntry(path, "projects")
+	if err != nil {
+		t.Fatalf("renameEntry: %v", err)
+	}
+	if filepath.Base(renamed) != "projects" {
+		t.Errorf("renamed = %s, want .../projects", renamed)
+	}
+
+	// moveEntries moves into the destination but never into itself
+	if _, err := moveEntries([]string{renamed}, filepath.Join(renamed, "sub")); err == nil {
+		t.Error("moveEntries should fail for a missing destination")
+	}
+	if err := os.Mkdir(filepath.Join(renamed, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if _, err := moveEntries([]string{renamed}, filepath.Join(renamed, "sub")); err == nil {
+		t.Error("moveEntries should refuse to move a folder into itself")
+	}
+	moved, err := moveEntries([]string{filepath.Join(tmp, "other")}, renamed)
+	if err != nil {
+		t.Fatalf("moveEntries: %v", err)
+	}
+	if len(moved) != 1 || moved[0] != filepath.Join(renamed, "other") {
+		t.Errorf("moved = %v, want [%s]", moved, filepath.Join(renamed, "other"))
+	}
+}
+
+func TestIntegrationNewFolderFlow(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmp, "src"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	root, err := buildSourceTree(tmp, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+
+	m := ImportBrowserModel{
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    tmp,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet:  make(map[string]bool),
+		height:      30,
+		width:       80,
+	}
+
+	// Cursor on collapsed "src": the new folder goes next to it, in the root
+	m.scroller.selectByPath(filepath.Join(tmp, "src"))
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = result.(ImportBrowserModel)
+	if m.state != StateFileOp || m.fileOpTarget != root {
+		t.Fatalf("after 'n', state = %s, want File Operation in root", m.state)
+	}
+
+	m.fileOpInput.SetValue("archive")
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBrowse {
+		t.Fatalf("after enter, state = %s, want Browse (error: %s)", m.state, m.fileOpError)
+	}
+	if node := m.scroller.selectedNode(); node == nil || node.Path != filepath.Join(tmp, "archive") {
+		t.Errorf("cursor should move to the new folder, got %v", node)
+	}
+
+	// Rename it, then move "src" into it
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	m = result.(ImportBrowserModel)
+	m.fileOpInput.SetValue("old")
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+
+	m.scroller.selectByPath(filepath.Join(tmp, "src"))
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = result.(ImportBrowserModel)
+	m.fileOpInput.SetValue("old")
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBrowse {
+		t.Fatalf("after move, state = %s, want Browse (error: %s)", m.state, m.fileOpError)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "old", "src")); err != nil {
+		t.Errorf("src should be moved into old/: %v", err)
+	}
+	if node := m.scroller.selectedNode(); node == nil || node.Path != filepath.Join(tmp, "old", "src") {
+		t.Errorf("cursor should follow the moved folder, got %v", node)
+	}
+}
+
+func TestIntegrationDeleteSkipsConfirmWhenPolicyDisabled(t *testing.T) {
+	srcRoot := t.TempDir()
+	target := filepath.Join(srcRoot, "scratch")
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+
+	noConfirm := false
+	m := ImportBrowserModel{
+		cfg:         &config.Config{Confirm: &config.ConfirmConfig{Delete: &noConfirm}},
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    srcRoot,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet:  map[string]bool{},
+		height:      30,
+		width:       80,
+	}
+	for i, node := range m.scroller.flatTree {
+		if node.Path == target {
+			m.scroller.selected = i
+		}
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m = result.(ImportBrowserModel)
+	if m.state == StateDeleteConfirm {
+		t.Fatal("delete should not ask for confirmation when confirm.delete is false")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("%s should be removed", target)
+	}
+}
+
+func TestIntegrationDeleteRefusesCodeRootContents(t *testing.T) {
+	srcRoot := t.TempDir()
+	codeRoot := filepath.Join(srcRoot, "Code")
+	workspace := filepath.Join(codeRoot, "acme--app")
+	if err := os.MkdirAll(workspace, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	cfg := &config.Config{CodeRoot: codeRoot}
+
+	if _, err := NewImportBrowser(cfg, workspace); err == nil {
+		t.Error("NewImportBrowser() inside the code root should fail")
+	}
+	if _, err := NewImportBrowser(cfg, codeRoot); err == nil {
+		t.Error("NewImportBrowser() at the code root should fail")
+	}
+	link := filepath.Join(t.TempDir(), "code-link")
+	if err := os.Symlink(codeRoot, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if _, err := NewImportBrowser(cfg, link); err == nil {
+		t.Error("NewImportBrowser() at a link to the code root should fail")
+	}
+	if err := (ImportBrowserModel{cfg: cfg}).checkRemovable(filepath.Join(link, "acme--app")); err == nil {
+		t.Error("checkRemovable() of a workspace through a link should fail")
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+	for _, child := range root.Children {
+		child.expandNode(map[string]bool{}, false)
+	}
+
+	noConfirm := false
+	cfg.Confirm = &config.ConfirmConfig{Delete: &noConfirm}
+	m := ImportBrowserModel{
+		cfg:         cfg,
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    srcRoot,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet:  map[string]bool{},
+		height:      30,
+		width:       80,
+	}
+
+	for _, target := range []string{codeRoot, workspace} {
+		m.scroller.selected = -1
+		for i, node := range m.scroller.flatTree {
+			if node.Path == target {
+				m.scroller.selected = i
+			}
+		}
+		if m.scroller.selected < 0 {
+			t.Fatalf("%s not in tree", target)
+		}
+
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+		m = result.(ImportBrowserModel)
+		if !m.messageIsError {
+			t.Errorf("delete of %s should be refused", target)
+		}
+		if _, err := os.Stat(target); err != nil {
+			t.Errorf("%s should still exist: %v", target, err)
+		}
+		m.message, m.messageIsError = "", false
+	}
+}
+
+func TestIntegrationDeleteAndStashRequireOverrideForDirtyRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	srcRoot := t.TempDir()
+	repoPath := filepath.Join(srcRoot, "work")
+	if err := os.MkdirAll(repoPath, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if out, err := exec.Command("git", "-C", repoPath, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, "notes.txt"), []byte("draft"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+
+	// The delete policy is off, but unsaved work still needs confirmation
+	noConfirm := false
+	m := ImportBrowserModel{
+		cfg:         &config.Config{Confirm: &config.ConfirmConfig{Delete: &noConfirm}},
+		state:       StateBrowse,
+		root:        root,
+		scroller:    newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath:    srcRoot,
+		sizeCache:   make(map[string]int64),
+		sizePending: make(map[string]struct{}),
+		gitRootSet:  map[string]bool{repoPath: true},
+		height:      30,
+		width:       80,
+	}
+	for i, node := range m.scroller.flatTree {
+		if node.Path == repoPath {
+			m.scroller.selected = i
+		}
+	}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = result.(ImportBrowserModel)
+	if m.state != StateTrashConfirm {
+		t.Fatalf("after 't', state = %s, want Trash Confirm", m.state)
+	}
+	if !strings.Contains(m.View(), "uncommitted changes") {
+		t.Error("trash confirm view should list the uncommitted changes")
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateTrashConfirm {
+		t.Fatalf("after enter, state = %s, want Trash Confirm", m.state)
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(ImportBrowserModel)
+
+	// Stash with delete is refused on enter as well
+	result, _ = m.startBatchStash([]*sourceNode{m.scroller.selectedNode()}, true)
+	m = result.(ImportBrowserModel)
+	if len(m.batchStashRisks) != 1 || !m.batchStashRisks[0].Dirty {
+		t.Fatalf("batchStashRisks = %+v, want one dirty repo", m.batchStashRisks)
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchStashConfirm {
+		t.Fatalf("after enter, state = %s, want Batch Stash Confirm", m.state)
+	}
+
+	// Turning delete off allows a plain stash
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m = result.(ImportBrowserModel)
+	if !strings.Contains(m.View(), "enter: start stash") {
+		t.Error("batch stash without delete should accept enter")
+	}
+	if _, err := os.Stat(repoPath); err != nil {
+		t.Fatalf("repo should still exist: %v", err)
+	}
+}
+
+func TestGitInfoCollectedAsync(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	srcRoot := t.TempDir()
+	var repos []string
+	for _, name := range []string{"api", "web", "empty"} {
+		repo := filepath.Join(srcRoot, name)
+		if err := os.MkdirAll(repo, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		args := [][]string{{"init", "-q"}}
+		if name != "empty" {
+			args = append(args, []string{"commit", "-q", "--allow-empty", "-m", "init"})
+		}
+		for _, a := range args {
+			cmd := exec.Command("git", append([]string{"-C", repo}, a...)...)
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", a, err, out)
+			}
+		}
+		repos = append(repos, repo)
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+	for _, child := range root.Children {
+		if child.GitInfo != nil {
+			t.Fatalf("%s: buildSourceTree should not collect git info", child.Name)
+		}
+	}
+
+	m := ImportBrowserModel{
+		root:     root,
+		scroller: newSourceTreeScroller(flattenSourceTree(root), 20),
+		rootPath: srcRoot,
+	}
+	cmd := m.triggerGitInfo()
+	if cmd == nil {
+		t.Fatal("triggerGitInfo() = nil, want collection commands")
+	}
+	if len(m.gitInfoPending) != len(repos) {
+		t.Fatalf("pending = %d, want %d", len(m.gitInfoPending), len(repos))
+	}
+	if m.triggerGitInfo() != nil {
+		t.Error("in-flight repos should not be collected twice")
+	}
+
+	// Run the batched commands and feed the results back
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		t.Fatalf("cmd() = %T, want tea.BatchMsg", msg)
+	}
+	for _, c := range batch {
+		result, _ := m.Update(c())
+		m = result.(ImportBrowserModel)
+	}
+
+	if len(m.gitInfoPending) != 0 {
+		t.Errorf("pending = %v, want none", m.gitInfoPending)
+	}
+	for _, node := range m.scroller.flatTree {
+		switch node.Name {
+		case "api", "web":
+			if node.GitInfo == nil || node.GitInfo.Head == "" {
+				t.Errorf("%s: GitInfo = %+v, want populated", node.Name, node.GitInfo)
+			}
+		case "empty":
+			if node.GitInfo != nil {
+				t.Errorf("empty: GitInfo = %+v, want nil for repo without commits", node.GitInfo)
+			}
+		}
+	}
+	if m.triggerGitInfo() != nil {
+		t.Error("failed collections should not be retried before refresh")
+	}
+
+	// Updates that leave the tree alone do not look for repos to collect
+	delete(m.gitInfoCache, repos[0])
+	for _, node := range m.scroller.flatTree {
+		node.GitInfo = nil
+	}
+	result, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = result.(ImportBrowserModel)
+	if len(m.gitInfoPending) != 0 {
+		t.Errorf("pending after a resize = %v, want none", m.gitInfoPending)
+	}
+	m.scroller.updateTree(flattenSourceTree(root))
+	result, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = result.(ImportBrowserModel)
+	if _, ok := m.gitInfoPending[repos[0]]; !ok || len(m.gitInfoPending) != 1 {
+		t.Errorf("pending after the tree changed = %v, want only %s", m.gitInfoPending, repos[0])
+	}
+}
+
+func TestScanDepthMarksTruncatedFolders(t *testing.T) {
+	srcRoot := t.TempDir()
+	for _, dir := range []string{"top/.git", "a/b/deep/.git"} {
+		if err := os.MkdirAll(filepath.Join(srcRoot, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	cfg := &config.Config{
+		CodeRoot: filepath.Join(t.TempDir(), "Code"),
+		GitScan:  &config.GitScanConfig{MaxDepth: 2},
+	}
+	m, err := NewImportBrowser(cfg, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	boundary := filepath.Join(srcRoot, "a", "b")
+	if !m.gitRootSet[filepath.Join(srcRoot, "top")] || m.gitRootSet[filepath.Join(srcRoot, "a", "b", "deep")] {
+		t.Errorf("gitRootSet = %v, want only top within depth 2", m.gitRootSet)
+	}
+	if !m.scanTruncated[boundary] {
+		t.Fatalf("scanTruncated = %v, want %s", m.scanTruncated, boundary)
+	}
+
+	m.revealPath(boundary)
+	node := m.scroller.selectedNode()
+	if node == nil || node.Path != boundary {
+		t.Fatalf("selected = %+v, want %s", node, boundary)
+	}
+	if line := m.renderNode(node, false, false); !strings.Contains(line, "…") {
+		t.Errorf("renderNode() = %q, want depth marker", line)
+	}
+	if details := m.renderDetailsPane(); !strings.Contains(details, "Scan depth 2 reached") {
+		t.Errorf("details missing scan depth note:\n%s", details)
+	}
+
+	// Raising the depth finds the deeper repo
+	cfg.GitScan.MaxDepth = -1
+	m, err = NewImportBrowser(cfg, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	if !m.gitRootSet[filepath.Join(srcRoot, "a", "b", "deep")] || len(m.scanTruncated) != 0 {
+		t.Errorf("unlimited scan: gitRootSet = %v, scanTruncated = %v", m.gitRootSet, m.scanTruncated)
+	}
+}
+
+func TestRescanSubtree(t *testing.T) {
+	srcRoot := t.TempDir()
+	for _, dir := range []string{"clients/acme/.git", "other"} {
+		if err := os.MkdirAll(filepath.Join(srcRoot, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	m, err := NewImportBrowser(&config.Config{CodeRoot: filepath.Join(t.TempDir(), "Code")}, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	clients := filepath.Join(srcRoot, "clients")
+	m.revealPath(filepath.Join(clients, "acme"))
+
+	// Repos change on disk in both folders; only the rescanned one is picked up
+	if err := os.RemoveAll(filepath.Join(clients, "acme", ".git")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	for _, dir := range []string{"clients/globex/.git", "other/new/.git"} {
+		if err := os.MkdirAll(filepath.Join(srcRoot, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	m.scroller.selectByPath(clients)
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	next := result.(ImportBrowserModel)
+
+	if next.gitRootSet[filepath.Join(clients, "acme")] || !next.gitRootSet[filepath.Join(clients, "globex")] {
+		t.Errorf("gitRootSet = %v, want globex and not acme", next.gitRootSet)
+	}
+	if next.gitRootSet[filepath.Join(srcRoot, "other", "new")] {
+		t.Error("rescan should not touch folders outside the selected one")
+	}
+
+	node := next.scroller.selectedNode()
+	if node == nil || node.Path != clients || !node.IsExpanded || !node.HasGitChild {
+		t.Fatalf("selected = %+v, want expanded clients with repos", node)
+	}
+	var names []string
+	for _, child := range node.Children {
+		names = append(names, child.Name)
+		if child.Name == "globex" && !child.IsGitRepo {
+			t.Error("globex should be marked as a git repo")
+		}
+		if child.Name == "acme" && child.IsGitRepo {
+			t.Error("acme should no longer be marked as a git repo")
+		}
+	}
+	if strings.Join(names, ",") != "acme,globex" {
+		t.Errorf("children = %v, want acme,globex", names)
+	}
+}
+
+func TestHiddenPolicyFromConfig(t *testing.T) {
+	srcRoot := t.TempDir()
+	for _, name := range []string{".env", ".envrc", ".hidden", "visible"} {
+		if err := os.WriteFile(filepath.Join(srcRoot, name), nil, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	names := func(nodes []*sourceNode) string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	root, err := buildSourceTree(srcRoot, false)
+	if err != nil {
+		t.Fatalf("buildSourceTree: %v", err)
+	}
+	if got := names(root.Children); got != ".env,visible" {
+		t.Errorf("default children = %s, want .env,visible", got)
+	}
+
+	cfg := &config.Config{Browser: &config.ImportBrowserConfig{AlwaysShow: []string{".envrc"}}}
+	m, err := NewImportBrowser(cfg, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	if got := names(m.root.Children); got != ".envrc,visible" {
+		t.Errorf("children = %s, want .envrc,visible", got)
+	}
+	m.refresh()
+	if got := names(m.root.Children); got != ".envrc,visible" {
+		t.Errorf("children after refresh = %s, want .envrc,visible", got)
+	}
+
+	items, err := FindNonGitItems(srcRoot, nil, HiddenPolicyFor(cfg), nil)
+	if err != nil {
+		t.Fatalf("FindNonGitItems() error = %v", err)
+	}
+	var itemNames []string
+	for _, item := range items {
+		itemNames = append(itemNames, item.Name)
+	}
+	if got := strings.Join(itemNames, ","); got != ".envrc,visible" {
+		t.Errorf("extra files = %s, want .envrc,visible", got)
+	}
+
+	cfg.Browser.ShowHidden = true
+	m, err = NewImportBrowser(cfg, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	if !m.showHidden || len(m.root.Children) != 4 {
+		t.Errorf("show_hidden: showHidden = %v, children = %s", m.showHidden, names(m.root.Children))
+	}
+}
+
+func TestFindNonGitItemsFlagsSecrets(t *testing.T) {
+	srcRoot := t.TempDir()
+	for _, rel := range []string{".env", ".env.example", "notes.md", "deploy/server.pem", "docs/guide.md"} {
+		path := filepath.Join(srcRoot, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, err := FindNonGitItems(srcRoot, nil, HiddenPolicyFor(&config.Config{}), []string{"*.md"})
+	if err != nil {
+		t.Fatalf("FindNonGitItems() error = %v", err)
+	}
+	var secret []string
+	for _, item := range items {
+		if item.Secret {
+			secret = append(secret, item.Name)
+		}
+	}
+	if got := strings.Join(secret, ","); got != ".env,deploy,docs,notes.md" {
+		t.Errorf("secret items = %s, want .env,deploy,docs,notes.md", got)
+	}
+
+	selectAllExtraFiles(items)
+	for _, item := range items {
+		if item.Checked == item.Secret {
+			t.Errorf("select all: %s checked = %v", item.Name, item.Checked)
+		}
+	}
+	if secretsWarning(items) != "" {
+		t.Error("secretsWarning() should be empty when no secret is checked")
+	}
+	items[0].Checked = true
+	if secretsWarning(items) == "" {
+		t.Error("secretsWarning() should warn when a secret is checked")
+	}
+}
+
+func TestFollowSymlinkedFolders(t *testing.T) {
+	srcRoot := t.TempDir()
+	farm := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(farm, "app", ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(farm, filepath.Join(srcRoot, "projects")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(srcRoot, filepath.Join(srcRoot, "self")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	child := func(m *ImportBrowserModel, name string) *sourceNode {
+		for _, c := range m.root.Children {
+			if c.Name == name {
+				return c
+			}
+		}
+		t.Fatalf("no child %q under root", name)
+		return nil
+	}
+
+	cfg := &config.Config{CodeRoot: filepath.Join(t.TempDir(), "Code")}
+	m, err := NewImportBrowser(cfg, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	if node := child(m, "projects"); node.IsDir || len(m.gitRootSet) != 0 {
+		t.Errorf("default mode: projects IsDir = %v, gitRootSet = %v, want link not followed", node.IsDir, m.gitRootSet)
+	}
+
+	cfg.GitScan = &config.GitScanConfig{FollowSymlinks: true}
+	m, err = NewImportBrowser(cfg, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	repo := filepath.Join(srcRoot, "projects", "app")
+	if !m.gitRootSet[repo] || len(m.gitRootSet) != 1 {
+		t.Errorf("gitRootSet = %v, want only %s", m.gitRootSet, repo)
+	}
+	projects := child(m, "projects")
+	if !projects.IsDir || !projects.HasGitChild {
+		t.Fatalf("projects = %+v, want followed directory with a repo", projects)
+	}
+	projects.expandNode(m.gitRootSet, m.showHidden)
+	if len(projects.Children) != 1 || !projects.Children[0].IsGitRepo {
+		t.Errorf("projects children = %+v, want the app repo", projects.Children)
+	}
+
+	self := child(m, "self")
+	if self.IsDir || !self.SymlinkLoop {
+		t.Errorf("self = %+v, want loop that is not expanded", self)
+	}
+	if line := m.renderNode(self, false, false); !strings.Contains(line, "↻") {
+		t.Errorf("renderNode() = %q, want loop marker", line)
+	}
+}
+
+// TestSplitSelect tests choosing subdirectories of a repo to split on import.
+func TestSplitSelect(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "mono")
+	for _, name := range []string{"services/api/main.go", "web/index.html", ".github/ci.yml"} {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	nameInput := textinput.New()
+	m := ImportBrowserModel{
+		cfg:            &config.Config{CodeRoot: t.TempDir()},
+		importTarget:   &sourceNode{Name: "mono", Path: repo, IsGitRepo: true},
+		splitNameInput: nameInput,
+		height:         30,
+		width:          80,
+	}
+	result, _ := m.startSplitSelect()
+	m = result.(ImportBrowserModel)
+	if m.state != StateSplitSelect {
+		t.Fatalf("state = %v, want Split Repo", m.state)
+	}
+	var dirs []string
+	for _, item := range m.splitItems {
+		dirs = append(dirs, item.Dir)
+	}
+	if got := strings.Join(dirs, ","); got != "services,services/api,web" {
+		t.Errorf("split candidates = %s, want services,services/api,web (no hidden dirs)", got)
+	}
+
+	update := func(msg tea.KeyMsg) {
+		result, _ := m.Update(msg)
+		m = result.(ImportBrowserModel)
+	}
+	press := func(keys string) {
+		for _, r := range keys {
+			update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	// Split services/api, and web renamed to site
+	press("j ")
+	press("jr")
+	for range "web" {
+		update(tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	press("site")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.splitRenaming || m.splitItems[2].Name != "site" || !m.splitItems[2].Checked {
+		t.Fatalf("rename: renaming=%v item=%+v", m.splitRenaming, m.splitItems[2])
+	}
+
+	splits := m.selectedSplits()
+	if len(splits) != 2 || splits[0].Name != "api" || splits[0].Dir != "services/api" || splits[1].Name != "site" {
+		t.Errorf("selected splits = %+v", splits)
+	}
+	if roots := m.importRoots(); len(roots) != 0 {
+		t.Errorf("import roots = %v, want none while the repo is only split", roots)
+	}
+	press("m")
+	if roots := m.importRoots(); len(roots) != 1 || roots[0] != repo {
+		t.Errorf("import roots = %v, want the repo with m", roots)
+	}
+
+	// Two splits with one name are refused
+	m.splitItems[0].Checked = true
+	m.splitItems[0].Name = "api"
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.state != StateSplitSelect || !strings.Contains(m.splitError, "api") {
+		t.Errorf("duplicate names: state=%v error=%q", m.state, m.splitError)
+	}
+
+	m.splitItems[0].Checked = false
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.state == StateSplitSelect {
+		t.Error("enter should continue past the split step")
+	}
+
+	// Esc skips splitting
+	m.state = StateSplitSelect
+	update(tea.KeyMsg{Type: tea.KeyEsc})
+	if len(m.selectedSplits()) != 0 {
+		t.Errorf("esc should clear splits, got %+v", m.selectedSplits())
+	}
+}
+
+// TestImportPreviewMerge tests toggling the merge of imported repos into one.
+func TestImportPreviewMerge(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "experiments")
+	m := ImportBrowserModel{
+		cfg:          &config.Config{CodeRoot: t.TempDir()},
+		state:        StateImportPreview,
+		importTarget: &sourceNode{Name: "experiments", Path: src},
+		gitRootSet:   map[string]bool{filepath.Join(src, "a"): true, filepath.Join(src, "b"): true},
+		result:       ImportBrowserResult{WorkspaceSlug: "acme--lab"},
+		height:       30,
+		width:        80,
+	}
+
+	if m.mergeInto() != "" {
+		t.Error("repos should not be merged before M is pressed")
+	}
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'M'}})
+	m = result.(ImportBrowserModel)
+	if got := m.mergeInto(); got != "lab" {
+		t.Errorf("mergeInto() = %q, want lab", got)
+	}
+	if view := m.View(); !strings.Contains(view, "Merge into: ") || !strings.Contains(view, "repos/lab") {
+		t.Errorf("preview does not show the merge:\n%s", view)
+	}
+
+	// Adding to an existing workspace never merges
+	m.addToTargetSlug = "acme--other"
+	if m.mergeInto() != "" {
+		t.Error("add-to should not merge")
+	}
+	m.addToTargetSlug = ""
+
+	// A single repo has nothing to merge with
+	delete(m.gitRootSet, filepath.Join(src, "b"))
+	if m.canMerge() || m.mergeInto() != "" {
+		t.Error("a single repo should not be mergeable")
+	}
+}
+
+// TestImportPreviewRenameRepo tests renaming repos in the import preview to
+// resolve duplicate names.
+func TestImportPreviewRenameRepo(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "clients")
+	dash, nested := filepath.Join(src, "web-app"), filepath.Join(src, "web", "app")
+	for _, repo := range []string{dash, nested} {
+		if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte(repo), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{CodeRoot: filepath.Join(t.TempDir(), "Code")}
+	browser, err := NewImportBrowser(cfg, src)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	m := *browser
+	m.startImport(&sourceNode{Name: "clients", Path: src, IsDir: true})
+	m.state = StateImportPreview
+	m.gitRootSet = map[string]bool{dash: true, nested: true}
+	m.result.WorkspaceSlug = "acme--clients"
+	m.width, m.height = 100, 40
+	update := func(msg tea.KeyMsg) {
+		t.Helper()
+		result, _ := m.Update(msg)
+		m = result.(ImportBrowserModel)
+	}
+	press := func(keys string) {
+		t.Helper()
+		for _, r := range keys {
+			update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	// Both repos derive web-app, so the import is refused
+	if view := m.View(); !strings.Contains(view, "web/app → repos/web-app (duplicate)") {
+		t.Errorf("preview should mark the duplicate:\n%s", view)
+	}
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.state != StateImportPreview || !strings.Contains(m.repoError, "web-app") {
+		t.Fatalf("duplicate names: state=%v error=%q", m.state, m.repoError)
+	}
+
+	// Rename the second repo
+	m.repoSelected = 0
+	press("jr")
+	if !m.repoRenaming || m.repoNameInput.Value() != "web-app" {
+		t.Fatalf("r should edit the name of the selected repo, got %q", m.repoNameInput.Value())
+	}
+	m.repoNameInput.SetValue("bad/name")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.repoRenaming || m.repoError == "" {
+		t.Errorf("a name with a slash should be refused")
+	}
+	m.repoNameInput.SetValue("storefront")
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	roots := m.importRoots()
+	if m.repoRenaming || m.importRepoName(roots[1]) != "storefront" || m.importRepoName(roots[0]) != "web-app" {
+		t.Fatalf("repo names = %v", m.repoNames)
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	for _, name := range []string{"web-app", "storefront"} {
+		if _, err := os.Stat(filepath.Join(cfg.CodeRoot, "acme--clients", "repos", name, "README.md")); err != nil {
+			t.Errorf("repos/%s was not imported: %v", name, err)
+		}
+	}
+}
+
+func TestImportBrowserSnapshot(t *testing.T) {
+	// A bare .git/HEAD marks the repo and keeps sizes the same everywhere
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"inbox/api/.git/HEAD":   "ref: refs/heads/main\n",
+		"inbox/api/README.md":   "api",
+		"inbox/api/go.mod":      "module api\n",
+		"inbox/notes/todo.txt":  "todo",
+		"inbox/notes/ideas.txt": "ideas",
+		"inbox/scratch.txt":     "x",
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newModel := func() tea.Model {
+		m, err := NewImportBrowser(&config.Config{CodeRoot: "Code"}, "inbox")
+		if err != nil {
+			t.Fatalf("NewImportBrowser() error = %v", err)
+		}
+		return *m
+	}
+	runSnapshot(t, dir, newModel, []snapshotStep{
+		{name: "browse", wait: []string{"Name:   inbox", "Modified:"}},
+		{name: "repo", keys: []string{"j"}, wait: []string{"Path:   inbox/api", "Modified:"}},
+		{name: "folder", keys: []string{"j"}, wait: []string{"Path:   inbox/notes", "Modified:"}},
+		{name: "expand", keys: []string{"l"}, wait: []string{"todo.txt"}},
+		{name: "filter", keys: []string{"/", "scr"}, wait: []string{"scr"}},
+		{name: "filter cleared", keys: []string{"esc"}, wait: []string{"api"}},
+		{name: "import form", keys: []string{"j", "i"}, wait: []string{"Owner"}},
+		{name: "import typed", keys: []string{"acme", "tab", "backspace", "backspace", "backspace", "platform"}, wait: []string{"acme--platform"}},
+		{name: "import cancelled", keys: []string{"esc"}, wait: []string{"Source Folder"}},
+		{name: "multi-select", keys: []string{"space", "j", "space"}, wait: []string{"[2 selected, 44 B]"}},
+		{name: "batch import", keys: []string{"i"}, wait: []string{"Batch Import"}},
+		{name: "batch cancelled", keys: []string{"esc"}, wait: []string{"Source Folder"}},
+	})
+}
+
+// BenchmarkSourceTree opens the import browser on a synthetic tree of 100k
+// entries and 1000 repos, with 510 entries at the top level, and expands
+// every group. The tree is made once for all runs. Opening it is budgeted at
+// 100ms, nearly all of it the git scan (see BenchmarkScanGitRoots); the
+// allocation budget is enforced by TestLoadSourceChildrenBudget.
+func BenchmarkSourceTree(b *testing.B) {
+	root := b.TempDir()
+	gittest.SyntheticTree(b, root, 1000)
+
+	b.Run("build", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := buildSourceTree(root, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("expand", func(b *testing.B) {
+		tree, err := buildSourceTree(root, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		gitRoots, _ := git.FindGitRoots(root)
+		gitRootSet := make(map[string]bool, len(gitRoots))
+		for _, r := range gitRoots {
+			gitRootSet[r] = true
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, child := range tree.Children {
+				if strings.HasPrefix(child.Name, "group-") {
+					child.IsExpanded, child.Children = false, nil
+					child.expandNode(gitRootSet, false)
+				}
+			}
+		}
+	})
+}
+
+// TestLoadSourceChildrenBudget holds listing a directory to an allocation
+// budget, which unlike wall time is the same on every machine. Each listed
+// entry costs its node, its two paths, and the lstat for its size and mtime;
+// whether a folder holds repos is looked up once per page rather than by
+// testing every repo against every entry.
+func TestLoadSourceChildrenBudget(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < sourceDirPageSize/2; i++ {
+		for _, dir := range []string{fmt.Sprintf("repo-%03d/.git", i), fmt.Sprintf("group-%03d/repo/.git", i)} {
+			if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	gitRoots, err := git.FindGitRoots(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitRootSet := make(map[string]bool, len(gitRoots))
+	for _, r := range gitRoots {
+		gitRootSet[r] = true
+	}
+
+	var node *sourceNode
+	allocs := testing.AllocsPerRun(5, func() {
+		node = &sourceNode{Path: root, RelPath: ".", IsDir: true}
+		loadSourceChildren(node, gitRootSet, false)
+	})
+	if len(node.Children) != sourceDirPageSize || !node.Children[0].HasGitChild || !node.Children[len(node.Children)-1].IsGitRepo {
+		t.Fatalf("loadSourceChildren() listed %d children, want %d groups and repos", len(node.Children), sourceDirPageSize)
+	}
+	if perEntry := allocs / sourceDirPageSize; perEntry > 7.5 {
+		t.Errorf("loadSourceChildren() made %.0f allocations for %d entries (%.2f each), budget is 7.5 each", allocs, sourceDirPageSize, perEntry)
+	}
+}
+
+func TestGitRootIndexBelow(t *testing.T) {
+	ix := newGitRootIndex(map[string]bool{
+		"/src/a":       true,
+		"/src/a/b":     true,
+		"/src/a/c/d":   true,
+		"/src/a-x":     true,
+		"/src/a-x/y":   true,
+		"/src/ab":      true,
+		"/src/z/inner": true,
+	})
+	tests := map[string][]string{
+		"/src/a":   {"/src/a/b", "/src/a/c/d"},
+		"/src/a-x": {"/src/a-x/y"},
+		"/src/ab":  {},
+		"/src/z":   {"/src/z/inner"},
+		"/src/q":   {},
+		"/other":   {},
+	}
+	for dir, want := range tests {
+		if got := ix.below(dir); !slices.Equal(got, want) {
+			t.Errorf("below(%q) = %v, want %v", dir, got, want)
+		}
+	}
+	if got := len(ix.below("/src")); got != 7 {
+		t.Errorf("below(/src) found %d repos, want 7", got)
+	}
+}
+
+// TestRefreshExpandedMatchesFlatten checks that splicing rows on expand and
+// collapse gives the same list as flattening the whole tree.
+func TestRefreshExpandedMatchesFlatten(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/x/deep", "a/y", "b/z", "c"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := NewImportBrowser(&config.Config{}, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := func(nodes []*sourceNode) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.RelPath)
+		}
+		return out
+	}
+	press := func(key string) {
+		t.Helper()
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		next := result.(ImportBrowserModel)
+		m = &next
+		if got, want := paths(m.scroller.flatTree), paths(flattenSourceTree(m.root)); !slices.Equal(got, want) {
+			t.Fatalf("after %q flat tree = %v, want %v", key, got, want)
+		}
+	}
+
+	for _, key := range []string{"j", "l", "j", "l", "k", "h", "l", "j", "j", "j", "l", "k", "k", "k", "h", "j", "l"} {
+		press(key)
+	}
+	if got := paths(m.scroller.flatTree); !slices.Equal(got, []string{".", "a", "b", "b/z", "c"}) {
+		t.Errorf("flat tree = %v", got)
+	}
+}
+
+func TestStreamSizeCancelsOnSelectionChange(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "f"), make([]byte, 10), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := NewImportBrowser(&config.Config{}, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := filepath.Join(root, "a")
+	b := filepath.Join(root, "b")
+
+	cmd := m.streamSize(a)
+	if cmd == nil || m.sizeStream == nil || m.sizeStream.path != a {
+		t.Fatal("streamSize() should start a stream for a")
+	}
+	old := m.sizeStream.events
+	if m.streamSize(a) != nil {
+		t.Error("streamSize() should not restart the stream for the same path")
+	}
+
+	cmd = m.streamSize(b)
+	if _, ok := m.sizePending[a]; ok {
+		t.Error("moving the selection should cancel the stream for a")
+	}
+	// Messages of the cancelled stream are ignored
+	result, _ := m.Update(sizeProgressMsg{Path: a, Size: 5, events: old})
+	next := result.(ImportBrowserModel)
+	m = &next
+	if m.sizeStream.total != 0 {
+		t.Errorf("stale progress changed total to %d", m.sizeStream.total)
+	}
+
+	// Drain the stream for b until its result is cached
+	for msg := cmd(); ; msg = cmd() {
+		result, cmd = m.Update(msg)
+		next := result.(ImportBrowserModel)
+		m = &next
+		if _, ok := msg.(sizeResultMsg); ok {
+			break
+		}
+	}
+	if size := m.sizeCache[b]; size != 10 || m.sizeStream != nil {
+		t.Errorf("size of b = %d, stream = %v; want 10 and no stream", size, m.sizeStream)
+	}
+}
+
+func TestWorkspaceConflict(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	cfg := &config.Config{CodeRoot: filepath.Join(tmp, "code")}
+	for _, slug := range []string{"acme--api", "acme--api-2"} {
+		if err := os.MkdirAll(cfg.WorkspacePath(slug), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srcRoot := filepath.Join(tmp, "api")
+	if err := os.MkdirAll(srcRoot, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Confirming an existing workspace opens the dialog instead of failing
+	conflict := func() ImportBrowserModel {
+		m, err := NewImportBrowser(cfg, srcRoot)
+		if err != nil {
+			t.Fatalf("NewImportBrowser() error = %v", err)
+		}
+		m.startImport(m.root)
+		m.ownerInput.SetValue("acme")
+		result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		next := result.(ImportBrowserModel)
+		if next.state != StateWorkspaceConflict {
+			t.Fatalf("state = %s, want the conflict dialog", next.state)
+		}
+		return next
+	}
+	press := func(m ImportBrowserModel, keys ...tea.KeyMsg) ImportBrowserModel {
+		for _, key := range keys {
+			result, _ := m.Update(key)
+			m = result.(ImportBrowserModel)
+		}
+		return m
+	}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	m := conflict()
+	if got := len(m.conflictOptions()); got != 3 {
+		t.Fatalf("conflictOptions() = %d options, want 3", got)
+	}
+
+	// The first option imports under the first free name
+	m = press(m, enter)
+	if m.result.WorkspaceSlug != "acme--api-3" || m.projectInput.Value() != "api-3" {
+		t.Errorf("free name = %q (project %q), want acme--api-3", m.result.WorkspaceSlug, m.projectInput.Value())
+	}
+	if m.addToTargetSlug != "" {
+		t.Errorf("addToTargetSlug = %q, want a new workspace", m.addToTargetSlug)
+	}
+
+	// The second switches to adding to the existing workspace
+	m = press(conflict(), tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}}, enter)
+	if m.addToTargetSlug != "acme--api" || m.result.WorkspaceSlug != "acme--api" {
+		t.Errorf("add-to target = %q, result slug = %q, want acme--api", m.addToTargetSlug, m.result.WorkspaceSlug)
+	}
+
+	// Esc goes back to editing the name
+	m = press(conflict(), tea.KeyMsg{Type: tea.KeyEsc})
+	if m.state != StateImportConfig {
+		t.Errorf("state after esc = %s, want Import Config", m.state)
+	}
+}
+
+func TestImportConfigSuggestions(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := &config.Config{CodeRoot: filepath.Join(tmp, "code")}
+	for _, slug := range []string{"acme--api", "acme--api-gateway"} {
+		if err := os.MkdirAll(cfg.WorkspacePath(slug), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := ImportBrowserModel{cfg: cfg, ownerInput: textinput.New(), projectInput: textinput.New()}
+	m.startImport(&sourceNode{Name: "api", Path: filepath.Join(tmp, "api"), IsDir: true})
+	m.ownerInput.Focus()
+
+	// Typing the owner makes the slug collide
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("acme")})
+	m = result.(ImportBrowserModel)
+	year := fmt.Sprintf("api-%d", time.Now().Year())
+	if want := []string{"api-2", year}; !slices.Equal(m.configSuggestions, want) {
+		t.Fatalf("configSuggestions = %v, want %v", m.configSuggestions, want)
+	}
+	if want := []string{"acme--api", "acme--api-gateway"}; !slices.Equal(m.configSimilar, want) {
+		t.Errorf("configSimilar = %v, want %v", m.configSimilar, want)
+	}
+	if view := m.renderImportConfigView(); !strings.Contains(view, "already exists") || !strings.Contains(view, "api-2") {
+		t.Errorf("view does not show the suggestions:\n%s", view)
+	}
+
+	// ctrl+n cycles through the suggestions
+	ctrlN := tea.KeyMsg{Type: tea.KeyCtrlN}
+	for _, want := range []string{"api-2", year, "api-2"} {
+		result, _ = m.Update(ctrlN)
+		m = result.(ImportBrowserModel)
+		if got := m.projectInput.Value(); got != want {
+			t.Errorf("project after ctrl+n = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestImportConfigOwnerCompletion(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := &config.Config{CodeRoot: filepath.Join(tmp, "code")}
+	for _, slug := range []string{"acme--api", "acme-labs--web", "globex--web"} {
+		if err := os.MkdirAll(cfg.WorkspacePath(slug), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srcRoot := filepath.Join(tmp, "src")
+	if err := os.MkdirAll(srcRoot, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	browser, err := NewImportBrowser(cfg, srcRoot)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	m := *browser
+
+	// The last owner is the default
+	m.lastOwner = "globex"
+	m.startImport(m.root)
+	if got := m.ownerInput.Value(); got != "globex" {
+		t.Errorf("owner = %q, want the last owner", got)
+	}
+
+	// Typing lists the matching owners, and tab completes instead of moving on
+	m.ownerInput.SetValue("")
+	m.ownerInput.Focus()
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ac")})
+	m = result.(ImportBrowserModel)
+	if view := m.renderImportConfigView(); !strings.Contains(view, "Owners: acme, acme-labs") {
+		t.Errorf("view does not list the matching owners:\n%s", view)
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = result.(ImportBrowserModel)
+	if m.ownerInput.Value() != "acme" || m.configFocusIdx != 0 {
+		t.Errorf("after tab owner = %q, focus = %d; want acme with focus kept", m.ownerInput.Value(), m.configFocusIdx)
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = result.(ImportBrowserModel)
+	if m.configFocusIdx != 1 {
+		t.Errorf("focus = %d after tab on a complete owner, want the project field", m.configFocusIdx)
+	}
+}
+
+func TestTemplatePreview(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	cfg := &config.Config{CodeRoot: filepath.Join(tmp, "code")}
+
+	tmplDir := filepath.Join(cfg.TemplatesDir(), "svc")
+	globalDir := template.GetGlobalFilesPath(cfg.TemplatesDir())
+	files := map[string]string{
+		filepath.Join(tmplDir, "template.json"):        `{"schema": 1, "name": "svc", "description": "Service", "repos": [{"name": "api", "clone_url": "https://example.com/api.git"}, {"name": "infra", "init": true}]}`,
+		filepath.Join(tmplDir, "files/README.md.tmpl"): "{{PROJECT}}",
+		filepath.Join(tmplDir, "files/docs/intro.md"):  "intro",
+		filepath.Join(globalDir, ".editorconfig"):      "root = true",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := ImportBrowserModel{cfg: cfg, state: StateImportConfig, width: 120, height: 30}
+	result, _ := m.startTemplateSelect()
+	m = result.(ImportBrowserModel)
+	if m.state != StateTemplateSelect {
+		t.Fatalf("state = %s, want Template Select", m.state)
+	}
+	if view := m.renderTemplateSelectView(); !strings.Contains(view, "Only the imported repos") {
+		t.Errorf("preview of no template missing:\n%s", view)
+	}
+
+	m.templateSelected = 1
+	view := m.renderTemplateSelectView()
+	for _, want := range []string{
+		"repos/api ← https://example.com/api.git",
+		"repos/infra (new repo)",
+		"Files (3):",
+		"README.md",
+		filepath.Join("docs", "intro.md"),
+		".editorconfig (global)",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("preview does not show %q:\n%s", want, view)
+		}
+	}
+	if _, ok := m.templatePreviews["svc"]; !ok {
+		t.Error("preview of svc was not cached")
+	}
+}
+
+func TestImportStickyDefaults(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	cfg := &config.Config{CodeRoot: filepath.Join(tmp, "code")}
+	for _, name := range []string{"api", "svc"} {
+		path := filepath.Join(cfg.TemplatesDir(), name, "template.json")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{"schema": 1, "name": "`+name+`", "description": "Template"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := ImportBrowserModel{
+		cfg:       cfg,
+		height:    30,
+		lastOwner: "acme",
+		defaults:  importDefaults{Template: "svc", ExtraFilesDest: "notes", PostImport: "stash"},
+	}
+	owner, defaults := m.stickyDefaults()
+	if owner != "acme" || defaults.ExtraFilesDest != "notes" {
+		t.Errorf("stickyDefaults() = %q, %+v", owner, defaults)
+	}
+	result, _ := m.startTemplateSelect()
+	if got := result.(ImportBrowserModel).templateSelected; got != 2 {
+		t.Errorf("templateSelected = %d, want 2 (svc)", got)
+	}
+	if got := m.defaultPostImportOption(); got != 1 {
+		t.Errorf("defaultPostImportOption() = %d, want 1 (stash)", got)
+	}
+
+	// Delete is never pre-selected
+	m.defaults.PostImport = "delete"
+	if got := m.defaultPostImportOption(); got != 0 {
+		t.Errorf("defaultPostImportOption() = %d, want 0 (keep) after delete", got)
+	}
+
+	// Nothing is pre-selected with sticky_defaults off
+	off := false
+	m.cfg.Browser = &config.ImportBrowserConfig{StickyDefaults: &off}
+	m.defaults.PostImport = "stash"
+	if owner, defaults := m.stickyDefaults(); owner != "" || defaults != (importDefaults{}) {
+		t.Errorf("stickyDefaults() = %q, %+v; want none when disabled", owner, defaults)
+	}
+	result, _ = m.startTemplateSelect()
+	if got := result.(ImportBrowserModel).templateSelected; got != 0 {
+		t.Errorf("templateSelected = %d, want 0 when disabled", got)
+	}
+	if got := m.defaultPostImportOption(); got != 0 {
+		t.Errorf("defaultPostImportOption() = %d, want 0 when disabled", got)
+	}
+}
+
+func TestOpenWorkspace(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	m := ImportBrowserModel{
+		cfg:   cfg,
+		state: StateBatchImportSummary,
+		batchImportResults: []BatchImportItemResult{
+			{SourceName: "api", WorkspaceSlug: "acme--api", Success: true},
+			{SourceName: "bad", Error: errors.New("boom")},
+			{SourceName: "web", WorkspaceSlug: "acme--web", Success: true},
+		},
+	}
+
+	// The batch summary offers the workspaces that were created
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	m = result.(ImportBrowserModel)
+	if m.state != StateOpenWorkspace || !slices.Equal(m.openSlugs, []string{"acme--api", "acme--web"}) {
+		t.Fatalf("state = %s, openSlugs = %v; want Open Workspace with the created workspaces", m.state, m.openSlugs)
+	}
+	if options := m.openWorkspaceOptions(); options[m.openOption] != openBrowse {
+		t.Errorf("default option = %v, want back to browser", options[m.openOption])
+	}
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	m = result.(ImportBrowserModel)
+	if view := m.renderOpenWorkspaceView(); !strings.Contains(view, "Workspace: acme--web (2 of 2)") {
+		t.Errorf("view does not show the second workspace:\n%s", view)
+	}
+
+	// cd quits with the workspace for the shell integration
+	options := m.openWorkspaceOptions()
+	m.openOption = slices.Index(options, openChdir)
+	result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.result.ChdirTo != cfg.WorkspacePath("acme--web") {
+		t.Errorf("ChdirTo = %q, want the path of acme--web", m.result.ChdirTo)
+	}
+	if cmd == nil {
+		t.Fatal("cd did not quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("cd did not quit")
+	}
+}
+
+func TestBatchImportProjectNames(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	if err := os.MkdirAll(cfg.WorkspacePath("acme--api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	browser, err := NewImportBrowser(cfg, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	m := *browser
+	result, _ := m.startBatchImport([]*sourceNode{{Name: "My App"}, {Name: "api"}, {Name: "???"}})
+	m = result.(ImportBrowserModel)
+	m.ownerInput.SetValue("acme")
+
+	if !slices.Equal(m.batchProjects, []string{"my-app", "api", ""}) {
+		t.Fatalf("batchProjects = %q, want names derived from the folders", m.batchProjects)
+	}
+	view := m.renderBatchImportConfirmView()
+	for _, want := range []string{"My App → my-app", "api (exists)", "??? (no project name, skipped)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view does not show %q:\n%s", want, view)
+		}
+	}
+
+	// Down edits the project name of the first folder
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = result.(ImportBrowserModel)
+	if m.batchFocus != 1 || m.projectInput.Value() != "my-app" {
+		t.Fatalf("focus = %d, input = %q; want the first folder's name", m.batchFocus, m.projectInput.Value())
+	}
+	m.projectInput.SetValue("")
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("api")})
+	m = result.(ImportBrowserModel)
+	if m.batchProjects[0] != "api" {
+		t.Fatalf("batchProjects[0] = %q, want the typed name", m.batchProjects[0])
+	}
+
+	// Names used twice are refused, with the focus on the second
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchImportConfirm || !strings.Contains(m.configError, "would both be imported as api") || m.batchFocus != 2 {
+		t.Errorf("state = %s, error = %q, focus = %d; want a duplicate error on the second folder", m.state, m.configError, m.batchFocus)
+	}
+}
+
+func TestBatchStashStopAndResume(t *testing.T) {
+	tmp := t.TempDir()
+	cfg := &config.Config{CodeRoot: filepath.Join(tmp, "code")}
+	var nodes []*sourceNode
+	for _, name := range []string{"one", "two", "three"} {
+		path := filepath.Join(tmp, "src", name)
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "notes.txt"), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, &sourceNode{Name: name, Path: path})
+	}
+	browser, err := NewImportBrowser(cfg, filepath.Join(tmp, "src"))
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	m := *browser
+	m.batchStashTargets = nodes
+
+	// Esc stops after the folder being stashed
+	result, cmd := m.executeBatchStash()
+	m = result.(ImportBrowserModel)
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(ImportBrowserModel)
+	if !strings.Contains(m.renderBatchStashExecuteView(), "Stopping after the current folder") {
+		t.Error("execute view does not say the stash is stopping")
+	}
+	result, cmd = m.Update(cmd())
+	m = result.(ImportBrowserModel)
+	if m.state != StateBatchStashSummary || cmd != nil || len(m.batchStashResults) != 1 {
+		t.Fatalf("state = %s, results = %d; want the summary after one folder", m.state, len(m.batchStashResults))
+	}
+	if view := m.renderBatchStashSummaryView(); !strings.Contains(view, "Stopped after 1 of 3 folders") {
+		t.Errorf("summary does not say the stash stopped:\n%s", view)
+	}
+
+	// r resumes with the remaining folders
+	result, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = result.(ImportBrowserModel)
+	for cmd != nil {
+		result, cmd = m.Update(cmd())
+		m = result.(ImportBrowserModel)
+	}
+	if m.state != StateBatchStashSummary || len(m.batchStashResults) != 3 {
+		t.Fatalf("state = %s, results = %d; want all three folders stashed", m.state, len(m.batchStashResults))
+	}
+	for _, r := range m.batchStashResults {
+		if !r.Success {
+			t.Errorf("stash %s: %v", r.SourceName, r.Error)
+		}
+	}
+}
+
+func TestImportPreviewSkipDuplicates(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "clients")
+	repo := filepath.Join(src, "api")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(repo, ".editorconfig"): "root = true\n",
+		filepath.Join(src, ".editorconfig"):  "root = true\n",
+		filepath.Join(src, "notes.md"):       "notes\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{CodeRoot: filepath.Join(t.TempDir(), "Code")}
+	browser, err := NewImportBrowser(cfg, src)
+	if err != nil {
+		t.Fatalf("NewImportBrowser() error = %v", err)
+	}
+	m := *browser
+	m.startImport(&sourceNode{Name: "clients", Path: src, IsDir: true})
+	m.gitRootSet = map[string]bool{repo: true}
+	m.result.WorkspaceSlug = "acme--clients"
+	m.width, m.height = 100, 40
+	m.state = StateExtraFiles
+	m.extraFilesItems = []extraFileItem{
+		{Name: ".editorconfig", RelPath: ".editorconfig", Checked: true},
+		{Name: "notes.md", RelPath: "notes.md", Checked: true},
+	}
+	m.extraFilesShowDest = true
+	update := func(msg tea.KeyMsg) {
+		t.Helper()
+		result, _ := m.Update(msg)
+		m = result.(ImportBrowserModel)
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.state != StateImportPreview || len(m.extraDuplicates) != 1 {
+		t.Fatalf("state=%v duplicates=%+v, want the preview with one duplicate", m.state, m.extraDuplicates)
+	}
+	view := m.View()
+	if !strings.Contains(view, "(same as "+filepath.Join("api", ".editorconfig")+")") || !strings.Contains(view, "x skips them") {
+		t.Errorf("preview should offer to skip the duplicate:\n%s", view)
+	}
+	if got := m.importExtraFiles(); len(got) != 2 {
+		t.Errorf("importExtraFiles() = %v before skipping", got)
+	}
+
+	update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if got := m.importExtraFiles(); len(got) != 1 || got[0] != "notes.md" {
+		t.Errorf("importExtraFiles() = %v, want only notes.md", got)
+	}
+	if view := m.View(); !strings.Contains(view, "Skipping 1 duplicate(s) saves 12 B") {
+		t.Errorf("preview should report the savings:\n%s", view)
+	}
+}
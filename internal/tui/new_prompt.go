@@ -2,11 +2,14 @@ package tui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 type NewPromptResult struct {
@@ -140,6 +143,11 @@ func (m newPromptModel) View() string {
 }
 
 func RunNewPrompt() (NewPromptResult, error) {
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return NewPromptResult{Abort: true}, err
+	} else if lines {
+		return runPlainNewPrompt(), nil
+	}
 	m := newNewPromptModel()
 	p := tea.NewProgram(m)
 
@@ -158,8 +166,8 @@ func RunNewPrompt() (NewPromptResult, error) {
 // 3. Variable prompting (if template has variables)
 //
 // If templates is empty, skips template selection.
-// If codeRoot is provided, used for builtin variable resolution.
-func RunNewWorkspacePrompt(templates []template.TemplateInfo, templatesDir, codeRoot string) (NewWorkspacePromptResult, error) {
+// If cfg is provided, used for builtin variable resolution.
+func RunNewWorkspacePrompt(templates []template.TemplateInfo, templatesDir string, cfg *config.Config) (NewWorkspacePromptResult, error) {
 	result := NewWorkspacePromptResult{
 		Variables: make(map[string]string),
 	}
@@ -195,12 +203,13 @@ func RunNewWorkspacePrompt(templates []template.TemplateInfo, templatesDir, code
 		}
 
 		// Get builtin variables
-		workspacePath := ""
-		if codeRoot != "" {
-			slug := result.Owner + "--" + result.Project
-			workspacePath = codeRoot + "/" + slug
+		slug := workspace.SchemeFor(cfg).Format(result.Owner, result.Project)
+		workspacePath, codeRoot := "", ""
+		if cfg != nil {
+			workspacePath = cfg.WorkspacePath(slug)
+			codeRoot = cfg.CodeRoot
 		}
-		builtins := template.GetBuiltinVariables(result.Owner, result.Project, workspacePath, codeRoot)
+		builtins := template.GetBuiltinVariables(result.Owner, result.Project, slug, workspacePath, codeRoot)
 
 		// Only prompt for variables that need input
 		if len(tmpl.Variables) > 0 {
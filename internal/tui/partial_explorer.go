@@ -1090,6 +1090,9 @@ func filterPartialVars(vars []partial.PartialVar, values map[string]string) map[
 
 // RunPartialExplorer runs the partial explorer TUI.
 func RunPartialExplorer(cfg *config.Config) error {
+	if err := requireFullScreen("partial explorer", "'co partial list' and 'co partial show <name>'"); err != nil {
+		return err
+	}
 	partials, err := partial.ListPartials(cfg.AllPartialsDirs())
 	if err != nil {
 		return fmt.Errorf("loading partials: %w", err)
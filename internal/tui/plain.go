@@ -0,0 +1,600 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/tormodhaugland/co/internal/archive"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/errfmt"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/platform"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// plain makes the TUIs ask with numbered menus and line prompts instead of
+// full-screen views. See SetPlain.
+var plain bool
+
+// plainIn and plainOut are where plain prompts read answers and write
+// questions. Questions go to stderr so stdout stays clean for output such
+// as the path printed by co cd -r.
+var (
+	plainIn            = bufio.NewReader(os.Stdin)
+	plainOut io.Writer = os.Stderr
+)
+
+// SetPlain turns plain mode on or off. In plain mode the TUIs render
+// without colors, box drawing, spinners, or emojis, as numbered menus and
+// line-based prompts that work with screen readers and dumb terminals.
+func SetPlain(on bool) {
+	plain = on
+	if on {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// Plain reports whether plain mode is on.
+func Plain() bool {
+	return plain
+}
+
+// ErrNoTerminal is returned by the TUIs when stdin is not a terminal, as in
+// CI or with input piped in, since nobody is there to answer them.
+var ErrNoTerminal = errors.New("stdin is not a terminal, so co cannot prompt")
+
+// Terminal reports whether stdin and stdout are both terminals, so a TUI
+// can run full screen.
+func Terminal() bool {
+	return platform.IsTerminal(os.Stdin) && platform.IsTerminal(os.Stdout)
+}
+
+// linePrompts reports whether a TUI that draws on out should ask with plain
+// prompts on stderr instead: in plain mode, or when out is not a terminal,
+// such as a pipe. It returns ErrNoTerminal when stdin is not a terminal.
+func linePrompts(out *os.File) (bool, error) {
+	if !platform.IsTerminal(os.Stdin) {
+		return false, ErrNoTerminal
+	}
+	return plain || !platform.IsTerminal(out), nil
+}
+
+// requireFullScreen returns an error naming the commands to use instead
+// when a TUI without plain prompts cannot run: in plain mode, or without a
+// terminal on stdin and stdout.
+func requireFullScreen(what, instead string) error {
+	if plain {
+		return fmt.Errorf("the %s is not available in plain mode; use %s instead", what, instead)
+	}
+	if !Terminal() {
+		return fmt.Errorf("the %s needs a terminal; use %s instead", what, instead)
+	}
+	return nil
+}
+
+// plainf writes one line of plain output.
+func plainf(format string, args ...interface{}) {
+	fmt.Fprintf(plainOut, format+"\n", args...)
+}
+
+// plainRead writes prompt and reads an answer line. ok is false at the end
+// of input, which cancels like esc does in the TUIs.
+func plainRead(prompt string) (answer string, ok bool) {
+	fmt.Fprint(plainOut, prompt)
+	line, err := plainIn.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(plainOut)
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+// plainAsk asks for a line of text. A blank answer gives def.
+func plainAsk(question, def string) (string, bool) {
+	prompt := question + ": "
+	if def != "" {
+		prompt = fmt.Sprintf("%s [%s]: ", question, def)
+	}
+	answer, ok := plainRead(prompt)
+	if answer == "" {
+		answer = def
+	}
+	return answer, ok
+}
+
+// plainConfirm asks a yes or no question. A blank answer gives def.
+func plainConfirm(question string, def bool) (yes, ok bool) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, ok := plainRead(fmt.Sprintf("%s [%s]: ", question, hint))
+		if !ok {
+			return false, false
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, true
+		case "y", "yes":
+			return true, true
+		case "n", "no":
+			return false, true
+		}
+		plainf("Answer y or n.")
+	}
+}
+
+// plainChoose lists options numbered from 1 and asks for one. A blank answer
+// picks def, unless def is negative; q cancels.
+func plainChoose(title string, options []string, def int) (int, bool) {
+	plainf("%s:", title)
+	for i, option := range options {
+		plainf("  %d. %s", i+1, option)
+	}
+	prompt := "Number, or q to cancel: "
+	if def >= 0 {
+		prompt = fmt.Sprintf("Number, or q to cancel [%d]: ", def+1)
+	}
+	for {
+		answer, ok := plainRead(prompt)
+		if !ok || answer == "q" {
+			return -1, false
+		}
+		if answer == "" && def >= 0 {
+			return def, true
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, true
+		}
+		plainf("Enter a number from 1 to %d.", len(options))
+	}
+}
+
+// plainChooseMany lists options numbered from 1 with whether they are
+// checked, then toggles the numbers given, such as "1,3-5", "all", or
+// "none", until a blank answer. "?" lists the options again; q cancels.
+func plainChooseMany(title string, options []string, checked []bool) ([]bool, bool) {
+	result := make([]bool, len(options))
+	copy(result, checked)
+
+	list := func() {
+		plainf("%s:", title)
+		for i, option := range options {
+			mark := " "
+			if result[i] {
+				mark = "x"
+			}
+			plainf("  %d. [%s] %s", i+1, mark, option)
+		}
+	}
+	list()
+	for {
+		answer, ok := plainRead("Numbers to toggle (like 1,3-5), all, none, ? to list, blank when done, q to cancel: ")
+		switch {
+		case !ok || answer == "q":
+			return nil, false
+		case answer == "":
+			return result, true
+		case answer == "?":
+			list()
+			continue
+		case answer == "all" || answer == "none":
+			for i := range result {
+				result[i] = answer == "all"
+			}
+		default:
+			nums, err := parsePlainNumbers(answer, len(options))
+			if err != nil {
+				plainf("%v", err)
+				continue
+			}
+			for _, n := range nums {
+				result[n] = !result[n]
+			}
+		}
+
+		var marked []string
+		for i, on := range result {
+			if on {
+				marked = append(marked, strconv.Itoa(i+1))
+			}
+		}
+		if len(marked) == 0 {
+			plainf("Checked: none")
+		} else {
+			plainf("Checked: %s", strings.Join(marked, ", "))
+		}
+	}
+}
+
+// parsePlainNumbers parses a list of numbers and ranges from 1 to n, such
+// as "1,3-5" or "2 4", into zero-based indexes.
+func parsePlainNumbers(s string, n int) ([]int, error) {
+	var nums []int
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		lo, hi, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(lo)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(hi)
+		}
+		if err != nil || first < 1 || last > n || first > last {
+			return nil, fmt.Errorf("%q is not a number or range from 1 to %d", field, n)
+		}
+		for i := first; i <= last; i++ {
+			nums = append(nums, i-1)
+		}
+	}
+	return nums, nil
+}
+
+// plainOwnerProject asks for an owner and a project, suggesting the values
+// given.
+func plainOwnerProject(owner, project string) (string, string, bool) {
+	ask := func(name, def string) (string, bool) {
+		for {
+			value, ok := plainAsk(strings.ToUpper(name[:1])+name[1:], def)
+			if !ok {
+				return "", false
+			}
+			switch {
+			case value == "":
+				plainf("Error: %s is required", name)
+			case !isValidSlugPart(value):
+				plainf("Error: %s must be lowercase alphanumeric with hyphens", name)
+			default:
+				return value, true
+			}
+		}
+	}
+	owner, ok := ask("owner", owner)
+	if !ok {
+		return "", "", false
+	}
+	project, ok = ask("project", project)
+	if !ok {
+		return "", "", false
+	}
+	return owner, project, true
+}
+
+func runPlainConfirm(message string) ConfirmResult {
+	yes, ok := plainConfirm(message, true)
+	return ConfirmResult{Confirmed: yes, Aborted: !ok}
+}
+
+func runPlainRepoSelect(repos []model.RepoSpec, workspacePath string) RepoSelectResult {
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.Name
+	}
+	i, ok := plainChoose("Select repository", names, 0)
+	if !ok {
+		return RepoSelectResult{Abort: true}
+	}
+	return RepoSelectResult{Selected: repos[i].Name, Path: filepath.Join(workspacePath, filepath.FromSlash(repos[i].Path))}
+}
+
+func runPlainSyncPicker(records []*model.IndexRecord) SyncPickerResult {
+	options := make([]string, len(records))
+	for i, r := range records {
+		options[i] = fmt.Sprintf("%s (%s)", r.Slug, r.State)
+	}
+	checked, ok := plainChooseMany("Workspaces to sync", options, nil)
+	if !ok {
+		return SyncPickerResult{Aborted: true}
+	}
+	var result SyncPickerResult
+	for i, on := range checked {
+		if on {
+			result.Slugs = append(result.Slugs, records[i].Slug)
+		}
+	}
+	return result
+}
+
+func runPlainTemplateSelect(templates []template.TemplateInfo) TemplateSelectResult {
+	options := []string{"No template: Create an empty workspace"}
+	for _, t := range templates {
+		option := t.Name
+		if t.Description != "" {
+			option += ": " + t.Description
+		}
+		options = append(options, option)
+	}
+	i, ok := plainChoose("Select template", options, 0)
+	if !ok {
+		return TemplateSelectResult{Abort: true}
+	}
+	if i == 0 {
+		return TemplateSelectResult{}
+	}
+	t := templates[i-1]
+	return TemplateSelectResult{Selected: t.Name, TemplateInfo: &t}
+}
+
+func runPlainNewPrompt() NewPromptResult {
+	plainf("New workspace")
+	owner, project, ok := plainOwnerProject("", "")
+	return NewPromptResult{Owner: owner, Project: project, Abort: !ok}
+}
+
+func runPlainImportPrompt(sourceFolder string, gitRoots []string, suggestedOwner, suggestedProject string) ImportPromptResult {
+	plainf("Import folder to workspace")
+	plainf("Source: %s", sourceFolder)
+	switch len(gitRoots) {
+	case 0:
+		plainf("Found:  no git repositories (files only)")
+	case 1:
+		plainf("Found:  1 git repository")
+	default:
+		plainf("Found:  %d git repositories", len(gitRoots))
+	}
+	owner, project, ok := plainOwnerProject(suggestedOwner, suggestedProject)
+	return ImportPromptResult{Owner: owner, Project: project, Abort: !ok}
+}
+
+func runPlainVariablePrompt(vars []template.TemplateVar, seed map[string]string) VariablePromptResult {
+	values := make(map[string]string, len(seed)+len(vars))
+	for k, v := range seed {
+		values[k] = v
+	}
+
+	for _, v := range vars {
+		if !template.VarApplies(v, values) {
+			values[v.Name] = ""
+			continue
+		}
+		if v.Description != "" {
+			plainf("%s: %s", v.Name, v.Description)
+		}
+		def := varDefault(v, values)
+
+		switch v.Type {
+		case template.VarTypeBoolean:
+			yes, ok := plainConfirm(v.Name, def == "true" || def == "yes" || def == "1")
+			if !ok {
+				return VariablePromptResult{Abort: true}
+			}
+			values[v.Name] = strconv.FormatBool(yes)
+
+		case template.VarTypeChoice:
+			selected := 0
+			for i, choice := range v.Choices {
+				if choice == def {
+					selected = i
+				}
+			}
+			i, ok := plainChoose(v.Name, v.Choices, selected)
+			if !ok {
+				return VariablePromptResult{Abort: true}
+			}
+			values[v.Name] = v.Choices[i]
+
+		default: // string or integer
+			for {
+				value, ok := plainAsk(v.Name, def)
+				if !ok {
+					return VariablePromptResult{Abort: true}
+				}
+				if err := checkVarInput(v, value); err != nil {
+					plainf("Error: %v", err)
+					continue
+				}
+				values[v.Name] = value
+				break
+			}
+		}
+	}
+	return VariablePromptResult{Variables: values}
+}
+
+func runPlainRenamePrompt(cfg *config.Config, workspaces []*model.IndexRecord) RenamePromptResult {
+	if len(workspaces) == 0 {
+		plainf("No workspaces found. Run 'co index' first.")
+		return RenamePromptResult{Cancelled: true}
+	}
+	slugs := make([]string, len(workspaces))
+	for i, ws := range workspaces {
+		slugs[i] = ws.Slug
+	}
+	i, ok := plainChoose("Select workspace to rename", slugs, -1)
+	if !ok {
+		return RenamePromptResult{Cancelled: true}
+	}
+
+	scheme := workspace.SchemeFor(cfg)
+	current, _ := scheme.Parse(slugs[i])
+	owner, project := current.Owner, current.Project
+	for {
+		if owner, ok = plainAsk("New owner", owner); !ok {
+			return RenamePromptResult{Cancelled: true}
+		}
+		if project, ok = plainAsk("New project", project); !ok {
+			return RenamePromptResult{Cancelled: true}
+		}
+		switch {
+		case owner == "":
+			plainf("Error: Owner is required")
+		case project == "":
+			plainf("Error: Project name is required")
+		case scheme.FormatSlug(workspace.Slug{Category: current.Category, Owner: owner, Project: project}) == slugs[i]:
+			plainf("Error: New name is the same as current")
+		default:
+			return RenamePromptResult{CurrentSlug: slugs[i], NewOwner: owner, NewProject: project}
+		}
+	}
+}
+
+// runPlainExcludePicker offers the top-level entries of the workspace, with
+// the default excludes checked.
+func runPlainExcludePicker(m excludePickerModel) ExcludePickerResult {
+	var nodes []*fileNode
+	var options []string
+	var checked []bool
+	for _, node := range m.root.Children {
+		if node.IsPlaceholder {
+			continue
+		}
+		name := node.Name
+		if node.IsDir {
+			name += "/"
+		}
+		nodes = append(nodes, node)
+		options = append(options, name)
+		checked = append(checked, node.IsExcluded)
+	}
+
+	excluded, ok := plainChooseMany("Exclude from sync", options, checked)
+	if !ok {
+		return ExcludePickerResult{Aborted: true}
+	}
+	for i, node := range nodes {
+		if node.IsExcluded != excluded[i] {
+			m.toggleExclude(node)
+		}
+	}
+	save, ok := plainConfirm("Save excludes to project.json?", false)
+	if !ok {
+		return ExcludePickerResult{Aborted: true}
+	}
+	return ExcludePickerResult{Excludes: m.getExcludePatterns(), Confirmed: true, SaveRequested: save}
+}
+
+func runPlainExtraFilesPicker(items []extraFileItem) ExtraFilesResult {
+	options := make([]string, len(items))
+	checked := make([]bool, len(items))
+	for i, item := range items {
+		options[i] = item.RelPath
+		if item.IsDir {
+			options[i] += "/"
+		}
+		if item.Secret {
+			options[i] += " (likely secret)"
+		}
+		checked[i] = item.Checked
+	}
+
+	included, ok := plainChooseMany("Extra files to include", options, checked)
+	if !ok {
+		return ExtraFilesResult{Aborted: true}
+	}
+	var result ExtraFilesResult
+	for i, on := range included {
+		if on {
+			result.SelectedPaths = append(result.SelectedPaths, items[i].RelPath)
+		}
+	}
+	if len(result.SelectedPaths) > 0 {
+		dest, ok := plainAsk("Destination subfolder (blank for project root)", "")
+		if !ok {
+			return ExtraFilesResult{Aborted: true}
+		}
+		result.DestSubfolder = strings.Trim(dest, "/\\")
+	}
+	result.Confirmed = true
+	return result
+}
+
+// runPlainDashboard lists the workspaces, shows the details of the one
+// picked, and offers actions on it, until cancelled.
+func runPlainDashboard(cfg *config.Config, records []*model.IndexRecord, cold map[string]bool) error {
+	if len(records) == 0 {
+		plainf("No workspaces found. Run 'co index' first.")
+		return nil
+	}
+	options := make([]string, len(records))
+	for i, r := range records {
+		options[i] = fmt.Sprintf("%s (%s, %d repos)", r.Slug, r.State, r.RepoCount)
+		if cold[r.Slug] {
+			options[i] += ", in cold storage"
+		}
+	}
+
+	for {
+		i, ok := plainChoose("Workspaces", options, -1)
+		if !ok {
+			return nil
+		}
+		r := records[i]
+		plainf("")
+		plainf("%s", plainDetails(r))
+
+		actions := []string{"Open a shell in the workspace", "Open in editor", "Back to workspaces"}
+		if cold[r.Slug] {
+			actions = []string{"Restore from cold storage", "Back to workspaces"}
+		}
+		action, ok := plainChoose("Actions", actions, len(actions)-1)
+		if !ok {
+			return nil
+		}
+		switch actions[action] {
+		case "Open a shell in the workspace":
+			cmd := platform.ShellCommand(r.Path)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := cmd.Run(); err != nil {
+				plainf("Shell failed: %v", err)
+			}
+		case "Open in editor":
+			editor := cfg.Editor
+			if editor == "" {
+				editor = "open"
+			}
+			if err := exec.Command(editor, r.Path).Start(); err != nil {
+				plainf("Open failed: %v", err)
+			}
+		case "Restore from cold storage":
+			if _, err := archive.RestoreCold(cfg, r.Slug); err != nil {
+				plainf("Restore failed: %s", errfmt.Message(err))
+			} else {
+				plainf("Restored %s.", r.Slug)
+				delete(cold, r.Slug)
+				options[i] = fmt.Sprintf("%s (%s, %d repos)", r.Slug, r.State, r.RepoCount)
+			}
+		}
+		plainf("")
+	}
+}
+
+// plainDetails describes a workspace like the dashboard's details pane.
+func plainDetails(r *model.IndexRecord) string {
+	var sb strings.Builder
+	sb.WriteString(r.Slug + "\n")
+	sb.WriteString(fmt.Sprintf("Owner:  %s\n", r.Owner))
+	if r.CreatedBy != "" {
+		sb.WriteString(fmt.Sprintf("By:     %s\n", r.CreatedBy))
+	}
+	sb.WriteString(fmt.Sprintf("State:  %s\n", r.State))
+	sb.WriteString(fmt.Sprintf("Path:   %s\n", r.Path))
+	sb.WriteString(fmt.Sprintf("Repos:  %d\n", r.RepoCount))
+	sb.WriteString(fmt.Sprintf("Dirty:  %d\n", r.DirtyRepos))
+	sb.WriteString(fmt.Sprintf("Size:   %s\n", formatBytes(r.SizeBytes)))
+	if len(r.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("Tags:   %s\n", strings.Join(r.Tags, ", ")))
+	}
+	if r.LastCommitAt != nil {
+		sb.WriteString(fmt.Sprintf("Last commit: %s\n", r.LastCommitAt.Format("2006-01-02 15:04")))
+	}
+	for _, repo := range r.Repos {
+		dirty := ""
+		if repo.Dirty {
+			dirty = ", dirty"
+		}
+		sb.WriteString(fmt.Sprintf("Repo: %s (%s%s)\n", repo.Name, repo.Branch, dirty))
+	}
+	for _, v := range r.Violations {
+		sb.WriteString(fmt.Sprintf("Policy violation: %s\n", v))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/template"
+)
+
+// withPlainInput feeds input to the plain prompts for the rest of the test.
+func withPlainInput(t *testing.T, input string) {
+	t.Helper()
+	in, out := plainIn, plainOut
+	plainIn = bufio.NewReader(strings.NewReader(input))
+	plainOut = io.Discard
+	t.Cleanup(func() { plainIn, plainOut = in, out })
+}
+
+func TestParsePlainNumbers(t *testing.T) {
+	got, err := parsePlainNumbers("1,3-5 7", 7)
+	if err != nil {
+		t.Fatalf("parsePlainNumbers() error = %v", err)
+	}
+	if want := []int{0, 2, 3, 4, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePlainNumbers() = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"0", "8", "x", "5-3", "2-"} {
+		if _, err := parsePlainNumbers(bad, 7); err == nil {
+			t.Errorf("parsePlainNumbers(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPlainChooseMany(t *testing.T) {
+	withPlainInput(t, "9\n1-3\n2\n\n")
+	got, ok := plainChooseMany("Pick", []string{"a", "b", "c"}, []bool{true, false, false})
+	if !ok {
+		t.Fatal("plainChooseMany() cancelled")
+	}
+	if want := []bool{false, false, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("plainChooseMany() = %v, want %v", got, want)
+	}
+
+	withPlainInput(t, "q\n")
+	if _, ok := plainChooseMany("Pick", []string{"a"}, nil); ok {
+		t.Error("plainChooseMany() should cancel on q")
+	}
+}
+
+func TestRunPlainVariablePrompt(t *testing.T) {
+	vars := []template.TemplateVar{
+		{Name: "port", Type: template.VarTypeInteger, Required: true},
+		{Name: "db", Type: template.VarTypeChoice, Choices: []string{"postgres", "sqlite"}, Default: "sqlite"},
+		{Name: "ci", Type: template.VarTypeBoolean, Default: true},
+		{Name: "title", Type: template.VarTypeString, Default: "{{project}} app"},
+	}
+	withPlainInput(t, "\nabc\n8080\n1\nn\n\n")
+	result := runPlainVariablePrompt(vars, map[string]string{"project": "api"})
+	if result.Abort {
+		t.Fatal("runPlainVariablePrompt() aborted")
+	}
+	want := map[string]string{"project": "api", "port": "8080", "db": "postgres", "ci": "false", "title": "api app"}
+	if !reflect.DeepEqual(result.Variables, want) {
+		t.Errorf("Variables = %v, want %v", result.Variables, want)
+	}
+
+	withPlainInput(t, "")
+	if result := runPlainVariablePrompt(vars, nil); !result.Abort {
+		t.Error("runPlainVariablePrompt() should abort at end of input")
+	}
+}
+
+func TestRunPlainVariablePromptConditions(t *testing.T) {
+	vars := []template.TemplateVar{
+		{Name: "use_db", Type: template.VarTypeBoolean},
+		{Name: "db_name", Type: template.VarTypeString, Required: true, DependsOn: []string{"use_db"}},
+		{Name: "port", Type: template.VarTypeInteger, Default: 5432, DependsOn: []string{"use_db"}, PromptIf: "{{db_name}} != local"},
+	}
+	for input, want := range map[string]map[string]string{
+		"n\n":         {"use_db": "false", "db_name": "", "port": ""},
+		"y\nlocal\n":  {"use_db": "true", "db_name": "local", "port": ""},
+		"y\nshop\n\n": {"use_db": "true", "db_name": "shop", "port": "5432"},
+	} {
+		withPlainInput(t, input)
+		result := runPlainVariablePrompt(vars, nil)
+		if result.Abort || !reflect.DeepEqual(result.Variables, want) {
+			t.Errorf("runPlainVariablePrompt() with %q = %+v, want %v", input, result, want)
+		}
+	}
+}
+
+func TestRunPlainConfirm(t *testing.T) {
+	for input, want := range map[string]ConfirmResult{
+		"\n":         {Confirmed: true},
+		"maybe\nn\n": {},
+		"":           {Aborted: true},
+	} {
+		withPlainInput(t, input)
+		if got := runPlainConfirm("Archive?"); got != want {
+			t.Errorf("runPlainConfirm() with %q = %+v, want %+v", input, got, want)
+		}
+	}
+}
@@ -2,11 +2,15 @@ package tui
 
 import (
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var (
@@ -165,6 +169,11 @@ func isValidSlugPart(s string) bool {
 }
 
 func RunImportPrompt(sourceFolder string, gitRoots []string, suggestedOwner, suggestedProject string) (ImportPromptResult, error) {
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return ImportPromptResult{Abort: true}, err
+	} else if lines {
+		return runPlainImportPrompt(sourceFolder, gitRoots, suggestedOwner, suggestedProject), nil
+	}
 	m := newImportPromptModel(sourceFolder, gitRoots, suggestedOwner, suggestedProject)
 	p := tea.NewProgram(m)
 
@@ -176,3 +185,46 @@ func RunImportPrompt(sourceFolder string, gitRoots []string, suggestedOwner, sug
 	result := finalModel.(importPromptModel).result
 	return result, nil
 }
+
+// maxOwnerMatches limits how many matching owners are listed under an owner
+// input.
+const maxOwnerMatches = 5
+
+// setOwnerCompletion offers the owners of existing workspaces as completions
+// of an owner input.
+func setOwnerCompletion(in *textinput.Model, cfg *config.Config) {
+	owners, _ := workspace.ListOwners(cfg)
+	in.ShowSuggestions = true
+	in.SetSuggestions(owners)
+}
+
+// addOwnerCompletion adds an owner that was just used to the completions of
+// an owner input.
+func addOwnerCompletion(in *textinput.Model, owner string) {
+	owners := in.AvailableSuggestions()
+	if owner != "" && !slices.Contains(owners, owner) {
+		in.SetSuggestions(append(owners, owner))
+	}
+}
+
+// canCompleteOwner reports whether tab should complete the owner input
+// rather than move to the next field: an owner starting with what was typed
+// is longer than it.
+func canCompleteOwner(in *textinput.Model) bool {
+	return len(in.CurrentSuggestion()) > len(in.Value())
+}
+
+// renderOwnerMatches lists the owners matching what was typed into an owner
+// input, or "" when there is nothing to choose between.
+func renderOwnerMatches(in *textinput.Model) string {
+	matches := in.MatchedSuggestions()
+	if len(matches) == 0 || (len(matches) == 1 && matches[0] == in.Value()) {
+		return ""
+	}
+	more := ""
+	if len(matches) > maxOwnerMatches {
+		more = fmt.Sprintf(" (+%d more)", len(matches)-maxOwnerMatches)
+		matches = matches[:maxOwnerMatches]
+	}
+	return "Owners: " + strings.Join(matches, ", ") + more + " (tab: complete)"
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 // RenamePromptResult holds the result of the rename prompt.
@@ -148,10 +150,9 @@ func (m renameModel) handleSelectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.result.CurrentSlug = ws.Slug
 
 		// Pre-fill with current values
-		parts := strings.SplitN(ws.Slug, "--", 2)
-		if len(parts) == 2 {
-			m.ownerInput.SetValue(parts[0])
-			m.projectInput.SetValue(parts[1])
+		if parsed, ok := workspace.SchemeFor(m.cfg).Parse(ws.Slug); ok {
+			m.ownerInput.SetValue(parsed.Owner)
+			m.projectInput.SetValue(parsed.Project)
 		}
 
 		m.state = renameStateOwner
@@ -213,7 +214,7 @@ func (m renameModel) handleProjectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 		owner := strings.TrimSpace(m.ownerInput.Value())
-		newSlug := owner + "--" + project
+		newSlug := m.newSlug(owner, project)
 
 		// Check if same as current
 		if newSlug == m.result.CurrentSlug {
@@ -237,6 +238,13 @@ func (m renameModel) handleProjectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// newSlug formats the renamed slug, keeping the current workspace's category.
+func (m renameModel) newSlug(owner, project string) string {
+	scheme := workspace.SchemeFor(m.cfg)
+	current, _ := scheme.Parse(m.result.CurrentSlug)
+	return scheme.FormatSlug(workspace.Slug{Category: current.Category, Owner: owner, Project: project})
+}
+
 func (m *renameModel) ensureVisible() {
 	if m.selected < m.scrollOffset {
 		m.scrollOffset = m.selected
@@ -301,7 +309,7 @@ func (m renameModel) View() string {
 		sb.WriteString(projectLabel + m.projectInput.View() + "\n")
 
 		// Preview new slug
-		newSlug := strings.TrimSpace(m.ownerInput.Value()) + "--" + strings.TrimSpace(m.projectInput.Value())
+		newSlug := m.newSlug(strings.TrimSpace(m.ownerInput.Value()), strings.TrimSpace(m.projectInput.Value()))
 		sb.WriteString(fmt.Sprintf("\nNew slug: %s\n", newSlug))
 
 		// Error
@@ -328,6 +336,11 @@ func RunRenamePrompt(cfg *config.Config) (RenamePromptResult, error) {
 	sort.Slice(workspaces, func(i, j int) bool {
 		return workspaces[i].Slug < workspaces[j].Slug
 	})
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return RenamePromptResult{}, err
+	} else if lines {
+		return runPlainRenamePrompt(cfg, workspaces), nil
+	}
 
 	m := newRenameModel(cfg, workspaces)
 	p := tea.NewProgram(m, tea.WithAltScreen())
@@ -9,6 +9,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/muesli/termenv"
+	"github.com/tormodhaugland/co/internal/model"
 )
 
 // RepoSelectResult holds the result of repo selection.
@@ -35,12 +36,12 @@ type repoSelectModel struct {
 	result        RepoSelectResult
 }
 
-func newRepoSelectModel(repos []string, workspacePath string) repoSelectModel {
+func newRepoSelectModel(repos []model.RepoSpec, workspacePath string) repoSelectModel {
 	items := make([]list.Item, 0, len(repos))
 	for _, repo := range repos {
-		repoPath := filepath.Join(workspacePath, "repos", repo)
+		repoPath := filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
 		items = append(items, repoItem{
-			name: repo,
+			name: repo.Name,
 			path: repoPath,
 		})
 	}
@@ -103,10 +104,15 @@ func (m repoSelectModel) View() string {
 }
 
 // RunRepoSelect runs the repo selection TUI and returns the selected repo.
-func RunRepoSelect(repos []string, workspacePath string) (RepoSelectResult, error) {
+func RunRepoSelect(repos []model.RepoSpec, workspacePath string) (RepoSelectResult, error) {
 	if len(repos) == 0 {
 		return RepoSelectResult{Abort: true}, fmt.Errorf("no repositories found in workspace")
 	}
+	if lines, err := linePrompts(os.Stderr); err != nil {
+		return RepoSelectResult{Abort: true}, err
+	} else if lines {
+		return runPlainRepoSelect(repos, workspacePath), nil
+	}
 
 	// Use stderr for rendering so stdout stays clean for path output in $(co cd -r)
 	// Also configure lipgloss to detect colors from stderr, not stdout
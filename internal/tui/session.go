@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+)
+
+// sessionFileName is the file under the state dir that holds TUI session state.
+const sessionFileName = "tui-session.json"
+
+// maxSessionRoots limits how many import browser roots are remembered.
+const maxSessionRoots = 20
+
+// tuiSession is the persisted state of each TUI between runs.
+type tuiSession struct {
+	// LastOwner is the owner of the workspace last created in a TUI, the
+	// default owner of the next one
+	LastOwner string `json:"last_owner,omitempty"`
+
+	ImportBrowser    importBrowserSession    `json:"import_browser"`
+	TemplateExplorer templateExplorerSession `json:"template_explorer"`
+	Dashboard        dashboardSession        `json:"dashboard"`
+}
+
+// importBrowserSession remembers the last browse root, per-root tree state,
+// and the choices of the last import.
+type importBrowserSession struct {
+	LastRoot string                        `json:"last_root,omitempty"`
+	Roots    map[string]*importRootSession `json:"roots,omitempty"`
+	Defaults importDefaults                `json:"defaults"`
+}
+
+// importDefaults are the choices of the last import, pre-selected in the next
+// one unless sticky_defaults is off. The owner is the session's LastOwner.
+type importDefaults struct {
+	Template       string `json:"template,omitempty"`         // "" for no template
+	ExtraFilesDest string `json:"extra_files_dest,omitempty"` // Subfolder for extra files
+	PostImport     string `json:"post_import,omitempty"`      // "keep", "stash", or "delete"
+}
+
+// importRootSession is the tree state for a single browse root.
+type importRootSession struct {
+	Expanded  []string  `json:"expanded,omitempty"`
+	Selected  string    `json:"selected,omitempty"`
+	Filter    string    `json:"filter,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// templateExplorerSession remembers the active tab and selected template.
+type templateExplorerSession struct {
+	Tab      string `json:"tab,omitempty"`
+	Template string `json:"template,omitempty"`
+}
+
+// dashboardSession remembers the selected workspace and the collapsed owner
+// sections.
+type dashboardSession struct {
+	Selected  string   `json:"selected,omitempty"`
+	Collapsed []string `json:"collapsed,omitempty"`
+}
+
+// sessionPath returns the path of the session state file.
+func sessionPath(cfg *config.Config) string {
+	return filepath.Join(cfg.StateDir(), sessionFileName)
+}
+
+// loadSession reads persisted session state. A missing or unreadable file
+// yields an empty session so the TUIs always start.
+func loadSession(cfg *config.Config) *tuiSession {
+	s := &tuiSession{}
+	data, err := os.ReadFile(sessionPath(cfg))
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return &tuiSession{}
+	}
+	return s
+}
+
+// saveSession writes session state, replacing the previous file atomically.
+func saveSession(cfg *config.Config, s *tuiSession) error {
+	s.ImportBrowser.prune()
+
+	path := sessionPath(cfg)
+	if err := os.MkdirAll(filepath.Dir(path), fs.DirPerm()); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, fs.FilePerm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// updateSession loads the session, applies fn, and saves it. Errors are ignored:
+// losing session state should never fail the command.
+func updateSession(cfg *config.Config, fn func(s *tuiSession)) {
+	s := loadSession(cfg)
+	fn(s)
+	_ = saveSession(cfg, s)
+}
+
+// prune drops the least recently used roots beyond maxSessionRoots.
+func (s *importBrowserSession) prune() {
+	if len(s.Roots) <= maxSessionRoots {
+		return
+	}
+	roots := make([]string, 0, len(s.Roots))
+	for root := range s.Roots {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return s.Roots[roots[i]].UpdatedAt.After(s.Roots[roots[j]].UpdatedAt)
+	})
+	for _, root := range roots[maxSessionRoots:] {
+		delete(s.Roots, root)
+	}
+}
+
+// LastImportRoot returns the browse root of the previous import browser session,
+// or an empty string if none was recorded or it no longer exists.
+func LastImportRoot(cfg *config.Config) string {
+	root := loadSession(cfg).ImportBrowser.LastRoot
+	if root == "" {
+		return ""
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return ""
+	}
+	return root
+}
+
+// applySession restores expansion, filter, and cursor position for the browse root.
+func (m *ImportBrowserModel) applySession(s *importBrowserSession) {
+	rs, ok := s.Roots[m.rootPath]
+	if !ok || rs == nil {
+		return
+	}
+
+	// The root is always expanded; restoration walks down from it
+	expanded := map[string]bool{m.rootPath: true}
+	for _, path := range rs.Expanded {
+		expanded[path] = true
+	}
+	m.restoreExpandedPaths(expanded, nil)
+	m.refreshTree()
+
+	if rs.Filter != "" {
+		m.filterInput.SetValue(rs.Filter)
+		m.filterText = rs.Filter
+		m.applyFilter()
+	}
+	if rs.Selected != "" {
+		m.scroller.selectByPath(rs.Selected)
+	}
+}
+
+// recordSession stores the browser's current tree state for its browse root.
+func (m ImportBrowserModel) recordSession(s *importBrowserSession) {
+	rs := &importRootSession{
+		Filter:    m.filterText,
+		UpdatedAt: time.Now(),
+	}
+	for path := range m.collectExpandedPaths() {
+		if path != m.rootPath {
+			rs.Expanded = append(rs.Expanded, path)
+		}
+	}
+	sort.Strings(rs.Expanded)
+	if node := m.scroller.selectedNode(); node != nil {
+		rs.Selected = node.Path
+	}
+
+	if s.Roots == nil {
+		s.Roots = make(map[string]*importRootSession)
+	}
+	s.Roots[m.rootPath] = rs
+	s.LastRoot = m.rootPath
+}
+
+// applySession restores the active tab and selected template.
+func (m TemplateExplorerModel) applySession(s templateExplorerSession) TemplateExplorerModel {
+	if s.Template != "" {
+		for i, listing := range m.listings {
+			if listing.Info.Name == s.Template {
+				m.list.Select(i)
+				m.selected = &m.listings[i]
+				break
+			}
+		}
+	}
+	if tab, ok := parseTab(s.Tab); ok && tab != m.activeTab {
+		updated, _ := m.switchTab(tab)
+		m = updated.(TemplateExplorerModel)
+	}
+	return m
+}
+
+// recordSession stores the explorer's active tab and selected template.
+func (m TemplateExplorerModel) recordSession(s *templateExplorerSession) {
+	s.Tab = m.activeTab.String()
+	s.Template = ""
+	if m.selected != nil {
+		s.Template = m.selected.Info.Name
+	}
+}
+
+// parseTab is the inverse of Tab.String.
+func parseTab(name string) (Tab, bool) {
+	for tab := TabBrowse; tab <= TabValidate; tab++ {
+		if tab.String() == name {
+			return tab, true
+		}
+	}
+	return TabBrowse, false
+}
+
+// applySession restores the collapsed owners and the selected workspace.
+// Without a selection to restore, the cursor stays on the first header.
+func (m Model) applySession(s dashboardSession) Model {
+	if len(s.Collapsed) > 0 {
+		for _, owner := range s.Collapsed {
+			m.collapsed[owner] = true
+		}
+		m.show(m.shown)
+		m.list.Select(0)
+		m.selected = nil
+	}
+	if s.Selected == "" {
+		return m
+	}
+	for i, item := range m.list.Items() {
+		if w, ok := item.(workspaceItem); ok && w.record.Slug == s.Selected {
+			m.list.Select(i)
+			m.selected = w.record
+			break
+		}
+	}
+	return m
+}
+
+// recordSession stores the dashboard's selected workspace and collapsed
+// owners.
+func (m Model) recordSession(s *dashboardSession) {
+	s.Selected = ""
+	if m.selected != nil {
+		s.Selected = m.selected.Slug
+	}
+	s.Collapsed = nil
+	for owner, collapsed := range m.collapsed {
+		if collapsed {
+			s.Collapsed = append(s.Collapsed, owner)
+		}
+	}
+	sort.Strings(s.Collapsed)
+}
@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := &config.Config{}
+
+	// Missing file yields an empty session
+	if s := loadSession(cfg); s.ImportBrowser.LastRoot != "" || s.Dashboard.Selected != "" {
+		t.Errorf("loadSession() without file = %+v, want empty", s)
+	}
+
+	root := t.TempDir()
+	updateSession(cfg, func(s *tuiSession) {
+		s.ImportBrowser.LastRoot = root
+		s.TemplateExplorer.Tab = TabFiles.String()
+		s.Dashboard.Selected = "acme--api"
+	})
+
+	s := loadSession(cfg)
+	if s.ImportBrowser.LastRoot != root {
+		t.Errorf("LastRoot = %q, want %q", s.ImportBrowser.LastRoot, root)
+	}
+	if s.TemplateExplorer.Tab != "Files" || s.Dashboard.Selected != "acme--api" {
+		t.Errorf("session = %+v, want Files tab and acme--api selected", s)
+	}
+	if got := LastImportRoot(cfg); got != root {
+		t.Errorf("LastImportRoot() = %q, want %q", got, root)
+	}
+
+	// A root that no longer exists is not offered
+	updateSession(cfg, func(s *tuiSession) {
+		s.ImportBrowser.LastRoot = filepath.Join(root, "gone")
+	})
+	if got := LastImportRoot(cfg); got != "" {
+		t.Errorf("LastImportRoot() for missing dir = %q, want empty", got)
+	}
+
+	// A corrupt file is ignored
+	if err := os.WriteFile(sessionPath(cfg), []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if s := loadSession(cfg); s.ImportBrowser.LastRoot != "" {
+		t.Errorf("loadSession() with corrupt file = %+v, want empty", s)
+	}
+}
+
+func TestSessionPrunesOldRoots(t *testing.T) {
+	s := importBrowserSession{Roots: make(map[string]*importRootSession)}
+	now := time.Now()
+	for i := 0; i < maxSessionRoots+5; i++ {
+		s.Roots[fmt.Sprintf("/root%d", i)] = &importRootSession{UpdatedAt: now.Add(time.Duration(i) * time.Minute)}
+	}
+
+	s.prune()
+
+	if len(s.Roots) != maxSessionRoots {
+		t.Fatalf("len(Roots) = %d, want %d", len(s.Roots), maxSessionRoots)
+	}
+	if _, ok := s.Roots["/root0"]; ok {
+		t.Error("oldest root should have been pruned")
+	}
+	if _, ok := s.Roots[fmt.Sprintf("/root%d", maxSessionRoots+4)]; !ok {
+		t.Error("newest root should be kept")
+	}
+}
+
+func TestImportBrowserSessionRestore(t *testing.T) {
+	tmp := t.TempDir()
+	for _, d := range []string{"alpha/inner", "beta/nested", "gamma"} {
+		if err := os.MkdirAll(filepath.Join(tmp, d), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	cfg := &config.Config{}
+
+	m, err := NewImportBrowser(cfg, tmp)
+	if err != nil {
+		t.Fatalf("NewImportBrowser: %v", err)
+	}
+	beta := filepath.Join(tmp, "beta")
+	nested := filepath.Join(beta, "nested")
+	if !m.scroller.selectByPath(beta) {
+		t.Fatalf("select beta failed")
+	}
+	m.scroller.selectedNode().expandNode(m.gitRootSet, m.showHidden)
+	m.refreshTree()
+	m.scroller.selectByPath(nested)
+
+	var s importBrowserSession
+	m.recordSession(&s)
+	if s.LastRoot != tmp {
+		t.Errorf("LastRoot = %q, want %q", s.LastRoot, tmp)
+	}
+	rs := s.Roots[tmp]
+	if rs == nil || rs.Selected != nested || len(rs.Expanded) != 1 || rs.Expanded[0] != beta {
+		t.Fatalf("recorded root session = %+v, want beta expanded and nested selected", rs)
+	}
+
+	// A fresh browser on the same root resumes the recorded state
+	restored, err := NewImportBrowser(cfg, tmp)
+	if err != nil {
+		t.Fatalf("NewImportBrowser: %v", err)
+	}
+	restored.applySession(&s)
+	if node := restored.scroller.selectedNode(); node == nil || node.Path != nested {
+		t.Errorf("restored selection = %v, want %s", node, nested)
+	}
+
+	// The filter is restored too
+	s.Roots[tmp].Filter = "gam"
+	s.Roots[tmp].Selected = filepath.Join(tmp, "gamma")
+	filtered, _ := NewImportBrowser(cfg, tmp)
+	filtered.applySession(&s)
+	if filtered.filterText != "gam" || filtered.filterInput.Value() != "gam" {
+		t.Errorf("filter = %q/%q, want gam", filtered.filterText, filtered.filterInput.Value())
+	}
+	if node := filtered.scroller.selectedNode(); node == nil || node.Name != "gamma" {
+		t.Errorf("filtered selection = %v, want gamma", node)
+	}
+}
+
+func TestDashboardOwnerSections(t *testing.T) {
+	records := []*model.IndexRecord{
+		{Slug: "solo--tool", Owner: "solo", RepoCount: 1},
+		{Slug: "acme--web", Owner: "acme", RepoCount: 1},
+		{Slug: "acme--api", Owner: "acme", RepoCount: 2, DirtyRepos: 1},
+	}
+	m := New(&config.Config{}, records)
+	if n := len(m.list.Items()); n != 5 {
+		t.Fatalf("items = %d, want 2 owner headers and 3 workspaces", n)
+	}
+	if header, ok := m.list.Items()[0].(ownerItem); !ok || header.group.Owner != "acme" || header.Description() != "2 workspaces • 3 repos • 1 dirty • 0 B" {
+		t.Errorf("first item = %+v, want the acme header with its totals", m.list.Items()[0])
+	}
+	if m.selected == nil || m.selected.Owner != "acme" {
+		t.Errorf("selected = %+v, want the first acme workspace", m.selected)
+	}
+
+	// Collapsing from a workspace moves the cursor to its owner's header
+	m.toggleOwner()
+	if n := len(m.list.Items()); n != 3 || m.list.Index() != 0 || m.selected != nil {
+		t.Errorf("after collapsing acme: %d items, cursor %d, selected %v", n, m.list.Index(), m.selected)
+	}
+	if !strings.Contains(m.list.Items()[0].FilterValue(), "acme--api") {
+		t.Errorf("collapsed header FilterValue() = %q, want its workspaces searchable", m.list.Items()[0].FilterValue())
+	}
+
+	var s dashboardSession
+	m.recordSession(&s)
+	if len(s.Collapsed) != 1 || s.Collapsed[0] != "acme" {
+		t.Errorf("recorded Collapsed = %v, want [acme]", s.Collapsed)
+	}
+	s.Selected = "solo--tool"
+	restored := New(&config.Config{}, records).applySession(s)
+	if n := len(restored.list.Items()); n != 3 || restored.selected == nil || restored.selected.Slug != "solo--tool" {
+		t.Errorf("restored: %d items, selected %v; want acme collapsed and solo--tool selected", n, restored.selected)
+	}
+}
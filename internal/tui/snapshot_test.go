@@ -0,0 +1,154 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/muesli/termenv"
+)
+
+// Snapshot tests run a full-screen TUI through teatest, play a script of
+// keys, and compare the frames captured along the way with the golden file
+// testdata/<TestName>.golden. After an intended change to a view, rewrite
+// the golden files with:
+//
+//	go test ./internal/tui -run Snapshot -update
+
+// snapshotStep is one step of a script: keys to press, then the frame
+// captured under name once the view shows every string in wait.
+type snapshotStep struct {
+	name string
+	keys []string // key names like "j", "enter", "esc", "space", or text to type
+	wait []string
+}
+
+// snapshotBusy are strings views show while async work is still running.
+// A frame is not captured while the view shows one of them.
+var snapshotBusy = []string{"Calculating...", "Scanning...", "Validating templates..."}
+
+// snapshotMsg asks the recorder for the frame of a step.
+type snapshotMsg struct {
+	step  int
+	tries int
+}
+
+// snapshotRecorder wraps the model under test. It plays the script from
+// inside the program, so keys, async results, and frames stay in order, and
+// quits after the last step.
+type snapshotRecorder struct {
+	tea.Model
+	steps  []snapshotStep
+	frames *[]string
+}
+
+func (r snapshotRecorder) Init() tea.Cmd {
+	return tea.Batch(r.Model.Init(), r.play(0))
+}
+
+// play presses the keys of step i, then asks for its frame.
+func (r snapshotRecorder) play(i int) tea.Cmd {
+	if i == len(r.steps) {
+		return tea.Quit
+	}
+	var cmds []tea.Cmd
+	for _, name := range r.steps[i].keys {
+		key := snapshotKey(name)
+		cmds = append(cmds, func() tea.Msg { return key })
+	}
+	cmds = append(cmds, func() tea.Msg { return snapshotMsg{step: i} })
+	return tea.Sequence(cmds...)
+}
+
+func (r snapshotRecorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	snap, ok := msg.(snapshotMsg)
+	if !ok {
+		var cmd tea.Cmd
+		r.Model, cmd = r.Model.Update(msg)
+		return r, cmd
+	}
+
+	// Async results (sizes, git status) may still be on their way; poll for
+	// up to five seconds, then record whatever is there so the diff shows it.
+	step := r.steps[snap.step]
+	view := r.Model.View()
+	ready := true
+	for _, want := range step.wait {
+		ready = ready && strings.Contains(view, want)
+	}
+	for _, busy := range snapshotBusy {
+		ready = ready && !strings.Contains(view, busy)
+	}
+	if !ready && snap.tries < 500 {
+		snap.tries++
+		return r, tea.Tick(10*time.Millisecond, func(time.Time) tea.Msg { return snap })
+	}
+	*r.frames = append(*r.frames, fmt.Sprintf("=== %s ===\n%s\n", step.name, view))
+	return r, r.play(snap.step + 1)
+}
+
+// snapshotKey returns the key message for a key name.
+func snapshotKey(name string) tea.KeyMsg {
+	switch name {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	case "shift+tab":
+		return tea.KeyMsg{Type: tea.KeyShiftTab}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "space":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// snapshotDate matches dates, which change from run to run.
+var snapshotDate = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// runSnapshot plays steps on the model newModel returns, in a 100x30
+// terminal without colors, and compares the frames with the golden file.
+// The model is made and run in dir, so that relative paths keep the frames
+// the same from run to run.
+func runSnapshot(t *testing.T, dir string, newModel func() tea.Model, steps []snapshotStep) {
+	t.Helper()
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.Ascii)
+	t.Cleanup(func() { lipgloss.SetColorProfile(profile) })
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	var frames []string
+	func() {
+		defer os.Chdir(wd) // golden files are relative to the package directory
+		tm := teatest.NewTestModel(t, snapshotRecorder{Model: newModel(), steps: steps, frames: &frames},
+			teatest.WithInitialTermSize(100, 30))
+		tm.WaitFinished(t, teatest.WithFinalTimeout(time.Duration(len(steps)+1)*5*time.Second))
+	}()
+
+	out := snapshotDate.ReplaceAllString(strings.Join(frames, "\n"), "YYYY-MM-DD")
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	golden.RequireEqual(t, []byte(strings.Join(lines, "\n")))
+}
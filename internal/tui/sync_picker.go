@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
@@ -29,9 +30,9 @@ type syncPickerItem struct {
 
 func (i syncPickerItem) Title() string {
 	if i.selected {
-		return "[x] " + i.record.Slug
+		return "[x] " + workspaceTitle(i.record)
 	}
-	return "[ ] " + i.record.Slug
+	return "[ ] " + workspaceTitle(i.record)
 }
 
 func (i syncPickerItem) Description() string {
@@ -42,7 +43,7 @@ func (i syncPickerItem) Description() string {
 	return fmt.Sprintf("%d repos%s", i.record.RepoCount, dirty)
 }
 
-func (i syncPickerItem) FilterValue() string { return i.record.Slug }
+func (i syncPickerItem) FilterValue() string { return i.record.Slug + " " + i.record.DisplayName }
 
 type syncPickerModel struct {
 	list     list.Model
@@ -173,6 +174,11 @@ func RunSyncPicker(records []*model.IndexRecord) (SyncPickerResult, error) {
 	sort.Slice(sorted, func(i, j int) bool {
 		return sorted[i].Slug < sorted[j].Slug
 	})
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return SyncPickerResult{}, err
+	} else if lines {
+		return runPlainSyncPicker(sorted), nil
+	}
 
 	m := newSyncPickerModel(sorted)
 	p := tea.NewProgram(m, tea.WithAltScreen())
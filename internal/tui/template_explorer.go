@@ -1,21 +1,29 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/fsnotify/fsnotify"
 	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/errfmt"
 	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 // Tab represents the currently active tab in the explorer.
@@ -26,9 +34,13 @@ const (
 	TabFiles
 	TabOutput
 	TabCreate
+	TabHooks
 	TabValidate
 )
 
+// tabCount is the number of tabs, for cycling through them.
+const tabCount = 6
+
 func (t Tab) String() string {
 	switch t {
 	case TabBrowse:
@@ -41,6 +53,8 @@ func (t Tab) String() string {
 		return "Create"
 	case TabValidate:
 		return "Validate"
+	case TabHooks:
+		return "Hooks"
 	default:
 		return "Unknown"
 	}
@@ -142,6 +156,15 @@ var (
 	selectedStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("212")).
 			Bold(true)
+
+	// Hook output pane shown while creating
+	hookLogStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("63")).
+			Padding(0, 1)
+
+	hookStderrStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
 )
 
 // explorerTemplateItem is a list item for the explorer.
@@ -168,6 +191,9 @@ func (i explorerTemplateItem) Description() string {
 		desc = desc[:37] + "..."
 	}
 	source := filepath.Base(i.listing.SourceDir)
+	if !i.listing.Trusted {
+		source += " • untrusted"
+	}
 	return fmt.Sprintf("%s (%d vars, %d repos) • %s", desc, i.listing.Info.VarCount, i.listing.Info.RepoCount, source)
 }
 func (i explorerTemplateItem) FilterValue() string {
@@ -195,6 +221,7 @@ type TemplateExplorerModel struct {
 	dryRun       bool
 	noHooks      bool
 	createError  string
+	lastOwner    string // Owner of the last workspace created, the default for the next
 
 	// Explorer state machine
 	state ExplorerState
@@ -203,6 +230,7 @@ type TemplateExplorerModel struct {
 	validationResults  []validationResult
 	validationSelected int
 	validating         bool
+	validationWatcher  *templateWatcher // Set while watch mode revalidates on changes
 
 	// Files tab state
 	fileTree            *fileTreeNode   // root of file tree
@@ -246,6 +274,12 @@ type TemplateExplorerModel struct {
 	// Workspace creation state
 	createResult *template.CreateResult
 	createErr    error
+	createEvents chan tea.Msg       // Hook events and the result of the running creation
+	createCancel context.CancelFunc // Stops the running creation
+	cancelling   bool               // esc was pressed; waiting for the creation to stop
+	hookLog      []string           // Hook output of the last creation
+	hookSummary  []string           // One line per finished hook
+	hookViewport viewport.Model
 
 	createVars map[string]string
 
@@ -257,6 +291,12 @@ type TemplateExplorerModel struct {
 	diagViewport     viewport.Model             // viewport for diagnostics
 	diagShowPatterns bool                       // true = show patterns, false = show placeholders
 
+	// Variables being declared for the unresolved placeholders (v in the
+	// placeholder diagnostics), prompted for one at a time
+	diagNewVars    []template.TemplateVar
+	diagNewVarIdx  int             // variable being prompted for
+	diagNewVarDesc textinput.Model // its description
+
 	// Compare state
 	compareMode     bool                      // true when showing compare overlay
 	compareMarked   *template.TemplateListing // template marked for comparison
@@ -264,6 +304,20 @@ type TemplateExplorerModel struct {
 	compareSelected int                       // selected item in compare list
 	compareSection  int                       // 0=vars, 1=repos, 2=hooks, 3=files
 	compareViewport viewport.Model            // viewport for compare content
+
+	// Hooks tab state
+	hooksTemplate   *template.Template  // selected template, loaded for its hooks
+	hooksList       []template.HookType // hooks it defines
+	hooksSelected   int                 // selected index in hooksList
+	hooksFocusPane  int                 // 0=list, 1=script or test run
+	hooksScriptPath string              // script of the selected hook
+	hooksScript     string              // its content
+	hooksScriptErr  string              // error loading it
+	hooksScroll     int                 // first line shown in the right pane
+	hooksEditing    bool                // true while editing the script
+	hooksEditor     textarea.Model      // in-app script editor
+	hooksRunning    bool                // true while a test run is in progress
+	hooksRun        *hookTestRunMsg     // last test run, shown until another hook is selected
 }
 
 // NewTemplateExplorer creates a new template explorer model.
@@ -293,6 +347,7 @@ func NewTemplateExplorer(cfg *config.Config, listings []template.TemplateListing
 	oi.Placeholder = "owner"
 	oi.CharLimit = 64
 	oi.Width = 30
+	setOwnerCompletion(&oi, cfg)
 
 	// Initialize project input
 	pi := textinput.New()
@@ -314,6 +369,17 @@ func NewTemplateExplorer(cfg *config.Config, listings []template.TemplateListing
 	dvp := viewport.New(40, 20)
 	dvp.SetContent("")
 
+	// Initialize the description input for declaring placeholder variables
+	ddi := textinput.New()
+	ddi.Placeholder = "description (optional)"
+	ddi.CharLimit = 256
+	ddi.Width = 50
+
+	// Initialize the hook script editor
+	ta := textarea.New()
+	ta.MaxHeight = 0
+	ta.CharLimit = 0
+
 	// Initialize compare viewport
 	cvp := viewport.New(40, 20)
 	cvp.SetContent("")
@@ -337,6 +403,8 @@ func NewTemplateExplorer(cfg *config.Config, listings []template.TemplateListing
 		fileViewport:    vp,
 		showLineNumbers: true,
 		diagViewport:    dvp,
+		diagNewVarDesc:  ddi,
+		hooksEditor:     ta,
 		compareViewport: cvp,
 	}
 }
@@ -382,12 +450,19 @@ func (m TemplateExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateConfirmCreate(msg)
 		}
 
-		// Handle creation in progress - only allow quit
+		// Handle creation in progress - only allow cancelling, quitting, and
+		// scrolling the hook output
 		if m.state == StateCreating {
-			if msg.String() == "ctrl+c" {
+			switch msg.String() {
+			case "ctrl+c":
+				m.createCancel()
 				return m, tea.Quit
+			case "esc":
+				m.createCancel()
+				m.cancelling = true
+				return m, nil
 			}
-			return m, nil
+			return m.scrollHookLog(msg)
 		}
 
 		// Handle creation complete state
@@ -425,6 +500,10 @@ func (m TemplateExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateOutputTab(msg)
 		}
 
+		if m.activeTab == TabHooks {
+			return m.updateHooksTab(msg)
+		}
+
 		// Don't handle keys when filtering
 		if m.list.FilterState() == list.Filtering {
 			break
@@ -435,10 +514,10 @@ func (m TemplateExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case key.Matches(msg, explorerKeys.NextTab):
-			return m.switchTab((m.activeTab + 1) % 5)
+			return m.switchTab((m.activeTab + 1) % tabCount)
 
 		case key.Matches(msg, explorerKeys.PrevTab):
-			return m.switchTab((m.activeTab + 4) % 5) // +4 is same as -1 mod 5
+			return m.switchTab((m.activeTab + tabCount - 1) % tabCount)
 
 		case key.Matches(msg, explorerKeys.SwitchPane):
 			if m.activePane == PaneList {
@@ -493,7 +572,18 @@ func (m TemplateExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.switchTab(TabCreate)
 		case msg.String() == "4":
 			return m.switchTab(TabValidate)
+		case msg.String() == "5":
+			return m.switchTab(TabHooks)
+		case msg.String() == "6":
+			return m.switchTab(TabValidate)
+		}
+
+	case templatesChangedMsg:
+		if m.validationWatcher == nil {
+			return m, nil
 		}
+		m.validating = true
+		return m, tea.Batch(m.validateAllTemplates(), waitForTemplateChange(m.validationWatcher.changes))
 
 	case validationResultMsg:
 		if msg.err != nil {
@@ -517,8 +607,18 @@ func (m TemplateExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case validateAllResultMsg:
 		m.validating = false
+		// Keep the selected template selected when results are refreshed
+		selectedName := ""
+		if m.validationSelected < len(m.validationResults) {
+			selectedName = m.validationResults[m.validationSelected].name
+		}
 		m.validationResults = msg.results
 		m.validationSelected = 0
+		for i, r := range msg.results {
+			if r.name == selectedName {
+				m.validationSelected = i
+			}
+		}
 		// Count successes
 		valid := 0
 		for _, r := range msg.results {
@@ -535,9 +635,20 @@ func (m TemplateExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case hookEventMsg:
+		m.appendHookEvent(msg.event)
+		return m, waitForCreateEvent(m.createEvents)
+
 	case createWorkspaceResultMsg:
 		m.createResult = msg.result
 		m.createErr = msg.err
+		if msg.err == nil && !m.dryRun {
+			m.lastOwner = strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
+			addOwnerCompletion(&m.ownerInput, m.lastOwner)
+		}
+		m.createEvents = nil
+		m.createCancel()
+		m.cancelling = false
 		m.state = StateCreateComplete
 		return m, nil
 
@@ -602,6 +713,37 @@ func (m TemplateExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case hookTestRunMsg:
+		m.hooksRunning = false
+		m.hooksRun = &msg
+		m.hooksScroll = 0
+		m.hooksFocusPane = 1
+		return m, nil
+
+	case hookScriptSavedMsg:
+		if msg.err != nil {
+			m.message = "Failed to save hook script: " + msg.err.Error()
+			m.messageIsError = true
+			return m, nil
+		}
+		m.hooksEditing = false
+		m.hooksEditor.Blur()
+		m.message = "Saved " + filepath.Base(msg.path)
+		m.messageIsError = false
+		m.loadHookScript()
+		return m, nil
+
+	case diagVarsAddedMsg:
+		if msg.err != nil {
+			m.message = "Failed to declare variables: " + msg.err.Error()
+			m.messageIsError = true
+			return m, nil
+		}
+		m.message = fmt.Sprintf("Declared %d variable(s) in %s", msg.count, template.TemplateManifestFile)
+		m.messageIsError = false
+		// Rescan, so the placeholders show as resolved
+		return m, m.loadPlaceholderDiagnostics()
+
 	case compareResultMsg:
 		if msg.err != nil {
 			m.message = "Error comparing templates: " + msg.err.Error()
@@ -679,6 +821,8 @@ func (m TemplateExplorerModel) View() string {
 		content = m.renderCreateTab()
 	case TabValidate:
 		content = m.renderValidateTab()
+	case TabHooks:
+		content = m.renderHooksTab()
 	}
 
 	// Build help line
@@ -688,7 +832,7 @@ func (m TemplateExplorerModel) View() string {
 }
 
 func (m TemplateExplorerModel) renderTabBar() string {
-	tabs := []Tab{TabBrowse, TabFiles, TabOutput, TabCreate, TabValidate}
+	tabs := []Tab{TabBrowse, TabFiles, TabOutput, TabCreate, TabHooks, TabValidate}
 	var renderedTabs []string
 
 	for i, tab := range tabs {
@@ -936,7 +1080,12 @@ func (m TemplateExplorerModel) renderCreateTab() string {
 
 	// Show selected template
 	sb.WriteString(fmt.Sprintf("Template: %s\n", titleStyle.Render(m.selected.Info.Name)))
-	sb.WriteString(fmt.Sprintf("Source:   %s\n\n", filepath.Base(m.selected.SourceDir)))
+	sb.WriteString(fmt.Sprintf("Source:   %s\n", filepath.Base(m.selected.SourceDir)))
+	if !m.selected.Trusted && m.selected.Info.HookCount > 0 {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+		sb.WriteString(warningStyle.Render(fmt.Sprintf("⚠ Untrusted: hooks are blocked; run 'co template trust %s'", m.selected.Info.Name)) + "\n")
+	}
+	sb.WriteString("\n")
 
 	// Owner input
 	ownerLabel := inputLabelStyle.Render("Owner:")
@@ -944,6 +1093,11 @@ func (m TemplateExplorerModel) renderCreateTab() string {
 		ownerLabel = inputFocusedStyle.Render("▶ Owner:")
 	}
 	sb.WriteString(fmt.Sprintf("%s %s\n", ownerLabel, m.ownerInput.View()))
+	if m.createFocus == CreateFocusOwner {
+		if matches := renderOwnerMatches(&m.ownerInput); matches != "" {
+			sb.WriteString(helpStyle.Render(matches) + "\n")
+		}
+	}
 
 	// Project input
 	projectLabel := inputLabelStyle.Render("Project:")
@@ -1002,13 +1156,13 @@ func (m TemplateExplorerModel) renderCreateTab() string {
 	owner := strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
 	project := strings.ToLower(strings.TrimSpace(m.projectInput.Value()))
 	if owner != "" || project != "" {
-		slug := owner + "--" + project
 		if owner == "" {
-			slug = "<owner>--" + project
+			owner = "<owner>"
 		}
 		if project == "" {
-			slug = owner + "--<project>"
+			project = "<project>"
 		}
+		slug := workspace.SchemeFor(m.cfg).Format(owner, project)
 		sb.WriteString("\n" + helpStyle.Render(fmt.Sprintf("Workspace slug: %s", slug)))
 	}
 
@@ -1180,7 +1334,11 @@ func (m TemplateExplorerModel) renderValidateTab() string {
 func (m TemplateExplorerModel) renderValidationResults() string {
 	var sb strings.Builder
 
-	sb.WriteString(headerStyle.Render("Validation Results") + "\n\n")
+	sb.WriteString(headerStyle.Render("Validation Results"))
+	if m.validationWatcher != nil {
+		sb.WriteString("  " + lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Render("● watching"))
+	}
+	sb.WriteString("\n\n")
 
 	if m.validating {
 		sb.WriteString("Validating templates...\n")
@@ -1267,6 +1425,10 @@ func (m TemplateExplorerModel) templateDetailsView() string {
 	sb.WriteString(fmt.Sprintf("Variables:   %d\n", info.VarCount))
 	sb.WriteString(fmt.Sprintf("Repos:       %d\n", info.RepoCount))
 	sb.WriteString(fmt.Sprintf("Hooks:       %d\n", info.HookCount))
+	sb.WriteString(fmt.Sprintf("Trust:       %s\n", m.selected.Trust.Status()))
+	if m.selected.Trust != nil {
+		sb.WriteString(fmt.Sprintf("Installed:   %s\n", m.selected.Trust.Source))
+	}
 	sb.WriteString(fmt.Sprintf("Source dir:  %s\n", m.selected.SourceDir))
 	sb.WriteString(fmt.Sprintf("Path:        %s\n", m.selected.TemplatePath))
 
@@ -1298,7 +1460,16 @@ func (m TemplateExplorerModel) renderHelp() string {
 	case TabCreate:
 		help = "tab/↓: next field • shift+tab/↑: prev field • space: toggle • enter: proceed • esc: back • q: quit"
 	case TabValidate:
-		help = "j/k: navigate • h/l: pane • v: validate selected • V: validate all • tab: next tab • q: quit"
+		help = "j/k: navigate • h/l: pane • v: validate selected • V: validate all • w: watch for changes • tab: next tab • q: quit"
+	case TabHooks:
+		switch {
+		case m.hooksEditing:
+			help = "ctrl+s: save • esc: discard changes"
+		case m.hooksRun != nil:
+			help = "j/k: navigate • d/u: scroll • t: test run again • s: show script • e: edit • tab: next tab • q: quit"
+		default:
+			help = "j/k: navigate • d/u: scroll • t: test run • e: edit script • tab: next tab • q: quit"
+		}
 	}
 
 	if m.message != "" {
@@ -1326,7 +1497,8 @@ func (m TemplateExplorerModel) switchTab(newTab Tab) (tea.Model, tea.Cmd) {
 
 	// When entering Create tab, focus the appropriate input
 	if newTab == TabCreate {
-		return m, m.focusCreateInput()
+		cmd := m.focusCreateInput()
+		return m, cmd
 	}
 
 	// When entering Files tab, build the file tree
@@ -1341,11 +1513,22 @@ func (m TemplateExplorerModel) switchTab(newTab Tab) (tea.Model, tea.Cmd) {
 		m.outputSelected = 0
 	}
 
+	// When entering Hooks tab, load the hooks of the selected template
+	if newTab == TabHooks {
+		m.loadHooks()
+	}
+
+	// When entering Validate tab, validate all templates
+	if newTab == TabValidate && !m.validating {
+		m.validating = true
+		return m, m.validateAllTemplates()
+	}
+
 	return m, nil
 }
 
 // focusCreateInput returns a command to focus the current Create tab input.
-func (m TemplateExplorerModel) focusCreateInput() tea.Cmd {
+func (m *TemplateExplorerModel) focusCreateInput() tea.Cmd {
 	m.ownerInput.Blur()
 	m.projectInput.Blur()
 
@@ -1381,6 +1564,11 @@ func (m TemplateExplorerModel) updateCreateTab(msg tea.KeyMsg) (tea.Model, tea.C
 		return m.switchTab(TabBrowse)
 
 	case "tab", "down":
+		if msg.String() == "tab" && m.createFocus == CreateFocusOwner && canCompleteOwner(&m.ownerInput) {
+			var cmd tea.Cmd
+			m.ownerInput, cmd = m.ownerInput.Update(msg)
+			return m, cmd
+		}
 		return m.nextCreateFocus()
 
 	case "shift+tab", "up":
@@ -1414,7 +1602,7 @@ func (m TemplateExplorerModel) updateCreateTab(msg tea.KeyMsg) (tea.Model, tea.C
 		}
 
 	// Number keys for quick tab switching (only when not in text input)
-	case "1", "2", "3", "4":
+	case "1", "2", "3", "4", "5", "6":
 		if m.createFocus != CreateFocusOwner && m.createFocus != CreateFocusProject {
 			tabNum := int(msg.String()[0] - '1')
 			return m.switchTab(Tab(tabNum))
@@ -1478,7 +1666,7 @@ func (m TemplateExplorerModel) validateAndProceed() (tea.Model, tea.Cmd) {
 		m.createFocus = CreateFocusOwner
 		return m, m.ownerInput.Focus()
 	}
-	if !isValidSlugPart(owner) {
+	if !workspace.SchemeFor(m.cfg).ValidPart(owner) {
 		m.createError = "Owner must be lowercase alphanumeric with hyphens only"
 		m.createFocus = CreateFocusOwner
 		return m, m.ownerInput.Focus()
@@ -1490,7 +1678,7 @@ func (m TemplateExplorerModel) validateAndProceed() (tea.Model, tea.Cmd) {
 		m.createFocus = CreateFocusProject
 		return m, m.projectInput.Focus()
 	}
-	if !isValidSlugPart(project) {
+	if !workspace.SchemeFor(m.cfg).ValidPart(project) {
 		m.createError = "Project must be lowercase alphanumeric with hyphens only"
 		m.createFocus = CreateFocusProject
 		return m, m.projectInput.Focus()
@@ -1511,9 +1699,9 @@ func (m TemplateExplorerModel) validateAndProceed() (tea.Model, tea.Cmd) {
 	m.loadedTemplate = tmpl
 
 	// Compute builtin variables
-	slug := owner + "--" + project
-	workspacePath := filepath.Join(m.cfg.CodeRoot, slug)
-	builtins := template.GetBuiltinVariables(owner, project, workspacePath, m.cfg.CodeRoot)
+	slug := workspace.SchemeFor(m.cfg).Format(owner, project)
+	workspacePath := m.cfg.WorkspacePath(slug)
+	builtins := template.GetBuiltinVariables(owner, project, slug, workspacePath, m.cfg.CodeRoot)
 
 	// Seed values with builtins and any previously captured vars
 	values := copyStringMap(m.createVars)
@@ -1524,9 +1712,15 @@ func (m TemplateExplorerModel) validateAndProceed() (tea.Model, tea.Cmd) {
 	// Apply defaults for any variables not yet set
 	values = m.applyDefaults(tmpl.Variables, values)
 
-	// Determine which required variables still need prompting (no value after defaults/builtins)
+	// Determine which required variables still need prompting (no value after defaults/builtins),
+	// in the order their conditions need
+	order, err := template.OrderVariables(tmpl.Variables)
+	if err != nil {
+		m.createError = err.Error()
+		return m, nil
+	}
 	promptVars := make([]template.TemplateVar, 0)
-	for _, v := range tmpl.Variables {
+	for _, v := range order {
 		if _, ok := values[v.Name]; ok {
 			continue
 		}
@@ -1537,7 +1731,8 @@ func (m TemplateExplorerModel) validateAndProceed() (tea.Model, tea.Cmd) {
 	}
 
 	// If no variables need prompting, proceed directly
-	if len(promptVars) == 0 {
+	first := skipInapplicableVars(promptVars, 0, values)
+	if first == len(promptVars) {
 		m.createVars = values
 		m.message = fmt.Sprintf("Variables captured: %d (builtins/defaults)", len(values))
 		m.messageIsError = false
@@ -1547,7 +1742,7 @@ func (m TemplateExplorerModel) validateAndProceed() (tea.Model, tea.Cmd) {
 	// Initialize variable prompting state
 	m.varPromptVars = promptVars
 	m.varPromptBuiltins = builtins
-	m.varPromptIndex = 0
+	m.varPromptIndex = first
 	m.varPromptValues = values
 	m.varPromptError = ""
 	m.state = StateVariablePrompt
@@ -1594,10 +1789,10 @@ func (m TemplateExplorerModel) updateValidateTab(msg tea.KeyMsg) (tea.Model, tea
 	case "ctrl+c", "q":
 		return m, tea.Quit
 	case "tab":
-		return m.switchTab((m.activeTab + 1) % 5)
+		return m.switchTab((m.activeTab + 1) % tabCount)
 	case "shift+tab":
-		return m.switchTab((m.activeTab + 4) % 5)
-	case "1", "2", "3", "4":
+		return m.switchTab((m.activeTab + tabCount - 1) % tabCount)
+	case "1", "2", "3", "4", "5", "6":
 		tabNum := int(msg.String()[0] - '1')
 		return m.switchTab(Tab(tabNum))
 
@@ -1633,10 +1828,130 @@ func (m TemplateExplorerModel) updateValidateTab(msg tea.KeyMsg) (tea.Model, tea
 		// Validate all templates
 		m.validating = true
 		return m, m.validateAllTemplates()
+	case "w":
+		// Toggle watch mode
+		if m.validationWatcher != nil {
+			m.validationWatcher.Close()
+			m.validationWatcher = nil
+			m.message = "Stopped watching templates"
+			m.messageIsError = false
+			return m, nil
+		}
+		watcher, err := watchTemplates(m.cfg.AllTemplatesDirs())
+		if err != nil {
+			m.message = "Cannot watch templates: " + err.Error()
+			m.messageIsError = true
+			return m, nil
+		}
+		m.validationWatcher = watcher
+		m.validating = true
+		m.message = "Watching templates; changes are revalidated"
+		m.messageIsError = false
+		return m, tea.Batch(m.validateAllTemplates(), waitForTemplateChange(watcher.changes))
 	}
 	return m, nil
 }
 
+// templateWatchDelay is how long template files must be left alone after a
+// change before watch mode revalidates, so saving several files, or an
+// editor's write-and-rename, causes one run.
+const templateWatchDelay = 200 * time.Millisecond
+
+// templateWatcher watches template directories, including directories
+// created in them later, for watch mode in the Validate tab.
+type templateWatcher struct {
+	watcher *fsnotify.Watcher
+	changes chan struct{} // Receives once changes have settled; closed by Close
+}
+
+// templatesChangedMsg is sent when watched template files have changed.
+type templatesChangedMsg struct{}
+
+// watchTemplates starts watching dirs and everything below them. Missing
+// directories are skipped.
+func watchTemplates(dirs []string) (*templateWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &templateWatcher{watcher: watcher, changes: make(chan struct{}, 1)}
+	for _, dir := range dirs {
+		if err := w.addTree(dir); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// addTree watches dir and the directories below it.
+func (w *templateWatcher) addTree(dir string) error {
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// run forwards settled changes to w.changes until the watcher is closed.
+func (w *templateWatcher) run() {
+	defer close(w.changes)
+	var settled <-chan time.Time
+	errs := w.watcher.Errors
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = w.addTree(event.Name)
+				}
+			}
+			settled = time.After(templateWatchDelay)
+		case <-settled:
+			settled = nil
+			select {
+			case w.changes <- struct{}{}:
+			default: // A run is already pending
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		}
+	}
+}
+
+// Close stops watching.
+func (w *templateWatcher) Close() {
+	w.watcher.Close()
+}
+
+// waitForTemplateChange waits for the next settled change of the watched
+// templates.
+func waitForTemplateChange(changes <-chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-changes; !ok {
+			return nil
+		}
+		return templatesChangedMsg{}
+	}
+}
+
 // validateSelectedForTab validates the selected template and updates the Validate tab results.
 func (m TemplateExplorerModel) validateSelectedForTab() tea.Cmd {
 	return func() tea.Msg {
@@ -1700,6 +2015,11 @@ type createWorkspaceResultMsg struct {
 	err    error
 }
 
+// hookEventMsg carries the progress of a hook run during creation.
+type hookEventMsg struct {
+	event template.HookEvent
+}
+
 // fileContentMsg is sent when file content is loaded.
 type fileContentMsg struct {
 	path            string
@@ -1709,6 +2029,7 @@ type fileContentMsg struct {
 	isBinary        bool
 	isLarge         bool
 	isTemplate      bool // true if file has template extension
+	isSecret        bool // true if the file likely holds secrets and was not read
 	err             error
 }
 
@@ -1726,6 +2047,26 @@ type diagFileDiagsMsg struct {
 	err   error
 }
 
+// hookTestRunMsg is sent when a test run of a hook finishes.
+type hookTestRunMsg struct {
+	hook   template.HookType
+	result *template.HookResult
+	err    error
+}
+
+// hookScriptSavedMsg is sent when an edited hook script has been written.
+type hookScriptSavedMsg struct {
+	path string
+	err  error
+}
+
+// diagVarsAddedMsg is sent when variables for unresolved placeholders have
+// been declared in template.json.
+type diagVarsAddedMsg struct {
+	count int
+	err   error
+}
+
 // diagPlaceholdersMsg is sent when placeholder diagnostics are loaded.
 type diagPlaceholdersMsg struct {
 	report *template.DiagnosticReport
@@ -1927,8 +2268,8 @@ func (m TemplateExplorerModel) submitCurrentVariable() (tea.Model, tea.Cmd) {
 	m.varPromptValues[v.Name] = value
 	m.varPromptError = ""
 
-	// Move to next variable
-	m.varPromptIndex++
+	// Move to the next variable that applies
+	m.varPromptIndex = skipInapplicableVars(m.varPromptVars, m.varPromptIndex+1, m.varPromptValues)
 	if m.varPromptIndex >= len(m.varPromptVars) {
 		// All variables collected, proceed to confirmation
 		m.createVars = m.varPromptValues
@@ -1959,24 +2300,113 @@ func (m TemplateExplorerModel) updateConfirmCreate(msg tea.KeyMsg) (tea.Model, t
 }
 
 // startCreation initiates workspace creation.
+// Hook events are streamed through a channel so their output shows up while
+// the creation runs; the result is the last message sent.
 func (m TemplateExplorerModel) startCreation() (tea.Model, tea.Cmd) {
 	m.state = StateCreating
+	m.hookLog = nil
+	m.hookSummary = nil
+	m.hookViewport = viewport.New(m.hookLogWidth(), m.hookLogHeight())
 
-	return m, func() tea.Msg {
-		owner := strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
-		project := strings.ToLower(strings.TrimSpace(m.projectInput.Value()))
+	events := make(chan tea.Msg, 64)
+	m.createEvents = events
+	ctx, cancel := context.WithCancel(context.Background())
+	m.createCancel = cancel
 
-		opts := template.CreateOptions{
-			TemplateName: m.selected.Info.Name,
-			Variables:    m.createVars,
-			NoHooks:      m.noHooks,
-			DryRun:       m.dryRun,
-			Verbose:      false,
-		}
+	owner := strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
+	project := strings.ToLower(strings.TrimSpace(m.projectInput.Value()))
+	opts := template.CreateOptions{
+		TemplateName: m.selected.Info.Name,
+		Variables:    m.createVars,
+		NoHooks:      m.noHooks,
+		DryRun:       m.dryRun,
+		Verbose:      false,
+		OnHookEvent: func(e template.HookEvent) {
+			events <- hookEventMsg{event: e}
+		},
+	}
+	cfg := m.cfg
+	go func() {
+		result, err := template.CreateWorkspaceContext(ctx, cfg, owner, project, opts)
+		events <- createWorkspaceResultMsg{result: result, err: err}
+		close(events)
+	}()
+
+	return m, waitForCreateEvent(events)
+}
+
+// waitForCreateEvent waits for the next message of a running creation.
+func waitForCreateEvent(events chan tea.Msg) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// appendHookEvent adds a hook event to the hook log, following the output
+// unless the user scrolled up.
+func (m *TemplateExplorerModel) appendHookEvent(e template.HookEvent) {
+	switch e.Kind {
+	case template.HookStarted:
+		m.hookLog = append(m.hookLog, titleStyle.Render(fmt.Sprintf("▶ %s (%s)", e.Hook, e.Script)))
+	case template.HookOutput:
+		line := fmt.Sprintf("  [%s] %s", e.Hook, e.Line)
+		if e.Stderr {
+			line = hookStderrStyle.Render(line)
+		}
+		m.hookLog = append(m.hookLog, line)
+	case template.HookFinished:
+		summary := hookSummaryLine(e)
+		m.hookSummary = append(m.hookSummary, summary)
+		m.hookLog = append(m.hookLog, summary)
+	}
+
+	atBottom := m.hookViewport.AtBottom()
+	m.hookViewport.SetContent(strings.Join(m.hookLog, "\n"))
+	if atBottom {
+		m.hookViewport.GotoBottom()
+	}
+}
+
+// hookSummaryLine describes a finished hook with its timing and exit code.
+func hookSummaryLine(e template.HookEvent) string {
+	r := e.Result
+	elapsed := r.Duration.Round(time.Millisecond)
+	switch {
+	case r.Error == nil:
+		return fmt.Sprintf("✓ %s finished in %s (exit 0)", e.Hook, elapsed)
+	case r.ExitCode != 0:
+		return promptErrorStyle.Render(fmt.Sprintf("✗ %s exited with code %d after %s", e.Hook, r.ExitCode, elapsed))
+	default:
+		return promptErrorStyle.Render(fmt.Sprintf("✗ %s failed after %s: %v", e.Hook, elapsed, r.Error))
+	}
+}
+
+// scrollHookLog scrolls the hook output pane.
+func (m TemplateExplorerModel) scrollHookLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k", "down", "j", "pgup", "pgdown", "home", "end":
+		var cmd tea.Cmd
+		m.hookViewport, cmd = m.hookViewport.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m TemplateExplorerModel) hookLogWidth() int {
+	if m.width-8 < 20 {
+		return 20
+	}
+	return m.width - 8
+}
 
-		result, err := template.CreateWorkspace(m.cfg, owner, project, opts)
-		return createWorkspaceResultMsg{result: result, err: err}
+func (m TemplateExplorerModel) hookLogHeight() int {
+	if m.height-14 < 5 {
+		return 5
 	}
+	return m.height - 14
 }
 
 // updateCreateComplete handles key events after creation is complete.
@@ -1990,7 +2420,7 @@ func (m TemplateExplorerModel) updateCreateComplete(msg tea.KeyMsg) (tea.Model,
 		m.createResult = nil
 		m.createErr = nil
 		m.createVars = make(map[string]string)
-		m.ownerInput.Reset()
+		m.ownerInput.SetValue(m.lastOwner)
 		m.projectInput.Reset()
 		m.createFocus = CreateFocusOwner
 		return m, m.ownerInput.Focus()
@@ -1999,6 +2429,8 @@ func (m TemplateExplorerModel) updateCreateComplete(msg tea.KeyMsg) (tea.Model,
 		if m.createResult != nil && m.createErr == nil {
 			return m, m.openWorkspace(m.createResult.WorkspacePath)
 		}
+	default:
+		return m.scrollHookLog(msg)
 	}
 	return m, nil
 }
@@ -2082,7 +2514,7 @@ func (m TemplateExplorerModel) renderConfirmCreate() string {
 
 	owner := strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
 	project := strings.ToLower(strings.TrimSpace(m.projectInput.Value()))
-	slug := owner + "--" + project
+	slug := workspace.SchemeFor(m.cfg).Format(owner, project)
 
 	sb.WriteString(fmt.Sprintf("Template:  %s\n", titleStyle.Render(m.selected.Info.Name)))
 	sb.WriteString(fmt.Sprintf("Owner:     %s\n", owner))
@@ -2128,10 +2560,20 @@ func (m TemplateExplorerModel) renderCreating() string {
 
 	owner := strings.ToLower(strings.TrimSpace(m.ownerInput.Value()))
 	project := strings.ToLower(strings.TrimSpace(m.projectInput.Value()))
-	slug := owner + "--" + project
+	slug := workspace.SchemeFor(m.cfg).Format(owner, project)
 
 	sb.WriteString(fmt.Sprintf("Creating %s from template %s\n\n", slug, m.selected.Info.Name))
-	sb.WriteString("Please wait...\n")
+	help := "esc: cancel"
+	if m.cancelling {
+		help = "Cancelling..."
+	}
+	if len(m.hookLog) == 0 {
+		sb.WriteString("Please wait...\n\n")
+	} else {
+		sb.WriteString(hookLogStyle.Render(m.hookViewport.View()) + "\n")
+		help = "↑/↓ pgup/pgdn: scroll hook output • " + help
+	}
+	sb.WriteString(helpStyle.Render(help))
 
 	return lipgloss.NewStyle().Padding(2).Render(sb.String())
 }
@@ -2141,8 +2583,20 @@ func (m TemplateExplorerModel) renderCreateComplete() string {
 	var sb strings.Builder
 
 	if m.createErr != nil {
-		sb.WriteString(headerStyle.Render("Creation Failed") + "\n\n")
-		sb.WriteString(promptErrorStyle.Render("Error: "+m.createErr.Error()) + "\n\n")
+		if errors.Is(m.createErr, context.Canceled) {
+			sb.WriteString(headerStyle.Render("Creation Cancelled") + "\n\n")
+			sb.WriteString("What was created so far is left in place.\n\n")
+		} else {
+			sb.WriteString(headerStyle.Render("Creation Failed") + "\n\n")
+			sb.WriteString(promptErrorStyle.Render("Error: "+m.createErr.Error()) + "\n")
+			if hint := errfmt.Hint(m.createErr); hint != "" {
+				sb.WriteString(helpStyle.Render("Hint: "+hint) + "\n")
+			}
+			sb.WriteString("\n")
+		}
+		if len(m.hookLog) > 0 {
+			sb.WriteString(hookLogStyle.Render(m.hookViewport.View()) + "\n")
+		}
 		sb.WriteString(helpStyle.Render("Press enter or esc to go back"))
 		return lipgloss.NewStyle().Padding(2).Render(sb.String())
 	}
@@ -2161,6 +2615,9 @@ func (m TemplateExplorerModel) renderCreateComplete() string {
 		result.FilesCreated, result.GlobalFiles, result.TemplateFiles))
 	sb.WriteString(fmt.Sprintf("Repos:          %d created, %d cloned\n",
 		result.ReposCreated, result.ReposCloned))
+	if len(result.PendingClones) > 0 {
+		sb.WriteString(fmt.Sprintf("Queued clones:  %s (offline; run co resume-network)\n", strings.Join(result.PendingClones, ", ")))
+	}
 
 	if len(result.HooksRun) > 0 {
 		sb.WriteString(fmt.Sprintf("Hooks run:      %s\n", strings.Join(result.HooksRun, ", ")))
@@ -2168,6 +2625,19 @@ func (m TemplateExplorerModel) renderCreateComplete() string {
 	if len(result.HooksSkipped) > 0 {
 		sb.WriteString(fmt.Sprintf("Hooks skipped:  %s\n", strings.Join(result.HooksSkipped, ", ")))
 	}
+	if len(m.hookSummary) > 0 {
+		sb.WriteString("\nHooks:\n")
+		for _, line := range m.hookSummary {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+
+	if len(result.Failures) > 0 {
+		sb.WriteString("\nFailed steps:\n")
+		for _, f := range result.Failures {
+			sb.WriteString(promptErrorStyle.Render(fmt.Sprintf("  ✗ %s %s (%d attempt(s)): %s", f.Step, f.Name, f.Attempts, f.Error)) + "\n")
+		}
+	}
 
 	if len(result.Warnings) > 0 {
 		sb.WriteString("\nWarnings:\n")
@@ -2193,9 +2663,9 @@ func (m TemplateExplorerModel) updateFilesTab(msg tea.KeyMsg) (tea.Model, tea.Cm
 		return m, nil
 
 	case "shift+tab":
-		return m.switchTab((m.activeTab + 4) % 5)
+		return m.switchTab((m.activeTab + tabCount - 1) % tabCount)
 
-	case "1", "2", "3", "4":
+	case "1", "2", "3", "4", "5", "6":
 		tabNum := int(msg.String()[0] - '1')
 		return m.switchTab(Tab(tabNum))
 
@@ -2348,9 +2818,9 @@ func (m TemplateExplorerModel) updateOutputTab(msg tea.KeyMsg) (tea.Model, tea.C
 		return m, nil
 
 	case "shift+tab":
-		return m.switchTab((m.activeTab + 4) % 5)
+		return m.switchTab((m.activeTab + tabCount - 1) % tabCount)
 
-	case "1", "2", "3", "4", "5":
+	case "1", "2", "3", "4", "5", "6":
 		tabNum := int(msg.String()[0] - '1')
 		return m.switchTab(Tab(tabNum))
 
@@ -2662,60 +3132,65 @@ func (m *TemplateExplorerModel) flattenNode(node *fileTreeNode) {
 // loadFileContent loads the content of a file asynchronously.
 func (m TemplateExplorerModel) loadFileContent(path string) tea.Cmd {
 	return func() tea.Msg {
-		info, err := os.Stat(path)
-		if err != nil {
-			return fileContentMsg{path: path, err: err}
+		msg := readFileForViewer(path)
+		if msg.err != nil || msg.isLarge || msg.isBinary {
+			return msg
 		}
 
-		size := info.Size()
-		isBinary := false
-		isLarge := size > maxFileViewerSize
+		// Check if template file
+		msg.isTemplate = isTemplateFile(path, m.getTemplateExtensions())
 
-		if isLarge {
-			return fileContentMsg{
-				path:    path,
-				size:    size,
-				isLarge: true,
+		// Render template if applicable
+		if msg.isTemplate {
+			vars := m.getPreviewVariables()
+			engine := m.previewEngine(path, vars)
+			rendered, err := template.RenderContent(engine, filepath.Base(path), msg.content, vars)
+			if err != nil {
+				rendered = fmt.Sprintf("Cannot render with the %s engine: %v", engine, err)
 			}
+			msg.renderedContent = rendered
 		}
 
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return fileContentMsg{path: path, err: err}
-		}
-
-		// Check if binary
-		isBinary = isBinaryData(content)
-		if isBinary {
-			return fileContentMsg{
-				path:     path,
-				size:     size,
-				isBinary: true,
-			}
-		}
+		return msg
+	}
+}
 
-		// Check if template file
-		isTemplate := isTemplateFile(path, m.getTemplateExtensions())
-		contentStr := string(content)
+// readFileForViewer reads a file for display, flagging files that are too large
+// (over maxFileViewerSize) or binary instead of returning their content.
+func readFileForViewer(path string) fileContentMsg {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileContentMsg{path: path, err: err}
+	}
 
-		// Render template if applicable
-		var renderedContent string
-		if isTemplate {
-			vars := m.getPreviewVariables()
-			rendered, _ := template.ProcessTemplateContent(contentStr, vars)
-			renderedContent = rendered
+	size := info.Size()
+	if size > maxFileViewerSize {
+		return fileContentMsg{
+			path:    path,
+			size:    size,
+			isLarge: true,
 		}
+	}
 
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fileContentMsg{path: path, err: err}
+	}
+
+	// Check if binary
+	if isBinaryData(content) {
 		return fileContentMsg{
-			path:            path,
-			content:         contentStr,
-			renderedContent: renderedContent,
-			size:            size,
-			isBinary:        false,
-			isLarge:         false,
-			isTemplate:      isTemplate,
+			path:     path,
+			size:     size,
+			isBinary: true,
 		}
 	}
+
+	return fileContentMsg{
+		path:    path,
+		content: string(content),
+		size:    size,
+	}
 }
 
 // isBinaryData checks if content appears to be binary.
@@ -2751,6 +3226,30 @@ func (m TemplateExplorerModel) getTemplateExtensions() []string {
 	return []string{".tmpl"}
 }
 
+// previewEngine returns the engine that renders the file at path: the
+// selected template's engine for its own files, and the simple engine for
+// global files. For the go engine, variables without a value get a
+// placeholder, as the simple engine leaves their {{VAR}} as it is.
+func (m TemplateExplorerModel) previewEngine(path string, vars map[string]string) string {
+	if m.selected == nil {
+		return template.EngineSimple
+	}
+	filesPath := filepath.Join(m.selected.TemplatePath, template.TemplateFilesDir)
+	if !strings.HasPrefix(path, filesPath+string(filepath.Separator)) {
+		return template.EngineSimple
+	}
+	tmpl, err := template.LoadTemplate(m.selected.SourceDir, m.selected.Info.Name)
+	if err != nil || tmpl.Engine != template.EngineGo {
+		return template.EngineSimple
+	}
+	for _, v := range tmpl.Variables {
+		if _, ok := vars[v.Name]; !ok {
+			vars[v.Name] = "<" + v.Name + ">"
+		}
+	}
+	return template.EngineGo
+}
+
 // getPreviewVariables returns variables for template preview.
 func (m TemplateExplorerModel) getPreviewVariables() map[string]string {
 	vars := make(map[string]string)
@@ -2772,9 +3271,9 @@ func (m TemplateExplorerModel) getPreviewVariables() map[string]string {
 
 	vars["OWNER"] = owner
 	vars["PROJECT"] = project
-	vars["SLUG"] = owner + "--" + project
+	vars["SLUG"] = workspace.SchemeFor(m.cfg).Format(owner, project)
 	vars["CODE_ROOT"] = m.cfg.CodeRoot
-	vars["WORKSPACE_PATH"] = filepath.Join(m.cfg.CodeRoot, owner+"--"+project)
+	vars["WORKSPACE_PATH"] = m.cfg.WorkspacePath(vars["SLUG"])
 	vars["CREATED_DATE"] = "<date>"
 	vars["CREATED_DATETIME"] = "<datetime>"
 	vars["YEAR"] = "<year>"
@@ -2788,6 +3287,9 @@ func (m TemplateExplorerModel) getPreviewVariables() map[string]string {
 
 // RunTemplateExplorer runs the template explorer TUI.
 func RunTemplateExplorer(cfg *config.Config) error {
+	if err := requireFullScreen("template explorer", "'co template list' and 'co template show <name>'"); err != nil {
+		return err
+	}
 	// Load templates from all directories
 	listings, globalPaths, err := template.ListTemplateListingsMulti(cfg.AllTemplatesDirs())
 	if err != nil {
@@ -2795,10 +3297,26 @@ func RunTemplateExplorer(cfg *config.Config) error {
 	}
 
 	m := NewTemplateExplorer(cfg, listings, globalPaths)
+	session := loadSession(cfg)
+	m = m.applySession(session.TemplateExplorer)
+	m.lastOwner = session.LastOwner
+	m.ownerInput.SetValue(session.LastOwner)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
-	_, err = p.Run()
-	return err
+	finalModel, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	if final, ok := finalModel.(TemplateExplorerModel); ok {
+		updateSession(cfg, func(s *tuiSession) {
+			final.recordSession(&s.TemplateExplorer)
+			if final.lastOwner != "" {
+				s.LastOwner = final.lastOwner
+			}
+		})
+	}
+	return nil
 }
 
 // loadFileDiagnostics loads file pattern diagnostics for the selected template.
@@ -2861,11 +3379,35 @@ func (m TemplateExplorerModel) loadPlaceholderDiagnostics() tea.Cmd {
 
 // updateDiagnosticsOverlay handles key events when the diagnostics overlay is showing.
 func (m TemplateExplorerModel) updateDiagnosticsOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if len(m.diagNewVars) > 0 {
+		return m.updateNewVarsPrompt(msg)
+	}
+
 	switch msg.String() {
 	case "ctrl+c", "q", "esc":
 		m.diagMode = false
 		return m, nil
 
+	case "v":
+		// Declare variables for the unresolved placeholders
+		if m.diagShowPatterns || m.diagReport == nil {
+			return m, nil
+		}
+		names := m.diagReport.UnresolvedVarNames()
+		if len(names) == 0 {
+			m.message = "No unresolved placeholders"
+			m.messageIsError = false
+			return m, nil
+		}
+		m.diagNewVars = make([]template.TemplateVar, len(names))
+		for i, name := range names {
+			m.diagNewVars[i] = template.TemplateVar{Name: name, Type: template.VarTypeString, Required: true}
+		}
+		m.diagNewVarIdx = 0
+		m.diagNewVarDesc.SetValue("")
+		m.message = ""
+		return m, m.diagNewVarDesc.Focus()
+
 	case "j", "down":
 		maxIdx := m.getDiagnosticsCount() - 1
 		if m.diagSelected < maxIdx {
@@ -2910,6 +3452,88 @@ func (m TemplateExplorerModel) updateDiagnosticsOverlay(msg tea.KeyMsg) (tea.Mod
 	return m, nil
 }
 
+// newVarTypes are the types offered when declaring a placeholder variable.
+// Choice variables need choices, which are added in template.json.
+var newVarTypes = []template.VarType{template.VarTypeString, template.VarTypeBoolean, template.VarTypeInteger}
+
+// updateNewVarsPrompt handles key events while declaring variables for the
+// unresolved placeholders: tab cycles the type, enter moves to the next
+// variable and, after the last, writes them all to template.json.
+func (m TemplateExplorerModel) updateNewVarsPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := &m.diagNewVars[m.diagNewVarIdx]
+	switch msg.String() {
+	case "ctrl+c", "esc":
+		m.diagNewVars = nil
+		m.diagNewVarDesc.Blur()
+		m.message = "No variables declared"
+		m.messageIsError = false
+		return m, nil
+
+	case "tab", "shift+tab":
+		step := 1
+		if msg.String() == "shift+tab" {
+			step = len(newVarTypes) - 1
+		}
+		i := slices.Index(newVarTypes, v.Type)
+		v.Type = newVarTypes[(i+step)%len(newVarTypes)]
+		return m, nil
+
+	case "enter":
+		v.Description = strings.TrimSpace(m.diagNewVarDesc.Value())
+		if m.diagNewVarIdx < len(m.diagNewVars)-1 {
+			m.diagNewVarIdx++
+			m.diagNewVarDesc.SetValue("")
+			return m, nil
+		}
+		vars := m.diagNewVars
+		m.diagNewVars = nil
+		m.diagNewVarDesc.Blur()
+		return m, m.addTemplateVariables(vars)
+	}
+
+	var cmd tea.Cmd
+	m.diagNewVarDesc, cmd = m.diagNewVarDesc.Update(msg)
+	return m, cmd
+}
+
+// addTemplateVariables declares vars in the selected template's template.json.
+func (m TemplateExplorerModel) addTemplateVariables(vars []template.TemplateVar) tea.Cmd {
+	return func() tea.Msg {
+		if m.selected == nil {
+			return diagVarsAddedMsg{err: fmt.Errorf("no template selected")}
+		}
+		if err := template.AddVariables(m.selected.SourceDir, m.selected.Info.Name, vars); err != nil {
+			return diagVarsAddedMsg{err: err}
+		}
+		return diagVarsAddedMsg{count: len(vars)}
+	}
+}
+
+// formatNewVarsPrompt formats the prompt for the variable being declared.
+func (m TemplateExplorerModel) formatNewVarsPrompt() string {
+	var sb strings.Builder
+	v := m.diagNewVars[m.diagNewVarIdx]
+
+	sb.WriteString(headerStyle.Render("Declare Placeholder Variables") + "\n\n")
+	sb.WriteString(fmt.Sprintf("Variable %d of %d: %s\n\n", m.diagNewVarIdx+1, len(m.diagNewVars),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Render("{{"+v.Name+"}}")))
+
+	var types []string
+	for _, t := range newVarTypes {
+		if t == v.Type {
+			types = append(types, lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212")).Render("["+string(t)+"]"))
+		} else {
+			types = append(types, " "+string(t)+" ")
+		}
+	}
+	sb.WriteString("Type:        " + strings.Join(types, " ") + "\n")
+	sb.WriteString("Description: " + m.diagNewVarDesc.View() + "\n\n")
+
+	noteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	sb.WriteString(noteStyle.Render("Declared as required, without a default. Set defaults, validation, and choices in template.json.") + "\n")
+	return sb.String()
+}
+
 // getDiagnosticsCount returns the number of items in the current diagnostics view.
 func (m TemplateExplorerModel) getDiagnosticsCount() int {
 	if m.diagShowPatterns {
@@ -3062,6 +3686,9 @@ func (m TemplateExplorerModel) renderDiagnosticsOverlay() string {
 	m.diagViewport.Height = contentHeight
 
 	content := m.formatDiagnosticsContent()
+	if len(m.diagNewVars) > 0 {
+		content = m.formatNewVarsPrompt()
+	}
 	m.diagViewport.SetContent(content)
 
 	contentBox := lipgloss.NewStyle().
@@ -3076,7 +3703,19 @@ func (m TemplateExplorerModel) renderDiagnosticsOverlay() string {
 
 	// Help
 	help := "j/k: navigate • g/G: top/bottom • p: toggle patterns/placeholders • esc: close"
+	if len(m.diagNewVars) > 0 {
+		help = "tab: type • enter: next variable • esc: cancel"
+	} else if !m.diagShowPatterns {
+		help = "j/k: navigate • g/G: top/bottom • v: declare unresolved as variables • p: toggle patterns/placeholders • esc: close"
+	}
 	helpLine := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(help)
+	if m.message != "" {
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color("82"))
+		if m.messageIsError {
+			style = promptErrorStyle
+		}
+		sb.WriteString("\n" + style.Render(m.message))
+	}
 	sb.WriteString("\n" + helpLine)
 
 	return sb.String()
@@ -3430,3 +4069,280 @@ func (m TemplateExplorerModel) renderCompareOverlay() string {
 
 	return sb.String()
 }
+
+// loadHooks loads the hooks of the selected template for the Hooks tab.
+func (m *TemplateExplorerModel) loadHooks() {
+	m.hooksTemplate, m.hooksList = nil, nil
+	m.hooksSelected, m.hooksScroll, m.hooksFocusPane = 0, 0, 0
+	m.hooksRun = nil
+	if m.selected != nil {
+		if tmpl, err := template.LoadTemplate(m.selected.SourceDir, m.selected.Info.Name); err == nil {
+			m.hooksTemplate = tmpl
+			m.hooksList = template.ListHooks(tmpl)
+		}
+	}
+	m.loadHookScript()
+}
+
+// loadHookScript reads the script of the selected hook.
+func (m *TemplateExplorerModel) loadHookScript() {
+	m.hooksScriptPath, m.hooksScript, m.hooksScriptErr = "", "", ""
+	if m.hooksTemplate == nil || m.hooksSelected >= len(m.hooksList) {
+		return
+	}
+	spec := template.GetHookSpec(m.hooksTemplate, m.hooksList[m.hooksSelected])
+	m.hooksScriptPath = template.ResolveHookPath(m.selected.TemplatePath, spec.Script)
+	data, err := os.ReadFile(m.hooksScriptPath)
+	if err != nil {
+		m.hooksScriptErr = err.Error()
+		return
+	}
+	m.hooksScript = string(data)
+}
+
+// selectHook selects the hook at index i, dropping the last test run.
+func (m *TemplateExplorerModel) selectHook(i int) {
+	if i < 0 || i >= len(m.hooksList) || i == m.hooksSelected {
+		return
+	}
+	m.hooksSelected = i
+	m.hooksScroll = 0
+	m.hooksRun = nil
+	m.loadHookScript()
+}
+
+// updateHooksTab handles key events for the Hooks tab.
+func (m TemplateExplorerModel) updateHooksTab(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.hooksEditing {
+		switch msg.String() {
+		case "ctrl+s":
+			return m, saveHookScript(m.hooksScriptPath, m.hooksEditor.Value())
+		case "esc":
+			m.hooksEditing = false
+			m.hooksEditor.Blur()
+			m.message = "Changes discarded"
+			m.messageIsError = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.hooksEditor, cmd = m.hooksEditor.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+
+	case "tab":
+		return m.switchTab((m.activeTab + 1) % tabCount)
+
+	case "shift+tab":
+		return m.switchTab((m.activeTab + tabCount - 1) % tabCount)
+
+	case "1", "2", "3", "4", "5", "6":
+		tabNum := int(msg.String()[0] - '1')
+		return m.switchTab(Tab(tabNum))
+
+	case "j", "down":
+		m.selectHook(m.hooksSelected + 1)
+		return m, nil
+
+	case "k", "up":
+		m.selectHook(m.hooksSelected - 1)
+		return m, nil
+
+	case "h", "left":
+		m.hooksFocusPane = 0
+		return m, nil
+
+	case "l", "right", "enter":
+		m.hooksFocusPane = 1
+		return m, nil
+
+	case "d", "ctrl+d":
+		m.hooksScroll += m.hooksPageSize()
+		return m, nil
+
+	case "u", "ctrl+u":
+		m.hooksScroll = max(0, m.hooksScroll-m.hooksPageSize())
+		return m, nil
+
+	case "s":
+		m.hooksRun = nil
+		m.hooksScroll = 0
+		return m, nil
+
+	case "e":
+		if m.hooksScriptPath == "" || m.hooksScriptErr != "" {
+			return m, nil
+		}
+		m.hooksEditing = true
+		m.hooksFocusPane = 1
+		m.hooksRun = nil
+		m.hooksEditor.SetWidth(max(20, m.width/2-6))
+		m.hooksEditor.SetHeight(max(5, m.height-14))
+		m.hooksEditor.SetValue(m.hooksScript)
+		m.hooksEditor.CursorStart()
+		m.message = ""
+		return m, m.hooksEditor.Focus()
+
+	case "t":
+		if m.hooksRunning || m.hooksSelected >= len(m.hooksList) {
+			return m, nil
+		}
+		m.hooksRunning = true
+		m.message = ""
+		return m, m.runHookTest(m.hooksList[m.hooksSelected])
+	}
+
+	return m, nil
+}
+
+// hooksPageSize is the number of lines d and u scroll the right pane by.
+func (m TemplateExplorerModel) hooksPageSize() int {
+	return max(5, (m.height-14)/2)
+}
+
+// runHookTest runs hook against a temporary workspace rendered from the
+// selected template.
+func (m TemplateExplorerModel) runHookTest(hook template.HookType) tea.Cmd {
+	name := m.selected.Info.Name
+	return func() tea.Msg {
+		result, err := template.TryHook(context.Background(), m.cfg, name, hook)
+		return hookTestRunMsg{hook: hook, result: result, err: err}
+	}
+}
+
+// saveHookScript writes content to the hook script at path.
+func saveHookScript(path, content string) tea.Cmd {
+	return func() tea.Msg {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		return hookScriptSavedMsg{path: path, err: os.WriteFile(path, []byte(content), 0o755)}
+	}
+}
+
+func (m TemplateExplorerModel) renderHooksTab() string {
+	paneHeight := m.height - 10
+	if paneHeight < 5 {
+		paneHeight = 5
+	}
+
+	leftStyle := paneStyle
+	rightStyle := paneStyle
+	if m.hooksFocusPane == 0 {
+		leftStyle = activePaneStyle
+	} else {
+		rightStyle = activePaneStyle
+	}
+
+	leftPane := leftStyle.Width(m.width/2 - 2).Height(paneHeight).Render(m.renderHooksList())
+	rightPane := rightStyle.Width(m.width/2 - 2).Height(paneHeight).Render(m.renderHookDetails(paneHeight - 4))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane)
+}
+
+func (m TemplateExplorerModel) renderHooksList() string {
+	var sb strings.Builder
+
+	sb.WriteString(headerStyle.Render("Hooks") + "\n\n")
+
+	if m.selected == nil {
+		sb.WriteString("No template selected.\n")
+		sb.WriteString("Select a template in the Browse tab first.")
+		return sb.String()
+	}
+	if len(m.hooksList) == 0 {
+		sb.WriteString("This template defines no hooks.\n")
+		sb.WriteString(helpStyle.Render("Add them under \"hooks\" in template.json."))
+		return sb.String()
+	}
+
+	for i, hook := range m.hooksList {
+		spec := template.GetHookSpec(m.hooksTemplate, hook)
+		prefix := "  "
+		style := lipgloss.NewStyle()
+		if i == m.hooksSelected {
+			prefix = "▶ "
+			style = selectedStyle
+		}
+		sb.WriteString(style.Render(fmt.Sprintf("%s%s  %s", prefix, hook, formatHookScript(spec))) + "\n")
+		sb.WriteString(helpStyle.Render("    "+template.HookTrigger(hook)) + "\n")
+	}
+
+	if !m.selected.Trusted {
+		sb.WriteString("\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("Untrusted template: hooks do not run, test runs included") + "\n")
+	}
+	return sb.String()
+}
+
+// formatHookScript formats the script and timeout of a hook for the list.
+func formatHookScript(spec template.HookSpec) string {
+	if spec.Timeout != "" {
+		return fmt.Sprintf("%s (timeout %s)", spec.Script, spec.Timeout)
+	}
+	return spec.Script
+}
+
+// renderHookDetails renders the right pane of the Hooks tab: the script
+// editor, the last test run, or the script, height lines at most.
+func (m TemplateExplorerModel) renderHookDetails(height int) string {
+	var sb strings.Builder
+
+	if m.hooksSelected >= len(m.hooksList) {
+		sb.WriteString(headerStyle.Render("Script") + "\n\n")
+		sb.WriteString("Select a hook to view its script.")
+		return sb.String()
+	}
+
+	if m.hooksEditing {
+		sb.WriteString(headerStyle.Render("Editing "+filepath.Base(m.hooksScriptPath)) + "\n\n")
+		sb.WriteString(m.hooksEditor.View())
+		return sb.String()
+	}
+
+	var body string
+	switch {
+	case m.hooksRunning:
+		sb.WriteString(headerStyle.Render("Test Run") + "\n\n")
+		sb.WriteString("Running " + string(m.hooksList[m.hooksSelected]) + " against a temporary workspace...")
+		return sb.String()
+
+	case m.hooksRun != nil:
+		sb.WriteString(headerStyle.Render("Test Run: "+string(m.hooksRun.hook)) + "\n\n")
+		run := m.hooksRun
+		if run.result != nil {
+			status := lipgloss.NewStyle().Foreground(lipgloss.Color("82")).Render("✓ exit code 0")
+			if run.result.ExitCode != 0 {
+				status = promptErrorStyle.Render(fmt.Sprintf("✗ exit code %d", run.result.ExitCode))
+			} else if run.err != nil {
+				status = promptErrorStyle.Render("✗ failed")
+			}
+			sb.WriteString(fmt.Sprintf("%s in %s\n", status, run.result.Duration.Round(time.Millisecond)))
+		}
+		if run.err != nil {
+			sb.WriteString(promptErrorStyle.Render(strings.SplitN(run.err.Error(), "\n", 2)[0]) + "\n")
+		}
+		sb.WriteString("\n")
+		body = "(no output)"
+		if run.result != nil && run.result.Output != "" {
+			body = strings.TrimRight(run.result.Output, "\n")
+		}
+
+	default:
+		sb.WriteString(headerStyle.Render("Script: "+filepath.Base(m.hooksScriptPath)) + "\n\n")
+		if m.hooksScriptErr != "" {
+			sb.WriteString(promptErrorStyle.Render(m.hooksScriptErr))
+			return sb.String()
+		}
+		body = strings.TrimRight(m.hooksScript, "\n")
+	}
+
+	lines := strings.Split(body, "\n")
+	visible := max(1, height-strings.Count(sb.String(), "\n"))
+	start := min(m.hooksScroll, max(0, len(lines)-visible))
+	end := min(len(lines), start+visible)
+	sb.WriteString(strings.Join(lines[start:end], "\n"))
+	return sb.String()
+}
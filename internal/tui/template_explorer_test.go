@@ -0,0 +1,263 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/template"
+)
+
+func TestTemplateExplorerSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	templates := filepath.Join("Code", "_system", "templates")
+	for path, content := range map[string]string{
+		"go-service/template.json": `{
+			"name": "go-service",
+			"description": "Go service with CI",
+			"variables": [
+				{"name": "port", "type": "integer", "default": 8080},
+				{"name": "ci", "type": "boolean", "default": true}
+			],
+			"repos": [{"name": "api", "init": true}]
+		}`,
+		"go-service/files/README.md.tmpl": "# {{project}}\n",
+		"go-service/files/Makefile":       "run:\n\tgo run .\n",
+		"notes/template.json": `{
+			"name": "notes",
+			"description": "Plain notes workspace"
+		}`,
+		"notes/files/index.md": "# Notes\n",
+	} {
+		path = filepath.Join(dir, templates, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newModel := func() tea.Model {
+		cfg := &config.Config{CodeRoot: "Code"}
+		listings, globalPaths, err := template.ListTemplateListingsMulti([]string{cfg.TemplatesDir()})
+		if err != nil {
+			t.Fatalf("ListTemplateListingsMulti() error = %v", err)
+		}
+		return NewTemplateExplorer(cfg, listings, globalPaths)
+	}
+	runSnapshot(t, dir, newModel, []snapshotStep{
+		{name: "browse", wait: []string{"go-service"}},
+		{name: "second template", keys: []string{"j"}, wait: []string{"Plain notes workspace"}},
+		{name: "files", keys: []string{"k", "2"}, wait: []string{"Makefile"}},
+		{name: "file viewer", keys: []string{"j", "j", "enter"}, wait: []string{"go run ."}},
+		{name: "output", keys: []string{"3"}, wait: []string{"README.md"}},
+		{name: "create", keys: []string{"4"}, wait: []string{"Owner"}},
+		{name: "create typed", keys: []string{"acme", "tab", "demo"}, wait: []string{"acme--demo"}},
+		{name: "validate", keys: []string{"esc", "shift+tab"}, wait: []string{"Validate"}},
+		{name: "validated", keys: []string{"V"}, wait: []string{"notes"}},
+		{name: "hooks", keys: []string{"5"}, wait: []string{"defines no hooks"}},
+	})
+}
+
+func TestDeclarePlaceholderVariables(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"svc/template.json":         `{"name": "svc", "description": "Service"}`,
+		"svc/files/config.env.tmpl": "PORT={{PORT}}\nDEBUG={{DEBUG}}\n",
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listings, globalPaths, err := template.ListTemplateListingsMulti([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var model tea.Model = NewTemplateExplorer(&config.Config{}, listings, globalPaths)
+
+	// run sends msg and follows the commands it returns, two levels deep,
+	// which covers writing template.json and the rescan after it
+	run := func(msg tea.Msg) {
+		var cmd tea.Cmd
+		model, cmd = model.Update(msg)
+		for depth := 0; depth < 2 && cmd != nil; depth++ {
+			next := cmd()
+			if next == nil {
+				break
+			}
+			model, cmd = model.Update(next)
+		}
+	}
+	key := func(s string) tea.KeyMsg {
+		switch s {
+		case "enter":
+			return tea.KeyMsg{Type: tea.KeyEnter}
+		case "tab":
+			return tea.KeyMsg{Type: tea.KeyTab}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	run(key("2"))
+	run(key("D"))
+	run(key("v"))
+	run(key("Port"))
+	run(key("tab"))
+	run(key("tab"))
+	run(key("enter"))
+	run(key("tab"))
+	run(key("enter"))
+
+	tmpl, err := template.LoadTemplate(dir, "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmpl.Variables) != 2 {
+		t.Fatalf("variables = %+v, want PORT and DEBUG", tmpl.Variables)
+	}
+	port, debug := tmpl.Variables[0], tmpl.Variables[1]
+	if port.Name != "PORT" || port.Type != template.VarTypeInteger || port.Description != "Port" {
+		t.Errorf("PORT = %+v, want an integer described as Port", port)
+	}
+	if debug.Name != "DEBUG" || debug.Type != template.VarTypeBoolean {
+		t.Errorf("DEBUG = %+v, want a boolean", debug)
+	}
+	if m := model.(TemplateExplorerModel); m.diagReport.HasUnresolvedPlaceholders() {
+		t.Error("placeholders are still unresolved after declaring them")
+	}
+}
+
+func TestTemplateExplorerHooks(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	for path, content := range map[string]string{
+		"svc/template.json":        `{"name": "svc", "description": "Service", "hooks": {"post_create": {"script": "setup.sh"}}}`,
+		"svc/files/README.md.tmpl": "# {{PROJECT}}\n",
+		"svc/hooks/setup.sh":       "#!/bin/bash\necho old\n",
+	} {
+		path = filepath.Join(cfg.TemplatesDir(), path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listings, globalPaths, err := template.ListTemplateListingsMulti([]string{cfg.TemplatesDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var model tea.Model = NewTemplateExplorer(cfg, listings, globalPaths)
+	send := func(msg tea.Msg) tea.Cmd {
+		var cmd tea.Cmd
+		model, cmd = model.Update(msg)
+		return cmd
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("5")})
+	m := model.(TemplateExplorerModel)
+	if len(m.hooksList) != 1 || m.hooksList[0] != template.HookPostCreate || m.hooksScript != "#!/bin/bash\necho old\n" {
+		t.Fatalf("hooks = %v with script %q, want post_create running setup.sh", m.hooksList, m.hooksScript)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m = model.(TemplateExplorerModel)
+	m.hooksEditor.SetValue("#!/bin/bash\ncat README.md\necho \"ran in $CO_WORKSPACE_SLUG\"")
+	model = m
+	if cmd := send(tea.KeyMsg{Type: tea.KeyCtrlS}); cmd != nil {
+		send(cmd())
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.TemplatesDir(), "svc", "hooks", "setup.sh"))
+	if err != nil || string(data) != "#!/bin/bash\ncat README.md\necho \"ran in $CO_WORKSPACE_SLUG\"\n" {
+		t.Fatalf("saved script = %q, %v", data, err)
+	}
+	if m = model.(TemplateExplorerModel); m.hooksEditing {
+		t.Error("still editing after saving")
+	}
+
+	if cmd := send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")}); cmd != nil {
+		send(cmd())
+	}
+	m = model.(TemplateExplorerModel)
+	if m.hooksRun == nil || m.hooksRun.err != nil {
+		t.Fatalf("test run = %+v, want a successful run", m.hooksRun)
+	}
+	if out := m.hooksRun.result.Output; out != "# svc\nran in co-test--svc\n" {
+		t.Errorf("test run output = %q", out)
+	}
+}
+
+func TestValidateTabWatch(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	dir := filepath.Join(cfg.TemplatesDir(), "svc")
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "template.json"), []byte(`{"name": "svc", "description": "Service"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	listings, globalPaths, err := template.ListTemplateListingsMulti([]string{cfg.TemplatesDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var model tea.Model = NewTemplateExplorer(cfg, listings, globalPaths)
+	send := func(msg tea.Msg) tea.Cmd {
+		var cmd tea.Cmd
+		model, cmd = model.Update(msg)
+		return cmd
+	}
+
+	// Opening the tab validates all templates
+	cmd := send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("6")})
+	if cmd == nil {
+		t.Fatal("opening the Validate tab did not start validation")
+	}
+	send(cmd())
+	if m := model.(TemplateExplorerModel); m.validating || len(m.validationResults) != 1 {
+		t.Fatalf("validation results = %+v, want one", m.validationResults)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	watcher := model.(TemplateExplorerModel).validationWatcher
+	if watcher == nil {
+		t.Fatal("w did not start watching")
+	}
+
+	// Files in directories created after watching started are watched too
+	sub := filepath.Join(dir, "files", "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange := func() {
+		t.Helper()
+		select {
+		case <-watcher.changes:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change reported")
+		}
+	}
+	waitForChange()
+	if err := os.WriteFile(filepath.Join(sub, "main.go.tmpl"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForChange()
+
+	if cmd := send(templatesChangedMsg{}); cmd == nil || !model.(TemplateExplorerModel).validating {
+		t.Error("a change did not start revalidation")
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if model.(TemplateExplorerModel).validationWatcher != nil {
+		t.Error("w did not stop watching")
+	}
+	if cmd := send(templatesChangedMsg{}); cmd != nil {
+		t.Error("a change after watching stopped started revalidation")
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -134,6 +135,11 @@ func (m templateSelectModel) View() string {
 
 // RunTemplateSelect runs the template selection TUI and returns the selected template name.
 func RunTemplateSelect(templates []template.TemplateInfo) (TemplateSelectResult, error) {
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return TemplateSelectResult{Abort: true}, err
+	} else if lines {
+		return runPlainTemplateSelect(templates), nil
+	}
 	m := newTemplateSelectModel(templates)
 	p := tea.NewProgram(m)
 
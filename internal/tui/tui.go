@@ -2,16 +2,26 @@ package tui
 
 import (
 	"fmt"
+	"hash/fnv"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/tormodhaugland/co/internal/archive"
 	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/index"
 	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/notes"
+	"github.com/tormodhaugland/co/internal/platform"
+	"github.com/tormodhaugland/co/internal/plugin"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/workspace"
 )
 
 var (
@@ -30,35 +40,108 @@ var (
 	headerStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212")).MarginBottom(1)
 )
 
+// ownerColors is the palette owners are color-coded with in co ls and the
+// workspace browser.
+var ownerColors = []lipgloss.Color{"39", "208", "42", "170", "220", "75", "203", "114", "141", "179"}
+
+// OwnerStyle returns the style an owner's name is shown in. The color is
+// picked from the owner name, so an owner keeps its color between runs.
+func OwnerStyle(owner string) lipgloss.Style {
+	h := fnv.New32a()
+	h.Write([]byte(owner))
+	return lipgloss.NewStyle().Bold(true).Foreground(ownerColors[h.Sum32()%uint32(len(ownerColors))])
+}
+
 type workspaceItem struct {
 	record *model.IndexRecord
 }
 
-func (i workspaceItem) Title() string { return i.record.Slug }
+func (i workspaceItem) Title() string { return workspaceTitle(i.record) }
 func (i workspaceItem) Description() string {
 	dirty := ""
 	if i.record.DirtyRepos > 0 {
 		dirty = fmt.Sprintf(" [%d dirty]", i.record.DirtyRepos)
 	}
-	return fmt.Sprintf("%s • %d repos%s", i.record.State, i.record.RepoCount, dirty)
+	if n := len(i.record.Violations); n > 0 {
+		dirty += fmt.Sprintf(" [%d policy]", n)
+	}
+	desc := fmt.Sprintf("%s • %d repos%s", i.record.State, i.record.RepoCount, dirty)
+	if line := index.DescriptionLine(i.record.Description, 60); line != "" {
+		desc += " • " + line
+	}
+	return desc
+}
+func (i workspaceItem) FilterValue() string {
+	return i.record.Slug + " " + i.record.DisplayName + " " + i.record.Owner + " " + i.record.Description
+}
+
+// workspaceTitle returns a workspace's icon and display name, followed by
+// its slug when the display name hides it.
+func workspaceTitle(r *model.IndexRecord) string {
+	if r.DisplayName == "" {
+		return r.Label()
+	}
+	return r.Label() + " · " + r.Slug
+}
+
+// ownerItem is the header of an owner's section in the workspace list. A
+// collapsed section hides its workspaces but still matches searches for them.
+type ownerItem struct {
+	group     index.OwnerGroup
+	collapsed bool
+}
+
+func (i ownerItem) Title() string {
+	arrow := "▾"
+	if i.collapsed {
+		arrow = "▸"
+	}
+	return arrow + " " + OwnerStyle(i.group.Owner).Render(i.group.Owner)
+}
+func (i ownerItem) Description() string { return i.group.Summary() }
+func (i ownerItem) FilterValue() string {
+	value := i.group.Owner
+	if i.collapsed {
+		for _, r := range i.group.Workspaces {
+			value += " " + workspaceItem{record: r}.FilterValue()
+		}
+	}
+	return value
 }
-func (i workspaceItem) FilterValue() string { return i.record.Slug + " " + i.record.Owner }
 
 type keyMap struct {
 	Open    key.Binding
 	Shell   key.Binding
+	Reveal  key.Binding
+	Notes   key.Binding
+	Edit    key.Binding
+	Up      key.Binding
 	Archive key.Binding
 	Sync    key.Binding
 	Reindex key.Binding
+	Restore key.Binding
+	Suggest key.Binding
+	Batch   key.Binding
+	Plugins key.Binding
+	Fold    key.Binding
 	Quit    key.Binding
 }
 
 var keys = keyMap{
 	Open:    key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open in editor")),
 	Shell:   key.NewBinding(key.WithKeys("enter", "c"), key.WithHelp("enter/c", "shell")),
+	Reveal:  key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "reveal in file manager")),
+	Notes:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "notes")),
+	Edit:    key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "describe")),
+	Up:      key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "dev container / nix shell")),
 	Archive: key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "archive")),
 	Sync:    key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sync")),
 	Reindex: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "reindex")),
+	Restore: key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "restore from cold storage")),
+	Suggest: key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "show archive suggestions")),
+	Batch:   key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "archive all suggestions")),
+	Plugins: key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "plugin actions")),
+	Fold:    key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "collapse/expand owner")),
 	Quit:    key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
 }
 
@@ -67,16 +150,38 @@ type Model struct {
 	list     list.Model
 	records  []*model.IndexRecord
 	selected *model.IndexRecord
+	cold     map[string]bool // Slugs in cold storage
 	width    int
 	height   int
 	message  string
+
+	// Owner sections: the records listed and the owners collapsed
+	shown     []*model.IndexRecord
+	collapsed map[string]bool
+
+	// Archive suggestions: inactive workspaces with clean, pushed repos
+	suggestOnly    bool
+	suggested      []string
+	confirmArchive bool
+
+	// Plugin actions menu
+	pluginActions []pluginAction
+	menuOpen      bool
+	menuIndex     int
+
+	// Description edit box
+	describing    bool
+	describeInput textinput.Model
+}
+
+// pluginAction is an action a plugin adds to the plugin menu.
+type pluginAction struct {
+	plugin plugin.Plugin
+	action plugin.Action
 }
 
 func New(cfg *config.Config, records []*model.IndexRecord) Model {
-	items := make([]list.Item, len(records))
-	for i, r := range records {
-		items[i] = workspaceItem{record: r}
-	}
+	items := workspaceItems(records, nil)
 
 	delegate := list.NewDefaultDelegate()
 	l := list.New(items, delegate, 40, 20)
@@ -85,17 +190,76 @@ func New(cfg *config.Config, records []*model.IndexRecord) Model {
 	l.SetFilteringEnabled(true)
 	l.SetShowHelp(false)
 
+	// Start on the first workspace rather than its owner's header
 	var selected *model.IndexRecord
-	if len(records) > 0 {
-		selected = records[0]
+	if len(items) > 1 {
+		l.Select(1)
+		selected = items[1].(workspaceItem).record
 	}
 
+	describeInput := textinput.New()
+	describeInput.Placeholder = "What is this workspace?"
+	describeInput.CharLimit = 500
+
 	return Model{
-		cfg:      cfg,
-		list:     l,
-		records:  records,
-		selected: selected,
+		cfg:           cfg,
+		list:          l,
+		records:       records,
+		selected:      selected,
+		cold:          map[string]bool{},
+		describeInput: describeInput,
+		shown:         records,
+		collapsed:     map[string]bool{},
+	}
+}
+
+// workspaceItems returns the list items for records: a header per owner,
+// followed by the owner's workspaces unless it is collapsed.
+func workspaceItems(records []*model.IndexRecord, collapsed map[string]bool) []list.Item {
+	var items []list.Item
+	for _, g := range index.GroupByOwner(records) {
+		items = append(items, ownerItem{group: g, collapsed: collapsed[g.Owner]})
+		if collapsed[g.Owner] {
+			continue
+		}
+		for _, r := range g.Workspaces {
+			items = append(items, workspaceItem{record: r})
+		}
+	}
+	return items
+}
+
+// show lists records, grouped by owner.
+func (m *Model) show(records []*model.IndexRecord) tea.Cmd {
+	m.shown = records
+	return m.list.SetItems(workspaceItems(records, m.collapsed))
+}
+
+// toggleOwner collapses or expands the owner section under the cursor, which
+// is on its header or one of its workspaces, and leaves the cursor on the
+// header.
+func (m *Model) toggleOwner() tea.Cmd {
+	var owner string
+	switch item := m.list.SelectedItem().(type) {
+	case ownerItem:
+		owner = item.group.Owner
+	case workspaceItem:
+		if owner = item.record.Owner; owner == "" {
+			owner = "(unknown)" // As grouped by index.GroupByOwner
+		}
+	default:
+		return nil
 	}
+	m.collapsed[owner] = !m.collapsed[owner]
+	cmd := m.show(m.shown)
+	for i, item := range m.list.Items() {
+		if header, ok := item.(ownerItem); ok && header.group.Owner == owner {
+			m.list.Select(i)
+			break
+		}
+	}
+	m.selected = nil
+	return cmd
 }
 
 func (m Model) Init() tea.Cmd {
@@ -109,7 +273,88 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		m.list.SetSize(msg.Width/2-4, msg.Height-6)
 
+	case notesClosedMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Opening notes failed: %v", msg.err)
+		}
+		return m, nil
+
+	case upClosedMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Dev environment failed: %v", msg.err)
+		}
+		return m, nil
+
+	case suggestionsMsg:
+		m.suggestOnly = true
+		m.suggested = msg.slugs
+		show := make(map[string]bool, len(msg.slugs))
+		for _, slug := range msg.slugs {
+			show[slug] = true
+		}
+		var records []*model.IndexRecord
+		for _, r := range m.records {
+			if show[r.Slug] {
+				records = append(records, r)
+			}
+		}
+		cmd := m.show(records)
+		if len(records) == 0 {
+			m.message = fmt.Sprintf("No clean workspaces inactive for %d+ days", msg.days)
+		} else {
+			m.message = fmt.Sprintf("%d workspace(s) inactive for %d+ days with clean, pushed repos • A: archive all • i: show all", len(records), msg.days)
+		}
+		return m, cmd
+
+	case batchArchivedMsg:
+		for slug, path := range msg.archived {
+			m.cold[slug] = true
+			for _, r := range m.records {
+				if r.Slug == slug {
+					r.State = model.StateArchived
+					r.Path = path
+				}
+			}
+		}
+		m.suggestOnly = false
+		m.suggested = nil
+		m.message = fmt.Sprintf("Moved %d workspace(s) to cold storage", len(msg.archived))
+		if len(msg.errs) > 0 {
+			m.message += fmt.Sprintf("; %d failed: %v", len(msg.errs), msg.errs[0])
+		}
+		return m, m.show(m.records)
+
+	case pluginClosedMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("%s failed: %v", msg.label, msg.err)
+		}
+		return m, nil
+
+	case restoredMsg:
+		if msg.err != nil {
+			m.message = fmt.Sprintf("Restore failed: %v", msg.err)
+			return m, nil
+		}
+		delete(m.cold, msg.slug)
+		m.message = fmt.Sprintf("Restored %s (press r to reindex)", msg.slug)
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.menuOpen {
+			return m.updateMenu(msg)
+		}
+		if m.describing {
+			return m.updateDescribe(msg)
+		}
+		if m.confirmArchive {
+			m.confirmArchive = false
+			if msg.String() == "y" {
+				m.message = fmt.Sprintf("Archiving %d workspace(s)...", len(m.suggested))
+				return m, m.archiveSuggested()
+			}
+			m.message = ""
+			return m, nil
+		}
 		if m.list.FilterState() == list.Filtering {
 			break
 		}
@@ -118,6 +363,48 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Quit):
 			return m, tea.Quit
 
+		case key.Matches(msg, keys.Fold):
+			return m, m.toggleOwner()
+
+		case key.Matches(msg, keys.Plugins):
+			if m.selected != nil && !m.cold[m.selected.Slug] {
+				if len(m.pluginActions) == 0 {
+					m.message = "No plugin actions (see co plugins)"
+					return m, nil
+				}
+				m.menuOpen = true
+				m.menuIndex = 0
+				return m, nil
+			}
+
+		case key.Matches(msg, keys.Suggest):
+			if m.suggestOnly {
+				m.suggestOnly = false
+				m.suggested = nil
+				m.message = ""
+				return m, m.show(m.records)
+			}
+			m.message = "Looking for workspaces to archive..."
+			return m, m.findSuggestions()
+
+		case key.Matches(msg, keys.Batch):
+			if m.suggestOnly && len(m.suggested) > 0 {
+				m.confirmArchive = true
+				m.message = fmt.Sprintf("Move %d workspace(s) to cold storage? (y/n)", len(m.suggested))
+				return m, nil
+			}
+
+		case key.Matches(msg, keys.Restore):
+			if m.selected != nil && m.cold[m.selected.Slug] {
+				m.message = fmt.Sprintf("Restoring %s...", m.selected.Slug)
+				return m, m.restore(m.selected.Slug)
+			}
+
+		case m.selected != nil && m.cold[m.selected.Slug] &&
+			key.Matches(msg, keys.Shell, keys.Open, keys.Reveal, keys.Notes, keys.Edit, keys.Up):
+			m.message = fmt.Sprintf("%s is in cold storage; press R to restore it", m.selected.Slug)
+			return m, nil
+
 		case key.Matches(msg, keys.Shell):
 			if m.selected != nil {
 				return m, m.openShell()
@@ -128,6 +415,49 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.openWorkspace()
 			}
 
+		case key.Matches(msg, keys.Reveal):
+			if m.selected != nil {
+				if err := platform.Reveal(m.selected.Path); err != nil {
+					m.message = fmt.Sprintf("Reveal failed: %v", err)
+				}
+				return m, nil
+			}
+
+		case key.Matches(msg, keys.Notes):
+			if m.selected != nil {
+				path, created, err := notes.Stub(m.cfg, m.selected.Path)
+				if err != nil {
+					m.message = fmt.Sprintf("Notes: %v", err)
+					return m, nil
+				}
+				if created {
+					m.message = fmt.Sprintf("Created %s", path)
+				}
+				return m, tea.ExecProcess(notes.OpenCommand(m.cfg, path), func(err error) tea.Msg {
+					return notesClosedMsg{err: err}
+				})
+			}
+
+		case key.Matches(msg, keys.Edit):
+			if m.selected != nil {
+				m.describing = true
+				m.describeInput.SetValue(m.selected.Description)
+				m.describeInput.CursorEnd()
+				return m, m.describeInput.Focus()
+			}
+
+		case key.Matches(msg, keys.Up):
+			if m.selected != nil {
+				upCmd, err := template.UpCommand(m.selected.Path)
+				if err != nil {
+					m.message = fmt.Sprintf("Up: %v", err)
+					return m, nil
+				}
+				return m, tea.ExecProcess(upCmd, func(err error) tea.Msg {
+					return upClosedMsg{err: err}
+				})
+			}
+
 		case key.Matches(msg, keys.Reindex):
 			return m, m.reindex()
 		}
@@ -136,8 +466,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
 
-	if i, ok := m.list.SelectedItem().(workspaceItem); ok {
+	switch i := m.list.SelectedItem().(type) {
+	case workspaceItem:
 		m.selected = i.record
+	case ownerItem:
+		m.selected = nil
 	}
 
 	return m, cmd
@@ -149,10 +482,14 @@ func (m Model) View() string {
 	}
 
 	leftPane := activePaneStyle.Width(m.width/2 - 2).Height(m.height - 6).Render(m.list.View())
-	rightPane := paneStyle.Width(m.width/2 - 2).Height(m.height - 6).Render(m.detailsView())
+	details := m.detailsView()
+	if m.menuOpen {
+		details = m.menuView()
+	}
+	rightPane := paneStyle.Width(m.width/2 - 2).Height(m.height - 6).Render(details)
 
 	main := lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane)
-	help := helpStyle.Render("enter/c: shell • o: editor • a: archive • s: sync • r: reindex • /: search • q: quit")
+	help := helpStyle.Render("enter/c: shell • space: collapse owner • o: editor • f: reveal • n: notes • e: describe • u: up • a: archive • s: sync • r: reindex • R: restore • i: archive suggestions • p: plugins • /: search • q: quit")
 
 	if m.message != "" {
 		help = m.message
@@ -162,6 +499,9 @@ func (m Model) View() string {
 }
 
 func (m Model) detailsView() string {
+	if header, ok := m.list.SelectedItem().(ownerItem); ok {
+		return m.ownerView(header)
+	}
 	if m.selected == nil {
 		return "No workspace selected"
 	}
@@ -169,13 +509,28 @@ func (m Model) detailsView() string {
 	r := m.selected
 	var sb strings.Builder
 
-	sb.WriteString(titleStyle.Render(r.Slug) + "\n\n")
+	sb.WriteString(titleStyle.Render(r.Label()) + "\n\n")
+	if m.describing {
+		sb.WriteString("Description:\n" + m.describeInput.View() + "\n")
+		sb.WriteString(helpStyle.Render("enter: save (empty: remove) • esc: cancel") + "\n\n")
+	} else if r.Description != "" {
+		sb.WriteString(r.Description + "\n\n")
+	}
+	if r.DisplayName != "" {
+		sb.WriteString(fmt.Sprintf("Slug:   %s\n", r.Slug))
+	}
 	sb.WriteString(fmt.Sprintf("Owner:  %s\n", r.Owner))
+	if r.CreatedBy != "" {
+		sb.WriteString(fmt.Sprintf("By:     %s\n", r.CreatedBy))
+	}
 	sb.WriteString(fmt.Sprintf("State:  %s\n", r.State))
 	sb.WriteString(fmt.Sprintf("Path:   %s\n", r.Path))
 	sb.WriteString(fmt.Sprintf("Repos:  %d\n", r.RepoCount))
 	sb.WriteString(fmt.Sprintf("Dirty:  %d\n", r.DirtyRepos))
 	sb.WriteString(fmt.Sprintf("Size:   %s\n", formatBytes(r.SizeBytes)))
+	if m.cold[r.Slug] {
+		sb.WriteString("\nIn cold storage. Press R to restore.\n")
+	}
 
 	if len(r.Tags) > 0 {
 		sb.WriteString(fmt.Sprintf("Tags:   %v\n", r.Tags))
@@ -196,16 +551,60 @@ func (m Model) detailsView() string {
 		}
 	}
 
+	if len(r.Violations) > 0 {
+		sb.WriteString(fmt.Sprintf("\nPolicy violations (%d):\n", len(r.Violations)))
+		for _, v := range r.Violations {
+			sb.WriteString(fmt.Sprintf("  ✗ %s\n", v))
+		}
+	}
+
 	return sb.String()
 }
 
-func (m Model) openShell() tea.Cmd {
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
+// ownerView describes the owner section under the cursor.
+func (m Model) ownerView(header ownerItem) string {
+	g := header.group
+	var sb strings.Builder
+	sb.WriteString(OwnerStyle(g.Owner).Render(g.Owner) + "\n\n")
+	sb.WriteString(g.Summary() + "\n\n")
+	for _, r := range g.Workspaces {
+		sb.WriteString(fmt.Sprintf("  • %s (%s)\n", r.Label(), r.State))
 	}
-	cmd := exec.Command(shell)
-	cmd.Dir = m.selected.Path
+	action := "collapse"
+	if header.collapsed {
+		action = "expand"
+	}
+	sb.WriteString("\n" + helpStyle.Render("space: "+action))
+	return sb.String()
+}
+
+// updateDescribe handles keys while the description edit box is open: enter
+// saves the description to the workspace's project.json, esc cancels.
+func (m Model) updateDescribe(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.describing = false
+		m.describeInput.Blur()
+		return m, nil
+	case "enter":
+		m.describing = false
+		m.describeInput.Blur()
+		description := strings.TrimSpace(m.describeInput.Value())
+		if err := workspace.SetDescription(m.cfg, m.selected.Slug, description); err != nil {
+			m.message = fmt.Sprintf("Describe failed: %v", err)
+			return m, nil
+		}
+		m.selected.Description = description
+		m.message = ""
+		return m, m.list.SetItems(m.list.Items())
+	}
+	var cmd tea.Cmd
+	m.describeInput, cmd = m.describeInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) openShell() tea.Cmd {
+	cmd := platform.ShellCommand(m.selected.Path)
 	return tea.ExecProcess(cmd, func(err error) tea.Msg {
 		return nil
 	})
@@ -223,6 +622,134 @@ func (m Model) openWorkspace() tea.Cmd {
 	}
 }
 
+// notesClosedMsg reports that the notes opener exited.
+type notesClosedMsg struct {
+	err error
+}
+
+// upClosedMsg reports that the dev container or nix shell exited.
+type upClosedMsg struct {
+	err error
+}
+
+// restoredMsg reports that a workspace was restored from cold storage.
+type restoredMsg struct {
+	slug string
+	err  error
+}
+
+// pluginClosedMsg reports that a plugin action exited.
+type pluginClosedMsg struct {
+	label string
+	err   error
+}
+
+// updateMenu handles keys while the plugin menu is open: arrows and enter
+// pick an action, an action's own key runs it, and esc closes the menu.
+func (m Model) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "p":
+		m.menuOpen = false
+		return m, nil
+	case "up", "k":
+		if m.menuIndex > 0 {
+			m.menuIndex--
+		}
+		return m, nil
+	case "down", "j":
+		if m.menuIndex < len(m.pluginActions)-1 {
+			m.menuIndex++
+		}
+		return m, nil
+	case "enter":
+		return m.runPluginAction(m.pluginActions[m.menuIndex])
+	}
+	for _, pa := range m.pluginActions {
+		if pa.action.Key != "" && pa.action.Key == msg.String() {
+			return m.runPluginAction(pa)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) runPluginAction(pa pluginAction) (tea.Model, tea.Cmd) {
+	m.menuOpen = false
+	label := pa.action.Label
+	return m, tea.ExecProcess(pa.plugin.ActionCommand(m.cfg, pa.action, m.selected), func(err error) tea.Msg {
+		return pluginClosedMsg{label: label, err: err}
+	})
+}
+
+func (m Model) menuView() string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Plugin actions: "+m.selected.Slug) + "\n\n")
+	for i, pa := range m.pluginActions {
+		line := pa.action.Label
+		if pa.action.Key != "" {
+			line = fmt.Sprintf("[%s] %s", pa.action.Key, line)
+		}
+		line += helpStyle.Render(" (" + pa.plugin.Name + ")")
+		if i == m.menuIndex {
+			sb.WriteString(selectedItemStyle.Render("> "+line) + "\n")
+		} else {
+			sb.WriteString(itemStyle.Render("  "+line) + "\n")
+		}
+	}
+	sb.WriteString("\n" + helpStyle.Render("enter: run • esc: close"))
+	return sb.String()
+}
+
+// suggestionsMsg carries the workspaces suggested for archiving.
+type suggestionsMsg struct {
+	slugs []string
+	days  int
+}
+
+// batchArchivedMsg reports the workspaces moved to cold storage, mapped to
+// their archive paths.
+type batchArchivedMsg struct {
+	archived map[string]string
+	errs     []error
+}
+
+func (m Model) findSuggestions() tea.Cmd {
+	records := m.records
+	days := m.cfg.GetArchiveConfig().SuggestDays
+	return func() tea.Msg {
+		var slugs []string
+		for _, s := range archive.Suggest(records, days, time.Now()) {
+			slugs = append(slugs, s.Slug)
+		}
+		return suggestionsMsg{slugs: slugs, days: days}
+	}
+}
+
+func (m Model) archiveSuggested() tea.Cmd {
+	cfg := m.cfg
+	slugs := m.suggested
+	days := cfg.GetArchiveConfig().SuggestDays
+	return func() tea.Msg {
+		msg := batchArchivedMsg{archived: map[string]string{}}
+		for _, slug := range slugs {
+			result, err := archive.ColdStore(cfg, slug, archive.ColdOptions{Reason: fmt.Sprintf("inactive for %d+ days", days)})
+			if err != nil {
+				msg.errs = append(msg.errs, fmt.Errorf("%s: %w", slug, err))
+				continue
+			}
+			msg.archived[slug] = result.ArchivePath
+		}
+		return msg
+	}
+}
+
+func (m Model) restore(slug string) tea.Cmd {
+	cfg := m.cfg
+	return func() tea.Msg {
+		_, err := archive.RestoreCold(cfg, slug)
+		return restoredMsg{slug: slug, err: err}
+	}
+}
+
 func (m Model) reindex() tea.Cmd {
 	return tea.ExecProcess(exec.Command(os.Args[0], "index"), nil)
 }
@@ -246,9 +773,42 @@ func Run(cfg *config.Config) error {
 		return fmt.Errorf("failed to load index (run 'co index' first): %w", err)
 	}
 
-	m := New(cfg, idx.Records)
+	cold, err := archive.ListCold(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to list cold storage: %w", err)
+	}
+
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return err
+	} else if lines {
+		coldSlugs := map[string]bool{}
+		for _, c := range cold {
+			coldSlugs[c.Slug] = true
+		}
+		return runPlainDashboard(cfg, archive.WithCold(idx.Records, cold), coldSlugs)
+	}
+
+	m := New(cfg, archive.WithCold(idx.Records, cold))
+	for _, c := range cold {
+		m.cold[c.Slug] = true
+	}
+	for _, p := range plugin.Discover(os.Getenv("PATH")) {
+		for _, a := range p.Manifest.Actions {
+			m.pluginActions = append(m.pluginActions, pluginAction{plugin: p, action: a})
+		}
+	}
+	m = m.applySession(loadSession(cfg).Dashboard)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
-	_, err = p.Run()
-	return err
+	finalModel, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	if final, ok := finalModel.(Model); ok {
+		updateSession(cfg, func(s *tuiSession) {
+			final.recordSession(&s.Dashboard)
+		})
+	}
+	return nil
 }
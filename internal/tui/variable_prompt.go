@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -78,22 +79,14 @@ func newVariablePromptModel(vars []template.TemplateVar, builtins map[string]str
 }
 
 func (m *variablePromptModel) setupCurrentVar() {
+	m.currentIndex = skipInapplicableVars(m.variables, m.currentIndex, m.values)
 	if m.currentIndex >= len(m.variables) {
 		m.done = true
 		return
 	}
 
 	v := m.variables[m.currentIndex]
-
-	// Get default value
-	defaultVal := ""
-	if v.Default != nil {
-		defaultVal = fmt.Sprintf("%v", v.Default)
-		// Substitute any variable references in default
-		if substituted, err := template.SubstituteVariables(defaultVal, m.values); err == nil {
-			defaultVal = substituted
-		}
-	}
+	defaultVal := varDefault(v, m.values)
 
 	switch v.Type {
 	case template.VarTypeBoolean:
@@ -130,6 +123,45 @@ func (m *variablePromptModel) setupCurrentVar() {
 	}
 }
 
+// varDefault returns the default value of v, with references to the values
+// given so far substituted.
+func varDefault(v template.TemplateVar, values map[string]string) string {
+	if v.Default == nil {
+		return ""
+	}
+	defaultVal := fmt.Sprintf("%v", v.Default)
+	if substituted, err := template.SubstituteVariables(defaultVal, values); err == nil {
+		defaultVal = substituted
+	}
+	return defaultVal
+}
+
+// skipInapplicableVars returns the index of the first of vars from i on that
+// applies given values (see template.VarApplies), emptying the values of the
+// variables skipped.
+func skipInapplicableVars(vars []template.TemplateVar, i int, values map[string]string) int {
+	for ; i < len(vars) && !template.VarApplies(vars[i], values); i++ {
+		values[vars[i].Name] = ""
+	}
+	return i
+}
+
+// checkVarInput validates a value typed for a string or integer variable.
+func checkVarInput(v template.TemplateVar, value string) error {
+	if value == "" {
+		if v.Required {
+			return fmt.Errorf("%s is required", v.Name)
+		}
+		return nil
+	}
+	if v.Type == template.VarTypeInteger {
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("must be a valid integer")
+		}
+	}
+	return template.ValidateVarValue(v, value)
+}
+
 func (m variablePromptModel) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -201,26 +233,11 @@ func (m variablePromptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				value := strings.TrimSpace(m.textInput.Value())
 
-				// Validate
-				if v.Required && value == "" {
-					m.err = fmt.Sprintf("%s is required", v.Name)
+				if err := checkVarInput(v, value); err != nil {
+					m.err = err.Error()
 					return m, nil
 				}
 
-				if v.Type == template.VarTypeInteger && value != "" {
-					if _, err := strconv.Atoi(value); err != nil {
-						m.err = "must be a valid integer"
-						return m, nil
-					}
-				}
-
-				if value != "" {
-					if err := template.ValidateVarValue(v, value); err != nil {
-						m.err = err.Error()
-						return m, nil
-					}
-				}
-
 				m.values[v.Name] = value
 				m.err = ""
 				m.textInput.SetValue("")
@@ -314,11 +331,20 @@ func RunVariablePrompt(vars []template.TemplateVar, builtins map[string]string)
 		}
 		promptVars = append(promptVars, v)
 	}
+	promptVars, err := template.OrderVariables(promptVars)
+	if err != nil {
+		return VariablePromptResult{Abort: true}, err
+	}
 
 	if len(promptVars) == 0 {
 		// No variables to prompt for
 		return VariablePromptResult{Variables: builtins}, nil
 	}
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return VariablePromptResult{Abort: true}, err
+	} else if lines {
+		return runPlainVariablePrompt(promptVars, builtins), nil
+	}
 
 	m := newVariablePromptModel(promptVars, builtins)
 	p := tea.NewProgram(m)
@@ -345,10 +371,19 @@ func RunVariablePromptWithSkip(vars []template.TemplateVar, seed map[string]stri
 		}
 		promptVars = append(promptVars, v)
 	}
+	promptVars, err := template.OrderVariables(promptVars)
+	if err != nil {
+		return VariablePromptResult{Abort: true}, err
+	}
 
 	if len(promptVars) == 0 {
 		return VariablePromptResult{Variables: seed}, nil
 	}
+	if lines, err := linePrompts(os.Stdout); err != nil {
+		return VariablePromptResult{Abort: true}, err
+	} else if lines {
+		return runPlainVariablePrompt(promptVars, seed), nil
+	}
 
 	m := newVariablePromptModel(promptVars, seed)
 	p := tea.NewProgram(m)
@@ -0,0 +1,189 @@
+// Package usage keeps local usage statistics: how often each co command runs,
+// how often it fails, and how long it takes. They are recorded only when
+// usage_stats is set in the config, stored in the state dir, and never sent
+// anywhere. Arguments are not recorded, only command names.
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+)
+
+// fileName is the file under the state dir that holds usage statistics.
+const fileName = "usage.json"
+
+// keepMonths is how many months of per-month totals are kept per command.
+const keepMonths = 12
+
+// Stats are the usage statistics of all commands.
+type Stats struct {
+	Since    time.Time           `json:"since"`
+	Commands map[string]*Command `json:"commands"`
+}
+
+// Command are the statistics of one command.
+type Command struct {
+	Count    int       `json:"count"`
+	Failures int       `json:"failures"`
+	TotalMs  int64     `json:"total_ms"`
+	MaxMs    int64     `json:"max_ms"`
+	LastUsed time.Time `json:"last_used"`
+
+	// Months holds runs and total duration per month ("2006-01"), so
+	// durations can be followed over time
+	Months map[string]*Period `json:"months,omitempty"`
+}
+
+// Period is the runs and total duration of a command in one month.
+type Period struct {
+	Count   int   `json:"count"`
+	TotalMs int64 `json:"total_ms"`
+}
+
+// NamedCommand is a command's statistics with its name, for listings.
+type NamedCommand struct {
+	Name string `json:"name"`
+	*Command
+}
+
+// Path returns the path of the usage statistics file.
+func Path(cfg *config.Config) string {
+	return filepath.Join(cfg.StateDir(), fileName)
+}
+
+// Load reads the usage statistics. A missing file yields empty statistics.
+func Load(cfg *config.Config) (*Stats, error) {
+	s := &Stats{Commands: map[string]*Command{}}
+	data, err := os.ReadFile(Path(cfg))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", Path(cfg), err)
+	}
+	if s.Commands == nil {
+		s.Commands = map[string]*Command{}
+	}
+	return s, nil
+}
+
+// Save writes the usage statistics, replacing the previous file atomically.
+func Save(cfg *config.Config, s *Stats) error {
+	path := Path(cfg)
+	if err := os.MkdirAll(filepath.Dir(path), fs.DirPerm()); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Per process, so concurrent runs do not write the same temp file
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Record adds a run of the command name that took d and finished at the
+// given time to the statistics file. Runs of concurrent co processes may
+// overwrite each other's record; the statistics are a rough guide.
+func Record(cfg *config.Config, name string, d time.Duration, failed bool, at time.Time) error {
+	s, err := Load(cfg)
+	if err != nil {
+		return err
+	}
+	s.Add(name, d, failed, at)
+	return Save(cfg, s)
+}
+
+// Add adds a run of the command name that took d and finished at the given
+// time, and drops per-month totals older than keepMonths.
+func (s *Stats) Add(name string, d time.Duration, failed bool, at time.Time) {
+	if s.Since.IsZero() {
+		s.Since = at
+	}
+	c := s.Commands[name]
+	if c == nil {
+		c = &Command{}
+		s.Commands[name] = c
+	}
+	ms := d.Milliseconds()
+	c.Count++
+	if failed {
+		c.Failures++
+	}
+	c.TotalMs += ms
+	c.MaxMs = max(c.MaxMs, ms)
+	c.LastUsed = at
+
+	if c.Months == nil {
+		c.Months = map[string]*Period{}
+	}
+	month := at.Format("2006-01")
+	p := c.Months[month]
+	if p == nil {
+		p = &Period{}
+		c.Months[month] = p
+	}
+	p.Count++
+	p.TotalMs += ms
+
+	// From the first of the month, so AddDate does not skip short months
+	first := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, at.Location())
+	oldest := first.AddDate(0, -(keepMonths - 1), 0).Format("2006-01")
+	for m := range c.Months {
+		if m < oldest {
+			delete(c.Months, m)
+		}
+	}
+}
+
+// Sorted returns the commands, most used first.
+func (s *Stats) Sorted() []NamedCommand {
+	list := make([]NamedCommand, 0, len(s.Commands))
+	for name, c := range s.Commands {
+		list = append(list, NamedCommand{Name: name, Command: c})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Average returns the mean duration of the command's runs.
+func (c *Command) Average() time.Duration {
+	if c.Count == 0 {
+		return 0
+	}
+	return time.Duration(c.TotalMs/int64(c.Count)) * time.Millisecond
+}
+
+// MonthlyAverages returns the mean duration in milliseconds of the command's
+// runs in each of the last n months up to now, oldest first; months without
+// runs are 0.
+func (c *Command) MonthlyAverages(now time.Time, n int) []int {
+	avgs := make([]int, n)
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	for i := range avgs {
+		month := first.AddDate(0, i-(n-1), 0).Format("2006-01")
+		if p := c.Months[month]; p != nil && p.Count > 0 {
+			avgs[i] = int(p.TotalMs / int64(p.Count))
+		}
+	}
+	return avgs
+}
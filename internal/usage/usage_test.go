@@ -0,0 +1,61 @@
+package usage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+)
+
+func TestRecord(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := config.DefaultConfig()
+
+	jan := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	runs := []struct {
+		name   string
+		d      time.Duration
+		failed bool
+		at     time.Time
+	}{
+		{"import", 4 * time.Second, false, jan},
+		{"import", 2 * time.Second, true, mar},
+		{"import", 6 * time.Second, false, mar},
+		{"ls", 100 * time.Millisecond, false, mar},
+	}
+	for _, r := range runs {
+		if err := Record(cfg, r.name, r.d, r.failed, r.at); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	s, err := Load(cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !s.Since.Equal(jan) {
+		t.Errorf("Since = %v, want %v", s.Since, jan)
+	}
+	sorted := s.Sorted()
+	if len(sorted) != 2 || sorted[0].Name != "import" || sorted[1].Name != "ls" {
+		t.Fatalf("Sorted() = %+v, want import then ls", sorted)
+	}
+	imp := sorted[0]
+	if imp.Count != 3 || imp.Failures != 1 || imp.MaxMs != 6000 || !imp.LastUsed.Equal(mar) {
+		t.Errorf("import = %+v", imp.Command)
+	}
+	if got := imp.Average(); got != 4*time.Second {
+		t.Errorf("Average() = %v, want 4s", got)
+	}
+	if got, want := imp.MonthlyAverages(mar, 3), []int{4000, 0, 4000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MonthlyAverages() = %v, want %v", got, want)
+	}
+
+	// Months beyond the kept window are dropped
+	s.Add("import", time.Second, false, jan.AddDate(1, 0, 0))
+	if _, ok := s.Commands["import"].Months["2026-01"]; ok {
+		t.Error("Add() kept a month older than a year")
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/tormodhaugland/co/internal/fs"
 )
 
 // DB wraps the SQLite database with vector search capabilities
@@ -27,7 +28,7 @@ func init() {
 func Open(dbPath string) (*DB, error) {
 	// Ensure parent directory exists
 	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, fs.DirPerm()); err != nil {
 		return nil, fmt.Errorf("creating database directory: %w", err)
 	}
 
@@ -0,0 +1,218 @@
+// Package vscode generates the editor-side configuration for the companion
+// VS Code extension and describes workspaces in a form the extension can open.
+package vscode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/jsonobj"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// Folder is a folder of a multi-root VS Code workspace.
+type Folder struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// OpenSpec describes how the extension should open a workspace.
+type OpenSpec struct {
+	Slug    string   `json:"slug"`
+	Path    string   `json:"path"`
+	URI     string   `json:"uri"`     // vscode:// URI opening the workspace folder
+	Folders []Folder `json:"folders"` // Workspace root followed by each repo
+}
+
+// Open returns the open spec for a workspace.
+func Open(cfg *config.Config, slug string) (*OpenSpec, error) {
+	if !workspace.Exists(cfg, slug) {
+		return nil, fmt.Errorf("%w: %s", workspace.ErrWorkspaceNotFound, slug)
+	}
+	path := cfg.WorkspacePath(slug)
+
+	repos, err := model.LocateWorkspaceRepos(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repos: %w", err)
+	}
+
+	spec := &OpenSpec{
+		Slug:    slug,
+		Path:    path,
+		URI:     (&url.URL{Scheme: "vscode", Host: "file", Path: filepath.ToSlash(path)}).String(),
+		Folders: []Folder{{Name: slug, Path: path}},
+	}
+	for _, repo := range repos {
+		spec.Folders = append(spec.Folders, Folder{Name: repo.Name, Path: filepath.Join(path, filepath.FromSlash(repo.Path))})
+	}
+	return spec, nil
+}
+
+// UserDir returns the VS Code user settings directory for the current platform.
+func UserDir(insiders bool) string {
+	return userDir(runtime.GOOS, insiders)
+}
+
+func userDir(goos string, insiders bool) string {
+	product := "Code"
+	if insiders {
+		product = "Code - Insiders"
+	}
+	home, _ := os.UserHomeDir()
+	switch goos {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", product, "User")
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			appData = filepath.Join(home, "AppData", "Roaming")
+		}
+		return filepath.Join(appData, product, "User")
+	default:
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configHome, product, "User")
+	}
+}
+
+// Settings returns the user settings the extension reads to reach co.
+func Settings(executable, serverURL, infoFile string) map[string]any {
+	return map[string]any{
+		"co.executable":      executable,
+		"co.server.url":      serverURL,
+		"co.server.infoFile": infoFile,
+	}
+}
+
+// taskLabelPrefix marks tasks owned by co so reinstalling replaces them.
+const taskLabelPrefix = "co: "
+
+// Tasks returns user tasks that run co commands from the command palette.
+func Tasks(executable string) []map[string]any {
+	task := func(label string, args ...string) map[string]any {
+		return map[string]any{
+			"label":          taskLabelPrefix + label,
+			"type":           "process",
+			"command":        executable,
+			"args":           args,
+			"problemMatcher": []string{},
+		}
+	}
+	return []map[string]any{
+		task("serve", "serve"),
+		task("index", "index"),
+		task("import current folder", "import-tui", "${workspaceFolder}"),
+		task("dashboard", "tui"),
+	}
+}
+
+// InstallResult reports which files Install wrote.
+type InstallResult struct {
+	SettingsPath string `json:"settings_path"`
+	TasksPath    string `json:"tasks_path"`
+}
+
+// Install merges settings into settings.json and tasks into tasks.json under
+// userDir. Existing keys and tasks are preserved, except co's own entries,
+// which are replaced.
+func Install(userDir string, settings map[string]any, tasks []map[string]any) (*InstallResult, error) {
+	result := &InstallResult{
+		SettingsPath: filepath.Join(userDir, "settings.json"),
+		TasksPath:    filepath.Join(userDir, "tasks.json"),
+	}
+
+	obj, err := readObject(result.SettingsPath)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := obj.Set(key, settings[key]); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeObject(result.SettingsPath, obj); err != nil {
+		return nil, err
+	}
+
+	tasksObj, err := readObject(result.TasksPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tasksObj.Get("version"); !ok {
+		tasksObj.Set("version", "2.0.0")
+	}
+	var existing []map[string]any
+	if raw, ok := tasksObj.Get("tasks"); ok {
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return nil, fmt.Errorf("%s: tasks is not a list: %w", result.TasksPath, err)
+		}
+	}
+	merged := make([]map[string]any, 0, len(existing)+len(tasks))
+	for _, t := range existing {
+		if label, _ := t["label"].(string); strings.HasPrefix(label, taskLabelPrefix) {
+			continue
+		}
+		merged = append(merged, t)
+	}
+	merged = append(merged, tasks...)
+	if err := tasksObj.Set("tasks", merged); err != nil {
+		return nil, err
+	}
+	if err := writeObject(result.TasksPath, tasksObj); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// readObject reads a JSON object from path, keeping its key order so merging
+// into a user's settings file does not reshuffle it. A missing or empty file
+// is an empty object. Files with comments (JSONC) are rejected rather than
+// rewritten.
+func readObject(path string) (*jsonobj.Object, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return jsonobj.New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return jsonobj.New(), nil
+	}
+
+	obj, err := jsonobj.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a plain JSON object (comments are not supported); use --print and merge by hand", path)
+	}
+	return obj, nil
+}
+
+// writeObject writes obj to path, indented as VS Code does.
+func writeObject(path string, obj *jsonobj.Object) error {
+	data, err := obj.Marshal("    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), fs.DirPerm()); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, fs.FilePerm())
+}
@@ -0,0 +1,126 @@
+package vscode
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+)
+
+func TestOpen(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	ws := filepath.Join(cfg.CodeRoot, "acme--api")
+	for _, d := range []string{"repos/server", "repos/web"} {
+		if err := os.MkdirAll(filepath.Join(ws, d), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(ws, "project.json"), []byte(`{"schema":1}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	spec, err := Open(cfg, "acme--api")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if spec.Path != ws || spec.URI != "vscode://file"+filepath.ToSlash(ws) {
+		t.Errorf("spec = %+v, want path %s", spec, ws)
+	}
+	if len(spec.Folders) != 3 || spec.Folders[0].Path != ws || spec.Folders[1].Name != "server" {
+		t.Errorf("Folders = %+v, want root then server and web", spec.Folders)
+	}
+
+	if _, err := Open(cfg, "acme--missing"); err == nil {
+		t.Error("Open of a missing workspace should fail")
+	}
+}
+
+func TestUserDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	if got := userDir("linux", false); got != filepath.Join("/tmp/xdg", "Code", "User") {
+		t.Errorf("userDir(linux) = %q", got)
+	}
+	if got := userDir("linux", true); got != filepath.Join("/tmp/xdg", "Code - Insiders", "User") {
+		t.Errorf("userDir(linux, insiders) = %q", got)
+	}
+	if got := userDir("darwin", false); !strings.HasSuffix(got, filepath.Join("Library", "Application Support", "Code", "User")) {
+		t.Errorf("userDir(darwin) = %q", got)
+	}
+}
+
+func TestInstallMergesAndPreservesOrder(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "settings.json")
+	existing := `{"zeta.setting": 1, "editor.fontSize": 14, "co.server.url": "old"}`
+	if err := os.WriteFile(settingsPath, []byte(existing), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	tasksPath := filepath.Join(dir, "tasks.json")
+	existingTasks := `{"version": "2.0.0", "tasks": [{"label": "build"}, {"label": "co: stale"}]}`
+	if err := os.WriteFile(tasksPath, []byte(existingTasks), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	settings := Settings("/usr/bin/co", "http://127.0.0.1:7717/v1", "/state/serve.json")
+	if _, err := Install(dir, settings, Tasks("/usr/bin/co")); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	data, _ := os.ReadFile(settingsPath)
+	out := string(data)
+	if strings.Index(out, "zeta.setting") > strings.Index(out, "editor.fontSize") {
+		t.Errorf("existing key order not preserved:\n%s", out)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("settings.json invalid: %v", err)
+	}
+	if got["co.server.url"] != "http://127.0.0.1:7717/v1" || got["co.executable"] != "/usr/bin/co" || got["editor.fontSize"] != float64(14) {
+		t.Errorf("settings = %v", got)
+	}
+
+	data, _ = os.ReadFile(tasksPath)
+	var tasks struct {
+		Version string           `json:"version"`
+		Tasks   []map[string]any `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		t.Fatalf("tasks.json invalid: %v", err)
+	}
+	var labels []string
+	for _, task := range tasks.Tasks {
+		labels = append(labels, task["label"].(string))
+	}
+	if labels[0] != "build" || strings.Contains(strings.Join(labels, ","), "co: stale") || len(labels) != 1+len(Tasks("")) {
+		t.Errorf("task labels = %v, want build followed by co tasks only", labels)
+	}
+
+	// Reinstalling does not duplicate co tasks
+	if _, err := Install(dir, settings, Tasks("/usr/bin/co")); err != nil {
+		t.Fatalf("Install again: %v", err)
+	}
+	data, _ = os.ReadFile(tasksPath)
+	json.Unmarshal(data, &tasks)
+	if len(tasks.Tasks) != 1+len(Tasks("")) {
+		t.Errorf("reinstall produced %d tasks, want %d", len(tasks.Tasks), 1+len(Tasks("")))
+	}
+}
+
+func TestInstallRejectsComments(t *testing.T) {
+	dir := t.TempDir()
+	original := "{\n  // font\n  \"editor.fontSize\": 14\n}\n"
+	settingsPath := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(settingsPath, []byte(original), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if _, err := Install(dir, Settings("co", "url", "info"), nil); err == nil {
+		t.Fatal("Install should refuse a settings file with comments")
+	}
+	if data, _ := os.ReadFile(settingsPath); string(data) != original {
+		t.Errorf("settings file was modified:\n%s", data)
+	}
+}
@@ -0,0 +1,473 @@
+package workspace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// Sources of repo dependencies.
+const (
+	DepDeclared    = "project.json" // depends_on of the repo in project.json
+	DepGoMod       = "go.mod"       // require of, or replace pointing at, another repo's module
+	DepPackageJSON = "package.json" // dependency on, or workspaces entry matching, another repo's package
+)
+
+// RepoDep is a dependency of one repo of a workspace on another.
+type RepoDep struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Source string `json:"source"`
+}
+
+// DepGraph is the dependency graph between the repos of a workspace.
+type DepGraph struct {
+	Repos    []string          `json:"repos"` // Sorted by name
+	Paths    map[string]string `json:"paths"` // Directory of each repo
+	Deps     []RepoDep         `json:"deps"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// CycleError reports repos that depend on each other in a cycle, so they
+// have no order.
+type CycleError struct {
+	Repos []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle between repos: %s", strings.Join(e.Repos, ", "))
+}
+
+// repoManifests is what BuildDepGraph reads from the manifests of a repo.
+type repoManifests struct {
+	dir        string
+	module     string            // go.mod module path
+	requires   []string          // go.mod required module paths
+	replaces   []string          // go.mod local replacement directories, absolute
+	pkgName    string            // package.json name
+	pkgDeps    map[string]string // package.json dependencies of every kind
+	workspaces []string          // package.json workspaces patterns, absolute
+}
+
+// BuildDepGraph builds the dependency graph between the repos of the
+// workspace at workspacePath: those in proj and any others under repos/.
+// Dependencies come from depends_on in project.json and are detected from
+// go.mod and package.json. A declared dependency on an unknown repo is
+// reported in Warnings.
+func BuildDepGraph(workspacePath string, proj *model.Project) (*DepGraph, error) {
+	dirs, err := repoDirs(workspacePath, proj)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &DepGraph{Repos: make([]string, 0, len(dirs)), Paths: dirs}
+	for name := range dirs {
+		g.Repos = append(g.Repos, name)
+	}
+	sort.Strings(g.Repos)
+
+	seen := make(map[[2]string]bool)
+	add := func(from, to, source string) {
+		if from == to || seen[[2]string{from, to}] {
+			return
+		}
+		seen[[2]string{from, to}] = true
+		g.Deps = append(g.Deps, RepoDep{From: from, To: to, Source: source})
+	}
+
+	for _, r := range proj.Repos {
+		for _, dep := range r.DependsOn {
+			if _, ok := dirs[dep]; !ok {
+				g.Warnings = append(g.Warnings, fmt.Sprintf("%s depends on unknown repo %s", r.Name, dep))
+				continue
+			}
+			add(r.Name, dep, DepDeclared)
+		}
+	}
+
+	manifests := make(map[string]*repoManifests, len(dirs))
+	for _, name := range g.Repos {
+		manifests[name] = readManifests(dirs[name])
+	}
+	for _, from := range g.Repos {
+		m := manifests[from]
+		for _, to := range g.Repos {
+			if from == to {
+				continue
+			}
+			other := manifests[to]
+			if m.dependsOnGo(other) {
+				add(from, to, DepGoMod)
+			}
+			if m.dependsOnPackage(other) {
+				add(from, to, DepPackageJSON)
+			}
+		}
+	}
+
+	sort.SliceStable(g.Deps, func(i, j int) bool {
+		if g.Deps[i].From != g.Deps[j].From {
+			return g.Deps[i].From < g.Deps[j].From
+		}
+		return g.Deps[i].To < g.Deps[j].To
+	})
+	return g, nil
+}
+
+// repoDirs maps the repos of a workspace, those in proj and any others found
+// under its layout, to their directories.
+func repoDirs(workspacePath string, proj *model.Project) (map[string]string, error) {
+	dirs := make(map[string]string)
+	for _, r := range proj.Repos {
+		dirs[r.Name] = filepath.Join(workspacePath, r.Path)
+	}
+	onDisk, err := proj.LocateRepos(workspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repos: %w", err)
+	}
+	for _, repo := range onDisk {
+		if _, ok := dirs[repo.Name]; !ok {
+			dirs[repo.Name] = filepath.Join(workspacePath, filepath.FromSlash(repo.Path))
+		}
+	}
+	return dirs, nil
+}
+
+// DependenciesOf returns the repos repo depends on directly.
+func (g *DepGraph) DependenciesOf(repo string) []RepoDep {
+	var deps []RepoDep
+	for _, d := range g.Deps {
+		if d.From == repo {
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+// Order returns the repos so that each comes after the repos it depends on,
+// by name where the graph leaves a choice. It returns a *CycleError when
+// some repos depend on each other.
+func (g *DepGraph) Order() ([]string, error) {
+	pending := make(map[string]int, len(g.Repos)) // Unordered dependencies per repo
+	dependents := make(map[string][]string)
+	for _, r := range g.Repos {
+		pending[r] = 0
+	}
+	for _, d := range g.Deps {
+		pending[d.From]++
+		dependents[d.To] = append(dependents[d.To], d.From)
+	}
+
+	var ready, order []string
+	for _, r := range g.Repos {
+		if pending[r] == 0 {
+			ready = append(ready, r)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		r := ready[0]
+		ready = ready[1:]
+		order = append(order, r)
+		for _, dep := range dependents[r] {
+			if pending[dep]--; pending[dep] == 0 {
+				ready = append(ready, dep)
+			}
+		}
+	}
+
+	if len(order) < len(g.Repos) {
+		var cycle []string
+		for _, r := range g.Repos {
+			if pending[r] > 0 {
+				cycle = append(cycle, r)
+			}
+		}
+		return nil, &CycleError{Repos: cycle}
+	}
+	return order, nil
+}
+
+// DOT renders the graph in Graphviz DOT, with an edge from each repo to
+// each repo it depends on.
+func (g *DepGraph) DOT(name string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %q {\n", name)
+	sb.WriteString("  rankdir=LR;\n")
+	for _, r := range g.Repos {
+		fmt.Fprintf(&sb, "  %q;\n", r)
+	}
+	for _, d := range g.Deps {
+		fmt.Fprintf(&sb, "  %q -> %q [label=%q];\n", d.From, d.To, d.Source)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ASCII renders the graph as text: each repo, in dependency order when
+// there is one, followed by the repos it depends on.
+func (g *DepGraph) ASCII() string {
+	repos, err := g.Order()
+	if err != nil {
+		repos = g.Repos
+	}
+	var sb strings.Builder
+	for _, r := range repos {
+		sb.WriteString(r + "\n")
+		deps := g.DependenciesOf(r)
+		for i, d := range deps {
+			branch := "├─"
+			if i == len(deps)-1 {
+				branch = "└─"
+			}
+			fmt.Fprintf(&sb, "  %s %s (%s)\n", branch, d.To, d.Source)
+		}
+	}
+	return sb.String()
+}
+
+func readManifests(dir string) *repoManifests {
+	m := &repoManifests{dir: dir}
+	readGoMod(m, filepath.Join(dir, "go.mod"))
+	readPackageJSON(m, filepath.Join(dir, "package.json"))
+	return m
+}
+
+// readGoMod reads the module path, requirements, and local replacements of
+// a go.mod. It reads lines rather than parsing the file fully, which is
+// enough for the directives that matter here.
+func readGoMod(m *repoManifests, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	block := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "":
+			continue
+		case line == ")":
+			block = ""
+			continue
+		case strings.HasSuffix(line, "("):
+			block = strings.TrimSpace(strings.TrimSuffix(line, "("))
+			continue
+		}
+
+		directive, rest := block, line
+		if block == "" {
+			directive, rest, _ = strings.Cut(line, " ")
+			rest = strings.TrimSpace(rest)
+		}
+		switch directive {
+		case "module":
+			m.module = strings.Trim(rest, `"`)
+		case "require":
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				m.requires = append(m.requires, fields[0])
+			}
+		case "replace":
+			if _, target, ok := strings.Cut(rest, "=>"); ok {
+				if fields := strings.Fields(target); len(fields) > 0 && isLocalPath(fields[0]) {
+					m.replaces = append(m.replaces, resolveFrom(m.dir, fields[0]))
+				}
+			}
+		}
+	}
+}
+
+// readPackageJSON reads the name, dependencies, and workspaces of a
+// package.json.
+func readPackageJSON(m *repoManifests, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var pkg struct {
+		Name                 string            `json:"name"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+		Workspaces           json.RawMessage   `json:"workspaces"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return
+	}
+
+	m.pkgName = pkg.Name
+	m.pkgDeps = make(map[string]string)
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.PeerDependencies, pkg.OptionalDependencies} {
+		for name, version := range deps {
+			m.pkgDeps[name] = version
+		}
+	}
+
+	// workspaces is a list of patterns, or {"packages": [...]} with yarn
+	var patterns []string
+	if json.Unmarshal(pkg.Workspaces, &patterns) != nil {
+		var yarn struct {
+			Packages []string `json:"packages"`
+		}
+		if json.Unmarshal(pkg.Workspaces, &yarn) == nil {
+			patterns = yarn.Packages
+		}
+	}
+	for _, p := range patterns {
+		m.workspaces = append(m.workspaces, resolveFrom(m.dir, p))
+	}
+}
+
+// PackageName returns the name a project in dir gives itself in its package
+// metadata, and the file it comes from: the last element of the module path
+// in go.mod (without a /vN major version suffix), the name in package.json
+// (without an @scope/), or the name in pyproject.toml or Cargo.toml. It
+// returns empty strings when none of them names the project.
+func PackageName(dir string) (name, source string) {
+	m := readManifests(dir)
+	if m.module != "" {
+		parts := strings.Split(m.module, "/")
+		last := parts[len(parts)-1]
+		if len(parts) > 1 && isMajorVersion(last) {
+			last = parts[len(parts)-2]
+		}
+		return last, "go.mod"
+	}
+	if m.pkgName != "" {
+		if i := strings.LastIndex(m.pkgName, "/"); i >= 0 {
+			return m.pkgName[i+1:], "package.json"
+		}
+		return m.pkgName, "package.json"
+	}
+	if name := readTOMLName(filepath.Join(dir, "pyproject.toml"), "project", "tool.poetry"); name != "" {
+		return name, "pyproject.toml"
+	}
+	if name := readTOMLName(filepath.Join(dir, "Cargo.toml"), "package"); name != "" {
+		return name, "Cargo.toml"
+	}
+	return "", ""
+}
+
+// isMajorVersion reports whether a module path element is a major version
+// suffix like v2.
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// readTOMLName returns the name key of the first of tables found in the
+// TOML file at path. Like readGoMod it reads lines rather than parsing the
+// file, which is enough for a top-level name = "..." under a table header.
+func readTOMLName(path string, tables ...string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	names := make(map[string]string)
+	table := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			table = strings.TrimSpace(strings.Trim(line, "[]"))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "name" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if i := strings.Index(value, "#"); i >= 0 && !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+			value = strings.TrimSpace(value[:i])
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+			if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+				value = value[1 : end+1]
+			}
+		}
+		if _, seen := names[table]; !seen {
+			names[table] = value
+		}
+	}
+	for _, t := range tables {
+		if name := names[t]; name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// dependsOnGo reports whether m's go.mod requires other's module, or
+// replaces a module with other's directory.
+func (m *repoManifests) dependsOnGo(other *repoManifests) bool {
+	if other.module != "" {
+		for _, req := range m.requires {
+			if req == other.module {
+				return true
+			}
+		}
+	}
+	for _, dir := range m.replaces {
+		if dir == other.dir {
+			return true
+		}
+	}
+	return false
+}
+
+// dependsOnPackage reports whether m's package.json depends on other's
+// package, by name or through a file: or link: path, or lists other's
+// directory in its workspaces.
+func (m *repoManifests) dependsOnPackage(other *repoManifests) bool {
+	if other.pkgName != "" {
+		if _, ok := m.pkgDeps[other.pkgName]; ok {
+			return true
+		}
+	}
+	for _, version := range m.pkgDeps {
+		for _, prefix := range []string{"file:", "link:"} {
+			if p, ok := strings.CutPrefix(version, prefix); ok && resolveFrom(m.dir, p) == other.dir {
+				return true
+			}
+		}
+	}
+	for _, pattern := range m.workspaces {
+		if ok, _ := filepath.Match(pattern, other.dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isLocalPath(p string) bool {
+	return strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") || filepath.IsAbs(p)
+}
+
+func resolveFrom(dir, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(dir, p)
+}
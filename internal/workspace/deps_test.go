@@ -0,0 +1,160 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func writeRepoFiles(t *testing.T, ws string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(ws, "repos", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+}
+
+func TestBuildDepGraph(t *testing.T) {
+	ws := t.TempDir()
+	writeRepoFiles(t, ws, map[string]string{
+		"lib/go.mod": "module example.com/lib\n\ngo 1.22\n",
+		"api/go.mod": `module example.com/api
+
+require (
+	example.com/lib v0.1.0 // indirect
+	github.com/other/thing v1.0.0
+)
+`,
+		"tool/go.mod":          "module example.com/tool\n\nreplace example.com/shared => ../shared\n",
+		"shared/README.md":     "no manifest",
+		"ui/package.json":      `{"name": "@acme/ui"}`,
+		"web/package.json":     `{"name": "web", "dependencies": {"@acme/ui": "^1.0.0"}, "devDependencies": {"helpers": "file:../helpers"}}`,
+		"helpers/package.json": `{"name": "helpers"}`,
+		"mono/package.json":    `{"workspaces": {"packages": ["../ui"]}}`,
+	})
+
+	proj := model.NewProject("acme--platform", "acme", "platform")
+	proj.Repos = []model.RepoSpec{
+		{Name: "web", Path: "repos/web", DependsOn: []string{"api", "missing"}},
+	}
+
+	g, err := BuildDepGraph(ws, proj)
+	if err != nil {
+		t.Fatalf("BuildDepGraph: %v", err)
+	}
+
+	want := []RepoDep{
+		{From: "api", To: "lib", Source: DepGoMod},
+		{From: "mono", To: "ui", Source: DepPackageJSON},
+		{From: "tool", To: "shared", Source: DepGoMod},
+		{From: "web", To: "api", Source: DepDeclared},
+		{From: "web", To: "helpers", Source: DepPackageJSON},
+		{From: "web", To: "ui", Source: DepPackageJSON},
+	}
+	if !reflect.DeepEqual(g.Deps, want) {
+		t.Errorf("Deps = %+v, want %+v", g.Deps, want)
+	}
+	if len(g.Warnings) != 1 || !strings.Contains(g.Warnings[0], "missing") {
+		t.Errorf("Warnings = %v, want one for the missing repo", g.Warnings)
+	}
+	if g.Paths["web"] != filepath.Join(ws, "repos", "web") {
+		t.Errorf("Paths[web] = %q", g.Paths["web"])
+	}
+
+	order, err := g.Order()
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	wantOrder := []string{"helpers", "lib", "api", "shared", "tool", "ui", "mono", "web"}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("Order = %v, want %v", order, wantOrder)
+	}
+}
+
+func TestDepGraphCycle(t *testing.T) {
+	g := &DepGraph{
+		Repos: []string{"a", "b", "c", "d"},
+		Deps: []RepoDep{
+			{From: "a", To: "b", Source: DepDeclared},
+			{From: "b", To: "c", Source: DepDeclared},
+			{From: "c", To: "b", Source: DepDeclared},
+		},
+	}
+	_, err := g.Order()
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Order error = %v, want CycleError", err)
+	}
+	// a is blocked by the cycle too; d is free
+	if !reflect.DeepEqual(cycle.Repos, []string{"a", "b", "c"}) {
+		t.Errorf("cycle = %v, want [a b c]", cycle.Repos)
+	}
+}
+
+func TestDepGraphRender(t *testing.T) {
+	g := &DepGraph{
+		Repos: []string{"api", "lib", "web"},
+		Deps: []RepoDep{
+			{From: "api", To: "lib", Source: DepGoMod},
+			{From: "web", To: "api", Source: DepDeclared},
+			{From: "web", To: "lib", Source: DepDeclared},
+		},
+	}
+
+	ascii := g.ASCII()
+	wantASCII := `lib
+api
+  └─ lib (go.mod)
+web
+  ├─ api (project.json)
+  └─ lib (project.json)
+`
+	if ascii != wantASCII {
+		t.Errorf("ASCII =\n%s\nwant\n%s", ascii, wantASCII)
+	}
+
+	dot := g.DOT("acme--platform")
+	for _, want := range []string{`digraph "acme--platform" {`, `"api" -> "lib" [label="go.mod"];`, `"web";`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	ws := t.TempDir()
+	writeRepoFiles(t, ws, map[string]string{
+		"api/go.mod":            "module github.com/acme/billing-api/v2\n\ngo 1.22\n",
+		"web/package.json":      `{"name": "@acme/storefront"}`,
+		"ml/pyproject.toml":     "[build-system]\nrequires = [\"hatchling\"]\n\n[project]\nname = \"forecaster\" # the model\n",
+		"poetry/pyproject.toml": "[tool.poetry]\nname = 'legacy-etl'\n",
+		"cli/Cargo.toml":        "[package]\nname = \"rusty\"\nversion = \"0.1.0\"\n\n[dependencies]\nname = \"nope\"\n",
+		"docs/README.md":        "# Docs\n",
+	})
+	tests := []struct {
+		dir, name, source string
+	}{
+		{"api", "billing-api", "go.mod"},
+		{"web", "storefront", "package.json"},
+		{"ml", "forecaster", "pyproject.toml"},
+		{"poetry", "legacy-etl", "pyproject.toml"},
+		{"cli", "rusty", "Cargo.toml"},
+		{"docs", "", ""},
+	}
+	for _, tt := range tests {
+		name, source := PackageName(filepath.Join(ws, "repos", tt.dir))
+		if name != tt.name || source != tt.source {
+			t.Errorf("PackageName(%s) = %q, %q; want %q, %q", tt.dir, name, source, tt.name, tt.source)
+		}
+	}
+}
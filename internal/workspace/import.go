@@ -2,14 +2,20 @@
 package workspace
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/tormodhaugland/co/internal/config"
 	"github.com/tormodhaugland/co/internal/fs"
 	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/lock"
 	"github.com/tormodhaugland/co/internal/model"
 )
 
@@ -22,45 +28,148 @@ type ImportOptions struct {
 	ExtraFiles     []string
 	ExtraFilesDest string // Destination subfolder for extra files (empty = project root)
 
+	// Subdirectories of repos to split into repos of their own. Their
+	// source repos are left in place unless also listed in gitRoots.
+	Splits      []RepoSplit
+	SplitMethod string // git.SplitSubtree or git.SplitFilterRepo (empty = git.DefaultSplitMethod)
+
+	// Name of a repo to merge the git roots into, each in a subdirectory
+	// named like the repo it would otherwise become, with its history. The
+	// merged repos are left in place. Empty moves each repo as usual.
+	MergeInto string
+
+	// Names of repos in the workspace by git root, overriding DeriveRepoName
+	// for the roots listed
+	RepoNames map[string]string
+
+	// Layout of a new workspace, model.LayoutNested or model.LayoutFlat
+	// (empty = the owner's configured repo_layout). Adding to a workspace
+	// follows the layout recorded in its project.json.
+	Layout string
+
+	// Paths of repos relative to the workspace by git root, overriding the
+	// layout for the roots listed, e.g. "services/api"
+	RepoPaths map[string]string
+
 	// Callbacks for progress reporting (all optional)
-	OnRepoMove func(repoName, srcPath, dstPath string)
-	OnRepoSkip func(repoName, reason string)
-	OnFileCopy func(relPath, dstPath string)
-	OnWarning  func(msg string)
+	OnRepoMove  func(repoName, srcPath, dstPath string)
+	OnRepoSplit func(repoName, srcPath, dir string)
+	OnRepoMerge func(repoName, srcPath, into string)
+	OnRepoSkip  func(repoName, reason string)
+	OnFileCopy  func(relPath, dstPath string)
+	OnWarning   func(msg string)
+}
+
+// repoName returns the name of the repo at root in the workspace.
+func (opts ImportOptions) repoName(root, sourcePath string) string {
+	if name := opts.RepoNames[root]; name != "" {
+		return name
+	}
+	return DeriveRepoName(root, sourcePath)
+}
+
+// repoDir returns the path, relative to the workspace, the repo at root
+// named name is moved to in proj's workspace.
+func (opts ImportOptions) repoDir(proj *model.Project, root, name string) string {
+	if path := opts.RepoPaths[root]; path != "" {
+		return filepath.Clean(filepath.FromSlash(path))
+	}
+	return proj.RepoDir(name)
+}
+
+// checkRepoPaths returns an error for a layout or repo path opts cannot
+// import with.
+func (opts ImportOptions) checkRepoPaths() error {
+	if !model.ValidLayout(opts.Layout) {
+		return fmt.Errorf("invalid repo layout %q (want %s or %s)", opts.Layout, model.LayoutNested, model.LayoutFlat)
+	}
+	for _, path := range opts.RepoPaths {
+		if clean := filepath.Clean(filepath.FromSlash(path)); path != "" && (!filepath.IsLocal(clean) || clean == "project.json") {
+			return fmt.Errorf("repo path %s must be inside the workspace", path)
+		}
+	}
+	return nil
+}
+
+// RepoSplit is a subdirectory of a repo to split, with its history, into a
+// repo of its own.
+type RepoSplit struct {
+	Repo string `json:"repo"` // Git root containing the subdirectory
+	Dir  string `json:"dir"`  // Subdirectory, relative to Repo
+	Name string `json:"name"` // Name of the new repo in the workspace
+}
+
+// SplitRepoName derives the repo name for a split subdirectory from its last
+// path element.
+func SplitRepoName(dir string) string {
+	return SanitizeSlugPart(filepath.Base(filepath.Clean(dir)))
 }
 
 // ImportResult holds the result of an import operation.
 type ImportResult struct {
-	WorkspacePath string   // Full path to created/updated workspace
-	WorkspaceSlug string   // Workspace slug (owner--project)
-	ReposImported []string // Names of repos imported
-	ReposSkipped  []string // Names of repos skipped (already exist, etc.)
-	FilesCopied   []string // Paths of extra files copied
-	SourceEmpty   bool     // True if source directory is now empty
-	Errors        []string // Non-fatal errors encountered
+	WorkspacePath string   `json:"workspace_path"`           // Full path to created/updated workspace
+	WorkspaceSlug string   `json:"workspace_slug"`           // Workspace slug (owner--project)
+	ReposImported []string `json:"repos_imported,omitempty"` // Names of repos imported
+	ReposSkipped  []string `json:"repos_skipped,omitempty"`  // Names of repos skipped (already exist, etc.)
+	ReposSplit    []string `json:"repos_split,omitempty"`    // Names of repos split out of other repos
+	ReposMerged   []string `json:"repos_merged,omitempty"`   // Subdirectories of the repos merged into MergeInto
+	FilesCopied   []string `json:"files_copied,omitempty"`   // Paths of extra files copied
+	SourceEmpty   bool     `json:"source_empty"`             // True if source directory is now empty
+	Errors        []string `json:"errors,omitempty"`         // Non-fatal errors encountered
 }
 
 // CreateWorkspace creates a new workspace from a source folder.
 // It moves git repositories into the workspace and optionally copies extra files.
 func CreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, opts ImportOptions) (*ImportResult, error) {
+	return CreateWorkspaceContext(context.Background(), cfg, sourcePath, gitRoots, opts)
+}
+
+// CreateWorkspaceContext imports like CreateWorkspace, stopping before the
+// next repo once ctx is done. The repos moved so far are kept and recorded
+// in project.json, and the result is returned with ctx's error.
+func CreateWorkspaceContext(ctx context.Context, cfg *config.Config, sourcePath string, gitRoots []string, opts ImportOptions) (*ImportResult, error) {
 	if opts.Owner == "" || opts.Project == "" {
 		return nil, fmt.Errorf("owner and project are required")
 	}
 
-	slug := opts.Owner + "--" + opts.Project
-	if !fs.IsValidWorkspaceSlug(slug) {
+	scheme := SchemeFor(cfg)
+	slug := scheme.Format(opts.Owner, opts.Project)
+	if !scheme.Valid(slug) {
 		return nil, fmt.Errorf("invalid workspace slug: %s", slug)
 	}
+	if err := CheckOwnerPolicy(cfg, opts.Owner, opts.Project); err != nil {
+		return nil, err
+	}
+	if err := opts.checkRepoPaths(); err != nil {
+		return nil, err
+	}
+
+	l, err := lock.Workspace(cfg, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	if Exists(cfg, slug) {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceExists, slug)
+	}
 
-	if fs.WorkspaceExists(cfg.CodeRoot, slug) {
-		return nil, fmt.Errorf("workspace already exists: %s", slug)
+	workspacePath := cfg.WorkspacePath(slug)
+
+	if err := checkImportSpace(workspacePath, sourcePath, gitRoots, opts.ExtraFiles); err != nil {
+		return nil, err
 	}
 
-	workspacePath := filepath.Join(cfg.CodeRoot, slug)
-	reposPath := filepath.Join(workspacePath, "repos")
+	// Create project model
+	proj := model.NewProject(slug, opts.Owner, opts.Project)
+	proj.RepoLayout = opts.Layout
+	if proj.RepoLayout == "" {
+		proj.RepoLayout = cfg.GetRepoLayout(opts.Owner)
+	}
+	ApplyOwnerDefaults(cfg, proj)
 
 	// Create workspace directory structure
-	if err := os.MkdirAll(reposPath, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Join(workspacePath, proj.RepoDir("")), fs.DirPerm()); err != nil {
 		return nil, fmt.Errorf("failed to create workspace: %w", err)
 	}
 
@@ -69,19 +178,32 @@ func CreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, o
 		WorkspaceSlug: slug,
 	}
 
-	// Create project model
-	proj := model.NewProject(opts.Owner, opts.Project)
+	// Split repos first, while their sources are still in place
+	splitRepos(workspacePath, proj, result, opts)
+
+	// Merged repos are not moved; a failed merge falls back to moving them
+	if opts.MergeInto != "" && len(gitRoots) > 0 && mergeRepos(sourcePath, gitRoots, workspacePath, proj, result, opts) {
+		gitRoots = nil
+	}
 
 	// Move git repos
 	for _, root := range gitRoots {
-		repoName := DeriveRepoName(root, sourcePath)
-		destPath := filepath.Join(reposPath, repoName)
+		if ctx.Err() != nil {
+			break
+		}
+		repoName := opts.repoName(root, sourcePath)
+		dir := opts.repoDir(proj, root, repoName)
+		destPath := filepath.Join(workspacePath, dir)
 
 		if opts.OnRepoMove != nil {
 			opts.OnRepoMove(repoName, root, destPath)
 		}
 
-		if err := moveDir(root, destPath); err != nil {
+		err := os.MkdirAll(filepath.Dir(destPath), fs.DirPerm())
+		if err == nil {
+			err = moveRepo(ctx, root, destPath)
+		}
+		if err != nil {
 			errMsg := fmt.Sprintf("failed to move %s: %v", root, err)
 			result.Errors = append(result.Errors, errMsg)
 			if opts.OnWarning != nil {
@@ -95,40 +217,66 @@ func CreateWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, o
 		if info, err := git.GetInfo(destPath); err == nil && info.Remote != "" {
 			remote = info.Remote
 		}
-		proj.AddRepo(repoName, "repos/"+repoName, remote)
+		proj.AddRepo(repoName, filepath.ToSlash(dir), remote)
 		result.ReposImported = append(result.ReposImported, repoName)
 	}
 
+	if err := RunEventHook(cfg, EventImport, proj, workspacePath); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("import hook failed: %v", err))
+	}
+
 	// Save project.json
 	if err := proj.Save(workspacePath); err != nil {
 		return nil, fmt.Errorf("failed to save project.json: %w", err)
 	}
 
-	// Copy extra files
-	if len(opts.ExtraFiles) > 0 {
+	// Copy extra files, unless cancelled
+	if len(opts.ExtraFiles) > 0 && ctx.Err() == nil {
 		copied, errs := CopyExtraFiles(sourcePath, workspacePath, opts.ExtraFiles, opts.ExtraFilesDest, opts.OnFileCopy)
 		result.FilesCopied = copied
 		result.Errors = append(result.Errors, errs...)
 	}
 
+	if err := RefreshIndex(cfg, workspacePath); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to update workspace index: %v", err))
+	}
+
 	// Check if source is now empty
 	result.SourceEmpty, _ = isDirEmpty(sourcePath)
 
-	return result, nil
+	return result, ctx.Err()
 }
 
 // AddToWorkspace adds repositories and files to an existing workspace.
 func AddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, slug string, opts ImportOptions) (*ImportResult, error) {
-	if !fs.IsValidWorkspaceSlug(slug) {
+	return AddToWorkspaceContext(context.Background(), cfg, sourcePath, gitRoots, slug, opts)
+}
+
+// AddToWorkspaceContext adds like AddToWorkspace, stopping before the next
+// repo once ctx is done, as CreateWorkspaceContext does.
+func AddToWorkspaceContext(ctx context.Context, cfg *config.Config, sourcePath string, gitRoots []string, slug string, opts ImportOptions) (*ImportResult, error) {
+	if !SchemeFor(cfg).Valid(slug) {
 		return nil, fmt.Errorf("invalid workspace slug: %s", slug)
 	}
 
-	if !fs.WorkspaceExists(cfg.CodeRoot, slug) {
-		return nil, fmt.Errorf("workspace does not exist: %s", slug)
+	l, err := lock.Workspace(cfg, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	if !Exists(cfg, slug) {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, slug)
+	}
+
+	workspacePath := cfg.WorkspacePath(slug)
+	if err := opts.checkRepoPaths(); err != nil {
+		return nil, err
 	}
 
-	workspacePath := filepath.Join(cfg.CodeRoot, slug)
-	reposPath := filepath.Join(workspacePath, "repos")
+	if err := checkImportSpace(workspacePath, sourcePath, gitRoots, opts.ExtraFiles); err != nil {
+		return nil, err
+	}
 
 	// Load existing project
 	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
@@ -149,8 +297,12 @@ func AddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, sl
 
 	// Move git repos
 	for _, root := range gitRoots {
-		repoName := DeriveRepoName(root, sourcePath)
-		destPath := filepath.Join(reposPath, repoName)
+		if ctx.Err() != nil {
+			break
+		}
+		repoName := opts.repoName(root, sourcePath)
+		dir := opts.repoDir(proj, root, repoName)
+		destPath := filepath.Join(workspacePath, dir)
 
 		if existingRepos[repoName] {
 			if opts.OnRepoSkip != nil {
@@ -164,7 +316,11 @@ func AddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, sl
 			opts.OnRepoMove(repoName, root, destPath)
 		}
 
-		if err := moveDir(root, destPath); err != nil {
+		err := os.MkdirAll(filepath.Dir(destPath), fs.DirPerm())
+		if err == nil {
+			err = moveRepo(ctx, root, destPath)
+		}
+		if err != nil {
 			errMsg := fmt.Sprintf("failed to move %s: %v", root, err)
 			result.Errors = append(result.Errors, errMsg)
 			if opts.OnWarning != nil {
@@ -178,7 +334,7 @@ func AddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, sl
 		if info, err := git.GetInfo(destPath); err == nil && info.Remote != "" {
 			remote = info.Remote
 		}
-		proj.AddRepo(repoName, "repos/"+repoName, remote)
+		proj.AddRepo(repoName, filepath.ToSlash(dir), remote)
 		result.ReposImported = append(result.ReposImported, repoName)
 	}
 
@@ -189,17 +345,185 @@ func AddToWorkspace(cfg *config.Config, sourcePath string, gitRoots []string, sl
 		}
 	}
 
-	// Copy extra files
-	if len(opts.ExtraFiles) > 0 {
+	// Copy extra files, unless cancelled
+	if len(opts.ExtraFiles) > 0 && ctx.Err() == nil {
 		copied, errs := CopyExtraFiles(sourcePath, workspacePath, opts.ExtraFiles, opts.ExtraFilesDest, opts.OnFileCopy)
 		result.FilesCopied = copied
 		result.Errors = append(result.Errors, errs...)
 	}
 
+	if err := RefreshIndex(cfg, workspacePath); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("failed to update workspace index: %v", err))
+	}
+
 	// Check if source is now empty
 	result.SourceEmpty, _ = isDirEmpty(sourcePath)
 
-	return result, nil
+	return result, ctx.Err()
+}
+
+// splitRepos splits opts.Splits into repos of the workspace at
+// workspacePath and adds them to proj. Failures are recorded as errors in
+// result.
+func splitRepos(workspacePath string, proj *model.Project, result *ImportResult, opts ImportOptions) {
+	if len(opts.Splits) == 0 {
+		return
+	}
+	method := opts.SplitMethod
+	if method == "" {
+		method = git.DefaultSplitMethod()
+	}
+	for _, split := range opts.Splits {
+		dir := proj.RepoDir(split.Name)
+		destPath := filepath.Join(workspacePath, dir)
+		if opts.OnRepoSplit != nil {
+			opts.OnRepoSplit(split.Name, split.Repo, split.Dir)
+		}
+		if err := git.SplitSubdir(split.Repo, split.Dir, destPath, method); err != nil {
+			errMsg := fmt.Sprintf("failed to split %s from %s: %v", split.Dir, split.Repo, err)
+			result.Errors = append(result.Errors, errMsg)
+			if opts.OnWarning != nil {
+				opts.OnWarning(errMsg)
+			}
+			continue
+		}
+		proj.AddRepo(split.Name, filepath.ToSlash(dir), "")
+		result.ReposSplit = append(result.ReposSplit, split.Name)
+	}
+}
+
+// mergeRepos merges gitRoots into the repo opts.MergeInto of the workspace
+// at workspacePath and adds it to proj. It reports whether the merge
+// succeeded.
+func mergeRepos(sourcePath string, gitRoots []string, workspacePath string, proj *model.Project, result *ImportResult, opts ImportOptions) bool {
+	sources := make([]git.MergeSource, 0, len(gitRoots))
+	for _, root := range gitRoots {
+		sources = append(sources, git.MergeSource{Path: root, Prefix: opts.repoName(root, sourcePath)})
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Prefix < sources[j].Prefix })
+
+	if opts.OnRepoMerge != nil {
+		for _, src := range sources {
+			opts.OnRepoMerge(src.Prefix, src.Path, opts.MergeInto)
+		}
+	}
+	dir := proj.RepoDir(opts.MergeInto)
+	if err := git.MergeRepos(filepath.Join(workspacePath, dir), sources); err != nil {
+		errMsg := fmt.Sprintf("failed to merge repos into %s, importing them separately: %v", opts.MergeInto, err)
+		result.Errors = append(result.Errors, errMsg)
+		if opts.OnWarning != nil {
+			opts.OnWarning(errMsg)
+		}
+		return false
+	}
+
+	proj.AddRepo(opts.MergeInto, filepath.ToSlash(dir), "")
+	result.ReposImported = append(result.ReposImported, opts.MergeInto)
+	for _, src := range sources {
+		result.ReposMerged = append(result.ReposMerged, src.Prefix)
+	}
+	return true
+}
+
+// ExtraDuplicate is a selected extra file identical to a file in one of the
+// imported repos.
+type ExtraDuplicate struct {
+	Path string `json:"path"` // Extra file, relative to the source
+	Same string `json:"same"` // Identical repo file, relative to the source
+	Size int64  `json:"size"`
+}
+
+// FindDuplicateExtras returns the extra files in selected, relative to
+// sourcePath, whose contents are identical to a file in one of gitRoots, in
+// the order selected. Only files selected themselves are compared, not the
+// contents of selected folders. Repo files are hashed only when their size
+// matches an extra file.
+func FindDuplicateExtras(sourcePath string, selected, gitRoots []string) ([]ExtraDuplicate, error) {
+	type extra struct {
+		path string
+		size int64
+		hash string
+		same string
+	}
+	var extras []*extra
+	bySize := make(map[int64][]*extra)
+	for _, rel := range selected {
+		info, err := os.Lstat(filepath.Join(sourcePath, rel))
+		if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+			continue
+		}
+		e := &extra{path: rel, size: info.Size()}
+		extras = append(extras, e)
+		bySize[e.size] = append(bySize[e.size], e)
+	}
+	if len(extras) == 0 {
+		return nil, nil
+	}
+
+	for _, root := range gitRoots {
+		err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			candidates := bySize[info.Size()]
+			if len(candidates) == 0 {
+				return nil
+			}
+			hash, err := fileHash(p)
+			if err != nil {
+				return nil
+			}
+			for _, e := range candidates {
+				if e.same != "" {
+					continue
+				}
+				if e.hash == "" {
+					if e.hash, err = fileHash(filepath.Join(sourcePath, e.path)); err != nil {
+						return err
+					}
+				}
+				if e.hash == hash {
+					e.same, _ = filepath.Rel(sourcePath, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var dups []ExtraDuplicate
+	for _, e := range extras {
+		if e.same != "" {
+			dups = append(dups, ExtraDuplicate{Path: e.path, Same: e.same, Size: e.size})
+		}
+	}
+	return dups, nil
+}
+
+// fileHash returns the hex SHA-256 of the contents of the file at path.
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // CopyExtraFiles copies selected files/folders from source to workspace.
@@ -211,7 +535,7 @@ func CopyExtraFiles(sourcePath, workspacePath string, selectedPaths []string, de
 	destBase := workspacePath
 	if destSubfolder != "" {
 		destBase = filepath.Join(workspacePath, destSubfolder)
-		if err := os.MkdirAll(destBase, 0755); err != nil {
+		if err := os.MkdirAll(destBase, fs.DirPerm()); err != nil {
 			errors = append(errors, fmt.Sprintf("failed to create destination subfolder: %v", err))
 			return copied, errors
 		}
@@ -221,7 +545,7 @@ func CopyExtraFiles(sourcePath, workspacePath string, selectedPaths []string, de
 		srcPath := filepath.Join(sourcePath, relPath)
 		dstPath := filepath.Join(destBase, relPath)
 
-		info, err := os.Stat(srcPath)
+		info, err := os.Lstat(srcPath)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("cannot access %s: %v", relPath, err))
 			continue
@@ -231,21 +555,15 @@ func CopyExtraFiles(sourcePath, workspacePath string, selectedPaths []string, de
 			onCopy(relPath, dstPath)
 		}
 
-		if info.IsDir() {
-			if err := copyDir(srcPath, dstPath); err != nil {
-				errors = append(errors, fmt.Sprintf("failed to copy directory %s: %v", relPath, err))
-				continue
-			}
-		} else {
-			// Create parent directory if needed
-			if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-				errors = append(errors, fmt.Sprintf("failed to create parent dir for %s: %v", relPath, err))
-				continue
-			}
-			if err := copyFile(srcPath, dstPath, info.Mode()); err != nil {
-				errors = append(errors, fmt.Sprintf("failed to copy file %s: %v", relPath, err))
-				continue
+		// Links are recreated as they are, as MoveDir does, since the source
+		// is removed afterwards and a followed link would copy its target
+		if _, err := fs.CopyTree(srcPath, dstPath, fs.CopyOptions{Symlinks: fs.SymlinkPreserve}); err != nil {
+			kind := "file"
+			if info.IsDir() {
+				kind = "directory"
 			}
+			errors = append(errors, fmt.Sprintf("failed to copy %s %s: %v", kind, relPath, err))
+			continue
 		}
 
 		// Remove the source after successful copy
@@ -259,6 +577,17 @@ func CopyExtraFiles(sourcePath, workspacePath string, selectedPaths []string, de
 	return copied, errors
 }
 
+// GitScanOptions returns the options for scanning an import source for git
+// repositories, as configured under "git_scan".
+func GitScanOptions(cfg *config.Config) git.ScanOptions {
+	sc := cfg.GetGitScanConfig()
+	return git.ScanOptions{
+		MaxDepth:       sc.MaxDepth,
+		FollowSymlinks: sc.FollowSymlinks,
+		Exclude:        sc.Exclude,
+	}
+}
+
 // DeriveRepoName derives a repo name from its path relative to the source folder.
 func DeriveRepoName(repoPath, sourcePath string) string {
 	if repoPath == sourcePath {
@@ -274,18 +603,10 @@ func DeriveRepoName(repoPath, sourcePath string) string {
 	return SanitizeSlugPart(name)
 }
 
-// SanitizeSlugPart cleans a string for use in a workspace slug.
+// SanitizeSlugPart cleans a string for use in a workspace slug using the
+// default slug scheme. See SlugScheme.Sanitize.
 func SanitizeSlugPart(s string) string {
-	s = strings.ToLower(s)
-	var result strings.Builder
-	for _, c := range s {
-		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' {
-			result.WriteRune(c)
-		} else if c == '_' || c == ' ' {
-			result.WriteRune('-')
-		}
-	}
-	return result.String()
+	return DefaultSlugScheme().Sanitize(s)
 }
 
 // RemoveEmptySource removes the source directory if it's empty.
@@ -301,11 +622,44 @@ func RemoveEmptySource(sourcePath string) bool {
 	return true
 }
 
-// moveDir moves a directory, falling back to copy+delete for cross-device moves.
-func moveDir(src, dst string) error {
+// checkImportSpace fails early when the destination filesystem cannot hold
+// the repos that must be copied across filesystems plus the extra files.
+// Repos on the same filesystem are renamed and need no extra space.
+func checkImportSpace(dest, sourcePath string, gitRoots, extraFiles []string) error {
+	var need int64
+	for _, root := range gitRoots {
+		if same, err := fs.SameDevice(root, dest); err == nil && same {
+			continue
+		}
+		if size, err := fs.DirSize(root); err == nil {
+			need += size
+		}
+	}
+	for _, relPath := range extraFiles {
+		if size, err := fs.DirSize(filepath.Join(sourcePath, relPath)); err == nil {
+			need += size
+		}
+	}
+	return fs.CheckFreeSpace(dest, need)
+}
+
+// MoveDir moves a directory (or file), falling back to copy+delete for
+// cross-device moves.
+func MoveDir(src, dst string) error {
+	return MoveDirContext(context.Background(), src, dst)
+}
+
+// MoveDirContext moves like MoveDir, stopping a cross-device copy when ctx
+// is done. The source is then left in place and the partial copy removed,
+// unless dst existed already.
+func MoveDirContext(ctx context.Context, src, dst string) error {
 	if err := os.Rename(src, dst); err != nil {
 		if isCrossDevice(err) {
-			if err := copyDir(src, dst); err != nil {
+			_, statErr := os.Lstat(dst)
+			if _, err := fs.CopyTreeContext(ctx, src, dst, fs.CopyOptions{Symlinks: fs.SymlinkPreserve}); err != nil {
+				if statErr != nil && ctx.Err() != nil {
+					os.RemoveAll(dst)
+				}
 				return err
 			}
 			return os.RemoveAll(src)
@@ -315,6 +669,24 @@ func moveDir(src, dst string) error {
 	return nil
 }
 
+// moveRepo moves a git root into a workspace. Roots found by following a
+// symlinked directory are moved from their real location, and the link left
+// behind is removed so it doesn't dangle.
+func moveRepo(ctx context.Context, root, dst string) error {
+	info, err := os.Lstat(root)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return MoveDirContext(ctx, root, dst)
+	}
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	if err := MoveDirContext(ctx, resolved, dst); err != nil {
+		return err
+	}
+	return os.Remove(root)
+}
+
 func isDirEmpty(path string) (bool, error) {
 	entries, err := os.ReadDir(path)
 	if err != nil {
@@ -333,34 +705,6 @@ func isCrossDevice(err error) bool {
 		strings.Contains(err.Error(), "invalid cross-device link")
 }
 
-func copyDir(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		rel, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-		targetPath := filepath.Join(dst, rel)
-
-		if info.IsDir() {
-			return os.MkdirAll(targetPath, info.Mode())
-		}
-
-		return copyFile(path, targetPath, info.Mode())
-	})
-}
-
-func copyFile(src, dst string, mode os.FileMode) error {
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(dst, data, mode)
-}
-
 // RenameResult holds the result of a workspace rename operation.
 type RenameResult struct {
 	OldSlug string
@@ -371,24 +715,38 @@ type RenameResult struct {
 
 // RenameWorkspace renames a workspace by updating its folder name and project.json.
 func RenameWorkspace(cfg *config.Config, currentSlug, newOwner, newProject string) (*RenameResult, error) {
-	// Validate new slug
-	newSlug := newOwner + "--" + newProject
-	if !fs.IsValidWorkspaceSlug(newSlug) {
+	// Validate new slug, keeping any category segment
+	scheme := SchemeFor(cfg)
+	current, _ := scheme.Parse(currentSlug)
+	newSlug := scheme.FormatSlug(Slug{Category: current.Category, Owner: newOwner, Project: newProject})
+	if !scheme.Valid(newSlug) {
 		return nil, fmt.Errorf("invalid new workspace slug: %s", newSlug)
 	}
 
+	// Lock both names so nothing else can create or modify either workspace mid-rename
+	oldLock, err := lock.Workspace(cfg, currentSlug)
+	if err != nil {
+		return nil, err
+	}
+	defer oldLock.Release()
+	newLock, err := lock.Workspace(cfg, newSlug)
+	if err != nil {
+		return nil, err
+	}
+	defer newLock.Release()
+
 	// Check current workspace exists
-	if !fs.WorkspaceExists(cfg.CodeRoot, currentSlug) {
-		return nil, fmt.Errorf("workspace does not exist: %s", currentSlug)
+	if !Exists(cfg, currentSlug) {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, currentSlug)
 	}
 
 	// Check new workspace doesn't exist (unless it's the same)
-	if currentSlug != newSlug && fs.WorkspaceExists(cfg.CodeRoot, newSlug) {
-		return nil, fmt.Errorf("workspace already exists: %s", newSlug)
+	if currentSlug != newSlug && Exists(cfg, newSlug) {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceExists, newSlug)
 	}
 
-	oldPath := filepath.Join(cfg.CodeRoot, currentSlug)
-	newPath := filepath.Join(cfg.CodeRoot, newSlug)
+	oldPath := cfg.WorkspacePath(currentSlug)
+	newPath := cfg.WorkspacePath(newSlug)
 
 	// Load and update project.json
 	projectPath := filepath.Join(oldPath, "project.json")
@@ -409,9 +767,14 @@ func RenameWorkspace(cfg *config.Config, currentSlug, newOwner, newProject strin
 
 	// Rename folder if slug changed
 	if currentSlug != newSlug {
+		if err := os.MkdirAll(filepath.Dir(newPath), fs.DirPerm()); err != nil {
+			return nil, fmt.Errorf("failed to create workspace parent: %w", err)
+		}
 		if err := os.Rename(oldPath, newPath); err != nil {
 			return nil, fmt.Errorf("failed to rename workspace folder: %w", err)
 		}
+		// Nested layouts may leave an empty owner directory behind
+		pruneEmptyParents(filepath.Dir(oldPath), cfg.CodeRoot)
 	}
 
 	return &RenameResult{
@@ -421,3 +784,77 @@ func RenameWorkspace(cfg *config.Config, currentSlug, newOwner, newProject strin
 		NewPath: newPath,
 	}, nil
 }
+
+// SetDescription sets the description of the workspace slug in its
+// project.json and in the workspace's index record, if it has one. An empty
+// description removes it.
+func SetDescription(cfg *config.Config, slug, description string) error {
+	return editProject(cfg, slug, func(proj *model.Project) error {
+		proj.Description = strings.TrimSpace(description)
+		return nil
+	})
+}
+
+// SetIcon sets the icon of the workspace slug, like SetDescription. The icon
+// must pass model.ValidIcon; an empty icon removes it.
+func SetIcon(cfg *config.Config, slug, icon string) error {
+	icon = strings.TrimSpace(icon)
+	if err := model.ValidIcon(icon); err != nil {
+		return err
+	}
+	return editProject(cfg, slug, func(proj *model.Project) error {
+		proj.Icon = icon
+		return nil
+	})
+}
+
+// SetDisplayName sets the display name of the workspace slug, like
+// SetDescription. An empty name removes it, so the slug is shown again.
+func SetDisplayName(cfg *config.Config, slug, name string) error {
+	name = strings.TrimSpace(name)
+	if strings.ContainsAny(name, "\r\n") {
+		return fmt.Errorf("display name must be a single line")
+	}
+	return editProject(cfg, slug, func(proj *model.Project) error {
+		proj.DisplayName = name
+		return nil
+	})
+}
+
+// editProject changes the project.json of the workspace slug with edit
+// under the workspace lock, and copies the fields shown in listings to the
+// workspace's index record, if it has one.
+func editProject(cfg *config.Config, slug string, edit func(*model.Project) error) error {
+	l, err := lock.Workspace(cfg, slug)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	if !Exists(cfg, slug) {
+		return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, slug)
+	}
+	workspacePath := cfg.WorkspacePath(slug)
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load project.json: %w", err)
+	}
+	if err := edit(proj); err != nil {
+		return err
+	}
+	if err := proj.Save(workspacePath); err != nil {
+		return fmt.Errorf("failed to save project.json: %w", err)
+	}
+
+	if idx, err := model.LoadIndex(cfg.IndexPath()); err == nil {
+		if record := idx.FindBySlug(slug); record != nil {
+			record.Description = proj.Description
+			record.Icon = proj.Icon
+			record.DisplayName = proj.DisplayName
+			if err := idx.Save(cfg.IndexPath()); err != nil {
+				return fmt.Errorf("failed to update index: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestCopyExtraFiles(t *testing.T) {
+	src := t.TempDir()
+	for name, content := range map[string]string{"notes.txt": "notes", "docs/a.md": "a", "docs/img/b.png": "b"} {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	ws := t.TempDir()
+
+	var reported []string
+	copied, errs := CopyExtraFiles(src, ws, []string{"notes.txt", "docs", "missing"}, "extra", func(rel, dst string) {
+		reported = append(reported, rel)
+	})
+	if len(copied) != 2 || len(reported) != 2 {
+		t.Errorf("copied = %v, reported = %v, want notes.txt and docs", copied, reported)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0], "missing") {
+		t.Errorf("errors = %v, want one for the missing path", errs)
+	}
+
+	for _, rel := range []string{"notes.txt", "docs/a.md", "docs/img/b.png"} {
+		if _, err := os.Stat(filepath.Join(ws, "extra", rel)); err != nil {
+			t.Errorf("%s not copied: %v", rel, err)
+		}
+		if _, err := os.Stat(filepath.Join(src, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s still in source after copy", rel)
+		}
+	}
+}
+
+func TestCopyExtraFilesSymlinks(t *testing.T) {
+	src := t.TempDir()
+	shared := t.TempDir()
+	if err := os.WriteFile(filepath.Join(shared, "big.bin"), []byte("data"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(src, "docs"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for link, target := range map[string]string{"shared": shared, "docs/current": ".."} {
+		if err := os.Symlink(target, filepath.Join(src, link)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	ws := t.TempDir()
+
+	copied, errs := CopyExtraFiles(src, ws, []string{"shared", "docs"}, "", nil)
+	if len(copied) != 2 || len(errs) != 0 {
+		t.Fatalf("CopyExtraFiles() = %v, %v; want both copied", copied, errs)
+	}
+	for link, want := range map[string]string{"shared": shared, "docs/current": ".."} {
+		if target, err := os.Readlink(filepath.Join(ws, link)); err != nil || target != want {
+			t.Errorf("%s = %q, %v; want a link to %s", link, target, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(shared, "big.bin")); err != nil {
+		t.Errorf("link target was removed with the source: %v", err)
+	}
+}
+
+func TestCreateWorkspaceSplits(t *testing.T) {
+	if out, _ := exec.Command("git", "subtree").CombinedOutput(); !strings.Contains(string(out), "usage") {
+		t.Skip("git subtree not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	src := filepath.Join(t.TempDir(), "mono")
+	for _, name := range []string{"services/api/main.go", "web/index.html"} {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", "initial"}} {
+		if out, err := exec.Command("git", append([]string{"-C", src}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	result, err := CreateWorkspace(cfg, src, nil, ImportOptions{
+		Owner:   "acme",
+		Project: "platform",
+		Splits: []RepoSplit{
+			{Repo: src, Dir: "services/api", Name: SplitRepoName("services/api")},
+			{Repo: src, Dir: "web", Name: "web"},
+			{Repo: src, Dir: "missing", Name: "missing"},
+		},
+		SplitMethod: git.SplitSubtree,
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	if strings.Join(result.ReposSplit, ",") != "api,web" {
+		t.Errorf("ReposSplit = %v, want [api web]", result.ReposSplit)
+	}
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "missing") {
+		t.Errorf("Errors = %v, want one for the missing directory", result.Errors)
+	}
+	if _, err := os.Stat(filepath.Join(result.WorkspacePath, "repos", "api", "main.go")); err != nil {
+		t.Errorf("split repo api missing main.go: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "web", "index.html")); err != nil {
+		t.Errorf("source repo changed: %v", err)
+	}
+
+	proj, err := model.LoadProject(filepath.Join(result.WorkspacePath, "project.json"))
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if len(proj.Repos) != 2 || proj.Repos[0].Name != "api" || proj.Repos[1].Path != "repos/web" {
+		t.Errorf("project repos = %+v, want api and web", proj.Repos)
+	}
+}
+
+func TestCreateWorkspaceMerge(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, kv := range []string{"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	src := t.TempDir()
+	var roots []string
+	for _, name := range []string{"exp-b", "exp-a"} {
+		repo := filepath.Join(src, name)
+		if err := os.MkdirAll(repo, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte(name), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", name}} {
+			if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+		roots = append(roots, repo)
+	}
+
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	var merged []string
+	result, err := CreateWorkspace(cfg, src, roots, ImportOptions{
+		Owner:       "acme",
+		Project:     "lab",
+		MergeInto:   "lab",
+		OnRepoMerge: func(repoName, srcPath, into string) { merged = append(merged, repoName) },
+	})
+	if err != nil {
+		t.Fatalf("CreateWorkspace: %v", err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("Errors = %v", result.Errors)
+	}
+	if strings.Join(result.ReposImported, ",") != "lab" || strings.Join(result.ReposMerged, ",") != "exp-a,exp-b" {
+		t.Errorf("imported = %v, merged = %v; want lab from exp-a and exp-b", result.ReposImported, result.ReposMerged)
+	}
+	if strings.Join(merged, ",") != "exp-a,exp-b" {
+		t.Errorf("OnRepoMerge calls = %v", merged)
+	}
+	for _, name := range []string{"exp-a/main.go", "exp-b/main.go"} {
+		if _, err := os.Stat(filepath.Join(result.WorkspacePath, "repos", "lab", name)); err != nil {
+			t.Errorf("%s missing from merged repo: %v", name, err)
+		}
+	}
+	for _, root := range roots {
+		if _, err := os.Stat(root); err != nil {
+			t.Errorf("merged repo %s not left in place: %v", root, err)
+		}
+	}
+	if result.SourceEmpty {
+		t.Error("SourceEmpty = true with the merged repos left in place")
+	}
+}
+
+func TestCreateWorkspaceContextCancelled(t *testing.T) {
+	src := t.TempDir()
+	var roots []string
+	for _, name := range []string{"api", "web"} {
+		root := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		roots = append(roots, root)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	result, err := CreateWorkspaceContext(ctx, cfg, src, roots, ImportOptions{
+		Owner:   "acme",
+		Project: "platform",
+		// Cancel once the first repo is on its way
+		OnRepoMove: func(repoName, srcPath, dstPath string) { cancel() },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CreateWorkspaceContext() error = %v, want context.Canceled", err)
+	}
+	if strings.Join(result.ReposImported, ",") != "api" {
+		t.Errorf("ReposImported = %v, want only api", result.ReposImported)
+	}
+	if _, err := os.Stat(filepath.Join(src, "web")); err != nil {
+		t.Errorf("web should be left in the source: %v", err)
+	}
+	proj, err := model.LoadProject(filepath.Join(result.WorkspacePath, "project.json"))
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if len(proj.Repos) != 1 || proj.Repos[0].Name != "api" {
+		t.Errorf("project repos = %+v, want the moved api", proj.Repos)
+	}
+}
+
+func TestImportFlatLayout(t *testing.T) {
+	src := t.TempDir()
+	roots := make(map[string]string)
+	for _, name := range []string{"api", "web", "cli"} {
+		root := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Join(root, ".git"), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		roots[name] = root
+	}
+
+	cfg := &config.Config{CodeRoot: t.TempDir(), RepoLayout: model.LayoutFlat}
+	result, err := CreateWorkspace(cfg, src, []string{roots["api"], roots["web"]}, ImportOptions{
+		Owner:     "acme",
+		Project:   "platform",
+		RepoPaths: map[string]string{roots["web"]: "services/web"},
+	})
+	if err != nil || len(result.Errors) > 0 {
+		t.Fatalf("CreateWorkspace() = %+v, %v", result, err)
+	}
+	// Adding to the workspace follows the layout it records
+	if _, err := AddToWorkspace(cfg, src, []string{roots["cli"]}, result.WorkspaceSlug, ImportOptions{Layout: model.LayoutNested}); err != nil {
+		t.Fatalf("AddToWorkspace() error = %v", err)
+	}
+
+	for _, dir := range []string{"api", "services/web", "cli"} {
+		if _, err := os.Stat(filepath.Join(result.WorkspacePath, dir, ".git")); err != nil {
+			t.Errorf("repo missing at %s: %v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(result.WorkspacePath, "repos")); err == nil {
+		t.Error("a flat workspace should not get a repos dir")
+	}
+	proj, err := model.LoadProject(filepath.Join(result.WorkspacePath, "project.json"))
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if proj.RepoLayout != model.LayoutFlat {
+		t.Errorf("RepoLayout = %q, want flat", proj.RepoLayout)
+	}
+	paths := make(map[string]string)
+	for _, r := range proj.Repos {
+		paths[r.Name] = r.Path
+	}
+	if paths["api"] != "api" || paths["web"] != "services/web" || paths["cli"] != "cli" {
+		t.Errorf("repo paths = %v", paths)
+	}
+
+	if _, err := CreateWorkspace(cfg, src, nil, ImportOptions{Owner: "acme", Project: "other", Layout: "deep"}); err == nil {
+		t.Error("CreateWorkspace() accepted an unknown layout")
+	}
+}
+
+func TestFindDuplicateExtras(t *testing.T) {
+	src := t.TempDir()
+	for path, content := range map[string]string{
+		"api/.git/config":           "[core]",
+		"api/.editorconfig":         "root = true\n",
+		"api/config/app.yaml":       "port: 8080\n",
+		".editorconfig":             "root = true\n",
+		"app.yaml":                  "port: 8080\n",
+		"notes.md":                  "port: 9090\n", // Same size, other contents
+		"empty.txt":                 "",
+		"docs/readme.md":            "root = true\n", // Inside a selected folder
+		"api/.git/objects/ab/cdef0": "[core]",
+	} {
+		full := filepath.Join(src, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dups, err := FindDuplicateExtras(src, []string{"app.yaml", ".editorconfig", "notes.md", "empty.txt", "docs"}, []string{filepath.Join(src, "api")})
+	if err != nil {
+		t.Fatalf("FindDuplicateExtras() error = %v", err)
+	}
+	want := []ExtraDuplicate{
+		{Path: "app.yaml", Same: filepath.Join("api", "config", "app.yaml"), Size: 11},
+		{Path: ".editorconfig", Same: filepath.Join("api", ".editorconfig"), Size: 12},
+	}
+	if !reflect.DeepEqual(dups, want) {
+		t.Errorf("FindDuplicateExtras() = %+v, want %+v", dups, want)
+	}
+}
+
+func TestSetDescription(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	workspacePath := cfg.WorkspacePath("acme--svc2")
+	if err := os.MkdirAll(workspacePath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.NewProject("acme--svc2", "acme", "svc2").Save(workspacePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.IndexPath()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	idx := model.NewIndex()
+	idx.Add(model.NewIndexRecord("acme--svc2", workspacePath))
+	if err := idx.Save(cfg.IndexPath()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetDescription(cfg, "acme--svc2", "  Billing API for the EU shop \n"); err != nil {
+		t.Fatalf("SetDescription() error = %v", err)
+	}
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil || proj.Description != "Billing API for the EU shop" {
+		t.Errorf("project description = %q, %v", proj.Description, err)
+	}
+	idx, err = model.LoadIndex(cfg.IndexPath())
+	if err != nil || idx.FindBySlug("acme--svc2").Description != "Billing API for the EU shop" {
+		t.Errorf("index record not updated: %v", err)
+	}
+
+	if err := SetDescription(cfg, "acme--nope", "x"); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Errorf("SetDescription() of a missing workspace error = %v", err)
+	}
+
+	if err := SetIcon(cfg, "acme--svc2", "💶"); err != nil {
+		t.Fatalf("SetIcon() error = %v", err)
+	}
+	if err := SetDisplayName(cfg, "acme--svc2", " Billing API "); err != nil {
+		t.Fatalf("SetDisplayName() error = %v", err)
+	}
+	idx, err = model.LoadIndex(cfg.IndexPath())
+	if record := idx.FindBySlug("acme--svc2"); err != nil || record.Label() != "💶 Billing API" || record.Description == "" {
+		t.Errorf("index record = %+v, %v; want the icon and name with the description kept", record, err)
+	}
+	if err := SetIcon(cfg, "acme--svc2", "money bag"); err == nil {
+		t.Error("SetIcon() with spaces succeeded")
+	}
+	if err := SetDisplayName(cfg, "acme--svc2", "Billing\nAPI"); err == nil {
+		t.Error("SetDisplayName() with a newline succeeded")
+	}
+}
@@ -0,0 +1,254 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// Layout identifies an existing directory layout that can be migrated.
+type Layout string
+
+const (
+	LayoutGhq    Layout = "ghq"    // <root>/<host>/<owner>/<repo>
+	LayoutGopath Layout = "gopath" // $GOPATH/src/<host>/<owner>/<repo>
+	LayoutFlat   Layout = "flat"   // <root>/<repo>, e.g. ~/projects or ~/src
+)
+
+// Layouts lists the supported migration layouts.
+var Layouts = []Layout{LayoutGhq, LayoutGopath, LayoutFlat}
+
+// ParseLayout validates a layout name.
+func ParseLayout(s string) (Layout, error) {
+	for _, l := range Layouts {
+		if string(l) == s {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown layout %q (expected ghq, gopath, or flat)", s)
+}
+
+// DefaultLayoutRoot returns the conventional root for a layout, or "" if the
+// layout has none and the root must be given explicitly.
+func DefaultLayoutRoot(layout Layout) string {
+	home, _ := os.UserHomeDir()
+	switch layout {
+	case LayoutGhq:
+		if root := os.Getenv("GHQ_ROOT"); root != "" {
+			return strings.Split(root, string(os.PathListSeparator))[0]
+		}
+		return filepath.Join(home, "ghq")
+	case LayoutGopath:
+		if gopath := os.Getenv("GOPATH"); gopath != "" {
+			return filepath.Join(strings.Split(gopath, string(os.PathListSeparator))[0], "src")
+		}
+		return filepath.Join(home, "go", "src")
+	default:
+		return ""
+	}
+}
+
+// MigrationItem is one repository in a migration plan.
+type MigrationItem struct {
+	Source  string `json:"source"`
+	Owner   string `json:"owner,omitempty"`
+	Project string `json:"project,omitempty"`
+	Slug    string `json:"slug,omitempty"`
+	Remote  string `json:"remote,omitempty"`
+	Skip    string `json:"skip,omitempty"` // Reason the item will not be migrated
+}
+
+// PlanMigration discovers the repositories of a layout under root and maps each
+// to an owner--project slug. Owners come from the origin remote URL, then from
+// the layout path (host/owner/repo), then from defaultOwner. Items that cannot
+// be migrated are kept in the plan with Skip set so previews can show them.
+func PlanMigration(cfg *config.Config, layout Layout, root, defaultOwner string) ([]MigrationItem, error) {
+	root = filepath.Clean(root)
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %s: %w", root, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", root)
+	}
+
+	depth := 1
+	if layout != LayoutFlat {
+		depth = -1
+	}
+	roots, err := git.FindGitRootsWithDepth(root, depth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	roots = outermostRoots(roots)
+
+	codeRoot := filepath.Clean(cfg.CodeRoot)
+	scheme := SchemeFor(cfg)
+	planned := make(map[string]bool)
+	var items []MigrationItem
+
+	for _, repo := range roots {
+		if repo == root {
+			continue
+		}
+		item := MigrationItem{Source: repo}
+		item.Remote, _ = git.RemoteURL(repo)
+
+		owner, project := "", filepath.Base(repo)
+		if remoteOwner, remoteProject, ok := ParseRemoteURL(item.Remote); ok {
+			owner, project = remoteOwner, remoteProject
+		} else if layout != LayoutFlat {
+			if rel, err := filepath.Rel(root, repo); err == nil {
+				if parts := strings.Split(rel, string(filepath.Separator)); len(parts) >= 3 {
+					owner = parts[1]
+				}
+			}
+		}
+		if owner == "" {
+			owner = defaultOwner
+		}
+		item.Owner = scheme.Sanitize(owner)
+		item.Project = scheme.Sanitize(project)
+		item.Slug = scheme.Format(item.Owner, item.Project)
+
+		switch {
+		case isWithin(repo, codeRoot):
+			item.Skip = "already under the code root"
+		case item.Owner == "":
+			item.Skip = "no owner (no remote; use --owner)"
+		case !scheme.Valid(item.Slug):
+			item.Skip = "invalid slug"
+		case Exists(cfg, item.Slug):
+			item.Skip = "workspace already exists"
+		case planned[item.Slug]:
+			item.Skip = "slug used by another repo in this migration"
+		default:
+			planned[item.Slug] = true
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Slug < items[j].Slug })
+	return items, nil
+}
+
+// MigrationResult is the outcome of migrating one plan item.
+type MigrationResult struct {
+	Item  MigrationItem `json:"item"`
+	Path  string        `json:"path,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// MigrationPlan describes the moves ExecuteMigration would make for items.
+func MigrationPlan(cfg *config.Config, layout Layout, items []MigrationItem) *model.Plan {
+	plan := model.NewPlan(fmt.Sprintf("Migrate %s layout into %s", layout, cfg.CodeRoot))
+	for _, item := range items {
+		if item.Skip != "" {
+			plan.Skip(model.ActionMove, item.Source, "", item.Skip)
+			continue
+		}
+		dest := filepath.Join(cfg.WorkspacePath(item.Slug), model.RepoDir(cfg.GetRepoLayout(item.Owner), DeriveRepoName(item.Source, item.Source)))
+		plan.Add(model.ActionMove, item.Source, dest, item.Slug)
+	}
+	return plan
+}
+
+// ExecuteMigration moves each non-skipped item into a new workspace. Directories
+// left empty under root by the moves are removed. onItem, if set, is called
+// after each item.
+func ExecuteMigration(cfg *config.Config, root string, items []MigrationItem, onItem func(MigrationResult)) []MigrationResult {
+	var results []MigrationResult
+	for _, item := range items {
+		if item.Skip != "" {
+			continue
+		}
+		r := MigrationResult{Item: item}
+		res, err := CreateWorkspace(cfg, item.Source, []string{item.Source}, ImportOptions{
+			Owner:   item.Owner,
+			Project: item.Project,
+		})
+		switch {
+		case err != nil:
+			r.Error = err.Error()
+		case len(res.Errors) > 0:
+			r.Path = res.WorkspacePath
+			r.Error = strings.Join(res.Errors, "; ")
+		default:
+			r.Path = res.WorkspacePath
+			pruneEmptyParents(filepath.Dir(item.Source), root)
+		}
+		results = append(results, r)
+		if onItem != nil {
+			onItem(r)
+		}
+	}
+	return results
+}
+
+// ParseRemoteURL extracts owner and repository name from a git remote URL
+// such as git@github.com:acme/api.git or https://gitlab.com/acme/sub/api.
+// For nested groups the top-level group is the owner.
+func ParseRemoteURL(remote string) (owner, project string, ok bool) {
+	remote = strings.TrimSpace(remote)
+	if remote == "" {
+		return "", "", false
+	}
+
+	var path string
+	if i := strings.Index(remote, "://"); i >= 0 {
+		rest := remote[i+3:]
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			return "", "", false
+		}
+		path = rest[slash+1:]
+	} else if i := strings.Index(remote, ":"); i >= 0 && !strings.HasPrefix(remote, "/") {
+		path = remote[i+1:]
+	} else {
+		return "", "", false
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[0] == "" || parts[len(parts)-1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[len(parts)-1], true
+}
+
+// outermostRoots drops repositories nested inside another repository; they
+// move along with their parent.
+func outermostRoots(roots []string) []string {
+	sorted := append([]string(nil), roots...)
+	sort.Strings(sorted)
+	var result []string
+	for _, r := range sorted {
+		if len(result) > 0 && isWithin(r, result[len(result)-1]) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// isWithin reports whether path is dir or inside it.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// pruneEmptyParents removes empty directories from dir up to, but not including, root.
+func pruneEmptyParents(dir, root string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && isWithin(dir, root); dir = filepath.Dir(dir) {
+		if !RemoveEmptySource(dir) {
+			return
+		}
+	}
+}
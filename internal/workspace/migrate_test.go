@@ -0,0 +1,153 @@
+package workspace
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote, owner, project string
+		ok                     bool
+	}{
+		{"git@github.com:acme/api.git", "acme", "api", true},
+		{"https://github.com/acme/api", "acme", "api", true},
+		{"https://github.com/acme/api.git/", "acme", "api", true},
+		{"ssh://git@gitlab.com:2222/group/sub/tool.git", "group", "tool", true},
+		{"https://github.com/acme", "", "", false},
+		{"/srv/git/api.git", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, project, ok := ParseRemoteURL(tt.remote)
+		if owner != tt.owner || project != tt.project || ok != tt.ok {
+			t.Errorf("ParseRemoteURL(%q) = %q, %q, %v; want %q, %q, %v", tt.remote, owner, project, ok, tt.owner, tt.project, tt.ok)
+		}
+	}
+}
+
+func initRepo(t *testing.T, path, remote string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	run := func(args ...string) {
+		if out, err := exec.Command("git", append([]string{"-C", path}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	if remote != "" {
+		run("remote", "add", "origin", remote)
+	}
+}
+
+func TestMigrateGhqLayout(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	root := t.TempDir()
+	initRepo(t, filepath.Join(root, "github.com", "acme", "api"), "git@github.com:acme/api.git")
+	initRepo(t, filepath.Join(root, "github.com", "Solo_Dev", "tool"), "")
+	initRepo(t, filepath.Join(root, "gitlab.com", "acme", "api"), "https://gitlab.com/acme/api.git")
+	initRepo(t, filepath.Join(root, "github.com", "acme", "api", "vendor-lib"), "")
+
+	items, err := PlanMigration(cfg, LayoutGhq, root, "")
+	if err != nil {
+		t.Fatalf("PlanMigration: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3 (nested repo folded into parent): %+v", len(items), items)
+	}
+
+	bySource := make(map[string]MigrationItem)
+	for _, item := range items {
+		bySource[item.Source] = item
+	}
+	api := bySource[filepath.Join(root, "github.com", "acme", "api")]
+	dup := bySource[filepath.Join(root, "gitlab.com", "acme", "api")]
+	tool := bySource[filepath.Join(root, "github.com", "Solo_Dev", "tool")]
+
+	if tool.Slug != "solo-dev--tool" || tool.Skip != "" {
+		t.Errorf("tool item = %+v, want solo-dev--tool from the path owner", tool)
+	}
+	// Exactly one of the two acme--api repos is migrated
+	if api.Slug != "acme--api" || dup.Slug != "acme--api" || (api.Skip == "") == (dup.Skip == "") {
+		t.Errorf("acme--api items = %+v / %+v, want one migrated and one skipped", api, dup)
+	}
+
+	results := ExecuteMigration(cfg, root, items, nil)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			t.Errorf("%s failed: %s", r.Item.Slug, r.Error)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.CodeRoot, "solo-dev--tool", "repos", "tool", ".git")); err != nil {
+		t.Errorf("tool repo not moved into workspace: %v", err)
+	}
+	// The emptied owner directory is pruned, the root is kept
+	if _, err := os.Stat(filepath.Join(root, "github.com", "Solo_Dev")); !os.IsNotExist(err) {
+		t.Errorf("empty owner directory should be removed")
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("layout root should be kept: %v", err)
+	}
+
+	// A second plan sees the existing workspaces
+	again, err := PlanMigration(cfg, LayoutGhq, root, "")
+	if err != nil {
+		t.Fatalf("PlanMigration: %v", err)
+	}
+	for _, item := range again {
+		if item.Skip == "" {
+			t.Errorf("item %s should be skipped after migration", item.Slug)
+		}
+	}
+}
+
+func TestMigrateFlatLayoutNeedsOwner(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	root := t.TempDir()
+	initRepo(t, filepath.Join(root, "my_project"), "")
+	initRepo(t, filepath.Join(root, "remote-one"), "https://github.com/acme/remote-one")
+
+	items, err := PlanMigration(cfg, LayoutFlat, root, "")
+	if err != nil {
+		t.Fatalf("PlanMigration: %v", err)
+	}
+	if len(items) != 2 || items[0].Skip == "" || items[1].Slug != "acme--remote-one" {
+		t.Errorf("items = %+v, want my_project skipped for missing owner and acme--remote-one planned", items)
+	}
+
+	plan := MigrationPlan(cfg, LayoutFlat, items)
+	if len(plan.Actions) != 1 || len(plan.Skipped) != 1 {
+		t.Fatalf("plan = %+v, want one move and one skip", plan)
+	}
+	wantDest := filepath.Join(cfg.CodeRoot, "acme--remote-one", "repos", "remote-one")
+	if plan.Actions[0].Target != wantDest {
+		t.Errorf("move target = %q, want %q", plan.Actions[0].Target, wantDest)
+	}
+
+	items, _ = PlanMigration(cfg, LayoutFlat, root, "personal")
+	for _, item := range items {
+		if item.Skip != "" {
+			t.Errorf("item %+v should not be skipped with --owner", item)
+		}
+		if item.Source == filepath.Join(root, "my_project") && item.Slug != "personal--my-project" {
+			t.Errorf("my_project slug = %q, want personal--my-project", item.Slug)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// PolicyError reports a workspace that violates its owner's policy.
+type PolicyError struct {
+	Owner  string
+	Rule   string // Config key of the rule, e.g. "project_pattern"
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("owner %s policy: %s: %s", e.Owner, e.Rule, e.Reason)
+}
+
+// Events passed to the registered event hook.
+const (
+	EventCreate  = "create"
+	EventImport  = "import"
+	EventArchive = "archive"
+)
+
+// Rules are user-defined checks and hooks, such as those of the co script,
+// consulted alongside the owner policies. Either function may be nil.
+type Rules struct {
+	// CheckName returns an error when owner/project is not an allowed name.
+	CheckName func(cfg *config.Config, owner, project string) error
+
+	// OnEvent runs when a workspace is created, imported, or archived, before
+	// its project.json is written or archived. It may change proj.
+	OnEvent func(cfg *config.Config, event string, proj *model.Project, path string) error
+}
+
+var rules Rules
+
+// RegisterRules registers the user-defined rules. It is set by the CLI to
+// avoid an import cycle with the script package.
+func RegisterRules(r Rules) {
+	rules = r
+}
+
+// RunEventHook runs the registered event hook for proj at path. Callers
+// report its error as a warning.
+func RunEventHook(cfg *config.Config, event string, proj *model.Project, path string) error {
+	if rules.OnEvent == nil {
+		return nil
+	}
+	return rules.OnEvent(cfg, event, proj, path)
+}
+
+// CheckOwnerPolicy returns a *PolicyError when a workspace for owner and
+// project would break the owner's policy in the config or a registered
+// naming rule.
+func CheckOwnerPolicy(cfg *config.Config, owner, project string) error {
+	policy := cfg.GetOwnerPolicy(owner)
+	if policy.ProjectPattern != "" {
+		re, err := regexp.Compile("^(?:" + policy.ProjectPattern + ")$")
+		if err != nil {
+			return &PolicyError{Owner: owner, Rule: "project_pattern", Reason: fmt.Sprintf("invalid pattern: %v", err)}
+		}
+		if !re.MatchString(project) {
+			return &PolicyError{
+				Owner:  owner,
+				Rule:   "project_pattern",
+				Reason: fmt.Sprintf("project name %q does not match %s", project, policy.ProjectPattern),
+			}
+		}
+	}
+	if rules.CheckName != nil {
+		if err := rules.CheckName(cfg, owner, project); err != nil {
+			return &PolicyError{Owner: owner, Rule: "script", Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+// PolicyViolation is a repo breaking a rule of the repo policy.
+type PolicyViolation struct {
+	Repo   string `json:"repo"`
+	Rule   string `json:"rule"` // Config key of the rule, e.g. "branch_pattern"
+	Detail string `json:"detail"`
+}
+
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Repo, v.Rule, v.Detail)
+}
+
+// CheckRepoPolicy checks the git repos of the workspace at workspacePath,
+// those in proj and any others under repos/, against the repo policy of
+// proj's owner. Violations are sorted by repo. An invalid pattern in the
+// policy is an error.
+func CheckRepoPolicy(cfg *config.Config, workspacePath string, proj *model.Project) ([]PolicyViolation, error) {
+	policy := cfg.GetRepoPolicy(proj.Owner)
+	if policy.Empty() {
+		return nil, nil
+	}
+	var branchRe, commitRe *regexp.Regexp
+	var err error
+	if policy.BranchPattern != "" {
+		if branchRe, err = regexp.Compile("^(?:" + policy.BranchPattern + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid branch_pattern: %w", err)
+		}
+	}
+	if policy.CommitPattern != "" {
+		if commitRe, err = regexp.Compile(policy.CommitPattern); err != nil {
+			return nil, fmt.Errorf("invalid commit_pattern: %w", err)
+		}
+	}
+
+	dirs, err := repoDirs(workspacePath, proj)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(dirs))
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []PolicyViolation
+	for _, name := range names {
+		if !git.IsRepo(dirs[name]) {
+			continue
+		}
+		for _, v := range checkRepo(dirs[name], policy, branchRe, commitRe) {
+			v.Repo = name
+			violations = append(violations, v)
+		}
+	}
+	return violations, nil
+}
+
+func checkRepo(repoPath string, policy config.RepoPolicy, branchRe, commitRe *regexp.Regexp) []PolicyViolation {
+	var violations []PolicyViolation
+	add := func(rule, format string, args ...any) {
+		violations = append(violations, PolicyViolation{Rule: rule, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	for _, required := range policy.RequiredFiles {
+		found := false
+		for _, alt := range strings.Split(required, "|") {
+			if _, err := os.Stat(filepath.Join(repoPath, strings.TrimSpace(alt))); err == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("required_files", "missing %s", strings.ReplaceAll(required, "|", " or "))
+		}
+	}
+
+	defaultBranch := git.DefaultBranch(repoPath)
+	if policy.DefaultBranch != "" && defaultBranch != "" && defaultBranch != policy.DefaultBranch {
+		add("default_branch", "default branch is %s, not %s", defaultBranch, policy.DefaultBranch)
+	}
+
+	if branchRe != nil || policy.ProtectDefaultBranch {
+		branches, _ := git.ListBranches(repoPath)
+		remotes, _ := git.ListRemotes(repoPath)
+		hasRemote := len(remotes) > 0
+		for _, b := range branches {
+			if b.Name == defaultBranch {
+				// Without a remote there is nothing to push to, so nothing to protect
+				if policy.ProtectDefaultBranch && hasRemote && b.Ahead > 0 {
+					add("protect_default_branch", "%d commit(s) on %s not on the remote", b.Ahead, b.Name)
+				}
+				continue
+			}
+			if branchRe != nil && !branchRe.MatchString(b.Name) {
+				add("branch_pattern", "branch %q does not match %s", b.Name, policy.BranchPattern)
+			}
+		}
+	}
+
+	if commitRe != nil {
+		subjects, _ := git.RecentSubjects(repoPath, policy.CommitDepth)
+		for _, subject := range subjects {
+			if !commitRe.MatchString(subject) {
+				add("commit_pattern", "commit %q does not match %s", subject, policy.CommitPattern)
+			}
+		}
+	}
+	return violations
+}
+
+// ApplyOwnerDefaults adds the tags of the owner's policy to proj.
+func ApplyOwnerDefaults(cfg *config.Config, proj *model.Project) {
+	policy := cfg.GetOwnerPolicy(proj.Owner)
+	for _, tag := range policy.Tags {
+		if !hasTag(proj.Tags, tag) {
+			proj.Tags = append(proj.Tags, tag)
+		}
+	}
+}
+
+// DefaultTemplate returns the template the owner's policy applies when none
+// is given, or "".
+func DefaultTemplate(cfg *config.Config, owner string) string {
+	return cfg.GetOwnerPolicy(owner).Template
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func policyConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Owners = map[string]config.OwnerPolicy{
+		"Acme": {
+			Template:       "go-service",
+			Tags:           []string{"work", "acme"},
+			ProjectPattern: "svc-[a-z0-9-]+",
+		},
+	}
+	return cfg
+}
+
+func TestCheckOwnerPolicy(t *testing.T) {
+	cfg := policyConfig()
+
+	tests := []struct {
+		owner, project string
+		wantErr        bool
+	}{
+		{"acme", "svc-billing", false},
+		{"acme", "billing", true},
+		{"acme", "svc-billing-old!", true},
+		{"acme", "my-svc-billing", true}, // The pattern is anchored
+		{"other", "anything", false},
+	}
+	for _, tt := range tests {
+		err := CheckOwnerPolicy(cfg, tt.owner, tt.project)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckOwnerPolicy(%q, %q) = %v, wantErr %v", tt.owner, tt.project, err, tt.wantErr)
+			continue
+		}
+		var policyErr *PolicyError
+		if err != nil && (!errors.As(err, &policyErr) || policyErr.Rule != "project_pattern") {
+			t.Errorf("CheckOwnerPolicy(%q, %q) = %v, want a project_pattern *PolicyError", tt.owner, tt.project, err)
+		}
+	}
+}
+
+func TestCheckOwnerPolicyInvalidPattern(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Owners = map[string]config.OwnerPolicy{"acme": {ProjectPattern: "svc-("}}
+
+	if err := CheckOwnerPolicy(cfg, "acme", "svc-a"); err == nil {
+		t.Error("CheckOwnerPolicy() with an invalid pattern should fail")
+	}
+}
+
+func TestApplyOwnerDefaults(t *testing.T) {
+	cfg := policyConfig()
+
+	proj := model.NewProject("acme--svc-billing", "acme", "svc-billing")
+	proj.Tags = []string{"acme"}
+	ApplyOwnerDefaults(cfg, proj)
+	if want := []string{"acme", "work"}; !reflect.DeepEqual(proj.Tags, want) {
+		t.Errorf("Tags = %v, want %v", proj.Tags, want)
+	}
+
+	if got := DefaultTemplate(cfg, "acme"); got != "go-service" {
+		t.Errorf("DefaultTemplate(acme) = %q, want go-service", got)
+	}
+	if got := DefaultTemplate(cfg, "other"); got != "" {
+		t.Errorf("DefaultTemplate(other) = %q, want empty", got)
+	}
+}
+
+func TestCheckOwnerPolicyRules(t *testing.T) {
+	RegisterRules(Rules{CheckName: func(cfg *config.Config, owner, project string) error {
+		if project == "forbidden" {
+			return errors.New("not allowed")
+		}
+		return nil
+	}})
+	defer RegisterRules(Rules{})
+
+	cfg := config.DefaultConfig()
+	if err := CheckOwnerPolicy(cfg, "acme", "app"); err != nil {
+		t.Errorf("CheckOwnerPolicy(acme, app) = %v", err)
+	}
+	var policyErr *PolicyError
+	if err := CheckOwnerPolicy(cfg, "acme", "forbidden"); !errors.As(err, &policyErr) || policyErr.Rule != "script" {
+		t.Errorf("CheckOwnerPolicy(acme, forbidden) = %v, want a script *PolicyError", err)
+	}
+}
+
+func TestCheckRepoPolicy(t *testing.T) {
+	for _, kv := range []string{"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	ws := t.TempDir()
+	remote := filepath.Join(t.TempDir(), "api.git")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(dir, file, msg string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(msg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		git(dir, "add", ".")
+		git(dir, "commit", "-q", "-m", msg)
+	}
+
+	api := filepath.Join(ws, "repos", "api")
+	git(t.TempDir(), "init", "-q", "--bare", remote)
+	git(t.TempDir(), "init", "-q", "-b", "main", api)
+	commit(api, "LICENSE", "feat: initial")
+	git(api, "remote", "add", "origin", remote)
+	git(api, "push", "-q", "-u", "origin", "main")
+	commit(api, "main.go", "wip")
+	git(api, "branch", "feature/login")
+	git(api, "branch", "Bad_Branch")
+
+	web := filepath.Join(ws, "repos", "web")
+	git(t.TempDir(), "init", "-q", "-b", "master", web)
+	commit(web, "CODEOWNERS", "fix: owners")
+
+	cfg := &config.Config{
+		RepoPolicy: &config.RepoPolicy{
+			BranchPattern:        "(feature|fix)/[a-z0-9-]+",
+			CommitPattern:        `^(feat|fix|chore)(\([a-z]+\))?: `,
+			RequiredFiles:        []string{"LICENSE"},
+			DefaultBranch:        "main",
+			ProtectDefaultBranch: true,
+		},
+		Owners: map[string]config.OwnerPolicy{
+			"acme": {RepoPolicy: &config.RepoPolicy{RequiredFiles: []string{"LICENSE", "CODEOWNERS|.github/CODEOWNERS"}}},
+		},
+	}
+	proj := model.NewProject("acme--platform", "acme", "platform")
+
+	got, err := CheckRepoPolicy(cfg, ws, proj)
+	if err != nil {
+		t.Fatalf("CheckRepoPolicy: %v", err)
+	}
+	var rules []string
+	for _, v := range got {
+		rules = append(rules, v.Repo+" "+v.Rule)
+	}
+	want := []string{
+		"api required_files",
+		"api branch_pattern",
+		"api protect_default_branch",
+		"api commit_pattern",
+		"web required_files",
+		"web default_branch",
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+
+	cfg.RepoPolicy.BranchPattern = "("
+	if _, err := CheckRepoPolicy(cfg, ws, proj); err == nil {
+		t.Error("CheckRepoPolicy with an invalid pattern should fail")
+	}
+	if got, _ := CheckRepoPolicy(&config.Config{}, ws, proj); len(got) != 0 {
+		t.Errorf("CheckRepoPolicy without a policy = %v, want none", got)
+	}
+}
@@ -0,0 +1,180 @@
+package workspace
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// The generated part of a workspace index file sits between these markers,
+// so the rest of the file (e.g. a hand-written README.md) is kept on update.
+const (
+	indexStartMarker = "<!-- co:index:start -->"
+	indexEndMarker   = "<!-- co:index:end -->"
+)
+
+// maxRepoDescription caps the description taken from a repo's README.
+const maxRepoDescription = 160
+
+// WriteIndex generates the workspace index file (workspace_index.file,
+// INDEX.md by default) listing the workspace's repos with descriptions from
+// their READMEs, the template it was created from, and its tags. It returns
+// the path written.
+func WriteIndex(cfg *config.Config, workspacePath string) (string, error) {
+	proj, err := model.LoadProject(filepath.Join(workspacePath, "project.json"))
+	if err != nil {
+		return "", fmt.Errorf("failed to load project.json: %w", err)
+	}
+	repos, err := proj.LocateRepos(workspacePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to list repos: %w", err)
+	}
+
+	block := renderIndex(proj, workspacePath, repos)
+	path := filepath.Join(workspacePath, cfg.GetWorkspaceIndexConfig().File)
+
+	var content string
+	existing, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		content = fmt.Sprintf("# %s\n\n%s", proj.Slug, block)
+	case err != nil:
+		return "", err
+	default:
+		content = spliceIndex(string(existing), block)
+	}
+
+	if err := os.WriteFile(path, []byte(content), fs.FilePerm()); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// RefreshIndex regenerates the workspace index file unless
+// workspace_index.manual is set. Import and add-to call it when they finish.
+func RefreshIndex(cfg *config.Config, workspacePath string) error {
+	if cfg.GetWorkspaceIndexConfig().Manual {
+		return nil
+	}
+	_, err := WriteIndex(cfg, workspacePath)
+	return err
+}
+
+// spliceIndex replaces the generated block in content, or appends it when
+// content has none.
+func spliceIndex(content, block string) string {
+	start := strings.Index(content, indexStartMarker)
+	end := strings.Index(content, indexEndMarker)
+	if start >= 0 && end > start {
+		rest := strings.TrimPrefix(content[end+len(indexEndMarker):], "\n")
+		return content[:start] + block + rest
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n" + block
+}
+
+func renderIndex(proj *model.Project, workspacePath string, repos []model.RepoSpec) string {
+	var sb strings.Builder
+	sb.WriteString(indexStartMarker + "\n")
+
+	if proj.Template != "" {
+		sb.WriteString(fmt.Sprintf("**Template:** %s  \n", proj.Template))
+	}
+	if len(proj.Tags) > 0 {
+		sb.WriteString(fmt.Sprintf("**Tags:** %s  \n", strings.Join(proj.Tags, ", ")))
+	}
+	if proj.State != "" {
+		sb.WriteString(fmt.Sprintf("**State:** %s\n", proj.State))
+	}
+
+	sb.WriteString("\n## Repositories\n\n")
+	if len(repos) == 0 {
+		sb.WriteString("No repositories yet.\n")
+	}
+	for _, repo := range repos {
+		line := fmt.Sprintf("- [%s](%s)", repo.Name, repo.Path)
+		if desc := repoDescription(filepath.Join(workspacePath, filepath.FromSlash(repo.Path))); desc != "" {
+			line += " — " + desc
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	sb.WriteString("\n_Generated by `co index`; edits between the co:index markers are overwritten._\n")
+	sb.WriteString(indexEndMarker + "\n")
+	return sb.String()
+}
+
+// repoDescription returns the first paragraph of prose in the repo's README,
+// skipping headings, badges, and HTML, or "" when there is none.
+func repoDescription(repoPath string) string {
+	entries, err := os.ReadDir(repoPath)
+	if err != nil {
+		return ""
+	}
+	var readme string
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if !entry.IsDir() && (name == "readme" || strings.HasPrefix(name, "readme.")) {
+			readme = filepath.Join(repoPath, entry.Name())
+			break
+		}
+	}
+	if readme == "" {
+		return ""
+	}
+
+	f, err := os.Open(readme)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var para []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			if len(para) > 0 {
+				break
+			}
+			continue
+		}
+		if isReadmeDecoration(line) {
+			// An underline turns the paragraph above it into a heading
+			if strings.HasPrefix(line, "===") || strings.HasPrefix(line, "---") {
+				para = nil
+				continue
+			}
+			if len(para) > 0 {
+				break
+			}
+			continue
+		}
+		para = append(para, line)
+	}
+
+	desc := strings.Join(para, " ")
+	if runes := []rune(desc); len(runes) > maxRepoDescription {
+		desc = strings.TrimSpace(string(runes[:maxRepoDescription-1])) + "…"
+	}
+	return desc
+}
+
+// isReadmeDecoration reports whether a README line is a heading, underline,
+// badge, image, HTML tag, or directive rather than prose.
+func isReadmeDecoration(line string) bool {
+	for _, prefix := range []string{"#", "![", "[![", "<", "..", "```", "---", "===", "***"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
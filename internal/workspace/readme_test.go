@@ -0,0 +1,84 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestWriteIndex(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	ws := cfg.WorkspacePath("acme--app")
+	readmes := map[string]string{
+		"api": "# API\n\n[![build](badge.svg)](ci)\n\nREST backend\nfor the app.\n\nMore details.\n",
+		"web": "Web\n===\n\nReact frontend.\n",
+		"ops": "",
+	}
+	for name, readme := range readmes {
+		dir := filepath.Join(ws, "repos", name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if readme != "" {
+			if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(readme), 0o644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+		}
+	}
+	proj := model.NewProject("acme--app", "acme", "app")
+	proj.Template = "fullstack"
+	proj.Tags = []string{"web", "api"}
+	if err := proj.Save(ws); err != nil {
+		t.Fatalf("save project: %v", err)
+	}
+
+	path, err := WriteIndex(cfg, ws)
+	if err != nil {
+		t.Fatalf("WriteIndex() error = %v", err)
+	}
+	if filepath.Base(path) != "INDEX.md" {
+		t.Errorf("path = %s, want INDEX.md", path)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{
+		"# acme--app",
+		"**Template:** fullstack",
+		"**Tags:** web, api",
+		"- [api](repos/api) — REST backend for the app.\n",
+		"- [ops](repos/ops)\n",
+		"- [web](repos/web) — React frontend.\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("index missing %q:\n%s", want, data)
+		}
+	}
+
+	// A README.md keeps its own content; only the generated block is replaced
+	cfg.WSIndex = &config.WorkspaceIndexConfig{File: "README.md"}
+	readme := filepath.Join(ws, "README.md")
+	if err := os.WriteFile(readme, []byte("# App\n\nNotes."), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := WriteIndex(cfg, ws); err != nil {
+			t.Fatalf("WriteIndex(README.md) error = %v", err)
+		}
+	}
+	data, _ = os.ReadFile(readme)
+	if !strings.HasPrefix(string(data), "# App\n\nNotes.\n\n"+indexStartMarker) || strings.Count(string(data), indexStartMarker) != 1 {
+		t.Errorf("README.md =\n%s", data)
+	}
+
+	cfg.WSIndex.Manual = true
+	os.Remove(readme)
+	if err := RefreshIndex(cfg, ws); err != nil {
+		t.Fatalf("RefreshIndex() error = %v", err)
+	}
+	if _, err := os.Stat(readme); !os.IsNotExist(err) {
+		t.Error("RefreshIndex() wrote the file with manual set")
+	}
+}
@@ -0,0 +1,467 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/fs"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+// SlugScheme describes how workspace slugs are formatted, parsed, and laid
+// out on disk. All slug handling should go through a scheme obtained from
+// SchemeFor so the configured separator and layout are respected.
+type SlugScheme struct {
+	Separator string // Joins slug segments (default "--")
+	Category  bool   // Allow an optional leading org/category segment
+	Nested    bool   // Store workspaces as code_root/[category/]owner/project
+
+	Unicode      bool   // Keep non-ASCII letters and digits instead of transliterating
+	AllowedChars string // Extra ASCII punctuation allowed in segments
+}
+
+// Slug holds the segments of a parsed workspace slug.
+type Slug struct {
+	Category string `json:"category,omitempty"`
+	Owner    string `json:"owner"`
+	Project  string `json:"project"`
+}
+
+// DefaultSlugScheme returns the built-in owner--project scheme.
+func DefaultSlugScheme() SlugScheme {
+	return SlugScheme{Separator: config.DefaultSlugSeparator}
+}
+
+// SchemeFor returns the slug scheme configured for cfg, or the default scheme
+// when cfg is nil.
+func SchemeFor(cfg *config.Config) SlugScheme {
+	if cfg == nil {
+		return DefaultSlugScheme()
+	}
+	sc := cfg.GetSlugConfig()
+	return SlugScheme{
+		Separator:    sc.Separator,
+		Category:     sc.Category,
+		Nested:       sc.Nested,
+		Unicode:      sc.Unicode,
+		AllowedChars: sc.AllowedChars,
+	}
+}
+
+// Format builds a slug from owner and project.
+func (s SlugScheme) Format(owner, project string) string {
+	return s.FormatSlug(Slug{Owner: owner, Project: project})
+}
+
+// FormatSlug builds a slug from its segments. The category is only included
+// when the scheme allows it and it is non-empty.
+func (s SlugScheme) FormatSlug(slug Slug) string {
+	if s.Category && slug.Category != "" {
+		return slug.Category + s.Separator + slug.Owner + s.Separator + slug.Project
+	}
+	return slug.Owner + s.Separator + slug.Project
+}
+
+// Parse splits a slug into its segments. Without categories everything after
+// the first separator belongs to the project, matching legacy slugs such as
+// owner--project--poc. With categories, a slug has either two or three
+// segments.
+func (s SlugScheme) Parse(slug string) (Slug, bool) {
+	if !s.Category {
+		parts := strings.SplitN(slug, s.Separator, 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return Slug{}, false
+		}
+		return Slug{Owner: parts[0], Project: parts[1]}, true
+	}
+
+	parts := strings.Split(slug, s.Separator)
+	for _, p := range parts {
+		if p == "" {
+			return Slug{}, false
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return Slug{Owner: parts[0], Project: parts[1]}, true
+	case 3:
+		return Slug{Category: parts[0], Owner: parts[1], Project: parts[2]}, true
+	}
+	return Slug{}, false
+}
+
+// Valid reports whether slug is a well-formed workspace slug for the scheme.
+func (s SlugScheme) Valid(slug string) bool {
+	parsed, ok := s.Parse(slug)
+	if !ok {
+		return false
+	}
+	if parsed.Category != "" && !s.ValidPart(parsed.Category) {
+		return false
+	}
+	if !s.ValidPart(parsed.Owner) {
+		return false
+	}
+	if !s.Category {
+		// Legacy qualifiers (owner--project--poc) keep the separator in the project.
+		for _, p := range strings.Split(parsed.Project, s.Separator) {
+			if !s.ValidPart(p) {
+				return false
+			}
+		}
+		return true
+	}
+	return s.ValidPart(parsed.Project)
+}
+
+// ValidPart reports whether part can be used as a single slug segment:
+// lowercase letters, digits, hyphens, and any configured extra characters,
+// without the separator. "." and ".." are never valid, even when dots are
+// allowed, since they would name a directory outside the workspace.
+func (s SlugScheme) ValidPart(part string) bool {
+	if part == "" || part == "." || part == ".." || strings.Contains(part, s.Separator) {
+		return false
+	}
+	for _, c := range part {
+		if !s.allowedRune(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowedRune reports whether c may appear in a slug segment as-is.
+func (s SlugScheme) allowedRune(c rune) bool {
+	switch {
+	case (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-':
+		return true
+	case c < 0x80:
+		return strings.ContainsRune(s.AllowedChars, c)
+	case s.Unicode:
+		return (unicode.IsLetter(c) && unicode.ToLower(c) == c) || unicode.IsDigit(c) || unicode.IsMark(c)
+	}
+	return false
+}
+
+// Sanitize converts an arbitrary name (such as a folder name) into a slug
+// segment. Names are lowercased, accented Latin and Cyrillic letters are
+// transliterated unless the scheme keeps Unicode, underscores and whitespace
+// become hyphens, and anything else that is not allowed is dropped. A name
+// whose letters were all dropped, such as one in a script without
+// transliterations, becomes "u-" and a hash of the name, so it still has a
+// slug. The result is empty for names without letters or digits, including
+// names that reduce to "." or "..".
+func (s SlugScheme) Sanitize(name string) string {
+	var b strings.Builder
+	dropped := false // Letters or digits were left out
+	for _, c := range strings.ToLower(name) {
+		switch {
+		case s.allowedRune(c):
+			b.WriteRune(c)
+		case c == '_' || unicode.IsSpace(c):
+			b.WriteByte('-')
+		default:
+			if t, ok := transliterations[c]; ok {
+				b.WriteString(t)
+			} else if unicode.IsLetter(c) || unicode.IsDigit(c) {
+				dropped = true
+			}
+		}
+	}
+
+	out := b.String()
+	if s.Separator != "" && strings.Trim(s.Separator, "-") != "" {
+		out = strings.ReplaceAll(out, s.Separator, "-")
+	}
+	for strings.Contains(out, "--") {
+		out = strings.ReplaceAll(out, "--", "-")
+	}
+	out = strings.Trim(out, "-")
+	if out == "." || out == ".." {
+		out = ""
+	}
+	if out == "" && dropped {
+		h := fnv.New32a()
+		h.Write([]byte(name))
+		out = fmt.Sprintf("u-%08x", h.Sum32())
+	}
+	return out
+}
+
+// transliterations maps common non-ASCII lowercase letters to ASCII.
+var transliterations = func() map[rune]string {
+	groups := map[string]string{
+		"àáâãäåāăą":  "a",
+		"æ":          "ae",
+		"çćĉċč":      "c",
+		"ďđð":        "d",
+		"èéêëēĕėęě":  "e",
+		"ĝğġģ":       "g",
+		"ĥħ":         "h",
+		"ìíîïĩīĭįı":  "i",
+		"ĳ":          "ij",
+		"ĵ":          "j",
+		"ķ":          "k",
+		"ĺļľŀł":      "l",
+		"ñńņňŉ":      "n",
+		"òóôõöøōŏő":  "o",
+		"œ":          "oe",
+		"ŕŗř":        "r",
+		"śŝşšș":      "s",
+		"ß":          "ss",
+		"ţťŧț":       "t",
+		"þ":          "th",
+		"ùúûüũūŭůűų": "u",
+		"ŵ":          "w",
+		"ýÿŷ":        "y",
+		"źżž":        "z",
+
+		// Cyrillic
+		"а":   "a",
+		"б":   "b",
+		"в":   "v",
+		"г":   "g",
+		"ґ":   "g",
+		"д":   "d",
+		"еёэ": "e",
+		"є":   "ye",
+		"ж":   "zh",
+		"з":   "z",
+		"иі":  "i",
+		"ї":   "yi",
+		"йы":  "y",
+		"к":   "k",
+		"л":   "l",
+		"м":   "m",
+		"н":   "n",
+		"о":   "o",
+		"п":   "p",
+		"р":   "r",
+		"с":   "s",
+		"т":   "t",
+		"у":   "u",
+		"ф":   "f",
+		"х":   "kh",
+		"ц":   "ts",
+		"ч":   "ch",
+		"ш":   "sh",
+		"щ":   "shch",
+		"ю":   "yu",
+		"я":   "ya",
+	}
+	m := make(map[rune]string)
+	for letters, ascii := range groups {
+		for _, r := range letters {
+			m[r] = ascii
+		}
+	}
+	return m
+}()
+
+// Owner returns the owner segment of slug, or "" if it cannot be parsed.
+func (s SlugScheme) Owner(slug string) string {
+	parsed, _ := s.Parse(slug)
+	return parsed.Owner
+}
+
+// ErrWorkspaceExists is returned, wrapped with the slug, when a workspace to
+// create or rename to is already there.
+var ErrWorkspaceExists = errors.New("workspace already exists")
+
+// ErrWorkspaceNotFound is returned, wrapped with the slug, when there is no
+// workspace directory for a slug.
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// Exists reports whether the workspace directory for slug exists.
+func Exists(cfg *config.Config, slug string) bool {
+	info, err := os.Stat(cfg.WorkspacePath(slug))
+	return err == nil && info.IsDir()
+}
+
+// Containing returns the path of the workspace that dir is in, or "" when
+// dir is not inside a workspace under the code root. It walks up from dir to
+// the nearest folder with a project.json, so it works for nested layouts and
+// from deep inside repos.
+func Containing(cfg *config.Config, dir string) string {
+	root := filepath.Clean(cfg.CodeRoot)
+	dir = filepath.Clean(dir)
+	if rel, err := filepath.Rel(root, dir); err != nil || !filepath.IsLocal(rel) {
+		// The code root or dir may be reached through a symlink
+		realRoot, err1 := filepath.EvalSymlinks(root)
+		realDir, err2 := filepath.EvalSymlinks(dir)
+		if err1 != nil || err2 != nil {
+			return ""
+		}
+		if rel, err := filepath.Rel(realRoot, realDir); err != nil || !filepath.IsLocal(rel) {
+			return ""
+		}
+		root, dir = realRoot, realDir
+	}
+	for ; dir != root; dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == "_system" && filepath.Dir(dir) == root {
+			return ""
+		}
+		if fs.HasProjectJSON(dir) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// FreeProject returns project, or project with the first numeric suffix
+// ("api-2", "api-3", ...) for which nothing exists at the workspace path of
+// owner and the name.
+func FreeProject(cfg *config.Config, owner, project string) string {
+	scheme := SchemeFor(cfg)
+	name := project
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(cfg.WorkspacePath(scheme.Format(owner, name))); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", project, i)
+	}
+}
+
+// ListOwners returns the owners of all workspaces under the code root,
+// sorted and without duplicates. Tmp workspaces have no owner.
+func ListOwners(cfg *config.Config) ([]string, error) {
+	slugs, err := ListWorkspaces(cfg)
+	if err != nil {
+		return nil, err
+	}
+	scheme := SchemeFor(cfg)
+	var owners []string
+	for _, slug := range slugs {
+		if strings.HasPrefix(slug, "tmp--") {
+			continue
+		}
+		if owner := scheme.Owner(slug); owner != "" && !slices.Contains(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+	return owners, nil
+}
+
+// SuggestProjects returns free variants of a project name whose workspace
+// already exists: the first numeric suffix and the year, as in "api-2" and
+// "api-2026". It returns nil while the name itself is free.
+func SuggestProjects(cfg *config.Config, owner, project string, now time.Time) []string {
+	scheme := SchemeFor(cfg)
+	taken := func(name string) bool {
+		_, err := os.Lstat(cfg.WorkspacePath(scheme.Format(owner, name)))
+		return !os.IsNotExist(err)
+	}
+	if !taken(project) {
+		return nil
+	}
+	suggestions := []string{FreeProject(cfg, owner, project)}
+	if year := fmt.Sprintf("%s-%d", project, now.Year()); !taken(year) {
+		suggestions = append(suggestions, year)
+	}
+	return suggestions
+}
+
+// SimilarWorkspaces returns the slugs of owner's workspaces whose project
+// starts with project, or is the start of it, so near-duplicates show up
+// before a new workspace is created.
+func SimilarWorkspaces(cfg *config.Config, owner, project string) ([]string, error) {
+	slugs, err := ListWorkspaces(cfg)
+	if err != nil {
+		return nil, err
+	}
+	scheme := SchemeFor(cfg)
+	var similar []string
+	for _, slug := range slugs {
+		parsed, ok := scheme.Parse(slug)
+		if !ok || parsed.Owner != owner {
+			continue
+		}
+		if strings.HasPrefix(parsed.Project, project) || strings.HasPrefix(project, parsed.Project) {
+			similar = append(similar, slug)
+		}
+	}
+	return similar, nil
+}
+
+// ListWorkspaces returns the slugs of all workspaces under the code root,
+// sorted by name. Flat layouts list valid slug directories directly; nested
+// layouts walk owner (and category) directories.
+func ListWorkspaces(cfg *config.Config) ([]string, error) {
+	scheme := SchemeFor(cfg)
+	if !scheme.Nested {
+		entries, err := os.ReadDir(cfg.CodeRoot)
+		if err != nil {
+			return nil, err
+		}
+		var slugs []string
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name() != "_system" && scheme.Valid(entry.Name()) {
+				slugs = append(slugs, entry.Name())
+			}
+		}
+		return slugs, nil
+	}
+
+	var slugs []string
+	var walk func(dir string, segments []string) error
+	walk = func(dir string, segments []string) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || name == "_system" || !scheme.ValidPart(name) {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			next := append(append([]string{}, segments...), name)
+			if len(next) == 1 {
+				if err := walk(path, next); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Category directories hold owner directories rather than workspaces.
+			if len(next) == 2 && scheme.Category && !fs.HasProjectJSON(path) && !fs.HasReposDir(path) {
+				if err := walk(path, next); err != nil {
+					return err
+				}
+				continue
+			}
+			slugs = append(slugs, strings.Join(next, scheme.Separator))
+		}
+		return nil
+	}
+	if err := walk(cfg.CodeRoot, nil); err != nil {
+		return nil, err
+	}
+
+	// Flat tmp workspaces live alongside nested owners
+	if tmps, err := fs.ListTmpWorkspaces(cfg.CodeRoot); err == nil {
+		slugs = append(slugs, tmps...)
+	}
+	sort.Strings(slugs)
+	return slugs, nil
+}
+
+// CreateDir creates the directory structure for a new workspace whose repos
+// go under layout and returns its path.
+func CreateDir(cfg *config.Config, slug, layout string) (string, error) {
+	workspacePath := cfg.WorkspacePath(slug)
+	if err := os.MkdirAll(filepath.Join(workspacePath, model.RepoDir(layout, "")), fs.DirPerm()); err != nil {
+		return "", err
+	}
+	return workspacePath, nil
+}
@@ -0,0 +1,303 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/model"
+)
+
+func TestSlugSchemeDefault(t *testing.T) {
+	s := DefaultSlugScheme()
+
+	if got := s.Format("acme", "api"); got != "acme--api" {
+		t.Errorf("Format() = %q, want acme--api", got)
+	}
+
+	parsed, ok := s.Parse("acme--api--legacy")
+	if !ok || parsed.Owner != "acme" || parsed.Project != "api--legacy" {
+		t.Errorf("Parse(acme--api--legacy) = %+v, %v", parsed, ok)
+	}
+
+	valid := map[string]bool{
+		"acme--api":        true,
+		"acme--api--poc":   true,
+		"tmp--scratch":     true,
+		"acme":             false,
+		"--api":            false,
+		"acme--":           false,
+		"Acme--api":        false,
+		"acme--api_server": false,
+	}
+	for slug, want := range valid {
+		if got := s.Valid(slug); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", slug, got, want)
+		}
+	}
+
+	if s.ValidPart("a--b") {
+		t.Error("ValidPart(a--b) = true, want false (contains separator)")
+	}
+}
+
+func TestSlugSchemeCategory(t *testing.T) {
+	s := SlugScheme{Separator: ".", Category: true}
+
+	if got := s.FormatSlug(Slug{Category: "work", Owner: "acme", Project: "api"}); got != "work.acme.api" {
+		t.Errorf("FormatSlug() = %q, want work.acme.api", got)
+	}
+	if got := s.Format("acme", "api"); got != "acme.api" {
+		t.Errorf("Format() = %q, want acme.api", got)
+	}
+
+	parsed, ok := s.Parse("work.acme.api")
+	if !ok || parsed != (Slug{Category: "work", Owner: "acme", Project: "api"}) {
+		t.Errorf("Parse(work.acme.api) = %+v, %v", parsed, ok)
+	}
+	parsed, ok = s.Parse("acme.api")
+	if !ok || parsed != (Slug{Owner: "acme", Project: "api"}) {
+		t.Errorf("Parse(acme.api) = %+v, %v", parsed, ok)
+	}
+	for _, slug := range []string{"a.b.c.d", "acme", "work..api", "acme--api"} {
+		if s.Valid(slug) {
+			t.Errorf("Valid(%q) = true, want false", slug)
+		}
+	}
+}
+
+func TestListWorkspacesNested(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{CodeRoot: root, Slug: &config.SlugConfig{Category: true, Nested: true}}
+
+	dirs := []string{
+		"acme/api/repos",
+		"work/acme/web/repos",
+		"_system/index",
+		"tmp--scratch",
+	}
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	got, err := ListWorkspaces(cfg)
+	if err != nil {
+		t.Fatalf("ListWorkspaces: %v", err)
+	}
+	want := []string{"acme--api", "tmp--scratch", "work--acme--web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListWorkspaces() = %v, want %v", got, want)
+	}
+
+	for _, slug := range want {
+		if !Exists(cfg, slug) {
+			t.Errorf("Exists(%q) = false, want true", slug)
+		}
+	}
+}
+
+func TestRenameWorkspaceNested(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{CodeRoot: root, Slug: &config.SlugConfig{Nested: true}}
+
+	if _, err := CreateDir(cfg, "acme--api", ""); err != nil {
+		t.Fatalf("CreateDir: %v", err)
+	}
+	proj := model.NewProject("acme--api", "acme", "api")
+	if err := proj.Save(cfg.WorkspacePath("acme--api")); err != nil {
+		t.Fatalf("save project.json: %v", err)
+	}
+
+	result, err := RenameWorkspace(cfg, "acme--api", "globex", "api")
+	if err != nil {
+		t.Fatalf("RenameWorkspace: %v", err)
+	}
+	if result.NewPath != filepath.Join(root, "globex", "api") {
+		t.Errorf("NewPath = %q", result.NewPath)
+	}
+	if _, err := os.Stat(filepath.Join(root, "acme")); !os.IsNotExist(err) {
+		t.Errorf("expected empty owner directory to be removed, stat err = %v", err)
+	}
+}
+
+func TestSlugSchemeSanitize(t *testing.T) {
+	def := DefaultSlugScheme()
+	tests := map[string]string{
+		"My Project":   "my-project",
+		"prosjektø":    "prosjekto",
+		"Straße_Nord":  "strasse-nord",
+		"Привет Мир":   "privet-mir",
+		"ूप्रोजेक्ट":   "u-e1082e78",
+		"日本 v2":        "v2",
+		"@#$ .":        "",
+		"--a  b--":     "a-b",
+		"v1.2 release": "v12-release",
+	}
+	for in, want := range tests {
+		if got := def.Sanitize(in); got != want {
+			t.Errorf("Sanitize(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	uni := SlugScheme{Separator: "--", Unicode: true, AllowedChars: "."}
+	if got := uni.Sanitize("Prosjektø v1.2"); got != "prosjektø-v1.2" {
+		t.Errorf("Unicode Sanitize() = %q, want prosjektø-v1.2", got)
+	}
+	if got := uni.Sanitize("ूप्रोजेक्ट"); got != "ूप्रोजेक्ट" {
+		t.Errorf("Unicode Sanitize() = %q, want unchanged Devanagari", got)
+	}
+	if !uni.ValidPart("prosjektø") || uni.ValidPart("Prosjektø") {
+		t.Error("Unicode ValidPart should accept lowercase letters only")
+	}
+	if got := uni.Sanitize(" .. "); got != "" || uni.ValidPart("..") || uni.ValidPart(".") {
+		t.Errorf("Sanitize(..) = %q; dot segments must never be valid", got)
+	}
+
+	dot := SlugScheme{Separator: ".", AllowedChars: "."}
+	if got := dot.Sanitize("a.b"); got != "a-b" {
+		t.Errorf("Sanitize() = %q, want separator replaced with hyphen", got)
+	}
+}
+
+// fuzzSchemes covers the default scheme and the configurable variations.
+var fuzzSchemes = []SlugScheme{
+	DefaultSlugScheme(),
+	{Separator: ".", Category: true, Nested: true, AllowedChars: "._"},
+	{Separator: "__", Unicode: true, AllowedChars: "."},
+}
+
+func FuzzSlugSchemeSanitize(f *testing.F) {
+	for _, seed := range []string{"My Project", "Straße_Nord", "../..", "a/b\\c", "..", ".", "v1.2 release", "--a--"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		for _, s := range fuzzSchemes {
+			got := s.Sanitize(name)
+			if got != "" && !s.ValidPart(got) {
+				t.Errorf("%+v Sanitize(%q) = %q, not a valid part", s, name, got)
+			}
+			if strings.ContainsAny(got, "/\\") || got == "." || got == ".." {
+				t.Errorf("%+v Sanitize(%q) = %q, unsafe as a directory name", s, name, got)
+			}
+		}
+	})
+}
+
+func FuzzSlugSchemeParse(f *testing.F) {
+	for _, seed := range []string{"acme--api", "acme--api--poc", "work.acme.api", "..--x", "a..b", "--", "a----b", "../etc--passwd"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, slug string) {
+		for _, s := range fuzzSchemes {
+			parsed, ok := s.Parse(slug)
+			if !ok {
+				continue
+			}
+			if got := s.FormatSlug(parsed); got != slug {
+				t.Errorf("%+v FormatSlug(Parse(%q)) = %q", s, slug, got)
+			}
+			if !s.Valid(slug) {
+				continue
+			}
+
+			// A valid slug must never resolve outside the code root.
+			cfg := &config.Config{CodeRoot: "/code", Slug: &config.SlugConfig{
+				Separator:    s.Separator,
+				Category:     s.Category,
+				Nested:       s.Nested,
+				Unicode:      s.Unicode,
+				AllowedChars: s.AllowedChars,
+			}}
+			path := cfg.WorkspacePath(slug)
+			if rel, err := filepath.Rel("/code", path); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				t.Errorf("%+v WorkspacePath(%q) = %q, outside the code root", s, slug, path)
+			}
+		}
+	})
+}
+
+func TestFreeProject(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	if got := FreeProject(cfg, "acme", "api"); got != "api" {
+		t.Errorf("FreeProject() = %q, want api while it is free", got)
+	}
+	for _, slug := range []string{"acme--api", "acme--api-2"} {
+		if err := os.MkdirAll(cfg.WorkspacePath(slug), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := FreeProject(cfg, "acme", "api"); got != "api-3" {
+		t.Errorf("FreeProject() = %q, want api-3", got)
+	}
+}
+
+func TestSuggestProjects(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	if got := SuggestProjects(cfg, "acme", "api", now); got != nil {
+		t.Errorf("SuggestProjects() = %v, want none while the name is free", got)
+	}
+	for _, slug := range []string{"acme--api", "acme--api-gateway", "other--api"} {
+		if err := os.MkdirAll(cfg.WorkspacePath(slug), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := SuggestProjects(cfg, "acme", "api", now), []string{"api-2", "api-2026"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SuggestProjects() = %v, want %v", got, want)
+	}
+
+	similar, err := SimilarWorkspaces(cfg, "acme", "api")
+	if err != nil {
+		t.Fatalf("SimilarWorkspaces() error = %v", err)
+	}
+	if want := []string{"acme--api", "acme--api-gateway"}; !reflect.DeepEqual(similar, want) {
+		t.Errorf("SimilarWorkspaces() = %v, want %v", similar, want)
+	}
+}
+
+func TestListOwners(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir()}
+	for _, slug := range []string{"globex--web", "acme--api", "acme--web", "tmp--scratch"} {
+		if err := os.MkdirAll(cfg.WorkspacePath(slug), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owners, err := ListOwners(cfg)
+	if err != nil {
+		t.Fatalf("ListOwners() error = %v", err)
+	}
+	if want := []string{"acme", "globex"}; !reflect.DeepEqual(owners, want) {
+		t.Errorf("ListOwners() = %v, want %v", owners, want)
+	}
+}
+
+func TestContaining(t *testing.T) {
+	cfg := &config.Config{CodeRoot: t.TempDir(), Slug: &config.SlugConfig{Nested: true}}
+	workspacePath := cfg.WorkspacePath("acme--api")
+	repo := filepath.Join(workspacePath, "repos", "server", "cmd")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspacePath, "project.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for dir, want := range map[string]string{
+		repo:                        workspacePath,
+		workspacePath:               workspacePath,
+		filepath.Dir(workspacePath): "",
+		cfg.CodeRoot:                "",
+		filepath.Dir(cfg.CodeRoot):  "",
+		t.TempDir():                 "",
+	} {
+		if got := Containing(cfg, dir); got != want {
+			t.Errorf("Containing(%s) = %q, want %q", dir, got, want)
+		}
+	}
+}
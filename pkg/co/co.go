@@ -0,0 +1,435 @@
+// Package co is the Go API for co workspace management.
+//
+// It exposes the operations behind the CLI (creating, importing, archiving,
+// stashing, restoring, and listing workspaces, and applying templates) so other
+// Go tools and tests can embed them without shelling out to the co binary.
+// Operations never prompt and never write to stdout.
+package co
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+	"github.com/tormodhaugland/co/internal/archive"
+	"github.com/tormodhaugland/co/internal/config"
+	"github.com/tormodhaugland/co/internal/embedder"
+	"github.com/tormodhaugland/co/internal/git"
+	"github.com/tormodhaugland/co/internal/index"
+	"github.com/tormodhaugland/co/internal/lock"
+	"github.com/tormodhaugland/co/internal/model"
+	"github.com/tormodhaugland/co/internal/partial"
+	"github.com/tormodhaugland/co/internal/script"
+	"github.com/tormodhaugland/co/internal/search"
+	"github.com/tormodhaugland/co/internal/template"
+	"github.com/tormodhaugland/co/internal/vectordb"
+	"github.com/tormodhaugland/co/internal/workspace"
+)
+
+// Config is the co configuration (code root, editor, servers, ...).
+type Config = config.Config
+
+// Workspace is an index record describing a single workspace.
+type Workspace = model.IndexRecord
+
+// TemplateInfo summarizes an available template.
+type TemplateInfo = template.TemplateInfo
+
+// ArchiveEntry describes an archive in the system archive directory.
+type ArchiveEntry = archive.ArchiveEntry
+
+// Result types returned by the operations below.
+type (
+	CreateResult  = template.CreateResult
+	ImportResult  = workspace.ImportResult
+	ArchiveResult = archive.Result
+	StashResult   = archive.StashResult
+	RestoreResult = archive.RestoreResult
+)
+
+func init() {
+	template.RegisterPartialApplier(func(opts template.PartialApplyOptions, partialsDirs []string) error {
+		_, err := partial.Apply(partial.ApplyOptions{
+			PartialName: opts.PartialName,
+			TargetPath:  opts.TargetPath,
+			Variables:   opts.Variables,
+			DryRun:      opts.DryRun,
+			NoHooks:     opts.NoHooks,
+		}, partialsDirs)
+		return err
+	})
+	script.Register()
+}
+
+// LoadConfig loads configuration the same way the CLI does. An empty path
+// searches the default locations.
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// DefaultConfig returns the built-in configuration (code root ~/Code).
+func DefaultConfig() *Config {
+	return config.DefaultConfig()
+}
+
+// Client runs workspace operations against a configuration.
+type Client struct {
+	cfg *Config
+}
+
+// New returns a client for cfg.
+func New(cfg *Config) *Client {
+	return &Client{cfg: cfg}
+}
+
+// Config returns the client's configuration.
+func (c *Client) Config() *Config {
+	return c.cfg
+}
+
+// ResolveSlug returns the workspace slug that name refers to: the target of
+// a workspace alias, or name itself. The operations below that take a slug
+// resolve it this way, as the co commands do.
+func (c *Client) ResolveSlug(name string) string {
+	return c.cfg.ResolveAlias(name)
+}
+
+// CreateOptions configures Create.
+type CreateOptions struct {
+	Template  string            // Template to create from (empty = bare workspace)
+	Variables map[string]string // Template variables
+	NoHooks   bool              // Skip template lifecycle hooks
+	NoCI      bool              // Skip the template's CI setup
+	DryRun    bool              // Report what would be created without changes
+}
+
+// Create creates the workspace for owner and project (owner--project with the
+// default slug scheme), optionally from a template.
+func (c *Client) Create(owner, project string, opts CreateOptions) (*CreateResult, error) {
+	owner, project = strings.ToLower(owner), strings.ToLower(project)
+	scheme := workspace.SchemeFor(c.cfg)
+	slug := scheme.Format(owner, project)
+	if !scheme.Valid(slug) {
+		return nil, fmt.Errorf("invalid workspace slug: %s", slug)
+	}
+	if workspace.Exists(c.cfg, slug) {
+		return nil, fmt.Errorf("workspace already exists: %s", slug)
+	}
+	if err := workspace.CheckOwnerPolicy(c.cfg, owner, project); err != nil {
+		return nil, err
+	}
+	if opts.Template == "" {
+		opts.Template = workspace.DefaultTemplate(c.cfg, owner)
+	}
+
+	if opts.Template != "" {
+		return template.CreateWorkspace(c.cfg, owner, project, template.CreateOptions{
+			TemplateName: opts.Template,
+			Variables:    opts.Variables,
+			NoHooks:      opts.NoHooks,
+			NoCI:         opts.NoCI,
+			DryRun:       opts.DryRun,
+		})
+	}
+
+	result := &CreateResult{
+		WorkspacePath: c.cfg.WorkspacePath(slug),
+		WorkspaceSlug: slug,
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	l, err := lock.Workspace(c.cfg, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+	layout := c.cfg.GetRepoLayout(owner)
+	if _, err := workspace.CreateDir(c.cfg, slug, layout); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	proj := model.NewProject(slug, owner, project)
+	proj.RepoLayout = layout
+	workspace.ApplyOwnerDefaults(c.cfg, proj)
+	if err := workspace.RunEventHook(c.cfg, workspace.EventCreate, proj, result.WorkspacePath); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("create hook failed: %v", err))
+	}
+	if err := proj.Save(result.WorkspacePath); err != nil {
+		return nil, fmt.Errorf("failed to save project.json: %w", err)
+	}
+	if result.ShellEnvFiles, err = template.WriteShellEnv(c.cfg, result.WorkspacePath); err != nil {
+		return result, fmt.Errorf("workspace created but shell env failed: %w", err)
+	}
+	return result, nil
+}
+
+// ImportOptions configures Import and AddTo.
+type ImportOptions struct {
+	Owner          string   // Workspace owner (Import only)
+	Project        string   // Project name (Import only)
+	ExtraFiles     []string // Non-git paths to copy, relative to the source
+	ExtraFilesDest string   // Destination subfolder for extra files (empty = workspace root)
+
+	Template  string            // Template to apply after import (Import only)
+	Variables map[string]string // Template variables
+	NoHooks   bool              // Skip template lifecycle hooks
+}
+
+// Import moves the git repositories under sourcePath into a new workspace.
+// Template errors are reported after the workspace has been created.
+func (c *Client) Import(sourcePath string, opts ImportOptions) (*ImportResult, error) {
+	gitRoots, err := c.scanSource(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := workspace.CreateWorkspace(c.cfg, sourcePath, gitRoots, workspace.ImportOptions{
+		Owner:          strings.ToLower(opts.Owner),
+		Project:        strings.ToLower(opts.Project),
+		ExtraFiles:     opts.ExtraFiles,
+		ExtraFilesDest: opts.ExtraFilesDest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Template == "" {
+		opts.Template = workspace.DefaultTemplate(c.cfg, opts.Owner)
+	}
+	if opts.Template != "" {
+		if _, err := template.ApplyTemplateToExisting(c.cfg, result.WorkspacePath, opts.Template, template.CreateOptions{
+			TemplateName: opts.Template,
+			Variables:    opts.Variables,
+			NoHooks:      opts.NoHooks,
+		}); err != nil {
+			return result, fmt.Errorf("workspace created but template failed: %w", err)
+		}
+	}
+	if _, err := template.WriteShellEnv(c.cfg, result.WorkspacePath); err != nil {
+		return result, fmt.Errorf("workspace created but shell env failed: %w", err)
+	}
+	return result, nil
+}
+
+// AddTo moves the git repositories under sourcePath into an existing workspace.
+func (c *Client) AddTo(slug, sourcePath string, opts ImportOptions) (*ImportResult, error) {
+	slug = c.ResolveSlug(slug)
+	gitRoots, err := c.scanSource(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	return workspace.AddToWorkspace(c.cfg, sourcePath, gitRoots, slug, workspace.ImportOptions{
+		ExtraFiles:     opts.ExtraFiles,
+		ExtraFilesDest: opts.ExtraFilesDest,
+	})
+}
+
+// scanSource validates an import source and returns the git roots beneath it.
+func (c *Client) scanSource(sourcePath string) ([]string, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access path: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("path is not a directory: %s", sourcePath)
+	}
+	scan, err := git.ScanGitRoots(sourcePath, workspace.GitScanOptions(c.cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for git repos: %w", err)
+	}
+	return scan.Roots, nil
+}
+
+// ApplyTemplateOptions configures ApplyTemplate.
+type ApplyTemplateOptions struct {
+	Variables map[string]string // Template variables
+	NoHooks   bool              // Skip template lifecycle hooks
+	DryRun    bool              // Report what would be created without changes
+}
+
+// ApplyTemplate applies a template to an existing workspace.
+func (c *Client) ApplyTemplate(slug, templateName string, opts ApplyTemplateOptions) (*CreateResult, error) {
+	slug = c.ResolveSlug(slug)
+	if !workspace.Exists(c.cfg, slug) {
+		return nil, fmt.Errorf("workspace not found: %s", slug)
+	}
+	return template.ApplyTemplateToExisting(c.cfg, c.cfg.WorkspacePath(slug), templateName, template.CreateOptions{
+		TemplateName: templateName,
+		Variables:    opts.Variables,
+		NoHooks:      opts.NoHooks,
+		DryRun:       opts.DryRun,
+	})
+}
+
+// ArchiveOptions configures Archive.
+type ArchiveOptions struct {
+	Reason      string // Recorded in the archive metadata
+	Full        bool   // Archive the whole workspace instead of git bundles only
+	DeleteAfter bool   // Delete the workspace once archived
+}
+
+// Archive archives a workspace into the system archive directory.
+func (c *Client) Archive(slug string, opts ArchiveOptions) (*ArchiveResult, error) {
+	return archive.ArchiveWorkspace(c.cfg, c.ResolveSlug(slug), archive.Options{
+		Reason:      opts.Reason,
+		Full:        opts.Full,
+		DeleteAfter: opts.DeleteAfter,
+	})
+}
+
+// StashOptions configures Stash.
+type StashOptions struct {
+	Name           string // Archive name (defaults to the folder name)
+	DeleteAfter    bool   // Delete the source once archived
+	IncludeSecrets bool   // Keep likely secret files (.env, *.pem, ...) in the archive
+	Bundles        bool   // Store git repos as bundles plus their untracked and changed files
+}
+
+// Stash archives an arbitrary file or folder into the system archive directory.
+func (c *Client) Stash(path string, opts StashOptions) (*StashResult, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("cannot access path: %w", err)
+	}
+	return archive.StashFolder(c.cfg, path, archive.StashOptions{
+		Name:           opts.Name,
+		DeleteAfter:    opts.DeleteAfter,
+		IncludeSecrets: opts.IncludeSecrets,
+		Bundles:        opts.Bundles,
+	})
+}
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	DestDir string // Parent directory for restored stashes (defaults to where the stash came from)
+}
+
+// Restore recreates a workspace or stash from an archive file.
+func (c *Client) Restore(archivePath string, opts RestoreOptions) (*RestoreResult, error) {
+	return archive.RestoreArchive(c.cfg, archivePath, archive.RestoreOptions{DestDir: opts.DestDir})
+}
+
+// ListOptions filters List. Empty fields match everything.
+type ListOptions struct {
+	Owner string
+	State string
+	Tag   string
+}
+
+// List returns workspaces from the index. Call Reindex first for fresh data.
+func (c *Client) List(opts ListOptions) ([]*Workspace, error) {
+	idx, err := model.LoadIndex(c.cfg.IndexPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+
+	var records []*Workspace
+	for _, r := range idx.Records {
+		if opts.Owner != "" && r.Owner != opts.Owner {
+			continue
+		}
+		if opts.State != "" && string(r.State) != opts.State {
+			continue
+		}
+		if opts.Tag != "" && !hasTag(r.Tags, opts.Tag) {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Reindex rescans the code root and rewrites the index.
+func (c *Client) Reindex() error {
+	builder := index.NewBuilder(c.cfg)
+	idx, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	return builder.Save(idx)
+}
+
+// Find fuzzy-matches query against workspace slugs, best match first, the
+// same way 'co cd' resolves partial names. An exact slug, or the workspace
+// of an alias, is always first.
+func (c *Client) Find(query string, limit int) ([]string, error) {
+	workspaces, err := workspace.ListWorkspaces(c.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	var slugs []string
+	exact := c.ResolveSlug(query)
+	if workspace.Exists(c.cfg, exact) {
+		slugs = append(slugs, exact)
+	}
+	for _, m := range fuzzy.Find(query, workspaces) {
+		if m.Score < minFindScore || m.Str == exact {
+			continue
+		}
+		slugs = append(slugs, m.Str)
+	}
+	if limit > 0 && len(slugs) > limit {
+		slugs = slugs[:limit]
+	}
+	return slugs, nil
+}
+
+// minFindScore is the fuzzy score below which matches are discarded (as in 'co cd').
+const minFindScore = -10
+
+// SearchOptions configures SearchCode.
+type SearchOptions struct {
+	Limit          int     // Maximum results (default 10)
+	Codebase       string  // Restrict to one workspace
+	MinScore       float64 // Minimum similarity (0-1)
+	IncludeContent bool    // Include chunk content in results
+}
+
+// SearchResult is a semantic code search hit.
+type SearchResult = search.SearchResult
+
+// SearchCode runs a semantic search over indexed codebases. It requires
+// 'co vector index' to have been run and Ollama to be reachable.
+func (c *Client) SearchCode(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	db, err := vectordb.Open(c.cfg.VectorsDBPath())
+	if err != nil {
+		return nil, fmt.Errorf("opening vector database: %w", err)
+	}
+	defer db.Close()
+
+	emb, err := embedder.New(embedder.DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("creating embedder: %w", err)
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+	return search.NewSearcher(db, emb).Search(ctx, query, search.SearchConfig{
+		Limit:          opts.Limit,
+		Codebase:       c.ResolveSlug(opts.Codebase),
+		MinScore:       opts.MinScore,
+		IncludeContent: opts.IncludeContent,
+	})
+}
+
+// Templates lists templates from the primary and fallback template directories.
+func (c *Client) Templates() ([]TemplateInfo, error) {
+	return template.ListTemplateInfosMulti(c.cfg.AllTemplatesDirs())
+}
+
+// Archives lists workspace archives.
+func (c *Client) Archives() ([]ArchiveEntry, error) {
+	return archive.ListArchives(c.cfg)
+}
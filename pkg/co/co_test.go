@@ -0,0 +1,247 @@
+package co
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.CodeRoot = filepath.Join(t.TempDir(), "Code")
+	if err := os.MkdirAll(cfg.CodeRoot, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	return New(cfg)
+}
+
+func TestCreateAndList(t *testing.T) {
+	c := newTestClient(t)
+
+	result, err := c.Create("Acme", "api", CreateOptions{})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if result.WorkspaceSlug != "acme--api" {
+		t.Errorf("WorkspaceSlug = %q, want acme--api", result.WorkspaceSlug)
+	}
+	if _, err := os.Stat(filepath.Join(result.WorkspacePath, "project.json")); err != nil {
+		t.Errorf("project.json missing: %v", err)
+	}
+
+	if _, err := c.Create("acme", "api", CreateOptions{}); err == nil {
+		t.Error("Create of an existing workspace should fail")
+	}
+	if _, err := c.Create("acme", "Bad Name", CreateOptions{}); err == nil {
+		t.Error("Create with an invalid slug should fail")
+	}
+
+	if _, err := c.Create("solo", "tool", CreateOptions{}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if slugs, err := c.Find("api", 0); err != nil || len(slugs) != 1 || slugs[0] != "acme--api" {
+		t.Errorf("Find(api) = %v, %v; want [acme--api]", slugs, err)
+	}
+	if slugs, err := c.Find("solo--tool", 0); err != nil || len(slugs) == 0 || slugs[0] != "solo--tool" {
+		t.Errorf("Find(solo--tool) = %v, %v; want exact slug first", slugs, err)
+	}
+
+	if err := c.Reindex(); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+
+	all, err := c.List(ListOptions{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("List() returned %d workspaces, want 2", len(all))
+	}
+	acme, err := c.List(ListOptions{Owner: "acme"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(acme) != 1 || acme[0].Slug != "acme--api" {
+		t.Errorf("List(owner=acme) = %v, want acme--api only", acme)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	c := newTestClient(t)
+	if _, err := c.Create("acme", "api", CreateOptions{}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	c.Config().Aliases = map[string]string{"backend": "acme--api"}
+
+	if got := c.ResolveSlug("backend"); got != "acme--api" {
+		t.Errorf("ResolveSlug(backend) = %q, want acme--api", got)
+	}
+	if slugs, err := c.Find("backend", 0); err != nil || len(slugs) == 0 || slugs[0] != "acme--api" {
+		t.Errorf("Find(backend) = %v, %v; want the aliased workspace first", slugs, err)
+	}
+	if _, err := c.ApplyTemplate("backend", "missing", ApplyTemplateOptions{}); err == nil || strings.Contains(err.Error(), "workspace not found") {
+		t.Errorf("ApplyTemplate(backend) error = %v, want the alias resolved", err)
+	}
+	result, err := c.Archive("backend", ArchiveOptions{})
+	if err != nil || !strings.HasPrefix(filepath.Base(result.ArchivePath), "acme--api--") {
+		t.Errorf("Archive(backend) = %+v, %v; want acme--api archived", result, err)
+	}
+}
+
+func TestImportArchiveRestore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	c := newTestClient(t)
+
+	source := filepath.Join(t.TempDir(), "legacy")
+	repo := filepath.Join(source, "service")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	gitRun(t, repo, "init", "-q")
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	gitRun(t, repo, "add", ".")
+	gitRun(t, repo, "commit", "-q", "-m", "init")
+	gitRun(t, repo, "remote", "add", "origin", "https://example.com/acme/service.git")
+
+	imported, err := c.Import(source, ImportOptions{Owner: "acme", Project: "legacy"})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(imported.ReposImported) != 1 || imported.ReposImported[0] != "service" {
+		t.Fatalf("ReposImported = %v, want [service]", imported.ReposImported)
+	}
+
+	archived, err := c.Archive("acme--legacy", ArchiveOptions{DeleteAfter: true})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if _, err := os.Stat(imported.WorkspacePath); !os.IsNotExist(err) {
+		t.Fatalf("workspace should be deleted after archive")
+	}
+	archives, err := c.Archives()
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("Archives() = %v, %v; want one entry", archives, err)
+	}
+
+	restored, err := c.Restore(archived.ArchivePath, RestoreOptions{})
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.Slug != "acme--legacy" || len(restored.ReposRestored) != 1 {
+		t.Fatalf("Restore result = %+v, want acme--legacy with one repo", restored)
+	}
+	restoredRepo := filepath.Join(imported.WorkspacePath, "repos", "service")
+	if _, err := os.Stat(filepath.Join(restoredRepo, "main.go")); err != nil {
+		t.Errorf("restored repo is missing main.go: %v", err)
+	}
+	out, err := exec.Command("git", "-C", restoredRepo, "remote", "get-url", "origin").Output()
+	if err != nil || string(out) != "https://example.com/acme/service.git\n" {
+		t.Errorf("restored origin = %q, %v; want the recorded remote", out, err)
+	}
+
+	if _, err := c.Restore(archived.ArchivePath, RestoreOptions{}); err == nil {
+		t.Error("Restore over an existing workspace should fail")
+	}
+}
+
+func TestStashRestore(t *testing.T) {
+	c := newTestClient(t)
+
+	folder := filepath.Join(t.TempDir(), "notes")
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(folder, "todo.txt"), []byte("ship it"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	stashed, err := c.Stash(folder, StashOptions{DeleteAfter: true})
+	if err != nil {
+		t.Fatalf("Stash: %v", err)
+	}
+	if !stashed.Deleted {
+		t.Error("stash source should be deleted")
+	}
+
+	// Without DestDir the stash goes back where it came from, once
+	if _, err := c.Restore(stashed.ArchivePath, RestoreOptions{}); err != nil {
+		t.Fatalf("Restore to the original location: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(folder, "todo.txt")); err != nil || string(data) != "ship it" {
+		t.Errorf("restored file = %q, %v; want %q", data, err, "ship it")
+	}
+	if _, err := c.Restore(stashed.ArchivePath, RestoreOptions{}); err == nil {
+		t.Error("Restore over the existing original folder should fail")
+	}
+
+	dest := t.TempDir()
+	if _, err := c.Restore(stashed.ArchivePath, RestoreOptions{DestDir: dest}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "notes", "todo.txt"))
+	if err != nil || string(data) != "ship it" {
+		t.Errorf("restored file = %q, %v; want %q", data, err, "ship it")
+	}
+}
+
+func TestStashSecrets(t *testing.T) {
+	c := newTestClient(t)
+
+	folder := filepath.Join(t.TempDir(), "api")
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, content := range map[string]string{"todo.txt": "ship it", ".env": "TOKEN=x", ".env.example": "TOKEN="} {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	// Deleting would lose the secrets left out of the archive
+	if _, err := c.Stash(folder, StashOptions{DeleteAfter: true}); err == nil {
+		t.Fatal("Stash with DeleteAfter should refuse a folder holding secrets")
+	}
+	if _, err := os.Stat(filepath.Join(folder, ".env")); err != nil {
+		t.Fatalf(".env should be kept: %v", err)
+	}
+
+	stashed, err := c.Stash(folder, StashOptions{})
+	if err != nil {
+		t.Fatalf("Stash: %v", err)
+	}
+	if len(stashed.SecretsExcluded) != 1 || stashed.SecretsExcluded[0] != ".env" {
+		t.Errorf("SecretsExcluded = %v, want [.env]", stashed.SecretsExcluded)
+	}
+
+	dest := t.TempDir()
+	if _, err := c.Restore(stashed.ArchivePath, RestoreOptions{DestDir: dest}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "api", ".env")); !os.IsNotExist(err) {
+		t.Error(".env should be left out of the stash archive")
+	}
+	for _, name := range []string{"todo.txt", ".env.example"} {
+		if _, err := os.Stat(filepath.Join(dest, "api", name)); err != nil {
+			t.Errorf("%s should be archived: %v", name, err)
+		}
+	}
+}